@@ -29,6 +29,9 @@ const (
 	FileMetadata_TYPE_UNSPECIFIED        FileMetadata_Type = 0
 	FileMetadata_TYPE_EXECUTABLE_FULL    FileMetadata_Type = 1
 	FileMetadata_TYPE_EXECUTABLE_NO_TEXT FileMetadata_Type = 2
+	// Standalone debug symbols, such as a PDB file
+	// or the DWARF file of a Mach-O dSYM bundle.
+	FileMetadata_TYPE_DEBUG_SYMBOLS FileMetadata_Type = 3
 )
 
 // Enum value maps for FileMetadata_Type.
@@ -37,11 +40,13 @@ var (
 		0: "TYPE_UNSPECIFIED",
 		1: "TYPE_EXECUTABLE_FULL",
 		2: "TYPE_EXECUTABLE_NO_TEXT",
+		3: "TYPE_DEBUG_SYMBOLS",
 	}
 	FileMetadata_Type_value = map[string]int32{
 		"TYPE_UNSPECIFIED":        0,
 		"TYPE_EXECUTABLE_FULL":    1,
 		"TYPE_EXECUTABLE_NO_TEXT": 2,
+		"TYPE_DEBUG_SYMBOLS":      3,
 	}
 )
 
@@ -120,7 +125,10 @@ func (ObjectMetadata_State) EnumDescriptor() ([]byte, []int) {
 
 type FileMetadata struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// GNU build ID of the executable.
+	// Build ID of the executable. Despite the name, the field is used for all
+	// the supported formats: GNU build ID for ELF files (hex), UUID for Mach-O
+	// files (8-4-4-4-12 hex digits), and debug ID for PE and PDB files: the PDB
+	// GUID (8-4-4-4-12 hex digits) followed by a hyphen and the age (hex).
 	GnuBuildId string `protobuf:"bytes,1,opt,name=gnu_build_id,json=gnuBuildId,proto3" json:"gnu_build_id,omitempty"`
 	// Go build ID of the executable.
 	GoBuildId string `protobuf:"bytes,2,opt,name=go_build_id,json=goBuildId,proto3" json:"go_build_id,omitempty"`
@@ -618,7 +626,7 @@ var File_debuginfo_v1alpha1_debuginfo_proto protoreflect.FileDescriptor
 
 const file_debuginfo_v1alpha1_debuginfo_proto_rawDesc = "" +
 	"\n" +
-	"\"debuginfo/v1alpha1/debuginfo.proto\x12\x12debuginfo.v1alpha1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x14types/v1/types.proto\"\x96\x02\n" +
+	"\"debuginfo/v1alpha1/debuginfo.proto\x12\x12debuginfo.v1alpha1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x14types/v1/types.proto\"\xae\x02\n" +
 	"\fFileMetadata\x12 \n" +
 	"\fgnu_build_id\x18\x01 \x01(\tR\n" +
 	"gnuBuildId\x12\x1e\n" +
@@ -626,11 +634,12 @@ const file_debuginfo_v1alpha1_debuginfo_proto_rawDesc = "" +
 	"\fotel_file_id\x18\x03 \x01(\tR\n" +
 	"otelFileId\x12\x12\n" +
 	"\x04name\x18\x04 \x01(\tR\x04name\x129\n" +
-	"\x04type\x18\x05 \x01(\x0e2%.debuginfo.v1alpha1.FileMetadata.TypeR\x04type\"S\n" +
+	"\x04type\x18\x05 \x01(\x0e2%.debuginfo.v1alpha1.FileMetadata.TypeR\x04type\"k\n" +
 	"\x04Type\x12\x14\n" +
 	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x18\n" +
 	"\x14TYPE_EXECUTABLE_FULL\x10\x01\x12\x1b\n" +
-	"\x17TYPE_EXECUTABLE_NO_TEXT\x10\x02\"S\n" +
+	"\x17TYPE_EXECUTABLE_NO_TEXT\x10\x02\x12\x16\n" +
+	"\x12TYPE_DEBUG_SYMBOLS\x10\x03\"S\n" +
 	"\x1bShouldInitiateUploadRequest\x124\n" +
 	"\x04file\x18\x01 \x01(\v2 .debuginfo.v1alpha1.FileMetadataR\x04file\"l\n" +
 	"\x1cShouldInitiateUploadResponse\x124\n" +
@@ -1143,9 +1143,13 @@ type MergeProfilesLabelsRequest struct {
 	// Select stack traces that match the provided selector.
 	StackTraceSelector *v1.StackTraceSelector `protobuf:"bytes,4,opt,name=stack_trace_selector,json=stackTraceSelector,proto3,oneof" json:"stack_trace_selector,omitempty"`
 	// On a batch of profiles, the client sends the profiles to keep for merging.
-	Profiles      []bool `protobuf:"varint,3,rep,packed,name=profiles,proto3" json:"profiles,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Profiles []bool `protobuf:"varint,3,rep,packed,name=profiles,proto3" json:"profiles,omitempty"`
+	// Type of exemplars to attach to the series points.
+	ExemplarType v1.ExemplarType `protobuf:"varint,5,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
+	// Maximum number of exemplars with the highest values per point.
+	MaxExemplarsPerPoint int64 `protobuf:"varint,6,opt,name=max_exemplars_per_point,json=maxExemplarsPerPoint,proto3" json:"max_exemplars_per_point,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *MergeProfilesLabelsRequest) Reset() {
@@ -1206,6 +1210,20 @@ func (x *MergeProfilesLabelsRequest) GetProfiles() []bool {
 	return nil
 }
 
+func (x *MergeProfilesLabelsRequest) GetExemplarType() v1.ExemplarType {
+	if x != nil {
+		return x.ExemplarType
+	}
+	return v1.ExemplarType(0)
+}
+
+func (x *MergeProfilesLabelsRequest) GetMaxExemplarsPerPoint() int64 {
+	if x != nil {
+		return x.MaxExemplarsPerPoint
+	}
+	return 0
+}
+
 type MergeProfilesLabelsResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The server replies batch of profiles.
@@ -1844,12 +1862,14 @@ const file_ingester_v1_ingester_proto_rawDesc = "" +
 	"\vstacktraces\x18\x05 \x03(\v2\x1d.ingester.v1.StacktraceSampleR\vstacktraces\"K\n" +
 	"\x10StacktraceSample\x12!\n" +
 	"\ffunction_ids\x18\x01 \x03(\x05R\vfunctionIds\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x03R\x05value\"\xf4\x01\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value\"\xe8\x02\n" +
 	"\x1aMergeProfilesLabelsRequest\x12<\n" +
 	"\arequest\x18\x01 \x01(\v2\".ingester.v1.SelectProfilesRequestR\arequest\x12\x0e\n" +
 	"\x02by\x18\x02 \x03(\tR\x02by\x12S\n" +
 	"\x14stack_trace_selector\x18\x04 \x01(\v2\x1c.types.v1.StackTraceSelectorH\x00R\x12stackTraceSelector\x88\x01\x01\x12\x1a\n" +
-	"\bprofiles\x18\x03 \x03(\bR\bprofilesB\x17\n" +
+	"\bprofiles\x18\x03 \x03(\bR\bprofiles\x12;\n" +
+	"\rexemplar_type\x18\x05 \x01(\x0e2\x16.types.v1.ExemplarTypeR\fexemplarType\x125\n" +
+	"\x17max_exemplars_per_point\x18\x06 \x01(\x03R\x14maxExemplarsPerPointB\x17\n" +
 	"\x15_stack_trace_selector\"\x8d\x01\n" +
 	"\x1bMergeProfilesLabelsResponse\x12D\n" +
 	"\x10selectedProfiles\x18\x01 \x01(\v2\x18.ingester.v1.ProfileSetsR\x10selectedProfiles\x12(\n" +
@@ -1961,16 +1981,17 @@ var file_ingester_v1_ingester_proto_goTypes = []any{
 	(v1.TimeSeriesAggregationType)(0),        // 32: types.v1.TimeSeriesAggregationType
 	(*v1.LabelPair)(nil),                     // 33: types.v1.LabelPair
 	(*v1.StackTraceSelector)(nil),            // 34: types.v1.StackTraceSelector
-	(*v1.Series)(nil),                        // 35: types.v1.Series
-	(*v1.BlockInfo)(nil),                     // 36: types.v1.BlockInfo
-	(*v11.PushRequest)(nil),                  // 37: push.v1.PushRequest
-	(*v1.LabelValuesRequest)(nil),            // 38: types.v1.LabelValuesRequest
-	(*v1.LabelNamesRequest)(nil),             // 39: types.v1.LabelNamesRequest
-	(*v1.GetProfileStatsRequest)(nil),        // 40: types.v1.GetProfileStatsRequest
-	(*v11.PushResponse)(nil),                 // 41: push.v1.PushResponse
-	(*v1.LabelValuesResponse)(nil),           // 42: types.v1.LabelValuesResponse
-	(*v1.LabelNamesResponse)(nil),            // 43: types.v1.LabelNamesResponse
-	(*v1.GetProfileStatsResponse)(nil),       // 44: types.v1.GetProfileStatsResponse
+	(v1.ExemplarType)(0),                     // 35: types.v1.ExemplarType
+	(*v1.Series)(nil),                        // 36: types.v1.Series
+	(*v1.BlockInfo)(nil),                     // 37: types.v1.BlockInfo
+	(*v11.PushRequest)(nil),                  // 38: push.v1.PushRequest
+	(*v1.LabelValuesRequest)(nil),            // 39: types.v1.LabelValuesRequest
+	(*v1.LabelNamesRequest)(nil),             // 40: types.v1.LabelNamesRequest
+	(*v1.GetProfileStatsRequest)(nil),        // 41: types.v1.GetProfileStatsRequest
+	(*v11.PushResponse)(nil),                 // 42: push.v1.PushResponse
+	(*v1.LabelValuesResponse)(nil),           // 43: types.v1.LabelValuesResponse
+	(*v1.LabelNamesResponse)(nil),            // 44: types.v1.LabelNamesResponse
+	(*v1.GetProfileStatsResponse)(nil),       // 45: types.v1.GetProfileStatsResponse
 }
 var file_ingester_v1_ingester_proto_depIdxs = []int32{
 	30, // 0: ingester.v1.ProfileTypesResponse.profile_types:type_name -> types.v1.ProfileType
@@ -1995,45 +2016,46 @@ var file_ingester_v1_ingester_proto_depIdxs = []int32{
 	18, // 19: ingester.v1.Profile.stacktraces:type_name -> ingester.v1.StacktraceSample
 	7,  // 20: ingester.v1.MergeProfilesLabelsRequest.request:type_name -> ingester.v1.SelectProfilesRequest
 	34, // 21: ingester.v1.MergeProfilesLabelsRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
-	15, // 22: ingester.v1.MergeProfilesLabelsResponse.selectedProfiles:type_name -> ingester.v1.ProfileSets
-	35, // 23: ingester.v1.MergeProfilesLabelsResponse.series:type_name -> types.v1.Series
-	7,  // 24: ingester.v1.MergeProfilesPprofRequest.request:type_name -> ingester.v1.SelectProfilesRequest
-	34, // 25: ingester.v1.MergeProfilesPprofRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
-	15, // 26: ingester.v1.MergeProfilesPprofResponse.selectedProfiles:type_name -> ingester.v1.ProfileSets
-	36, // 27: ingester.v1.BlockMetadataResponse.blocks:type_name -> types.v1.BlockInfo
-	26, // 28: ingester.v1.Hints.block:type_name -> ingester.v1.BlockHints
-	29, // 29: ingester.v1.GetBlockStatsResponse.block_stats:type_name -> ingester.v1.BlockStats
-	37, // 30: ingester.v1.IngesterService.Push:input_type -> push.v1.PushRequest
-	38, // 31: ingester.v1.IngesterService.LabelValues:input_type -> types.v1.LabelValuesRequest
-	39, // 32: ingester.v1.IngesterService.LabelNames:input_type -> types.v1.LabelNamesRequest
-	1,  // 33: ingester.v1.IngesterService.ProfileTypes:input_type -> ingester.v1.ProfileTypesRequest
-	3,  // 34: ingester.v1.IngesterService.Series:input_type -> ingester.v1.SeriesRequest
-	5,  // 35: ingester.v1.IngesterService.Flush:input_type -> ingester.v1.FlushRequest
-	8,  // 36: ingester.v1.IngesterService.MergeProfilesStacktraces:input_type -> ingester.v1.MergeProfilesStacktracesRequest
-	19, // 37: ingester.v1.IngesterService.MergeProfilesLabels:input_type -> ingester.v1.MergeProfilesLabelsRequest
-	21, // 38: ingester.v1.IngesterService.MergeProfilesPprof:input_type -> ingester.v1.MergeProfilesPprofRequest
-	12, // 39: ingester.v1.IngesterService.MergeSpanProfile:input_type -> ingester.v1.MergeSpanProfileRequest
-	23, // 40: ingester.v1.IngesterService.BlockMetadata:input_type -> ingester.v1.BlockMetadataRequest
-	40, // 41: ingester.v1.IngesterService.GetProfileStats:input_type -> types.v1.GetProfileStatsRequest
-	27, // 42: ingester.v1.IngesterService.GetBlockStats:input_type -> ingester.v1.GetBlockStatsRequest
-	41, // 43: ingester.v1.IngesterService.Push:output_type -> push.v1.PushResponse
-	42, // 44: ingester.v1.IngesterService.LabelValues:output_type -> types.v1.LabelValuesResponse
-	43, // 45: ingester.v1.IngesterService.LabelNames:output_type -> types.v1.LabelNamesResponse
-	2,  // 46: ingester.v1.IngesterService.ProfileTypes:output_type -> ingester.v1.ProfileTypesResponse
-	4,  // 47: ingester.v1.IngesterService.Series:output_type -> ingester.v1.SeriesResponse
-	6,  // 48: ingester.v1.IngesterService.Flush:output_type -> ingester.v1.FlushResponse
-	10, // 49: ingester.v1.IngesterService.MergeProfilesStacktraces:output_type -> ingester.v1.MergeProfilesStacktracesResponse
-	20, // 50: ingester.v1.IngesterService.MergeProfilesLabels:output_type -> ingester.v1.MergeProfilesLabelsResponse
-	22, // 51: ingester.v1.IngesterService.MergeProfilesPprof:output_type -> ingester.v1.MergeProfilesPprofResponse
-	13, // 52: ingester.v1.IngesterService.MergeSpanProfile:output_type -> ingester.v1.MergeSpanProfileResponse
-	24, // 53: ingester.v1.IngesterService.BlockMetadata:output_type -> ingester.v1.BlockMetadataResponse
-	44, // 54: ingester.v1.IngesterService.GetProfileStats:output_type -> types.v1.GetProfileStatsResponse
-	28, // 55: ingester.v1.IngesterService.GetBlockStats:output_type -> ingester.v1.GetBlockStatsResponse
-	43, // [43:56] is the sub-list for method output_type
-	30, // [30:43] is the sub-list for method input_type
-	30, // [30:30] is the sub-list for extension type_name
-	30, // [30:30] is the sub-list for extension extendee
-	0,  // [0:30] is the sub-list for field type_name
+	35, // 22: ingester.v1.MergeProfilesLabelsRequest.exemplar_type:type_name -> types.v1.ExemplarType
+	15, // 23: ingester.v1.MergeProfilesLabelsResponse.selectedProfiles:type_name -> ingester.v1.ProfileSets
+	36, // 24: ingester.v1.MergeProfilesLabelsResponse.series:type_name -> types.v1.Series
+	7,  // 25: ingester.v1.MergeProfilesPprofRequest.request:type_name -> ingester.v1.SelectProfilesRequest
+	34, // 26: ingester.v1.MergeProfilesPprofRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
+	15, // 27: ingester.v1.MergeProfilesPprofResponse.selectedProfiles:type_name -> ingester.v1.ProfileSets
+	37, // 28: ingester.v1.BlockMetadataResponse.blocks:type_name -> types.v1.BlockInfo
+	26, // 29: ingester.v1.Hints.block:type_name -> ingester.v1.BlockHints
+	29, // 30: ingester.v1.GetBlockStatsResponse.block_stats:type_name -> ingester.v1.BlockStats
+	38, // 31: ingester.v1.IngesterService.Push:input_type -> push.v1.PushRequest
+	39, // 32: ingester.v1.IngesterService.LabelValues:input_type -> types.v1.LabelValuesRequest
+	40, // 33: ingester.v1.IngesterService.LabelNames:input_type -> types.v1.LabelNamesRequest
+	1,  // 34: ingester.v1.IngesterService.ProfileTypes:input_type -> ingester.v1.ProfileTypesRequest
+	3,  // 35: ingester.v1.IngesterService.Series:input_type -> ingester.v1.SeriesRequest
+	5,  // 36: ingester.v1.IngesterService.Flush:input_type -> ingester.v1.FlushRequest
+	8,  // 37: ingester.v1.IngesterService.MergeProfilesStacktraces:input_type -> ingester.v1.MergeProfilesStacktracesRequest
+	19, // 38: ingester.v1.IngesterService.MergeProfilesLabels:input_type -> ingester.v1.MergeProfilesLabelsRequest
+	21, // 39: ingester.v1.IngesterService.MergeProfilesPprof:input_type -> ingester.v1.MergeProfilesPprofRequest
+	12, // 40: ingester.v1.IngesterService.MergeSpanProfile:input_type -> ingester.v1.MergeSpanProfileRequest
+	23, // 41: ingester.v1.IngesterService.BlockMetadata:input_type -> ingester.v1.BlockMetadataRequest
+	41, // 42: ingester.v1.IngesterService.GetProfileStats:input_type -> types.v1.GetProfileStatsRequest
+	27, // 43: ingester.v1.IngesterService.GetBlockStats:input_type -> ingester.v1.GetBlockStatsRequest
+	42, // 44: ingester.v1.IngesterService.Push:output_type -> push.v1.PushResponse
+	43, // 45: ingester.v1.IngesterService.LabelValues:output_type -> types.v1.LabelValuesResponse
+	44, // 46: ingester.v1.IngesterService.LabelNames:output_type -> types.v1.LabelNamesResponse
+	2,  // 47: ingester.v1.IngesterService.ProfileTypes:output_type -> ingester.v1.ProfileTypesResponse
+	4,  // 48: ingester.v1.IngesterService.Series:output_type -> ingester.v1.SeriesResponse
+	6,  // 49: ingester.v1.IngesterService.Flush:output_type -> ingester.v1.FlushResponse
+	10, // 50: ingester.v1.IngesterService.MergeProfilesStacktraces:output_type -> ingester.v1.MergeProfilesStacktracesResponse
+	20, // 51: ingester.v1.IngesterService.MergeProfilesLabels:output_type -> ingester.v1.MergeProfilesLabelsResponse
+	22, // 52: ingester.v1.IngesterService.MergeProfilesPprof:output_type -> ingester.v1.MergeProfilesPprofResponse
+	13, // 53: ingester.v1.IngesterService.MergeSpanProfile:output_type -> ingester.v1.MergeSpanProfileResponse
+	24, // 54: ingester.v1.IngesterService.BlockMetadata:output_type -> ingester.v1.BlockMetadataResponse
+	45, // 55: ingester.v1.IngesterService.GetProfileStats:output_type -> types.v1.GetProfileStatsResponse
+	28, // 56: ingester.v1.IngesterService.GetBlockStats:output_type -> ingester.v1.GetBlockStatsResponse
+	44, // [44:57] is the sub-list for method output_type
+	31, // [31:44] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
 }
 
 func init() { file_ingester_v1_ingester_proto_init() }
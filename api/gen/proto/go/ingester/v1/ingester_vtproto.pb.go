@@ -491,6 +491,8 @@ func (m *MergeProfilesLabelsRequest) CloneVT() *MergeProfilesLabelsRequest {
 	}
 	r := new(MergeProfilesLabelsRequest)
 	r.Request = m.Request.CloneVT()
+	r.ExemplarType = m.ExemplarType
+	r.MaxExemplarsPerPoint = m.MaxExemplarsPerPoint
 	if rhs := m.By; rhs != nil {
 		tmpContainer := make([]string, len(rhs))
 		copy(tmpContainer, rhs)
@@ -1391,6 +1393,12 @@ func (this *MergeProfilesLabelsRequest) EqualVT(that *MergeProfilesLabelsRequest
 	} else if !proto.Equal(this.StackTraceSelector, that.StackTraceSelector) {
 		return false
 	}
+	if this.ExemplarType != that.ExemplarType {
+		return false
+	}
+	if this.MaxExemplarsPerPoint != that.MaxExemplarsPerPoint {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -3480,6 +3488,16 @@ func (m *MergeProfilesLabelsRequest) MarshalToSizedBufferVT(dAtA []byte) (int, e
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.MaxExemplarsPerPoint != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.MaxExemplarsPerPoint))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.ExemplarType != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.ExemplarType))
+		i--
+		dAtA[i] = 0x28
+	}
 	if m.StackTraceSelector != nil {
 		if vtmsg, ok := interface{}(m.StackTraceSelector).(interface {
 			MarshalToSizedBufferVT([]byte) (int, error)
@@ -4531,6 +4549,12 @@ func (m *MergeProfilesLabelsRequest) SizeVT() (n int) {
 		}
 		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 	}
+	if m.ExemplarType != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.ExemplarType))
+	}
+	if m.MaxExemplarsPerPoint != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.MaxExemplarsPerPoint))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -7450,6 +7474,44 @@ func (m *MergeProfilesLabelsRequest) UnmarshalVT(dAtA []byte) error {
 				}
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExemplarType", wireType)
+			}
+			m.ExemplarType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExemplarType |= v1.ExemplarType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxExemplarsPerPoint", wireType)
+			}
+			m.MaxExemplarsPerPoint = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxExemplarsPerPoint |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -183,8 +183,22 @@ type CompactionJob struct {
 	CompactionLevel uint32                 `protobuf:"varint,4,opt,name=compaction_level,json=compactionLevel,proto3" json:"compaction_level,omitempty"`
 	SourceBlocks    []string               `protobuf:"bytes,5,rep,name=source_blocks,json=sourceBlocks,proto3" json:"source_blocks,omitempty"`
 	Tombstones      []*Tombstones          `protobuf:"bytes,6,rep,name=tombstones,proto3" json:"tombstones,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// Series to be removed from the compacted blocks.
+	SeriesTombstones []*SeriesTombstone `protobuf:"bytes,7,rep,name=series_tombstones,json=seriesTombstones,proto3" json:"series_tombstones,omitempty"`
+	// If set, the source blocks are moved to the target shard,
+	// without changing the compaction level.
+	TargetShard uint32 `protobuf:"varint,8,opt,name=target_shard,json=targetShard,proto3" json:"target_shard,omitempty"`
+	// If set, the source blocks are rewritten in place,
+	// without changing the shard and the compaction level.
+	Rewrite bool `protobuf:"varint,9,opt,name=rewrite,proto3" json:"rewrite,omitempty"`
+	// If set, the compacted blocks include downsampled
+	// datasets, regardless of the age of the data.
+	Downsample bool `protobuf:"varint,10,opt,name=downsample,proto3" json:"downsample,omitempty"`
+	// If set, the compacted blocks are written
+	// to the cold storage tier.
+	ColdStorage   bool `protobuf:"varint,11,opt,name=cold_storage,json=coldStorage,proto3" json:"cold_storage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CompactionJob) Reset() {
@@ -259,6 +273,41 @@ func (x *CompactionJob) GetTombstones() []*Tombstones {
 	return nil
 }
 
+func (x *CompactionJob) GetSeriesTombstones() []*SeriesTombstone {
+	if x != nil {
+		return x.SeriesTombstones
+	}
+	return nil
+}
+
+func (x *CompactionJob) GetTargetShard() uint32 {
+	if x != nil {
+		return x.TargetShard
+	}
+	return 0
+}
+
+func (x *CompactionJob) GetRewrite() bool {
+	if x != nil {
+		return x.Rewrite
+	}
+	return false
+}
+
+func (x *CompactionJob) GetDownsample() bool {
+	if x != nil {
+		return x.Downsample
+	}
+	return false
+}
+
+func (x *CompactionJob) GetColdStorage() bool {
+	if x != nil {
+		return x.ColdStorage
+	}
+	return false
+}
+
 // Tombstones represent objects removed from the index but still stored.
 type Tombstones struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -465,6 +514,96 @@ func (x *ShardTombstone) GetTenant() string {
 	return ""
 }
 
+// SeriesTombstone represents series deleted by a label selector within
+// a time range. Matching series are filtered out at query time until the
+// blocks are rewritten by compaction.
+type SeriesTombstone struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tenant        string                 `protobuf:"bytes,2,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	LabelSelector string                 `protobuf:"bytes,3,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	// Milliseconds since epoch, inclusive.
+	StartTime int64 `protobuf:"varint,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// Milliseconds since epoch, inclusive.
+	EndTime int64 `protobuf:"varint,5,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	// Milliseconds since epoch.
+	CreatedAt     int64 `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeriesTombstone) Reset() {
+	*x = SeriesTombstone{}
+	mi := &file_metastore_v1_compactor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeriesTombstone) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeriesTombstone) ProtoMessage() {}
+
+func (x *SeriesTombstone) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_compactor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeriesTombstone.ProtoReflect.Descriptor instead.
+func (*SeriesTombstone) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_compactor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SeriesTombstone) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SeriesTombstone) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *SeriesTombstone) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *SeriesTombstone) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *SeriesTombstone) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *SeriesTombstone) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
 type CompactionJobAssignment struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	Name           string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -476,7 +615,7 @@ type CompactionJobAssignment struct {
 
 func (x *CompactionJobAssignment) Reset() {
 	*x = CompactionJobAssignment{}
-	mi := &file_metastore_v1_compactor_proto_msgTypes[6]
+	mi := &file_metastore_v1_compactor_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -488,7 +627,7 @@ func (x *CompactionJobAssignment) String() string {
 func (*CompactionJobAssignment) ProtoMessage() {}
 
 func (x *CompactionJobAssignment) ProtoReflect() protoreflect.Message {
-	mi := &file_metastore_v1_compactor_proto_msgTypes[6]
+	mi := &file_metastore_v1_compactor_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -501,7 +640,7 @@ func (x *CompactionJobAssignment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompactionJobAssignment.ProtoReflect.Descriptor instead.
 func (*CompactionJobAssignment) Descriptor() ([]byte, []int) {
-	return file_metastore_v1_compactor_proto_rawDescGZIP(), []int{6}
+	return file_metastore_v1_compactor_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CompactionJobAssignment) GetName() string {
@@ -538,7 +677,7 @@ type CompactionJobStatusUpdate struct {
 
 func (x *CompactionJobStatusUpdate) Reset() {
 	*x = CompactionJobStatusUpdate{}
-	mi := &file_metastore_v1_compactor_proto_msgTypes[7]
+	mi := &file_metastore_v1_compactor_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -550,7 +689,7 @@ func (x *CompactionJobStatusUpdate) String() string {
 func (*CompactionJobStatusUpdate) ProtoMessage() {}
 
 func (x *CompactionJobStatusUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_metastore_v1_compactor_proto_msgTypes[7]
+	mi := &file_metastore_v1_compactor_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -563,7 +702,7 @@ func (x *CompactionJobStatusUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompactionJobStatusUpdate.ProtoReflect.Descriptor instead.
 func (*CompactionJobStatusUpdate) Descriptor() ([]byte, []int) {
-	return file_metastore_v1_compactor_proto_rawDescGZIP(), []int{7}
+	return file_metastore_v1_compactor_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CompactionJobStatusUpdate) GetName() string {
@@ -604,7 +743,7 @@ type CompactedBlocks struct {
 
 func (x *CompactedBlocks) Reset() {
 	*x = CompactedBlocks{}
-	mi := &file_metastore_v1_compactor_proto_msgTypes[8]
+	mi := &file_metastore_v1_compactor_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -616,7 +755,7 @@ func (x *CompactedBlocks) String() string {
 func (*CompactedBlocks) ProtoMessage() {}
 
 func (x *CompactedBlocks) ProtoReflect() protoreflect.Message {
-	mi := &file_metastore_v1_compactor_proto_msgTypes[8]
+	mi := &file_metastore_v1_compactor_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -629,7 +768,7 @@ func (x *CompactedBlocks) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompactedBlocks.ProtoReflect.Descriptor instead.
 func (*CompactedBlocks) Descriptor() ([]byte, []int) {
-	return file_metastore_v1_compactor_proto_rawDescGZIP(), []int{8}
+	return file_metastore_v1_compactor_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *CompactedBlocks) GetSourceBlocks() *BlockList {
@@ -656,7 +795,7 @@ const file_metastore_v1_compactor_proto_rawDesc = "" +
 	"\fjob_capacity\x18\x02 \x01(\rR\vjobCapacity\"\xab\x01\n" +
 	"\x1aPollCompactionJobsResponse\x12D\n" +
 	"\x0fcompaction_jobs\x18\x01 \x03(\v2\x1b.metastore.v1.CompactionJobR\x0ecompactionJobs\x12G\n" +
-	"\vassignments\x18\x02 \x03(\v2%.metastore.v1.CompactionJobAssignmentR\vassignments\"\xdb\x01\n" +
+	"\vassignments\x18\x02 \x03(\v2%.metastore.v1.CompactionJobAssignmentR\vassignments\"\xa7\x03\n" +
 	"\rCompactionJob\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
 	"\x05shard\x18\x02 \x01(\rR\x05shard\x12\x16\n" +
@@ -665,7 +804,15 @@ const file_metastore_v1_compactor_proto_rawDesc = "" +
 	"\rsource_blocks\x18\x05 \x03(\tR\fsourceBlocks\x128\n" +
 	"\n" +
 	"tombstones\x18\x06 \x03(\v2\x18.metastore.v1.TombstonesR\n" +
-	"tombstones\"w\n" +
+	"tombstones\x12J\n" +
+	"\x11series_tombstones\x18\a \x03(\v2\x1d.metastore.v1.SeriesTombstoneR\x10seriesTombstones\x12!\n" +
+	"\ftarget_shard\x18\b \x01(\rR\vtargetShard\x12\x18\n" +
+	"\arewrite\x18\t \x01(\bR\arewrite\x12\x1e\n" +
+	"\n" +
+	"downsample\x18\n" +
+	" \x01(\bR\n" +
+	"downsample\x12!\n" +
+	"\fcold_storage\x18\v \x01(\bR\vcoldStorage\"w\n" +
 	"\n" +
 	"Tombstones\x125\n" +
 	"\x06blocks\x18\x01 \x01(\v2\x1d.metastore.v1.BlockTombstonesR\x06blocks\x122\n" +
@@ -681,7 +828,16 @@ const file_metastore_v1_compactor_proto_rawDesc = "" +
 	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x1a\n" +
 	"\bduration\x18\x03 \x01(\x03R\bduration\x12\x14\n" +
 	"\x05shard\x18\x04 \x01(\rR\x05shard\x12\x16\n" +
-	"\x06tenant\x18\x05 \x01(\tR\x06tenant\"m\n" +
+	"\x06tenant\x18\x05 \x01(\tR\x06tenant\"\xbd\x01\n" +
+	"\x0fSeriesTombstone\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06tenant\x18\x02 \x01(\tR\x06tenant\x12%\n" +
+	"\x0elabel_selector\x18\x03 \x01(\tR\rlabelSelector\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x04 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x05 \x01(\x03R\aendTime\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\"m\n" +
 	"\x17CompactionJobAssignment\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
 	"\x05token\x18\x02 \x01(\x04R\x05token\x12(\n" +
@@ -716,7 +872,7 @@ func file_metastore_v1_compactor_proto_rawDescGZIP() []byte {
 }
 
 var file_metastore_v1_compactor_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_metastore_v1_compactor_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_metastore_v1_compactor_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_metastore_v1_compactor_proto_goTypes = []any{
 	(CompactionJobStatus)(0),           // 0: metastore.v1.CompactionJobStatus
 	(*PollCompactionJobsRequest)(nil),  // 1: metastore.v1.PollCompactionJobsRequest
@@ -725,30 +881,32 @@ var file_metastore_v1_compactor_proto_goTypes = []any{
 	(*Tombstones)(nil),                 // 4: metastore.v1.Tombstones
 	(*BlockTombstones)(nil),            // 5: metastore.v1.BlockTombstones
 	(*ShardTombstone)(nil),             // 6: metastore.v1.ShardTombstone
-	(*CompactionJobAssignment)(nil),    // 7: metastore.v1.CompactionJobAssignment
-	(*CompactionJobStatusUpdate)(nil),  // 8: metastore.v1.CompactionJobStatusUpdate
-	(*CompactedBlocks)(nil),            // 9: metastore.v1.CompactedBlocks
-	(*BlockList)(nil),                  // 10: metastore.v1.BlockList
-	(*BlockMeta)(nil),                  // 11: metastore.v1.BlockMeta
+	(*SeriesTombstone)(nil),            // 7: metastore.v1.SeriesTombstone
+	(*CompactionJobAssignment)(nil),    // 8: metastore.v1.CompactionJobAssignment
+	(*CompactionJobStatusUpdate)(nil),  // 9: metastore.v1.CompactionJobStatusUpdate
+	(*CompactedBlocks)(nil),            // 10: metastore.v1.CompactedBlocks
+	(*BlockList)(nil),                  // 11: metastore.v1.BlockList
+	(*BlockMeta)(nil),                  // 12: metastore.v1.BlockMeta
 }
 var file_metastore_v1_compactor_proto_depIdxs = []int32{
-	8,  // 0: metastore.v1.PollCompactionJobsRequest.status_updates:type_name -> metastore.v1.CompactionJobStatusUpdate
+	9,  // 0: metastore.v1.PollCompactionJobsRequest.status_updates:type_name -> metastore.v1.CompactionJobStatusUpdate
 	3,  // 1: metastore.v1.PollCompactionJobsResponse.compaction_jobs:type_name -> metastore.v1.CompactionJob
-	7,  // 2: metastore.v1.PollCompactionJobsResponse.assignments:type_name -> metastore.v1.CompactionJobAssignment
+	8,  // 2: metastore.v1.PollCompactionJobsResponse.assignments:type_name -> metastore.v1.CompactionJobAssignment
 	4,  // 3: metastore.v1.CompactionJob.tombstones:type_name -> metastore.v1.Tombstones
-	5,  // 4: metastore.v1.Tombstones.blocks:type_name -> metastore.v1.BlockTombstones
-	6,  // 5: metastore.v1.Tombstones.shard:type_name -> metastore.v1.ShardTombstone
-	0,  // 6: metastore.v1.CompactionJobStatusUpdate.status:type_name -> metastore.v1.CompactionJobStatus
-	9,  // 7: metastore.v1.CompactionJobStatusUpdate.compacted_blocks:type_name -> metastore.v1.CompactedBlocks
-	10, // 8: metastore.v1.CompactedBlocks.source_blocks:type_name -> metastore.v1.BlockList
-	11, // 9: metastore.v1.CompactedBlocks.new_blocks:type_name -> metastore.v1.BlockMeta
-	1,  // 10: metastore.v1.CompactionService.PollCompactionJobs:input_type -> metastore.v1.PollCompactionJobsRequest
-	2,  // 11: metastore.v1.CompactionService.PollCompactionJobs:output_type -> metastore.v1.PollCompactionJobsResponse
-	11, // [11:12] is the sub-list for method output_type
-	10, // [10:11] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	7,  // 4: metastore.v1.CompactionJob.series_tombstones:type_name -> metastore.v1.SeriesTombstone
+	5,  // 5: metastore.v1.Tombstones.blocks:type_name -> metastore.v1.BlockTombstones
+	6,  // 6: metastore.v1.Tombstones.shard:type_name -> metastore.v1.ShardTombstone
+	0,  // 7: metastore.v1.CompactionJobStatusUpdate.status:type_name -> metastore.v1.CompactionJobStatus
+	10, // 8: metastore.v1.CompactionJobStatusUpdate.compacted_blocks:type_name -> metastore.v1.CompactedBlocks
+	11, // 9: metastore.v1.CompactedBlocks.source_blocks:type_name -> metastore.v1.BlockList
+	12, // 10: metastore.v1.CompactedBlocks.new_blocks:type_name -> metastore.v1.BlockMeta
+	1,  // 11: metastore.v1.CompactionService.PollCompactionJobs:input_type -> metastore.v1.PollCompactionJobsRequest
+	2,  // 12: metastore.v1.CompactionService.PollCompactionJobs:output_type -> metastore.v1.PollCompactionJobsResponse
+	12, // [12:13] is the sub-list for method output_type
+	11, // [11:12] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_metastore_v1_compactor_proto_init() }
@@ -763,7 +921,7 @@ func file_metastore_v1_compactor_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metastore_v1_compactor_proto_rawDesc), len(file_metastore_v1_compactor_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   9,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -200,9 +200,10 @@ const file_metastore_v1_index_proto_rawDesc = "" +
 	"\x17GetBlockMetadataRequest\x12/\n" +
 	"\x06blocks\x18\x01 \x01(\v2\x17.metastore.v1.BlockListR\x06blocks\"K\n" +
 	"\x18GetBlockMetadataResponse\x12/\n" +
-	"\x06blocks\x18\x01 \x03(\v2\x17.metastore.v1.BlockMetaR\x06blocks2\xc0\x01\n" +
+	"\x06blocks\x18\x01 \x03(\v2\x17.metastore.v1.BlockMetaR\x06blocks2\x96\x02\n" +
 	"\fIndexService\x12K\n" +
-	"\bAddBlock\x12\x1d.metastore.v1.AddBlockRequest\x1a\x1e.metastore.v1.AddBlockResponse\"\x00\x12c\n" +
+	"\bAddBlock\x12\x1d.metastore.v1.AddBlockRequest\x1a\x1e.metastore.v1.AddBlockResponse\"\x00\x12T\n" +
+	"\x11AddRecoveredBlock\x12\x1d.metastore.v1.AddBlockRequest\x1a\x1e.metastore.v1.AddBlockResponse\"\x00\x12c\n" +
 	"\x10GetBlockMetadata\x12%.metastore.v1.GetBlockMetadataRequest\x1a&.metastore.v1.GetBlockMetadataResponse\"\x00B\xb7\x01\n" +
 	"\x10com.metastore.v1B\n" +
 	"IndexProtoP\x01ZFgithub.com/grafana/pyroscope/api/gen/proto/go/metastore/v1;metastorev1\xa2\x02\x03MXX\xaa\x02\fMetastore.V1\xca\x02\fMetastore\\V1\xe2\x02\x18Metastore\\V1\\GPBMetadata\xea\x02\rMetastore::V1b\x06proto3"
@@ -233,11 +234,13 @@ var file_metastore_v1_index_proto_depIdxs = []int32{
 	5, // 1: metastore.v1.GetBlockMetadataRequest.blocks:type_name -> metastore.v1.BlockList
 	4, // 2: metastore.v1.GetBlockMetadataResponse.blocks:type_name -> metastore.v1.BlockMeta
 	0, // 3: metastore.v1.IndexService.AddBlock:input_type -> metastore.v1.AddBlockRequest
-	2, // 4: metastore.v1.IndexService.GetBlockMetadata:input_type -> metastore.v1.GetBlockMetadataRequest
-	1, // 5: metastore.v1.IndexService.AddBlock:output_type -> metastore.v1.AddBlockResponse
-	3, // 6: metastore.v1.IndexService.GetBlockMetadata:output_type -> metastore.v1.GetBlockMetadataResponse
-	5, // [5:7] is the sub-list for method output_type
-	3, // [3:5] is the sub-list for method input_type
+	0, // 4: metastore.v1.IndexService.AddRecoveredBlock:input_type -> metastore.v1.AddBlockRequest
+	2, // 5: metastore.v1.IndexService.GetBlockMetadata:input_type -> metastore.v1.GetBlockMetadataRequest
+	1, // 6: metastore.v1.IndexService.AddBlock:output_type -> metastore.v1.AddBlockResponse
+	1, // 7: metastore.v1.IndexService.AddRecoveredBlock:output_type -> metastore.v1.AddBlockResponse
+	3, // 8: metastore.v1.IndexService.GetBlockMetadata:output_type -> metastore.v1.GetBlockMetadataResponse
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
 	3, // [3:3] is the sub-list for extension type_name
 	3, // [3:3] is the sub-list for extension extendee
 	0, // [0:3] is the sub-list for field type_name
@@ -194,6 +194,9 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type IndexServiceClient interface {
 	AddBlock(ctx context.Context, in *AddBlockRequest, opts ...grpc.CallOption) (*AddBlockResponse, error)
+	// AddRecoveredBlock adds a block recovered from the object storage.
+	// Unlike AddBlock, the block is not accounted in the placement stats.
+	AddRecoveredBlock(ctx context.Context, in *AddBlockRequest, opts ...grpc.CallOption) (*AddBlockResponse, error)
 	GetBlockMetadata(ctx context.Context, in *GetBlockMetadataRequest, opts ...grpc.CallOption) (*GetBlockMetadataResponse, error)
 }
 
@@ -214,6 +217,15 @@ func (c *indexServiceClient) AddBlock(ctx context.Context, in *AddBlockRequest,
 	return out, nil
 }
 
+func (c *indexServiceClient) AddRecoveredBlock(ctx context.Context, in *AddBlockRequest, opts ...grpc.CallOption) (*AddBlockResponse, error) {
+	out := new(AddBlockResponse)
+	err := c.cc.Invoke(ctx, "/metastore.v1.IndexService/AddRecoveredBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *indexServiceClient) GetBlockMetadata(ctx context.Context, in *GetBlockMetadataRequest, opts ...grpc.CallOption) (*GetBlockMetadataResponse, error) {
 	out := new(GetBlockMetadataResponse)
 	err := c.cc.Invoke(ctx, "/metastore.v1.IndexService/GetBlockMetadata", in, out, opts...)
@@ -228,6 +240,9 @@ func (c *indexServiceClient) GetBlockMetadata(ctx context.Context, in *GetBlockM
 // for forward compatibility
 type IndexServiceServer interface {
 	AddBlock(context.Context, *AddBlockRequest) (*AddBlockResponse, error)
+	// AddRecoveredBlock adds a block recovered from the object storage.
+	// Unlike AddBlock, the block is not accounted in the placement stats.
+	AddRecoveredBlock(context.Context, *AddBlockRequest) (*AddBlockResponse, error)
 	GetBlockMetadata(context.Context, *GetBlockMetadataRequest) (*GetBlockMetadataResponse, error)
 	mustEmbedUnimplementedIndexServiceServer()
 }
@@ -239,6 +254,9 @@ type UnimplementedIndexServiceServer struct {
 func (UnimplementedIndexServiceServer) AddBlock(context.Context, *AddBlockRequest) (*AddBlockResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddBlock not implemented")
 }
+func (UnimplementedIndexServiceServer) AddRecoveredBlock(context.Context, *AddBlockRequest) (*AddBlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddRecoveredBlock not implemented")
+}
 func (UnimplementedIndexServiceServer) GetBlockMetadata(context.Context, *GetBlockMetadataRequest) (*GetBlockMetadataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlockMetadata not implemented")
 }
@@ -273,6 +291,24 @@ func _IndexService_AddBlock_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _IndexService_AddRecoveredBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).AddRecoveredBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metastore.v1.IndexService/AddRecoveredBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).AddRecoveredBlock(ctx, req.(*AddBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _IndexService_GetBlockMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetBlockMetadataRequest)
 	if err := dec(in); err != nil {
@@ -302,6 +338,10 @@ var IndexService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddBlock",
 			Handler:    _IndexService_AddBlock_Handler,
 		},
+		{
+			MethodName: "AddRecoveredBlock",
+			Handler:    _IndexService_AddRecoveredBlock_Handler,
+		},
 		{
 			MethodName: "GetBlockMetadata",
 			Handler:    _IndexService_GetBlockMetadata_Handler,
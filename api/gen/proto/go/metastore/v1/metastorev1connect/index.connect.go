@@ -35,6 +35,9 @@ const (
 const (
 	// IndexServiceAddBlockProcedure is the fully-qualified name of the IndexService's AddBlock RPC.
 	IndexServiceAddBlockProcedure = "/metastore.v1.IndexService/AddBlock"
+	// IndexServiceAddRecoveredBlockProcedure is the fully-qualified name of the IndexService's
+	// AddRecoveredBlock RPC.
+	IndexServiceAddRecoveredBlockProcedure = "/metastore.v1.IndexService/AddRecoveredBlock"
 	// IndexServiceGetBlockMetadataProcedure is the fully-qualified name of the IndexService's
 	// GetBlockMetadata RPC.
 	IndexServiceGetBlockMetadataProcedure = "/metastore.v1.IndexService/GetBlockMetadata"
@@ -43,6 +46,9 @@ const (
 // IndexServiceClient is a client for the metastore.v1.IndexService service.
 type IndexServiceClient interface {
 	AddBlock(context.Context, *connect.Request[v1.AddBlockRequest]) (*connect.Response[v1.AddBlockResponse], error)
+	// AddRecoveredBlock adds a block recovered from the object storage.
+	// Unlike AddBlock, the block is not accounted in the placement stats.
+	AddRecoveredBlock(context.Context, *connect.Request[v1.AddBlockRequest]) (*connect.Response[v1.AddBlockResponse], error)
 	GetBlockMetadata(context.Context, *connect.Request[v1.GetBlockMetadataRequest]) (*connect.Response[v1.GetBlockMetadataResponse], error)
 }
 
@@ -63,6 +69,12 @@ func NewIndexServiceClient(httpClient connect.HTTPClient, baseURL string, opts .
 			connect.WithSchema(indexServiceMethods.ByName("AddBlock")),
 			connect.WithClientOptions(opts...),
 		),
+		addRecoveredBlock: connect.NewClient[v1.AddBlockRequest, v1.AddBlockResponse](
+			httpClient,
+			baseURL+IndexServiceAddRecoveredBlockProcedure,
+			connect.WithSchema(indexServiceMethods.ByName("AddRecoveredBlock")),
+			connect.WithClientOptions(opts...),
+		),
 		getBlockMetadata: connect.NewClient[v1.GetBlockMetadataRequest, v1.GetBlockMetadataResponse](
 			httpClient,
 			baseURL+IndexServiceGetBlockMetadataProcedure,
@@ -74,8 +86,9 @@ func NewIndexServiceClient(httpClient connect.HTTPClient, baseURL string, opts .
 
 // indexServiceClient implements IndexServiceClient.
 type indexServiceClient struct {
-	addBlock         *connect.Client[v1.AddBlockRequest, v1.AddBlockResponse]
-	getBlockMetadata *connect.Client[v1.GetBlockMetadataRequest, v1.GetBlockMetadataResponse]
+	addBlock          *connect.Client[v1.AddBlockRequest, v1.AddBlockResponse]
+	addRecoveredBlock *connect.Client[v1.AddBlockRequest, v1.AddBlockResponse]
+	getBlockMetadata  *connect.Client[v1.GetBlockMetadataRequest, v1.GetBlockMetadataResponse]
 }
 
 // AddBlock calls metastore.v1.IndexService.AddBlock.
@@ -83,6 +96,11 @@ func (c *indexServiceClient) AddBlock(ctx context.Context, req *connect.Request[
 	return c.addBlock.CallUnary(ctx, req)
 }
 
+// AddRecoveredBlock calls metastore.v1.IndexService.AddRecoveredBlock.
+func (c *indexServiceClient) AddRecoveredBlock(ctx context.Context, req *connect.Request[v1.AddBlockRequest]) (*connect.Response[v1.AddBlockResponse], error) {
+	return c.addRecoveredBlock.CallUnary(ctx, req)
+}
+
 // GetBlockMetadata calls metastore.v1.IndexService.GetBlockMetadata.
 func (c *indexServiceClient) GetBlockMetadata(ctx context.Context, req *connect.Request[v1.GetBlockMetadataRequest]) (*connect.Response[v1.GetBlockMetadataResponse], error) {
 	return c.getBlockMetadata.CallUnary(ctx, req)
@@ -91,6 +109,9 @@ func (c *indexServiceClient) GetBlockMetadata(ctx context.Context, req *connect.
 // IndexServiceHandler is an implementation of the metastore.v1.IndexService service.
 type IndexServiceHandler interface {
 	AddBlock(context.Context, *connect.Request[v1.AddBlockRequest]) (*connect.Response[v1.AddBlockResponse], error)
+	// AddRecoveredBlock adds a block recovered from the object storage.
+	// Unlike AddBlock, the block is not accounted in the placement stats.
+	AddRecoveredBlock(context.Context, *connect.Request[v1.AddBlockRequest]) (*connect.Response[v1.AddBlockResponse], error)
 	GetBlockMetadata(context.Context, *connect.Request[v1.GetBlockMetadataRequest]) (*connect.Response[v1.GetBlockMetadataResponse], error)
 }
 
@@ -107,6 +128,12 @@ func NewIndexServiceHandler(svc IndexServiceHandler, opts ...connect.HandlerOpti
 		connect.WithSchema(indexServiceMethods.ByName("AddBlock")),
 		connect.WithHandlerOptions(opts...),
 	)
+	indexServiceAddRecoveredBlockHandler := connect.NewUnaryHandler(
+		IndexServiceAddRecoveredBlockProcedure,
+		svc.AddRecoveredBlock,
+		connect.WithSchema(indexServiceMethods.ByName("AddRecoveredBlock")),
+		connect.WithHandlerOptions(opts...),
+	)
 	indexServiceGetBlockMetadataHandler := connect.NewUnaryHandler(
 		IndexServiceGetBlockMetadataProcedure,
 		svc.GetBlockMetadata,
@@ -117,6 +144,8 @@ func NewIndexServiceHandler(svc IndexServiceHandler, opts ...connect.HandlerOpti
 		switch r.URL.Path {
 		case IndexServiceAddBlockProcedure:
 			indexServiceAddBlockHandler.ServeHTTP(w, r)
+		case IndexServiceAddRecoveredBlockProcedure:
+			indexServiceAddRecoveredBlockHandler.ServeHTTP(w, r)
 		case IndexServiceGetBlockMetadataProcedure:
 			indexServiceGetBlockMetadataHandler.ServeHTTP(w, r)
 		default:
@@ -132,6 +161,10 @@ func (UnimplementedIndexServiceHandler) AddBlock(context.Context, *connect.Reque
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("metastore.v1.IndexService.AddBlock is not implemented"))
 }
 
+func (UnimplementedIndexServiceHandler) AddRecoveredBlock(context.Context, *connect.Request[v1.AddBlockRequest]) (*connect.Response[v1.AddBlockResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("metastore.v1.IndexService.AddRecoveredBlock is not implemented"))
+}
+
 func (UnimplementedIndexServiceHandler) GetBlockMetadata(context.Context, *connect.Request[v1.GetBlockMetadataRequest]) (*connect.Response[v1.GetBlockMetadataResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("metastore.v1.IndexService.GetBlockMetadata is not implemented"))
 }
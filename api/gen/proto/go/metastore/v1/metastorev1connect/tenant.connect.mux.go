@@ -34,4 +34,9 @@ func RegisterTenantServiceHandler(mux *mux.Router, svc TenantServiceHandler, opt
 		svc.DeleteTenant,
 		opts...,
 	))
+	mux.Handle("/metastore.v1.TenantService/DeleteSeries", connect.NewUnaryHandler(
+		"/metastore.v1.TenantService/DeleteSeries",
+		svc.DeleteSeries,
+		opts...,
+	))
 }
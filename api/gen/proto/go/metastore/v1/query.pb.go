@@ -99,10 +99,12 @@ func (x *QueryMetadataRequest) GetLabels() []string {
 }
 
 type QueryMetadataResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Blocks        []*BlockMeta           `protobuf:"bytes,1,rep,name=blocks,proto3" json:"blocks,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Blocks []*BlockMeta           `protobuf:"bytes,1,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	// Series tombstones of the tenants overlapping with the query time range.
+	SeriesTombstones []*SeriesTombstone `protobuf:"bytes,2,rep,name=series_tombstones,json=seriesTombstones,proto3" json:"series_tombstones,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *QueryMetadataResponse) Reset() {
@@ -142,13 +144,23 @@ func (x *QueryMetadataResponse) GetBlocks() []*BlockMeta {
 	return nil
 }
 
+func (x *QueryMetadataResponse) GetSeriesTombstones() []*SeriesTombstone {
+	if x != nil {
+		return x.SeriesTombstones
+	}
+	return nil
+}
+
 type QueryMetadataLabelsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TenantId      []string               `protobuf:"bytes,1,rep,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
-	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
-	EndTime       int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
-	Query         string                 `protobuf:"bytes,4,opt,name=query,proto3" json:"query,omitempty"`
-	Labels        []string               `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	TenantId  []string               `protobuf:"bytes,1,rep,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	StartTime int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime   int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Query     string                 `protobuf:"bytes,4,opt,name=query,proto3" json:"query,omitempty"`
+	Labels    []string               `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty"`
+	// If set, the number of datasets labeled with each
+	// label set is returned in dataset_counts.
+	CountDatasets bool `protobuf:"varint,6,opt,name=count_datasets,json=countDatasets,proto3" json:"count_datasets,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -218,9 +230,19 @@ func (x *QueryMetadataLabelsRequest) GetLabels() []string {
 	return nil
 }
 
+func (x *QueryMetadataLabelsRequest) GetCountDatasets() bool {
+	if x != nil {
+		return x.CountDatasets
+	}
+	return false
+}
+
 type QueryMetadataLabelsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Labels        []*v1.Labels           `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Labels []*v1.Labels           `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	// The number of datasets overlapping with the query time range
+	// labeled with the label set at the same position in labels.
+	DatasetCounts []int64 `protobuf:"varint,2,rep,packed,name=dataset_counts,json=datasetCounts,proto3" json:"dataset_counts,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -262,29 +284,39 @@ func (x *QueryMetadataLabelsResponse) GetLabels() []*v1.Labels {
 	return nil
 }
 
+func (x *QueryMetadataLabelsResponse) GetDatasetCounts() []int64 {
+	if x != nil {
+		return x.DatasetCounts
+	}
+	return nil
+}
+
 var File_metastore_v1_query_proto protoreflect.FileDescriptor
 
 const file_metastore_v1_query_proto_rawDesc = "" +
 	"\n" +
-	"\x18metastore/v1/query.proto\x12\fmetastore.v1\x1a\x18metastore/v1/types.proto\x1a\x14types/v1/types.proto\"\x9b\x01\n" +
+	"\x18metastore/v1/query.proto\x12\fmetastore.v1\x1a\x1cmetastore/v1/compactor.proto\x1a\x18metastore/v1/types.proto\x1a\x14types/v1/types.proto\"\x9b\x01\n" +
 	"\x14QueryMetadataRequest\x12\x1b\n" +
 	"\ttenant_id\x18\x01 \x03(\tR\btenantId\x12\x1d\n" +
 	"\n" +
 	"start_time\x18\x02 \x01(\x03R\tstartTime\x12\x19\n" +
 	"\bend_time\x18\x03 \x01(\x03R\aendTime\x12\x14\n" +
 	"\x05query\x18\x04 \x01(\tR\x05query\x12\x16\n" +
-	"\x06labels\x18\x05 \x03(\tR\x06labels\"H\n" +
+	"\x06labels\x18\x05 \x03(\tR\x06labels\"\x94\x01\n" +
 	"\x15QueryMetadataResponse\x12/\n" +
-	"\x06blocks\x18\x01 \x03(\v2\x17.metastore.v1.BlockMetaR\x06blocks\"\xa1\x01\n" +
+	"\x06blocks\x18\x01 \x03(\v2\x17.metastore.v1.BlockMetaR\x06blocks\x12J\n" +
+	"\x11series_tombstones\x18\x02 \x03(\v2\x1d.metastore.v1.SeriesTombstoneR\x10seriesTombstones\"\xc8\x01\n" +
 	"\x1aQueryMetadataLabelsRequest\x12\x1b\n" +
 	"\ttenant_id\x18\x01 \x03(\tR\btenantId\x12\x1d\n" +
 	"\n" +
 	"start_time\x18\x02 \x01(\x03R\tstartTime\x12\x19\n" +
 	"\bend_time\x18\x03 \x01(\x03R\aendTime\x12\x14\n" +
 	"\x05query\x18\x04 \x01(\tR\x05query\x12\x16\n" +
-	"\x06labels\x18\x05 \x03(\tR\x06labels\"G\n" +
+	"\x06labels\x18\x05 \x03(\tR\x06labels\x12%\n" +
+	"\x0ecount_datasets\x18\x06 \x01(\bR\rcountDatasets\"n\n" +
 	"\x1bQueryMetadataLabelsResponse\x12(\n" +
-	"\x06labels\x18\x01 \x03(\v2\x10.types.v1.LabelsR\x06labels2\xe0\x01\n" +
+	"\x06labels\x18\x01 \x03(\v2\x10.types.v1.LabelsR\x06labels\x12%\n" +
+	"\x0edataset_counts\x18\x02 \x03(\x03R\rdatasetCounts2\xe0\x01\n" +
 	"\x14MetadataQueryService\x12Z\n" +
 	"\rQueryMetadata\x12\".metastore.v1.QueryMetadataRequest\x1a#.metastore.v1.QueryMetadataResponse\"\x00\x12l\n" +
 	"\x13QueryMetadataLabels\x12(.metastore.v1.QueryMetadataLabelsRequest\x1a).metastore.v1.QueryMetadataLabelsResponse\"\x00B\xb7\x01\n" +
@@ -310,20 +342,22 @@ var file_metastore_v1_query_proto_goTypes = []any{
 	(*QueryMetadataLabelsRequest)(nil),  // 2: metastore.v1.QueryMetadataLabelsRequest
 	(*QueryMetadataLabelsResponse)(nil), // 3: metastore.v1.QueryMetadataLabelsResponse
 	(*BlockMeta)(nil),                   // 4: metastore.v1.BlockMeta
-	(*v1.Labels)(nil),                   // 5: types.v1.Labels
+	(*SeriesTombstone)(nil),             // 5: metastore.v1.SeriesTombstone
+	(*v1.Labels)(nil),                   // 6: types.v1.Labels
 }
 var file_metastore_v1_query_proto_depIdxs = []int32{
 	4, // 0: metastore.v1.QueryMetadataResponse.blocks:type_name -> metastore.v1.BlockMeta
-	5, // 1: metastore.v1.QueryMetadataLabelsResponse.labels:type_name -> types.v1.Labels
-	0, // 2: metastore.v1.MetadataQueryService.QueryMetadata:input_type -> metastore.v1.QueryMetadataRequest
-	2, // 3: metastore.v1.MetadataQueryService.QueryMetadataLabels:input_type -> metastore.v1.QueryMetadataLabelsRequest
-	1, // 4: metastore.v1.MetadataQueryService.QueryMetadata:output_type -> metastore.v1.QueryMetadataResponse
-	3, // 5: metastore.v1.MetadataQueryService.QueryMetadataLabels:output_type -> metastore.v1.QueryMetadataLabelsResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	5, // 1: metastore.v1.QueryMetadataResponse.series_tombstones:type_name -> metastore.v1.SeriesTombstone
+	6, // 2: metastore.v1.QueryMetadataLabelsResponse.labels:type_name -> types.v1.Labels
+	0, // 3: metastore.v1.MetadataQueryService.QueryMetadata:input_type -> metastore.v1.QueryMetadataRequest
+	2, // 4: metastore.v1.MetadataQueryService.QueryMetadataLabels:input_type -> metastore.v1.QueryMetadataLabelsRequest
+	1, // 5: metastore.v1.MetadataQueryService.QueryMetadata:output_type -> metastore.v1.QueryMetadataResponse
+	3, // 6: metastore.v1.MetadataQueryService.QueryMetadataLabels:output_type -> metastore.v1.QueryMetadataLabelsResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_metastore_v1_query_proto_init() }
@@ -331,6 +365,7 @@ func file_metastore_v1_query_proto_init() {
 	if File_metastore_v1_query_proto != nil {
 		return
 	}
+	file_metastore_v1_compactor_proto_init()
 	file_metastore_v1_types_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -65,6 +65,13 @@ func (m *QueryMetadataResponse) CloneVT() *QueryMetadataResponse {
 		}
 		r.Blocks = tmpContainer
 	}
+	if rhs := m.SeriesTombstones; rhs != nil {
+		tmpContainer := make([]*SeriesTombstone, len(rhs))
+		for k, v := range rhs {
+			tmpContainer[k] = v.CloneVT()
+		}
+		r.SeriesTombstones = tmpContainer
+	}
 	if len(m.unknownFields) > 0 {
 		r.unknownFields = make([]byte, len(m.unknownFields))
 		copy(r.unknownFields, m.unknownFields)
@@ -84,6 +91,7 @@ func (m *QueryMetadataLabelsRequest) CloneVT() *QueryMetadataLabelsRequest {
 	r.StartTime = m.StartTime
 	r.EndTime = m.EndTime
 	r.Query = m.Query
+	r.CountDatasets = m.CountDatasets
 	if rhs := m.TenantId; rhs != nil {
 		tmpContainer := make([]string, len(rhs))
 		copy(tmpContainer, rhs)
@@ -121,6 +129,11 @@ func (m *QueryMetadataLabelsResponse) CloneVT() *QueryMetadataLabelsResponse {
 		}
 		r.Labels = tmpContainer
 	}
+	if rhs := m.DatasetCounts; rhs != nil {
+		tmpContainer := make([]int64, len(rhs))
+		copy(tmpContainer, rhs)
+		r.DatasetCounts = tmpContainer
+	}
 	if len(m.unknownFields) > 0 {
 		r.unknownFields = make([]byte, len(m.unknownFields))
 		copy(r.unknownFields, m.unknownFields)
@@ -198,6 +211,23 @@ func (this *QueryMetadataResponse) EqualVT(that *QueryMetadataResponse) bool {
 			}
 		}
 	}
+	if len(this.SeriesTombstones) != len(that.SeriesTombstones) {
+		return false
+	}
+	for i, vx := range this.SeriesTombstones {
+		vy := that.SeriesTombstones[i]
+		if p, q := vx, vy; p != q {
+			if p == nil {
+				p = &SeriesTombstone{}
+			}
+			if q == nil {
+				q = &SeriesTombstone{}
+			}
+			if !p.EqualVT(q) {
+				return false
+			}
+		}
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -241,6 +271,9 @@ func (this *QueryMetadataLabelsRequest) EqualVT(that *QueryMetadataLabelsRequest
 			return false
 		}
 	}
+	if this.CountDatasets != that.CountDatasets {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -278,6 +311,15 @@ func (this *QueryMetadataLabelsResponse) EqualVT(that *QueryMetadataLabelsRespon
 			}
 		}
 	}
+	if len(this.DatasetCounts) != len(that.DatasetCounts) {
+		return false
+	}
+	for i, vx := range this.DatasetCounts {
+		vy := that.DatasetCounts[i]
+		if vx != vy {
+			return false
+		}
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -514,6 +556,18 @@ func (m *QueryMetadataResponse) MarshalToSizedBufferVT(dAtA []byte) (int, error)
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if len(m.SeriesTombstones) > 0 {
+		for iNdEx := len(m.SeriesTombstones) - 1; iNdEx >= 0; iNdEx-- {
+			size, err := m.SeriesTombstones[iNdEx].MarshalToSizedBufferVT(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
 	if len(m.Blocks) > 0 {
 		for iNdEx := len(m.Blocks) - 1; iNdEx >= 0; iNdEx-- {
 			size, err := m.Blocks[iNdEx].MarshalToSizedBufferVT(dAtA[:i])
@@ -559,6 +613,16 @@ func (m *QueryMetadataLabelsRequest) MarshalToSizedBufferVT(dAtA []byte) (int, e
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.CountDatasets {
+		i--
+		if m.CountDatasets {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
 	if len(m.Labels) > 0 {
 		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
 			i -= len(m.Labels[iNdEx])
@@ -627,6 +691,27 @@ func (m *QueryMetadataLabelsResponse) MarshalToSizedBufferVT(dAtA []byte) (int,
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if len(m.DatasetCounts) > 0 {
+		var pksize2 int
+		for _, num := range m.DatasetCounts {
+			pksize2 += protohelpers.SizeOfVarint(uint64(num))
+		}
+		i -= pksize2
+		j1 := i
+		for _, num1 := range m.DatasetCounts {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA[j1] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j1++
+			}
+			dAtA[j1] = uint8(num)
+			j1++
+		}
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(pksize2))
+		i--
+		dAtA[i] = 0x12
+	}
 	if len(m.Labels) > 0 {
 		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
 			if vtmsg, ok := interface{}(m.Labels[iNdEx]).(interface {
@@ -698,6 +783,12 @@ func (m *QueryMetadataResponse) SizeVT() (n int) {
 			n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 		}
 	}
+	if len(m.SeriesTombstones) > 0 {
+		for _, e := range m.SeriesTombstones {
+			l = e.SizeVT()
+			n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+		}
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -730,6 +821,9 @@ func (m *QueryMetadataLabelsRequest) SizeVT() (n int) {
 			n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 		}
 	}
+	if m.CountDatasets {
+		n += 2
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -752,6 +846,13 @@ func (m *QueryMetadataLabelsResponse) SizeVT() (n int) {
 			n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 		}
 	}
+	if len(m.DatasetCounts) > 0 {
+		l = 0
+		for _, e := range m.DatasetCounts {
+			l += protohelpers.SizeOfVarint(uint64(e))
+		}
+		n += 1 + protohelpers.SizeOfVarint(uint64(l)) + l
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -1004,6 +1105,40 @@ func (m *QueryMetadataResponse) UnmarshalVT(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesTombstones", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SeriesTombstones = append(m.SeriesTombstones, &SeriesTombstone{})
+			if err := m.SeriesTombstones[len(m.SeriesTombstones)-1].UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -1189,6 +1324,26 @@ func (m *QueryMetadataLabelsRequest) UnmarshalVT(dAtA []byte) error {
 			}
 			m.Labels = append(m.Labels, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CountDatasets", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CountDatasets = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -1282,6 +1437,82 @@ func (m *QueryMetadataLabelsResponse) UnmarshalVT(dAtA []byte) error {
 				}
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType == 0 {
+				var v int64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return protohelpers.ErrIntOverflow
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.DatasetCounts = append(m.DatasetCounts, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return protohelpers.ErrIntOverflow
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return protohelpers.ErrInvalidLength
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return protohelpers.ErrInvalidLength
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.DatasetCounts) == 0 {
+					m.DatasetCounts = make([]int64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return protohelpers.ErrIntOverflow
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.DatasetCounts = append(m.DatasetCounts, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field DatasetCounts", wireType)
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -30,6 +30,9 @@ const (
 	RaftCommand_RAFT_COMMAND_GET_COMPACTION_PLAN_UPDATE RaftCommand = 2
 	RaftCommand_RAFT_COMMAND_UPDATE_COMPACTION_PLAN     RaftCommand = 3
 	RaftCommand_RAFT_COMMAND_TRUNCATE_INDEX             RaftCommand = 4
+	RaftCommand_RAFT_COMMAND_DELETE_SERIES              RaftCommand = 5
+	RaftCommand_RAFT_COMMAND_ADD_RESHARD_JOBS           RaftCommand = 6
+	RaftCommand_RAFT_COMMAND_ADD_REWRITE_JOBS           RaftCommand = 7
 )
 
 // Enum value maps for RaftCommand.
@@ -40,6 +43,9 @@ var (
 		2: "RAFT_COMMAND_GET_COMPACTION_PLAN_UPDATE",
 		3: "RAFT_COMMAND_UPDATE_COMPACTION_PLAN",
 		4: "RAFT_COMMAND_TRUNCATE_INDEX",
+		5: "RAFT_COMMAND_DELETE_SERIES",
+		6: "RAFT_COMMAND_ADD_RESHARD_JOBS",
+		7: "RAFT_COMMAND_ADD_REWRITE_JOBS",
 	}
 	RaftCommand_value = map[string]int32{
 		"RAFT_COMMAND_UNKNOWN":                    0,
@@ -47,6 +53,9 @@ var (
 		"RAFT_COMMAND_GET_COMPACTION_PLAN_UPDATE": 2,
 		"RAFT_COMMAND_UPDATE_COMPACTION_PLAN":     3,
 		"RAFT_COMMAND_TRUNCATE_INDEX":             4,
+		"RAFT_COMMAND_DELETE_SERIES":              5,
+		"RAFT_COMMAND_ADD_RESHARD_JOBS":           6,
+		"RAFT_COMMAND_ADD_REWRITE_JOBS":           7,
 	}
 )
 
@@ -755,7 +764,22 @@ type CompactionJobPlan struct {
 	CompactionLevel uint32   `protobuf:"varint,4,opt,name=compaction_level,json=compactionLevel,proto3" json:"compaction_level,omitempty"`
 	SourceBlocks    []string `protobuf:"bytes,5,rep,name=source_blocks,json=sourceBlocks,proto3" json:"source_blocks,omitempty"`
 	// Objects to be deleted.
-	Tombstones    []*v1.Tombstones `protobuf:"bytes,6,rep,name=tombstones,proto3" json:"tombstones,omitempty"`
+	Tombstones []*v1.Tombstones `protobuf:"bytes,6,rep,name=tombstones,proto3" json:"tombstones,omitempty"`
+	// Series to be removed from the compacted blocks. The field is
+	// only populated for the assigned jobs and is never persisted.
+	SeriesTombstones []*v1.SeriesTombstone `protobuf:"bytes,7,rep,name=series_tombstones,json=seriesTombstones,proto3" json:"series_tombstones,omitempty"`
+	// Shard the source blocks are moved to. Zero, if the job
+	// does not move the blocks between shards.
+	TargetShard uint32 `protobuf:"varint,8,opt,name=target_shard,json=targetShard,proto3" json:"target_shard,omitempty"`
+	// If set, the source blocks are rewritten in place:
+	// the shard and the compaction level are preserved.
+	Rewrite bool `protobuf:"varint,9,opt,name=rewrite,proto3" json:"rewrite,omitempty"`
+	// If set, the compacted blocks include downsampled
+	// datasets, regardless of the age of the data.
+	Downsample bool `protobuf:"varint,10,opt,name=downsample,proto3" json:"downsample,omitempty"`
+	// If set, the compacted blocks are written
+	// to the cold storage tier.
+	ColdStorage   bool `protobuf:"varint,11,opt,name=cold_storage,json=coldStorage,proto3" json:"cold_storage,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -832,6 +856,41 @@ func (x *CompactionJobPlan) GetTombstones() []*v1.Tombstones {
 	return nil
 }
 
+func (x *CompactionJobPlan) GetSeriesTombstones() []*v1.SeriesTombstone {
+	if x != nil {
+		return x.SeriesTombstones
+	}
+	return nil
+}
+
+func (x *CompactionJobPlan) GetTargetShard() uint32 {
+	if x != nil {
+		return x.TargetShard
+	}
+	return 0
+}
+
+func (x *CompactionJobPlan) GetRewrite() bool {
+	if x != nil {
+		return x.Rewrite
+	}
+	return false
+}
+
+func (x *CompactionJobPlan) GetDownsample() bool {
+	if x != nil {
+		return x.Downsample
+	}
+	return false
+}
+
+func (x *CompactionJobPlan) GetColdStorage() bool {
+	if x != nil {
+		return x.ColdStorage
+	}
+	return false
+}
+
 // UpdateCompactionPlanRequest proposes compaction plan changes.
 type UpdateCompactionPlanRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -1017,6 +1076,274 @@ func (*TruncateIndexResponse) Descriptor() ([]byte, []int) {
 	return file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP(), []int{16}
 }
 
+// AddReshardJobsRequest proposes jobs that move blocks
+// of a tenant to the shards of its current placement.
+type AddReshardJobsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Jobs          []*CompactionJobPlan   `protobuf:"bytes,2,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddReshardJobsRequest) Reset() {
+	*x = AddReshardJobsRequest{}
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddReshardJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddReshardJobsRequest) ProtoMessage() {}
+
+func (x *AddReshardJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddReshardJobsRequest.ProtoReflect.Descriptor instead.
+func (*AddReshardJobsRequest) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AddReshardJobsRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AddReshardJobsRequest) GetJobs() []*CompactionJobPlan {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type AddReshardJobsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddReshardJobsResponse) Reset() {
+	*x = AddReshardJobsResponse{}
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddReshardJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddReshardJobsResponse) ProtoMessage() {}
+
+func (x *AddReshardJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddReshardJobsResponse.ProtoReflect.Descriptor instead.
+func (*AddReshardJobsResponse) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP(), []int{18}
+}
+
+// AddRewriteJobsRequest proposes jobs that rewrite blocks in place,
+// e.g., to drop the data that has passed the retention period.
+type AddRewriteJobsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Jobs          []*CompactionJobPlan   `protobuf:"bytes,2,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddRewriteJobsRequest) Reset() {
+	*x = AddRewriteJobsRequest{}
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddRewriteJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddRewriteJobsRequest) ProtoMessage() {}
+
+func (x *AddRewriteJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddRewriteJobsRequest.ProtoReflect.Descriptor instead.
+func (*AddRewriteJobsRequest) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AddRewriteJobsRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AddRewriteJobsRequest) GetJobs() []*CompactionJobPlan {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type AddRewriteJobsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddRewriteJobsResponse) Reset() {
+	*x = AddRewriteJobsResponse{}
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddRewriteJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddRewriteJobsResponse) ProtoMessage() {}
+
+func (x *AddRewriteJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddRewriteJobsResponse.ProtoReflect.Descriptor instead.
+func (*AddRewriteJobsResponse) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP(), []int{20}
+}
+
+type DeleteSeriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tombstone     *v1.SeriesTombstone    `protobuf:"bytes,1,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSeriesRequest) Reset() {
+	*x = DeleteSeriesRequest{}
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSeriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSeriesRequest) ProtoMessage() {}
+
+func (x *DeleteSeriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSeriesRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSeriesRequest) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeleteSeriesRequest) GetTombstone() *v1.SeriesTombstone {
+	if x != nil {
+		return x.Tombstone
+	}
+	return nil
+}
+
+type DeleteSeriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tombstone     *v1.SeriesTombstone    `protobuf:"bytes,1,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSeriesResponse) Reset() {
+	*x = DeleteSeriesResponse{}
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSeriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSeriesResponse) ProtoMessage() {}
+
+func (x *DeleteSeriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_raft_log_raft_log_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSeriesResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSeriesResponse) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteSeriesResponse) GetTombstone() *v1.SeriesTombstone {
+	if x != nil {
+		return x.Tombstone
+	}
+	return nil
+}
+
 var File_metastore_v1_raft_log_raft_log_proto protoreflect.FileDescriptor
 
 const file_metastore_v1_raft_log_raft_log_proto_rawDesc = "" +
@@ -1062,7 +1389,7 @@ const file_metastore_v1_raft_log_raft_log_proto_rawDesc = "" +
 	"\x05token\x18\x04 \x01(\x04R\x05token\x12(\n" +
 	"\x10lease_expires_at\x18\x05 \x01(\x03R\x0eleaseExpiresAt\x12\x19\n" +
 	"\badded_at\x18\x06 \x01(\x03R\aaddedAt\x12\x1a\n" +
-	"\bfailures\x18\a \x01(\rR\bfailures\"\xdf\x01\n" +
+	"\bfailures\x18\a \x01(\rR\bfailures\"\xab\x03\n" +
 	"\x11CompactionJobPlan\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
 	"\x06tenant\x18\x02 \x01(\tR\x06tenant\x12\x14\n" +
@@ -1071,7 +1398,15 @@ const file_metastore_v1_raft_log_raft_log_proto_rawDesc = "" +
 	"\rsource_blocks\x18\x05 \x03(\tR\fsourceBlocks\x128\n" +
 	"\n" +
 	"tombstones\x18\x06 \x03(\v2\x18.metastore.v1.TombstonesR\n" +
-	"tombstones\"r\n" +
+	"tombstones\x12J\n" +
+	"\x11series_tombstones\x18\a \x03(\v2\x1d.metastore.v1.SeriesTombstoneR\x10seriesTombstones\x12!\n" +
+	"\ftarget_shard\x18\b \x01(\rR\vtargetShard\x12\x18\n" +
+	"\arewrite\x18\t \x01(\bR\arewrite\x12\x1e\n" +
+	"\n" +
+	"downsample\x18\n" +
+	" \x01(\bR\n" +
+	"downsample\x12!\n" +
+	"\fcold_storage\x18\v \x01(\bR\vcoldStorage\"r\n" +
 	"\x1bUpdateCompactionPlanRequest\x12\x12\n" +
 	"\x04term\x18\x01 \x01(\x04R\x04term\x12?\n" +
 	"\vplan_update\x18\x02 \x01(\v2\x1e.raft_log.CompactionPlanUpdateR\n" +
@@ -1084,14 +1419,29 @@ const file_metastore_v1_raft_log_raft_log_proto_rawDesc = "" +
 	"\n" +
 	"tombstones\x18\x02 \x03(\v2\x18.metastore.v1.TombstonesR\n" +
 	"tombstones\"\x17\n" +
-	"\x15TruncateIndexResponse*\xc3\x01\n" +
+	"\x15TruncateIndexResponse\"\\\n" +
+	"\x15AddReshardJobsRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12/\n" +
+	"\x04jobs\x18\x02 \x03(\v2\x1b.raft_log.CompactionJobPlanR\x04jobs\"\x18\n" +
+	"\x16AddReshardJobsResponse\"\\\n" +
+	"\x15AddRewriteJobsRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12/\n" +
+	"\x04jobs\x18\x02 \x03(\v2\x1b.raft_log.CompactionJobPlanR\x04jobs\"\x18\n" +
+	"\x16AddRewriteJobsResponse\"R\n" +
+	"\x13DeleteSeriesRequest\x12;\n" +
+	"\ttombstone\x18\x01 \x01(\v2\x1d.metastore.v1.SeriesTombstoneR\ttombstone\"S\n" +
+	"\x14DeleteSeriesResponse\x12;\n" +
+	"\ttombstone\x18\x01 \x01(\v2\x1d.metastore.v1.SeriesTombstoneR\ttombstone*\xa9\x02\n" +
 	"\vRaftCommand\x12\x18\n" +
 	"\x14RAFT_COMMAND_UNKNOWN\x10\x00\x12#\n" +
 	"\x1fRAFT_COMMAND_ADD_BLOCK_METADATA\x10\x01\x12+\n" +
 	"'RAFT_COMMAND_GET_COMPACTION_PLAN_UPDATE\x10\x02\x12'\n" +
 	"#RAFT_COMMAND_UPDATE_COMPACTION_PLAN\x10\x03\x12\x1f\n" +
-	"\x1bRAFT_COMMAND_TRUNCATE_INDEX\x10\x04B\x9d\x01\n" +
-	"\fcom.raft_logB\fRaftLogProtoP\x01ZCgithub.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log\xa2\x02\x03RXX\xaa\x02\aRaftLog\xca\x02\aRaftLog\xe2\x02\x13RaftLog\\GPBMetadata\xea\x02\aRaftLogb\x06proto3"
+	"\x1bRAFT_COMMAND_TRUNCATE_INDEX\x10\x04\x12\x1e\n" +
+	"\x1aRAFT_COMMAND_DELETE_SERIES\x10\x05\x12!\n" +
+	"\x1dRAFT_COMMAND_ADD_RESHARD_JOBS\x10\x06\x12!\n" +
+	"\x1dRAFT_COMMAND_ADD_REWRITE_JOBS\x10\aB\xac\x01\n" +
+	"\fcom.raft_logB\fRaftLogProtoP\x01ZNgithub.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log;v1raft_log\xa2\x02\x03RXX\xaa\x02\bRaft_log\xca\x02\bRaft_log\xe2\x02\x14Raft_log\\GPBMetadata\xea\x02\bRaft_logb\x06proto3"
 
 var (
 	file_metastore_v1_raft_log_raft_log_proto_rawDescOnce sync.Once
@@ -1106,7 +1456,7 @@ func file_metastore_v1_raft_log_raft_log_proto_rawDescGZIP() []byte {
 }
 
 var file_metastore_v1_raft_log_raft_log_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_metastore_v1_raft_log_raft_log_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_metastore_v1_raft_log_raft_log_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_metastore_v1_raft_log_raft_log_proto_goTypes = []any{
 	(RaftCommand)(0),                        // 0: raft_log.RaftCommand
 	(*AddBlockMetadataRequest)(nil),         // 1: raft_log.AddBlockMetadataRequest
@@ -1126,15 +1476,22 @@ var file_metastore_v1_raft_log_raft_log_proto_goTypes = []any{
 	(*UpdateCompactionPlanResponse)(nil),    // 15: raft_log.UpdateCompactionPlanResponse
 	(*TruncateIndexRequest)(nil),            // 16: raft_log.TruncateIndexRequest
 	(*TruncateIndexResponse)(nil),           // 17: raft_log.TruncateIndexResponse
-	(*v1.BlockMeta)(nil),                    // 18: metastore.v1.BlockMeta
-	(v1.CompactionJobStatus)(0),             // 19: metastore.v1.CompactionJobStatus
-	(*v1.CompactedBlocks)(nil),              // 20: metastore.v1.CompactedBlocks
-	(*v1.Tombstones)(nil),                   // 21: metastore.v1.Tombstones
+	(*AddReshardJobsRequest)(nil),           // 18: raft_log.AddReshardJobsRequest
+	(*AddReshardJobsResponse)(nil),          // 19: raft_log.AddReshardJobsResponse
+	(*AddRewriteJobsRequest)(nil),           // 20: raft_log.AddRewriteJobsRequest
+	(*AddRewriteJobsResponse)(nil),          // 21: raft_log.AddRewriteJobsResponse
+	(*DeleteSeriesRequest)(nil),             // 22: raft_log.DeleteSeriesRequest
+	(*DeleteSeriesResponse)(nil),            // 23: raft_log.DeleteSeriesResponse
+	(*v1.BlockMeta)(nil),                    // 24: metastore.v1.BlockMeta
+	(v1.CompactionJobStatus)(0),             // 25: metastore.v1.CompactionJobStatus
+	(*v1.CompactedBlocks)(nil),              // 26: metastore.v1.CompactedBlocks
+	(*v1.Tombstones)(nil),                   // 27: metastore.v1.Tombstones
+	(*v1.SeriesTombstone)(nil),              // 28: metastore.v1.SeriesTombstone
 }
 var file_metastore_v1_raft_log_raft_log_proto_depIdxs = []int32{
-	18, // 0: raft_log.AddBlockMetadataRequest.metadata:type_name -> metastore.v1.BlockMeta
+	24, // 0: raft_log.AddBlockMetadataRequest.metadata:type_name -> metastore.v1.BlockMeta
 	4,  // 1: raft_log.GetCompactionPlanUpdateRequest.status_updates:type_name -> raft_log.CompactionJobStatusUpdate
-	19, // 2: raft_log.CompactionJobStatusUpdate.status:type_name -> metastore.v1.CompactionJobStatus
+	25, // 2: raft_log.CompactionJobStatusUpdate.status:type_name -> metastore.v1.CompactionJobStatus
 	6,  // 3: raft_log.GetCompactionPlanUpdateResponse.plan_update:type_name -> raft_log.CompactionPlanUpdate
 	7,  // 4: raft_log.CompactionPlanUpdate.new_jobs:type_name -> raft_log.NewCompactionJob
 	8,  // 5: raft_log.CompactionPlanUpdate.assigned_jobs:type_name -> raft_log.AssignedCompactionJob
@@ -1147,18 +1504,23 @@ var file_metastore_v1_raft_log_raft_log_proto_depIdxs = []int32{
 	13, // 12: raft_log.AssignedCompactionJob.plan:type_name -> raft_log.CompactionJobPlan
 	12, // 13: raft_log.UpdatedCompactionJob.state:type_name -> raft_log.CompactionJobState
 	12, // 14: raft_log.CompletedCompactionJob.state:type_name -> raft_log.CompactionJobState
-	20, // 15: raft_log.CompletedCompactionJob.compacted_blocks:type_name -> metastore.v1.CompactedBlocks
+	26, // 15: raft_log.CompletedCompactionJob.compacted_blocks:type_name -> metastore.v1.CompactedBlocks
 	12, // 16: raft_log.EvictedCompactionJob.state:type_name -> raft_log.CompactionJobState
-	19, // 17: raft_log.CompactionJobState.status:type_name -> metastore.v1.CompactionJobStatus
-	21, // 18: raft_log.CompactionJobPlan.tombstones:type_name -> metastore.v1.Tombstones
-	6,  // 19: raft_log.UpdateCompactionPlanRequest.plan_update:type_name -> raft_log.CompactionPlanUpdate
-	6,  // 20: raft_log.UpdateCompactionPlanResponse.plan_update:type_name -> raft_log.CompactionPlanUpdate
-	21, // 21: raft_log.TruncateIndexRequest.tombstones:type_name -> metastore.v1.Tombstones
-	22, // [22:22] is the sub-list for method output_type
-	22, // [22:22] is the sub-list for method input_type
-	22, // [22:22] is the sub-list for extension type_name
-	22, // [22:22] is the sub-list for extension extendee
-	0,  // [0:22] is the sub-list for field type_name
+	25, // 17: raft_log.CompactionJobState.status:type_name -> metastore.v1.CompactionJobStatus
+	27, // 18: raft_log.CompactionJobPlan.tombstones:type_name -> metastore.v1.Tombstones
+	28, // 19: raft_log.CompactionJobPlan.series_tombstones:type_name -> metastore.v1.SeriesTombstone
+	6,  // 20: raft_log.UpdateCompactionPlanRequest.plan_update:type_name -> raft_log.CompactionPlanUpdate
+	6,  // 21: raft_log.UpdateCompactionPlanResponse.plan_update:type_name -> raft_log.CompactionPlanUpdate
+	27, // 22: raft_log.TruncateIndexRequest.tombstones:type_name -> metastore.v1.Tombstones
+	13, // 23: raft_log.AddReshardJobsRequest.jobs:type_name -> raft_log.CompactionJobPlan
+	13, // 24: raft_log.AddRewriteJobsRequest.jobs:type_name -> raft_log.CompactionJobPlan
+	28, // 25: raft_log.DeleteSeriesRequest.tombstone:type_name -> metastore.v1.SeriesTombstone
+	28, // 26: raft_log.DeleteSeriesResponse.tombstone:type_name -> metastore.v1.SeriesTombstone
+	27, // [27:27] is the sub-list for method output_type
+	27, // [27:27] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
 }
 
 func init() { file_metastore_v1_raft_log_raft_log_proto_init() }
@@ -1172,7 +1534,7 @@ func file_metastore_v1_raft_log_raft_log_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metastore_v1_raft_log_raft_log_proto_rawDesc), len(file_metastore_v1_raft_log_raft_log_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   17,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -332,11 +332,126 @@ func (*DeleteTenantResponse) Descriptor() ([]byte, []int) {
 	return file_metastore_v1_tenant_proto_rawDescGZIP(), []int{6}
 }
 
+type DeleteSeriesRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	TenantId string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// Label selector of the series to delete, e.g. '{service_name="foo"}'.
+	LabelSelector string `protobuf:"bytes,2,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	// Milliseconds since epoch.
+	StartTime int64 `protobuf:"varint,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// Milliseconds since epoch.
+	EndTime       int64 `protobuf:"varint,4,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSeriesRequest) Reset() {
+	*x = DeleteSeriesRequest{}
+	mi := &file_metastore_v1_tenant_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSeriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSeriesRequest) ProtoMessage() {}
+
+func (x *DeleteSeriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_tenant_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSeriesRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSeriesRequest) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_tenant_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteSeriesRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *DeleteSeriesRequest) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *DeleteSeriesRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *DeleteSeriesRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+type DeleteSeriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tombstone     *SeriesTombstone       `protobuf:"bytes,1,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSeriesResponse) Reset() {
+	*x = DeleteSeriesResponse{}
+	mi := &file_metastore_v1_tenant_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSeriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSeriesResponse) ProtoMessage() {}
+
+func (x *DeleteSeriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metastore_v1_tenant_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSeriesResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSeriesResponse) Descriptor() ([]byte, []int) {
+	return file_metastore_v1_tenant_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteSeriesResponse) GetTombstone() *SeriesTombstone {
+	if x != nil {
+		return x.Tombstone
+	}
+	return nil
+}
+
 var File_metastore_v1_tenant_proto protoreflect.FileDescriptor
 
 const file_metastore_v1_tenant_proto_rawDesc = "" +
 	"\n" +
-	"\x19metastore/v1/tenant.proto\x12\fmetastore.v1\"\x13\n" +
+	"\x19metastore/v1/tenant.proto\x12\fmetastore.v1\x1a\x1cmetastore/v1/compactor.proto\"\x13\n" +
 	"\x11GetTenantsRequest\"3\n" +
 	"\x12GetTenantsResponse\x12\x1d\n" +
 	"\n" +
@@ -351,12 +466,21 @@ const file_metastore_v1_tenant_proto_rawDesc = "" +
 	"\x13newest_profile_time\x18\x03 \x01(\x03R\x11newestProfileTime\"2\n" +
 	"\x13DeleteTenantRequest\x12\x1b\n" +
 	"\ttenant_id\x18\x01 \x01(\tR\btenantId\"\x16\n" +
-	"\x14DeleteTenantResponse2\x8b\x02\n" +
+	"\x14DeleteTenantResponse\"\x93\x01\n" +
+	"\x13DeleteSeriesRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12%\n" +
+	"\x0elabel_selector\x18\x02 \x01(\tR\rlabelSelector\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x03 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x04 \x01(\x03R\aendTime\"S\n" +
+	"\x14DeleteSeriesResponse\x12;\n" +
+	"\ttombstone\x18\x01 \x01(\v2\x1d.metastore.v1.SeriesTombstoneR\ttombstone2\xe4\x02\n" +
 	"\rTenantService\x12Q\n" +
 	"\n" +
 	"GetTenants\x12\x1f.metastore.v1.GetTenantsRequest\x1a .metastore.v1.GetTenantsResponse\"\x00\x12N\n" +
 	"\tGetTenant\x12\x1e.metastore.v1.GetTenantRequest\x1a\x1f.metastore.v1.GetTenantResponse\"\x00\x12W\n" +
-	"\fDeleteTenant\x12!.metastore.v1.DeleteTenantRequest\x1a\".metastore.v1.DeleteTenantResponse\"\x00B\xb8\x01\n" +
+	"\fDeleteTenant\x12!.metastore.v1.DeleteTenantRequest\x1a\".metastore.v1.DeleteTenantResponse\"\x00\x12W\n" +
+	"\fDeleteSeries\x12!.metastore.v1.DeleteSeriesRequest\x1a\".metastore.v1.DeleteSeriesResponse\"\x00B\xb8\x01\n" +
 	"\x10com.metastore.v1B\vTenantProtoP\x01ZFgithub.com/grafana/pyroscope/api/gen/proto/go/metastore/v1;metastorev1\xa2\x02\x03MXX\xaa\x02\fMetastore.V1\xca\x02\fMetastore\\V1\xe2\x02\x18Metastore\\V1\\GPBMetadata\xea\x02\rMetastore::V1b\x06proto3"
 
 var (
@@ -371,7 +495,7 @@ func file_metastore_v1_tenant_proto_rawDescGZIP() []byte {
 	return file_metastore_v1_tenant_proto_rawDescData
 }
 
-var file_metastore_v1_tenant_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_metastore_v1_tenant_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_metastore_v1_tenant_proto_goTypes = []any{
 	(*GetTenantsRequest)(nil),    // 0: metastore.v1.GetTenantsRequest
 	(*GetTenantsResponse)(nil),   // 1: metastore.v1.GetTenantsResponse
@@ -380,20 +504,26 @@ var file_metastore_v1_tenant_proto_goTypes = []any{
 	(*TenantStats)(nil),          // 4: metastore.v1.TenantStats
 	(*DeleteTenantRequest)(nil),  // 5: metastore.v1.DeleteTenantRequest
 	(*DeleteTenantResponse)(nil), // 6: metastore.v1.DeleteTenantResponse
+	(*DeleteSeriesRequest)(nil),  // 7: metastore.v1.DeleteSeriesRequest
+	(*DeleteSeriesResponse)(nil), // 8: metastore.v1.DeleteSeriesResponse
+	(*SeriesTombstone)(nil),      // 9: metastore.v1.SeriesTombstone
 }
 var file_metastore_v1_tenant_proto_depIdxs = []int32{
 	4, // 0: metastore.v1.GetTenantResponse.stats:type_name -> metastore.v1.TenantStats
-	0, // 1: metastore.v1.TenantService.GetTenants:input_type -> metastore.v1.GetTenantsRequest
-	2, // 2: metastore.v1.TenantService.GetTenant:input_type -> metastore.v1.GetTenantRequest
-	5, // 3: metastore.v1.TenantService.DeleteTenant:input_type -> metastore.v1.DeleteTenantRequest
-	1, // 4: metastore.v1.TenantService.GetTenants:output_type -> metastore.v1.GetTenantsResponse
-	3, // 5: metastore.v1.TenantService.GetTenant:output_type -> metastore.v1.GetTenantResponse
-	6, // 6: metastore.v1.TenantService.DeleteTenant:output_type -> metastore.v1.DeleteTenantResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	9, // 1: metastore.v1.DeleteSeriesResponse.tombstone:type_name -> metastore.v1.SeriesTombstone
+	0, // 2: metastore.v1.TenantService.GetTenants:input_type -> metastore.v1.GetTenantsRequest
+	2, // 3: metastore.v1.TenantService.GetTenant:input_type -> metastore.v1.GetTenantRequest
+	5, // 4: metastore.v1.TenantService.DeleteTenant:input_type -> metastore.v1.DeleteTenantRequest
+	7, // 5: metastore.v1.TenantService.DeleteSeries:input_type -> metastore.v1.DeleteSeriesRequest
+	1, // 6: metastore.v1.TenantService.GetTenants:output_type -> metastore.v1.GetTenantsResponse
+	3, // 7: metastore.v1.TenantService.GetTenant:output_type -> metastore.v1.GetTenantResponse
+	6, // 8: metastore.v1.TenantService.DeleteTenant:output_type -> metastore.v1.DeleteTenantResponse
+	8, // 9: metastore.v1.TenantService.DeleteSeries:output_type -> metastore.v1.DeleteSeriesResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_metastore_v1_tenant_proto_init() }
@@ -401,13 +531,14 @@ func file_metastore_v1_tenant_proto_init() {
 	if File_metastore_v1_tenant_proto != nil {
 		return
 	}
+	file_metastore_v1_compactor_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metastore_v1_tenant_proto_rawDesc), len(file_metastore_v1_tenant_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
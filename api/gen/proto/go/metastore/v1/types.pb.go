@@ -21,6 +21,52 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type StorageTier int32
+
+const (
+	StorageTier_STORAGE_TIER_HOT  StorageTier = 0
+	StorageTier_STORAGE_TIER_COLD StorageTier = 1
+)
+
+// Enum value maps for StorageTier.
+var (
+	StorageTier_name = map[int32]string{
+		0: "STORAGE_TIER_HOT",
+		1: "STORAGE_TIER_COLD",
+	}
+	StorageTier_value = map[string]int32{
+		"STORAGE_TIER_HOT":  0,
+		"STORAGE_TIER_COLD": 1,
+	}
+)
+
+func (x StorageTier) Enum() *StorageTier {
+	p := new(StorageTier)
+	*p = x
+	return p
+}
+
+func (x StorageTier) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StorageTier) Descriptor() protoreflect.EnumDescriptor {
+	return file_metastore_v1_types_proto_enumTypes[0].Descriptor()
+}
+
+func (StorageTier) Type() protoreflect.EnumType {
+	return &file_metastore_v1_types_proto_enumTypes[0]
+}
+
+func (x StorageTier) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StorageTier.Descriptor instead.
+func (StorageTier) EnumDescriptor() ([]byte, []int) {
+	return file_metastore_v1_types_proto_rawDescGZIP(), []int{0}
+}
+
 // BlockMeta is a metadata entry that describes the block's contents. A block
 // is a collection of datasets that share certain properties, such as shard ID,
 // compaction level, tenant ID, time range, creation time, and more.
@@ -48,9 +94,19 @@ type BlockMeta struct {
 	Datasets        []*Dataset `protobuf:"bytes,10,rep,name=datasets,proto3" json:"datasets,omitempty"`
 	// String table contains strings of the block.
 	// By convention, the first string is always an empty string.
-	StringTable   []string `protobuf:"bytes,11,rep,name=string_table,json=stringTable,proto3" json:"string_table,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	StringTable []string `protobuf:"bytes,11,rep,name=string_table,json=stringTable,proto3" json:"string_table,omitempty"`
+	// Storage tier the block object is stored in.
+	StorageTier StorageTier `protobuf:"varint,13,opt,name=storage_tier,json=storageTier,proto3,enum=metastore.v1.StorageTier" json:"storage_tier,omitempty"`
+	// Non-zero replica index marks a segment that holds a copy of the
+	// data written to the primary replica by a replicated push. Such
+	// segments are not queried: the duplicates are removed at compaction.
+	Replica uint32 `protobuf:"varint,14,opt,name=replica,proto3" json:"replica,omitempty"`
+	// End of the replication window of the segment, in milliseconds since
+	// epoch. The segments of all the replicas written within the window
+	// are compacted together. 0 if the segment holds no replicated pushes.
+	ReplicationWindow int64 `protobuf:"varint,15,opt,name=replication_window,json=replicationWindow,proto3" json:"replication_window,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *BlockMeta) Reset() {
@@ -167,6 +223,27 @@ func (x *BlockMeta) GetStringTable() []string {
 	return nil
 }
 
+func (x *BlockMeta) GetStorageTier() StorageTier {
+	if x != nil {
+		return x.StorageTier
+	}
+	return StorageTier_STORAGE_TIER_HOT
+}
+
+func (x *BlockMeta) GetReplica() uint32 {
+	if x != nil {
+		return x.Replica
+	}
+	return 0
+}
+
+func (x *BlockMeta) GetReplicationWindow() int64 {
+	if x != nil {
+		return x.ReplicationWindow
+	}
+	return 0
+}
+
 type Dataset struct {
 	state   protoimpl.MessageState `protogen:"open.v1"`
 	Format  uint32                 `protobuf:"varint,9,opt,name=format,proto3" json:"format,omitempty"`
@@ -181,12 +258,12 @@ type Dataset struct {
 	// to the format.
 	//
 	// By default (format 0), the sections are:
-	//   - 0: profiles.parquet
-	//   - 1: index.tsdb
-	//   - 2: symbols.symdb
+	//  - 0: profiles.parquet
+	//  - 1: index.tsdb
+	//  - 2: symbols.symdb
 	//
 	// Format 1 corresponds to the tenant-wide index:
-	//   - 0: index.tsdb (dataset index)
+	//  - 0: index.tsdb (dataset index)
 	TableOfContents []uint64 `protobuf:"varint,5,rep,packed,name=table_of_contents,json=tableOfContents,proto3" json:"table_of_contents,omitempty"`
 	// Size of the dataset in bytes.
 	Size uint64 `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
@@ -396,7 +473,7 @@ var File_metastore_v1_types_proto protoreflect.FileDescriptor
 
 const file_metastore_v1_types_proto_rawDesc = "" +
 	"\n" +
-	"\x18metastore/v1/types.proto\x12\fmetastore.v1\"\x83\x03\n" +
+	"\x18metastore/v1/types.proto\x12\fmetastore.v1\"\x8a\x04\n" +
 	"\tBlockMeta\x12%\n" +
 	"\x0eformat_version\x18\x01 \x01(\rR\rformatVersion\x12\x0e\n" +
 	"\x02id\x18\x02 \x01(\tR\x02id\x12\x16\n" +
@@ -411,7 +488,10 @@ const file_metastore_v1_types_proto_rawDesc = "" +
 	"\x04size\x18\t \x01(\x04R\x04size\x121\n" +
 	"\bdatasets\x18\n" +
 	" \x03(\v2\x15.metastore.v1.DatasetR\bdatasets\x12!\n" +
-	"\fstring_table\x18\v \x03(\tR\vstringTable\"\xe1\x01\n" +
+	"\fstring_table\x18\v \x03(\tR\vstringTable\x12<\n" +
+	"\fstorage_tier\x18\r \x01(\x0e2\x19.metastore.v1.StorageTierR\vstorageTier\x12\x18\n" +
+	"\areplica\x18\x0e \x01(\rR\areplica\x12-\n" +
+	"\x12replication_window\x18\x0f \x01(\x03R\x11replicationWindow\"\xe1\x01\n" +
 	"\aDataset\x12\x16\n" +
 	"\x06format\x18\t \x01(\rR\x06format\x12\x16\n" +
 	"\x06tenant\x18\x01 \x01(\x05R\x06tenant\x12\x12\n" +
@@ -424,7 +504,10 @@ const file_metastore_v1_types_proto_rawDesc = "" +
 	"\tBlockList\x12\x16\n" +
 	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x14\n" +
 	"\x05shard\x18\x02 \x01(\rR\x05shard\x12\x16\n" +
-	"\x06blocks\x18\x03 \x03(\tR\x06blocksB\xb7\x01\n" +
+	"\x06blocks\x18\x03 \x03(\tR\x06blocks*:\n" +
+	"\vStorageTier\x12\x14\n" +
+	"\x10STORAGE_TIER_HOT\x10\x00\x12\x15\n" +
+	"\x11STORAGE_TIER_COLD\x10\x01B\xb7\x01\n" +
 	"\x10com.metastore.v1B\n" +
 	"TypesProtoP\x01ZFgithub.com/grafana/pyroscope/api/gen/proto/go/metastore/v1;metastorev1\xa2\x02\x03MXX\xaa\x02\fMetastore.V1\xca\x02\fMetastore\\V1\xe2\x02\x18Metastore\\V1\\GPBMetadata\xea\x02\rMetastore::V1b\x06proto3"
 
@@ -440,19 +523,22 @@ func file_metastore_v1_types_proto_rawDescGZIP() []byte {
 	return file_metastore_v1_types_proto_rawDescData
 }
 
+var file_metastore_v1_types_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
 var file_metastore_v1_types_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_metastore_v1_types_proto_goTypes = []any{
-	(*BlockMeta)(nil), // 0: metastore.v1.BlockMeta
-	(*Dataset)(nil),   // 1: metastore.v1.Dataset
-	(*BlockList)(nil), // 2: metastore.v1.BlockList
+	(StorageTier)(0),  // 0: metastore.v1.StorageTier
+	(*BlockMeta)(nil), // 1: metastore.v1.BlockMeta
+	(*Dataset)(nil),   // 2: metastore.v1.Dataset
+	(*BlockList)(nil), // 3: metastore.v1.BlockList
 }
 var file_metastore_v1_types_proto_depIdxs = []int32{
-	1, // 0: metastore.v1.BlockMeta.datasets:type_name -> metastore.v1.Dataset
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2, // 0: metastore.v1.BlockMeta.datasets:type_name -> metastore.v1.Dataset
+	0, // 1: metastore.v1.BlockMeta.storage_tier:type_name -> metastore.v1.StorageTier
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_metastore_v1_types_proto_init() }
@@ -465,13 +551,14 @@ func file_metastore_v1_types_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metastore_v1_types_proto_rawDesc), len(file_metastore_v1_types_proto_rawDesc)),
-			NumEnums:      0,
+			NumEnums:      1,
 			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_metastore_v1_types_proto_goTypes,
 		DependencyIndexes: file_metastore_v1_types_proto_depIdxs,
+		EnumInfos:         file_metastore_v1_types_proto_enumTypes,
 		MessageInfos:      file_metastore_v1_types_proto_msgTypes,
 	}.Build()
 	File_metastore_v1_types_proto = out.File
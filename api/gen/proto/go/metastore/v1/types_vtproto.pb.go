@@ -34,6 +34,9 @@ func (m *BlockMeta) CloneVT() *BlockMeta {
 	r.CreatedBy = m.CreatedBy
 	r.MetadataOffset = m.MetadataOffset
 	r.Size = m.Size
+	r.StorageTier = m.StorageTier
+	r.Replica = m.Replica
+	r.ReplicationWindow = m.ReplicationWindow
 	if rhs := m.Datasets; rhs != nil {
 		tmpContainer := make([]*Dataset, len(rhs))
 		for k, v := range rhs {
@@ -174,6 +177,15 @@ func (this *BlockMeta) EqualVT(that *BlockMeta) bool {
 	if this.MetadataOffset != that.MetadataOffset {
 		return false
 	}
+	if this.StorageTier != that.StorageTier {
+		return false
+	}
+	if this.Replica != that.Replica {
+		return false
+	}
+	if this.ReplicationWindow != that.ReplicationWindow {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -297,6 +309,21 @@ func (m *BlockMeta) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.ReplicationWindow != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.ReplicationWindow))
+		i--
+		dAtA[i] = 0x78
+	}
+	if m.Replica != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.Replica))
+		i--
+		dAtA[i] = 0x70
+	}
+	if m.StorageTier != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.StorageTier))
+		i--
+		dAtA[i] = 0x68
+	}
 	if m.MetadataOffset != 0 {
 		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.MetadataOffset))
 		i--
@@ -580,6 +607,15 @@ func (m *BlockMeta) SizeVT() (n int) {
 	if m.MetadataOffset != 0 {
 		n += 1 + protohelpers.SizeOfVarint(uint64(m.MetadataOffset))
 	}
+	if m.StorageTier != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.StorageTier))
+	}
+	if m.Replica != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.Replica))
+	}
+	if m.ReplicationWindow != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.ReplicationWindow))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -947,6 +983,63 @@ func (m *BlockMeta) UnmarshalVT(dAtA []byte) error {
 					break
 				}
 			}
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StorageTier", wireType)
+			}
+			m.StorageTier = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StorageTier |= StorageTier(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+			}
+			m.Replica = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Replica |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicationWindow", wireType)
+			}
+			m.ReplicationWindow = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReplicationWindow |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
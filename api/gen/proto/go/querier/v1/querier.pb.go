@@ -150,6 +150,7 @@ const (
 	AsyncQueryStatus_ASYNC_QUERY_STATUS_IN_PROGRESS AsyncQueryStatus = 1
 	AsyncQueryStatus_ASYNC_QUERY_STATUS_SUCCESS     AsyncQueryStatus = 2
 	AsyncQueryStatus_ASYNC_QUERY_STATUS_FAILURE     AsyncQueryStatus = 3
+	AsyncQueryStatus_ASYNC_QUERY_STATUS_CANCELED    AsyncQueryStatus = 4
 )
 
 // Enum value maps for AsyncQueryStatus.
@@ -159,12 +160,14 @@ var (
 		1: "ASYNC_QUERY_STATUS_IN_PROGRESS",
 		2: "ASYNC_QUERY_STATUS_SUCCESS",
 		3: "ASYNC_QUERY_STATUS_FAILURE",
+		4: "ASYNC_QUERY_STATUS_CANCELED",
 	}
 	AsyncQueryStatus_value = map[string]int32{
 		"ASYNC_QUERY_STATUS_UNKNOWN":     0,
 		"ASYNC_QUERY_STATUS_IN_PROGRESS": 1,
 		"ASYNC_QUERY_STATUS_SUCCESS":     2,
 		"ASYNC_QUERY_STATUS_FAILURE":     3,
+		"ASYNC_QUERY_STATUS_CANCELED":    4,
 	}
 )
 
@@ -488,7 +491,11 @@ type SelectMergeStacktracesRequest struct {
 	// List of trace IDs (32 hex characters, 128-bit) to filter samples by.
 	TraceIdSelector []string `protobuf:"bytes,10,rep,name=trace_id_selector,json=traceIdSelector,proto3" json:"trace_id_selector,omitempty"`
 	// List of span IDs (16 hex characters, 64-bit) to filter samples by.
-	SpanSelector  []string `protobuf:"bytes,11,rep,name=span_selector,json=spanSelector,proto3" json:"span_selector,omitempty"`
+	SpanSelector []string `protobuf:"bytes,11,rep,name=span_selector,json=spanSelector,proto3" json:"span_selector,omitempty"`
+	// (experimental) Fraction of profiles to read, in (0, 1]: the result is
+	// approximated from a deterministic sample of the profiles. If not
+	// specified, all the profiles are read.
+	SamplingRatio *float64 `protobuf:"fixed64,12,opt,name=sampling_ratio,json=samplingRatio,proto3,oneof" json:"sampling_ratio,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -600,6 +607,13 @@ func (x *SelectMergeStacktracesRequest) GetSpanSelector() []string {
 	return nil
 }
 
+func (x *SelectMergeStacktracesRequest) GetSamplingRatio() float64 {
+	if x != nil && x.SamplingRatio != nil {
+		return *x.SamplingRatio
+	}
+	return 0
+}
+
 type SelectMergeStacktracesResponse struct {
 	state      protoimpl.MessageState `protogen:"open.v1"`
 	Flamegraph *FlameGraph            `protobuf:"bytes,1,opt,name=flamegraph,proto3" json:"flamegraph,omitempty"`
@@ -610,7 +624,9 @@ type SelectMergeStacktracesResponse struct {
 	// (experimental) Used for responding to async queries.
 	Async *AsyncQueryResponse `protobuf:"bytes,4,opt,name=async,proto3,oneof" json:"async,omitempty"`
 	// Profile in pprof format.
-	Pprof         *PprofProfile `protobuf:"bytes,5,opt,name=pprof,proto3" json:"pprof,omitempty"`
+	Pprof *PprofProfile `protobuf:"bytes,5,opt,name=pprof,proto3" json:"pprof,omitempty"`
+	// (experimental) Set if the result is approximated from a sample of the profiles.
+	Sampling      *QuerySampling `protobuf:"bytes,6,opt,name=sampling,proto3" json:"sampling,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -680,6 +696,13 @@ func (x *SelectMergeStacktracesResponse) GetPprof() *PprofProfile {
 	return nil
 }
 
+func (x *SelectMergeStacktracesResponse) GetSampling() *QuerySampling {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
 // PprofProfile contains pprof output and related response metadata.
 type PprofProfile struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -842,6 +865,517 @@ func (x *AsyncQueryResponse) GetErrorMessage() string {
 	return ""
 }
 
+type ListAsyncQueriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAsyncQueriesRequest) Reset() {
+	*x = ListAsyncQueriesRequest{}
+	mi := &file_querier_v1_querier_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAsyncQueriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAsyncQueriesRequest) ProtoMessage() {}
+
+func (x *ListAsyncQueriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAsyncQueriesRequest.ProtoReflect.Descriptor instead.
+func (*ListAsyncQueriesRequest) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{9}
+}
+
+type ListAsyncQueriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queries       []*AsyncQueryInfo      `protobuf:"bytes,1,rep,name=queries,proto3" json:"queries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAsyncQueriesResponse) Reset() {
+	*x = ListAsyncQueriesResponse{}
+	mi := &file_querier_v1_querier_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAsyncQueriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAsyncQueriesResponse) ProtoMessage() {}
+
+func (x *ListAsyncQueriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAsyncQueriesResponse.ProtoReflect.Descriptor instead.
+func (*ListAsyncQueriesResponse) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListAsyncQueriesResponse) GetQueries() []*AsyncQueryInfo {
+	if x != nil {
+		return x.Queries
+	}
+	return nil
+}
+
+type AsyncQueryInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Id of the async query.
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Name of the query RPC, e.g. SelectMergeStacktraces.
+	QueryType string `protobuf:"bytes,2,opt,name=query_type,json=queryType,proto3" json:"query_type,omitempty"`
+	// Status of the query: unknown, in_progress, success, failed, canceled
+	Status AsyncQueryStatus `protobuf:"varint,3,opt,name=status,proto3,enum=querier.v1.AsyncQueryStatus" json:"status,omitempty"`
+	// Populated on FAILURE.
+	ErrorMessage string `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// Milliseconds since epoch.
+	CreatedAt     int64 `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AsyncQueryInfo) Reset() {
+	*x = AsyncQueryInfo{}
+	mi := &file_querier_v1_querier_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AsyncQueryInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AsyncQueryInfo) ProtoMessage() {}
+
+func (x *AsyncQueryInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AsyncQueryInfo.ProtoReflect.Descriptor instead.
+func (*AsyncQueryInfo) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AsyncQueryInfo) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *AsyncQueryInfo) GetQueryType() string {
+	if x != nil {
+		return x.QueryType
+	}
+	return ""
+}
+
+func (x *AsyncQueryInfo) GetStatus() AsyncQueryStatus {
+	if x != nil {
+		return x.Status
+	}
+	return AsyncQueryStatus_ASYNC_QUERY_STATUS_UNKNOWN
+}
+
+func (x *AsyncQueryInfo) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *AsyncQueryInfo) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type CancelAsyncQueryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Id of the async query.
+	RequestId     string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelAsyncQueryRequest) Reset() {
+	*x = CancelAsyncQueryRequest{}
+	mi := &file_querier_v1_querier_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelAsyncQueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelAsyncQueryRequest) ProtoMessage() {}
+
+func (x *CancelAsyncQueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelAsyncQueryRequest.ProtoReflect.Descriptor instead.
+func (*CancelAsyncQueryRequest) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CancelAsyncQueryRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type CancelAsyncQueryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Status of the query after the cancellation. A query that has already
+	// completed keeps its status.
+	Status        AsyncQueryStatus `protobuf:"varint,1,opt,name=status,proto3,enum=querier.v1.AsyncQueryStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelAsyncQueryResponse) Reset() {
+	*x = CancelAsyncQueryResponse{}
+	mi := &file_querier_v1_querier_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelAsyncQueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelAsyncQueryResponse) ProtoMessage() {}
+
+func (x *CancelAsyncQueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelAsyncQueryResponse.ProtoReflect.Descriptor instead.
+func (*CancelAsyncQueryResponse) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CancelAsyncQueryResponse) GetStatus() AsyncQueryStatus {
+	if x != nil {
+		return x.Status
+	}
+	return AsyncQueryStatus_ASYNC_QUERY_STATUS_UNKNOWN
+}
+
+// QueryProgress reports how much of a query has been processed.
+type QueryProgress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of blocks processed.
+	BlocksDone int64 `protobuf:"varint,1,opt,name=blocks_done,json=blocksDone,proto3" json:"blocks_done,omitempty"`
+	// Number of blocks the query reads.
+	BlocksTotal   int64 `protobuf:"varint,2,opt,name=blocks_total,json=blocksTotal,proto3" json:"blocks_total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryProgress) Reset() {
+	*x = QueryProgress{}
+	mi := &file_querier_v1_querier_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryProgress) ProtoMessage() {}
+
+func (x *QueryProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryProgress.ProtoReflect.Descriptor instead.
+func (*QueryProgress) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *QueryProgress) GetBlocksDone() int64 {
+	if x != nil {
+		return x.BlocksDone
+	}
+	return 0
+}
+
+func (x *QueryProgress) GetBlocksTotal() int64 {
+	if x != nil {
+		return x.BlocksTotal
+	}
+	return 0
+}
+
+// QuerySampling describes the sample of profiles an approximate query read.
+type QuerySampling struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Fraction of the matching profiles that were read.
+	SampleRate float64 `protobuf:"fixed64,1,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	// Estimated relative standard error of the total value.
+	RelativeError float64 `protobuf:"fixed64,2,opt,name=relative_error,json=relativeError,proto3" json:"relative_error,omitempty"`
+	// Number of profiles read.
+	ProfilesSampled int64 `protobuf:"varint,3,opt,name=profiles_sampled,json=profilesSampled,proto3" json:"profiles_sampled,omitempty"`
+	// Number of profiles matching the query.
+	ProfilesTotal int64 `protobuf:"varint,4,opt,name=profiles_total,json=profilesTotal,proto3" json:"profiles_total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuerySampling) Reset() {
+	*x = QuerySampling{}
+	mi := &file_querier_v1_querier_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuerySampling) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuerySampling) ProtoMessage() {}
+
+func (x *QuerySampling) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuerySampling.ProtoReflect.Descriptor instead.
+func (*QuerySampling) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *QuerySampling) GetSampleRate() float64 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *QuerySampling) GetRelativeError() float64 {
+	if x != nil {
+		return x.RelativeError
+	}
+	return 0
+}
+
+func (x *QuerySampling) GetProfilesSampled() int64 {
+	if x != nil {
+		return x.ProfilesSampled
+	}
+	return 0
+}
+
+func (x *QuerySampling) GetProfilesTotal() int64 {
+	if x != nil {
+		return x.ProfilesTotal
+	}
+	return 0
+}
+
+type SelectMergeStacktracesStreamResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Flamegraph *FlameGraph            `protobuf:"bytes,1,opt,name=flamegraph,proto3" json:"flamegraph,omitempty"`
+	// Pyroscope tree bytes.
+	Tree     []byte         `protobuf:"bytes,2,opt,name=tree,proto3" json:"tree,omitempty"`
+	Progress *QueryProgress `protobuf:"bytes,3,opt,name=progress,proto3" json:"progress,omitempty"`
+	// Set in the last message, which carries the complete results.
+	Final         bool `protobuf:"varint,4,opt,name=final,proto3" json:"final,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelectMergeStacktracesStreamResponse) Reset() {
+	*x = SelectMergeStacktracesStreamResponse{}
+	mi := &file_querier_v1_querier_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelectMergeStacktracesStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelectMergeStacktracesStreamResponse) ProtoMessage() {}
+
+func (x *SelectMergeStacktracesStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelectMergeStacktracesStreamResponse.ProtoReflect.Descriptor instead.
+func (*SelectMergeStacktracesStreamResponse) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SelectMergeStacktracesStreamResponse) GetFlamegraph() *FlameGraph {
+	if x != nil {
+		return x.Flamegraph
+	}
+	return nil
+}
+
+func (x *SelectMergeStacktracesStreamResponse) GetTree() []byte {
+	if x != nil {
+		return x.Tree
+	}
+	return nil
+}
+
+func (x *SelectMergeStacktracesStreamResponse) GetProgress() *QueryProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *SelectMergeStacktracesStreamResponse) GetFinal() bool {
+	if x != nil {
+		return x.Final
+	}
+	return false
+}
+
+type SelectSeriesStreamResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Series   []*v1.Series           `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	Progress *QueryProgress         `protobuf:"bytes,2,opt,name=progress,proto3" json:"progress,omitempty"`
+	// Set in the last message, which carries the complete results.
+	Final         bool `protobuf:"varint,3,opt,name=final,proto3" json:"final,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelectSeriesStreamResponse) Reset() {
+	*x = SelectSeriesStreamResponse{}
+	mi := &file_querier_v1_querier_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelectSeriesStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelectSeriesStreamResponse) ProtoMessage() {}
+
+func (x *SelectSeriesStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelectSeriesStreamResponse.ProtoReflect.Descriptor instead.
+func (*SelectSeriesStreamResponse) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SelectSeriesStreamResponse) GetSeries() []*v1.Series {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+func (x *SelectSeriesStreamResponse) GetProgress() *QueryProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *SelectSeriesStreamResponse) GetFinal() bool {
+	if x != nil {
+		return x.Final
+	}
+	return false
+}
+
 type SelectMergeSpanProfileRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Profile Type ID string in the form
@@ -860,14 +1394,16 @@ type SelectMergeSpanProfileRequest struct {
 	MaxNodes *int64 `protobuf:"varint,6,opt,name=max_nodes,json=maxNodes,proto3,oneof" json:"max_nodes,omitempty"`
 	// Profile format specifies the format of profile to be returned.
 	// If not specified, the profile will be returned in flame graph format.
-	Format        ProfileFormat `protobuf:"varint,7,opt,name=format,proto3,enum=querier.v1.ProfileFormat" json:"format,omitempty"`
+	Format ProfileFormat `protobuf:"varint,7,opt,name=format,proto3,enum=querier.v1.ProfileFormat" json:"format,omitempty"`
+	// (experimental) Used for making and polling async queries.
+	Async         *AsyncQueryRequest `protobuf:"bytes,8,opt,name=async,proto3,oneof" json:"async,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SelectMergeSpanProfileRequest) Reset() {
 	*x = SelectMergeSpanProfileRequest{}
-	mi := &file_querier_v1_querier_proto_msgTypes[9]
+	mi := &file_querier_v1_querier_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -879,7 +1415,7 @@ func (x *SelectMergeSpanProfileRequest) String() string {
 func (*SelectMergeSpanProfileRequest) ProtoMessage() {}
 
 func (x *SelectMergeSpanProfileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[9]
+	mi := &file_querier_v1_querier_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -892,7 +1428,7 @@ func (x *SelectMergeSpanProfileRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectMergeSpanProfileRequest.ProtoReflect.Descriptor instead.
 func (*SelectMergeSpanProfileRequest) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{9}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *SelectMergeSpanProfileRequest) GetProfileTypeID() string {
@@ -944,18 +1480,27 @@ func (x *SelectMergeSpanProfileRequest) GetFormat() ProfileFormat {
 	return ProfileFormat_PROFILE_FORMAT_UNSPECIFIED
 }
 
+func (x *SelectMergeSpanProfileRequest) GetAsync() *AsyncQueryRequest {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
 type SelectMergeSpanProfileResponse struct {
 	state      protoimpl.MessageState `protogen:"open.v1"`
 	Flamegraph *FlameGraph            `protobuf:"bytes,1,opt,name=flamegraph,proto3" json:"flamegraph,omitempty"`
 	// Pyroscope tree bytes.
-	Tree          []byte `protobuf:"bytes,2,opt,name=tree,proto3" json:"tree,omitempty"`
+	Tree []byte `protobuf:"bytes,2,opt,name=tree,proto3" json:"tree,omitempty"`
+	// (experimental) Used for responding to async queries.
+	Async         *AsyncQueryResponse `protobuf:"bytes,3,opt,name=async,proto3,oneof" json:"async,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SelectMergeSpanProfileResponse) Reset() {
 	*x = SelectMergeSpanProfileResponse{}
-	mi := &file_querier_v1_querier_proto_msgTypes[10]
+	mi := &file_querier_v1_querier_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -967,7 +1512,7 @@ func (x *SelectMergeSpanProfileResponse) String() string {
 func (*SelectMergeSpanProfileResponse) ProtoMessage() {}
 
 func (x *SelectMergeSpanProfileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[10]
+	mi := &file_querier_v1_querier_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -980,7 +1525,7 @@ func (x *SelectMergeSpanProfileResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectMergeSpanProfileResponse.ProtoReflect.Descriptor instead.
 func (*SelectMergeSpanProfileResponse) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{10}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *SelectMergeSpanProfileResponse) GetFlamegraph() *FlameGraph {
@@ -997,18 +1542,28 @@ func (x *SelectMergeSpanProfileResponse) GetTree() []byte {
 	return nil
 }
 
+func (x *SelectMergeSpanProfileResponse) GetAsync() *AsyncQueryResponse {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
 type DiffRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The format of each request is ignored; diff queries always compare trees.
-	Left          *SelectMergeStacktracesRequest `protobuf:"bytes,1,opt,name=left,proto3" json:"left,omitempty"`
-	Right         *SelectMergeStacktracesRequest `protobuf:"bytes,2,opt,name=right,proto3" json:"right,omitempty"`
+	// The async field of each request is ignored.
+	Left  *SelectMergeStacktracesRequest `protobuf:"bytes,1,opt,name=left,proto3" json:"left,omitempty"`
+	Right *SelectMergeStacktracesRequest `protobuf:"bytes,2,opt,name=right,proto3" json:"right,omitempty"`
+	// (experimental) Used for making and polling async queries.
+	Async         *AsyncQueryRequest `protobuf:"bytes,3,opt,name=async,proto3,oneof" json:"async,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DiffRequest) Reset() {
 	*x = DiffRequest{}
-	mi := &file_querier_v1_querier_proto_msgTypes[11]
+	mi := &file_querier_v1_querier_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1020,7 +1575,7 @@ func (x *DiffRequest) String() string {
 func (*DiffRequest) ProtoMessage() {}
 
 func (x *DiffRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[11]
+	mi := &file_querier_v1_querier_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1033,7 +1588,7 @@ func (x *DiffRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DiffRequest.ProtoReflect.Descriptor instead.
 func (*DiffRequest) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{11}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *DiffRequest) GetLeft() *SelectMergeStacktracesRequest {
@@ -1050,16 +1605,25 @@ func (x *DiffRequest) GetRight() *SelectMergeStacktracesRequest {
 	return nil
 }
 
+func (x *DiffRequest) GetAsync() *AsyncQueryRequest {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
 type DiffResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Flamegraph    *FlameGraphDiff        `protobuf:"bytes,1,opt,name=flamegraph,proto3" json:"flamegraph,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Flamegraph *FlameGraphDiff        `protobuf:"bytes,1,opt,name=flamegraph,proto3" json:"flamegraph,omitempty"`
+	// (experimental) Used for responding to async queries.
+	Async         *AsyncQueryResponse `protobuf:"bytes,2,opt,name=async,proto3,oneof" json:"async,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DiffResponse) Reset() {
 	*x = DiffResponse{}
-	mi := &file_querier_v1_querier_proto_msgTypes[12]
+	mi := &file_querier_v1_querier_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1071,7 +1635,7 @@ func (x *DiffResponse) String() string {
 func (*DiffResponse) ProtoMessage() {}
 
 func (x *DiffResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[12]
+	mi := &file_querier_v1_querier_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1084,7 +1648,7 @@ func (x *DiffResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DiffResponse.ProtoReflect.Descriptor instead.
 func (*DiffResponse) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{12}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *DiffResponse) GetFlamegraph() *FlameGraphDiff {
@@ -1094,6 +1658,13 @@ func (x *DiffResponse) GetFlamegraph() *FlameGraphDiff {
 	return nil
 }
 
+func (x *DiffResponse) GetAsync() *AsyncQueryResponse {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
 type FlameGraph struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
@@ -1106,7 +1677,7 @@ type FlameGraph struct {
 
 func (x *FlameGraph) Reset() {
 	*x = FlameGraph{}
-	mi := &file_querier_v1_querier_proto_msgTypes[13]
+	mi := &file_querier_v1_querier_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1118,7 +1689,7 @@ func (x *FlameGraph) String() string {
 func (*FlameGraph) ProtoMessage() {}
 
 func (x *FlameGraph) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[13]
+	mi := &file_querier_v1_querier_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1131,7 +1702,7 @@ func (x *FlameGraph) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FlameGraph.ProtoReflect.Descriptor instead.
 func (*FlameGraph) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{13}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *FlameGraph) GetNames() []string {
@@ -1176,7 +1747,7 @@ type FlameGraphDiff struct {
 
 func (x *FlameGraphDiff) Reset() {
 	*x = FlameGraphDiff{}
-	mi := &file_querier_v1_querier_proto_msgTypes[14]
+	mi := &file_querier_v1_querier_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1188,7 +1759,7 @@ func (x *FlameGraphDiff) String() string {
 func (*FlameGraphDiff) ProtoMessage() {}
 
 func (x *FlameGraphDiff) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[14]
+	mi := &file_querier_v1_querier_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1201,7 +1772,7 @@ func (x *FlameGraphDiff) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FlameGraphDiff.ProtoReflect.Descriptor instead.
 func (*FlameGraphDiff) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{14}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *FlameGraphDiff) GetNames() []string {
@@ -1255,7 +1826,7 @@ type Level struct {
 
 func (x *Level) Reset() {
 	*x = Level{}
-	mi := &file_querier_v1_querier_proto_msgTypes[15]
+	mi := &file_querier_v1_querier_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1267,7 +1838,7 @@ func (x *Level) String() string {
 func (*Level) ProtoMessage() {}
 
 func (x *Level) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[15]
+	mi := &file_querier_v1_querier_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1280,7 +1851,7 @@ func (x *Level) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Level.ProtoReflect.Descriptor instead.
 func (*Level) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{15}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *Level) GetValues() []int64 {
@@ -1310,13 +1881,19 @@ type SelectMergeProfileRequest struct {
 	ProfileIdSelector []string `protobuf:"bytes,7,rep,name=profile_id_selector,json=profileIdSelector,proto3" json:"profile_id_selector,omitempty"`
 	// List of trace IDs (32 hex characters, 128-bit) to filter samples by.
 	TraceIdSelector []string `protobuf:"bytes,8,rep,name=trace_id_selector,json=traceIdSelector,proto3" json:"trace_id_selector,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// (experimental) Used for making and polling async queries. The response
+	// is a pprof profile and cannot carry the async query metadata: it is
+	// returned in the Pyroscope-Async-Request-Id, Pyroscope-Async-Status and
+	// Pyroscope-Async-Error response headers instead, and the profile is only
+	// populated on SUCCESS.
+	Async         *AsyncQueryRequest `protobuf:"bytes,9,opt,name=async,proto3,oneof" json:"async,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SelectMergeProfileRequest) Reset() {
 	*x = SelectMergeProfileRequest{}
-	mi := &file_querier_v1_querier_proto_msgTypes[16]
+	mi := &file_querier_v1_querier_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1328,7 +1905,7 @@ func (x *SelectMergeProfileRequest) String() string {
 func (*SelectMergeProfileRequest) ProtoMessage() {}
 
 func (x *SelectMergeProfileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[16]
+	mi := &file_querier_v1_querier_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1341,7 +1918,7 @@ func (x *SelectMergeProfileRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectMergeProfileRequest.ProtoReflect.Descriptor instead.
 func (*SelectMergeProfileRequest) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{16}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *SelectMergeProfileRequest) GetProfileTypeID() string {
@@ -1400,6 +1977,13 @@ func (x *SelectMergeProfileRequest) GetTraceIdSelector() []string {
 	return nil
 }
 
+func (x *SelectMergeProfileRequest) GetAsync() *AsyncQueryRequest {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
 type SelectSeriesRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Profile Type ID string in the form
@@ -1420,14 +2004,23 @@ type SelectSeriesRequest struct {
 	// Select the top N series by total value.
 	Limit *int64 `protobuf:"varint,9,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
 	// Type of exemplars to include in the response.
-	ExemplarType  v1.ExemplarType `protobuf:"varint,10,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	ExemplarType v1.ExemplarType `protobuf:"varint,10,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
+	// (experimental) Used for making and polling async queries.
+	Async *AsyncQueryRequest `protobuf:"bytes,11,opt,name=async,proto3,oneof" json:"async,omitempty"`
+	// (experimental) Fraction of profiles to read, in (0, 1]: the result is
+	// approximated from a deterministic sample of the profiles. If not
+	// specified, all the profiles are read.
+	SamplingRatio *float64 `protobuf:"fixed64,12,opt,name=sampling_ratio,json=samplingRatio,proto3,oneof" json:"sampling_ratio,omitempty"`
+	// Maximum number of exemplars per point: the exemplars that contributed
+	// most to the point value are included. Defaults to 1, at most 100.
+	MaxExemplarsPerPoint *int64 `protobuf:"varint,13,opt,name=max_exemplars_per_point,json=maxExemplarsPerPoint,proto3,oneof" json:"max_exemplars_per_point,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *SelectSeriesRequest) Reset() {
 	*x = SelectSeriesRequest{}
-	mi := &file_querier_v1_querier_proto_msgTypes[17]
+	mi := &file_querier_v1_querier_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1439,7 +2032,7 @@ func (x *SelectSeriesRequest) String() string {
 func (*SelectSeriesRequest) ProtoMessage() {}
 
 func (x *SelectSeriesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[17]
+	mi := &file_querier_v1_querier_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1452,7 +2045,7 @@ func (x *SelectSeriesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectSeriesRequest.ProtoReflect.Descriptor instead.
 func (*SelectSeriesRequest) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{17}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *SelectSeriesRequest) GetProfileTypeID() string {
@@ -1525,16 +2118,41 @@ func (x *SelectSeriesRequest) GetExemplarType() v1.ExemplarType {
 	return v1.ExemplarType(0)
 }
 
+func (x *SelectSeriesRequest) GetAsync() *AsyncQueryRequest {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
+func (x *SelectSeriesRequest) GetSamplingRatio() float64 {
+	if x != nil && x.SamplingRatio != nil {
+		return *x.SamplingRatio
+	}
+	return 0
+}
+
+func (x *SelectSeriesRequest) GetMaxExemplarsPerPoint() int64 {
+	if x != nil && x.MaxExemplarsPerPoint != nil {
+		return *x.MaxExemplarsPerPoint
+	}
+	return 0
+}
+
 type SelectSeriesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Series        []*v1.Series           `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Series []*v1.Series           `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	// (experimental) Used for responding to async queries.
+	Async *AsyncQueryResponse `protobuf:"bytes,2,opt,name=async,proto3,oneof" json:"async,omitempty"`
+	// (experimental) Set if the result is approximated from a sample of the profiles.
+	Sampling      *QuerySampling `protobuf:"bytes,3,opt,name=sampling,proto3" json:"sampling,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SelectSeriesResponse) Reset() {
 	*x = SelectSeriesResponse{}
-	mi := &file_querier_v1_querier_proto_msgTypes[18]
+	mi := &file_querier_v1_querier_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1546,7 +2164,7 @@ func (x *SelectSeriesResponse) String() string {
 func (*SelectSeriesResponse) ProtoMessage() {}
 
 func (x *SelectSeriesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[18]
+	mi := &file_querier_v1_querier_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1559,7 +2177,7 @@ func (x *SelectSeriesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectSeriesResponse.ProtoReflect.Descriptor instead.
 func (*SelectSeriesResponse) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{18}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *SelectSeriesResponse) GetSeries() []*v1.Series {
@@ -1569,6 +2187,20 @@ func (x *SelectSeriesResponse) GetSeries() []*v1.Series {
 	return nil
 }
 
+func (x *SelectSeriesResponse) GetAsync() *AsyncQueryResponse {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
+func (x *SelectSeriesResponse) GetSampling() *QuerySampling {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
 type SelectHeatmapRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Profile Type ID string in the form
@@ -1589,14 +2221,16 @@ type SelectHeatmapRequest struct {
 	// Type of exemplars to include in the response. Needs to matching query_type or be NONE
 	ExemplarType v1.ExemplarType `protobuf:"varint,8,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
 	// Select the top N series by total value.
-	Limit         *int64 `protobuf:"varint,9,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	Limit *int64 `protobuf:"varint,9,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	// (experimental) Used for making and polling async queries.
+	Async         *AsyncQueryRequest `protobuf:"bytes,10,opt,name=async,proto3,oneof" json:"async,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SelectHeatmapRequest) Reset() {
 	*x = SelectHeatmapRequest{}
-	mi := &file_querier_v1_querier_proto_msgTypes[19]
+	mi := &file_querier_v1_querier_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1608,7 +2242,7 @@ func (x *SelectHeatmapRequest) String() string {
 func (*SelectHeatmapRequest) ProtoMessage() {}
 
 func (x *SelectHeatmapRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[19]
+	mi := &file_querier_v1_querier_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1621,7 +2255,7 @@ func (x *SelectHeatmapRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectHeatmapRequest.ProtoReflect.Descriptor instead.
 func (*SelectHeatmapRequest) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{19}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *SelectHeatmapRequest) GetProfileTypeID() string {
@@ -1687,16 +2321,25 @@ func (x *SelectHeatmapRequest) GetLimit() int64 {
 	return 0
 }
 
+func (x *SelectHeatmapRequest) GetAsync() *AsyncQueryRequest {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
 type SelectHeatmapResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Series        []*v1.HeatmapSeries    `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Series []*v1.HeatmapSeries    `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	// (experimental) Used for responding to async queries.
+	Async         *AsyncQueryResponse `protobuf:"bytes,2,opt,name=async,proto3,oneof" json:"async,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SelectHeatmapResponse) Reset() {
 	*x = SelectHeatmapResponse{}
-	mi := &file_querier_v1_querier_proto_msgTypes[20]
+	mi := &file_querier_v1_querier_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1708,7 +2351,7 @@ func (x *SelectHeatmapResponse) String() string {
 func (*SelectHeatmapResponse) ProtoMessage() {}
 
 func (x *SelectHeatmapResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[20]
+	mi := &file_querier_v1_querier_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1721,7 +2364,7 @@ func (x *SelectHeatmapResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectHeatmapResponse.ProtoReflect.Descriptor instead.
 func (*SelectHeatmapResponse) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{20}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *SelectHeatmapResponse) GetSeries() []*v1.HeatmapSeries {
@@ -1731,6 +2374,13 @@ func (x *SelectHeatmapResponse) GetSeries() []*v1.HeatmapSeries {
 	return nil
 }
 
+func (x *SelectHeatmapResponse) GetAsync() *AsyncQueryResponse {
+	if x != nil {
+		return x.Async
+	}
+	return nil
+}
+
 type AnalyzeQueryRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Start         int64                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
@@ -1742,7 +2392,7 @@ type AnalyzeQueryRequest struct {
 
 func (x *AnalyzeQueryRequest) Reset() {
 	*x = AnalyzeQueryRequest{}
-	mi := &file_querier_v1_querier_proto_msgTypes[21]
+	mi := &file_querier_v1_querier_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1754,7 +2404,7 @@ func (x *AnalyzeQueryRequest) String() string {
 func (*AnalyzeQueryRequest) ProtoMessage() {}
 
 func (x *AnalyzeQueryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[21]
+	mi := &file_querier_v1_querier_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1767,7 +2417,7 @@ func (x *AnalyzeQueryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AnalyzeQueryRequest.ProtoReflect.Descriptor instead.
 func (*AnalyzeQueryRequest) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{21}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *AnalyzeQueryRequest) GetStart() int64 {
@@ -1801,7 +2451,7 @@ type AnalyzeQueryResponse struct {
 
 func (x *AnalyzeQueryResponse) Reset() {
 	*x = AnalyzeQueryResponse{}
-	mi := &file_querier_v1_querier_proto_msgTypes[22]
+	mi := &file_querier_v1_querier_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1813,7 +2463,7 @@ func (x *AnalyzeQueryResponse) String() string {
 func (*AnalyzeQueryResponse) ProtoMessage() {}
 
 func (x *AnalyzeQueryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[22]
+	mi := &file_querier_v1_querier_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1826,7 +2476,7 @@ func (x *AnalyzeQueryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AnalyzeQueryResponse.ProtoReflect.Descriptor instead.
 func (*AnalyzeQueryResponse) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{22}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *AnalyzeQueryResponse) GetQueryScopes() []*QueryScope {
@@ -1843,6 +2493,214 @@ func (x *AnalyzeQueryResponse) GetQueryImpact() *QueryImpact {
 	return nil
 }
 
+type LabelValuesCardinalityRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The label name.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Label selectors the datasets must match.
+	Matchers []string `protobuf:"bytes,2,rep,name=matchers,proto3" json:"matchers,omitempty"`
+	// Milliseconds since epoch.
+	Start int64 `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	// Milliseconds since epoch.
+	End int64 `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`
+	// If set, only values starting with the prefix are returned.
+	Prefix string `protobuf:"bytes,5,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// If set, only values matching the regular expression are returned.
+	Regex string `protobuf:"bytes,6,opt,name=regex,proto3" json:"regex,omitempty"`
+	// The maximum number of values returned; values with the highest
+	// cardinality are preferred. Zero means no limit.
+	Limit         int64 `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LabelValuesCardinalityRequest) Reset() {
+	*x = LabelValuesCardinalityRequest{}
+	mi := &file_querier_v1_querier_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LabelValuesCardinalityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LabelValuesCardinalityRequest) ProtoMessage() {}
+
+func (x *LabelValuesCardinalityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LabelValuesCardinalityRequest.ProtoReflect.Descriptor instead.
+func (*LabelValuesCardinalityRequest) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *LabelValuesCardinalityRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LabelValuesCardinalityRequest) GetMatchers() []string {
+	if x != nil {
+		return x.Matchers
+	}
+	return nil
+}
+
+func (x *LabelValuesCardinalityRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *LabelValuesCardinalityRequest) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *LabelValuesCardinalityRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *LabelValuesCardinalityRequest) GetRegex() string {
+	if x != nil {
+		return x.Regex
+	}
+	return ""
+}
+
+func (x *LabelValuesCardinalityRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type LabelValuesCardinalityResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Values ordered by cardinality in descending order.
+	Values []*LabelValueCardinality `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	// Whether the values were truncated according to the limit.
+	Truncated     bool `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LabelValuesCardinalityResponse) Reset() {
+	*x = LabelValuesCardinalityResponse{}
+	mi := &file_querier_v1_querier_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LabelValuesCardinalityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LabelValuesCardinalityResponse) ProtoMessage() {}
+
+func (x *LabelValuesCardinalityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LabelValuesCardinalityResponse.ProtoReflect.Descriptor instead.
+func (*LabelValuesCardinalityResponse) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *LabelValuesCardinalityResponse) GetValues() []*LabelValueCardinality {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *LabelValuesCardinalityResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type LabelValueCardinality struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Value string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// The approximate cardinality: the number of datasets labeled
+	// with the value, within the query time range.
+	Cardinality   int64 `protobuf:"varint,2,opt,name=cardinality,proto3" json:"cardinality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LabelValueCardinality) Reset() {
+	*x = LabelValueCardinality{}
+	mi := &file_querier_v1_querier_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LabelValueCardinality) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LabelValueCardinality) ProtoMessage() {}
+
+func (x *LabelValueCardinality) ProtoReflect() protoreflect.Message {
+	mi := &file_querier_v1_querier_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LabelValueCardinality.ProtoReflect.Descriptor instead.
+func (*LabelValueCardinality) Descriptor() ([]byte, []int) {
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *LabelValueCardinality) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *LabelValueCardinality) GetCardinality() int64 {
+	if x != nil {
+		return x.Cardinality
+	}
+	return 0
+}
+
 type QueryScope struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ComponentType string                 `protobuf:"bytes,1,opt,name=component_type,json=componentType,proto3" json:"component_type,omitempty"` // a descriptive high level name of the component processing one part
@@ -1861,7 +2719,7 @@ type QueryScope struct {
 
 func (x *QueryScope) Reset() {
 	*x = QueryScope{}
-	mi := &file_querier_v1_querier_proto_msgTypes[23]
+	mi := &file_querier_v1_querier_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1873,7 +2731,7 @@ func (x *QueryScope) String() string {
 func (*QueryScope) ProtoMessage() {}
 
 func (x *QueryScope) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[23]
+	mi := &file_querier_v1_querier_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1886,7 +2744,7 @@ func (x *QueryScope) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryScope.ProtoReflect.Descriptor instead.
 func (*QueryScope) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{23}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *QueryScope) GetComponentType() string {
@@ -1963,7 +2821,7 @@ type QueryImpact struct {
 
 func (x *QueryImpact) Reset() {
 	*x = QueryImpact{}
-	mi := &file_querier_v1_querier_proto_msgTypes[24]
+	mi := &file_querier_v1_querier_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1975,7 +2833,7 @@ func (x *QueryImpact) String() string {
 func (*QueryImpact) ProtoMessage() {}
 
 func (x *QueryImpact) ProtoReflect() protoreflect.Message {
-	mi := &file_querier_v1_querier_proto_msgTypes[24]
+	mi := &file_querier_v1_querier_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1988,7 +2846,7 @@ func (x *QueryImpact) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryImpact.ProtoReflect.Descriptor instead.
 func (*QueryImpact) Descriptor() ([]byte, []int) {
-	return file_querier_v1_querier_proto_rawDescGZIP(), []int{24}
+	return file_querier_v1_querier_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *QueryImpact) GetTotalBytesInTimeRange() uint64 {
@@ -2031,7 +2889,7 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\x03end\x18\x04 \x01(\x03B\x14\xbaG\x11:\x0f\x12\r1676289600000R\x03end\"A\n" +
 	"\x0eSeriesResponse\x12/\n" +
 	"\n" +
-	"labels_set\x18\x02 \x03(\v2\x10.types.v1.LabelsR\tlabelsSet\"\xbe\x06\n" +
+	"labels_set\x18\x02 \x03(\v2\x10.types.v1.LabelsR\tlabelsSet\"\xfd\x06\n" +
 	"\x1dSelectMergeStacktracesRequest\x12Y\n" +
 	"\x0eprofile_typeID\x18\x01 \x01(\tB2\xbaG/:-\x12+process_cpu:cpu:nanoseconds:cpu:nanosecondsR\rprofileTypeID\x12J\n" +
 	"\x0elabel_selector\x18\x02 \x01(\tB#\xbaG :\x1e\x12\x1c'{namespace=\"my-namespace\"}'R\rlabelSelector\x12*\n" +
@@ -2044,11 +2902,13 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\x05async\x18\t \x01(\v2\x1d.querier.v1.AsyncQueryRequestH\x02R\x05async\x88\x01\x01\x12W\n" +
 	"\x11trace_id_selector\x18\n" +
 	" \x03(\tB+\xbaG(:&\x12$['7c9e66797425440de944be07fc1f90ae']R\x0ftraceIdSelector\x12S\n" +
-	"\rspan_selector\x18\v \x03(\tB.\xbaG+:)\x12'['9a517183f26a089d','5a4fe264a9c987fe']R\fspanSelectorB\f\n" +
+	"\rspan_selector\x18\v \x03(\tB.\xbaG+:)\x12'['9a517183f26a089d','5a4fe264a9c987fe']R\fspanSelector\x12*\n" +
+	"\x0esampling_ratio\x18\f \x01(\x01H\x03R\rsamplingRatio\x88\x01\x01B\f\n" +
 	"\n" +
 	"_max_nodesB\x17\n" +
 	"\x15_stack_trace_selectorB\b\n" +
-	"\x06_async\"\xf3\x01\n" +
+	"\x06_asyncB\x11\n" +
+	"\x0f_sampling_ratio\"\xaa\x02\n" +
 	"\x1eSelectMergeStacktracesResponse\x126\n" +
 	"\n" +
 	"flamegraph\x18\x01 \x01(\v2\x16.querier.v1.FlameGraphR\n" +
@@ -2056,7 +2916,8 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\x04tree\x18\x02 \x01(\fR\x04tree\x12\x10\n" +
 	"\x03dot\x18\x03 \x01(\tR\x03dot\x129\n" +
 	"\x05async\x18\x04 \x01(\v2\x1e.querier.v1.AsyncQueryResponseH\x00R\x05async\x88\x01\x01\x12.\n" +
-	"\x05pprof\x18\x05 \x01(\v2\x18.querier.v1.PprofProfileR\x05pprofB\b\n" +
+	"\x05pprof\x18\x05 \x01(\v2\x18.querier.v1.PprofProfileR\x05pprof\x125\n" +
+	"\bsampling\x18\x06 \x01(\v2\x19.querier.v1.QuerySamplingR\bsamplingB\b\n" +
 	"\x06_async\"<\n" +
 	"\fPprofProfile\x12,\n" +
 	"\aprofile\x18\x01 \x01(\v2\x12.google.v1.ProfileR\aprofile\"b\n" +
@@ -2068,7 +2929,45 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\n" +
 	"request_id\x18\x01 \x01(\tR\trequestId\x124\n" +
 	"\x06status\x18\x02 \x01(\x0e2\x1c.querier.v1.AsyncQueryStatusR\x06status\x12#\n" +
-	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\"\xd2\x03\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\"\x19\n" +
+	"\x17ListAsyncQueriesRequest\"P\n" +
+	"\x18ListAsyncQueriesResponse\x124\n" +
+	"\aqueries\x18\x01 \x03(\v2\x1a.querier.v1.AsyncQueryInfoR\aqueries\"\xc8\x01\n" +
+	"\x0eAsyncQueryInfo\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1d\n" +
+	"\n" +
+	"query_type\x18\x02 \x01(\tR\tqueryType\x124\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x1c.querier.v1.AsyncQueryStatusR\x06status\x12#\n" +
+	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\"8\n" +
+	"\x17CancelAsyncQueryRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\"P\n" +
+	"\x18CancelAsyncQueryResponse\x124\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x1c.querier.v1.AsyncQueryStatusR\x06status\"S\n" +
+	"\rQueryProgress\x12\x1f\n" +
+	"\vblocks_done\x18\x01 \x01(\x03R\n" +
+	"blocksDone\x12!\n" +
+	"\fblocks_total\x18\x02 \x01(\x03R\vblocksTotal\"\xa9\x01\n" +
+	"\rQuerySampling\x12\x1f\n" +
+	"\vsample_rate\x18\x01 \x01(\x01R\n" +
+	"sampleRate\x12%\n" +
+	"\x0erelative_error\x18\x02 \x01(\x01R\rrelativeError\x12)\n" +
+	"\x10profiles_sampled\x18\x03 \x01(\x03R\x0fprofilesSampled\x12%\n" +
+	"\x0eprofiles_total\x18\x04 \x01(\x03R\rprofilesTotal\"\xbf\x01\n" +
+	"$SelectMergeStacktracesStreamResponse\x126\n" +
+	"\n" +
+	"flamegraph\x18\x01 \x01(\v2\x16.querier.v1.FlameGraphR\n" +
+	"flamegraph\x12\x12\n" +
+	"\x04tree\x18\x02 \x01(\fR\x04tree\x125\n" +
+	"\bprogress\x18\x03 \x01(\v2\x19.querier.v1.QueryProgressR\bprogress\x12\x14\n" +
+	"\x05final\x18\x04 \x01(\bR\x05final\"\x93\x01\n" +
+	"\x1aSelectSeriesStreamResponse\x12(\n" +
+	"\x06series\x18\x01 \x03(\v2\x10.types.v1.SeriesR\x06series\x125\n" +
+	"\bprogress\x18\x02 \x01(\v2\x19.querier.v1.QueryProgressR\bprogress\x12\x14\n" +
+	"\x05final\x18\x03 \x01(\bR\x05final\"\x96\x04\n" +
 	"\x1dSelectMergeSpanProfileRequest\x12Y\n" +
 	"\x0eprofile_typeID\x18\x01 \x01(\tB2\xbaG/:-\x12+process_cpu:cpu:nanoseconds:cpu:nanosecondsR\rprofileTypeID\x12J\n" +
 	"\x0elabel_selector\x18\x02 \x01(\tB#\xbaG :\x1e\x12\x1c'{namespace=\"my-namespace\"}'R\rlabelSelector\x12S\n" +
@@ -2076,21 +2975,29 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\x05start\x18\x04 \x01(\x03B\x14\xbaG\x11:\x0f\x12\r1676282400000R\x05start\x12&\n" +
 	"\x03end\x18\x05 \x01(\x03B\x14\xbaG\x11:\x0f\x12\r1676289600000R\x03end\x12 \n" +
 	"\tmax_nodes\x18\x06 \x01(\x03H\x00R\bmaxNodes\x88\x01\x01\x121\n" +
-	"\x06format\x18\a \x01(\x0e2\x19.querier.v1.ProfileFormatR\x06formatB\f\n" +
+	"\x06format\x18\a \x01(\x0e2\x19.querier.v1.ProfileFormatR\x06format\x128\n" +
+	"\x05async\x18\b \x01(\v2\x1d.querier.v1.AsyncQueryRequestH\x01R\x05async\x88\x01\x01B\f\n" +
 	"\n" +
-	"_max_nodes\"l\n" +
+	"_max_nodesB\b\n" +
+	"\x06_async\"\xb1\x01\n" +
 	"\x1eSelectMergeSpanProfileResponse\x126\n" +
 	"\n" +
 	"flamegraph\x18\x01 \x01(\v2\x16.querier.v1.FlameGraphR\n" +
 	"flamegraph\x12\x12\n" +
-	"\x04tree\x18\x02 \x01(\fR\x04tree\"\x8d\x01\n" +
+	"\x04tree\x18\x02 \x01(\fR\x04tree\x129\n" +
+	"\x05async\x18\x03 \x01(\v2\x1e.querier.v1.AsyncQueryResponseH\x00R\x05async\x88\x01\x01B\b\n" +
+	"\x06_async\"\xd1\x01\n" +
 	"\vDiffRequest\x12=\n" +
 	"\x04left\x18\x01 \x01(\v2).querier.v1.SelectMergeStacktracesRequestR\x04left\x12?\n" +
-	"\x05right\x18\x02 \x01(\v2).querier.v1.SelectMergeStacktracesRequestR\x05right\"J\n" +
+	"\x05right\x18\x02 \x01(\v2).querier.v1.SelectMergeStacktracesRequestR\x05right\x128\n" +
+	"\x05async\x18\x03 \x01(\v2\x1d.querier.v1.AsyncQueryRequestH\x00R\x05async\x88\x01\x01B\b\n" +
+	"\x06_async\"\x8f\x01\n" +
 	"\fDiffResponse\x12:\n" +
 	"\n" +
 	"flamegraph\x18\x01 \x01(\v2\x1a.querier.v1.FlameGraphDiffR\n" +
-	"flamegraph\"~\n" +
+	"flamegraph\x129\n" +
+	"\x05async\x18\x02 \x01(\v2\x1e.querier.v1.AsyncQueryResponseH\x00R\x05async\x88\x01\x01B\b\n" +
+	"\x06_async\"~\n" +
 	"\n" +
 	"FlameGraph\x12\x14\n" +
 	"\x05names\x18\x01 \x03(\tR\x05names\x12)\n" +
@@ -2107,7 +3014,7 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"rightTicks\x18\x06 \x01(\x03R\n" +
 	"rightTicks\"\x1f\n" +
 	"\x05Level\x12\x16\n" +
-	"\x06values\x18\x01 \x03(\x03R\x06values\"\xee\x04\n" +
+	"\x06values\x18\x01 \x03(\x03R\x06values\"\xb2\x05\n" +
 	"\x19SelectMergeProfileRequest\x12Y\n" +
 	"\x0eprofile_typeID\x18\x01 \x01(\tB2\xbaG/:-\x12+process_cpu:cpu:nanoseconds:cpu:nanosecondsR\rprofileTypeID\x12J\n" +
 	"\x0elabel_selector\x18\x02 \x01(\tB#\xbaG :\x1e\x12\x1c'{namespace=\"my-namespace\"}'R\rlabelSelector\x12*\n" +
@@ -2116,10 +3023,12 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\tmax_nodes\x18\x05 \x01(\x03H\x00R\bmaxNodes\x88\x01\x01\x12S\n" +
 	"\x14stack_trace_selector\x18\x06 \x01(\v2\x1c.types.v1.StackTraceSelectorH\x01R\x12stackTraceSelector\x88\x01\x01\x12_\n" +
 	"\x13profile_id_selector\x18\a \x03(\tB/\xbaG,:*\x12(['7c9e6679-7425-40de-944b-e07fc1f90ae7']R\x11profileIdSelector\x12W\n" +
-	"\x11trace_id_selector\x18\b \x03(\tB+\xbaG(:&\x12$['7c9e66797425440de944be07fc1f90ae']R\x0ftraceIdSelectorB\f\n" +
+	"\x11trace_id_selector\x18\b \x03(\tB+\xbaG(:&\x12$['7c9e66797425440de944be07fc1f90ae']R\x0ftraceIdSelector\x128\n" +
+	"\x05async\x18\t \x01(\v2\x1d.querier.v1.AsyncQueryRequestH\x02R\x05async\x88\x01\x01B\f\n" +
 	"\n" +
 	"_max_nodesB\x17\n" +
-	"\x15_stack_trace_selector\"\xfb\x04\n" +
+	"\x15_stack_trace_selectorB\b\n" +
+	"\x06_async\"\xd6\x06\n" +
 	"\x13SelectSeriesRequest\x12Y\n" +
 	"\x0eprofile_typeID\x18\x01 \x01(\tB2\xbaG/:-\x12+process_cpu:cpu:nanoseconds:cpu:nanosecondsR\rprofileTypeID\x12J\n" +
 	"\x0elabel_selector\x18\x02 \x01(\tB#\xbaG :\x1e\x12\x1c'{namespace=\"my-namespace\"}'R\rlabelSelector\x12*\n" +
@@ -2131,12 +3040,21 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\x14stack_trace_selector\x18\b \x01(\v2\x1c.types.v1.StackTraceSelectorH\x01R\x12stackTraceSelector\x88\x01\x01\x12\x19\n" +
 	"\x05limit\x18\t \x01(\x03H\x02R\x05limit\x88\x01\x01\x12;\n" +
 	"\rexemplar_type\x18\n" +
-	" \x01(\x0e2\x16.types.v1.ExemplarTypeR\fexemplarTypeB\x0e\n" +
+	" \x01(\x0e2\x16.types.v1.ExemplarTypeR\fexemplarType\x128\n" +
+	"\x05async\x18\v \x01(\v2\x1d.querier.v1.AsyncQueryRequestH\x03R\x05async\x88\x01\x01\x12*\n" +
+	"\x0esampling_ratio\x18\f \x01(\x01H\x04R\rsamplingRatio\x88\x01\x01\x12:\n" +
+	"\x17max_exemplars_per_point\x18\r \x01(\x03H\x05R\x14maxExemplarsPerPoint\x88\x01\x01B\x0e\n" +
 	"\f_aggregationB\x17\n" +
 	"\x15_stack_trace_selectorB\b\n" +
-	"\x06_limit\"@\n" +
+	"\x06_limitB\b\n" +
+	"\x06_asyncB\x11\n" +
+	"\x0f_sampling_ratioB\x1a\n" +
+	"\x18_max_exemplars_per_point\"\xbc\x01\n" +
 	"\x14SelectSeriesResponse\x12(\n" +
-	"\x06series\x18\x01 \x03(\v2\x10.types.v1.SeriesR\x06series\"\xae\x04\n" +
+	"\x06series\x18\x01 \x03(\v2\x10.types.v1.SeriesR\x06series\x129\n" +
+	"\x05async\x18\x02 \x01(\v2\x1e.querier.v1.AsyncQueryResponseH\x00R\x05async\x88\x01\x01\x125\n" +
+	"\bsampling\x18\x03 \x01(\v2\x19.querier.v1.QuerySamplingR\bsamplingB\b\n" +
+	"\x06_async\"\xf2\x04\n" +
 	"\x14SelectHeatmapRequest\x12Y\n" +
 	"\x0eprofile_typeID\x18\x01 \x01(\tB2\xbaG/:-\x12+process_cpu:cpu:nanoseconds:cpu:nanosecondsR\rprofileTypeID\x12J\n" +
 	"\x0elabel_selector\x18\x02 \x01(\tB#\xbaG :\x1e\x12\x1c'{namespace=\"my-namespace\"}'R\rlabelSelector\x12*\n" +
@@ -2147,17 +3065,36 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\n" +
 	"query_type\x18\a \x01(\x0e2\x1c.querier.v1.HeatmapQueryTypeB \xbaG\x1d:\x1b\x12\x19'HEATMAP_QUERY_TYPE_SPAN'R\tqueryType\x12X\n" +
 	"\rexemplar_type\x18\b \x01(\x0e2\x16.types.v1.ExemplarTypeB\x1b\xbaG\x18:\x16\x12\x14'EXEMPLAR_TYPE_SPAN'R\fexemplarType\x12\x19\n" +
-	"\x05limit\x18\t \x01(\x03H\x00R\x05limit\x88\x01\x01B\b\n" +
-	"\x06_limit\"H\n" +
+	"\x05limit\x18\t \x01(\x03H\x00R\x05limit\x88\x01\x01\x128\n" +
+	"\x05async\x18\n" +
+	" \x01(\v2\x1d.querier.v1.AsyncQueryRequestH\x01R\x05async\x88\x01\x01B\b\n" +
+	"\x06_limitB\b\n" +
+	"\x06_async\"\x8d\x01\n" +
 	"\x15SelectHeatmapResponse\x12/\n" +
-	"\x06series\x18\x01 \x03(\v2\x17.types.v1.HeatmapSeriesR\x06series\"S\n" +
+	"\x06series\x18\x01 \x03(\v2\x17.types.v1.HeatmapSeriesR\x06series\x129\n" +
+	"\x05async\x18\x02 \x01(\v2\x1e.querier.v1.AsyncQueryResponseH\x00R\x05async\x88\x01\x01B\b\n" +
+	"\x06_async\"S\n" +
 	"\x13AnalyzeQueryRequest\x12\x14\n" +
 	"\x05start\x18\x02 \x01(\x03R\x05start\x12\x10\n" +
 	"\x03end\x18\x03 \x01(\x03R\x03end\x12\x14\n" +
 	"\x05query\x18\x04 \x01(\tR\x05query\"\x8d\x01\n" +
 	"\x14AnalyzeQueryResponse\x129\n" +
 	"\fquery_scopes\x18\x01 \x03(\v2\x16.querier.v1.QueryScopeR\vqueryScopes\x12:\n" +
-	"\fquery_impact\x18\x02 \x01(\v2\x17.querier.v1.QueryImpactR\vqueryImpact\"\xd1\x02\n" +
+	"\fquery_impact\x18\x02 \x01(\v2\x17.querier.v1.QueryImpactR\vqueryImpact\"\xbb\x01\n" +
+	"\x1dLabelValuesCardinalityRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bmatchers\x18\x02 \x03(\tR\bmatchers\x12\x14\n" +
+	"\x05start\x18\x03 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x04 \x01(\x03R\x03end\x12\x16\n" +
+	"\x06prefix\x18\x05 \x01(\tR\x06prefix\x12\x14\n" +
+	"\x05regex\x18\x06 \x01(\tR\x05regex\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x03R\x05limit\"y\n" +
+	"\x1eLabelValuesCardinalityResponse\x129\n" +
+	"\x06values\x18\x01 \x03(\v2!.querier.v1.LabelValueCardinalityR\x06values\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"O\n" +
+	"\x15LabelValueCardinality\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12 \n" +
+	"\vcardinality\x18\x02 \x01(\x03R\vcardinality\"\xd1\x02\n" +
 	"\n" +
 	"QueryScope\x12%\n" +
 	"\x0ecomponent_type\x18\x01 \x01(\tR\rcomponentType\x12'\n" +
@@ -2183,16 +3120,18 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\x14PROFILE_FORMAT_PPROF\x10\x04*K\n" +
 	"\x0eAsyncQueryType\x12\x1d\n" +
 	"\x19ASYNC_QUERY_TYPE_DISABLED\x10\x00\x12\x1a\n" +
-	"\x16ASYNC_QUERY_TYPE_FORCE\x10\x01*\x96\x01\n" +
+	"\x16ASYNC_QUERY_TYPE_FORCE\x10\x01*\xb7\x01\n" +
 	"\x10AsyncQueryStatus\x12\x1e\n" +
 	"\x1aASYNC_QUERY_STATUS_UNKNOWN\x10\x00\x12\"\n" +
 	"\x1eASYNC_QUERY_STATUS_IN_PROGRESS\x10\x01\x12\x1e\n" +
 	"\x1aASYNC_QUERY_STATUS_SUCCESS\x10\x02\x12\x1e\n" +
-	"\x1aASYNC_QUERY_STATUS_FAILURE\x10\x03*v\n" +
+	"\x1aASYNC_QUERY_STATUS_FAILURE\x10\x03\x12\x1f\n" +
+	"\x1bASYNC_QUERY_STATUS_CANCELED\x10\x04*v\n" +
 	"\x10HeatmapQueryType\x12\"\n" +
 	"\x1eHEATMAP_QUERY_TYPE_UNSPECIFIED\x10\x00\x12!\n" +
 	"\x1dHEATMAP_QUERY_TYPE_INDIVIDUAL\x10\x01\x12\x1b\n" +
-	"\x17HEATMAP_QUERY_TYPE_SPAN\x10\x022\xe5\t\n" +
+	"\x17HEATMAP_QUERY_TYPE_SPAN\x10\x022\xea\n" +
+	"\n" +
 	"\x0eQuerierService\x12d\n" +
 	"\fProfileTypes\x12\x1f.querier.v1.ProfileTypesRequest\x1a .querier.v1.ProfileTypesResponse\"\x11\xbaG\x0e\n" +
 	"\fscope/public\x12]\n" +
@@ -2218,7 +3157,19 @@ const file_querier_v1_querier_proto_rawDesc = "" +
 	"\x0fGetProfileStats\x12 .types.v1.GetProfileStatsRequest\x1a!.types.v1.GetProfileStatsResponse\"\x13\xbaG\x10\n" +
 	"\x0escope/internal\x12f\n" +
 	"\fAnalyzeQuery\x12\x1f.querier.v1.AnalyzeQueryRequest\x1a .querier.v1.AnalyzeQueryResponse\"\x13\xbaG\x10\n" +
-	"\x0escope/internalB\xab\x01\n" +
+	"\x0escope/internal\x12\x82\x01\n" +
+	"\x16LabelValuesCardinality\x12).querier.v1.LabelValuesCardinalityRequest\x1a*.querier.v1.LabelValuesCardinalityResponse\"\x11\xbaG\x0e\n" +
+	"\fscope/public2\xf7\x01\n" +
+	"\x11AsyncQueryService\x12p\n" +
+	"\x10ListAsyncQueries\x12#.querier.v1.ListAsyncQueriesRequest\x1a$.querier.v1.ListAsyncQueriesResponse\"\x11\xbaG\x0e\n" +
+	"\fscope/public\x12p\n" +
+	"\x10CancelAsyncQuery\x12#.querier.v1.CancelAsyncQueryRequest\x1a$.querier.v1.CancelAsyncQueryResponse\"\x11\xbaG\x0e\n" +
+	"\fscope/public2\x9e\x02\n" +
+	"\x15StreamingQueryService\x12\x90\x01\n" +
+	"\x1cSelectMergeStacktracesStream\x12).querier.v1.SelectMergeStacktracesRequest\x1a0.querier.v1.SelectMergeStacktracesStreamResponse\"\x11\xbaG\x0e\n" +
+	"\fscope/public0\x01\x12r\n" +
+	"\x12SelectSeriesStream\x12\x1f.querier.v1.SelectSeriesRequest\x1a&.querier.v1.SelectSeriesStreamResponse\"\x11\xbaG\x0e\n" +
+	"\fscope/public0\x01B\xab\x01\n" +
 	"\x0ecom.querier.v1B\fQuerierProtoP\x01ZBgithub.com/grafana/pyroscope/api/gen/proto/go/querier/v1;querierv1\xa2\x02\x03QXX\xaa\x02\n" +
 	"Querier.V1\xca\x02\n" +
 	"Querier\\V1\xe2\x02\x16Querier\\V1\\GPBMetadata\xea\x02\vQuerier::V1b\x06proto3"
@@ -2236,110 +3187,151 @@ func file_querier_v1_querier_proto_rawDescGZIP() []byte {
 }
 
 var file_querier_v1_querier_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_querier_v1_querier_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_querier_v1_querier_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_querier_v1_querier_proto_goTypes = []any{
-	(ProfileFormat)(0),                     // 0: querier.v1.ProfileFormat
-	(AsyncQueryType)(0),                    // 1: querier.v1.AsyncQueryType
-	(AsyncQueryStatus)(0),                  // 2: querier.v1.AsyncQueryStatus
-	(HeatmapQueryType)(0),                  // 3: querier.v1.HeatmapQueryType
-	(*ProfileTypesRequest)(nil),            // 4: querier.v1.ProfileTypesRequest
-	(*ProfileTypesResponse)(nil),           // 5: querier.v1.ProfileTypesResponse
-	(*SeriesRequest)(nil),                  // 6: querier.v1.SeriesRequest
-	(*SeriesResponse)(nil),                 // 7: querier.v1.SeriesResponse
-	(*SelectMergeStacktracesRequest)(nil),  // 8: querier.v1.SelectMergeStacktracesRequest
-	(*SelectMergeStacktracesResponse)(nil), // 9: querier.v1.SelectMergeStacktracesResponse
-	(*PprofProfile)(nil),                   // 10: querier.v1.PprofProfile
-	(*AsyncQueryRequest)(nil),              // 11: querier.v1.AsyncQueryRequest
-	(*AsyncQueryResponse)(nil),             // 12: querier.v1.AsyncQueryResponse
-	(*SelectMergeSpanProfileRequest)(nil),  // 13: querier.v1.SelectMergeSpanProfileRequest
-	(*SelectMergeSpanProfileResponse)(nil), // 14: querier.v1.SelectMergeSpanProfileResponse
-	(*DiffRequest)(nil),                    // 15: querier.v1.DiffRequest
-	(*DiffResponse)(nil),                   // 16: querier.v1.DiffResponse
-	(*FlameGraph)(nil),                     // 17: querier.v1.FlameGraph
-	(*FlameGraphDiff)(nil),                 // 18: querier.v1.FlameGraphDiff
-	(*Level)(nil),                          // 19: querier.v1.Level
-	(*SelectMergeProfileRequest)(nil),      // 20: querier.v1.SelectMergeProfileRequest
-	(*SelectSeriesRequest)(nil),            // 21: querier.v1.SelectSeriesRequest
-	(*SelectSeriesResponse)(nil),           // 22: querier.v1.SelectSeriesResponse
-	(*SelectHeatmapRequest)(nil),           // 23: querier.v1.SelectHeatmapRequest
-	(*SelectHeatmapResponse)(nil),          // 24: querier.v1.SelectHeatmapResponse
-	(*AnalyzeQueryRequest)(nil),            // 25: querier.v1.AnalyzeQueryRequest
-	(*AnalyzeQueryResponse)(nil),           // 26: querier.v1.AnalyzeQueryResponse
-	(*QueryScope)(nil),                     // 27: querier.v1.QueryScope
-	(*QueryImpact)(nil),                    // 28: querier.v1.QueryImpact
-	(*v1.ProfileType)(nil),                 // 29: types.v1.ProfileType
-	(*v1.Labels)(nil),                      // 30: types.v1.Labels
-	(*v1.StackTraceSelector)(nil),          // 31: types.v1.StackTraceSelector
-	(*v11.Profile)(nil),                    // 32: google.v1.Profile
-	(v1.TimeSeriesAggregationType)(0),      // 33: types.v1.TimeSeriesAggregationType
-	(v1.ExemplarType)(0),                   // 34: types.v1.ExemplarType
-	(*v1.Series)(nil),                      // 35: types.v1.Series
-	(*v1.HeatmapSeries)(nil),               // 36: types.v1.HeatmapSeries
-	(*v1.LabelValuesRequest)(nil),          // 37: types.v1.LabelValuesRequest
-	(*v1.LabelNamesRequest)(nil),           // 38: types.v1.LabelNamesRequest
-	(*v1.GetProfileStatsRequest)(nil),      // 39: types.v1.GetProfileStatsRequest
-	(*v1.LabelValuesResponse)(nil),         // 40: types.v1.LabelValuesResponse
-	(*v1.LabelNamesResponse)(nil),          // 41: types.v1.LabelNamesResponse
-	(*v1.GetProfileStatsResponse)(nil),     // 42: types.v1.GetProfileStatsResponse
+	(ProfileFormat)(0),                           // 0: querier.v1.ProfileFormat
+	(AsyncQueryType)(0),                          // 1: querier.v1.AsyncQueryType
+	(AsyncQueryStatus)(0),                        // 2: querier.v1.AsyncQueryStatus
+	(HeatmapQueryType)(0),                        // 3: querier.v1.HeatmapQueryType
+	(*ProfileTypesRequest)(nil),                  // 4: querier.v1.ProfileTypesRequest
+	(*ProfileTypesResponse)(nil),                 // 5: querier.v1.ProfileTypesResponse
+	(*SeriesRequest)(nil),                        // 6: querier.v1.SeriesRequest
+	(*SeriesResponse)(nil),                       // 7: querier.v1.SeriesResponse
+	(*SelectMergeStacktracesRequest)(nil),        // 8: querier.v1.SelectMergeStacktracesRequest
+	(*SelectMergeStacktracesResponse)(nil),       // 9: querier.v1.SelectMergeStacktracesResponse
+	(*PprofProfile)(nil),                         // 10: querier.v1.PprofProfile
+	(*AsyncQueryRequest)(nil),                    // 11: querier.v1.AsyncQueryRequest
+	(*AsyncQueryResponse)(nil),                   // 12: querier.v1.AsyncQueryResponse
+	(*ListAsyncQueriesRequest)(nil),              // 13: querier.v1.ListAsyncQueriesRequest
+	(*ListAsyncQueriesResponse)(nil),             // 14: querier.v1.ListAsyncQueriesResponse
+	(*AsyncQueryInfo)(nil),                       // 15: querier.v1.AsyncQueryInfo
+	(*CancelAsyncQueryRequest)(nil),              // 16: querier.v1.CancelAsyncQueryRequest
+	(*CancelAsyncQueryResponse)(nil),             // 17: querier.v1.CancelAsyncQueryResponse
+	(*QueryProgress)(nil),                        // 18: querier.v1.QueryProgress
+	(*QuerySampling)(nil),                        // 19: querier.v1.QuerySampling
+	(*SelectMergeStacktracesStreamResponse)(nil), // 20: querier.v1.SelectMergeStacktracesStreamResponse
+	(*SelectSeriesStreamResponse)(nil),           // 21: querier.v1.SelectSeriesStreamResponse
+	(*SelectMergeSpanProfileRequest)(nil),        // 22: querier.v1.SelectMergeSpanProfileRequest
+	(*SelectMergeSpanProfileResponse)(nil),       // 23: querier.v1.SelectMergeSpanProfileResponse
+	(*DiffRequest)(nil),                          // 24: querier.v1.DiffRequest
+	(*DiffResponse)(nil),                         // 25: querier.v1.DiffResponse
+	(*FlameGraph)(nil),                           // 26: querier.v1.FlameGraph
+	(*FlameGraphDiff)(nil),                       // 27: querier.v1.FlameGraphDiff
+	(*Level)(nil),                                // 28: querier.v1.Level
+	(*SelectMergeProfileRequest)(nil),            // 29: querier.v1.SelectMergeProfileRequest
+	(*SelectSeriesRequest)(nil),                  // 30: querier.v1.SelectSeriesRequest
+	(*SelectSeriesResponse)(nil),                 // 31: querier.v1.SelectSeriesResponse
+	(*SelectHeatmapRequest)(nil),                 // 32: querier.v1.SelectHeatmapRequest
+	(*SelectHeatmapResponse)(nil),                // 33: querier.v1.SelectHeatmapResponse
+	(*AnalyzeQueryRequest)(nil),                  // 34: querier.v1.AnalyzeQueryRequest
+	(*AnalyzeQueryResponse)(nil),                 // 35: querier.v1.AnalyzeQueryResponse
+	(*LabelValuesCardinalityRequest)(nil),        // 36: querier.v1.LabelValuesCardinalityRequest
+	(*LabelValuesCardinalityResponse)(nil),       // 37: querier.v1.LabelValuesCardinalityResponse
+	(*LabelValueCardinality)(nil),                // 38: querier.v1.LabelValueCardinality
+	(*QueryScope)(nil),                           // 39: querier.v1.QueryScope
+	(*QueryImpact)(nil),                          // 40: querier.v1.QueryImpact
+	(*v1.ProfileType)(nil),                       // 41: types.v1.ProfileType
+	(*v1.Labels)(nil),                            // 42: types.v1.Labels
+	(*v1.StackTraceSelector)(nil),                // 43: types.v1.StackTraceSelector
+	(*v11.Profile)(nil),                          // 44: google.v1.Profile
+	(*v1.Series)(nil),                            // 45: types.v1.Series
+	(v1.TimeSeriesAggregationType)(0),            // 46: types.v1.TimeSeriesAggregationType
+	(v1.ExemplarType)(0),                         // 47: types.v1.ExemplarType
+	(*v1.HeatmapSeries)(nil),                     // 48: types.v1.HeatmapSeries
+	(*v1.LabelValuesRequest)(nil),                // 49: types.v1.LabelValuesRequest
+	(*v1.LabelNamesRequest)(nil),                 // 50: types.v1.LabelNamesRequest
+	(*v1.GetProfileStatsRequest)(nil),            // 51: types.v1.GetProfileStatsRequest
+	(*v1.LabelValuesResponse)(nil),               // 52: types.v1.LabelValuesResponse
+	(*v1.LabelNamesResponse)(nil),                // 53: types.v1.LabelNamesResponse
+	(*v1.GetProfileStatsResponse)(nil),           // 54: types.v1.GetProfileStatsResponse
 }
 var file_querier_v1_querier_proto_depIdxs = []int32{
-	29, // 0: querier.v1.ProfileTypesResponse.profile_types:type_name -> types.v1.ProfileType
-	30, // 1: querier.v1.SeriesResponse.labels_set:type_name -> types.v1.Labels
+	41, // 0: querier.v1.ProfileTypesResponse.profile_types:type_name -> types.v1.ProfileType
+	42, // 1: querier.v1.SeriesResponse.labels_set:type_name -> types.v1.Labels
 	0,  // 2: querier.v1.SelectMergeStacktracesRequest.format:type_name -> querier.v1.ProfileFormat
-	31, // 3: querier.v1.SelectMergeStacktracesRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
+	43, // 3: querier.v1.SelectMergeStacktracesRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
 	11, // 4: querier.v1.SelectMergeStacktracesRequest.async:type_name -> querier.v1.AsyncQueryRequest
-	17, // 5: querier.v1.SelectMergeStacktracesResponse.flamegraph:type_name -> querier.v1.FlameGraph
+	26, // 5: querier.v1.SelectMergeStacktracesResponse.flamegraph:type_name -> querier.v1.FlameGraph
 	12, // 6: querier.v1.SelectMergeStacktracesResponse.async:type_name -> querier.v1.AsyncQueryResponse
 	10, // 7: querier.v1.SelectMergeStacktracesResponse.pprof:type_name -> querier.v1.PprofProfile
-	32, // 8: querier.v1.PprofProfile.profile:type_name -> google.v1.Profile
-	1,  // 9: querier.v1.AsyncQueryRequest.type:type_name -> querier.v1.AsyncQueryType
-	2,  // 10: querier.v1.AsyncQueryResponse.status:type_name -> querier.v1.AsyncQueryStatus
-	0,  // 11: querier.v1.SelectMergeSpanProfileRequest.format:type_name -> querier.v1.ProfileFormat
-	17, // 12: querier.v1.SelectMergeSpanProfileResponse.flamegraph:type_name -> querier.v1.FlameGraph
-	8,  // 13: querier.v1.DiffRequest.left:type_name -> querier.v1.SelectMergeStacktracesRequest
-	8,  // 14: querier.v1.DiffRequest.right:type_name -> querier.v1.SelectMergeStacktracesRequest
-	18, // 15: querier.v1.DiffResponse.flamegraph:type_name -> querier.v1.FlameGraphDiff
-	19, // 16: querier.v1.FlameGraph.levels:type_name -> querier.v1.Level
-	19, // 17: querier.v1.FlameGraphDiff.levels:type_name -> querier.v1.Level
-	31, // 18: querier.v1.SelectMergeProfileRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
-	33, // 19: querier.v1.SelectSeriesRequest.aggregation:type_name -> types.v1.TimeSeriesAggregationType
-	31, // 20: querier.v1.SelectSeriesRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
-	34, // 21: querier.v1.SelectSeriesRequest.exemplar_type:type_name -> types.v1.ExemplarType
-	35, // 22: querier.v1.SelectSeriesResponse.series:type_name -> types.v1.Series
-	3,  // 23: querier.v1.SelectHeatmapRequest.query_type:type_name -> querier.v1.HeatmapQueryType
-	34, // 24: querier.v1.SelectHeatmapRequest.exemplar_type:type_name -> types.v1.ExemplarType
-	36, // 25: querier.v1.SelectHeatmapResponse.series:type_name -> types.v1.HeatmapSeries
-	27, // 26: querier.v1.AnalyzeQueryResponse.query_scopes:type_name -> querier.v1.QueryScope
-	28, // 27: querier.v1.AnalyzeQueryResponse.query_impact:type_name -> querier.v1.QueryImpact
-	4,  // 28: querier.v1.QuerierService.ProfileTypes:input_type -> querier.v1.ProfileTypesRequest
-	37, // 29: querier.v1.QuerierService.LabelValues:input_type -> types.v1.LabelValuesRequest
-	38, // 30: querier.v1.QuerierService.LabelNames:input_type -> types.v1.LabelNamesRequest
-	6,  // 31: querier.v1.QuerierService.Series:input_type -> querier.v1.SeriesRequest
-	8,  // 32: querier.v1.QuerierService.SelectMergeStacktraces:input_type -> querier.v1.SelectMergeStacktracesRequest
-	13, // 33: querier.v1.QuerierService.SelectMergeSpanProfile:input_type -> querier.v1.SelectMergeSpanProfileRequest
-	20, // 34: querier.v1.QuerierService.SelectMergeProfile:input_type -> querier.v1.SelectMergeProfileRequest
-	21, // 35: querier.v1.QuerierService.SelectSeries:input_type -> querier.v1.SelectSeriesRequest
-	23, // 36: querier.v1.QuerierService.SelectHeatmap:input_type -> querier.v1.SelectHeatmapRequest
-	15, // 37: querier.v1.QuerierService.Diff:input_type -> querier.v1.DiffRequest
-	39, // 38: querier.v1.QuerierService.GetProfileStats:input_type -> types.v1.GetProfileStatsRequest
-	25, // 39: querier.v1.QuerierService.AnalyzeQuery:input_type -> querier.v1.AnalyzeQueryRequest
-	5,  // 40: querier.v1.QuerierService.ProfileTypes:output_type -> querier.v1.ProfileTypesResponse
-	40, // 41: querier.v1.QuerierService.LabelValues:output_type -> types.v1.LabelValuesResponse
-	41, // 42: querier.v1.QuerierService.LabelNames:output_type -> types.v1.LabelNamesResponse
-	7,  // 43: querier.v1.QuerierService.Series:output_type -> querier.v1.SeriesResponse
-	9,  // 44: querier.v1.QuerierService.SelectMergeStacktraces:output_type -> querier.v1.SelectMergeStacktracesResponse
-	14, // 45: querier.v1.QuerierService.SelectMergeSpanProfile:output_type -> querier.v1.SelectMergeSpanProfileResponse
-	32, // 46: querier.v1.QuerierService.SelectMergeProfile:output_type -> google.v1.Profile
-	22, // 47: querier.v1.QuerierService.SelectSeries:output_type -> querier.v1.SelectSeriesResponse
-	24, // 48: querier.v1.QuerierService.SelectHeatmap:output_type -> querier.v1.SelectHeatmapResponse
-	16, // 49: querier.v1.QuerierService.Diff:output_type -> querier.v1.DiffResponse
-	42, // 50: querier.v1.QuerierService.GetProfileStats:output_type -> types.v1.GetProfileStatsResponse
-	26, // 51: querier.v1.QuerierService.AnalyzeQuery:output_type -> querier.v1.AnalyzeQueryResponse
-	40, // [40:52] is the sub-list for method output_type
-	28, // [28:40] is the sub-list for method input_type
-	28, // [28:28] is the sub-list for extension type_name
-	28, // [28:28] is the sub-list for extension extendee
-	0,  // [0:28] is the sub-list for field type_name
+	19, // 8: querier.v1.SelectMergeStacktracesResponse.sampling:type_name -> querier.v1.QuerySampling
+	44, // 9: querier.v1.PprofProfile.profile:type_name -> google.v1.Profile
+	1,  // 10: querier.v1.AsyncQueryRequest.type:type_name -> querier.v1.AsyncQueryType
+	2,  // 11: querier.v1.AsyncQueryResponse.status:type_name -> querier.v1.AsyncQueryStatus
+	15, // 12: querier.v1.ListAsyncQueriesResponse.queries:type_name -> querier.v1.AsyncQueryInfo
+	2,  // 13: querier.v1.AsyncQueryInfo.status:type_name -> querier.v1.AsyncQueryStatus
+	2,  // 14: querier.v1.CancelAsyncQueryResponse.status:type_name -> querier.v1.AsyncQueryStatus
+	26, // 15: querier.v1.SelectMergeStacktracesStreamResponse.flamegraph:type_name -> querier.v1.FlameGraph
+	18, // 16: querier.v1.SelectMergeStacktracesStreamResponse.progress:type_name -> querier.v1.QueryProgress
+	45, // 17: querier.v1.SelectSeriesStreamResponse.series:type_name -> types.v1.Series
+	18, // 18: querier.v1.SelectSeriesStreamResponse.progress:type_name -> querier.v1.QueryProgress
+	0,  // 19: querier.v1.SelectMergeSpanProfileRequest.format:type_name -> querier.v1.ProfileFormat
+	11, // 20: querier.v1.SelectMergeSpanProfileRequest.async:type_name -> querier.v1.AsyncQueryRequest
+	26, // 21: querier.v1.SelectMergeSpanProfileResponse.flamegraph:type_name -> querier.v1.FlameGraph
+	12, // 22: querier.v1.SelectMergeSpanProfileResponse.async:type_name -> querier.v1.AsyncQueryResponse
+	8,  // 23: querier.v1.DiffRequest.left:type_name -> querier.v1.SelectMergeStacktracesRequest
+	8,  // 24: querier.v1.DiffRequest.right:type_name -> querier.v1.SelectMergeStacktracesRequest
+	11, // 25: querier.v1.DiffRequest.async:type_name -> querier.v1.AsyncQueryRequest
+	27, // 26: querier.v1.DiffResponse.flamegraph:type_name -> querier.v1.FlameGraphDiff
+	12, // 27: querier.v1.DiffResponse.async:type_name -> querier.v1.AsyncQueryResponse
+	28, // 28: querier.v1.FlameGraph.levels:type_name -> querier.v1.Level
+	28, // 29: querier.v1.FlameGraphDiff.levels:type_name -> querier.v1.Level
+	43, // 30: querier.v1.SelectMergeProfileRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
+	11, // 31: querier.v1.SelectMergeProfileRequest.async:type_name -> querier.v1.AsyncQueryRequest
+	46, // 32: querier.v1.SelectSeriesRequest.aggregation:type_name -> types.v1.TimeSeriesAggregationType
+	43, // 33: querier.v1.SelectSeriesRequest.stack_trace_selector:type_name -> types.v1.StackTraceSelector
+	47, // 34: querier.v1.SelectSeriesRequest.exemplar_type:type_name -> types.v1.ExemplarType
+	11, // 35: querier.v1.SelectSeriesRequest.async:type_name -> querier.v1.AsyncQueryRequest
+	45, // 36: querier.v1.SelectSeriesResponse.series:type_name -> types.v1.Series
+	12, // 37: querier.v1.SelectSeriesResponse.async:type_name -> querier.v1.AsyncQueryResponse
+	19, // 38: querier.v1.SelectSeriesResponse.sampling:type_name -> querier.v1.QuerySampling
+	3,  // 39: querier.v1.SelectHeatmapRequest.query_type:type_name -> querier.v1.HeatmapQueryType
+	47, // 40: querier.v1.SelectHeatmapRequest.exemplar_type:type_name -> types.v1.ExemplarType
+	11, // 41: querier.v1.SelectHeatmapRequest.async:type_name -> querier.v1.AsyncQueryRequest
+	48, // 42: querier.v1.SelectHeatmapResponse.series:type_name -> types.v1.HeatmapSeries
+	12, // 43: querier.v1.SelectHeatmapResponse.async:type_name -> querier.v1.AsyncQueryResponse
+	39, // 44: querier.v1.AnalyzeQueryResponse.query_scopes:type_name -> querier.v1.QueryScope
+	40, // 45: querier.v1.AnalyzeQueryResponse.query_impact:type_name -> querier.v1.QueryImpact
+	38, // 46: querier.v1.LabelValuesCardinalityResponse.values:type_name -> querier.v1.LabelValueCardinality
+	4,  // 47: querier.v1.QuerierService.ProfileTypes:input_type -> querier.v1.ProfileTypesRequest
+	49, // 48: querier.v1.QuerierService.LabelValues:input_type -> types.v1.LabelValuesRequest
+	50, // 49: querier.v1.QuerierService.LabelNames:input_type -> types.v1.LabelNamesRequest
+	6,  // 50: querier.v1.QuerierService.Series:input_type -> querier.v1.SeriesRequest
+	8,  // 51: querier.v1.QuerierService.SelectMergeStacktraces:input_type -> querier.v1.SelectMergeStacktracesRequest
+	22, // 52: querier.v1.QuerierService.SelectMergeSpanProfile:input_type -> querier.v1.SelectMergeSpanProfileRequest
+	29, // 53: querier.v1.QuerierService.SelectMergeProfile:input_type -> querier.v1.SelectMergeProfileRequest
+	30, // 54: querier.v1.QuerierService.SelectSeries:input_type -> querier.v1.SelectSeriesRequest
+	32, // 55: querier.v1.QuerierService.SelectHeatmap:input_type -> querier.v1.SelectHeatmapRequest
+	24, // 56: querier.v1.QuerierService.Diff:input_type -> querier.v1.DiffRequest
+	51, // 57: querier.v1.QuerierService.GetProfileStats:input_type -> types.v1.GetProfileStatsRequest
+	34, // 58: querier.v1.QuerierService.AnalyzeQuery:input_type -> querier.v1.AnalyzeQueryRequest
+	36, // 59: querier.v1.QuerierService.LabelValuesCardinality:input_type -> querier.v1.LabelValuesCardinalityRequest
+	13, // 60: querier.v1.AsyncQueryService.ListAsyncQueries:input_type -> querier.v1.ListAsyncQueriesRequest
+	16, // 61: querier.v1.AsyncQueryService.CancelAsyncQuery:input_type -> querier.v1.CancelAsyncQueryRequest
+	8,  // 62: querier.v1.StreamingQueryService.SelectMergeStacktracesStream:input_type -> querier.v1.SelectMergeStacktracesRequest
+	30, // 63: querier.v1.StreamingQueryService.SelectSeriesStream:input_type -> querier.v1.SelectSeriesRequest
+	5,  // 64: querier.v1.QuerierService.ProfileTypes:output_type -> querier.v1.ProfileTypesResponse
+	52, // 65: querier.v1.QuerierService.LabelValues:output_type -> types.v1.LabelValuesResponse
+	53, // 66: querier.v1.QuerierService.LabelNames:output_type -> types.v1.LabelNamesResponse
+	7,  // 67: querier.v1.QuerierService.Series:output_type -> querier.v1.SeriesResponse
+	9,  // 68: querier.v1.QuerierService.SelectMergeStacktraces:output_type -> querier.v1.SelectMergeStacktracesResponse
+	23, // 69: querier.v1.QuerierService.SelectMergeSpanProfile:output_type -> querier.v1.SelectMergeSpanProfileResponse
+	44, // 70: querier.v1.QuerierService.SelectMergeProfile:output_type -> google.v1.Profile
+	31, // 71: querier.v1.QuerierService.SelectSeries:output_type -> querier.v1.SelectSeriesResponse
+	33, // 72: querier.v1.QuerierService.SelectHeatmap:output_type -> querier.v1.SelectHeatmapResponse
+	25, // 73: querier.v1.QuerierService.Diff:output_type -> querier.v1.DiffResponse
+	54, // 74: querier.v1.QuerierService.GetProfileStats:output_type -> types.v1.GetProfileStatsResponse
+	35, // 75: querier.v1.QuerierService.AnalyzeQuery:output_type -> querier.v1.AnalyzeQueryResponse
+	37, // 76: querier.v1.QuerierService.LabelValuesCardinality:output_type -> querier.v1.LabelValuesCardinalityResponse
+	14, // 77: querier.v1.AsyncQueryService.ListAsyncQueries:output_type -> querier.v1.ListAsyncQueriesResponse
+	17, // 78: querier.v1.AsyncQueryService.CancelAsyncQuery:output_type -> querier.v1.CancelAsyncQueryResponse
+	20, // 79: querier.v1.StreamingQueryService.SelectMergeStacktracesStream:output_type -> querier.v1.SelectMergeStacktracesStreamResponse
+	21, // 80: querier.v1.StreamingQueryService.SelectSeriesStream:output_type -> querier.v1.SelectSeriesStreamResponse
+	64, // [64:81] is the sub-list for method output_type
+	47, // [47:64] is the sub-list for method input_type
+	47, // [47:47] is the sub-list for extension type_name
+	47, // [47:47] is the sub-list for extension extendee
+	0,  // [0:47] is the sub-list for field type_name
 }
 
 func init() { file_querier_v1_querier_proto_init() }
@@ -2349,19 +3341,24 @@ func file_querier_v1_querier_proto_init() {
 	}
 	file_querier_v1_querier_proto_msgTypes[4].OneofWrappers = []any{}
 	file_querier_v1_querier_proto_msgTypes[5].OneofWrappers = []any{}
-	file_querier_v1_querier_proto_msgTypes[9].OneofWrappers = []any{}
-	file_querier_v1_querier_proto_msgTypes[16].OneofWrappers = []any{}
-	file_querier_v1_querier_proto_msgTypes[17].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[18].OneofWrappers = []any{}
 	file_querier_v1_querier_proto_msgTypes[19].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[20].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[21].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[25].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[26].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[27].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[28].OneofWrappers = []any{}
+	file_querier_v1_querier_proto_msgTypes[29].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_querier_v1_querier_proto_rawDesc), len(file_querier_v1_querier_proto_rawDesc)),
 			NumEnums:      4,
-			NumMessages:   25,
+			NumMessages:   37,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   3,
 		},
 		GoTypes:           file_querier_v1_querier_proto_goTypes,
 		DependencyIndexes: file_querier_v1_querier_proto_depIdxs,
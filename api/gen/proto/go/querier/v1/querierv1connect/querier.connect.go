@@ -27,6 +27,10 @@ const _ = connect.IsAtLeastVersion1_13_0
 const (
 	// QuerierServiceName is the fully-qualified name of the QuerierService service.
 	QuerierServiceName = "querier.v1.QuerierService"
+	// AsyncQueryServiceName is the fully-qualified name of the AsyncQueryService service.
+	AsyncQueryServiceName = "querier.v1.AsyncQueryService"
+	// StreamingQueryServiceName is the fully-qualified name of the StreamingQueryService service.
+	StreamingQueryServiceName = "querier.v1.StreamingQueryService"
 )
 
 // These constants are the fully-qualified names of the RPCs defined in this package. They're
@@ -71,6 +75,21 @@ const (
 	// QuerierServiceAnalyzeQueryProcedure is the fully-qualified name of the QuerierService's
 	// AnalyzeQuery RPC.
 	QuerierServiceAnalyzeQueryProcedure = "/querier.v1.QuerierService/AnalyzeQuery"
+	// QuerierServiceLabelValuesCardinalityProcedure is the fully-qualified name of the QuerierService's
+	// LabelValuesCardinality RPC.
+	QuerierServiceLabelValuesCardinalityProcedure = "/querier.v1.QuerierService/LabelValuesCardinality"
+	// AsyncQueryServiceListAsyncQueriesProcedure is the fully-qualified name of the AsyncQueryService's
+	// ListAsyncQueries RPC.
+	AsyncQueryServiceListAsyncQueriesProcedure = "/querier.v1.AsyncQueryService/ListAsyncQueries"
+	// AsyncQueryServiceCancelAsyncQueryProcedure is the fully-qualified name of the AsyncQueryService's
+	// CancelAsyncQuery RPC.
+	AsyncQueryServiceCancelAsyncQueryProcedure = "/querier.v1.AsyncQueryService/CancelAsyncQuery"
+	// StreamingQueryServiceSelectMergeStacktracesStreamProcedure is the fully-qualified name of the
+	// StreamingQueryService's SelectMergeStacktracesStream RPC.
+	StreamingQueryServiceSelectMergeStacktracesStreamProcedure = "/querier.v1.StreamingQueryService/SelectMergeStacktracesStream"
+	// StreamingQueryServiceSelectSeriesStreamProcedure is the fully-qualified name of the
+	// StreamingQueryService's SelectSeriesStream RPC.
+	StreamingQueryServiceSelectSeriesStreamProcedure = "/querier.v1.StreamingQueryService/SelectSeriesStream"
 )
 
 // QuerierServiceClient is a client for the querier.v1.QuerierService service.
@@ -113,6 +132,13 @@ type QuerierServiceClient interface {
 	// GetProfileStats returns profile stats for the current tenant.
 	GetProfileStats(context.Context, *connect.Request[v11.GetProfileStatsRequest]) (*connect.Response[v11.GetProfileStatsResponse], error)
 	AnalyzeQuery(context.Context, *connect.Request[v1.AnalyzeQueryRequest]) (*connect.Response[v1.AnalyzeQueryResponse], error)
+	// LabelValuesCardinality returns the values of the label along with their
+	// approximate cardinality, intended for autocompletion. The values are
+	// resolved from the dataset labels stored in the metastore, without
+	// accessing the blocks: only labels recorded in the dataset metadata are
+	// supported.
+	// Note: This endpoint is only available in the v2 storage layer
+	LabelValuesCardinality(context.Context, *connect.Request[v1.LabelValuesCardinalityRequest]) (*connect.Response[v1.LabelValuesCardinalityResponse], error)
 }
 
 // NewQuerierServiceClient constructs a client for the querier.v1.QuerierService service. By
@@ -198,6 +224,12 @@ func NewQuerierServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(querierServiceMethods.ByName("AnalyzeQuery")),
 			connect.WithClientOptions(opts...),
 		),
+		labelValuesCardinality: connect.NewClient[v1.LabelValuesCardinalityRequest, v1.LabelValuesCardinalityResponse](
+			httpClient,
+			baseURL+QuerierServiceLabelValuesCardinalityProcedure,
+			connect.WithSchema(querierServiceMethods.ByName("LabelValuesCardinality")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
@@ -215,6 +247,7 @@ type querierServiceClient struct {
 	diff                   *connect.Client[v1.DiffRequest, v1.DiffResponse]
 	getProfileStats        *connect.Client[v11.GetProfileStatsRequest, v11.GetProfileStatsResponse]
 	analyzeQuery           *connect.Client[v1.AnalyzeQueryRequest, v1.AnalyzeQueryResponse]
+	labelValuesCardinality *connect.Client[v1.LabelValuesCardinalityRequest, v1.LabelValuesCardinalityResponse]
 }
 
 // ProfileTypes calls querier.v1.QuerierService.ProfileTypes.
@@ -277,6 +310,11 @@ func (c *querierServiceClient) AnalyzeQuery(ctx context.Context, req *connect.Re
 	return c.analyzeQuery.CallUnary(ctx, req)
 }
 
+// LabelValuesCardinality calls querier.v1.QuerierService.LabelValuesCardinality.
+func (c *querierServiceClient) LabelValuesCardinality(ctx context.Context, req *connect.Request[v1.LabelValuesCardinalityRequest]) (*connect.Response[v1.LabelValuesCardinalityResponse], error) {
+	return c.labelValuesCardinality.CallUnary(ctx, req)
+}
+
 // QuerierServiceHandler is an implementation of the querier.v1.QuerierService service.
 type QuerierServiceHandler interface {
 	// ProfileType returns a list of the existing profile types.
@@ -317,6 +355,13 @@ type QuerierServiceHandler interface {
 	// GetProfileStats returns profile stats for the current tenant.
 	GetProfileStats(context.Context, *connect.Request[v11.GetProfileStatsRequest]) (*connect.Response[v11.GetProfileStatsResponse], error)
 	AnalyzeQuery(context.Context, *connect.Request[v1.AnalyzeQueryRequest]) (*connect.Response[v1.AnalyzeQueryResponse], error)
+	// LabelValuesCardinality returns the values of the label along with their
+	// approximate cardinality, intended for autocompletion. The values are
+	// resolved from the dataset labels stored in the metastore, without
+	// accessing the blocks: only labels recorded in the dataset metadata are
+	// supported.
+	// Note: This endpoint is only available in the v2 storage layer
+	LabelValuesCardinality(context.Context, *connect.Request[v1.LabelValuesCardinalityRequest]) (*connect.Response[v1.LabelValuesCardinalityResponse], error)
 }
 
 // NewQuerierServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -398,6 +443,12 @@ func NewQuerierServiceHandler(svc QuerierServiceHandler, opts ...connect.Handler
 		connect.WithSchema(querierServiceMethods.ByName("AnalyzeQuery")),
 		connect.WithHandlerOptions(opts...),
 	)
+	querierServiceLabelValuesCardinalityHandler := connect.NewUnaryHandler(
+		QuerierServiceLabelValuesCardinalityProcedure,
+		svc.LabelValuesCardinality,
+		connect.WithSchema(querierServiceMethods.ByName("LabelValuesCardinality")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/querier.v1.QuerierService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case QuerierServiceProfileTypesProcedure:
@@ -424,6 +475,8 @@ func NewQuerierServiceHandler(svc QuerierServiceHandler, opts ...connect.Handler
 			querierServiceGetProfileStatsHandler.ServeHTTP(w, r)
 		case QuerierServiceAnalyzeQueryProcedure:
 			querierServiceAnalyzeQueryHandler.ServeHTTP(w, r)
+		case QuerierServiceLabelValuesCardinalityProcedure:
+			querierServiceLabelValuesCardinalityHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -480,3 +533,212 @@ func (UnimplementedQuerierServiceHandler) GetProfileStats(context.Context, *conn
 func (UnimplementedQuerierServiceHandler) AnalyzeQuery(context.Context, *connect.Request[v1.AnalyzeQueryRequest]) (*connect.Response[v1.AnalyzeQueryResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("querier.v1.QuerierService.AnalyzeQuery is not implemented"))
 }
+
+func (UnimplementedQuerierServiceHandler) LabelValuesCardinality(context.Context, *connect.Request[v1.LabelValuesCardinalityRequest]) (*connect.Response[v1.LabelValuesCardinalityResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("querier.v1.QuerierService.LabelValuesCardinality is not implemented"))
+}
+
+// AsyncQueryServiceClient is a client for the querier.v1.AsyncQueryService service.
+type AsyncQueryServiceClient interface {
+	// ListAsyncQueries returns the async queries of the current tenant that
+	// have not expired yet.
+	ListAsyncQueries(context.Context, *connect.Request[v1.ListAsyncQueriesRequest]) (*connect.Response[v1.ListAsyncQueriesResponse], error)
+	// CancelAsyncQuery cancels an async query that is still in progress.
+	CancelAsyncQuery(context.Context, *connect.Request[v1.CancelAsyncQueryRequest]) (*connect.Response[v1.CancelAsyncQueryResponse], error)
+}
+
+// NewAsyncQueryServiceClient constructs a client for the querier.v1.AsyncQueryService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewAsyncQueryServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) AsyncQueryServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	asyncQueryServiceMethods := v1.File_querier_v1_querier_proto.Services().ByName("AsyncQueryService").Methods()
+	return &asyncQueryServiceClient{
+		listAsyncQueries: connect.NewClient[v1.ListAsyncQueriesRequest, v1.ListAsyncQueriesResponse](
+			httpClient,
+			baseURL+AsyncQueryServiceListAsyncQueriesProcedure,
+			connect.WithSchema(asyncQueryServiceMethods.ByName("ListAsyncQueries")),
+			connect.WithClientOptions(opts...),
+		),
+		cancelAsyncQuery: connect.NewClient[v1.CancelAsyncQueryRequest, v1.CancelAsyncQueryResponse](
+			httpClient,
+			baseURL+AsyncQueryServiceCancelAsyncQueryProcedure,
+			connect.WithSchema(asyncQueryServiceMethods.ByName("CancelAsyncQuery")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// asyncQueryServiceClient implements AsyncQueryServiceClient.
+type asyncQueryServiceClient struct {
+	listAsyncQueries *connect.Client[v1.ListAsyncQueriesRequest, v1.ListAsyncQueriesResponse]
+	cancelAsyncQuery *connect.Client[v1.CancelAsyncQueryRequest, v1.CancelAsyncQueryResponse]
+}
+
+// ListAsyncQueries calls querier.v1.AsyncQueryService.ListAsyncQueries.
+func (c *asyncQueryServiceClient) ListAsyncQueries(ctx context.Context, req *connect.Request[v1.ListAsyncQueriesRequest]) (*connect.Response[v1.ListAsyncQueriesResponse], error) {
+	return c.listAsyncQueries.CallUnary(ctx, req)
+}
+
+// CancelAsyncQuery calls querier.v1.AsyncQueryService.CancelAsyncQuery.
+func (c *asyncQueryServiceClient) CancelAsyncQuery(ctx context.Context, req *connect.Request[v1.CancelAsyncQueryRequest]) (*connect.Response[v1.CancelAsyncQueryResponse], error) {
+	return c.cancelAsyncQuery.CallUnary(ctx, req)
+}
+
+// AsyncQueryServiceHandler is an implementation of the querier.v1.AsyncQueryService service.
+type AsyncQueryServiceHandler interface {
+	// ListAsyncQueries returns the async queries of the current tenant that
+	// have not expired yet.
+	ListAsyncQueries(context.Context, *connect.Request[v1.ListAsyncQueriesRequest]) (*connect.Response[v1.ListAsyncQueriesResponse], error)
+	// CancelAsyncQuery cancels an async query that is still in progress.
+	CancelAsyncQuery(context.Context, *connect.Request[v1.CancelAsyncQueryRequest]) (*connect.Response[v1.CancelAsyncQueryResponse], error)
+}
+
+// NewAsyncQueryServiceHandler builds an HTTP handler from the service implementation. It returns
+// the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewAsyncQueryServiceHandler(svc AsyncQueryServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	asyncQueryServiceMethods := v1.File_querier_v1_querier_proto.Services().ByName("AsyncQueryService").Methods()
+	asyncQueryServiceListAsyncQueriesHandler := connect.NewUnaryHandler(
+		AsyncQueryServiceListAsyncQueriesProcedure,
+		svc.ListAsyncQueries,
+		connect.WithSchema(asyncQueryServiceMethods.ByName("ListAsyncQueries")),
+		connect.WithHandlerOptions(opts...),
+	)
+	asyncQueryServiceCancelAsyncQueryHandler := connect.NewUnaryHandler(
+		AsyncQueryServiceCancelAsyncQueryProcedure,
+		svc.CancelAsyncQuery,
+		connect.WithSchema(asyncQueryServiceMethods.ByName("CancelAsyncQuery")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/querier.v1.AsyncQueryService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case AsyncQueryServiceListAsyncQueriesProcedure:
+			asyncQueryServiceListAsyncQueriesHandler.ServeHTTP(w, r)
+		case AsyncQueryServiceCancelAsyncQueryProcedure:
+			asyncQueryServiceCancelAsyncQueryHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedAsyncQueryServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedAsyncQueryServiceHandler struct{}
+
+func (UnimplementedAsyncQueryServiceHandler) ListAsyncQueries(context.Context, *connect.Request[v1.ListAsyncQueriesRequest]) (*connect.Response[v1.ListAsyncQueriesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("querier.v1.AsyncQueryService.ListAsyncQueries is not implemented"))
+}
+
+func (UnimplementedAsyncQueryServiceHandler) CancelAsyncQuery(context.Context, *connect.Request[v1.CancelAsyncQueryRequest]) (*connect.Response[v1.CancelAsyncQueryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("querier.v1.AsyncQueryService.CancelAsyncQuery is not implemented"))
+}
+
+// StreamingQueryServiceClient is a client for the querier.v1.StreamingQueryService service.
+type StreamingQueryServiceClient interface {
+	// SelectMergeStacktracesStream streams progressively merged flamegraphs.
+	// Only the flamegraph and tree formats are supported.
+	SelectMergeStacktracesStream(context.Context, *connect.Request[v1.SelectMergeStacktracesRequest]) (*connect.ServerStreamForClient[v1.SelectMergeStacktracesStreamResponse], error)
+	// SelectSeriesStream streams progressively merged time series.
+	SelectSeriesStream(context.Context, *connect.Request[v1.SelectSeriesRequest]) (*connect.ServerStreamForClient[v1.SelectSeriesStreamResponse], error)
+}
+
+// NewStreamingQueryServiceClient constructs a client for the querier.v1.StreamingQueryService
+// service. By default, it uses the Connect protocol with the binary Protobuf Codec, asks for
+// gzipped responses, and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply
+// the connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewStreamingQueryServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) StreamingQueryServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	streamingQueryServiceMethods := v1.File_querier_v1_querier_proto.Services().ByName("StreamingQueryService").Methods()
+	return &streamingQueryServiceClient{
+		selectMergeStacktracesStream: connect.NewClient[v1.SelectMergeStacktracesRequest, v1.SelectMergeStacktracesStreamResponse](
+			httpClient,
+			baseURL+StreamingQueryServiceSelectMergeStacktracesStreamProcedure,
+			connect.WithSchema(streamingQueryServiceMethods.ByName("SelectMergeStacktracesStream")),
+			connect.WithClientOptions(opts...),
+		),
+		selectSeriesStream: connect.NewClient[v1.SelectSeriesRequest, v1.SelectSeriesStreamResponse](
+			httpClient,
+			baseURL+StreamingQueryServiceSelectSeriesStreamProcedure,
+			connect.WithSchema(streamingQueryServiceMethods.ByName("SelectSeriesStream")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// streamingQueryServiceClient implements StreamingQueryServiceClient.
+type streamingQueryServiceClient struct {
+	selectMergeStacktracesStream *connect.Client[v1.SelectMergeStacktracesRequest, v1.SelectMergeStacktracesStreamResponse]
+	selectSeriesStream           *connect.Client[v1.SelectSeriesRequest, v1.SelectSeriesStreamResponse]
+}
+
+// SelectMergeStacktracesStream calls querier.v1.StreamingQueryService.SelectMergeStacktracesStream.
+func (c *streamingQueryServiceClient) SelectMergeStacktracesStream(ctx context.Context, req *connect.Request[v1.SelectMergeStacktracesRequest]) (*connect.ServerStreamForClient[v1.SelectMergeStacktracesStreamResponse], error) {
+	return c.selectMergeStacktracesStream.CallServerStream(ctx, req)
+}
+
+// SelectSeriesStream calls querier.v1.StreamingQueryService.SelectSeriesStream.
+func (c *streamingQueryServiceClient) SelectSeriesStream(ctx context.Context, req *connect.Request[v1.SelectSeriesRequest]) (*connect.ServerStreamForClient[v1.SelectSeriesStreamResponse], error) {
+	return c.selectSeriesStream.CallServerStream(ctx, req)
+}
+
+// StreamingQueryServiceHandler is an implementation of the querier.v1.StreamingQueryService
+// service.
+type StreamingQueryServiceHandler interface {
+	// SelectMergeStacktracesStream streams progressively merged flamegraphs.
+	// Only the flamegraph and tree formats are supported.
+	SelectMergeStacktracesStream(context.Context, *connect.Request[v1.SelectMergeStacktracesRequest], *connect.ServerStream[v1.SelectMergeStacktracesStreamResponse]) error
+	// SelectSeriesStream streams progressively merged time series.
+	SelectSeriesStream(context.Context, *connect.Request[v1.SelectSeriesRequest], *connect.ServerStream[v1.SelectSeriesStreamResponse]) error
+}
+
+// NewStreamingQueryServiceHandler builds an HTTP handler from the service implementation. It
+// returns the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewStreamingQueryServiceHandler(svc StreamingQueryServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	streamingQueryServiceMethods := v1.File_querier_v1_querier_proto.Services().ByName("StreamingQueryService").Methods()
+	streamingQueryServiceSelectMergeStacktracesStreamHandler := connect.NewServerStreamHandler(
+		StreamingQueryServiceSelectMergeStacktracesStreamProcedure,
+		svc.SelectMergeStacktracesStream,
+		connect.WithSchema(streamingQueryServiceMethods.ByName("SelectMergeStacktracesStream")),
+		connect.WithHandlerOptions(opts...),
+	)
+	streamingQueryServiceSelectSeriesStreamHandler := connect.NewServerStreamHandler(
+		StreamingQueryServiceSelectSeriesStreamProcedure,
+		svc.SelectSeriesStream,
+		connect.WithSchema(streamingQueryServiceMethods.ByName("SelectSeriesStream")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/querier.v1.StreamingQueryService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case StreamingQueryServiceSelectMergeStacktracesStreamProcedure:
+			streamingQueryServiceSelectMergeStacktracesStreamHandler.ServeHTTP(w, r)
+		case StreamingQueryServiceSelectSeriesStreamProcedure:
+			streamingQueryServiceSelectSeriesStreamHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedStreamingQueryServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedStreamingQueryServiceHandler struct{}
+
+func (UnimplementedStreamingQueryServiceHandler) SelectMergeStacktracesStream(context.Context, *connect.Request[v1.SelectMergeStacktracesRequest], *connect.ServerStream[v1.SelectMergeStacktracesStreamResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("querier.v1.StreamingQueryService.SelectMergeStacktracesStream is not implemented"))
+}
+
+func (UnimplementedStreamingQueryServiceHandler) SelectSeriesStream(context.Context, *connect.Request[v1.SelectSeriesRequest], *connect.ServerStream[v1.SelectSeriesStreamResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("querier.v1.StreamingQueryService.SelectSeriesStream is not implemented"))
+}
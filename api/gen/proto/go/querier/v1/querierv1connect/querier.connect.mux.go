@@ -79,4 +79,39 @@ func RegisterQuerierServiceHandler(mux *mux.Router, svc QuerierServiceHandler, o
 		svc.AnalyzeQuery,
 		opts...,
 	))
+	mux.Handle("/querier.v1.QuerierService/LabelValuesCardinality", connect.NewUnaryHandler(
+		"/querier.v1.QuerierService/LabelValuesCardinality",
+		svc.LabelValuesCardinality,
+		opts...,
+	))
+}
+
+// RegisterAsyncQueryServiceHandler register an HTTP handler to a mux.Router from the service
+// implementation.
+func RegisterAsyncQueryServiceHandler(mux *mux.Router, svc AsyncQueryServiceHandler, opts ...connect.HandlerOption) {
+	mux.Handle("/querier.v1.AsyncQueryService/ListAsyncQueries", connect.NewUnaryHandler(
+		"/querier.v1.AsyncQueryService/ListAsyncQueries",
+		svc.ListAsyncQueries,
+		opts...,
+	))
+	mux.Handle("/querier.v1.AsyncQueryService/CancelAsyncQuery", connect.NewUnaryHandler(
+		"/querier.v1.AsyncQueryService/CancelAsyncQuery",
+		svc.CancelAsyncQuery,
+		opts...,
+	))
+}
+
+// RegisterStreamingQueryServiceHandler register an HTTP handler to a mux.Router from the service
+// implementation.
+func RegisterStreamingQueryServiceHandler(mux *mux.Router, svc StreamingQueryServiceHandler, opts ...connect.HandlerOption) {
+	mux.Handle("/querier.v1.StreamingQueryService/SelectMergeStacktracesStream", connect.NewServerStreamHandler(
+		"/querier.v1.StreamingQueryService/SelectMergeStacktracesStream",
+		svc.SelectMergeStacktracesStream,
+		opts...,
+	))
+	mux.Handle("/querier.v1.StreamingQueryService/SelectSeriesStream", connect.NewServerStreamHandler(
+		"/querier.v1.StreamingQueryService/SelectSeriesStream",
+		svc.SelectSeriesStream,
+		opts...,
+	))
 }
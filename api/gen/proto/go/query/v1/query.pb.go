@@ -7,10 +7,10 @@
 package queryv1
 
 import (
-	v12 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	v13 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	v1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
-	v13 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
-	v11 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	v11 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	v12 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -437,8 +437,10 @@ type InvokeRequest struct {
 	Query         []*Query               `protobuf:"bytes,5,rep,name=query,proto3" json:"query,omitempty"`
 	QueryPlan     *QueryPlan             `protobuf:"bytes,6,opt,name=query_plan,json=queryPlan,proto3" json:"query_plan,omitempty"`
 	Options       *InvokeOptions         `protobuf:"bytes,7,opt,name=options,proto3" json:"options,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	// Series deleted but not yet removed from the blocks.
+	SeriesTombstones []*v1.SeriesTombstone `protobuf:"bytes,8,rep,name=series_tombstones,json=seriesTombstones,proto3" json:"series_tombstones,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *InvokeRequest) Reset() {
@@ -520,6 +522,13 @@ func (x *InvokeRequest) GetOptions() *InvokeOptions {
 	return nil
 }
 
+func (x *InvokeRequest) GetSeriesTombstones() []*v1.SeriesTombstone {
+	if x != nil {
+		return x.SeriesTombstones
+	}
+	return nil
+}
+
 // A query plan is represented by a directed acyclic graph (DAG),
 // where each node is either a "merge" node or a "read" node.
 //
@@ -791,6 +800,68 @@ func (x *InvokeResponse) GetDiagnostics() *Diagnostics {
 	return nil
 }
 
+type InvokeStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Partial responses only include the time series and tree reports.
+	Response *InvokeResponse    `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	Progress *v11.QueryProgress `protobuf:"bytes,2,opt,name=progress,proto3" json:"progress,omitempty"`
+	// Set in the last message, which carries the complete response.
+	Final         bool `protobuf:"varint,3,opt,name=final,proto3" json:"final,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvokeStreamResponse) Reset() {
+	*x = InvokeStreamResponse{}
+	mi := &file_query_v1_query_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvokeStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvokeStreamResponse) ProtoMessage() {}
+
+func (x *InvokeStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_query_v1_query_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvokeStreamResponse.ProtoReflect.Descriptor instead.
+func (*InvokeStreamResponse) Descriptor() ([]byte, []int) {
+	return file_query_v1_query_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *InvokeStreamResponse) GetResponse() *InvokeResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *InvokeStreamResponse) GetProgress() *v11.QueryProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *InvokeStreamResponse) GetFinal() bool {
+	if x != nil {
+		return x.Final
+	}
+	return false
+}
+
 // Diagnostic messages, events, statistics, analytics, etc.
 type Diagnostics struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -802,7 +873,7 @@ type Diagnostics struct {
 
 func (x *Diagnostics) Reset() {
 	*x = Diagnostics{}
-	mi := &file_query_v1_query_proto_msgTypes[8]
+	mi := &file_query_v1_query_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -814,7 +885,7 @@ func (x *Diagnostics) String() string {
 func (*Diagnostics) ProtoMessage() {}
 
 func (x *Diagnostics) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[8]
+	mi := &file_query_v1_query_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -827,7 +898,7 @@ func (x *Diagnostics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Diagnostics.ProtoReflect.Descriptor instead.
 func (*Diagnostics) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{8}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Diagnostics) GetQueryPlan() *QueryPlan {
@@ -860,7 +931,7 @@ type ExecutionNode struct {
 
 func (x *ExecutionNode) Reset() {
 	*x = ExecutionNode{}
-	mi := &file_query_v1_query_proto_msgTypes[9]
+	mi := &file_query_v1_query_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -872,7 +943,7 @@ func (x *ExecutionNode) String() string {
 func (*ExecutionNode) ProtoMessage() {}
 
 func (x *ExecutionNode) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[9]
+	mi := &file_query_v1_query_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -885,7 +956,7 @@ func (x *ExecutionNode) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExecutionNode.ProtoReflect.Descriptor instead.
 func (*ExecutionNode) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{9}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ExecutionNode) GetType() QueryNode_Type {
@@ -947,14 +1018,34 @@ type ExecutionStats struct {
 	// during this invocation. It counts only the bytes from this single,
 	// successful Invoke call: retried or hedged calls that did not produce a
 	// response are not included, so the value is deterministic across retries.
-	BytesFetched  uint64 `protobuf:"varint,4,opt,name=bytes_fetched,json=bytesFetched,proto3" json:"bytes_fetched,omitempty"`
+	BytesFetched uint64 `protobuf:"varint,4,opt,name=bytes_fetched,json=bytesFetched,proto3" json:"bytes_fetched,omitempty"`
+	// The resource usage counters below are collected on every invocation,
+	// regardless of the diagnostics option, and are summed up the execution
+	// tree: the stats of a MERGE node include the stats of its children.
+	//
+	// rows_scanned is the number of profile table rows matching the series
+	// and time range of the query, before deleted and not sampled profiles
+	// are skipped.
+	RowsScanned int64 `protobuf:"varint,5,opt,name=rows_scanned,json=rowsScanned,proto3" json:"rows_scanned,omitempty"`
+	// profiles_scanned is the number of profiles read by the queries.
+	ProfilesScanned int64 `protobuf:"varint,6,opt,name=profiles_scanned,json=profilesScanned,proto3" json:"profiles_scanned,omitempty"`
+	// stack_traces is the number of distinct stack traces symbolized.
+	StackTraces int64 `protobuf:"varint,7,opt,name=stack_traces,json=stackTraces,proto3" json:"stack_traces,omitempty"`
+	// peak_memory_bytes estimates the peak memory usage as the maximum total
+	// size of the dataset sections opened concurrently. The estimate of a
+	// MERGE node is the maximum of the estimates of its children.
+	PeakMemoryBytes uint64 `protobuf:"varint,8,opt,name=peak_memory_bytes,json=peakMemoryBytes,proto3" json:"peak_memory_bytes,omitempty"`
+	// cache_hits and cache_misses are the numbers of query splits served from
+	// and missing in the query-frontend results cache.
+	CacheHits     int64 `protobuf:"varint,9,opt,name=cache_hits,json=cacheHits,proto3" json:"cache_hits,omitempty"`
+	CacheMisses   int64 `protobuf:"varint,10,opt,name=cache_misses,json=cacheMisses,proto3" json:"cache_misses,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ExecutionStats) Reset() {
 	*x = ExecutionStats{}
-	mi := &file_query_v1_query_proto_msgTypes[10]
+	mi := &file_query_v1_query_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -966,7 +1057,7 @@ func (x *ExecutionStats) String() string {
 func (*ExecutionStats) ProtoMessage() {}
 
 func (x *ExecutionStats) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[10]
+	mi := &file_query_v1_query_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -979,7 +1070,7 @@ func (x *ExecutionStats) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExecutionStats.ProtoReflect.Descriptor instead.
 func (*ExecutionStats) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{10}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ExecutionStats) GetBlocksRead() int64 {
@@ -1010,6 +1101,48 @@ func (x *ExecutionStats) GetBytesFetched() uint64 {
 	return 0
 }
 
+func (x *ExecutionStats) GetRowsScanned() int64 {
+	if x != nil {
+		return x.RowsScanned
+	}
+	return 0
+}
+
+func (x *ExecutionStats) GetProfilesScanned() int64 {
+	if x != nil {
+		return x.ProfilesScanned
+	}
+	return 0
+}
+
+func (x *ExecutionStats) GetStackTraces() int64 {
+	if x != nil {
+		return x.StackTraces
+	}
+	return 0
+}
+
+func (x *ExecutionStats) GetPeakMemoryBytes() uint64 {
+	if x != nil {
+		return x.PeakMemoryBytes
+	}
+	return 0
+}
+
+func (x *ExecutionStats) GetCacheHits() int64 {
+	if x != nil {
+		return x.CacheHits
+	}
+	return 0
+}
+
+func (x *ExecutionStats) GetCacheMisses() int64 {
+	if x != nil {
+		return x.CacheMisses
+	}
+	return 0
+}
+
 // BlockExecution captures execution details for a single block.
 type BlockExecution struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
@@ -1026,7 +1159,7 @@ type BlockExecution struct {
 
 func (x *BlockExecution) Reset() {
 	*x = BlockExecution{}
-	mi := &file_query_v1_query_proto_msgTypes[11]
+	mi := &file_query_v1_query_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1038,7 +1171,7 @@ func (x *BlockExecution) String() string {
 func (*BlockExecution) ProtoMessage() {}
 
 func (x *BlockExecution) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[11]
+	mi := &file_query_v1_query_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1051,7 +1184,7 @@ func (x *BlockExecution) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockExecution.ProtoReflect.Descriptor instead.
 func (*BlockExecution) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{11}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *BlockExecution) GetBlockId() string {
@@ -1122,7 +1255,7 @@ type Report struct {
 
 func (x *Report) Reset() {
 	*x = Report{}
-	mi := &file_query_v1_query_proto_msgTypes[12]
+	mi := &file_query_v1_query_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1134,7 +1267,7 @@ func (x *Report) String() string {
 func (*Report) ProtoMessage() {}
 
 func (x *Report) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[12]
+	mi := &file_query_v1_query_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1147,7 +1280,7 @@ func (x *Report) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Report.ProtoReflect.Descriptor instead.
 func (*Report) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{12}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *Report) GetReportType() ReportType {
@@ -1221,7 +1354,7 @@ type LabelNamesQuery struct {
 
 func (x *LabelNamesQuery) Reset() {
 	*x = LabelNamesQuery{}
-	mi := &file_query_v1_query_proto_msgTypes[13]
+	mi := &file_query_v1_query_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1233,7 +1366,7 @@ func (x *LabelNamesQuery) String() string {
 func (*LabelNamesQuery) ProtoMessage() {}
 
 func (x *LabelNamesQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[13]
+	mi := &file_query_v1_query_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1246,7 +1379,7 @@ func (x *LabelNamesQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LabelNamesQuery.ProtoReflect.Descriptor instead.
 func (*LabelNamesQuery) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{13}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{14}
 }
 
 type LabelNamesReport struct {
@@ -1259,7 +1392,7 @@ type LabelNamesReport struct {
 
 func (x *LabelNamesReport) Reset() {
 	*x = LabelNamesReport{}
-	mi := &file_query_v1_query_proto_msgTypes[14]
+	mi := &file_query_v1_query_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1271,7 +1404,7 @@ func (x *LabelNamesReport) String() string {
 func (*LabelNamesReport) ProtoMessage() {}
 
 func (x *LabelNamesReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[14]
+	mi := &file_query_v1_query_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1284,7 +1417,7 @@ func (x *LabelNamesReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LabelNamesReport.ProtoReflect.Descriptor instead.
 func (*LabelNamesReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{14}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *LabelNamesReport) GetQuery() *LabelNamesQuery {
@@ -1310,7 +1443,7 @@ type LabelValuesQuery struct {
 
 func (x *LabelValuesQuery) Reset() {
 	*x = LabelValuesQuery{}
-	mi := &file_query_v1_query_proto_msgTypes[15]
+	mi := &file_query_v1_query_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1322,7 +1455,7 @@ func (x *LabelValuesQuery) String() string {
 func (*LabelValuesQuery) ProtoMessage() {}
 
 func (x *LabelValuesQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[15]
+	mi := &file_query_v1_query_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1335,7 +1468,7 @@ func (x *LabelValuesQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LabelValuesQuery.ProtoReflect.Descriptor instead.
 func (*LabelValuesQuery) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{15}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *LabelValuesQuery) GetLabelName() string {
@@ -1355,7 +1488,7 @@ type LabelValuesReport struct {
 
 func (x *LabelValuesReport) Reset() {
 	*x = LabelValuesReport{}
-	mi := &file_query_v1_query_proto_msgTypes[16]
+	mi := &file_query_v1_query_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1367,7 +1500,7 @@ func (x *LabelValuesReport) String() string {
 func (*LabelValuesReport) ProtoMessage() {}
 
 func (x *LabelValuesReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[16]
+	mi := &file_query_v1_query_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1380,7 +1513,7 @@ func (x *LabelValuesReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LabelValuesReport.ProtoReflect.Descriptor instead.
 func (*LabelValuesReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{16}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *LabelValuesReport) GetQuery() *LabelValuesQuery {
@@ -1406,7 +1539,7 @@ type SeriesLabelsQuery struct {
 
 func (x *SeriesLabelsQuery) Reset() {
 	*x = SeriesLabelsQuery{}
-	mi := &file_query_v1_query_proto_msgTypes[17]
+	mi := &file_query_v1_query_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1418,7 +1551,7 @@ func (x *SeriesLabelsQuery) String() string {
 func (*SeriesLabelsQuery) ProtoMessage() {}
 
 func (x *SeriesLabelsQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[17]
+	mi := &file_query_v1_query_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1431,7 +1564,7 @@ func (x *SeriesLabelsQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SeriesLabelsQuery.ProtoReflect.Descriptor instead.
 func (*SeriesLabelsQuery) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{17}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *SeriesLabelsQuery) GetLabelNames() []string {
@@ -1444,14 +1577,14 @@ func (x *SeriesLabelsQuery) GetLabelNames() []string {
 type SeriesLabelsReport struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Query         *SeriesLabelsQuery     `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
-	SeriesLabels  []*v11.Labels          `protobuf:"bytes,2,rep,name=series_labels,json=seriesLabels,proto3" json:"series_labels,omitempty"`
+	SeriesLabels  []*v12.Labels          `protobuf:"bytes,2,rep,name=series_labels,json=seriesLabels,proto3" json:"series_labels,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SeriesLabelsReport) Reset() {
 	*x = SeriesLabelsReport{}
-	mi := &file_query_v1_query_proto_msgTypes[18]
+	mi := &file_query_v1_query_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1463,7 +1596,7 @@ func (x *SeriesLabelsReport) String() string {
 func (*SeriesLabelsReport) ProtoMessage() {}
 
 func (x *SeriesLabelsReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[18]
+	mi := &file_query_v1_query_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1476,7 +1609,7 @@ func (x *SeriesLabelsReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SeriesLabelsReport.ProtoReflect.Descriptor instead.
 func (*SeriesLabelsReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{18}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *SeriesLabelsReport) GetQuery() *SeriesLabelsQuery {
@@ -1486,7 +1619,7 @@ func (x *SeriesLabelsReport) GetQuery() *SeriesLabelsQuery {
 	return nil
 }
 
-func (x *SeriesLabelsReport) GetSeriesLabels() []*v11.Labels {
+func (x *SeriesLabelsReport) GetSeriesLabels() []*v12.Labels {
 	if x != nil {
 		return x.SeriesLabels
 	}
@@ -1494,18 +1627,25 @@ func (x *SeriesLabelsReport) GetSeriesLabels() []*v11.Labels {
 }
 
 type TimeSeriesQuery struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Step          float64                `protobuf:"fixed64,1,opt,name=step,proto3" json:"step,omitempty"`
-	GroupBy       []string               `protobuf:"bytes,2,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
-	Limit         int64                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
-	ExemplarType  v11.ExemplarType       `protobuf:"varint,4,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Step         float64                `protobuf:"fixed64,1,opt,name=step,proto3" json:"step,omitempty"`
+	GroupBy      []string               `protobuf:"bytes,2,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	Limit        int64                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	ExemplarType v12.ExemplarType       `protobuf:"varint,4,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
+	// sampling_ratio is the fraction of profiles read by an approximate
+	// query, in (0, 1]. Values are scaled back to estimate the totals.
+	// Zero or one means all the profiles are read.
+	SamplingRatio float64 `protobuf:"fixed64,5,opt,name=sampling_ratio,json=samplingRatio,proto3" json:"sampling_ratio,omitempty"`
+	// max_exemplars_per_point is the maximum number of exemplars
+	// with the highest values attached to each point of the series.
+	MaxExemplarsPerPoint int64 `protobuf:"varint,6,opt,name=max_exemplars_per_point,json=maxExemplarsPerPoint,proto3" json:"max_exemplars_per_point,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *TimeSeriesQuery) Reset() {
 	*x = TimeSeriesQuery{}
-	mi := &file_query_v1_query_proto_msgTypes[19]
+	mi := &file_query_v1_query_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1517,7 +1657,7 @@ func (x *TimeSeriesQuery) String() string {
 func (*TimeSeriesQuery) ProtoMessage() {}
 
 func (x *TimeSeriesQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[19]
+	mi := &file_query_v1_query_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1530,7 +1670,7 @@ func (x *TimeSeriesQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TimeSeriesQuery.ProtoReflect.Descriptor instead.
 func (*TimeSeriesQuery) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{19}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *TimeSeriesQuery) GetStep() float64 {
@@ -1554,24 +1694,39 @@ func (x *TimeSeriesQuery) GetLimit() int64 {
 	return 0
 }
 
-func (x *TimeSeriesQuery) GetExemplarType() v11.ExemplarType {
+func (x *TimeSeriesQuery) GetExemplarType() v12.ExemplarType {
 	if x != nil {
 		return x.ExemplarType
 	}
-	return v11.ExemplarType(0)
+	return v12.ExemplarType(0)
+}
+
+func (x *TimeSeriesQuery) GetSamplingRatio() float64 {
+	if x != nil {
+		return x.SamplingRatio
+	}
+	return 0
+}
+
+func (x *TimeSeriesQuery) GetMaxExemplarsPerPoint() int64 {
+	if x != nil {
+		return x.MaxExemplarsPerPoint
+	}
+	return 0
 }
 
 type TimeSeriesReport struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Query         *TimeSeriesQuery       `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
-	TimeSeries    []*v11.Series          `protobuf:"bytes,2,rep,name=time_series,json=timeSeries,proto3" json:"time_series,omitempty"`
+	TimeSeries    []*v12.Series          `protobuf:"bytes,2,rep,name=time_series,json=timeSeries,proto3" json:"time_series,omitempty"`
+	Sampling      *SamplingStats         `protobuf:"bytes,3,opt,name=sampling,proto3" json:"sampling,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TimeSeriesReport) Reset() {
 	*x = TimeSeriesReport{}
-	mi := &file_query_v1_query_proto_msgTypes[20]
+	mi := &file_query_v1_query_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1583,7 +1738,7 @@ func (x *TimeSeriesReport) String() string {
 func (*TimeSeriesReport) ProtoMessage() {}
 
 func (x *TimeSeriesReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[20]
+	mi := &file_query_v1_query_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1596,7 +1751,7 @@ func (x *TimeSeriesReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TimeSeriesReport.ProtoReflect.Descriptor instead.
 func (*TimeSeriesReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{20}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *TimeSeriesReport) GetQuery() *TimeSeriesQuery {
@@ -1606,18 +1761,100 @@ func (x *TimeSeriesReport) GetQuery() *TimeSeriesQuery {
 	return nil
 }
 
-func (x *TimeSeriesReport) GetTimeSeries() []*v11.Series {
+func (x *TimeSeriesReport) GetTimeSeries() []*v12.Series {
 	if x != nil {
 		return x.TimeSeries
 	}
 	return nil
 }
 
+func (x *TimeSeriesReport) GetSampling() *SamplingStats {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
+// SamplingStats describes the profiles read by an approximate query:
+// the stats are summed as the reports are merged, which allows to estimate
+// the effective sampling rate and the error of the result.
+type SamplingStats struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of profiles matching the query.
+	ProfilesTotal int64 `protobuf:"varint,1,opt,name=profiles_total,json=profilesTotal,proto3" json:"profiles_total,omitempty"`
+	// Number of profiles read.
+	ProfilesSampled int64 `protobuf:"varint,2,opt,name=profiles_sampled,json=profilesSampled,proto3" json:"profiles_sampled,omitempty"`
+	// Sum and sum of squares of the total values of the
+	// profiles read, before they are scaled.
+	ValueSum        float64 `protobuf:"fixed64,3,opt,name=value_sum,json=valueSum,proto3" json:"value_sum,omitempty"`
+	ValueSumSquares float64 `protobuf:"fixed64,4,opt,name=value_sum_squares,json=valueSumSquares,proto3" json:"value_sum_squares,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SamplingStats) Reset() {
+	*x = SamplingStats{}
+	mi := &file_query_v1_query_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SamplingStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamplingStats) ProtoMessage() {}
+
+func (x *SamplingStats) ProtoReflect() protoreflect.Message {
+	mi := &file_query_v1_query_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamplingStats.ProtoReflect.Descriptor instead.
+func (*SamplingStats) Descriptor() ([]byte, []int) {
+	return file_query_v1_query_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SamplingStats) GetProfilesTotal() int64 {
+	if x != nil {
+		return x.ProfilesTotal
+	}
+	return 0
+}
+
+func (x *SamplingStats) GetProfilesSampled() int64 {
+	if x != nil {
+		return x.ProfilesSampled
+	}
+	return 0
+}
+
+func (x *SamplingStats) GetValueSum() float64 {
+	if x != nil {
+		return x.ValueSum
+	}
+	return 0
+}
+
+func (x *SamplingStats) GetValueSumSquares() float64 {
+	if x != nil {
+		return x.ValueSumSquares
+	}
+	return 0
+}
+
 type TreeQuery struct {
 	state              protoimpl.MessageState  `protogen:"open.v1"`
 	MaxNodes           int64                   `protobuf:"varint,1,opt,name=max_nodes,json=maxNodes,proto3" json:"max_nodes,omitempty"`
 	SpanSelector       []string                `protobuf:"bytes,2,rep,name=span_selector,json=spanSelector,proto3" json:"span_selector,omitempty"`
-	StackTraceSelector *v11.StackTraceSelector `protobuf:"bytes,3,opt,name=stack_trace_selector,json=stackTraceSelector,proto3,oneof" json:"stack_trace_selector,omitempty"`
+	StackTraceSelector *v12.StackTraceSelector `protobuf:"bytes,3,opt,name=stack_trace_selector,json=stackTraceSelector,proto3,oneof" json:"stack_trace_selector,omitempty"`
 	ProfileIdSelector  []string                `protobuf:"bytes,4,rep,name=profile_id_selector,json=profileIdSelector,proto3" json:"profile_id_selector,omitempty"`
 	// Deprecated: use symbol_mode = SYMBOL_MODE_FULL. Retained for wire
 	// compatibility; will be removed in a couple of releases.
@@ -1630,13 +1867,17 @@ type TreeQuery struct {
 	// symbol-ref tree result may carry; the query fails past it. Zero means
 	// unlimited. Effective only with SYMBOL_MODE_REFS.
 	MaxUnresolvedLocations int64 `protobuf:"varint,8,opt,name=max_unresolved_locations,json=maxUnresolvedLocations,proto3" json:"max_unresolved_locations,omitempty"`
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+	// sampling_ratio is the fraction of profiles read by an approximate
+	// query, in (0, 1]. Values are scaled back to estimate the totals.
+	// Zero or one means all the profiles are read.
+	SamplingRatio float64 `protobuf:"fixed64,9,opt,name=sampling_ratio,json=samplingRatio,proto3" json:"sampling_ratio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TreeQuery) Reset() {
 	*x = TreeQuery{}
-	mi := &file_query_v1_query_proto_msgTypes[21]
+	mi := &file_query_v1_query_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1648,7 +1889,7 @@ func (x *TreeQuery) String() string {
 func (*TreeQuery) ProtoMessage() {}
 
 func (x *TreeQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[21]
+	mi := &file_query_v1_query_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1661,7 +1902,7 @@ func (x *TreeQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TreeQuery.ProtoReflect.Descriptor instead.
 func (*TreeQuery) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{21}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *TreeQuery) GetMaxNodes() int64 {
@@ -1678,7 +1919,7 @@ func (x *TreeQuery) GetSpanSelector() []string {
 	return nil
 }
 
-func (x *TreeQuery) GetStackTraceSelector() *v11.StackTraceSelector {
+func (x *TreeQuery) GetStackTraceSelector() *v12.StackTraceSelector {
 	if x != nil {
 		return x.StackTraceSelector
 	}
@@ -1720,11 +1961,18 @@ func (x *TreeQuery) GetMaxUnresolvedLocations() int64 {
 	return 0
 }
 
+func (x *TreeQuery) GetSamplingRatio() float64 {
+	if x != nil {
+		return x.SamplingRatio
+	}
+	return 0
+}
+
 type TreeSymbols struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	Mappings       []*v12.Mapping         `protobuf:"bytes,1,rep,name=mappings,proto3" json:"mappings,omitempty"`
-	Locations      []*v12.Location        `protobuf:"bytes,2,rep,name=locations,proto3" json:"locations,omitempty"`
-	Functions      []*v12.Function        `protobuf:"bytes,3,rep,name=functions,proto3" json:"functions,omitempty"`
+	Mappings       []*v13.Mapping         `protobuf:"bytes,1,rep,name=mappings,proto3" json:"mappings,omitempty"`
+	Locations      []*v13.Location        `protobuf:"bytes,2,rep,name=locations,proto3" json:"locations,omitempty"`
+	Functions      []*v13.Function        `protobuf:"bytes,3,rep,name=functions,proto3" json:"functions,omitempty"`
 	Strings        []string               `protobuf:"bytes,4,rep,name=strings,proto3" json:"strings,omitempty"`
 	MappingHashes  []uint64               `protobuf:"varint,5,rep,packed,name=mapping_hashes,json=mappingHashes,proto3" json:"mapping_hashes,omitempty"`
 	LocationHashes []uint64               `protobuf:"varint,6,rep,packed,name=location_hashes,json=locationHashes,proto3" json:"location_hashes,omitempty"`
@@ -1736,7 +1984,7 @@ type TreeSymbols struct {
 
 func (x *TreeSymbols) Reset() {
 	*x = TreeSymbols{}
-	mi := &file_query_v1_query_proto_msgTypes[22]
+	mi := &file_query_v1_query_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1748,7 +1996,7 @@ func (x *TreeSymbols) String() string {
 func (*TreeSymbols) ProtoMessage() {}
 
 func (x *TreeSymbols) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[22]
+	mi := &file_query_v1_query_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1761,24 +2009,24 @@ func (x *TreeSymbols) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TreeSymbols.ProtoReflect.Descriptor instead.
 func (*TreeSymbols) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{22}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *TreeSymbols) GetMappings() []*v12.Mapping {
+func (x *TreeSymbols) GetMappings() []*v13.Mapping {
 	if x != nil {
 		return x.Mappings
 	}
 	return nil
 }
 
-func (x *TreeSymbols) GetLocations() []*v12.Location {
+func (x *TreeSymbols) GetLocations() []*v13.Location {
 	if x != nil {
 		return x.Locations
 	}
 	return nil
 }
 
-func (x *TreeSymbols) GetFunctions() []*v12.Function {
+func (x *TreeSymbols) GetFunctions() []*v13.Function {
 	if x != nil {
 		return x.Functions
 	}
@@ -1849,7 +2097,7 @@ type SymbolRefTable struct {
 
 func (x *SymbolRefTable) Reset() {
 	*x = SymbolRefTable{}
-	mi := &file_query_v1_query_proto_msgTypes[23]
+	mi := &file_query_v1_query_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1861,7 +2109,7 @@ func (x *SymbolRefTable) String() string {
 func (*SymbolRefTable) ProtoMessage() {}
 
 func (x *SymbolRefTable) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[23]
+	mi := &file_query_v1_query_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1874,7 +2122,7 @@ func (x *SymbolRefTable) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SymbolRefTable.ProtoReflect.Descriptor instead.
 func (*SymbolRefTable) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{23}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *SymbolRefTable) GetNames() []string {
@@ -1918,13 +2166,14 @@ type TreeReport struct {
 	Tree          []byte                 `protobuf:"bytes,2,opt,name=tree,proto3" json:"tree,omitempty"`
 	Symbols       *TreeSymbols           `protobuf:"bytes,3,opt,name=symbols,proto3,oneof" json:"symbols,omitempty"`
 	SymbolRefs    *SymbolRefTable        `protobuf:"bytes,4,opt,name=symbol_refs,json=symbolRefs,proto3,oneof" json:"symbol_refs,omitempty"`
+	Sampling      *SamplingStats         `protobuf:"bytes,5,opt,name=sampling,proto3" json:"sampling,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TreeReport) Reset() {
 	*x = TreeReport{}
-	mi := &file_query_v1_query_proto_msgTypes[24]
+	mi := &file_query_v1_query_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1936,7 +2185,7 @@ func (x *TreeReport) String() string {
 func (*TreeReport) ProtoMessage() {}
 
 func (x *TreeReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[24]
+	mi := &file_query_v1_query_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1949,7 +2198,7 @@ func (x *TreeReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TreeReport.ProtoReflect.Descriptor instead.
 func (*TreeReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{24}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *TreeReport) GetQuery() *TreeQuery {
@@ -1980,10 +2229,17 @@ func (x *TreeReport) GetSymbolRefs() *SymbolRefTable {
 	return nil
 }
 
+func (x *TreeReport) GetSampling() *SamplingStats {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
 type PprofQuery struct {
 	state              protoimpl.MessageState  `protogen:"open.v1"`
 	MaxNodes           int64                   `protobuf:"varint,1,opt,name=max_nodes,json=maxNodes,proto3" json:"max_nodes,omitempty"`
-	StackTraceSelector *v11.StackTraceSelector `protobuf:"bytes,2,opt,name=stack_trace_selector,json=stackTraceSelector,proto3,oneof" json:"stack_trace_selector,omitempty"`
+	StackTraceSelector *v12.StackTraceSelector `protobuf:"bytes,2,opt,name=stack_trace_selector,json=stackTraceSelector,proto3,oneof" json:"stack_trace_selector,omitempty"`
 	ProfileIdSelector  []string                `protobuf:"bytes,3,rep,name=profile_id_selector,json=profileIdSelector,proto3" json:"profile_id_selector,omitempty"`
 	SpanSelector       []string                `protobuf:"bytes,4,rep,name=span_selector,json=spanSelector,proto3" json:"span_selector,omitempty"`
 	TraceIdSelector    []string                `protobuf:"bytes,5,rep,name=trace_id_selector,json=traceIdSelector,proto3" json:"trace_id_selector,omitempty"` // TODO(kolesnikovae): Go PGO options.
@@ -1993,7 +2249,7 @@ type PprofQuery struct {
 
 func (x *PprofQuery) Reset() {
 	*x = PprofQuery{}
-	mi := &file_query_v1_query_proto_msgTypes[25]
+	mi := &file_query_v1_query_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2005,7 +2261,7 @@ func (x *PprofQuery) String() string {
 func (*PprofQuery) ProtoMessage() {}
 
 func (x *PprofQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[25]
+	mi := &file_query_v1_query_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2018,7 +2274,7 @@ func (x *PprofQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PprofQuery.ProtoReflect.Descriptor instead.
 func (*PprofQuery) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{25}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *PprofQuery) GetMaxNodes() int64 {
@@ -2028,7 +2284,7 @@ func (x *PprofQuery) GetMaxNodes() int64 {
 	return 0
 }
 
-func (x *PprofQuery) GetStackTraceSelector() *v11.StackTraceSelector {
+func (x *PprofQuery) GetStackTraceSelector() *v12.StackTraceSelector {
 	if x != nil {
 		return x.StackTraceSelector
 	}
@@ -2066,7 +2322,7 @@ type PprofReport struct {
 
 func (x *PprofReport) Reset() {
 	*x = PprofReport{}
-	mi := &file_query_v1_query_proto_msgTypes[26]
+	mi := &file_query_v1_query_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2078,7 +2334,7 @@ func (x *PprofReport) String() string {
 func (*PprofReport) ProtoMessage() {}
 
 func (x *PprofReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[26]
+	mi := &file_query_v1_query_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2091,7 +2347,7 @@ func (x *PprofReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PprofReport.ProtoReflect.Descriptor instead.
 func (*PprofReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{26}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *PprofReport) GetQuery() *PprofQuery {
@@ -2112,16 +2368,16 @@ type HeatmapQuery struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Step          float64                `protobuf:"fixed64,1,opt,name=step,proto3" json:"step,omitempty"`
 	GroupBy       []string               `protobuf:"bytes,2,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
-	QueryType     v13.HeatmapQueryType   `protobuf:"varint,3,opt,name=query_type,json=queryType,proto3,enum=querier.v1.HeatmapQueryType" json:"query_type,omitempty"`
+	QueryType     v11.HeatmapQueryType   `protobuf:"varint,3,opt,name=query_type,json=queryType,proto3,enum=querier.v1.HeatmapQueryType" json:"query_type,omitempty"`
 	Limit         int64                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
-	ExemplarType  v11.ExemplarType       `protobuf:"varint,5,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
+	ExemplarType  v12.ExemplarType       `protobuf:"varint,5,opt,name=exemplar_type,json=exemplarType,proto3,enum=types.v1.ExemplarType" json:"exemplar_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HeatmapQuery) Reset() {
 	*x = HeatmapQuery{}
-	mi := &file_query_v1_query_proto_msgTypes[27]
+	mi := &file_query_v1_query_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2133,7 +2389,7 @@ func (x *HeatmapQuery) String() string {
 func (*HeatmapQuery) ProtoMessage() {}
 
 func (x *HeatmapQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[27]
+	mi := &file_query_v1_query_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2146,7 +2402,7 @@ func (x *HeatmapQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeatmapQuery.ProtoReflect.Descriptor instead.
 func (*HeatmapQuery) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{27}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *HeatmapQuery) GetStep() float64 {
@@ -2163,11 +2419,11 @@ func (x *HeatmapQuery) GetGroupBy() []string {
 	return nil
 }
 
-func (x *HeatmapQuery) GetQueryType() v13.HeatmapQueryType {
+func (x *HeatmapQuery) GetQueryType() v11.HeatmapQueryType {
 	if x != nil {
 		return x.QueryType
 	}
-	return v13.HeatmapQueryType(0)
+	return v11.HeatmapQueryType(0)
 }
 
 func (x *HeatmapQuery) GetLimit() int64 {
@@ -2177,11 +2433,11 @@ func (x *HeatmapQuery) GetLimit() int64 {
 	return 0
 }
 
-func (x *HeatmapQuery) GetExemplarType() v11.ExemplarType {
+func (x *HeatmapQuery) GetExemplarType() v12.ExemplarType {
 	if x != nil {
 		return x.ExemplarType
 	}
-	return v11.ExemplarType(0)
+	return v12.ExemplarType(0)
 }
 
 type AttributeTable struct {
@@ -2194,7 +2450,7 @@ type AttributeTable struct {
 
 func (x *AttributeTable) Reset() {
 	*x = AttributeTable{}
-	mi := &file_query_v1_query_proto_msgTypes[28]
+	mi := &file_query_v1_query_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2206,7 +2462,7 @@ func (x *AttributeTable) String() string {
 func (*AttributeTable) ProtoMessage() {}
 
 func (x *AttributeTable) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[28]
+	mi := &file_query_v1_query_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2219,7 +2475,7 @@ func (x *AttributeTable) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AttributeTable.ProtoReflect.Descriptor instead.
 func (*AttributeTable) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{28}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *AttributeTable) GetKeys() []string {
@@ -2250,7 +2506,7 @@ type HeatmapPoint struct {
 
 func (x *HeatmapPoint) Reset() {
 	*x = HeatmapPoint{}
-	mi := &file_query_v1_query_proto_msgTypes[29]
+	mi := &file_query_v1_query_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2262,7 +2518,7 @@ func (x *HeatmapPoint) String() string {
 func (*HeatmapPoint) ProtoMessage() {}
 
 func (x *HeatmapPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[29]
+	mi := &file_query_v1_query_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2275,7 +2531,7 @@ func (x *HeatmapPoint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeatmapPoint.ProtoReflect.Descriptor instead.
 func (*HeatmapPoint) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{29}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *HeatmapPoint) GetTimestamp() int64 {
@@ -2330,7 +2586,7 @@ type HeatmapSeries struct {
 
 func (x *HeatmapSeries) Reset() {
 	*x = HeatmapSeries{}
-	mi := &file_query_v1_query_proto_msgTypes[30]
+	mi := &file_query_v1_query_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2342,7 +2598,7 @@ func (x *HeatmapSeries) String() string {
 func (*HeatmapSeries) ProtoMessage() {}
 
 func (x *HeatmapSeries) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[30]
+	mi := &file_query_v1_query_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2355,7 +2611,7 @@ func (x *HeatmapSeries) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeatmapSeries.ProtoReflect.Descriptor instead.
 func (*HeatmapSeries) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{30}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *HeatmapSeries) GetAttributeRefs() []int64 {
@@ -2377,13 +2633,14 @@ type HeatmapReport struct {
 	Query          *HeatmapQuery          `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
 	HeatmapSeries  []*HeatmapSeries       `protobuf:"bytes,2,rep,name=heatmap_series,json=heatmapSeries,proto3" json:"heatmap_series,omitempty"`
 	AttributeTable *AttributeTable        `protobuf:"bytes,3,opt,name=attribute_table,json=attributeTable,proto3" json:"attribute_table,omitempty"`
+	Sampling       *SamplingStats         `protobuf:"bytes,4,opt,name=sampling,proto3" json:"sampling,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *HeatmapReport) Reset() {
 	*x = HeatmapReport{}
-	mi := &file_query_v1_query_proto_msgTypes[31]
+	mi := &file_query_v1_query_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2395,7 +2652,7 @@ func (x *HeatmapReport) String() string {
 func (*HeatmapReport) ProtoMessage() {}
 
 func (x *HeatmapReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[31]
+	mi := &file_query_v1_query_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2408,7 +2665,7 @@ func (x *HeatmapReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeatmapReport.ProtoReflect.Descriptor instead.
 func (*HeatmapReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{31}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *HeatmapReport) GetQuery() *HeatmapQuery {
@@ -2432,6 +2689,13 @@ func (x *HeatmapReport) GetAttributeTable() *AttributeTable {
 	return nil
 }
 
+func (x *HeatmapReport) GetSampling() *SamplingStats {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
 type Exemplar struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Milliseconds unix timestamp
@@ -2440,13 +2704,15 @@ type Exemplar struct {
 	SpanId        string  `protobuf:"bytes,3,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
 	Value         int64   `protobuf:"varint,4,opt,name=value,proto3" json:"value,omitempty"`
 	AttributeRefs []int64 `protobuf:"varint,5,rep,packed,name=attribute_refs,json=attributeRefs,proto3" json:"attribute_refs,omitempty"`
+	// Trace ID of the span, encoded as 32 lowercase hexadecimal characters.
+	TraceId       string `protobuf:"bytes,6,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Exemplar) Reset() {
 	*x = Exemplar{}
-	mi := &file_query_v1_query_proto_msgTypes[32]
+	mi := &file_query_v1_query_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2458,7 +2724,7 @@ func (x *Exemplar) String() string {
 func (*Exemplar) ProtoMessage() {}
 
 func (x *Exemplar) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[32]
+	mi := &file_query_v1_query_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2471,7 +2737,7 @@ func (x *Exemplar) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Exemplar.ProtoReflect.Descriptor instead.
 func (*Exemplar) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{32}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *Exemplar) GetTimestamp() int64 {
@@ -2509,6 +2775,13 @@ func (x *Exemplar) GetAttributeRefs() []int64 {
 	return nil
 }
 
+func (x *Exemplar) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
 type Point struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	Value float64                `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
@@ -2524,7 +2797,7 @@ type Point struct {
 
 func (x *Point) Reset() {
 	*x = Point{}
-	mi := &file_query_v1_query_proto_msgTypes[33]
+	mi := &file_query_v1_query_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2536,7 +2809,7 @@ func (x *Point) String() string {
 func (*Point) ProtoMessage() {}
 
 func (x *Point) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[33]
+	mi := &file_query_v1_query_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2549,7 +2822,7 @@ func (x *Point) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Point.ProtoReflect.Descriptor instead.
 func (*Point) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{33}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *Point) GetValue() float64 {
@@ -2593,7 +2866,7 @@ type Series struct {
 
 func (x *Series) Reset() {
 	*x = Series{}
-	mi := &file_query_v1_query_proto_msgTypes[34]
+	mi := &file_query_v1_query_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2605,7 +2878,7 @@ func (x *Series) String() string {
 func (*Series) ProtoMessage() {}
 
 func (x *Series) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[34]
+	mi := &file_query_v1_query_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2618,7 +2891,7 @@ func (x *Series) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Series.ProtoReflect.Descriptor instead.
 func (*Series) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{34}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *Series) GetAttributeRefs() []int64 {
@@ -2640,13 +2913,14 @@ type TimeSeriesCompactReport struct {
 	Query          *TimeSeriesQuery       `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
 	TimeSeries     []*Series              `protobuf:"bytes,2,rep,name=time_series,json=timeSeries,proto3" json:"time_series,omitempty"`
 	AttributeTable *AttributeTable        `protobuf:"bytes,3,opt,name=attribute_table,json=attributeTable,proto3" json:"attribute_table,omitempty"`
+	Sampling       *SamplingStats         `protobuf:"bytes,4,opt,name=sampling,proto3" json:"sampling,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *TimeSeriesCompactReport) Reset() {
 	*x = TimeSeriesCompactReport{}
-	mi := &file_query_v1_query_proto_msgTypes[35]
+	mi := &file_query_v1_query_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2658,7 +2932,7 @@ func (x *TimeSeriesCompactReport) String() string {
 func (*TimeSeriesCompactReport) ProtoMessage() {}
 
 func (x *TimeSeriesCompactReport) ProtoReflect() protoreflect.Message {
-	mi := &file_query_v1_query_proto_msgTypes[35]
+	mi := &file_query_v1_query_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2671,7 +2945,7 @@ func (x *TimeSeriesCompactReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TimeSeriesCompactReport.ProtoReflect.Descriptor instead.
 func (*TimeSeriesCompactReport) Descriptor() ([]byte, []int) {
-	return file_query_v1_query_proto_rawDescGZIP(), []int{35}
+	return file_query_v1_query_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *TimeSeriesCompactReport) GetQuery() *TimeSeriesQuery {
@@ -2695,11 +2969,18 @@ func (x *TimeSeriesCompactReport) GetAttributeTable() *AttributeTable {
 	return nil
 }
 
+func (x *TimeSeriesCompactReport) GetSampling() *SamplingStats {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
 var File_query_v1_query_proto protoreflect.FileDescriptor
 
 const file_query_v1_query_proto_rawDesc = "" +
 	"\n" +
-	"\x14query/v1/query.proto\x12\bquery.v1\x1a\x17google/v1/profile.proto\x1a\x18metastore/v1/types.proto\x1a\x18querier/v1/querier.proto\x1a\x14types/v1/types.proto\"\x96\x01\n" +
+	"\x14query/v1/query.proto\x12\bquery.v1\x1a\x17google/v1/profile.proto\x1a\x1cmetastore/v1/compactor.proto\x1a\x18metastore/v1/types.proto\x1a\x18querier/v1/querier.proto\x1a\x14types/v1/types.proto\"\x96\x01\n" +
 	"\fQueryRequest\x12\x1d\n" +
 	"\n" +
 	"start_time\x18\x01 \x01(\x03R\tstartTime\x12\x19\n" +
@@ -2710,7 +2991,7 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\areports\x18\x01 \x03(\v2\x10.query.v1.ReportR\areports\"l\n" +
 	"\rInvokeOptions\x12*\n" +
 	"\x11sanitize_on_merge\x18\x01 \x01(\bR\x0fsanitizeOnMerge\x12/\n" +
-	"\x13collect_diagnostics\x18\x02 \x01(\bR\x12collectDiagnostics\"\x96\x02\n" +
+	"\x13collect_diagnostics\x18\x02 \x01(\bR\x12collectDiagnostics\"\xe2\x02\n" +
 	"\rInvokeRequest\x12\x16\n" +
 	"\x06tenant\x18\x01 \x03(\tR\x06tenant\x12\x1d\n" +
 	"\n" +
@@ -2720,7 +3001,8 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\x05query\x18\x05 \x03(\v2\x0f.query.v1.QueryR\x05query\x122\n" +
 	"\n" +
 	"query_plan\x18\x06 \x01(\v2\x13.query.v1.QueryPlanR\tqueryPlan\x121\n" +
-	"\aoptions\x18\a \x01(\v2\x17.query.v1.InvokeOptionsR\aoptions\"4\n" +
+	"\aoptions\x18\a \x01(\v2\x17.query.v1.InvokeOptionsR\aoptions\x12J\n" +
+	"\x11series_tombstones\x18\b \x03(\v2\x1d.metastore.v1.SeriesTombstoneR\x10seriesTombstones\"4\n" +
 	"\tQueryPlan\x12'\n" +
 	"\x04root\x18\x01 \x01(\v2\x13.query.v1.QueryNodeR\x04root\"\xc5\x01\n" +
 	"\tQueryNode\x12,\n" +
@@ -2746,7 +3028,11 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\x13time_series_compact\x18\t \x01(\v2\x19.query.v1.TimeSeriesQueryR\x11timeSeriesCompact\"u\n" +
 	"\x0eInvokeResponse\x12*\n" +
 	"\areports\x18\x01 \x03(\v2\x10.query.v1.ReportR\areports\x127\n" +
-	"\vdiagnostics\x18\x02 \x01(\v2\x15.query.v1.DiagnosticsR\vdiagnostics\"\x81\x01\n" +
+	"\vdiagnostics\x18\x02 \x01(\v2\x15.query.v1.DiagnosticsR\vdiagnostics\"\x99\x01\n" +
+	"\x14InvokeStreamResponse\x124\n" +
+	"\bresponse\x18\x01 \x01(\v2\x18.query.v1.InvokeResponseR\bresponse\x125\n" +
+	"\bprogress\x18\x02 \x01(\v2\x19.querier.v1.QueryProgressR\bprogress\x12\x14\n" +
+	"\x05final\x18\x03 \x01(\bR\x05final\"\x81\x01\n" +
 	"\vDiagnostics\x122\n" +
 	"\n" +
 	"query_plan\x18\x01 \x01(\v2\x13.query.v1.QueryPlanR\tqueryPlan\x12>\n" +
@@ -2758,13 +3044,21 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\vend_time_ns\x18\x04 \x01(\x03R\tendTimeNs\x123\n" +
 	"\bchildren\x18\x05 \x03(\v2\x17.query.v1.ExecutionNodeR\bchildren\x12.\n" +
 	"\x05stats\x18\x06 \x01(\v2\x18.query.v1.ExecutionStatsR\x05stats\x12\x14\n" +
-	"\x05error\x18\a \x01(\tR\x05error\"\xca\x01\n" +
+	"\x05error\x18\a \x01(\tR\x05error\"\xa9\x03\n" +
 	"\x0eExecutionStats\x12\x1f\n" +
 	"\vblocks_read\x18\x01 \x01(\x03R\n" +
 	"blocksRead\x12-\n" +
 	"\x12datasets_processed\x18\x02 \x01(\x03R\x11datasetsProcessed\x12C\n" +
 	"\x10block_executions\x18\x03 \x03(\v2\x18.query.v1.BlockExecutionR\x0fblockExecutions\x12#\n" +
-	"\rbytes_fetched\x18\x04 \x01(\x04R\fbytesFetched\"\xf3\x01\n" +
+	"\rbytes_fetched\x18\x04 \x01(\x04R\fbytesFetched\x12!\n" +
+	"\frows_scanned\x18\x05 \x01(\x03R\vrowsScanned\x12)\n" +
+	"\x10profiles_scanned\x18\x06 \x01(\x03R\x0fprofilesScanned\x12!\n" +
+	"\fstack_traces\x18\a \x01(\x03R\vstackTraces\x12*\n" +
+	"\x11peak_memory_bytes\x18\b \x01(\x04R\x0fpeakMemoryBytes\x12\x1d\n" +
+	"\n" +
+	"cache_hits\x18\t \x01(\x03R\tcacheHits\x12!\n" +
+	"\fcache_misses\x18\n" +
+	" \x01(\x03R\vcacheMisses\"\xf3\x01\n" +
 	"\x0eBlockExecution\x12\x19\n" +
 	"\bblock_id\x18\x01 \x01(\tR\ablockId\x12\"\n" +
 	"\rstart_time_ns\x18\x02 \x01(\x03R\vstartTimeNs\x12\x1e\n" +
@@ -2802,16 +3096,24 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"labelNames\"~\n" +
 	"\x12SeriesLabelsReport\x121\n" +
 	"\x05query\x18\x01 \x01(\v2\x1b.query.v1.SeriesLabelsQueryR\x05query\x125\n" +
-	"\rseries_labels\x18\x02 \x03(\v2\x10.types.v1.LabelsR\fseriesLabels\"\x93\x01\n" +
+	"\rseries_labels\x18\x02 \x03(\v2\x10.types.v1.LabelsR\fseriesLabels\"\xf1\x01\n" +
 	"\x0fTimeSeriesQuery\x12\x12\n" +
 	"\x04step\x18\x01 \x01(\x01R\x04step\x12\x19\n" +
 	"\bgroup_by\x18\x02 \x03(\tR\agroupBy\x12\x14\n" +
 	"\x05limit\x18\x03 \x01(\x03R\x05limit\x12;\n" +
-	"\rexemplar_type\x18\x04 \x01(\x0e2\x16.types.v1.ExemplarTypeR\fexemplarType\"v\n" +
+	"\rexemplar_type\x18\x04 \x01(\x0e2\x16.types.v1.ExemplarTypeR\fexemplarType\x12%\n" +
+	"\x0esampling_ratio\x18\x05 \x01(\x01R\rsamplingRatio\x125\n" +
+	"\x17max_exemplars_per_point\x18\x06 \x01(\x03R\x14maxExemplarsPerPoint\"\xab\x01\n" +
 	"\x10TimeSeriesReport\x12/\n" +
 	"\x05query\x18\x01 \x01(\v2\x19.query.v1.TimeSeriesQueryR\x05query\x121\n" +
 	"\vtime_series\x18\x02 \x03(\v2\x10.types.v1.SeriesR\n" +
-	"timeSeries\"\xab\x03\n" +
+	"timeSeries\x123\n" +
+	"\bsampling\x18\x03 \x01(\v2\x17.query.v1.SamplingStatsR\bsampling\"\xaa\x01\n" +
+	"\rSamplingStats\x12%\n" +
+	"\x0eprofiles_total\x18\x01 \x01(\x03R\rprofilesTotal\x12)\n" +
+	"\x10profiles_sampled\x18\x02 \x01(\x03R\x0fprofilesSampled\x12\x1b\n" +
+	"\tvalue_sum\x18\x03 \x01(\x01R\bvalueSum\x12*\n" +
+	"\x11value_sum_squares\x18\x04 \x01(\x01R\x0fvalueSumSquares\"\xd2\x03\n" +
 	"\tTreeQuery\x12\x1b\n" +
 	"\tmax_nodes\x18\x01 \x01(\x03R\bmaxNodes\x12#\n" +
 	"\rspan_selector\x18\x02 \x03(\tR\fspanSelector\x12S\n" +
@@ -2821,7 +3123,8 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\x11trace_id_selector\x18\x06 \x03(\tR\x0ftraceIdSelector\x125\n" +
 	"\vsymbol_mode\x18\a \x01(\x0e2\x14.query.v1.SymbolModeR\n" +
 	"symbolMode\x128\n" +
-	"\x18max_unresolved_locations\x18\b \x01(\x03R\x16maxUnresolvedLocationsB\x17\n" +
+	"\x18max_unresolved_locations\x18\b \x01(\x03R\x16maxUnresolvedLocations\x12%\n" +
+	"\x0esampling_ratio\x18\t \x01(\x01R\rsamplingRatioB\x17\n" +
 	"\x15_stack_trace_selector\"\xdb\x02\n" +
 	"\vTreeSymbols\x12.\n" +
 	"\bmappings\x18\x01 \x03(\v2\x12.google.v1.MappingR\bmappings\x121\n" +
@@ -2837,14 +3140,15 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\tbuild_ids\x18\x02 \x03(\tR\bbuildIds\x12!\n" +
 	"\fbinary_names\x18\x03 \x03(\tR\vbinaryNames\x12.\n" +
 	"\x13unresolved_build_id\x18\x04 \x03(\rR\x11unresolvedBuildId\x12-\n" +
-	"\x12unresolved_address\x18\x05 \x03(\x04R\x11unresolvedAddress\"\xdd\x01\n" +
+	"\x12unresolved_address\x18\x05 \x03(\x04R\x11unresolvedAddress\"\x92\x02\n" +
 	"\n" +
 	"TreeReport\x12)\n" +
 	"\x05query\x18\x01 \x01(\v2\x13.query.v1.TreeQueryR\x05query\x12\x12\n" +
 	"\x04tree\x18\x02 \x01(\fR\x04tree\x124\n" +
 	"\asymbols\x18\x03 \x01(\v2\x15.query.v1.TreeSymbolsH\x00R\asymbols\x88\x01\x01\x12>\n" +
 	"\vsymbol_refs\x18\x04 \x01(\v2\x18.query.v1.SymbolRefTableH\x01R\n" +
-	"symbolRefs\x88\x01\x01B\n" +
+	"symbolRefs\x88\x01\x01\x123\n" +
+	"\bsampling\x18\x05 \x01(\v2\x17.query.v1.SamplingStatsR\bsamplingB\n" +
 	"\n" +
 	"\b_symbolsB\x0e\n" +
 	"\f_symbol_refs\"\x98\x02\n" +
@@ -2879,18 +3183,20 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\btrace_id\x18\x06 \x01(\fR\atraceId\"f\n" +
 	"\rHeatmapSeries\x12%\n" +
 	"\x0eattribute_refs\x18\x01 \x03(\x03R\rattributeRefs\x12.\n" +
-	"\x06points\x18\x02 \x03(\v2\x16.query.v1.HeatmapPointR\x06points\"\xc0\x01\n" +
+	"\x06points\x18\x02 \x03(\v2\x16.query.v1.HeatmapPointR\x06points\"\xf5\x01\n" +
 	"\rHeatmapReport\x12,\n" +
 	"\x05query\x18\x01 \x01(\v2\x16.query.v1.HeatmapQueryR\x05query\x12>\n" +
 	"\x0eheatmap_series\x18\x02 \x03(\v2\x17.query.v1.HeatmapSeriesR\rheatmapSeries\x12A\n" +
-	"\x0fattribute_table\x18\x03 \x01(\v2\x18.query.v1.AttributeTableR\x0eattributeTable\"\x9d\x01\n" +
+	"\x0fattribute_table\x18\x03 \x01(\v2\x18.query.v1.AttributeTableR\x0eattributeTable\x123\n" +
+	"\bsampling\x18\x04 \x01(\v2\x17.query.v1.SamplingStatsR\bsampling\"\xb8\x01\n" +
 	"\bExemplar\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x1d\n" +
 	"\n" +
 	"profile_id\x18\x02 \x01(\tR\tprofileId\x12\x17\n" +
 	"\aspan_id\x18\x03 \x01(\tR\x06spanId\x12\x14\n" +
 	"\x05value\x18\x04 \x01(\x03R\x05value\x12%\n" +
-	"\x0eattribute_refs\x18\x05 \x03(\x03R\rattributeRefs\"\x96\x01\n" +
+	"\x0eattribute_refs\x18\x05 \x03(\x03R\rattributeRefs\x12\x19\n" +
+	"\btrace_id\x18\x06 \x01(\tR\atraceId\"\x96\x01\n" +
 	"\x05Point\x12\x14\n" +
 	"\x05value\x18\x01 \x01(\x01R\x05value\x12\x1c\n" +
 	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12'\n" +
@@ -2898,12 +3204,13 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\texemplars\x18\x04 \x03(\v2\x12.query.v1.ExemplarR\texemplars\"X\n" +
 	"\x06Series\x12%\n" +
 	"\x0eattribute_refs\x18\x01 \x03(\x03R\rattributeRefs\x12'\n" +
-	"\x06points\x18\x02 \x03(\v2\x0f.query.v1.PointR\x06points\"\xc0\x01\n" +
+	"\x06points\x18\x02 \x03(\v2\x0f.query.v1.PointR\x06points\"\xf5\x01\n" +
 	"\x17TimeSeriesCompactReport\x12/\n" +
 	"\x05query\x18\x01 \x01(\v2\x19.query.v1.TimeSeriesQueryR\x05query\x121\n" +
 	"\vtime_series\x18\x02 \x03(\v2\x10.query.v1.SeriesR\n" +
 	"timeSeries\x12A\n" +
-	"\x0fattribute_table\x18\x03 \x01(\v2\x18.query.v1.AttributeTableR\x0eattributeTable*\xd4\x01\n" +
+	"\x0fattribute_table\x18\x03 \x01(\v2\x18.query.v1.AttributeTableR\x0eattributeTable\x123\n" +
+	"\bsampling\x18\x04 \x01(\v2\x17.query.v1.SamplingStatsR\bsampling*\xd4\x01\n" +
 	"\tQueryType\x12\x15\n" +
 	"\x11QUERY_UNSPECIFIED\x10\x00\x12\x15\n" +
 	"\x11QUERY_LABEL_NAMES\x10\x01\x12\x16\n" +
@@ -2933,9 +3240,10 @@ const file_query_v1_query_proto_rawDesc = "" +
 	"\x10SYMBOL_MODE_FULL\x10\x02\x12\x14\n" +
 	"\x10SYMBOL_MODE_REFS\x10\x032R\n" +
 	"\x14QueryFrontendService\x12:\n" +
-	"\x05Query\x12\x16.query.v1.QueryRequest\x1a\x17.query.v1.QueryResponse\"\x002T\n" +
+	"\x05Query\x12\x16.query.v1.QueryRequest\x1a\x17.query.v1.QueryResponse\"\x002\xa1\x01\n" +
 	"\x13QueryBackendService\x12=\n" +
-	"\x06Invoke\x12\x17.query.v1.InvokeRequest\x1a\x18.query.v1.InvokeResponse\"\x00B\x9b\x01\n" +
+	"\x06Invoke\x12\x17.query.v1.InvokeRequest\x1a\x18.query.v1.InvokeResponse\"\x00\x12K\n" +
+	"\fInvokeStream\x12\x17.query.v1.InvokeRequest\x1a\x1e.query.v1.InvokeStreamResponse\"\x000\x01B\x9b\x01\n" +
 	"\fcom.query.v1B\n" +
 	"QueryProtoP\x01Z>github.com/grafana/pyroscope/api/gen/proto/go/query/v1;queryv1\xa2\x02\x03QXX\xaa\x02\bQuery.V1\xca\x02\bQuery\\V1\xe2\x02\x14Query\\V1\\GPBMetadata\xea\x02\tQuery::V1b\x06proto3"
 
@@ -2952,7 +3260,7 @@ func file_query_v1_query_proto_rawDescGZIP() []byte {
 }
 
 var file_query_v1_query_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_query_v1_query_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
+var file_query_v1_query_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_query_v1_query_proto_goTypes = []any{
 	(QueryType)(0),                  // 0: query.v1.QueryType
 	(ReportType)(0),                 // 1: query.v1.ReportType
@@ -2966,117 +3274,130 @@ var file_query_v1_query_proto_goTypes = []any{
 	(*QueryNode)(nil),               // 9: query.v1.QueryNode
 	(*Query)(nil),                   // 10: query.v1.Query
 	(*InvokeResponse)(nil),          // 11: query.v1.InvokeResponse
-	(*Diagnostics)(nil),             // 12: query.v1.Diagnostics
-	(*ExecutionNode)(nil),           // 13: query.v1.ExecutionNode
-	(*ExecutionStats)(nil),          // 14: query.v1.ExecutionStats
-	(*BlockExecution)(nil),          // 15: query.v1.BlockExecution
-	(*Report)(nil),                  // 16: query.v1.Report
-	(*LabelNamesQuery)(nil),         // 17: query.v1.LabelNamesQuery
-	(*LabelNamesReport)(nil),        // 18: query.v1.LabelNamesReport
-	(*LabelValuesQuery)(nil),        // 19: query.v1.LabelValuesQuery
-	(*LabelValuesReport)(nil),       // 20: query.v1.LabelValuesReport
-	(*SeriesLabelsQuery)(nil),       // 21: query.v1.SeriesLabelsQuery
-	(*SeriesLabelsReport)(nil),      // 22: query.v1.SeriesLabelsReport
-	(*TimeSeriesQuery)(nil),         // 23: query.v1.TimeSeriesQuery
-	(*TimeSeriesReport)(nil),        // 24: query.v1.TimeSeriesReport
-	(*TreeQuery)(nil),               // 25: query.v1.TreeQuery
-	(*TreeSymbols)(nil),             // 26: query.v1.TreeSymbols
-	(*SymbolRefTable)(nil),          // 27: query.v1.SymbolRefTable
-	(*TreeReport)(nil),              // 28: query.v1.TreeReport
-	(*PprofQuery)(nil),              // 29: query.v1.PprofQuery
-	(*PprofReport)(nil),             // 30: query.v1.PprofReport
-	(*HeatmapQuery)(nil),            // 31: query.v1.HeatmapQuery
-	(*AttributeTable)(nil),          // 32: query.v1.AttributeTable
-	(*HeatmapPoint)(nil),            // 33: query.v1.HeatmapPoint
-	(*HeatmapSeries)(nil),           // 34: query.v1.HeatmapSeries
-	(*HeatmapReport)(nil),           // 35: query.v1.HeatmapReport
-	(*Exemplar)(nil),                // 36: query.v1.Exemplar
-	(*Point)(nil),                   // 37: query.v1.Point
-	(*Series)(nil),                  // 38: query.v1.Series
-	(*TimeSeriesCompactReport)(nil), // 39: query.v1.TimeSeriesCompactReport
-	(*v1.BlockMeta)(nil),            // 40: metastore.v1.BlockMeta
-	(*v11.Labels)(nil),              // 41: types.v1.Labels
-	(v11.ExemplarType)(0),           // 42: types.v1.ExemplarType
-	(*v11.Series)(nil),              // 43: types.v1.Series
-	(*v11.StackTraceSelector)(nil),  // 44: types.v1.StackTraceSelector
-	(*v12.Mapping)(nil),             // 45: google.v1.Mapping
-	(*v12.Location)(nil),            // 46: google.v1.Location
-	(*v12.Function)(nil),            // 47: google.v1.Function
-	(v13.HeatmapQueryType)(0),       // 48: querier.v1.HeatmapQueryType
+	(*InvokeStreamResponse)(nil),    // 12: query.v1.InvokeStreamResponse
+	(*Diagnostics)(nil),             // 13: query.v1.Diagnostics
+	(*ExecutionNode)(nil),           // 14: query.v1.ExecutionNode
+	(*ExecutionStats)(nil),          // 15: query.v1.ExecutionStats
+	(*BlockExecution)(nil),          // 16: query.v1.BlockExecution
+	(*Report)(nil),                  // 17: query.v1.Report
+	(*LabelNamesQuery)(nil),         // 18: query.v1.LabelNamesQuery
+	(*LabelNamesReport)(nil),        // 19: query.v1.LabelNamesReport
+	(*LabelValuesQuery)(nil),        // 20: query.v1.LabelValuesQuery
+	(*LabelValuesReport)(nil),       // 21: query.v1.LabelValuesReport
+	(*SeriesLabelsQuery)(nil),       // 22: query.v1.SeriesLabelsQuery
+	(*SeriesLabelsReport)(nil),      // 23: query.v1.SeriesLabelsReport
+	(*TimeSeriesQuery)(nil),         // 24: query.v1.TimeSeriesQuery
+	(*TimeSeriesReport)(nil),        // 25: query.v1.TimeSeriesReport
+	(*SamplingStats)(nil),           // 26: query.v1.SamplingStats
+	(*TreeQuery)(nil),               // 27: query.v1.TreeQuery
+	(*TreeSymbols)(nil),             // 28: query.v1.TreeSymbols
+	(*SymbolRefTable)(nil),          // 29: query.v1.SymbolRefTable
+	(*TreeReport)(nil),              // 30: query.v1.TreeReport
+	(*PprofQuery)(nil),              // 31: query.v1.PprofQuery
+	(*PprofReport)(nil),             // 32: query.v1.PprofReport
+	(*HeatmapQuery)(nil),            // 33: query.v1.HeatmapQuery
+	(*AttributeTable)(nil),          // 34: query.v1.AttributeTable
+	(*HeatmapPoint)(nil),            // 35: query.v1.HeatmapPoint
+	(*HeatmapSeries)(nil),           // 36: query.v1.HeatmapSeries
+	(*HeatmapReport)(nil),           // 37: query.v1.HeatmapReport
+	(*Exemplar)(nil),                // 38: query.v1.Exemplar
+	(*Point)(nil),                   // 39: query.v1.Point
+	(*Series)(nil),                  // 40: query.v1.Series
+	(*TimeSeriesCompactReport)(nil), // 41: query.v1.TimeSeriesCompactReport
+	(*v1.SeriesTombstone)(nil),      // 42: metastore.v1.SeriesTombstone
+	(*v1.BlockMeta)(nil),            // 43: metastore.v1.BlockMeta
+	(*v11.QueryProgress)(nil),       // 44: querier.v1.QueryProgress
+	(*v12.Labels)(nil),              // 45: types.v1.Labels
+	(v12.ExemplarType)(0),           // 46: types.v1.ExemplarType
+	(*v12.Series)(nil),              // 47: types.v1.Series
+	(*v12.StackTraceSelector)(nil),  // 48: types.v1.StackTraceSelector
+	(*v13.Mapping)(nil),             // 49: google.v1.Mapping
+	(*v13.Location)(nil),            // 50: google.v1.Location
+	(*v13.Function)(nil),            // 51: google.v1.Function
+	(v11.HeatmapQueryType)(0),       // 52: querier.v1.HeatmapQueryType
 }
 var file_query_v1_query_proto_depIdxs = []int32{
 	10, // 0: query.v1.QueryRequest.query:type_name -> query.v1.Query
-	16, // 1: query.v1.QueryResponse.reports:type_name -> query.v1.Report
+	17, // 1: query.v1.QueryResponse.reports:type_name -> query.v1.Report
 	10, // 2: query.v1.InvokeRequest.query:type_name -> query.v1.Query
 	8,  // 3: query.v1.InvokeRequest.query_plan:type_name -> query.v1.QueryPlan
 	6,  // 4: query.v1.InvokeRequest.options:type_name -> query.v1.InvokeOptions
-	9,  // 5: query.v1.QueryPlan.root:type_name -> query.v1.QueryNode
-	3,  // 6: query.v1.QueryNode.type:type_name -> query.v1.QueryNode.Type
-	9,  // 7: query.v1.QueryNode.children:type_name -> query.v1.QueryNode
-	40, // 8: query.v1.QueryNode.blocks:type_name -> metastore.v1.BlockMeta
-	0,  // 9: query.v1.Query.query_type:type_name -> query.v1.QueryType
-	17, // 10: query.v1.Query.label_names:type_name -> query.v1.LabelNamesQuery
-	19, // 11: query.v1.Query.label_values:type_name -> query.v1.LabelValuesQuery
-	21, // 12: query.v1.Query.series_labels:type_name -> query.v1.SeriesLabelsQuery
-	23, // 13: query.v1.Query.time_series:type_name -> query.v1.TimeSeriesQuery
-	25, // 14: query.v1.Query.tree:type_name -> query.v1.TreeQuery
-	29, // 15: query.v1.Query.pprof:type_name -> query.v1.PprofQuery
-	31, // 16: query.v1.Query.heatmap:type_name -> query.v1.HeatmapQuery
-	23, // 17: query.v1.Query.time_series_compact:type_name -> query.v1.TimeSeriesQuery
-	16, // 18: query.v1.InvokeResponse.reports:type_name -> query.v1.Report
-	12, // 19: query.v1.InvokeResponse.diagnostics:type_name -> query.v1.Diagnostics
-	8,  // 20: query.v1.Diagnostics.query_plan:type_name -> query.v1.QueryPlan
-	13, // 21: query.v1.Diagnostics.execution_node:type_name -> query.v1.ExecutionNode
-	3,  // 22: query.v1.ExecutionNode.type:type_name -> query.v1.QueryNode.Type
-	13, // 23: query.v1.ExecutionNode.children:type_name -> query.v1.ExecutionNode
-	14, // 24: query.v1.ExecutionNode.stats:type_name -> query.v1.ExecutionStats
-	15, // 25: query.v1.ExecutionStats.block_executions:type_name -> query.v1.BlockExecution
-	1,  // 26: query.v1.Report.report_type:type_name -> query.v1.ReportType
-	18, // 27: query.v1.Report.label_names:type_name -> query.v1.LabelNamesReport
-	20, // 28: query.v1.Report.label_values:type_name -> query.v1.LabelValuesReport
-	22, // 29: query.v1.Report.series_labels:type_name -> query.v1.SeriesLabelsReport
-	24, // 30: query.v1.Report.time_series:type_name -> query.v1.TimeSeriesReport
-	28, // 31: query.v1.Report.tree:type_name -> query.v1.TreeReport
-	30, // 32: query.v1.Report.pprof:type_name -> query.v1.PprofReport
-	35, // 33: query.v1.Report.heatmap:type_name -> query.v1.HeatmapReport
-	39, // 34: query.v1.Report.time_series_compact:type_name -> query.v1.TimeSeriesCompactReport
-	17, // 35: query.v1.LabelNamesReport.query:type_name -> query.v1.LabelNamesQuery
-	19, // 36: query.v1.LabelValuesReport.query:type_name -> query.v1.LabelValuesQuery
-	21, // 37: query.v1.SeriesLabelsReport.query:type_name -> query.v1.SeriesLabelsQuery
-	41, // 38: query.v1.SeriesLabelsReport.series_labels:type_name -> types.v1.Labels
-	42, // 39: query.v1.TimeSeriesQuery.exemplar_type:type_name -> types.v1.ExemplarType
-	23, // 40: query.v1.TimeSeriesReport.query:type_name -> query.v1.TimeSeriesQuery
-	43, // 41: query.v1.TimeSeriesReport.time_series:type_name -> types.v1.Series
-	44, // 42: query.v1.TreeQuery.stack_trace_selector:type_name -> types.v1.StackTraceSelector
-	2,  // 43: query.v1.TreeQuery.symbol_mode:type_name -> query.v1.SymbolMode
-	45, // 44: query.v1.TreeSymbols.mappings:type_name -> google.v1.Mapping
-	46, // 45: query.v1.TreeSymbols.locations:type_name -> google.v1.Location
-	47, // 46: query.v1.TreeSymbols.functions:type_name -> google.v1.Function
-	25, // 47: query.v1.TreeReport.query:type_name -> query.v1.TreeQuery
-	26, // 48: query.v1.TreeReport.symbols:type_name -> query.v1.TreeSymbols
-	27, // 49: query.v1.TreeReport.symbol_refs:type_name -> query.v1.SymbolRefTable
-	44, // 50: query.v1.PprofQuery.stack_trace_selector:type_name -> types.v1.StackTraceSelector
-	29, // 51: query.v1.PprofReport.query:type_name -> query.v1.PprofQuery
-	48, // 52: query.v1.HeatmapQuery.query_type:type_name -> querier.v1.HeatmapQueryType
-	42, // 53: query.v1.HeatmapQuery.exemplar_type:type_name -> types.v1.ExemplarType
-	33, // 54: query.v1.HeatmapSeries.points:type_name -> query.v1.HeatmapPoint
-	31, // 55: query.v1.HeatmapReport.query:type_name -> query.v1.HeatmapQuery
-	34, // 56: query.v1.HeatmapReport.heatmap_series:type_name -> query.v1.HeatmapSeries
-	32, // 57: query.v1.HeatmapReport.attribute_table:type_name -> query.v1.AttributeTable
-	36, // 58: query.v1.Point.exemplars:type_name -> query.v1.Exemplar
-	37, // 59: query.v1.Series.points:type_name -> query.v1.Point
-	23, // 60: query.v1.TimeSeriesCompactReport.query:type_name -> query.v1.TimeSeriesQuery
-	38, // 61: query.v1.TimeSeriesCompactReport.time_series:type_name -> query.v1.Series
-	32, // 62: query.v1.TimeSeriesCompactReport.attribute_table:type_name -> query.v1.AttributeTable
-	4,  // 63: query.v1.QueryFrontendService.Query:input_type -> query.v1.QueryRequest
-	7,  // 64: query.v1.QueryBackendService.Invoke:input_type -> query.v1.InvokeRequest
-	5,  // 65: query.v1.QueryFrontendService.Query:output_type -> query.v1.QueryResponse
-	11, // 66: query.v1.QueryBackendService.Invoke:output_type -> query.v1.InvokeResponse
-	65, // [65:67] is the sub-list for method output_type
-	63, // [63:65] is the sub-list for method input_type
-	63, // [63:63] is the sub-list for extension type_name
-	63, // [63:63] is the sub-list for extension extendee
-	0,  // [0:63] is the sub-list for field type_name
+	42, // 5: query.v1.InvokeRequest.series_tombstones:type_name -> metastore.v1.SeriesTombstone
+	9,  // 6: query.v1.QueryPlan.root:type_name -> query.v1.QueryNode
+	3,  // 7: query.v1.QueryNode.type:type_name -> query.v1.QueryNode.Type
+	9,  // 8: query.v1.QueryNode.children:type_name -> query.v1.QueryNode
+	43, // 9: query.v1.QueryNode.blocks:type_name -> metastore.v1.BlockMeta
+	0,  // 10: query.v1.Query.query_type:type_name -> query.v1.QueryType
+	18, // 11: query.v1.Query.label_names:type_name -> query.v1.LabelNamesQuery
+	20, // 12: query.v1.Query.label_values:type_name -> query.v1.LabelValuesQuery
+	22, // 13: query.v1.Query.series_labels:type_name -> query.v1.SeriesLabelsQuery
+	24, // 14: query.v1.Query.time_series:type_name -> query.v1.TimeSeriesQuery
+	27, // 15: query.v1.Query.tree:type_name -> query.v1.TreeQuery
+	31, // 16: query.v1.Query.pprof:type_name -> query.v1.PprofQuery
+	33, // 17: query.v1.Query.heatmap:type_name -> query.v1.HeatmapQuery
+	24, // 18: query.v1.Query.time_series_compact:type_name -> query.v1.TimeSeriesQuery
+	17, // 19: query.v1.InvokeResponse.reports:type_name -> query.v1.Report
+	13, // 20: query.v1.InvokeResponse.diagnostics:type_name -> query.v1.Diagnostics
+	11, // 21: query.v1.InvokeStreamResponse.response:type_name -> query.v1.InvokeResponse
+	44, // 22: query.v1.InvokeStreamResponse.progress:type_name -> querier.v1.QueryProgress
+	8,  // 23: query.v1.Diagnostics.query_plan:type_name -> query.v1.QueryPlan
+	14, // 24: query.v1.Diagnostics.execution_node:type_name -> query.v1.ExecutionNode
+	3,  // 25: query.v1.ExecutionNode.type:type_name -> query.v1.QueryNode.Type
+	14, // 26: query.v1.ExecutionNode.children:type_name -> query.v1.ExecutionNode
+	15, // 27: query.v1.ExecutionNode.stats:type_name -> query.v1.ExecutionStats
+	16, // 28: query.v1.ExecutionStats.block_executions:type_name -> query.v1.BlockExecution
+	1,  // 29: query.v1.Report.report_type:type_name -> query.v1.ReportType
+	19, // 30: query.v1.Report.label_names:type_name -> query.v1.LabelNamesReport
+	21, // 31: query.v1.Report.label_values:type_name -> query.v1.LabelValuesReport
+	23, // 32: query.v1.Report.series_labels:type_name -> query.v1.SeriesLabelsReport
+	25, // 33: query.v1.Report.time_series:type_name -> query.v1.TimeSeriesReport
+	30, // 34: query.v1.Report.tree:type_name -> query.v1.TreeReport
+	32, // 35: query.v1.Report.pprof:type_name -> query.v1.PprofReport
+	37, // 36: query.v1.Report.heatmap:type_name -> query.v1.HeatmapReport
+	41, // 37: query.v1.Report.time_series_compact:type_name -> query.v1.TimeSeriesCompactReport
+	18, // 38: query.v1.LabelNamesReport.query:type_name -> query.v1.LabelNamesQuery
+	20, // 39: query.v1.LabelValuesReport.query:type_name -> query.v1.LabelValuesQuery
+	22, // 40: query.v1.SeriesLabelsReport.query:type_name -> query.v1.SeriesLabelsQuery
+	45, // 41: query.v1.SeriesLabelsReport.series_labels:type_name -> types.v1.Labels
+	46, // 42: query.v1.TimeSeriesQuery.exemplar_type:type_name -> types.v1.ExemplarType
+	24, // 43: query.v1.TimeSeriesReport.query:type_name -> query.v1.TimeSeriesQuery
+	47, // 44: query.v1.TimeSeriesReport.time_series:type_name -> types.v1.Series
+	26, // 45: query.v1.TimeSeriesReport.sampling:type_name -> query.v1.SamplingStats
+	48, // 46: query.v1.TreeQuery.stack_trace_selector:type_name -> types.v1.StackTraceSelector
+	2,  // 47: query.v1.TreeQuery.symbol_mode:type_name -> query.v1.SymbolMode
+	49, // 48: query.v1.TreeSymbols.mappings:type_name -> google.v1.Mapping
+	50, // 49: query.v1.TreeSymbols.locations:type_name -> google.v1.Location
+	51, // 50: query.v1.TreeSymbols.functions:type_name -> google.v1.Function
+	27, // 51: query.v1.TreeReport.query:type_name -> query.v1.TreeQuery
+	28, // 52: query.v1.TreeReport.symbols:type_name -> query.v1.TreeSymbols
+	29, // 53: query.v1.TreeReport.symbol_refs:type_name -> query.v1.SymbolRefTable
+	26, // 54: query.v1.TreeReport.sampling:type_name -> query.v1.SamplingStats
+	48, // 55: query.v1.PprofQuery.stack_trace_selector:type_name -> types.v1.StackTraceSelector
+	31, // 56: query.v1.PprofReport.query:type_name -> query.v1.PprofQuery
+	52, // 57: query.v1.HeatmapQuery.query_type:type_name -> querier.v1.HeatmapQueryType
+	46, // 58: query.v1.HeatmapQuery.exemplar_type:type_name -> types.v1.ExemplarType
+	35, // 59: query.v1.HeatmapSeries.points:type_name -> query.v1.HeatmapPoint
+	33, // 60: query.v1.HeatmapReport.query:type_name -> query.v1.HeatmapQuery
+	36, // 61: query.v1.HeatmapReport.heatmap_series:type_name -> query.v1.HeatmapSeries
+	34, // 62: query.v1.HeatmapReport.attribute_table:type_name -> query.v1.AttributeTable
+	26, // 63: query.v1.HeatmapReport.sampling:type_name -> query.v1.SamplingStats
+	38, // 64: query.v1.Point.exemplars:type_name -> query.v1.Exemplar
+	39, // 65: query.v1.Series.points:type_name -> query.v1.Point
+	24, // 66: query.v1.TimeSeriesCompactReport.query:type_name -> query.v1.TimeSeriesQuery
+	40, // 67: query.v1.TimeSeriesCompactReport.time_series:type_name -> query.v1.Series
+	34, // 68: query.v1.TimeSeriesCompactReport.attribute_table:type_name -> query.v1.AttributeTable
+	26, // 69: query.v1.TimeSeriesCompactReport.sampling:type_name -> query.v1.SamplingStats
+	4,  // 70: query.v1.QueryFrontendService.Query:input_type -> query.v1.QueryRequest
+	7,  // 71: query.v1.QueryBackendService.Invoke:input_type -> query.v1.InvokeRequest
+	7,  // 72: query.v1.QueryBackendService.InvokeStream:input_type -> query.v1.InvokeRequest
+	5,  // 73: query.v1.QueryFrontendService.Query:output_type -> query.v1.QueryResponse
+	11, // 74: query.v1.QueryBackendService.Invoke:output_type -> query.v1.InvokeResponse
+	12, // 75: query.v1.QueryBackendService.InvokeStream:output_type -> query.v1.InvokeStreamResponse
+	73, // [73:76] is the sub-list for method output_type
+	70, // [70:73] is the sub-list for method input_type
+	70, // [70:70] is the sub-list for extension type_name
+	70, // [70:70] is the sub-list for extension extendee
+	0,  // [0:70] is the sub-list for field type_name
 }
 
 func init() { file_query_v1_query_proto_init() }
@@ -3084,16 +3405,16 @@ func file_query_v1_query_proto_init() {
 	if File_query_v1_query_proto != nil {
 		return
 	}
-	file_query_v1_query_proto_msgTypes[21].OneofWrappers = []any{}
-	file_query_v1_query_proto_msgTypes[24].OneofWrappers = []any{}
-	file_query_v1_query_proto_msgTypes[25].OneofWrappers = []any{}
+	file_query_v1_query_proto_msgTypes[23].OneofWrappers = []any{}
+	file_query_v1_query_proto_msgTypes[26].OneofWrappers = []any{}
+	file_query_v1_query_proto_msgTypes[27].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_query_v1_query_proto_rawDesc), len(file_query_v1_query_proto_rawDesc)),
 			NumEnums:      4,
-			NumMessages:   36,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
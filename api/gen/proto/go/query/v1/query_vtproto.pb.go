@@ -8,10 +8,10 @@ import (
 	context "context"
 	binary "encoding/binary"
 	fmt "fmt"
-	v12 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	v13 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	v1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
-	v13 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
-	v11 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	v11 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	v12 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 	protohelpers "github.com/planetscale/vtprotobuf/protohelpers"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -118,6 +118,17 @@ func (m *InvokeRequest) CloneVT() *InvokeRequest {
 		}
 		r.Query = tmpContainer
 	}
+	if rhs := m.SeriesTombstones; rhs != nil {
+		tmpContainer := make([]*v1.SeriesTombstone, len(rhs))
+		for k, v := range rhs {
+			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v1.SeriesTombstone }); ok {
+				tmpContainer[k] = vtpb.CloneVT()
+			} else {
+				tmpContainer[k] = proto.Clone(v).(*v1.SeriesTombstone)
+			}
+		}
+		r.SeriesTombstones = tmpContainer
+	}
 	if len(m.unknownFields) > 0 {
 		r.unknownFields = make([]byte, len(m.unknownFields))
 		copy(r.unknownFields, m.unknownFields)
@@ -230,6 +241,31 @@ func (m *InvokeResponse) CloneMessageVT() proto.Message {
 	return m.CloneVT()
 }
 
+func (m *InvokeStreamResponse) CloneVT() *InvokeStreamResponse {
+	if m == nil {
+		return (*InvokeStreamResponse)(nil)
+	}
+	r := new(InvokeStreamResponse)
+	r.Response = m.Response.CloneVT()
+	r.Final = m.Final
+	if rhs := m.Progress; rhs != nil {
+		if vtpb, ok := interface{}(rhs).(interface{ CloneVT() *v11.QueryProgress }); ok {
+			r.Progress = vtpb.CloneVT()
+		} else {
+			r.Progress = proto.Clone(rhs).(*v11.QueryProgress)
+		}
+	}
+	if len(m.unknownFields) > 0 {
+		r.unknownFields = make([]byte, len(m.unknownFields))
+		copy(r.unknownFields, m.unknownFields)
+	}
+	return r
+}
+
+func (m *InvokeStreamResponse) CloneMessageVT() proto.Message {
+	return m.CloneVT()
+}
+
 func (m *Diagnostics) CloneVT() *Diagnostics {
 	if m == nil {
 		return (*Diagnostics)(nil)
@@ -285,6 +321,12 @@ func (m *ExecutionStats) CloneVT() *ExecutionStats {
 	r.BlocksRead = m.BlocksRead
 	r.DatasetsProcessed = m.DatasetsProcessed
 	r.BytesFetched = m.BytesFetched
+	r.RowsScanned = m.RowsScanned
+	r.ProfilesScanned = m.ProfilesScanned
+	r.StackTraces = m.StackTraces
+	r.PeakMemoryBytes = m.PeakMemoryBytes
+	r.CacheHits = m.CacheHits
+	r.CacheMisses = m.CacheMisses
 	if rhs := m.BlockExecutions; rhs != nil {
 		tmpContainer := make([]*BlockExecution, len(rhs))
 		for k, v := range rhs {
@@ -456,12 +498,12 @@ func (m *SeriesLabelsReport) CloneVT() *SeriesLabelsReport {
 	r := new(SeriesLabelsReport)
 	r.Query = m.Query.CloneVT()
 	if rhs := m.SeriesLabels; rhs != nil {
-		tmpContainer := make([]*v11.Labels, len(rhs))
+		tmpContainer := make([]*v12.Labels, len(rhs))
 		for k, v := range rhs {
-			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v11.Labels }); ok {
+			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v12.Labels }); ok {
 				tmpContainer[k] = vtpb.CloneVT()
 			} else {
-				tmpContainer[k] = proto.Clone(v).(*v11.Labels)
+				tmpContainer[k] = proto.Clone(v).(*v12.Labels)
 			}
 		}
 		r.SeriesLabels = tmpContainer
@@ -485,6 +527,8 @@ func (m *TimeSeriesQuery) CloneVT() *TimeSeriesQuery {
 	r.Step = m.Step
 	r.Limit = m.Limit
 	r.ExemplarType = m.ExemplarType
+	r.SamplingRatio = m.SamplingRatio
+	r.MaxExemplarsPerPoint = m.MaxExemplarsPerPoint
 	if rhs := m.GroupBy; rhs != nil {
 		tmpContainer := make([]string, len(rhs))
 		copy(tmpContainer, rhs)
@@ -507,13 +551,14 @@ func (m *TimeSeriesReport) CloneVT() *TimeSeriesReport {
 	}
 	r := new(TimeSeriesReport)
 	r.Query = m.Query.CloneVT()
+	r.Sampling = m.Sampling.CloneVT()
 	if rhs := m.TimeSeries; rhs != nil {
-		tmpContainer := make([]*v11.Series, len(rhs))
+		tmpContainer := make([]*v12.Series, len(rhs))
 		for k, v := range rhs {
-			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v11.Series }); ok {
+			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v12.Series }); ok {
 				tmpContainer[k] = vtpb.CloneVT()
 			} else {
-				tmpContainer[k] = proto.Clone(v).(*v11.Series)
+				tmpContainer[k] = proto.Clone(v).(*v12.Series)
 			}
 		}
 		r.TimeSeries = tmpContainer
@@ -529,6 +574,26 @@ func (m *TimeSeriesReport) CloneMessageVT() proto.Message {
 	return m.CloneVT()
 }
 
+func (m *SamplingStats) CloneVT() *SamplingStats {
+	if m == nil {
+		return (*SamplingStats)(nil)
+	}
+	r := new(SamplingStats)
+	r.ProfilesTotal = m.ProfilesTotal
+	r.ProfilesSampled = m.ProfilesSampled
+	r.ValueSum = m.ValueSum
+	r.ValueSumSquares = m.ValueSumSquares
+	if len(m.unknownFields) > 0 {
+		r.unknownFields = make([]byte, len(m.unknownFields))
+		copy(r.unknownFields, m.unknownFields)
+	}
+	return r
+}
+
+func (m *SamplingStats) CloneMessageVT() proto.Message {
+	return m.CloneVT()
+}
+
 func (m *TreeQuery) CloneVT() *TreeQuery {
 	if m == nil {
 		return (*TreeQuery)(nil)
@@ -538,6 +603,7 @@ func (m *TreeQuery) CloneVT() *TreeQuery {
 	r.FullSymbols = m.FullSymbols
 	r.SymbolMode = m.SymbolMode
 	r.MaxUnresolvedLocations = m.MaxUnresolvedLocations
+	r.SamplingRatio = m.SamplingRatio
 	if rhs := m.SpanSelector; rhs != nil {
 		tmpContainer := make([]string, len(rhs))
 		copy(tmpContainer, rhs)
@@ -545,11 +611,11 @@ func (m *TreeQuery) CloneVT() *TreeQuery {
 	}
 	if rhs := m.StackTraceSelector; rhs != nil {
 		if vtpb, ok := interface{}(rhs).(interface {
-			CloneVT() *v11.StackTraceSelector
+			CloneVT() *v12.StackTraceSelector
 		}); ok {
 			r.StackTraceSelector = vtpb.CloneVT()
 		} else {
-			r.StackTraceSelector = proto.Clone(rhs).(*v11.StackTraceSelector)
+			r.StackTraceSelector = proto.Clone(rhs).(*v12.StackTraceSelector)
 		}
 	}
 	if rhs := m.ProfileIdSelector; rhs != nil {
@@ -579,34 +645,34 @@ func (m *TreeSymbols) CloneVT() *TreeSymbols {
 	}
 	r := new(TreeSymbols)
 	if rhs := m.Mappings; rhs != nil {
-		tmpContainer := make([]*v12.Mapping, len(rhs))
+		tmpContainer := make([]*v13.Mapping, len(rhs))
 		for k, v := range rhs {
-			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v12.Mapping }); ok {
+			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v13.Mapping }); ok {
 				tmpContainer[k] = vtpb.CloneVT()
 			} else {
-				tmpContainer[k] = proto.Clone(v).(*v12.Mapping)
+				tmpContainer[k] = proto.Clone(v).(*v13.Mapping)
 			}
 		}
 		r.Mappings = tmpContainer
 	}
 	if rhs := m.Locations; rhs != nil {
-		tmpContainer := make([]*v12.Location, len(rhs))
+		tmpContainer := make([]*v13.Location, len(rhs))
 		for k, v := range rhs {
-			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v12.Location }); ok {
+			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v13.Location }); ok {
 				tmpContainer[k] = vtpb.CloneVT()
 			} else {
-				tmpContainer[k] = proto.Clone(v).(*v12.Location)
+				tmpContainer[k] = proto.Clone(v).(*v13.Location)
 			}
 		}
 		r.Locations = tmpContainer
 	}
 	if rhs := m.Functions; rhs != nil {
-		tmpContainer := make([]*v12.Function, len(rhs))
+		tmpContainer := make([]*v13.Function, len(rhs))
 		for k, v := range rhs {
-			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v12.Function }); ok {
+			if vtpb, ok := interface{}(v).(interface{ CloneVT() *v13.Function }); ok {
 				tmpContainer[k] = vtpb.CloneVT()
 			} else {
-				tmpContainer[k] = proto.Clone(v).(*v12.Function)
+				tmpContainer[k] = proto.Clone(v).(*v13.Function)
 			}
 		}
 		r.Functions = tmpContainer
@@ -696,6 +762,7 @@ func (m *TreeReport) CloneVT() *TreeReport {
 	r.Query = m.Query.CloneVT()
 	r.Symbols = m.Symbols.CloneVT()
 	r.SymbolRefs = m.SymbolRefs.CloneVT()
+	r.Sampling = m.Sampling.CloneVT()
 	if rhs := m.Tree; rhs != nil {
 		tmpBytes := make([]byte, len(rhs))
 		copy(tmpBytes, rhs)
@@ -720,11 +787,11 @@ func (m *PprofQuery) CloneVT() *PprofQuery {
 	r.MaxNodes = m.MaxNodes
 	if rhs := m.StackTraceSelector; rhs != nil {
 		if vtpb, ok := interface{}(rhs).(interface {
-			CloneVT() *v11.StackTraceSelector
+			CloneVT() *v12.StackTraceSelector
 		}); ok {
 			r.StackTraceSelector = vtpb.CloneVT()
 		} else {
-			r.StackTraceSelector = proto.Clone(rhs).(*v11.StackTraceSelector)
+			r.StackTraceSelector = proto.Clone(rhs).(*v12.StackTraceSelector)
 		}
 	}
 	if rhs := m.ProfileIdSelector; rhs != nil {
@@ -891,6 +958,7 @@ func (m *HeatmapReport) CloneVT() *HeatmapReport {
 	r := new(HeatmapReport)
 	r.Query = m.Query.CloneVT()
 	r.AttributeTable = m.AttributeTable.CloneVT()
+	r.Sampling = m.Sampling.CloneVT()
 	if rhs := m.HeatmapSeries; rhs != nil {
 		tmpContainer := make([]*HeatmapSeries, len(rhs))
 		for k, v := range rhs {
@@ -918,6 +986,7 @@ func (m *Exemplar) CloneVT() *Exemplar {
 	r.ProfileId = m.ProfileId
 	r.SpanId = m.SpanId
 	r.Value = m.Value
+	r.TraceId = m.TraceId
 	if rhs := m.AttributeRefs; rhs != nil {
 		tmpContainer := make([]int64, len(rhs))
 		copy(tmpContainer, rhs)
@@ -999,6 +1068,7 @@ func (m *TimeSeriesCompactReport) CloneVT() *TimeSeriesCompactReport {
 	r := new(TimeSeriesCompactReport)
 	r.Query = m.Query.CloneVT()
 	r.AttributeTable = m.AttributeTable.CloneVT()
+	r.Sampling = m.Sampling.CloneVT()
 	if rhs := m.TimeSeries; rhs != nil {
 		tmpContainer := make([]*Series, len(rhs))
 		for k, v := range rhs {
@@ -1161,6 +1231,29 @@ func (this *InvokeRequest) EqualVT(that *InvokeRequest) bool {
 	if !this.Options.EqualVT(that.Options) {
 		return false
 	}
+	if len(this.SeriesTombstones) != len(that.SeriesTombstones) {
+		return false
+	}
+	for i, vx := range this.SeriesTombstones {
+		vy := that.SeriesTombstones[i]
+		if p, q := vx, vy; p != q {
+			if p == nil {
+				p = &v1.SeriesTombstone{}
+			}
+			if q == nil {
+				q = &v1.SeriesTombstone{}
+			}
+			if equal, ok := interface{}(p).(interface {
+				EqualVT(*v1.SeriesTombstone) bool
+			}); ok {
+				if !equal.EqualVT(q) {
+					return false
+				}
+			} else if !proto.Equal(p, q) {
+				return false
+			}
+		}
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -1326,6 +1419,35 @@ func (this *InvokeResponse) EqualMessageVT(thatMsg proto.Message) bool {
 	}
 	return this.EqualVT(that)
 }
+func (this *InvokeStreamResponse) EqualVT(that *InvokeStreamResponse) bool {
+	if this == that {
+		return true
+	} else if this == nil || that == nil {
+		return false
+	}
+	if !this.Response.EqualVT(that.Response) {
+		return false
+	}
+	if equal, ok := interface{}(this.Progress).(interface{ EqualVT(*v11.QueryProgress) bool }); ok {
+		if !equal.EqualVT(that.Progress) {
+			return false
+		}
+	} else if !proto.Equal(this.Progress, that.Progress) {
+		return false
+	}
+	if this.Final != that.Final {
+		return false
+	}
+	return string(this.unknownFields) == string(that.unknownFields)
+}
+
+func (this *InvokeStreamResponse) EqualMessageVT(thatMsg proto.Message) bool {
+	that, ok := thatMsg.(*InvokeStreamResponse)
+	if !ok {
+		return false
+	}
+	return this.EqualVT(that)
+}
 func (this *Diagnostics) EqualVT(that *Diagnostics) bool {
 	if this == that {
 		return true
@@ -1431,6 +1553,24 @@ func (this *ExecutionStats) EqualVT(that *ExecutionStats) bool {
 	if this.BytesFetched != that.BytesFetched {
 		return false
 	}
+	if this.RowsScanned != that.RowsScanned {
+		return false
+	}
+	if this.ProfilesScanned != that.ProfilesScanned {
+		return false
+	}
+	if this.StackTraces != that.StackTraces {
+		return false
+	}
+	if this.PeakMemoryBytes != that.PeakMemoryBytes {
+		return false
+	}
+	if this.CacheHits != that.CacheHits {
+		return false
+	}
+	if this.CacheMisses != that.CacheMisses {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -1653,12 +1793,12 @@ func (this *SeriesLabelsReport) EqualVT(that *SeriesLabelsReport) bool {
 		vy := that.SeriesLabels[i]
 		if p, q := vx, vy; p != q {
 			if p == nil {
-				p = &v11.Labels{}
+				p = &v12.Labels{}
 			}
 			if q == nil {
-				q = &v11.Labels{}
+				q = &v12.Labels{}
 			}
-			if equal, ok := interface{}(p).(interface{ EqualVT(*v11.Labels) bool }); ok {
+			if equal, ok := interface{}(p).(interface{ EqualVT(*v12.Labels) bool }); ok {
 				if !equal.EqualVT(q) {
 					return false
 				}
@@ -1701,6 +1841,12 @@ func (this *TimeSeriesQuery) EqualVT(that *TimeSeriesQuery) bool {
 	if this.ExemplarType != that.ExemplarType {
 		return false
 	}
+	if this.SamplingRatio != that.SamplingRatio {
+		return false
+	}
+	if this.MaxExemplarsPerPoint != that.MaxExemplarsPerPoint {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -1727,12 +1873,12 @@ func (this *TimeSeriesReport) EqualVT(that *TimeSeriesReport) bool {
 		vy := that.TimeSeries[i]
 		if p, q := vx, vy; p != q {
 			if p == nil {
-				p = &v11.Series{}
+				p = &v12.Series{}
 			}
 			if q == nil {
-				q = &v11.Series{}
+				q = &v12.Series{}
 			}
-			if equal, ok := interface{}(p).(interface{ EqualVT(*v11.Series) bool }); ok {
+			if equal, ok := interface{}(p).(interface{ EqualVT(*v12.Series) bool }); ok {
 				if !equal.EqualVT(q) {
 					return false
 				}
@@ -1741,6 +1887,9 @@ func (this *TimeSeriesReport) EqualVT(that *TimeSeriesReport) bool {
 			}
 		}
 	}
+	if !this.Sampling.EqualVT(that.Sampling) {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -1751,6 +1900,34 @@ func (this *TimeSeriesReport) EqualMessageVT(thatMsg proto.Message) bool {
 	}
 	return this.EqualVT(that)
 }
+func (this *SamplingStats) EqualVT(that *SamplingStats) bool {
+	if this == that {
+		return true
+	} else if this == nil || that == nil {
+		return false
+	}
+	if this.ProfilesTotal != that.ProfilesTotal {
+		return false
+	}
+	if this.ProfilesSampled != that.ProfilesSampled {
+		return false
+	}
+	if this.ValueSum != that.ValueSum {
+		return false
+	}
+	if this.ValueSumSquares != that.ValueSumSquares {
+		return false
+	}
+	return string(this.unknownFields) == string(that.unknownFields)
+}
+
+func (this *SamplingStats) EqualMessageVT(thatMsg proto.Message) bool {
+	that, ok := thatMsg.(*SamplingStats)
+	if !ok {
+		return false
+	}
+	return this.EqualVT(that)
+}
 func (this *TreeQuery) EqualVT(that *TreeQuery) bool {
 	if this == that {
 		return true
@@ -1770,7 +1947,7 @@ func (this *TreeQuery) EqualVT(that *TreeQuery) bool {
 		}
 	}
 	if equal, ok := interface{}(this.StackTraceSelector).(interface {
-		EqualVT(*v11.StackTraceSelector) bool
+		EqualVT(*v12.StackTraceSelector) bool
 	}); ok {
 		if !equal.EqualVT(that.StackTraceSelector) {
 			return false
@@ -1805,6 +1982,9 @@ func (this *TreeQuery) EqualVT(that *TreeQuery) bool {
 	if this.MaxUnresolvedLocations != that.MaxUnresolvedLocations {
 		return false
 	}
+	if this.SamplingRatio != that.SamplingRatio {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -1828,12 +2008,12 @@ func (this *TreeSymbols) EqualVT(that *TreeSymbols) bool {
 		vy := that.Mappings[i]
 		if p, q := vx, vy; p != q {
 			if p == nil {
-				p = &v12.Mapping{}
+				p = &v13.Mapping{}
 			}
 			if q == nil {
-				q = &v12.Mapping{}
+				q = &v13.Mapping{}
 			}
-			if equal, ok := interface{}(p).(interface{ EqualVT(*v12.Mapping) bool }); ok {
+			if equal, ok := interface{}(p).(interface{ EqualVT(*v13.Mapping) bool }); ok {
 				if !equal.EqualVT(q) {
 					return false
 				}
@@ -1849,12 +2029,12 @@ func (this *TreeSymbols) EqualVT(that *TreeSymbols) bool {
 		vy := that.Locations[i]
 		if p, q := vx, vy; p != q {
 			if p == nil {
-				p = &v12.Location{}
+				p = &v13.Location{}
 			}
 			if q == nil {
-				q = &v12.Location{}
+				q = &v13.Location{}
 			}
-			if equal, ok := interface{}(p).(interface{ EqualVT(*v12.Location) bool }); ok {
+			if equal, ok := interface{}(p).(interface{ EqualVT(*v13.Location) bool }); ok {
 				if !equal.EqualVT(q) {
 					return false
 				}
@@ -1870,12 +2050,12 @@ func (this *TreeSymbols) EqualVT(that *TreeSymbols) bool {
 		vy := that.Functions[i]
 		if p, q := vx, vy; p != q {
 			if p == nil {
-				p = &v12.Function{}
+				p = &v13.Function{}
 			}
 			if q == nil {
-				q = &v12.Function{}
+				q = &v13.Function{}
 			}
-			if equal, ok := interface{}(p).(interface{ EqualVT(*v12.Function) bool }); ok {
+			if equal, ok := interface{}(p).(interface{ EqualVT(*v13.Function) bool }); ok {
 				if !equal.EqualVT(q) {
 					return false
 				}
@@ -2018,6 +2198,9 @@ func (this *TreeReport) EqualVT(that *TreeReport) bool {
 	if !this.SymbolRefs.EqualVT(that.SymbolRefs) {
 		return false
 	}
+	if !this.Sampling.EqualVT(that.Sampling) {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -2038,7 +2221,7 @@ func (this *PprofQuery) EqualVT(that *PprofQuery) bool {
 		return false
 	}
 	if equal, ok := interface{}(this.StackTraceSelector).(interface {
-		EqualVT(*v11.StackTraceSelector) bool
+		EqualVT(*v12.StackTraceSelector) bool
 	}); ok {
 		if !equal.EqualVT(that.StackTraceSelector) {
 			return false
@@ -2287,6 +2470,9 @@ func (this *HeatmapReport) EqualVT(that *HeatmapReport) bool {
 	if !this.AttributeTable.EqualVT(that.AttributeTable) {
 		return false
 	}
+	if !this.Sampling.EqualVT(that.Sampling) {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -2324,6 +2510,9 @@ func (this *Exemplar) EqualVT(that *Exemplar) bool {
 			return false
 		}
 	}
+	if this.TraceId != that.TraceId {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -2453,6 +2642,9 @@ func (this *TimeSeriesCompactReport) EqualVT(that *TimeSeriesCompactReport) bool
 	if !this.AttributeTable.EqualVT(that.AttributeTable) {
 		return false
 	}
+	if !this.Sampling.EqualVT(that.Sampling) {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -2560,6 +2752,10 @@ var QueryFrontendService_ServiceDesc = grpc.ServiceDesc{
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type QueryBackendServiceClient interface {
 	Invoke(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (*InvokeResponse, error)
+	// InvokeStream executes the query plan like Invoke, but streams the
+	// time series and tree reports merged so far as the plan nodes complete.
+	// The last message carries the complete response.
+	InvokeStream(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (QueryBackendService_InvokeStreamClient, error)
 }
 
 type queryBackendServiceClient struct {
@@ -2579,11 +2775,47 @@ func (c *queryBackendServiceClient) Invoke(ctx context.Context, in *InvokeReques
 	return out, nil
 }
 
+func (c *queryBackendServiceClient) InvokeStream(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (QueryBackendService_InvokeStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &QueryBackendService_ServiceDesc.Streams[0], "/query.v1.QueryBackendService/InvokeStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &queryBackendServiceInvokeStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type QueryBackendService_InvokeStreamClient interface {
+	Recv() (*InvokeStreamResponse, error)
+	grpc.ClientStream
+}
+
+type queryBackendServiceInvokeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *queryBackendServiceInvokeStreamClient) Recv() (*InvokeStreamResponse, error) {
+	m := new(InvokeStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // QueryBackendServiceServer is the server API for QueryBackendService service.
 // All implementations must embed UnimplementedQueryBackendServiceServer
 // for forward compatibility
 type QueryBackendServiceServer interface {
 	Invoke(context.Context, *InvokeRequest) (*InvokeResponse, error)
+	// InvokeStream executes the query plan like Invoke, but streams the
+	// time series and tree reports merged so far as the plan nodes complete.
+	// The last message carries the complete response.
+	InvokeStream(*InvokeRequest, QueryBackendService_InvokeStreamServer) error
 	mustEmbedUnimplementedQueryBackendServiceServer()
 }
 
@@ -2594,6 +2826,9 @@ type UnimplementedQueryBackendServiceServer struct {
 func (UnimplementedQueryBackendServiceServer) Invoke(context.Context, *InvokeRequest) (*InvokeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Invoke not implemented")
 }
+func (UnimplementedQueryBackendServiceServer) InvokeStream(*InvokeRequest, QueryBackendService_InvokeStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method InvokeStream not implemented")
+}
 func (UnimplementedQueryBackendServiceServer) mustEmbedUnimplementedQueryBackendServiceServer() {}
 
 // UnsafeQueryBackendServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -2625,6 +2860,27 @@ func _QueryBackendService_Invoke_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _QueryBackendService_InvokeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(InvokeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryBackendServiceServer).InvokeStream(m, &queryBackendServiceInvokeStreamServer{stream})
+}
+
+type QueryBackendService_InvokeStreamServer interface {
+	Send(*InvokeStreamResponse) error
+	grpc.ServerStream
+}
+
+type queryBackendServiceInvokeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *queryBackendServiceInvokeStreamServer) Send(m *InvokeStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // QueryBackendService_ServiceDesc is the grpc.ServiceDesc for QueryBackendService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -2637,7 +2893,13 @@ var QueryBackendService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _QueryBackendService_Invoke_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "InvokeStream",
+			Handler:       _QueryBackendService_InvokeStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "query/v1/query.proto",
 }
 
@@ -2831,6 +3093,30 @@ func (m *InvokeRequest) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if len(m.SeriesTombstones) > 0 {
+		for iNdEx := len(m.SeriesTombstones) - 1; iNdEx >= 0; iNdEx-- {
+			if vtmsg, ok := interface{}(m.SeriesTombstones[iNdEx]).(interface {
+				MarshalToSizedBufferVT([]byte) (int, error)
+			}); ok {
+				size, err := vtmsg.MarshalToSizedBufferVT(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+			} else {
+				encoded, err := proto.Marshal(m.SeriesTombstones[iNdEx])
+				if err != nil {
+					return 0, err
+				}
+				i -= len(encoded)
+				copy(dAtA[i:], encoded)
+				i = protohelpers.EncodeVarint(dAtA, i, uint64(len(encoded)))
+			}
+			i--
+			dAtA[i] = 0x42
+		}
+	}
 	if m.Options != nil {
 		size, err := m.Options.MarshalToSizedBufferVT(dAtA[:i])
 		if err != nil {
@@ -3182,6 +3468,81 @@ func (m *InvokeResponse) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *InvokeStreamResponse) MarshalVT() (dAtA []byte, err error) {
+	if m == nil {
+		return nil, nil
+	}
+	size := m.SizeVT()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBufferVT(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *InvokeStreamResponse) MarshalToVT(dAtA []byte) (int, error) {
+	size := m.SizeVT()
+	return m.MarshalToSizedBufferVT(dAtA[:size])
+}
+
+func (m *InvokeStreamResponse) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
+	if m == nil {
+		return 0, nil
+	}
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.unknownFields != nil {
+		i -= len(m.unknownFields)
+		copy(dAtA[i:], m.unknownFields)
+	}
+	if m.Final {
+		i--
+		if m.Final {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Progress != nil {
+		if vtmsg, ok := interface{}(m.Progress).(interface {
+			MarshalToSizedBufferVT([]byte) (int, error)
+		}); ok {
+			size, err := vtmsg.MarshalToSizedBufferVT(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+		} else {
+			encoded, err := proto.Marshal(m.Progress)
+			if err != nil {
+				return 0, err
+			}
+			i -= len(encoded)
+			copy(dAtA[i:], encoded)
+			i = protohelpers.EncodeVarint(dAtA, i, uint64(len(encoded)))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Response != nil {
+		size, err := m.Response.MarshalToSizedBufferVT(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Diagnostics) MarshalVT() (dAtA []byte, err error) {
 	if m == nil {
 		return nil, nil
@@ -3349,6 +3710,36 @@ func (m *ExecutionStats) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.CacheMisses != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.CacheMisses))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.CacheHits != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.CacheHits))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.PeakMemoryBytes != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.PeakMemoryBytes))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.StackTraces != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.StackTraces))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.ProfilesScanned != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.ProfilesScanned))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.RowsScanned != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.RowsScanned))
+		i--
+		dAtA[i] = 0x28
+	}
 	if m.BytesFetched != 0 {
 		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.BytesFetched))
 		i--
@@ -3883,6 +4274,17 @@ func (m *TimeSeriesQuery) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.MaxExemplarsPerPoint != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.MaxExemplarsPerPoint))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.SamplingRatio != 0 {
+		i -= 8
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.SamplingRatio))))
+		i--
+		dAtA[i] = 0x29
+	}
 	if m.ExemplarType != 0 {
 		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.ExemplarType))
 		i--
@@ -3941,6 +4343,16 @@ func (m *TimeSeriesReport) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.Sampling != nil {
+		size, err := m.Sampling.MarshalToSizedBufferVT(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+		i--
+		dAtA[i] = 0x1a
+	}
 	if len(m.TimeSeries) > 0 {
 		for iNdEx := len(m.TimeSeries) - 1; iNdEx >= 0; iNdEx-- {
 			if vtmsg, ok := interface{}(m.TimeSeries[iNdEx]).(interface {
@@ -3978,6 +4390,61 @@ func (m *TimeSeriesReport) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *SamplingStats) MarshalVT() (dAtA []byte, err error) {
+	if m == nil {
+		return nil, nil
+	}
+	size := m.SizeVT()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBufferVT(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SamplingStats) MarshalToVT(dAtA []byte) (int, error) {
+	size := m.SizeVT()
+	return m.MarshalToSizedBufferVT(dAtA[:size])
+}
+
+func (m *SamplingStats) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
+	if m == nil {
+		return 0, nil
+	}
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.unknownFields != nil {
+		i -= len(m.unknownFields)
+		copy(dAtA[i:], m.unknownFields)
+	}
+	if m.ValueSumSquares != 0 {
+		i -= 8
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ValueSumSquares))))
+		i--
+		dAtA[i] = 0x21
+	}
+	if m.ValueSum != 0 {
+		i -= 8
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ValueSum))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if m.ProfilesSampled != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.ProfilesSampled))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ProfilesTotal != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.ProfilesTotal))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *TreeQuery) MarshalVT() (dAtA []byte, err error) {
 	if m == nil {
 		return nil, nil
@@ -4008,6 +4475,12 @@ func (m *TreeQuery) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.SamplingRatio != 0 {
+		i -= 8
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.SamplingRatio))))
+		i--
+		dAtA[i] = 0x49
+	}
 	if m.MaxUnresolvedLocations != 0 {
 		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.MaxUnresolvedLocations))
 		i--
@@ -4409,6 +4882,16 @@ func (m *TreeReport) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.Sampling != nil {
+		size, err := m.Sampling.MarshalToSizedBufferVT(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+		i--
+		dAtA[i] = 0x2a
+	}
 	if m.SymbolRefs != nil {
 		size, err := m.SymbolRefs.MarshalToSizedBufferVT(dAtA[:i])
 		if err != nil {
@@ -4877,6 +5360,16 @@ func (m *HeatmapReport) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.Sampling != nil {
+		size, err := m.Sampling.MarshalToSizedBufferVT(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+		i--
+		dAtA[i] = 0x22
+	}
 	if m.AttributeTable != nil {
 		size, err := m.AttributeTable.MarshalToSizedBufferVT(dAtA[:i])
 		if err != nil {
@@ -4942,6 +5435,13 @@ func (m *Exemplar) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if len(m.TraceId) > 0 {
+		i -= len(m.TraceId)
+		copy(dAtA[i:], m.TraceId)
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(len(m.TraceId)))
+		i--
+		dAtA[i] = 0x32
+	}
 	if len(m.AttributeRefs) > 0 {
 		var pksize2 int
 		for _, num := range m.AttributeRefs {
@@ -5163,6 +5663,16 @@ func (m *TimeSeriesCompactReport) MarshalToSizedBufferVT(dAtA []byte) (int, erro
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.Sampling != nil {
+		size, err := m.Sampling.MarshalToSizedBufferVT(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(size))
+		i--
+		dAtA[i] = 0x22
+	}
 	if m.AttributeTable != nil {
 		size, err := m.AttributeTable.MarshalToSizedBufferVT(dAtA[:i])
 		if err != nil {
@@ -5292,6 +5802,18 @@ func (m *InvokeRequest) SizeVT() (n int) {
 		l = m.Options.SizeVT()
 		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 	}
+	if len(m.SeriesTombstones) > 0 {
+		for _, e := range m.SeriesTombstones {
+			if size, ok := interface{}(e).(interface {
+				SizeVT() int
+			}); ok {
+				l = size.SizeVT()
+			} else {
+				l = proto.Size(e)
+			}
+			n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+		}
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -5406,6 +5928,33 @@ func (m *InvokeResponse) SizeVT() (n int) {
 	return n
 }
 
+func (m *InvokeStreamResponse) SizeVT() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Response != nil {
+		l = m.Response.SizeVT()
+		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+	}
+	if m.Progress != nil {
+		if size, ok := interface{}(m.Progress).(interface {
+			SizeVT() int
+		}); ok {
+			l = size.SizeVT()
+		} else {
+			l = proto.Size(m.Progress)
+		}
+		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+	}
+	if m.Final {
+		n += 2
+	}
+	n += len(m.unknownFields)
+	return n
+}
+
 func (m *Diagnostics) SizeVT() (n int) {
 	if m == nil {
 		return 0
@@ -5482,6 +6031,24 @@ func (m *ExecutionStats) SizeVT() (n int) {
 	if m.BytesFetched != 0 {
 		n += 1 + protohelpers.SizeOfVarint(uint64(m.BytesFetched))
 	}
+	if m.RowsScanned != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.RowsScanned))
+	}
+	if m.ProfilesScanned != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.ProfilesScanned))
+	}
+	if m.StackTraces != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.StackTraces))
+	}
+	if m.PeakMemoryBytes != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.PeakMemoryBytes))
+	}
+	if m.CacheHits != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.CacheHits))
+	}
+	if m.CacheMisses != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.CacheMisses))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -5690,6 +6257,12 @@ func (m *TimeSeriesQuery) SizeVT() (n int) {
 	if m.ExemplarType != 0 {
 		n += 1 + protohelpers.SizeOfVarint(uint64(m.ExemplarType))
 	}
+	if m.SamplingRatio != 0 {
+		n += 9
+	}
+	if m.MaxExemplarsPerPoint != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.MaxExemplarsPerPoint))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -5716,6 +6289,32 @@ func (m *TimeSeriesReport) SizeVT() (n int) {
 			n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 		}
 	}
+	if m.Sampling != nil {
+		l = m.Sampling.SizeVT()
+		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+	}
+	n += len(m.unknownFields)
+	return n
+}
+
+func (m *SamplingStats) SizeVT() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ProfilesTotal != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.ProfilesTotal))
+	}
+	if m.ProfilesSampled != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.ProfilesSampled))
+	}
+	if m.ValueSum != 0 {
+		n += 9
+	}
+	if m.ValueSumSquares != 0 {
+		n += 9
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -5766,6 +6365,9 @@ func (m *TreeQuery) SizeVT() (n int) {
 	if m.MaxUnresolvedLocations != 0 {
 		n += 1 + protohelpers.SizeOfVarint(uint64(m.MaxUnresolvedLocations))
 	}
+	if m.SamplingRatio != 0 {
+		n += 9
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -5914,6 +6516,10 @@ func (m *TreeReport) SizeVT() (n int) {
 		l = m.SymbolRefs.SizeVT()
 		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 	}
+	if m.Sampling != nil {
+		l = m.Sampling.SizeVT()
+		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -6103,6 +6709,10 @@ func (m *HeatmapReport) SizeVT() (n int) {
 		l = m.AttributeTable.SizeVT()
 		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 	}
+	if m.Sampling != nil {
+		l = m.Sampling.SizeVT()
+		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -6134,6 +6744,10 @@ func (m *Exemplar) SizeVT() (n int) {
 		}
 		n += 1 + protohelpers.SizeOfVarint(uint64(l)) + l
 	}
+	l = len(m.TraceId)
+	if l > 0 {
+		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -6210,6 +6824,10 @@ func (m *TimeSeriesCompactReport) SizeVT() (n int) {
 		l = m.AttributeTable.SizeVT()
 		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 	}
+	if m.Sampling != nil {
+		l = m.Sampling.SizeVT()
+		n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -6782,6 +7400,48 @@ func (m *InvokeRequest) UnmarshalVT(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesTombstones", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SeriesTombstones = append(m.SeriesTombstones, &v1.SeriesTombstone{})
+			if unmarshal, ok := interface{}(m.SeriesTombstones[len(m.SeriesTombstones)-1]).(interface {
+				UnmarshalVT([]byte) error
+			}); ok {
+				if err := unmarshal.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+					return err
+				}
+			} else {
+				if err := proto.Unmarshal(dAtA[iNdEx:postIndex], m.SeriesTombstones[len(m.SeriesTombstones)-1]); err != nil {
+					return err
+				}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -7516,7 +8176,7 @@ func (m *InvokeResponse) UnmarshalVT(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Diagnostics) UnmarshalVT(dAtA []byte) error {
+func (m *InvokeStreamResponse) UnmarshalVT(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7539,15 +8199,15 @@ func (m *Diagnostics) UnmarshalVT(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Diagnostics: wiretype end group for non-group")
+			return fmt.Errorf("proto: InvokeStreamResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Diagnostics: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: InvokeStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field QueryPlan", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Response", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -7574,16 +8234,16 @@ func (m *Diagnostics) UnmarshalVT(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.QueryPlan == nil {
-				m.QueryPlan = &QueryPlan{}
+			if m.Response == nil {
+				m.Response = &InvokeResponse{}
 			}
-			if err := m.QueryPlan.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Response.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExecutionNode", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Progress", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -7610,13 +8270,41 @@ func (m *Diagnostics) UnmarshalVT(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.ExecutionNode == nil {
-				m.ExecutionNode = &ExecutionNode{}
+			if m.Progress == nil {
+				m.Progress = &v11.QueryProgress{}
 			}
-			if err := m.ExecutionNode.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			if unmarshal, ok := interface{}(m.Progress).(interface {
+				UnmarshalVT([]byte) error
+			}); ok {
+				if err := unmarshal.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+					return err
+				}
+			} else {
+				if err := proto.Unmarshal(dAtA[iNdEx:postIndex], m.Progress); err != nil {
+					return err
+				}
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Final", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Final = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -7639,7 +8327,7 @@ func (m *Diagnostics) UnmarshalVT(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExecutionNode) UnmarshalVT(dAtA []byte) error {
+func (m *Diagnostics) UnmarshalVT(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7662,17 +8350,17 @@ func (m *ExecutionNode) UnmarshalVT(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExecutionNode: wiretype end group for non-group")
+			return fmt.Errorf("proto: Diagnostics: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExecutionNode: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Diagnostics: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryPlan", wireType)
 			}
-			m.Type = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return protohelpers.ErrIntOverflow
@@ -7682,24 +8370,147 @@ func (m *ExecutionNode) UnmarshalVT(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Type |= QueryNode_Type(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Executor", wireType)
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
 			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return protohelpers.ErrIntOverflow
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.QueryPlan == nil {
+				m.QueryPlan = &QueryPlan{}
+			}
+			if err := m.QueryPlan.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExecutionNode", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ExecutionNode == nil {
+				m.ExecutionNode = &ExecutionNode{}
+			}
+			if err := m.ExecutionNode.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.unknownFields = append(m.unknownFields, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExecutionNode) UnmarshalVT(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return protohelpers.ErrIntOverflow
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExecutionNode: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExecutionNode: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= QueryNode_Type(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Executor", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
 				iNdEx++
 				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
@@ -8001,6 +8812,120 @@ func (m *ExecutionStats) UnmarshalVT(dAtA []byte) error {
 					break
 				}
 			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RowsScanned", wireType)
+			}
+			m.RowsScanned = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RowsScanned |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ProfilesScanned", wireType)
+			}
+			m.ProfilesScanned = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ProfilesScanned |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StackTraces", wireType)
+			}
+			m.StackTraces = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StackTraces |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PeakMemoryBytes", wireType)
+			}
+			m.PeakMemoryBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PeakMemoryBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CacheHits", wireType)
+			}
+			m.CacheHits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CacheHits |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CacheMisses", wireType)
+			}
+			m.CacheMisses = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CacheMisses |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -9127,7 +10052,7 @@ func (m *SeriesLabelsReport) UnmarshalVT(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.SeriesLabels = append(m.SeriesLabels, &v11.Labels{})
+			m.SeriesLabels = append(m.SeriesLabels, &v12.Labels{})
 			if unmarshal, ok := interface{}(m.SeriesLabels[len(m.SeriesLabels)-1]).(interface {
 				UnmarshalVT([]byte) error
 			}); ok {
@@ -9204,9 +10129,196 @@ func (m *TimeSeriesQuery) UnmarshalVT(dAtA []byte) error {
 			m.Step = float64(math.Float64frombits(v))
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field GroupBy", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field GroupBy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GroupBy = append(m.GroupBy, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExemplarType", wireType)
+			}
+			m.ExemplarType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExemplarType |= v12.ExemplarType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplingRatio", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.SamplingRatio = float64(math.Float64frombits(v))
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxExemplarsPerPoint", wireType)
+			}
+			m.MaxExemplarsPerPoint = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxExemplarsPerPoint |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.unknownFields = append(m.unknownFields, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TimeSeriesReport) UnmarshalVT(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return protohelpers.ErrIntOverflow
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TimeSeriesReport: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TimeSeriesReport: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Query == nil {
+				m.Query = &TimeSeriesQuery{}
+			}
+			if err := m.Query.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeSeries", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return protohelpers.ErrIntOverflow
@@ -9216,29 +10328,39 @@ func (m *TimeSeriesQuery) UnmarshalVT(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return protohelpers.ErrInvalidLength
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return protohelpers.ErrInvalidLength
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.GroupBy = append(m.GroupBy, string(dAtA[iNdEx:postIndex]))
+			m.TimeSeries = append(m.TimeSeries, &v12.Series{})
+			if unmarshal, ok := interface{}(m.TimeSeries[len(m.TimeSeries)-1]).(interface {
+				UnmarshalVT([]byte) error
+			}); ok {
+				if err := unmarshal.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+					return err
+				}
+			} else {
+				if err := proto.Unmarshal(dAtA[iNdEx:postIndex], m.TimeSeries[len(m.TimeSeries)-1]); err != nil {
+					return err
+				}
+			}
 			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sampling", wireType)
 			}
-			m.Limit = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return protohelpers.ErrIntOverflow
@@ -9248,30 +10370,28 @@ func (m *TimeSeriesQuery) UnmarshalVT(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Limit |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExemplarType", wireType)
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
 			}
-			m.ExemplarType = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return protohelpers.ErrIntOverflow
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ExemplarType |= v11.ExemplarType(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Sampling == nil {
+				m.Sampling = &SamplingStats{}
+			}
+			if err := m.Sampling.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -9294,7 +10414,7 @@ func (m *TimeSeriesQuery) UnmarshalVT(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *TimeSeriesReport) UnmarshalVT(dAtA []byte) error {
+func (m *SamplingStats) UnmarshalVT(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -9317,17 +10437,17 @@ func (m *TimeSeriesReport) UnmarshalVT(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: TimeSeriesReport: wiretype end group for non-group")
+			return fmt.Errorf("proto: SamplingStats: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: TimeSeriesReport: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SamplingStats: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ProfilesTotal", wireType)
 			}
-			var msglen int
+			m.ProfilesTotal = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return protohelpers.ErrIntOverflow
@@ -9337,33 +10457,16 @@ func (m *TimeSeriesReport) UnmarshalVT(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ProfilesTotal |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return protohelpers.ErrInvalidLength
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return protohelpers.ErrInvalidLength
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Query == nil {
-				m.Query = &TimeSeriesQuery{}
-			}
-			if err := m.Query.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TimeSeries", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ProfilesSampled", wireType)
 			}
-			var msglen int
+			m.ProfilesSampled = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return protohelpers.ErrIntOverflow
@@ -9373,34 +10476,33 @@ func (m *TimeSeriesReport) UnmarshalVT(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ProfilesSampled |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return protohelpers.ErrInvalidLength
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return protohelpers.ErrInvalidLength
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ValueSum", wireType)
 			}
-			if postIndex > l {
+			var v uint64
+			if (iNdEx + 8) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.TimeSeries = append(m.TimeSeries, &v11.Series{})
-			if unmarshal, ok := interface{}(m.TimeSeries[len(m.TimeSeries)-1]).(interface {
-				UnmarshalVT([]byte) error
-			}); ok {
-				if err := unmarshal.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
-					return err
-				}
-			} else {
-				if err := proto.Unmarshal(dAtA[iNdEx:postIndex], m.TimeSeries[len(m.TimeSeries)-1]); err != nil {
-					return err
-				}
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ValueSum = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ValueSumSquares", wireType)
 			}
-			iNdEx = postIndex
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ValueSumSquares = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -9533,7 +10635,7 @@ func (m *TreeQuery) UnmarshalVT(dAtA []byte) error {
 				return io.ErrUnexpectedEOF
 			}
 			if m.StackTraceSelector == nil {
-				m.StackTraceSelector = &v11.StackTraceSelector{}
+				m.StackTraceSelector = &v12.StackTraceSelector{}
 			}
 			if unmarshal, ok := interface{}(m.StackTraceSelector).(interface {
 				UnmarshalVT([]byte) error
@@ -9669,6 +10771,17 @@ func (m *TreeQuery) UnmarshalVT(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplingRatio", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.SamplingRatio = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -9749,7 +10862,7 @@ func (m *TreeSymbols) UnmarshalVT(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Mappings = append(m.Mappings, &v12.Mapping{})
+			m.Mappings = append(m.Mappings, &v13.Mapping{})
 			if unmarshal, ok := interface{}(m.Mappings[len(m.Mappings)-1]).(interface {
 				UnmarshalVT([]byte) error
 			}); ok {
@@ -9791,7 +10904,7 @@ func (m *TreeSymbols) UnmarshalVT(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Locations = append(m.Locations, &v12.Location{})
+			m.Locations = append(m.Locations, &v13.Location{})
 			if unmarshal, ok := interface{}(m.Locations[len(m.Locations)-1]).(interface {
 				UnmarshalVT([]byte) error
 			}); ok {
@@ -9833,7 +10946,7 @@ func (m *TreeSymbols) UnmarshalVT(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Functions = append(m.Functions, &v12.Function{})
+			m.Functions = append(m.Functions, &v13.Function{})
 			if unmarshal, ok := interface{}(m.Functions[len(m.Functions)-1]).(interface {
 				UnmarshalVT([]byte) error
 			}); ok {
@@ -10674,6 +11787,42 @@ func (m *TreeReport) UnmarshalVT(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sampling", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Sampling == nil {
+				m.Sampling = &SamplingStats{}
+			}
+			if err := m.Sampling.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -10774,7 +11923,7 @@ func (m *PprofQuery) UnmarshalVT(dAtA []byte) error {
 				return io.ErrUnexpectedEOF
 			}
 			if m.StackTraceSelector == nil {
-				m.StackTraceSelector = &v11.StackTraceSelector{}
+				m.StackTraceSelector = &v12.StackTraceSelector{}
 			}
 			if unmarshal, ok := interface{}(m.StackTraceSelector).(interface {
 				UnmarshalVT([]byte) error
@@ -11113,7 +12262,7 @@ func (m *HeatmapQuery) UnmarshalVT(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.QueryType |= v13.HeatmapQueryType(b&0x7F) << shift
+				m.QueryType |= v11.HeatmapQueryType(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -11151,7 +12300,7 @@ func (m *HeatmapQuery) UnmarshalVT(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ExemplarType |= v11.ExemplarType(b&0x7F) << shift
+				m.ExemplarType |= v12.ExemplarType(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -11826,6 +12975,42 @@ func (m *HeatmapReport) UnmarshalVT(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sampling", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Sampling == nil {
+				m.Sampling = &SamplingStats{}
+			}
+			if err := m.Sampling.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -12055,6 +13240,38 @@ func (m *Exemplar) UnmarshalVT(dAtA []byte) error {
 			} else {
 				return fmt.Errorf("proto: wrong wireType = %d for field AttributeRefs", wireType)
 			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TraceId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -12564,6 +13781,42 @@ func (m *TimeSeriesCompactReport) UnmarshalVT(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sampling", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return protohelpers.ErrInvalidLength
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Sampling == nil {
+				m.Sampling = &SamplingStats{}
+			}
+			if err := m.Sampling.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -71,9 +71,16 @@ type PushRequest struct {
 	// Shard identifier the profile belongs to.
 	Shard uint32 `protobuf:"varint,6,opt,name=shard,proto3" json:"shard,omitempty"`
 	// Profile annotations with additional metadata.
-	Annotations   []*v1.ProfileAnnotation `protobuf:"bytes,7,rep,name=annotations,proto3" json:"annotations,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Annotations []*v1.ProfileAnnotation `protobuf:"bytes,7,rep,name=annotations,proto3" json:"annotations,omitempty"`
+	// Index of the replica within the replication group of the
+	// profile. 0 identifies the primary replica.
+	Replica uint32 `protobuf:"varint,8,opt,name=replica,proto3" json:"replica,omitempty"`
+	// End of the replication window of the request, in milliseconds since
+	// epoch. All the replicas of the profile share the window, and are
+	// written to the segments of the window. 0 if the push is not replicated.
+	ReplicationWindow int64 `protobuf:"varint,9,opt,name=replication_window,json=replicationWindow,proto3" json:"replication_window,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *PushRequest) Reset() {
@@ -148,12 +155,26 @@ func (x *PushRequest) GetAnnotations() []*v1.ProfileAnnotation {
 	return nil
 }
 
+func (x *PushRequest) GetReplica() uint32 {
+	if x != nil {
+		return x.Replica
+	}
+	return 0
+}
+
+func (x *PushRequest) GetReplicationWindow() int64 {
+	if x != nil {
+		return x.ReplicationWindow
+	}
+	return 0
+}
+
 var File_segmentwriter_v1_push_proto protoreflect.FileDescriptor
 
 const file_segmentwriter_v1_push_proto_rawDesc = "" +
 	"\n" +
 	"\x1bsegmentwriter/v1/push.proto\x12\x10segmentwriter.v1\x1a\x14types/v1/types.proto\"\x0e\n" +
-	"\fPushResponse\"\xeb\x01\n" +
+	"\fPushResponse\"\xb4\x02\n" +
 	"\vPushRequest\x12\x1b\n" +
 	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12+\n" +
 	"\x06labels\x18\x02 \x03(\v2\x13.types.v1.LabelPairR\x06labels\x12\x18\n" +
@@ -161,7 +182,9 @@ const file_segmentwriter_v1_push_proto_rawDesc = "" +
 	"\n" +
 	"profile_id\x18\x05 \x01(\fR\tprofileId\x12\x14\n" +
 	"\x05shard\x18\x06 \x01(\rR\x05shard\x12=\n" +
-	"\vannotations\x18\a \x03(\v2\x1b.types.v1.ProfileAnnotationR\vannotationsJ\x04\b\x03\x10\x042_\n" +
+	"\vannotations\x18\a \x03(\v2\x1b.types.v1.ProfileAnnotationR\vannotations\x12\x18\n" +
+	"\areplica\x18\b \x01(\rR\areplica\x12-\n" +
+	"\x12replication_window\x18\t \x01(\x03R\x11replicationWindowJ\x04\b\x03\x10\x042_\n" +
 	"\x14SegmentWriterService\x12G\n" +
 	"\x04Push\x12\x1d.segmentwriter.v1.PushRequest\x1a\x1e.segmentwriter.v1.PushResponse\"\x00B\xd2\x01\n" +
 	"\x14com.segmentwriter.v1B\tPushProtoP\x01ZNgithub.com/grafana/pyroscope/api/gen/proto/go/segmentwriter/v1;segmentwriterv1\xa2\x02\x03SXX\xaa\x02\x10Segmentwriter.V1\xca\x02\x10Segmentwriter\\V1\xe2\x02\x1cSegmentwriter\\V1\\GPBMetadata\xea\x02\x11Segmentwriter::V1b\x06proto3"
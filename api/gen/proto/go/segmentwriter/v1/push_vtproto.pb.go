@@ -47,6 +47,8 @@ func (m *PushRequest) CloneVT() *PushRequest {
 	r := new(PushRequest)
 	r.TenantId = m.TenantId
 	r.Shard = m.Shard
+	r.Replica = m.Replica
+	r.ReplicationWindow = m.ReplicationWindow
 	if rhs := m.Labels; rhs != nil {
 		tmpContainer := make([]*v1.LabelPair, len(rhs))
 		for k, v := range rhs {
@@ -168,6 +170,12 @@ func (this *PushRequest) EqualVT(that *PushRequest) bool {
 			}
 		}
 	}
+	if this.Replica != that.Replica {
+		return false
+	}
+	if this.ReplicationWindow != that.ReplicationWindow {
+		return false
+	}
 	return string(this.unknownFields) == string(that.unknownFields)
 }
 
@@ -333,6 +341,16 @@ func (m *PushRequest) MarshalToSizedBufferVT(dAtA []byte) (int, error) {
 		i -= len(m.unknownFields)
 		copy(dAtA[i:], m.unknownFields)
 	}
+	if m.ReplicationWindow != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.ReplicationWindow))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.Replica != 0 {
+		i = protohelpers.EncodeVarint(dAtA, i, uint64(m.Replica))
+		i--
+		dAtA[i] = 0x40
+	}
 	if len(m.Annotations) > 0 {
 		for iNdEx := len(m.Annotations) - 1; iNdEx >= 0; iNdEx-- {
 			if vtmsg, ok := interface{}(m.Annotations[iNdEx]).(interface {
@@ -465,6 +483,12 @@ func (m *PushRequest) SizeVT() (n int) {
 			n += 1 + l + protohelpers.SizeOfVarint(uint64(l))
 		}
 	}
+	if m.Replica != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.Replica))
+	}
+	if m.ReplicationWindow != 0 {
+		n += 1 + protohelpers.SizeOfVarint(uint64(m.ReplicationWindow))
+	}
 	n += len(m.unknownFields)
 	return n
 }
@@ -752,6 +776,44 @@ func (m *PushRequest) UnmarshalVT(dAtA []byte) error {
 				}
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+			}
+			m.Replica = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Replica |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicationWindow", wireType)
+			}
+			m.ReplicationWindow = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return protohelpers.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReplicationWindow |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := protohelpers.Skip(dAtA[iNdEx:])
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"debug/elf"
+	"debug/macho"
+	"debug/pe"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -10,16 +12,22 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
 
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 
 	debuginfov1alpha1 "github.com/grafana/pyroscope/api/gen/proto/go/debuginfo/v1alpha1"
 	debuginfov1alpha1connect "github.com/grafana/pyroscope/api/gen/proto/go/debuginfo/v1alpha1/debuginfov1alpha1connect"
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/lidia"
 	connectapi "github.com/grafana/pyroscope/v2/pkg/api/connect"
 	"github.com/grafana/pyroscope/v2/pkg/debuginfo"
+	metastoreclient "github.com/grafana/pyroscope/v2/pkg/metastore/client"
 )
 
 func (c *phlareClient) debuginfoServiceClient() debuginfov1alpha1connect.DebuginfoServiceClient {
@@ -76,6 +84,71 @@ func extractGnuBuildId(path string) (string, error) {
 	return extractGnuBuildIdFromReader(f)
 }
 
+// resolveDebuginfoPath returns the path of the DWARF file
+// if the path refers to a dSYM bundle directory.
+func resolveDebuginfoPath(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "Contents", "Resources", "DWARF", "*"))
+	if err != nil {
+		return "", err
+	}
+	if len(files) != 1 {
+		return "", fmt.Errorf("%q is not a dSYM bundle: expected a single file in Contents/Resources/DWARF, found %d", path, len(files))
+	}
+	return files[0], nil
+}
+
+// extractBuildIDs returns the build IDs the file is to be uploaded under:
+// the GNU build ID of ELF files, the UUID of Mach-O files (one per
+// architecture for universal binaries), and the debug ID of PE and PDB files.
+func extractBuildIDs(r io.ReaderAt) ([]string, lidia.ObjectFormat, error) {
+	format, err := lidia.DetectObjectFormat(r)
+	if err != nil {
+		return nil, format, err
+	}
+	var id string
+	switch format {
+	case lidia.ObjectFormatELF:
+		id, err = extractGnuBuildIdFromReader(r)
+	case lidia.ObjectFormatMachO:
+		var f *macho.File
+		if f, err = macho.NewFile(r); err == nil {
+			id = lidia.MachOUUID(f)
+		}
+	case lidia.ObjectFormatMachOFat:
+		f, err := macho.NewFatFile(r)
+		if err != nil {
+			return nil, format, err
+		}
+		var ids []string
+		for _, arch := range f.Arches {
+			if id := lidia.MachOUUID(arch.File); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids, format, nil
+	case lidia.ObjectFormatPE:
+		var f *pe.File
+		if f, err = pe.NewFile(r); err == nil {
+			id, err = lidia.PEDebugID(f)
+		}
+	case lidia.ObjectFormatPDB:
+		id, err = lidia.PDBDebugID(r)
+	default:
+		return nil, format, fmt.Errorf("unsupported file format")
+	}
+	if err != nil || id == "" {
+		return nil, format, err
+	}
+	return []string{id}, format, nil
+}
+
 func shouldInitiateUploadCheck(ctx context.Context, client debuginfov1alpha1connect.DebuginfoServiceClient, gnuBuildId string, fileName string, fileType debuginfov1alpha1.FileMetadata_Type) (bool, string, error) {
 	req := &debuginfov1alpha1.ShouldInitiateUploadRequest{
 		File: &debuginfov1alpha1.FileMetadata{
@@ -94,18 +167,25 @@ func shouldInitiateUploadCheck(ctx context.Context, client debuginfov1alpha1conn
 func uploadDebuginfo(ctx context.Context, params *debuginfoUploadParams) error {
 	client := params.debuginfoServiceClient()
 
-	f, err := os.Open(params.path)
+	path, err := resolveDebuginfoPath(params.path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer f.Close()
 
-	gnuBuildId, err := extractGnuBuildIdFromReader(f)
+	buildIDs, format, err := extractBuildIDs(f)
 	if err != nil {
-		return fmt.Errorf("failed to extract GNU build ID from %q: %w", params.path, err)
+		return fmt.Errorf("failed to extract build ID from %q: %w", path, err)
 	}
-	if gnuBuildId == "" {
-		return fmt.Errorf("file %q has no .note.gnu.build-id section; cannot upload", params.path)
+	if len(buildIDs) == 0 {
+		if format == lidia.ObjectFormatELF {
+			return fmt.Errorf("file %q has no .note.gnu.build-id section; cannot upload", path)
+		}
+		return fmt.Errorf("%s file %q has no build ID; cannot upload", format, path)
 	}
 
 	var fileType debuginfov1alpha1.FileMetadata_Type
@@ -114,11 +194,34 @@ func uploadDebuginfo(ctx context.Context, params *debuginfoUploadParams) error {
 		fileType = debuginfov1alpha1.FileMetadata_TYPE_EXECUTABLE_FULL
 	case "executable-no-text":
 		fileType = debuginfov1alpha1.FileMetadata_TYPE_EXECUTABLE_NO_TEXT
+	case "debug-symbols":
+		fileType = debuginfov1alpha1.FileMetadata_TYPE_DEBUG_SYMBOLS
 	default:
 		fileType = debuginfov1alpha1.FileMetadata_TYPE_UNSPECIFIED
 	}
+	if format == lidia.ObjectFormatPDB {
+		fileType = debuginfov1alpha1.FileMetadata_TYPE_DEBUG_SYMBOLS
+	}
 
-	shouldUpload, reason, err := shouldInitiateUploadCheck(ctx, client, gnuBuildId, filepath.Base(params.path), fileType)
+	// Universal Mach-O binaries are uploaded once per architecture:
+	// the symbolizer picks the architecture that matches the UUID.
+	for _, buildID := range buildIDs {
+		if err = uploadDebuginfoFile(ctx, client, params.phlareClient, f, buildID, fileType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func uploadDebuginfoFile(
+	ctx context.Context,
+	client debuginfov1alpha1connect.DebuginfoServiceClient,
+	params *phlareClient,
+	f *os.File,
+	buildID string,
+	fileType debuginfov1alpha1.FileMetadata_Type,
+) error {
+	shouldUpload, reason, err := shouldInitiateUploadCheck(ctx, client, buildID, filepath.Base(f.Name()), fileType)
 	if err != nil {
 		return fmt.Errorf("ShouldInitiateUpload check failed: %w", err)
 	}
@@ -126,7 +229,7 @@ func uploadDebuginfo(ctx context.Context, params *debuginfoUploadParams) error {
 		if reason == debuginfo.ReasonDisabled {
 			return fmt.Errorf("server has debuginfo upload disabled")
 		}
-		level.Info(logger).Log("msg", "server declined upload", "build_id", gnuBuildId, "reason", reason)
+		level.Info(logger).Log("msg", "server declined upload", "build_id", buildID, "reason", reason)
 		return nil
 	}
 
@@ -135,7 +238,7 @@ func uploadDebuginfo(ctx context.Context, params *debuginfoUploadParams) error {
 		return fmt.Errorf("failed to rewind file: %w", err)
 	}
 
-	uploadURL := params.URL + "/debuginfo.v1alpha1.DebuginfoService/Upload/" + gnuBuildId
+	uploadURL := params.URL + "/debuginfo.v1alpha1.DebuginfoService/Upload/" + buildID
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, f)
 	if err != nil {
 		return fmt.Errorf("failed to create upload request: %w", err)
@@ -153,12 +256,12 @@ func uploadDebuginfo(ctx context.Context, params *debuginfoUploadParams) error {
 	}
 
 	if _, err := client.UploadFinished(ctx, connect.NewRequest(&debuginfov1alpha1.UploadFinishedRequest{
-		GnuBuildId: gnuBuildId,
+		GnuBuildId: buildID,
 	})); err != nil {
 		return fmt.Errorf("failed to finish upload: %w", err)
 	}
 
-	level.Info(logger).Log("msg", "successfully uploaded debuginfo", "build_id", gnuBuildId, "path", params.path)
+	level.Info(logger).Log("msg", "successfully uploaded debuginfo", "build_id", buildID, "path", f.Name())
 	return nil
 }
 
@@ -170,8 +273,8 @@ type debuginfoUploadParams struct {
 
 func addDebuginfoUploadParams(cmd commander) *debuginfoUploadParams {
 	params := new(debuginfoUploadParams)
-	cmd.Arg("path", "Path to the file to upload").Required().ExistingFileVar(&params.path)
-	cmd.Flag("type", "Type of executable: executable-full, executable-no-text").Default("executable-full").StringVar(&params.fileType)
+	cmd.Arg("path", "Path to the file or dSYM bundle to upload").Required().ExistingFileOrDirVar(&params.path)
+	cmd.Flag("type", "Type of executable: executable-full, executable-no-text, debug-symbols. PDB files are always uploaded as debug-symbols.").Default("executable-full").StringVar(&params.fileType)
 
 	params.phlareClient = addPhlareClient(cmd)
 	return params
@@ -219,7 +322,7 @@ type debuginfoDeleteParams struct {
 
 func addDebuginfoDeleteParams(cmd commander) *debuginfoDeleteParams {
 	params := new(debuginfoDeleteParams)
-	cmd.Arg("gnu-build-id", "GNU build ID to delete").Required().StringVar(&params.gnuBuildID)
+	cmd.Arg("gnu-build-id", "Build ID to delete: GNU build ID, Mach-O UUID or PE debug ID").Required().StringVar(&params.gnuBuildID)
 	params.phlareClient = addPhlareClient(cmd)
 	return params
 }
@@ -238,6 +341,79 @@ func deleteDebuginfo(ctx context.Context, params *debuginfoDeleteParams) error {
 	return nil
 }
 
+type debuginfoGCParams struct {
+	*bucketParams
+
+	MetastoreAddress string
+	Tenant           string
+	GracePeriod      time.Duration
+	DryRun           bool
+}
+
+func addDebuginfoGCParams(cmd commander) *debuginfoGCParams {
+	params := new(debuginfoGCParams)
+	params.bucketParams = addBucketParams(cmd)
+	cmd.Flag("metastore.address", "Address of the metastore (host:port). Accepts a comma-separated list of peers.").
+		Default("localhost:9095").StringVar(&params.MetastoreAddress)
+	cmd.Flag("tenant-id", "Tenant ID to collect debuginfo for. If empty, all tenants are processed.").StringVar(&params.Tenant)
+	cmd.Flag("grace-period", "Period after the upload during which debuginfo is never deleted.").Default("168h").DurationVar(&params.GracePeriod)
+	cmd.Flag("dry-run", "Only print the debuginfo that would be deleted.").Default("true").BoolVar(&params.DryRun)
+	return params
+}
+
+// metadataLabelsQuerier adapts the metastore client
+// to the debuginfo.MetadataLabelsQuerier interface.
+type metadataLabelsQuerier struct{ *metastoreclient.Client }
+
+func (q metadataLabelsQuerier) QueryMetadataLabels(ctx context.Context, req *metastorev1.QueryMetadataLabelsRequest) (*metastorev1.QueryMetadataLabelsResponse, error) {
+	return q.Client.QueryMetadataLabels(ctx, req)
+}
+
+func gcDebuginfo(ctx context.Context, params *debuginfoGCParams) error {
+	bucket, err := params.initClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create bucket client: %w", err)
+	}
+
+	mc, err := newMetastoreClient(ctx, params.MetastoreAddress)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), mc.Service())
+	}()
+
+	gc := debuginfo.NewGarbageCollector(logger, debuginfo.GCConfig{
+		GracePeriod: params.GracePeriod,
+		DryRun:      params.DryRun,
+	}, bucket, metadataLabelsQuerier{mc}, nil)
+
+	var results []*debuginfo.GCResult
+	if params.Tenant != "" {
+		r, err := gc.CollectTenant(ctx, params.Tenant)
+		if err != nil {
+			return fmt.Errorf("failed to collect debuginfo: %w", err)
+		}
+		results = append(results, r)
+	} else if results, err = gc.Collect(ctx); err != nil {
+		return fmt.Errorf("failed to collect debuginfo: %w", err)
+	}
+
+	action := "deleted"
+	if params.DryRun {
+		action = "would delete"
+	}
+	for _, r := range results {
+		for _, buildID := range r.Deleted {
+			fmt.Printf("%s debuginfo tenant=%s build_id=%s\n", action, r.TenantID, buildID)
+		}
+		fmt.Printf("tenant=%s scanned=%d referenced=%d %s=%d\n",
+			r.TenantID, r.Scanned, r.Referenced, strings.ReplaceAll(action, " ", "_"), len(r.Deleted))
+	}
+
+	return nil
+}
+
 func formatUploadedAt(ts *timestamppb.Timestamp) string {
 	if ts == nil {
 		return "in-progress"
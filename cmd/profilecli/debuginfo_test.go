@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"debug/elf"
+	"debug/macho"
 	"encoding/binary"
 	"net/http/httptest"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	debuginfov1alpha1connect "github.com/grafana/pyroscope/api/gen/proto/go/debuginfo/v1alpha1/debuginfov1alpha1connect"
+	"github.com/grafana/pyroscope/lidia"
 	"github.com/grafana/pyroscope/v2/pkg/debuginfo"
 	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/memory"
 	"github.com/grafana/pyroscope/v2/pkg/tenant"
@@ -90,6 +92,31 @@ func makeTestELF(t *testing.T, buildID []byte) string {
 	return path
 }
 
+// makeTestMachO writes a minimal 64-bit Mach-O file with
+// an LC_UUID load command only, and returns its path.
+func makeTestMachO(t *testing.T, dir string, uuid []byte) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, macho.FileHeader{
+		Magic:  macho.Magic64,
+		Cpu:    macho.CpuArm64,
+		Type:   macho.TypeExec,
+		Ncmd:   1,
+		Cmdsz:  24,
+		Flags:  0,
+		SubCpu: 0,
+	})
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0)) // reserved
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0x1b))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(24))
+	buf.Write(uuid)
+
+	path := filepath.Join(dir, "test")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+	return path
+}
+
 func startDebuginfoTestServer(t *testing.T, enabled bool) *httptest.Server {
 	t.Helper()
 	store, err := debuginfo.NewStore(log.NewNopLogger(), memory.NewInMemBucket(), debuginfo.Config{
@@ -149,6 +176,43 @@ func TestExtractGnuBuildId_Truncated(t *testing.T) {
 	assert.Contains(t, err.Error(), "truncated")
 }
 
+func TestExtractBuildIDs(t *testing.T) {
+	t.Parallel()
+
+	uuid := []byte{0x2c, 0xfd, 0xda, 0xcb, 0x28, 0xb2, 0x34, 0xd7, 0x99, 0x58, 0xb9, 0x3f, 0x1e, 0x31, 0x8d, 0x67}
+	f, err := os.Open(makeTestMachO(t, t.TempDir(), uuid))
+	require.NoError(t, err)
+	defer f.Close()
+
+	ids, format, err := extractBuildIDs(f)
+	require.NoError(t, err)
+	assert.Equal(t, lidia.ObjectFormatMachO, format)
+	assert.Equal(t, []string{"2cfddacb-28b2-34d7-9958-b93f1e318d67"}, ids)
+
+	_, format, err = extractBuildIDs(bytes.NewReader([]byte("not an object file")))
+	require.Error(t, err)
+	assert.Equal(t, lidia.ObjectFormatUnknown, format)
+}
+
+func TestResolveDebuginfoPath(t *testing.T) {
+	t.Parallel()
+
+	bundle := filepath.Join(t.TempDir(), "test.dSYM")
+	dwarfDir := filepath.Join(bundle, "Contents", "Resources", "DWARF")
+	require.NoError(t, os.MkdirAll(dwarfDir, 0o755))
+	_, err := resolveDebuginfoPath(bundle)
+	require.Error(t, err)
+
+	path := makeTestMachO(t, dwarfDir, make([]byte, 16))
+	resolved, err := resolveDebuginfoPath(bundle)
+	require.NoError(t, err)
+	assert.Equal(t, path, resolved)
+
+	resolved, err = resolveDebuginfoPath(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, resolved)
+}
+
 func TestUploadDebuginfo(t *testing.T) {
 	t.Parallel()
 	srv := startDebuginfoTestServer(t, true)
@@ -165,6 +229,22 @@ func TestUploadDebuginfo(t *testing.T) {
 	require.NoError(t, uploadDebuginfo(context.Background(), params))
 }
 
+func TestUploadDebuginfo_MachO(t *testing.T) {
+	t.Parallel()
+	srv := startDebuginfoTestServer(t, true)
+
+	bundle := filepath.Join(t.TempDir(), "test.dSYM")
+	dwarfDir := filepath.Join(bundle, "Contents", "Resources", "DWARF")
+	require.NoError(t, os.MkdirAll(dwarfDir, 0o755))
+	makeTestMachO(t, dwarfDir, []byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3})
+
+	require.NoError(t, uploadDebuginfo(context.Background(), &debuginfoUploadParams{
+		path:         bundle,
+		fileType:     "debug-symbols",
+		phlareClient: &phlareClient{URL: srv.URL, TenantID: "t4"},
+	}))
+}
+
 func TestUploadDebuginfo_Disabled(t *testing.T) {
 	t.Parallel()
 	srv := startDebuginfoTestServer(t, false)
@@ -89,10 +89,10 @@ func main() {
 	queryLabelValuesCardinalityParams := addQueryLabelValuesCardinalityParams(queryLabelValuesCardinalityCmd)
 	queryTopCmd := queryCmd.Command("top", "List top N label values by total value for a time window.")
 	queryTopParams := addQueryTopParams(queryTopCmd)
-	queryExemplarsCmd := queryCmd.Command("exemplars", "Query exemplars from profile data. V2 only.")
+	queryExemplarsCmd := queryCmd.Command("exemplars", "Query exemplars from profile data.")
 	queryExemplarsProfileCmd := queryExemplarsCmd.Command("profile", "List profile exemplars for a time window.")
 	queryExemplarsParams := addQueryExemplarsParams(queryExemplarsProfileCmd)
-	queryExemplarsSpanCmd := queryExemplarsCmd.Command("span", "List span exemplars for a time window. Requires span-aware SDK instrumentation. V2 only.")
+	queryExemplarsSpanCmd := queryExemplarsCmd.Command("span", "List span exemplars for a time window. Requires span-aware SDK instrumentation.")
 	queryExemplarsSpanParams := addQueryExemplarsParams(queryExemplarsSpanCmd)
 
 	queryTracerCmd := app.Command("query-tracer", "Analyze query traces.")
@@ -126,6 +126,10 @@ func main() {
 	raftInfoCmd := raftCmd.Command("info", "Print info about a Raft node.")
 	raftInfoParams := addRaftInfoParams(raftInfoCmd)
 
+	metastoreCmd := adminCmd.Command("metastore", "Operate on the metastore index.")
+	metastoreRebuildCmd := metastoreCmd.Command("rebuild", "Rebuild the metastore index from the segments and blocks found in the object storage. The latest index export is used, if available.")
+	metastoreRebuildParams := addMetastoreRebuildParams(metastoreRebuildCmd)
+
 	v2MigrationCmd := adminCmd.Command("v2-migration", "Operation to aid the v1 to v2 storage migration.")
 	v2MigrationBucketCleanupCmd := v2MigrationCmd.Command("bucket-cleanup", "Clean up v1 artificats from data bucket.")
 	v2MigrationBucketCleanupParams := addV2MigrationBackupCleanupParam(v2MigrationBucketCleanupCmd)
@@ -152,12 +156,22 @@ func main() {
 	replayPushParams := addReplayPushParams(replayPushCmd)
 
 	debuginfoCmd := app.Command("debuginfo", "Operations on debuginfo (experimental).")
-	debuginfoUploadCmd := debuginfoCmd.Command("upload", "Upload debuginfo.")
+	debuginfoUploadCmd := debuginfoCmd.Command("upload", "Upload debuginfo: ELF, Mach-O (including dSYM and universal binaries), PE or PDB files.")
 	debuginfoUploadParams := addDebuginfoUploadParams(debuginfoUploadCmd)
 	debuginfoListCmd := debuginfoCmd.Command("list", "List debuginfo.")
 	debuginfoListParams := addDebuginfoListParams(debuginfoListCmd)
-	debuginfoDeleteCmd := debuginfoCmd.Command("delete", "Delete debuginfo by GNU build ID.")
+	debuginfoDeleteCmd := debuginfoCmd.Command("delete", "Delete debuginfo by build ID.")
 	debuginfoDeleteParams := addDebuginfoDeleteParams(debuginfoDeleteCmd)
+	debuginfoGCCmd := debuginfoCmd.Command("gc", "Delete debuginfo that is not referenced by any block in the metastore index. Runs in dry-run mode by default.")
+	debuginfoGCParams := addDebuginfoGCParams(debuginfoGCCmd)
+
+	sourceMapCmd := app.Command("sourcemap", "Operations on JavaScript source maps (experimental).")
+	sourceMapUploadCmd := sourceMapCmd.Command("upload", "Upload a source map for a bundle.")
+	sourceMapUploadParams := addSourceMapUploadParams(sourceMapUploadCmd)
+	sourceMapListCmd := sourceMapCmd.Command("list", "List source maps.")
+	sourceMapListParams := addSourceMapListParams(sourceMapListCmd)
+	sourceMapDeleteCmd := sourceMapCmd.Command("delete", "Delete the source map of a bundle.")
+	sourceMapDeleteParams := addSourceMapDeleteParams(sourceMapDeleteCmd)
 
 	// parse command line arguments
 	parsedCmd := kingpin.MustParse(app.Parse(os.Args[1:]))
@@ -262,6 +276,10 @@ func main() {
 		if err := raftInfo(ctx, raftInfoParams); err != nil {
 			os.Exit(checkError(err))
 		}
+	case metastoreRebuildCmd.FullCommand():
+		if err := metastoreRebuild(ctx, metastoreRebuildParams); err != nil {
+			os.Exit(checkError(err))
+		}
 	case v2MigrationBucketCleanupCmd.FullCommand():
 		if err := v2MigrationBucketCleanup(ctx, v2MigrationBucketCleanupParams); err != nil {
 			os.Exit(checkError(err))
@@ -306,6 +324,22 @@ func main() {
 		if err := deleteDebuginfo(ctx, debuginfoDeleteParams); err != nil {
 			os.Exit(checkError(err))
 		}
+	case debuginfoGCCmd.FullCommand():
+		if err := gcDebuginfo(ctx, debuginfoGCParams); err != nil {
+			os.Exit(checkError(err))
+		}
+	case sourceMapUploadCmd.FullCommand():
+		if err := uploadSourceMap(ctx, sourceMapUploadParams); err != nil {
+			os.Exit(checkError(err))
+		}
+	case sourceMapListCmd.FullCommand():
+		if err := listSourceMaps(ctx, sourceMapListParams); err != nil {
+			os.Exit(checkError(err))
+		}
+	case sourceMapDeleteCmd.FullCommand():
+		if err := deleteSourceMap(ctx, sourceMapDeleteParams); err != nil {
+			os.Exit(checkError(err))
+		}
 	default:
 		level.Error(logger).Log("msg", "unknown command", "cmd", parsedCmd)
 	}
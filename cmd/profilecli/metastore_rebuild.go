@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	metastoreclient "github.com/grafana/pyroscope/v2/pkg/metastore/client"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index/backup"
+)
+
+type metastoreRebuildParams struct {
+	*bucketParams
+
+	MetastoreAddress string
+	Concurrency      int
+	DryRun           bool
+}
+
+func addMetastoreRebuildParams(cmd commander) *metastoreRebuildParams {
+	params := &metastoreRebuildParams{}
+	params.bucketParams = addBucketParams(cmd)
+
+	cmd.Flag("metastore.address", "Address of the metastore (host:port). Accepts a comma-separated list of peers.").
+		Default("localhost:9095").StringVar(&params.MetastoreAddress)
+	cmd.Flag("concurrency", "Number of objects to process concurrently.").Default("16").IntVar(&params.Concurrency)
+	cmd.Flag("dry-run", "Read the block metadata without adding it to the metastore.").Default("false").BoolVar(&params.DryRun)
+	return params
+}
+
+// metastoreAddBlock adds recovered blocks via the metastore client. Unlike
+// regular blocks, they are not accounted in the placement stats.
+type metastoreAddBlock struct {
+	client *metastoreclient.Client
+}
+
+func (m metastoreAddBlock) AddRecoveredBlock(ctx context.Context, req *metastorev1.AddBlockRequest) (*metastorev1.AddBlockResponse, error) {
+	return m.client.AddRecoveredBlock(ctx, req)
+}
+
+type metastoreDryRun struct {
+	blocks atomic.Int64
+}
+
+func (m *metastoreDryRun) AddRecoveredBlock(_ context.Context, req *metastorev1.AddBlockRequest) (*metastorev1.AddBlockResponse, error) {
+	m.blocks.Add(1)
+	level.Debug(logger).Log("msg", "found block", "block", req.Block.Id, "shard", req.Block.Shard, "level", req.Block.CompactionLevel)
+	return new(metastorev1.AddBlockResponse), nil
+}
+
+func metastoreRebuild(ctx context.Context, params *metastoreRebuildParams) error {
+	bucket, err := params.initClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create bucket client: %w", err)
+	}
+
+	var m backup.Metastore = new(metastoreDryRun)
+	if !params.DryRun {
+		mc, err := newMetastoreClient(ctx, params.MetastoreAddress)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = services.StopAndAwaitTerminated(context.Background(), mc.Service())
+		}()
+		m = metastoreAddBlock{client: mc}
+	}
+
+	level.Info(logger).Log("msg", "rebuilding metastore index", "dry_run", params.DryRun)
+	r := backup.NewRebuilder(logger, backup.Config{}, m, bucket, prometheus.NewRegistry())
+	r.SetConcurrency(params.Concurrency)
+	stats, err := r.Rebuild(ctx)
+	level.Info(logger).Log(
+		"msg", "metastore index rebuild finished",
+		"objects", stats.Objects,
+		"exported", stats.Exported,
+		"added", stats.Added,
+		"deleted", stats.Deleted,
+		"skipped", stats.Skipped,
+	)
+	return err
+}
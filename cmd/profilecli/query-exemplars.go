@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -22,6 +23,7 @@ type queryExemplarsParams struct {
 	ProfileType     string
 	Output          string
 	TopN            uint64
+	PerPoint        int64
 	MaxLabelColumns int
 }
 
@@ -31,6 +33,7 @@ func addQueryExemplarsParams(queryCmd commander) *queryExemplarsParams {
 	queryCmd.Flag("profile-type", "Profile type to query.").Default("process_cpu:cpu:nanoseconds:cpu:nanoseconds").StringVar(&params.ProfileType)
 	queryCmd.Flag("output", "Output format, one of: table, json.").Default("table").StringVar(&params.Output)
 	queryCmd.Flag("top-n", "Maximum number of exemplars to show.").Default("100").Uint64Var(&params.TopN)
+	queryCmd.Flag("exemplars-per-point", "Maximum number of exemplars returned for each time series point, at most 100.").Default("1").Int64Var(&params.PerPoint)
 	queryCmd.Flag("max-label-columns", "Maximum number of label columns to show in table output. Set to 0 to hide labels.").Default("3").IntVar(&params.MaxLabelColumns)
 	return params
 }
@@ -62,66 +65,11 @@ func queryExemplars(ctx context.Context, params *queryExemplarsParams) error {
 		"top_n", params.TopN,
 	)
 
-	// Calculate step: divide time range into approximately topN buckets so we
-	// get roughly one exemplar per bucket (DefaultMaxExemplarsPerPoint = 1).
-	rangeSeconds := to.Sub(from).Seconds()
-	stepSeconds := rangeSeconds / float64(params.TopN)
-	if stepSeconds < 1 {
-		stepSeconds = 1
-	}
-
-	qc := params.queryClient()
-	resp, err := qc.SelectSeries(ctx, connect.NewRequest(&querierv1.SelectSeriesRequest{
-		ProfileTypeID: params.ProfileType,
-		LabelSelector: params.Query,
-		Start:         from.UnixMilli(),
-		End:           to.UnixMilli(),
-		Step:          stepSeconds,
-		ExemplarType:  typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL,
-	}))
+	entries, err := selectSeriesExemplars(ctx, params, from, to, typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL)
 	if err != nil {
 		return fmt.Errorf("failed to query exemplars: %w", err)
 	}
 
-	logDiagnostics(params.phlareClient, resp.Header())
-
-	// Extract exemplars from all series points into a flat slice.
-	// Pre-count to avoid repeated slice growth.
-	var totalExemplars int
-	for _, s := range resp.Msg.Series {
-		for _, p := range s.Points {
-			totalExemplars += len(p.Exemplars)
-		}
-	}
-	entries := make([]exemplarEntry, 0, totalExemplars)
-	for _, s := range resp.Msg.Series {
-		// Build series-level labels map for context.
-		seriesLabels := make(map[string]string, len(s.Labels))
-		for _, lp := range s.Labels {
-			seriesLabels[lp.Name] = lp.Value
-		}
-
-		for _, p := range s.Points {
-			for _, ex := range p.Exemplars {
-				lbls := make(map[string]string, len(seriesLabels)+len(ex.Labels))
-				for k, v := range seriesLabels {
-					lbls[k] = v
-				}
-				for _, lp := range ex.Labels {
-					lbls[lp.Name] = lp.Value
-				}
-				entries = append(entries, exemplarEntry{
-					ProfileID: ex.ProfileId,
-					Timestamp: time.UnixMilli(ex.Timestamp),
-					Value:     ex.Value,
-					SpanID:    ex.SpanId,
-					TraceID:   ex.TraceId,
-					Labels:    lbls,
-				})
-			}
-		}
-	}
-
 	// Sort by value descending (highest value = most interesting).
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Value > entries[j].Value
@@ -258,62 +206,51 @@ func outputExemplarsJSON(ctx context.Context, entries []exemplarEntry, from, to
 	return enc.Encode(out)
 }
 
-// querySpanExemplars lists span exemplars by calling SelectHeatmap with
-// HEATMAP_QUERY_TYPE_SPAN, which is already fully implemented in the backend.
-// The heatmap slots each carry at most one exemplar (the highest-value span in
-// that time×value bucket), so iterating all slots gives a representative sample
-// of the most expensive spans in the requested window.
-func querySpanExemplars(ctx context.Context, params *queryExemplarsParams) error {
-	from, to, err := params.parseFromTo()
-	if err != nil {
-		return err
-	}
-
-	level.Info(logger).Log(
-		"msg", "querying span exemplars",
-		"url", params.URL,
-		"from", from,
-		"to", to,
-		"query", params.Query,
-		"type", params.ProfileType,
-		"top_n", params.TopN,
-	)
-
+// selectSeriesExemplars calls SelectSeries with the given exemplar type and
+// returns the exemplars of all the series points as a flat slice.
+func selectSeriesExemplars(ctx context.Context, params *queryExemplarsParams, from, to time.Time, exemplarType typesv1.ExemplarType) ([]exemplarEntry, error) {
+	// Calculate step: divide time range into approximately topN buckets so we
+	// get roughly exemplars-per-point exemplars per bucket.
 	rangeSeconds := to.Sub(from).Seconds()
 	stepSeconds := rangeSeconds / float64(params.TopN)
 	if stepSeconds < 1 {
 		stepSeconds = 1
 	}
 
-	limit := int64(params.TopN)
 	qc := params.queryClient()
-	resp, err := qc.SelectHeatmap(ctx, connect.NewRequest(&querierv1.SelectHeatmapRequest{
-		ProfileTypeID: params.ProfileType,
-		LabelSelector: params.Query,
-		Start:         from.UnixMilli(),
-		End:           to.UnixMilli(),
-		Step:          stepSeconds,
-		QueryType:     querierv1.HeatmapQueryType_HEATMAP_QUERY_TYPE_SPAN,
-		ExemplarType:  typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN,
-		Limit:         &limit,
+	resp, err := qc.SelectSeries(ctx, connect.NewRequest(&querierv1.SelectSeriesRequest{
+		ProfileTypeID:        params.ProfileType,
+		LabelSelector:        params.Query,
+		Start:                from.UnixMilli(),
+		End:                  to.UnixMilli(),
+		Step:                 stepSeconds,
+		ExemplarType:         exemplarType,
+		MaxExemplarsPerPoint: &params.PerPoint,
 	}))
 	if err != nil {
-		return fmt.Errorf("failed to query span exemplars: %w", err)
+		return nil, err
 	}
 
 	logDiagnostics(params.phlareClient, resp.Header())
 
-	var entries []exemplarEntry
-	for _, series := range resp.Msg.Series {
-		seriesLabels := make(map[string]string, len(series.Labels))
-		for _, lp := range series.Labels {
+	// Extract exemplars from all series points into a flat slice.
+	// Pre-count to avoid repeated slice growth.
+	var totalExemplars int
+	for _, s := range resp.Msg.Series {
+		for _, p := range s.Points {
+			totalExemplars += len(p.Exemplars)
+		}
+	}
+	entries := make([]exemplarEntry, 0, totalExemplars)
+	for _, s := range resp.Msg.Series {
+		// Build series-level labels map for context.
+		seriesLabels := make(map[string]string, len(s.Labels))
+		for _, lp := range s.Labels {
 			seriesLabels[lp.Name] = lp.Value
 		}
-		for _, slot := range series.Slots {
-			for _, ex := range slot.Exemplars {
-				if ex.SpanId == "" {
-					continue
-				}
+
+		for _, p := range s.Points {
+			for _, ex := range p.Exemplars {
 				lbls := make(map[string]string, len(seriesLabels)+len(ex.Labels))
 				for k, v := range seriesLabels {
 					lbls[k] = v
@@ -322,15 +259,46 @@ func querySpanExemplars(ctx context.Context, params *queryExemplarsParams) error
 					lbls[lp.Name] = lp.Value
 				}
 				entries = append(entries, exemplarEntry{
-					SpanID:    ex.SpanId,
-					TraceID:   ex.TraceId,
+					ProfileID: ex.ProfileId,
 					Timestamp: time.UnixMilli(ex.Timestamp),
 					Value:     ex.Value,
+					SpanID:    ex.SpanId,
+					TraceID:   ex.TraceId,
 					Labels:    lbls,
 				})
 			}
 		}
 	}
+	return entries, nil
+}
+
+// querySpanExemplars lists span exemplars by calling SelectSeries with
+// EXEMPLAR_TYPE_SPAN: each point carries the spans that contributed
+// most to its value, along with their trace and profile IDs.
+func querySpanExemplars(ctx context.Context, params *queryExemplarsParams) error {
+	from, to, err := params.parseFromTo()
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log(
+		"msg", "querying span exemplars",
+		"url", params.URL,
+		"from", from,
+		"to", to,
+		"query", params.Query,
+		"type", params.ProfileType,
+		"top_n", params.TopN,
+	)
+
+	entries, err := selectSeriesExemplars(ctx, params, from, to, typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN)
+	if err != nil {
+		return fmt.Errorf("failed to query span exemplars: %w", err)
+	}
+	// Profiles without span data are represented by profile exemplars.
+	entries = slices.DeleteFunc(entries, func(e exemplarEntry) bool {
+		return e.SpanID == ""
+	})
 
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Value > entries[j].Value
@@ -516,17 +516,67 @@ func querySeries(ctx context.Context, params *querySeriesParams) (err error) {
 
 type queryLabelValuesCardinalityParams struct {
 	*queryParams
-	TopN uint64
+	TopN       uint64
+	LabelNames []string
+	Prefix     string
+	Regex      string
+	FullScan   bool
 }
 
 func addQueryLabelValuesCardinalityParams(queryCmd commander) *queryLabelValuesCardinalityParams {
 	params := new(queryLabelValuesCardinalityParams)
 	params.queryParams = addQueryParams(queryCmd)
 	queryCmd.Flag("top-n", "Show the top N high cardinality label values").Default("20").Uint64Var(&params.TopN)
+	queryCmd.Flag("label-names", "Label names to show the values of. Only labels recorded in the dataset metadata are supported.").Default(model.LabelNameServiceName, model.LabelNameProfileType).StringsVar(&params.LabelNames)
+	queryCmd.Flag("prefix", "Only show label values starting with the prefix.").StringVar(&params.Prefix)
+	queryCmd.Flag("regex", "Only show label values matching the regular expression.").StringVar(&params.Regex)
+	queryCmd.Flag("full-scan", "Count the values of all the label names by querying the blocks, instead of using the dataset metadata. This may be slow for large time ranges.").BoolVar(&params.FullScan)
 	return params
 }
 
 func queryLabelValuesCardinality(ctx context.Context, params *queryLabelValuesCardinalityParams) (err error) {
+	if params.FullScan {
+		return queryLabelValuesCardinalityFullScan(ctx, params)
+	}
+	from, to, err := params.parseFromTo()
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "query label values cardinality", "url", params.URL, "from", from, "to", to, "labelNames", fmt.Sprintf("%q", params.LabelNames))
+
+	qc := params.phlareClient.queryClient()
+	table := newTableWriter(output(ctx))
+	table.SetHeader([]string{"LabelName", "Value", "Cardinality"})
+	for _, name := range params.LabelNames {
+		resp, err := qc.LabelValuesCardinality(ctx, connect.NewRequest(&querierv1.LabelValuesCardinalityRequest{
+			Name:     name,
+			Start:    from.UnixMilli(),
+			End:      to.UnixMilli(),
+			Matchers: []string{params.Query},
+			Prefix:   params.Prefix,
+			Regex:    params.Regex,
+			Limit:    int64(params.TopN),
+		}))
+		if err != nil {
+			return fmt.Errorf("failed to query label values cardinality for %s: %w", name, err)
+		}
+		logDiagnostics(params.phlareClient, resp.Header())
+		for _, v := range resp.Msg.Values {
+			table.Append([]string{name, v.Value, humanize.FormatInteger("#,###.", int(v.Cardinality))})
+		}
+		if resp.Msg.Truncated {
+			level.Info(logger).Log("msg", fmt.Sprintf("label values of %s truncated to top %d", name, params.TopN))
+		}
+	}
+	table.Render()
+
+	return nil
+}
+
+// queryLabelValuesCardinalityFullScan counts the values of each label
+// name, which requires querying the blocks for every label name.
+func queryLabelValuesCardinalityFullScan(ctx context.Context, params *queryLabelValuesCardinalityParams) (err error) {
 	from, to, err := params.parseFromTo()
 	if err != nil {
 		return err
@@ -48,6 +48,7 @@ func (c *hybridVCSClient) GetFile(ctx context.Context, req client.FileRequest) (
 type functionResult struct {
 	FunctionName string
 	Path         string
+	Language     config.Language
 	Covered      bool
 	Error        string
 	ResolvedURL  string
@@ -59,9 +60,18 @@ type coverageReport struct {
 	CoveredFunctions   int
 	UncoveredFunctions int
 	CoveragePercentage float64
+	Languages          []languageCoverage
 	Results            []functionResult
 }
 
+// languageCoverage is the coverage of the functions of a language.
+type languageCoverage struct {
+	Language           config.Language
+	TotalFunctions     int
+	CoveredFunctions   int
+	CoveragePercentage float64
+}
+
 type sourceCodeCoverageParams struct {
 	ProfilePath   string
 	ConfigPath    string
@@ -161,9 +171,12 @@ func checkFunctionCoverage(ctx context.Context, fn config.FileSpec, cfg *config.
 	mapping := cfg.FindMapping(fn)
 
 	if mapping == nil {
+		result.Language = source.DetectLanguage(fn)
 		result.Covered = false
 		result.Error = "no mapping found"
 	} else {
+		result.Language = config.Language(mapping.Language)
+
 		dummyRepo, _ := giturl.NewGitURL("https://github.com/dummy/repo")
 
 		finder := source.NewFileFinder(
@@ -316,6 +329,7 @@ func analyzeCoverage(ctx context.Context, profile *profilev1.Profile, functions
 	}
 
 	report.sortBySampleCount()
+	report.Languages = coverageByLanguage(report.Results)
 
 	fmt.Fprintf(os.Stderr, "\n✓ Analysis complete: %d/%d functions covered (%.2f%%)\n",
 		report.CoveredFunctions, report.TotalFunctions, report.CoveragePercentage)
@@ -387,6 +401,35 @@ func calculateSampleCountsMap(profile *profilev1.Profile) map[string]int64 {
 	return functionSampleCounts
 }
 
+// coverageByLanguage returns the coverage of each language, ordered by the
+// number of functions in descending order.
+func coverageByLanguage(results []functionResult) []languageCoverage {
+	byLanguage := make(map[config.Language]*languageCoverage)
+	for _, r := range results {
+		c, ok := byLanguage[r.Language]
+		if !ok {
+			c = &languageCoverage{Language: r.Language}
+			byLanguage[r.Language] = c
+		}
+		c.TotalFunctions++
+		if r.Covered {
+			c.CoveredFunctions++
+		}
+	}
+	languages := make([]languageCoverage, 0, len(byLanguage))
+	for _, c := range byLanguage {
+		c.CoveragePercentage = float64(c.CoveredFunctions) / float64(c.TotalFunctions) * 100
+		languages = append(languages, *c)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].TotalFunctions != languages[j].TotalFunctions {
+			return languages[i].TotalFunctions > languages[j].TotalFunctions
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	return languages
+}
+
 func (r *coverageReport) sortBySampleCount() {
 	sort.Slice(r.Results, func(i, j int) bool {
 		return r.Results[i].SampleCount > r.Results[j].SampleCount
@@ -413,6 +456,18 @@ func outputText(report *coverageReport) {
 	fmt.Printf("Uncovered Functions: %d\n", report.UncoveredFunctions)
 	fmt.Printf("Coverage:            %.2f%%\n", report.CoveragePercentage)
 	fmt.Println()
+	if len(report.Languages) == 0 {
+		return
+	}
+	fmt.Println("=== Coverage by Language ===")
+	for _, l := range report.Languages {
+		name := string(l.Language)
+		if l.Language == config.LanguageUnknown {
+			name = "unknown"
+		}
+		fmt.Printf("%-12s %d/%d (%.2f%%)\n", name+":", l.CoveredFunctions, l.TotalFunctions, l.CoveragePercentage)
+	}
+	fmt.Println()
 }
 
 func outputDetailed(report *coverageReport) {
@@ -430,6 +485,9 @@ func outputDetailed(report *coverageReport) {
 		if result.Path != "" {
 			fmt.Printf("    Path: %s\n", result.Path)
 		}
+		if result.Language != config.LanguageUnknown {
+			fmt.Printf("    Language: %s\n", result.Language)
+		}
 		if result.Covered {
 			if result.ResolvedURL != "" {
 				fmt.Printf("    URL: %s\n", result.ResolvedURL)
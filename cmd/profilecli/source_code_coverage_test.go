@@ -166,6 +166,34 @@ func TestGenerateOutput(t *testing.T) {
 	})
 }
 
+func TestCoverageByLanguage(t *testing.T) {
+	languages := coverageByLanguage([]functionResult{
+		{FunctionName: "main", Language: config.LanguageGo, Covered: true},
+		{FunctionName: "foo", Language: config.LanguageGo, Covered: false},
+		{FunctionName: "tokio::run", Language: config.LanguageRust, Covered: true},
+		{FunctionName: "Example!Example.App.Run()", Language: config.LanguageDotNet, Covered: false},
+	})
+	require.Equal(t, []languageCoverage{
+		{Language: config.LanguageGo, TotalFunctions: 2, CoveredFunctions: 1, CoveragePercentage: 50},
+		{Language: config.LanguageDotNet, TotalFunctions: 1, CoveredFunctions: 0, CoveragePercentage: 0},
+		{Language: config.LanguageRust, TotalFunctions: 1, CoveredFunctions: 1, CoveragePercentage: 100},
+	}, languages)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	outputText(&coverageReport{Languages: languages})
+	w.Close()
+	os.Stdout = oldStdout
+
+	output := make([]byte, 1024)
+	n, _ := r.Read(output)
+	outputStr := string(output[:n])
+	require.Contains(t, outputStr, "Coverage by Language")
+	require.Contains(t, outputStr, "rust:        1/1 (100.00%)")
+	require.Contains(t, outputStr, "go:          1/2 (50.00%)")
+}
+
 func TestListAllFunctions(t *testing.T) {
 	// Create a temporary profile file
 	builder := testhelper.NewProfileBuilder(1000).
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+const sourceMapsPath = "/api/v1/sourcemaps"
+
+type sourceMapParams struct {
+	bundleURL string
+	release   string
+	*phlareClient
+}
+
+type sourceMapUploadParams struct {
+	path string
+	sourceMapParams
+}
+
+func addSourceMapUploadParams(cmd commander) *sourceMapUploadParams {
+	params := new(sourceMapUploadParams)
+	cmd.Arg("path", "Path to the source map file to upload").Required().ExistingFileVar(&params.path)
+	cmd.Flag("bundle-url", "URL or path of the bundle as it appears in the profiles (e.g. https://example.com/static/bundle.js).").Required().StringVar(&params.bundleURL)
+	cmd.Flag("release", "Release the bundle belongs to: the value of the release label of the profiles. Source maps uploaded without a release apply to all releases.").StringVar(&params.release)
+	params.phlareClient = addPhlareClient(cmd)
+	return params
+}
+
+func addSourceMapDeleteParams(cmd commander) *sourceMapParams {
+	params := new(sourceMapParams)
+	cmd.Flag("bundle-url", "URL or path of the bundle the source map was uploaded for.").Required().StringVar(&params.bundleURL)
+	cmd.Flag("release", "Release the source map was uploaded for.").StringVar(&params.release)
+	params.phlareClient = addPhlareClient(cmd)
+	return params
+}
+
+func addSourceMapListParams(cmd commander) *sourceMapParams {
+	params := new(sourceMapParams)
+	cmd.Flag("release", "Only list the source maps of the release.").StringVar(&params.release)
+	params.phlareClient = addPhlareClient(cmd)
+	return params
+}
+
+func (p *sourceMapParams) do(ctx context.Context, method string, query url.Values, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.URL+sourceMapsPath+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed with status %s: %s", resp.Status, b)
+	}
+	return b, nil
+}
+
+func (p *sourceMapParams) query() url.Values {
+	q := url.Values{}
+	if p.bundleURL != "" {
+		q.Set("bundle_url", p.bundleURL)
+	}
+	if p.release != "" {
+		q.Set("release", p.release)
+	}
+	return q
+}
+
+func uploadSourceMap(ctx context.Context, params *sourceMapUploadParams) error {
+	f, err := os.Open(params.path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	if _, err = params.do(ctx, http.MethodPost, params.query(), f); err != nil {
+		return fmt.Errorf("failed to upload source map: %w", err)
+	}
+	level.Info(logger).Log("msg", "successfully uploaded source map", "bundle_url", params.bundleURL, "release", params.release, "path", params.path)
+	return nil
+}
+
+func listSourceMaps(ctx context.Context, params *sourceMapParams) error {
+	b, err := params.do(ctx, http.MethodGet, params.query(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to list source maps: %w", err)
+	}
+	var resp struct {
+		SourceMaps []struct {
+			BundleURL  string    `json:"bundle_url"`
+			Release    string    `json:"release"`
+			SizeBytes  int64     `json:"size_bytes"`
+			UploadedAt time.Time `json:"uploaded_at"`
+		} `json:"source_maps"`
+	}
+	if err = json.Unmarshal(b, &resp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, m := range resp.SourceMaps {
+		fmt.Printf("bundle_url=%s release=%s size=%s uploaded_at=%s\n",
+			m.BundleURL,
+			m.Release,
+			humanizeBytes(m.SizeBytes),
+			m.UploadedAt.Format(time.RFC3339),
+		)
+	}
+	return nil
+}
+
+func deleteSourceMap(ctx context.Context, params *sourceMapParams) error {
+	if _, err := params.do(ctx, http.MethodDelete, params.query(), nil); err != nil {
+		return fmt.Errorf("failed to delete source map: %w", err)
+	}
+	level.Info(logger).Log("msg", "successfully deleted source map", "bundle_url", params.bundleURL, "release", params.release)
+	return nil
+}
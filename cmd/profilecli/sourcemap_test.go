@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/memory"
+	"github.com/grafana/pyroscope/v2/pkg/sourcemap"
+	httputil "github.com/grafana/pyroscope/v2/pkg/util/http"
+)
+
+func startSourceMapTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	store, err := sourcemap.NewStore(log.NewNopLogger(), memory.NewInMemBucket(), sourcemap.Config{
+		Enabled:       true,
+		MaxUploadSize: 1 << 20,
+	})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	auth := httputil.AuthenticateUser(true)
+	router.Handle(sourceMapsPath, auth.Wrap(store.UploadHTTPHandler())).Methods("POST")
+	router.Handle(sourceMapsPath, auth.Wrap(store.ListHTTPHandler())).Methods("GET")
+	router.Handle(sourceMapsPath, auth.Wrap(store.DeleteHTTPHandler())).Methods("DELETE")
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSourceMap(t *testing.T) {
+	t.Parallel()
+	srv := startSourceMapTestServer(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "bundle.js.map")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":3,"sources":["a.ts"],"names":[],"mappings":"AAAA"}`), 0o644))
+
+	client := &phlareClient{URL: srv.URL, TenantID: "t1"}
+	require.NoError(t, uploadSourceMap(ctx, &sourceMapUploadParams{
+		path: path,
+		sourceMapParams: sourceMapParams{
+			bundleURL:    "https://example.com/bundle.js",
+			release:      "v1",
+			phlareClient: client,
+		},
+	}))
+	require.NoError(t, listSourceMaps(ctx, &sourceMapParams{phlareClient: client}))
+	require.NoError(t, deleteSourceMap(ctx, &sourceMapParams{
+		bundleURL:    "https://example.com/bundle.js",
+		release:      "v1",
+		phlareClient: client,
+	}))
+
+	// Invalid source maps are rejected.
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0o644))
+	require.Error(t, uploadSourceMap(ctx, &sourceMapUploadParams{
+		path: path,
+		sourceMapParams: sourceMapParams{
+			bundleURL:    "https://example.com/bundle.js",
+			phlareClient: client,
+		},
+	}))
+}
@@ -2,6 +2,8 @@ package lidia
 
 import (
 	"encoding/binary"
+	"fmt"
+	"io"
 	"sort"
 )
 
@@ -111,6 +113,67 @@ type rangeCollector struct {
 	opt options
 }
 
+func newRangeCollector(opts ...Option) *rangeCollector {
+	rc := &rangeCollector{
+		sb: newStringBuilder(),
+		rb: newRangesBuilder(),
+		lb: newLineTableBuilder(),
+		opt: options{
+			symtab:         true,
+			parseGoPclntab: true,
+		},
+	}
+	for _, o := range opts {
+		o(&rc.opt)
+	}
+	return rc
+}
+
+func (rc *rangeCollector) finish(output io.WriteSeeker) error {
+	rc.rb.sort()
+	if err := rc.write(output); err != nil {
+		return fmt.Errorf("failed to write lidia file: %w", err)
+	}
+	return nil
+}
+
+// symbol is a function symbol of an object file. Unlike ELF, the
+// Mach-O and COFF symbol tables do not record symbol sizes.
+type symbol struct {
+	va   uint64
+	size uint64
+	name string
+}
+
+// visitSymbols adds ranges for the symbols of a single section that
+// ends at the given address. Symbols without a size are assumed to
+// span up to the next symbol, or to the end of the section.
+func (rc *rangeCollector) visitSymbols(symbols []symbol, end uint64) {
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].va < symbols[j].va
+	})
+	for i, s := range symbols {
+		if s.size == 0 {
+			next := end
+			for j := i + 1; j < len(symbols); j++ {
+				if symbols[j].va > s.va {
+					next = symbols[j].va
+					break
+				}
+			}
+			if next <= s.va {
+				continue
+			}
+			s.size = next - s.va
+		}
+		rc.VisitRange(&Range{
+			VA:       s.va,
+			Length:   uint32(min(s.size, uint64(^uint32(0)))),
+			Function: s.name,
+		})
+	}
+}
+
 func (rc *rangeCollector) VisitRange(r *Range) {
 	lt := lineTableRef{}
 	funcOffset := rc.sb.add(r.Function)
@@ -34,6 +34,11 @@
 //	    // handle error
 //	}
 //
+// Besides ELF, symbols can be extracted from Mach-O executables and dSYM bundles
+// (CreateLidiaFromMachO), PE executables (CreateLidiaFromPE) and PDB files
+// (CreateLidiaFromPDB). CreateLidia detects the format of the file. For PE and
+// PDB files, the addresses are relative to the image base.
+//
 // # Reading and Querying Lidia Files
 //
 //	// Read a lidia file into memory
@@ -40,8 +40,6 @@ func ParseRuntimeTextFromPclntab18(pclntab []byte) uint64 {
 var errEmptyText = errors.New("empty text")
 
 func GoFunctions(f *elf.File) ([]Func, error) {
-	const headerSize = 64
-	var err error
 	text := f.Section(".text")
 	if text == nil {
 		return nil, errEmptyText
@@ -54,7 +52,14 @@ func GoFunctions(f *elf.File) ([]Func, error) {
 	if err != nil {
 		return nil, err
 	}
+	return GoFunctionsFromPclntab(pclntabData, text.Addr, text.Size)
+}
 
+// GoFunctionsFromPclntab parses the functions from the raw pclntab data.
+// textAddr and textSize describe the text section of the binary the
+// pclntab belongs to, which makes it usable with any object file format.
+func GoFunctionsFromPclntab(pclntabData []byte, textAddr, textSize uint64) ([]Func, error) {
+	const headerSize = 64
 	if len(pclntabData) < headerSize {
 		return nil, fmt.Errorf("invalid .gopclntab header")
 	}
@@ -65,10 +70,10 @@ func GoFunctions(f *elf.File) ([]Func, error) {
 	if textStart == 0 {
 		// for older versions text.Addr is enough
 		// https://github.com/golang/go/commit/b38ab0ac5f78ac03a38052018ff629c03e36b864
-		textStart = text.Addr
+		textStart = textAddr
 	}
-	if textStart < text.Addr || textStart >= text.Addr+text.Size {
-		return nil, fmt.Errorf("runtime.text out of .text bounds %d %d %d", textStart, text.Addr, text.Size)
+	if textStart < textAddr || textStart >= textAddr+textSize {
+		return nil, fmt.Errorf("runtime.text out of .text bounds %d %d %d", textStart, textAddr, textSize)
 	}
 	pcln := NewLineTable(pclntabData, textStart)
 
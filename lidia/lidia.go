@@ -1,8 +1,9 @@
 // Package lidia implements a custom binary format for efficient symbolization of Go profiles.
 //
 // Lidia provides a compact binary representation of symbol information extracted from
-// ELF files, optimized for fast lookup by memory address. This is particularly useful
-// for symbolizing profile data collected from Go applications.
+// ELF, Mach-O and PE executables and PDB files, optimized for fast lookup by memory
+// address. This is particularly useful for symbolizing profile data collected from Go
+// applications.
 package lidia
 
 import (
@@ -130,8 +131,9 @@ func OpenReader(f ReaderAtCloser, opt ...Option) (*Table, error) {
 	return res, nil
 }
 
-// CreateLidia generates a lidia format file from an ELF executable.
-// It extracts symbol information and writes it to the output file.
+// CreateLidia generates a lidia format file from an ELF, Mach-O or PE
+// executable, or a PDB file. It extracts symbol information and writes
+// it to the output file.
 func CreateLidia(executablePath, outputPath string, opts ...Option) error {
 	executable, err := os.Open(executablePath)
 	if err != nil {
@@ -145,28 +147,13 @@ func CreateLidia(executablePath, outputPath string, opts ...Option) error {
 	}
 	defer output.Close()
 
-	e, err := elf.NewFile(executable)
-	if err != nil {
-		return fmt.Errorf("failed to parse ELF file: %w", err)
-	}
-
-	return CreateLidiaFromELF(e, output, opts...)
+	return CreateLidiaFromObject(executable, output, opts...)
 }
 
 // CreateLidiaFromELF generates a lidia format file from an already opened ELF file.
 // This allows more control over the ELF file handling.
 func CreateLidiaFromELF(elfFile *elf.File, output io.WriteSeeker, opts ...Option) error {
-	sb := newStringBuilder()
-	rb := newRangesBuilder()
-	lb := newLineTableBuilder()
-	rc := &rangeCollector{sb: sb, rb: rb, lb: lb, opt: options{
-		symtab:         true,
-		parseGoPclntab: true,
-	}}
-
-	for _, o := range opts {
-		o(&rc.opt)
-	}
+	rc := newRangeCollector(opts...)
 
 	if rc.opt.symtab {
 		var (
@@ -203,24 +190,23 @@ func CreateLidiaFromELF(elfFile *elf.File, output io.WriteSeeker, opts ...Option
 		if err != nil {
 			return err
 		}
-		for i := range functions {
-			f := &functions[i]
-			rc.VisitRange(&Range{
-				VA:       f.Entry,
-				Length:   uint32(f.End - f.Entry),
-				Function: f.Name,
-			})
-		}
+		rc.visitGoFunctions(functions, 0)
 	}
 
-	rb.sort()
+	return rc.finish(output)
+}
 
-	err := rc.write(output)
-	if err != nil {
-		return fmt.Errorf("failed to write lidia file: %w", err)
+// visitGoFunctions adds ranges for the functions found in the Go pclntab.
+// The base is subtracted from the function addresses.
+func (rc *rangeCollector) visitGoFunctions(functions []gosym.Func, base uint64) {
+	for i := range functions {
+		f := &functions[i]
+		rc.VisitRange(&Range{
+			VA:       f.Entry - base,
+			Length:   uint32(f.End - f.Entry),
+			Function: f.Name,
+		})
 	}
-
-	return nil
 }
 
 // Lookup performs a symbol lookup by memory address.
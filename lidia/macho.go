@@ -0,0 +1,108 @@
+package lidia
+
+import (
+	"debug/macho"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/grafana/pyroscope/lidia/gosym"
+)
+
+const (
+	machoStab = 0xe0 // N_STAB
+	machoType = 0x0e // N_TYPE
+	machoSect = 0x0e // N_SECT
+
+	machoLoadCmdUUID = 0x1b // LC_UUID
+
+	// S_ATTR_PURE_INSTRUCTIONS | S_ATTR_SOME_INSTRUCTIONS
+	machoSectionInstructions = 0x80000000 | 0x400
+)
+
+// CreateLidiaFromMachO generates a lidia format file from an already opened
+// Mach-O file. Both executables and the DWARF companion files of dSYM bundles
+// (Contents/Resources/DWARF/<name>) are supported.
+func CreateLidiaFromMachO(f *macho.File, output io.WriteSeeker, opts ...Option) error {
+	rc := newRangeCollector(opts...)
+
+	if rc.opt.symtab && f.Symtab != nil {
+		bySection := make(map[uint8][]symbol)
+		for _, s := range f.Symtab.Syms {
+			if s.Type&machoStab != 0 || s.Type&machoType != machoSect {
+				continue
+			}
+			if s.Sect == 0 || int(s.Sect) > len(f.Sections) || s.Name == "" {
+				continue
+			}
+			if f.Sections[s.Sect-1].Flags&machoSectionInstructions == 0 {
+				continue
+			}
+			// C symbol names are prefixed with an underscore in Mach-O files.
+			bySection[s.Sect] = append(bySection[s.Sect], symbol{
+				va:   s.Value,
+				name: strings.TrimPrefix(s.Name, "_"),
+			})
+		}
+		for sect, symbols := range bySection {
+			s := f.Sections[sect-1]
+			rc.visitSymbols(symbols, s.Addr+s.Size)
+		}
+	}
+
+	if rc.opt.parseGoPclntab {
+		functions, err := machoGoFunctions(f)
+		if err != nil {
+			return err
+		}
+		rc.visitGoFunctions(functions, 0)
+	}
+
+	return rc.finish(output)
+}
+
+func machoGoFunctions(f *macho.File) ([]gosym.Func, error) {
+	pclntab := f.Section("__gopclntab")
+	// The sections of dSYM companion files have no data.
+	if pclntab == nil || pclntab.Offset == 0 {
+		return nil, nil
+	}
+	text := f.Section("__text")
+	if text == nil {
+		return nil, fmt.Errorf("no __text section")
+	}
+	data, err := pclntab.Data()
+	if err != nil {
+		return nil, err
+	}
+	return gosym.GoFunctionsFromPclntab(data, text.Addr, text.Size)
+}
+
+// MachOUUID returns the UUID of the Mach-O file (LC_UUID), formatted as
+// a lowercase hyphenated string. An empty string is returned if the file
+// has no UUID.
+func MachOUUID(f *macho.File) string {
+	for _, l := range f.Loads {
+		raw := l.Raw()
+		if len(raw) < 24 || f.ByteOrder.Uint32(raw) != machoLoadCmdUUID {
+			continue
+		}
+		return formatUUID(raw[8:24])
+	}
+	return ""
+}
+
+func formatUUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// OpenMachOFatArch returns the file of the universal Mach-O binary
+// architecture that has the given UUID.
+func OpenMachOFatArch(f *macho.FatFile, uuid string) (*macho.File, error) {
+	for _, arch := range f.Arches {
+		if strings.EqualFold(MachOUUID(arch.File), uuid) {
+			return arch.File, nil
+		}
+	}
+	return nil, fmt.Errorf("no architecture with UUID %s found", uuid)
+}
@@ -0,0 +1,109 @@
+package lidia
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ObjectFormat is the format of a file lidia tables can be created from.
+type ObjectFormat int
+
+const (
+	ObjectFormatUnknown ObjectFormat = iota
+	ObjectFormatELF
+	ObjectFormatMachO
+	// ObjectFormatMachOFat is a universal binary that
+	// includes Mach-O files for multiple architectures.
+	ObjectFormatMachOFat
+	ObjectFormatPE
+	ObjectFormatPDB
+)
+
+func (f ObjectFormat) String() string {
+	switch f {
+	case ObjectFormatELF:
+		return "ELF"
+	case ObjectFormatMachO:
+		return "Mach-O"
+	case ObjectFormatMachOFat:
+		return "Mach-O universal"
+	case ObjectFormatPE:
+		return "PE"
+	case ObjectFormatPDB:
+		return "PDB"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectObjectFormat detects the format of the file by its magic number.
+func DetectObjectFormat(r io.ReaderAt) (ObjectFormat, error) {
+	buf := make([]byte, len(pdbMagic))
+	n, err := r.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return ObjectFormatUnknown, err
+	}
+	buf = buf[:n]
+	if len(buf) < 4 {
+		return ObjectFormatUnknown, nil
+	}
+	switch {
+	case bytes.HasPrefix(buf, []byte(elf.ELFMAG)):
+		return ObjectFormatELF, nil
+	case bytes.HasPrefix(buf, pdbMagic):
+		return ObjectFormatPDB, nil
+	case bytes.HasPrefix(buf, []byte("MZ")):
+		return ObjectFormatPE, nil
+	}
+	switch binary.BigEndian.Uint32(buf) {
+	case macho.MagicFat:
+		return ObjectFormatMachOFat, nil
+	case macho.Magic32, macho.Magic64:
+		return ObjectFormatMachO, nil
+	}
+	switch binary.LittleEndian.Uint32(buf) {
+	case macho.Magic32, macho.Magic64:
+		return ObjectFormatMachO, nil
+	}
+	return ObjectFormatUnknown, nil
+}
+
+// CreateLidiaFromObject generates a lidia format file from an ELF, Mach-O
+// or PE executable, or a PDB file. Universal Mach-O binaries are not
+// supported: the architecture must be selected by the caller.
+func CreateLidiaFromObject(r io.ReaderAt, output io.WriteSeeker, opts ...Option) error {
+	format, err := DetectObjectFormat(r)
+	if err != nil {
+		return fmt.Errorf("failed to detect object format: %w", err)
+	}
+	switch format {
+	case ObjectFormatELF:
+		f, err := elf.NewFile(r)
+		if err != nil {
+			return fmt.Errorf("failed to parse ELF file: %w", err)
+		}
+		return CreateLidiaFromELF(f, output, opts...)
+	case ObjectFormatMachO:
+		f, err := macho.NewFile(r)
+		if err != nil {
+			return fmt.Errorf("failed to parse Mach-O file: %w", err)
+		}
+		return CreateLidiaFromMachO(f, output, opts...)
+	case ObjectFormatPE:
+		f, err := pe.NewFile(r)
+		if err != nil {
+			return fmt.Errorf("failed to parse PE file: %w", err)
+		}
+		return CreateLidiaFromPE(f, output, opts...)
+	case ObjectFormatPDB:
+		return CreateLidiaFromPDB(r, output, opts...)
+	default:
+		return fmt.Errorf("unsupported object format: %s", format)
+	}
+}
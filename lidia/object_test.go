@@ -0,0 +1,275 @@
+package lidia_test
+
+import (
+	"bytes"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/pyroscope/lidia"
+)
+
+// buildTestBinary cross-compiles a trivial Go program for the given platform.
+func buildTestBinary(t *testing.T, goos, goarch string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping cross-compilation in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain is not available")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(src, []byte("package main\n\nfunc main() { println(\"hello\") }\n"), 0o644))
+	out := filepath.Join(dir, "main")
+	cmd := exec.Command(goBin, "build", "-o", out, src)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0", "GOFLAGS=")
+	b, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(b))
+	return out
+}
+
+func lookupLidia(t *testing.T, binaryPath string, addr uint64) []lidia.SourceInfoFrame {
+	t.Helper()
+	lidiaPath := filepath.Join(t.TempDir(), "test.lidia")
+	require.NoError(t, lidia.CreateLidia(binaryPath, lidiaPath, lidia.WithCRC()))
+	f, err := os.Open(lidiaPath)
+	require.NoError(t, err)
+	table, err := lidia.OpenReader(f, lidia.WithCRC())
+	require.NoError(t, err)
+	defer table.Close()
+	frames, err := table.Lookup(nil, addr)
+	require.NoError(t, err)
+	return frames
+}
+
+func TestCreateLidiaFromMachO(t *testing.T) {
+	binaryPath := buildTestBinary(t, "darwin", "arm64")
+
+	f, err := macho.Open(binaryPath)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`, lidia.MachOUUID(f))
+
+	var addr uint64
+	for _, s := range f.Symtab.Syms {
+		if s.Name == "main.main" {
+			addr = s.Value + 4
+		}
+	}
+	require.NotZero(t, addr)
+
+	frames := lookupLidia(t, binaryPath, addr)
+	require.NotEmpty(t, frames)
+	assert.Equal(t, "main.main", frames[0].FunctionName)
+}
+
+func TestCreateLidiaFromPE(t *testing.T) {
+	binaryPath := buildTestBinary(t, "windows", "amd64")
+
+	f, err := pe.Open(binaryPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var rva uint64
+	for _, s := range f.Symbols {
+		if s.Name == "main.main" {
+			rva = uint64(f.Sections[s.SectionNumber-1].VirtualAddress) + uint64(s.Value) + 4
+		}
+	}
+	require.NotZero(t, rva)
+
+	frames := lookupLidia(t, binaryPath, rva)
+	require.NotEmpty(t, frames)
+	assert.Equal(t, "main.main", frames[0].FunctionName)
+}
+
+// pdbBuilder creates minimal PDB files: an MSF container with
+// the PDB info, DBI, section headers and symbol streams.
+type pdbBuilder struct {
+	guid    [16]byte
+	age     uint32
+	modules [][]byte
+	publics []byte
+}
+
+func symbolRecord(kind uint16, data []byte) []byte {
+	for (len(data)+2)%4 != 0 {
+		data = append(data, 0)
+	}
+	b := binary.LittleEndian.AppendUint16(nil, uint16(len(data)+2))
+	b = binary.LittleEndian.AppendUint16(b, kind)
+	return append(b, data...)
+}
+
+func (b *pdbBuilder) addProc(segment uint16, offset, size uint32, name string) {
+	rec := make([]byte, 35)
+	binary.LittleEndian.PutUint32(rec[12:], size)
+	binary.LittleEndian.PutUint32(rec[28:], offset)
+	binary.LittleEndian.PutUint16(rec[32:], segment)
+	rec = append(rec, name...)
+	rec = append(rec, 0)
+	mod := binary.LittleEndian.AppendUint32(nil, 4) // CV_SIGNATURE_C13
+	b.modules = append(b.modules, append(mod, symbolRecord(0x1110, rec)...))
+}
+
+func (b *pdbBuilder) addPublic(segment uint16, offset uint32, name string) {
+	rec := make([]byte, 10)
+	binary.LittleEndian.PutUint32(rec, 2) // cvpsfFunction
+	binary.LittleEndian.PutUint32(rec[4:], offset)
+	binary.LittleEndian.PutUint16(rec[8:], segment)
+	rec = append(rec, name...)
+	rec = append(rec, 0)
+	b.publics = append(b.publics, symbolRecord(0x110e, rec)...)
+}
+
+func (b *pdbBuilder) build() []byte {
+	const blockSize = 512
+	info := make([]byte, 28)
+	copy(info[12:], b.guid[:])
+
+	sections := make([]byte, 2*40)
+	// .text at RVA 0x1000, .data at RVA 0x3000.
+	binary.LittleEndian.PutUint32(sections[8:], 0x2000)
+	binary.LittleEndian.PutUint32(sections[12:], 0x1000)
+	binary.LittleEndian.PutUint32(sections[40+8:], 0x100)
+	binary.LittleEndian.PutUint32(sections[40+12:], 0x3000)
+
+	// Streams: 0 (old directory), 1 (info), 2 (TPI), 3 (DBI),
+	// 4 (section headers), 5 (public symbols), 6+ (modules).
+	streams := [][]byte{nil, info, nil, nil, sections, b.publics}
+	var modInfo []byte
+	for i, m := range b.modules {
+		streams = append(streams, m)
+		mi := make([]byte, 64)
+		binary.LittleEndian.PutUint16(mi[34:], uint16(6+i))
+		binary.LittleEndian.PutUint32(mi[36:], uint32(len(m)))
+		mi = append(mi, "mod.obj\x00mod.obj\x00"...)
+		for len(mi)%4 != 0 {
+			mi = append(mi, 0)
+		}
+		modInfo = append(modInfo, mi...)
+	}
+	dbgHeader := bytes.Repeat([]byte{0xff, 0xff}, 11)
+	binary.LittleEndian.PutUint16(dbgHeader[10:], 4)
+
+	dbi := make([]byte, 64)
+	binary.LittleEndian.PutUint32(dbi[8:], b.age)
+	binary.LittleEndian.PutUint16(dbi[20:], 5)
+	binary.LittleEndian.PutUint32(dbi[24:], uint32(len(modInfo)))
+	binary.LittleEndian.PutUint32(dbi[48:], uint32(len(dbgHeader)))
+	dbi = append(dbi, modInfo...)
+	dbi = append(dbi, dbgHeader...)
+	streams[3] = dbi
+
+	// Block 0 is the superblock, block 1 is the block map,
+	// the directory and the streams follow.
+	blocks := [][]byte{nil, nil}
+	alloc := func(data []byte) []uint32 {
+		var ids []uint32
+		for off := 0; off < len(data); off += blockSize {
+			ids = append(ids, uint32(len(blocks)))
+			blk := make([]byte, blockSize)
+			copy(blk, data[off:])
+			blocks = append(blocks, blk)
+		}
+		return ids
+	}
+	var dir []byte
+	dir = binary.LittleEndian.AppendUint32(dir, uint32(len(streams)))
+	for _, s := range streams {
+		dir = binary.LittleEndian.AppendUint32(dir, uint32(len(s)))
+	}
+	for _, s := range streams {
+		for _, id := range alloc(s) {
+			dir = binary.LittleEndian.AppendUint32(dir, id)
+		}
+	}
+	blockMap := make([]byte, blockSize)
+	for i, id := range alloc(dir) {
+		binary.LittleEndian.PutUint32(blockMap[4*i:], id)
+	}
+	blocks[1] = blockMap
+
+	sb := make([]byte, blockSize)
+	copy(sb, "Microsoft C/C++ MSF 7.00\r\n\x1aDS\x00\x00\x00")
+	binary.LittleEndian.PutUint32(sb[32:], blockSize)
+	binary.LittleEndian.PutUint32(sb[40:], uint32(len(blocks)))
+	binary.LittleEndian.PutUint32(sb[44:], uint32(len(dir)))
+	binary.LittleEndian.PutUint32(sb[52:], 1)
+	blocks[0] = sb
+
+	return bytes.Join(blocks, nil)
+}
+
+func TestCreateLidiaFromPDB(t *testing.T) {
+	b := &pdbBuilder{
+		guid: [16]byte{0x78, 0x56, 0x34, 0x12, 0x34, 0x12, 0x78, 0x56, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		age:  3,
+	}
+	b.addProc(1, 0x10, 0x20, "foo")
+	b.addProc(1, 0x100, 0x40, "Bar::baz")
+	b.addPublic(1, 0x10, "?foo@@YAXXZ")
+	b.addPublic(1, 0x200, "qux")
+	pdb := b.build()
+
+	format, err := lidia.DetectObjectFormat(bytes.NewReader(pdb))
+	require.NoError(t, err)
+	assert.Equal(t, lidia.ObjectFormatPDB, format)
+
+	id, err := lidia.PDBDebugID(bytes.NewReader(pdb))
+	require.NoError(t, err)
+	assert.Equal(t, "12345678-1234-5678-0102-030405060708-3", id)
+
+	pdbPath := filepath.Join(t.TempDir(), "test.pdb")
+	require.NoError(t, os.WriteFile(pdbPath, pdb, 0o644))
+
+	for _, tc := range []struct {
+		rva  uint64
+		name string
+	}{
+		{rva: 0x1010, name: "foo"},
+		{rva: 0x102f, name: "foo"},
+		{rva: 0x1030},
+		{rva: 0x1120, name: "Bar::baz"},
+		// Public symbols span up to the end of the section.
+		{rva: 0x1200, name: "qux"},
+		{rva: 0x2fff, name: "qux"},
+		{rva: 0x3000},
+	} {
+		frames := lookupLidia(t, pdbPath, tc.rva)
+		if tc.name == "" {
+			assert.Empty(t, frames, "0x%x", tc.rva)
+			continue
+		}
+		require.Len(t, frames, 1, "0x%x", tc.rva)
+		assert.Equal(t, tc.name, frames[0].FunctionName, "0x%x", tc.rva)
+	}
+}
+
+func TestDetectObjectFormat(t *testing.T) {
+	for _, tc := range []struct {
+		data   []byte
+		format lidia.ObjectFormat
+	}{
+		{data: []byte("\x7fELF\x02\x01\x01"), format: lidia.ObjectFormatELF},
+		{data: []byte{0xcf, 0xfa, 0xed, 0xfe, 0x0c}, format: lidia.ObjectFormatMachO},
+		{data: []byte{0xca, 0xfe, 0xba, 0xbe, 0x00}, format: lidia.ObjectFormatMachOFat},
+		{data: []byte("MZ\x90\x00\x03"), format: lidia.ObjectFormatPE},
+		{data: []byte("#!/bin/sh"), format: lidia.ObjectFormatUnknown},
+		{data: []byte("ab"), format: lidia.ObjectFormatUnknown},
+	} {
+		format, err := lidia.DetectObjectFormat(bytes.NewReader(tc.data))
+		require.NoError(t, err)
+		assert.Equal(t, tc.format, format, tc.format.String())
+	}
+}
@@ -0,0 +1,387 @@
+package lidia
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// PDB files are MSF (multi-stream format) containers: a set of streams
+// stored in fixed-size blocks. Only the streams needed to build the
+// symbol table are read: PDB info, DBI, module symbols, public symbols
+// and section headers.
+//
+// https://llvm.org/docs/PDB/index.html
+
+var pdbMagic = []byte("Microsoft C/C++ MSF 7.00\r\n\x1aDS\x00\x00\x00")
+
+const (
+	pdbStreamInfo = 1
+	pdbStreamDBI  = 3
+
+	pdbSuperBlockSize   = 56
+	pdbDBIHeaderSize    = 64
+	pdbModInfoSize      = 64
+	pdbSectionHdrSize   = 40
+	pdbDbgSectionHdrIdx = 5
+	pdbNilStream        = 0xffff
+	pdbNilStreamSize    = 0xffffffff
+
+	pdbSymPub32      = 0x110e // S_PUB32
+	pdbSymLProc32    = 0x110f // S_LPROC32
+	pdbSymGProc32    = 0x1110 // S_GPROC32
+	pdbSymLProc32ID  = 0x1146 // S_LPROC32_ID
+	pdbSymGProc32ID  = 0x1147 // S_GPROC32_ID
+	pdbPubSymCode    = 0x1    // cvpsfCode
+	pdbPubSymFunc    = 0x2    // cvpsfFunction
+	pdbMaxStreamSize = 1 << 30
+)
+
+var errPDBTruncated = errors.New("truncated PDB stream")
+
+type msf struct {
+	r         io.ReaderAt
+	blockSize uint32
+	numBlocks uint32
+	sizes     []uint32
+	blocks    [][]uint32
+}
+
+func openMSF(r io.ReaderAt) (*msf, error) {
+	sb := make([]byte, pdbSuperBlockSize)
+	if _, err := r.ReadAt(sb, 0); err != nil {
+		return nil, fmt.Errorf("failed to read MSF superblock: %w", err)
+	}
+	if !bytes.HasPrefix(sb, pdbMagic) {
+		return nil, fmt.Errorf("invalid MSF magic")
+	}
+	m := &msf{
+		r:         r,
+		blockSize: binary.LittleEndian.Uint32(sb[32:]),
+		numBlocks: binary.LittleEndian.Uint32(sb[40:]),
+	}
+	switch m.blockSize {
+	case 512, 1024, 2048, 4096:
+	default:
+		return nil, fmt.Errorf("invalid MSF block size: %d", m.blockSize)
+	}
+	dirSize := binary.LittleEndian.Uint32(sb[44:])
+	if dirSize == 0 || dirSize > pdbMaxStreamSize {
+		return nil, fmt.Errorf("invalid MSF directory size: %d", dirSize)
+	}
+	blockMap, err := m.readBlocks([]uint32{binary.LittleEndian.Uint32(sb[52:])}, 4*m.numBlocksFor(dirSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MSF block map: %w", err)
+	}
+	dirBlocks := make([]uint32, m.numBlocksFor(dirSize))
+	for i := range dirBlocks {
+		dirBlocks[i] = binary.LittleEndian.Uint32(blockMap[4*i:])
+	}
+	dir, err := m.readBlocks(dirBlocks, dirSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MSF directory: %w", err)
+	}
+	if err = m.parseDirectory(dir); err != nil {
+		return nil, fmt.Errorf("failed to parse MSF directory: %w", err)
+	}
+	return m, nil
+}
+
+func (m *msf) numBlocksFor(size uint32) uint32 {
+	return (size + m.blockSize - 1) / m.blockSize
+}
+
+func (m *msf) parseDirectory(dir []byte) error {
+	if len(dir) < 4 {
+		return errPDBTruncated
+	}
+	n := binary.LittleEndian.Uint32(dir)
+	dir = dir[4:]
+	if uint64(n)*4 > uint64(len(dir)) {
+		return errPDBTruncated
+	}
+	m.sizes = make([]uint32, n)
+	for i := range m.sizes {
+		if m.sizes[i] = binary.LittleEndian.Uint32(dir[4*i:]); m.sizes[i] == pdbNilStreamSize {
+			m.sizes[i] = 0
+		}
+	}
+	dir = dir[4*n:]
+	m.blocks = make([][]uint32, n)
+	for i, size := range m.sizes {
+		k := m.numBlocksFor(size)
+		if uint64(k)*4 > uint64(len(dir)) {
+			return errPDBTruncated
+		}
+		m.blocks[i] = make([]uint32, k)
+		for j := range m.blocks[i] {
+			m.blocks[i][j] = binary.LittleEndian.Uint32(dir[4*j:])
+		}
+		dir = dir[4*k:]
+	}
+	return nil
+}
+
+func (m *msf) readBlocks(blocks []uint32, size uint32) ([]byte, error) {
+	if size > pdbMaxStreamSize {
+		return nil, fmt.Errorf("stream is too large: %d bytes", size)
+	}
+	buf := make([]byte, size)
+	for i, b := range blocks {
+		if b >= m.numBlocks {
+			return nil, fmt.Errorf("block %d is out of bounds", b)
+		}
+		lo := uint32(i) * m.blockSize
+		if lo >= size {
+			break
+		}
+		hi := min(lo+m.blockSize, size)
+		if _, err := m.r.ReadAt(buf[lo:hi], int64(b)*int64(m.blockSize)); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (m *msf) stream(i int) ([]byte, error) {
+	if i < 0 || i >= len(m.sizes) {
+		return nil, fmt.Errorf("stream %d does not exist", i)
+	}
+	return m.readBlocks(m.blocks[i], m.sizes[i])
+}
+
+type pdbFile struct {
+	msf *msf
+	dbi []byte
+	// Relative virtual addresses of the image sections.
+	sections []pdbSection
+}
+
+type pdbSection struct {
+	va   uint32
+	size uint32
+}
+
+func openPDB(r io.ReaderAt) (*pdbFile, error) {
+	m, err := openMSF(r)
+	if err != nil {
+		return nil, err
+	}
+	dbi, err := m.stream(pdbStreamDBI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DBI stream: %w", err)
+	}
+	if len(dbi) < pdbDBIHeaderSize {
+		return nil, fmt.Errorf("invalid DBI stream: %w", errPDBTruncated)
+	}
+	return &pdbFile{msf: m, dbi: dbi}, nil
+}
+
+// substream returns the DBI substream at the given index, in the order they
+// are stored: module info, section contributions, section map, file info,
+// type server map, EC and optional debug header.
+func (p *pdbFile) substream(idx int) ([]byte, error) {
+	sizeOffsets := []int{24, 28, 32, 36, 40, 52, 48}
+	off := pdbDBIHeaderSize
+	for i := 0; i <= idx; i++ {
+		size := int(int32(binary.LittleEndian.Uint32(p.dbi[sizeOffsets[i]:])))
+		if size < 0 || off+size > len(p.dbi) {
+			return nil, fmt.Errorf("invalid DBI substream: %w", errPDBTruncated)
+		}
+		if i == idx {
+			return p.dbi[off : off+size], nil
+		}
+		off += size
+	}
+	return nil, nil
+}
+
+func (p *pdbFile) readSections() error {
+	dbgHeader, err := p.substream(6)
+	if err != nil {
+		return err
+	}
+	if len(dbgHeader) < 2*(pdbDbgSectionHdrIdx+1) {
+		return fmt.Errorf("no section headers stream")
+	}
+	idx := binary.LittleEndian.Uint16(dbgHeader[2*pdbDbgSectionHdrIdx:])
+	if idx == pdbNilStream {
+		return fmt.Errorf("no section headers stream")
+	}
+	data, err := p.msf.stream(int(idx))
+	if err != nil {
+		return fmt.Errorf("failed to read section headers: %w", err)
+	}
+	for ; len(data) >= pdbSectionHdrSize; data = data[pdbSectionHdrSize:] {
+		p.sections = append(p.sections, pdbSection{
+			size: binary.LittleEndian.Uint32(data[8:]),
+			va:   binary.LittleEndian.Uint32(data[12:]),
+		})
+	}
+	return nil
+}
+
+// visitSymbolRecords calls fn for each CodeView symbol record.
+func visitSymbolRecords(data []byte, fn func(kind uint16, rec []byte)) {
+	for len(data) >= 4 {
+		recLen := int(binary.LittleEndian.Uint16(data))
+		if recLen < 2 || 2+recLen > len(data) {
+			return
+		}
+		fn(binary.LittleEndian.Uint16(data[2:]), data[4:2+recLen])
+		data = data[2+recLen:]
+	}
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return string(b[:i])
+	}
+	return string(b)
+}
+
+// procedures returns the functions defined in the module symbol streams.
+func (p *pdbFile) procedures(fn func(segment uint16, s symbol)) error {
+	mods, err := p.substream(0)
+	if err != nil {
+		return err
+	}
+	for len(mods) >= pdbModInfoSize {
+		stream := binary.LittleEndian.Uint16(mods[34:])
+		symSize := binary.LittleEndian.Uint32(mods[36:])
+		// The fixed part is followed by the module and object file names.
+		rest := mods[pdbModInfoSize:]
+		n := 0
+		for k := 0; k < 2; k++ {
+			i := bytes.IndexByte(rest[n:], 0)
+			if i < 0 {
+				return fmt.Errorf("invalid module info: %w", errPDBTruncated)
+			}
+			n += i + 1
+		}
+		next := (pdbModInfoSize + n + 3) &^ 3
+		if next > len(mods) {
+			next = len(mods)
+		}
+		mods = mods[next:]
+
+		if stream == pdbNilStream || symSize <= 4 {
+			continue
+		}
+		data, err := p.msf.stream(int(stream))
+		if err != nil {
+			return fmt.Errorf("failed to read module symbols: %w", err)
+		}
+		if int(symSize) > len(data) {
+			return fmt.Errorf("invalid module symbols: %w", errPDBTruncated)
+		}
+		// Module symbols are preceded by the CodeView signature.
+		visitSymbolRecords(data[4:symSize], func(kind uint16, rec []byte) {
+			switch kind {
+			case pdbSymGProc32, pdbSymLProc32, pdbSymGProc32ID, pdbSymLProc32ID:
+			default:
+				return
+			}
+			if len(rec) < 35 {
+				return
+			}
+			fn(binary.LittleEndian.Uint16(rec[32:]), symbol{
+				size: uint64(binary.LittleEndian.Uint32(rec[12:])),
+				va:   uint64(binary.LittleEndian.Uint32(rec[28:])),
+				name: cString(rec[35:]),
+			})
+		})
+	}
+	return nil
+}
+
+// publics returns the function public symbols. Unlike procedures,
+// public symbols have no size, and their names are decorated.
+func (p *pdbFile) publics(fn func(segment uint16, s symbol)) error {
+	idx := binary.LittleEndian.Uint16(p.dbi[20:])
+	if idx == pdbNilStream {
+		return nil
+	}
+	data, err := p.msf.stream(int(idx))
+	if err != nil {
+		return fmt.Errorf("failed to read symbol records: %w", err)
+	}
+	visitSymbolRecords(data, func(kind uint16, rec []byte) {
+		if kind != pdbSymPub32 || len(rec) < 10 {
+			return
+		}
+		if binary.LittleEndian.Uint32(rec)&(pdbPubSymCode|pdbPubSymFunc) == 0 {
+			return
+		}
+		fn(binary.LittleEndian.Uint16(rec[8:]), symbol{
+			va:   uint64(binary.LittleEndian.Uint32(rec[4:])),
+			name: cString(rec[10:]),
+		})
+	})
+	return nil
+}
+
+// CreateLidiaFromPDB generates a lidia format file from a PDB file. Function
+// ranges are taken from the module symbols, and public symbols are used for
+// functions that have no module symbols. Like for PE files, the addresses
+// are relative virtual addresses.
+func CreateLidiaFromPDB(r io.ReaderAt, output io.WriteSeeker, opts ...Option) error {
+	rc := newRangeCollector(opts...)
+
+	if rc.opt.symtab {
+		p, err := openPDB(r)
+		if err != nil {
+			return fmt.Errorf("failed to parse PDB file: %w", err)
+		}
+		if err = p.readSections(); err != nil {
+			return fmt.Errorf("failed to parse PDB file: %w", err)
+		}
+		bySection := make(map[uint16][]symbol)
+		seen := make(map[uint64]struct{})
+		add := func(segment uint16, s symbol) {
+			if segment == 0 || int(segment) > len(p.sections) || s.name == "" {
+				return
+			}
+			s.va += uint64(p.sections[segment-1].va)
+			if _, ok := seen[s.va]; ok {
+				return
+			}
+			seen[s.va] = struct{}{}
+			bySection[segment] = append(bySection[segment], s)
+		}
+		if err = p.procedures(add); err != nil {
+			return fmt.Errorf("failed to read PDB module symbols: %w", err)
+		}
+		if err = p.publics(add); err != nil {
+			return fmt.Errorf("failed to read PDB public symbols: %w", err)
+		}
+		for segment, symbols := range bySection {
+			s := p.sections[segment-1]
+			rc.visitSymbols(symbols, uint64(s.va)+uint64(s.size))
+		}
+	}
+
+	return rc.finish(output)
+}
+
+// PDBDebugID returns the debug ID of the PDB file: the GUID and age that
+// the PE files linked with it refer to (see PEDebugID).
+func PDBDebugID(r io.ReaderAt) (string, error) {
+	p, err := openPDB(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse PDB file: %w", err)
+	}
+	info, err := p.msf.stream(pdbStreamInfo)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDB info stream: %w", err)
+	}
+	if len(info) < 28 {
+		return "", fmt.Errorf("invalid PDB info stream: %w", errPDBTruncated)
+	}
+	// The age in the PDB info stream may be incremented on updates,
+	// while the DBI stream age matches the one recorded in PE files.
+	age := binary.LittleEndian.Uint32(p.dbi[8:])
+	return formatPEDebugID(info[12:28], age), nil
+}
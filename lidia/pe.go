@@ -0,0 +1,180 @@
+package lidia
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/grafana/pyroscope/lidia/gosym"
+)
+
+const (
+	peSectionCode = 0x20 // IMAGE_SCN_CNT_CODE
+
+	peSymClassExternal = 2 // IMAGE_SYM_CLASS_EXTERNAL
+	peSymClassStatic   = 3 // IMAGE_SYM_CLASS_STATIC
+
+	peDebugDirectorySize = 28
+	peDebugTypeCodeView  = 2 // IMAGE_DEBUG_TYPE_CODEVIEW
+)
+
+// CreateLidiaFromPE generates a lidia format file from an already opened PE
+// file, using its COFF symbol table. Executables linked by MSVC usually have
+// no symbol table: their symbols are to be taken from the PDB file instead.
+//
+// Unlike for ELF files, the addresses are relative virtual addresses (RVA):
+// offsets from the image base, which makes them consistent with PDB files.
+func CreateLidiaFromPE(f *pe.File, output io.WriteSeeker, opts ...Option) error {
+	rc := newRangeCollector(opts...)
+
+	if rc.opt.symtab {
+		bySection := make(map[int16][]symbol)
+		for _, s := range f.Symbols {
+			if s.StorageClass != peSymClassExternal && s.StorageClass != peSymClassStatic {
+				continue
+			}
+			if s.SectionNumber <= 0 || int(s.SectionNumber) > len(f.Sections) || s.Name == "" {
+				continue
+			}
+			sect := f.Sections[s.SectionNumber-1]
+			if sect.Characteristics&peSectionCode == 0 || s.Name == sect.Name {
+				continue
+			}
+			bySection[s.SectionNumber] = append(bySection[s.SectionNumber], symbol{
+				va:   uint64(sect.VirtualAddress) + uint64(s.Value),
+				name: s.Name,
+			})
+		}
+		for n, symbols := range bySection {
+			s := f.Sections[n-1]
+			rc.visitSymbols(symbols, uint64(s.VirtualAddress)+uint64(s.VirtualSize))
+		}
+	}
+
+	if rc.opt.parseGoPclntab {
+		functions, err := peGoFunctions(f)
+		if err != nil {
+			return err
+		}
+		rc.visitGoFunctions(functions, peImageBase(f))
+	}
+
+	return rc.finish(output)
+}
+
+func peImageBase(f *pe.File) uint64 {
+	switch h := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		return uint64(h.ImageBase)
+	case *pe.OptionalHeader64:
+		return h.ImageBase
+	}
+	return 0
+}
+
+func peGoFunctions(f *pe.File) ([]gosym.Func, error) {
+	// Go does not place pclntab in a dedicated section of PE files:
+	// it is located with the runtime.pclntab and runtime.epclntab symbols.
+	var start, end *pe.Symbol
+	for _, s := range f.Symbols {
+		switch s.Name {
+		case "runtime.pclntab":
+			start = s
+		case "runtime.epclntab":
+			end = s
+		}
+	}
+	if start == nil || end == nil || start.SectionNumber != end.SectionNumber || end.Value <= start.Value {
+		return nil, nil
+	}
+	if start.SectionNumber <= 0 || int(start.SectionNumber) > len(f.Sections) {
+		return nil, fmt.Errorf("invalid runtime.pclntab section %d", start.SectionNumber)
+	}
+	data, err := f.Sections[start.SectionNumber-1].Data()
+	if err != nil {
+		return nil, err
+	}
+	if int(end.Value) > len(data) {
+		return nil, fmt.Errorf("runtime.pclntab out of section bounds")
+	}
+	text := f.Section(".text")
+	if text == nil {
+		return nil, fmt.Errorf("no .text section")
+	}
+	textAddr := peImageBase(f) + uint64(text.VirtualAddress)
+	return gosym.GoFunctionsFromPclntab(data[start.Value:end.Value], textAddr, uint64(text.VirtualSize))
+}
+
+// PEDebugID returns the debug ID of the PE file: the GUID and age of the
+// PDB file the executable was linked with, as recorded in the CodeView
+// entry of the debug directory. An empty string is returned if the file
+// has no such entry.
+func PEDebugID(f *pe.File) (string, error) {
+	var dir pe.DataDirectory
+	switch h := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if h.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_DEBUG {
+			dir = h.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_DEBUG]
+		}
+	case *pe.OptionalHeader64:
+		if h.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_DEBUG {
+			dir = h.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_DEBUG]
+		}
+	}
+	if dir.VirtualAddress == 0 || dir.Size == 0 {
+		return "", nil
+	}
+	entries, err := peReadRVA(f, dir.VirtualAddress, dir.Size)
+	if err != nil {
+		return "", fmt.Errorf("failed to read debug directory: %w", err)
+	}
+	for ; len(entries) >= peDebugDirectorySize; entries = entries[peDebugDirectorySize:] {
+		if binary.LittleEndian.Uint32(entries[12:]) != peDebugTypeCodeView {
+			continue
+		}
+		size := binary.LittleEndian.Uint32(entries[16:])
+		rva := binary.LittleEndian.Uint32(entries[20:])
+		if size < 24 || rva == 0 {
+			continue
+		}
+		cv, err := peReadRVA(f, rva, 24)
+		if err != nil {
+			return "", fmt.Errorf("failed to read CodeView entry: %w", err)
+		}
+		// Only the PDB 7.0 format (RSDS) is supported.
+		if string(cv[:4]) != "RSDS" {
+			continue
+		}
+		return formatPEDebugID(cv[4:20], binary.LittleEndian.Uint32(cv[20:])), nil
+	}
+	return "", nil
+}
+
+func peReadRVA(f *pe.File, rva, size uint32) ([]byte, error) {
+	for _, s := range f.Sections {
+		if rva < s.VirtualAddress || rva+size > s.VirtualAddress+s.Size {
+			continue
+		}
+		b := make([]byte, size)
+		if _, err := s.ReadAt(b, int64(rva-s.VirtualAddress)); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("RVA 0x%x is out of sections bounds", rva)
+}
+
+// formatPEDebugID formats the PDB GUID and age as a lowercase hyphenated
+// GUID string followed by the age in hex: the debug ID identifies both
+// the PE file and the PDB file that holds its symbols.
+func formatPEDebugID(guid []byte, age uint32) string {
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x-%x",
+		binary.LittleEndian.Uint32(guid[0:4]),
+		binary.LittleEndian.Uint16(guid[4:6]),
+		binary.LittleEndian.Uint16(guid[6:8]),
+		guid[8:10],
+		guid[10:16],
+		age,
+	)
+}
@@ -48,6 +48,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/scheduler"
 	"github.com/grafana/pyroscope/v2/pkg/scheduler/schedulerpb/schedulerpbconnect"
 	"github.com/grafana/pyroscope/v2/pkg/settings"
+	"github.com/grafana/pyroscope/v2/pkg/sourcemap"
 	"github.com/grafana/pyroscope/v2/pkg/storegateway"
 	"github.com/grafana/pyroscope/v2/pkg/validation/exporter"
 )
@@ -272,6 +273,12 @@ func (a *API) RegisterDebugInfo(svc debuginfov1alpha1connect.DebuginfoServiceHan
 		a.WithAuthMiddleware(), WithMethod("POST"))
 }
 
+func (a *API) RegisterSourceMaps(store *sourcemap.Store) {
+	a.RegisterRoute("/api/v1/sourcemaps", store.UploadHTTPHandler(), a.WithAuthMiddleware(), WithMethod("POST"))
+	a.RegisterRoute("/api/v1/sourcemaps", store.ListHTTPHandler(), a.WithAuthMiddleware(), WithMethod("GET"))
+	a.RegisterRoute("/api/v1/sourcemaps", store.DeleteHTTPHandler(), a.WithAuthMiddleware(), WithMethod("DELETE"))
+}
+
 // RegisterDistributor registers the endpoints associated with the distributor.
 func (a *API) RegisterDistributor(d *distributor.Distributor, limits *validation.Overrides, cfg server.Config) {
 	writePathOpts := a.registerOptionsWritePath(limits)
@@ -311,6 +318,14 @@ func (a *API) RegisterQuerierServiceHandler(svc querierv1connect.QuerierServiceH
 	querierv1connect.RegisterQuerierServiceHandler(a.server.HTTP, svc, a.connectOptionsAuthLogDiagnosticsRecovery()...)
 }
 
+func (a *API) RegisterAsyncQueryServiceHandler(svc querierv1connect.AsyncQueryServiceHandler) {
+	querierv1connect.RegisterAsyncQueryServiceHandler(a.server.HTTP, svc, a.connectOptionsAuthLogRecovery()...)
+}
+
+func (a *API) RegisterStreamingQueryServiceHandler(svc querierv1connect.StreamingQueryServiceHandler) {
+	querierv1connect.RegisterStreamingQueryServiceHandler(a.server.HTTP, svc, a.connectOptionsAuthLogRecovery()...)
+}
+
 func (a *API) RegisterVCSServiceHandler(svc vcsv1connect.VCSServiceHandler) {
 	vcsv1connect.RegisterVCSServiceHandler(a.server.HTTP, svc, a.connectOptionsAuthLogRecovery()...)
 }
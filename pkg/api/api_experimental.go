@@ -54,3 +54,13 @@ func (a *API) RegisterQueryDiagnosticsAdmin(adm *querydiagnostics.Admin) {
 		{Desc: "View Stored Diagnostics", Path: "/query-diagnostics/list"},
 	})
 }
+
+func (a *API) RegisterRunningQueriesAdmin(adm *querydiagnostics.RunningQueriesAdmin) {
+	a.registerAdminRoute("/query-diagnostics/running-queries", adm.RunningQueriesHandler(), a.registerOptionsRingPage()...)
+	a.registerAdminRoute("/query-diagnostics/api/running-queries", adm.RunningQueriesAPIHandler(), WithMethod("GET"))
+	a.registerAdminRoute("/query-diagnostics/api/running-queries/", adm.KillQueryAPIHandler(), WithMethod("DELETE"), WithPrefix())
+
+	a.addOperationalLinks(defaultWeight, "Query Frontend", []IndexPageLink{
+		{Desc: "Running Queries", Path: "/query-diagnostics/running-queries"},
+	})
+}
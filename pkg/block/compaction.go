@@ -58,12 +58,74 @@ func WithSampleObserver(observer SampleObserver) CompactionOption {
 	}
 }
 
+// WithSeriesTombstones drops the rows of the deleted series.
+func WithSeriesTombstones(tombstones ...SeriesTombstone) CompactionOption {
+	return func(p *compactionConfig) {
+		p.seriesTombstones = append(p.seriesTombstones, tombstones...)
+	}
+}
+
+// WithDatasetSplitThreshold compacts datasets whose total size in the
+// source blocks exceeds the threshold into separate blocks. This prevents
+// a single hot dataset from making the compacted block of the whole tenant
+// shard huge and slow to query.
+func WithDatasetSplitThreshold(bytes uint64) CompactionOption {
+	return func(p *compactionConfig) {
+		p.datasetSplitThreshold = bytes
+	}
+}
+
+// WithTargetShard moves the source blocks to the given shard. Unlike
+// regular compaction, the compaction level of the blocks is preserved:
+// the blocks are only rewritten to the new location.
+func WithTargetShard(shard uint32) CompactionOption {
+	return func(p *compactionConfig) {
+		p.targetShard = shard
+	}
+}
+
+// WithRewrite rewrites the source blocks in place: both the shard and
+// the compaction level of the blocks are preserved. The option is used
+// to drop the deleted data from the blocks that are not compacted anymore.
+func WithRewrite() CompactionOption {
+	return func(p *compactionConfig) {
+		p.rewrite = true
+	}
+}
+
+// WithCompactionColdStorage specifies the bucket of the cold storage tier:
+// source blocks stored in the cold tier are read from it, and the compacted
+// blocks can be written to it with WithCompactionStorageTier.
+func WithCompactionColdStorage(storage objstore.Bucket) CompactionOption {
+	return func(p *compactionConfig) {
+		p.coldStorage = storage
+	}
+}
+
+// WithCompactionStorageTier specifies the storage tier the compacted
+// blocks are written to. The option has no effect if the cold storage
+// is not configured.
+func WithCompactionStorageTier(tier metastorev1.StorageTier) CompactionOption {
+	return func(p *compactionConfig) {
+		p.storageTier = tier
+	}
+}
+
 type compactionConfig struct {
-	objectOptions  []ObjectOption
-	source         objstore.BucketReader
-	destination    objstore.Bucket
-	tempdir        string
-	sampleObserver SampleObserver
+	objectOptions    []ObjectOption
+	source           objstore.BucketReader
+	destination      objstore.Bucket
+	tempdir          string
+	sampleObserver   SampleObserver
+	seriesTombstones []SeriesTombstone
+	downsampling     DownsamplingConfig
+
+	datasetSplitThreshold uint64
+	targetShard           uint32
+	rewrite               bool
+
+	coldStorage objstore.Bucket
+	storageTier metastorev1.StorageTier
 }
 
 type SampleObserver interface {
@@ -88,9 +150,16 @@ func Compact(
 	for _, option := range options {
 		option(c)
 	}
+	if c.coldStorage == nil {
+		c.storageTier = metastorev1.StorageTier_STORAGE_TIER_HOT
+	}
+	destination := StorageTierBucket(c.destination, c.coldStorage, c.storageTier)
 
-	objects := ObjectsFromMetas(storage, blocks, c.objectOptions...)
-	plan, err := PlanCompaction(objects)
+	objects := make(Objects, len(blocks))
+	for i, md := range blocks {
+		objects[i] = NewObject(StorageTierBucket(storage, c.coldStorage, md.StorageTier), md, c.objectOptions...)
+	}
+	plan, err := planCompaction(objects, c)
 	if err != nil {
 		return nil, err
 	}
@@ -104,10 +173,16 @@ func Compact(
 
 	compacted := make([]*metastorev1.BlockMeta, 0, len(plan))
 	for _, p := range plan {
-		md, compactionErr := p.Compact(ctx, c.destination, c.tempdir, c.sampleObserver)
+		p.tombstones = newSeriesTombstoneFilter(p.tenant, c.seriesTombstones)
+		p.downsampling = c.downsampling
+		md, compactionErr := p.Compact(ctx, destination, c.tempdir, c.sampleObserver)
 		if compactionErr != nil {
 			return nil, compactionErr
 		}
+		if md == nil {
+			// All the data has been deleted.
+			continue
+		}
 		compacted = append(compacted, md)
 	}
 
@@ -115,6 +190,18 @@ func Compact(
 }
 
 func PlanCompaction(objects Objects) ([]*CompactionPlan, error) {
+	return planCompaction(objects, new(compactionConfig))
+}
+
+// compactionPlanKey identifies the compacted block: typically, datasets
+// of a tenant are compacted into a single block, unless the dataset
+// exceeds the split threshold.
+type compactionPlanKey struct {
+	tenant  string
+	dataset string
+}
+
+func planCompaction(objects Objects, c *compactionConfig) ([]*CompactionPlan, error) {
 	if len(objects) == 0 {
 		// Even if there's just a single object, we still need to rewrite it.
 		return nil, ErrNoBlocksToMerge
@@ -128,10 +215,23 @@ func PlanCompaction(objects Objects) ([]*CompactionPlan, error) {
 		}
 		level = max(level, obj.meta.CompactionLevel)
 	}
-	level++
+	shard := r.meta.Shard
+	switch {
+	case c.targetShard > 0:
+		shard = c.targetShard
+	case c.rewrite:
+		// Both the shard and the level are preserved.
+	default:
+		level++
+	}
+
+	var split map[compactionPlanKey]struct{}
+	if c.datasetSplitThreshold > 0 {
+		split = datasetsToSplit(objects, c.datasetSplitThreshold)
+	}
 
 	g := NewULIDGenerator(objects)
-	m := make(map[string]*CompactionPlan)
+	m := make(map[compactionPlanKey]*CompactionPlan)
 	for _, obj := range objects {
 		for _, ds := range obj.meta.Datasets {
 			if ds.Name == 0 {
@@ -139,15 +239,29 @@ func PlanCompaction(objects Objects) ([]*CompactionPlan, error) {
 				// it is rebuilt based on the actual block contents.
 				continue
 			}
-			tm, ok := m[obj.meta.StringTable[ds.Tenant]]
+			if DatasetFormat(ds.Format) == DatasetFormat2 {
+				// Downsampled datasets are never compacted: they
+				// are rebuilt from the raw data, if needed.
+				continue
+			}
+			k := compactionPlanKey{
+				tenant:  obj.meta.StringTable[ds.Tenant],
+				dataset: obj.meta.StringTable[ds.Name],
+			}
+			if _, ok := split[k]; !ok {
+				k.dataset = ""
+			}
+			tm, ok := m[k]
 			if !ok {
 				tm = newBlockCompaction(
 					g.ULID().String(),
-					obj.meta.StringTable[ds.Tenant],
-					r.meta.Shard,
+					k.tenant,
+					shard,
 					level,
 				)
-				m[obj.meta.StringTable[ds.Tenant]] = tm
+				tm.dataset = k.dataset
+				tm.meta.StorageTier = c.storageTier
+				m[k] = tm
 			}
 			// Bind objects to datasets.
 			sm := tm.addDataset(obj.meta, ds)
@@ -163,20 +277,53 @@ func PlanCompaction(objects Objects) ([]*CompactionPlan, error) {
 		})
 	}
 	slices.SortFunc(ordered, func(a, b *CompactionPlan) int {
-		return strings.Compare(a.tenant, b.tenant)
+		if c := strings.Compare(a.tenant, b.tenant); c != 0 {
+			return c
+		}
+		return strings.Compare(a.dataset, b.dataset)
 	})
 
 	return ordered, nil
 }
 
+// datasetsToSplit returns datasets which total size in the
+// source objects exceeds the threshold.
+func datasetsToSplit(objects Objects, threshold uint64) map[compactionPlanKey]struct{} {
+	sizes := make(map[compactionPlanKey]uint64)
+	for _, obj := range objects {
+		for _, ds := range obj.meta.Datasets {
+			if ds.Name == 0 || DatasetFormat(ds.Format) == DatasetFormat2 {
+				continue
+			}
+			k := compactionPlanKey{
+				tenant:  obj.meta.StringTable[ds.Tenant],
+				dataset: obj.meta.StringTable[ds.Name],
+			}
+			sizes[k] += ds.Size
+		}
+	}
+	split := make(map[compactionPlanKey]struct{})
+	for k, size := range sizes {
+		if size > threshold {
+			split[k] = struct{}{}
+		}
+	}
+	return split
+}
+
 type CompactionPlan struct {
-	tenant       string
+	tenant string
+	// Name of the dataset, if the dataset is split from
+	// the rest of the tenant datasets.
+	dataset      string
 	path         string
 	datasetMap   map[int32]*datasetCompaction
 	datasets     []*datasetCompaction
 	meta         *metastorev1.BlockMeta
 	strings      *metadata.StringTable
 	datasetIndex *DatasetIndexWriter
+	tombstones   *seriesTombstoneFilter
+	downsampling DownsamplingConfig
 
 	// datasetIndex state for the compaction-time dedup of consecutive
 	// rows that share a fingerprint. The DatasetIndexWriter itself
@@ -225,14 +372,38 @@ func (b *CompactionPlan) Compact(
 	}()
 
 	// Datasets are compacted in a strict order.
-	for i, s := range b.datasets {
-		b.currentDatasetIdx = uint32(i)
+	var minTime, maxTime int64
+	for _, s := range b.datasets {
+		b.currentDatasetIdx = uint32(len(b.meta.Datasets))
 		s.registerSampleObserver(observer)
+		off := w.Offset()
 		if err = s.compact(ctx, w); err != nil {
 			return nil, fmt.Errorf("compacting block: %w", err)
 		}
+		if s.profiles == 0 && b.tombstones != nil {
+			// All the dataset rows have been deleted: the dataset
+			// is dropped from the block object and its metadata.
+			if err = w.Truncate(off); err != nil {
+				return nil, fmt.Errorf("truncating block: %w", err)
+			}
+			continue
+		}
+		if minTime == 0 || s.meta.MinTime < minTime {
+			minTime = s.meta.MinTime
+		}
+		maxTime = max(maxTime, s.meta.MaxTime)
 		b.meta.Datasets = append(b.meta.Datasets, s.meta)
+		if s.downsampled != nil {
+			// Note that the downsampled dataset is not
+			// included into the tenant dataset index.
+			b.meta.Datasets = append(b.meta.Datasets, s.downsampled)
+		}
 	}
+	if len(b.meta.Datasets) == 0 {
+		return nil, nil
+	}
+	// The time range of the block only covers the datasets retained.
+	b.meta.MinTime, b.meta.MaxTime = minTime, maxTime
 	if err = b.writeDatasetIndex(w); err != nil {
 		return nil, fmt.Errorf("writing tenant index: %w", err)
 	}
@@ -318,11 +489,18 @@ type datasetCompaction struct {
 	indexRewriter   *indexRewriter
 	symbolsRewriter *symbolsRewriter
 	profilesWriter  *profilesWriter
+	downsampler     *downsampler
+	downsampled     *metastorev1.Dataset
 
 	samples  uint64
 	series   uint64
 	profiles uint64
 
+	// Profile types of the rows written, tracked
+	// if series tombstones are applied.
+	profileTypes map[string]struct{}
+	lastFP       model.Fingerprint
+
 	flushOnce sync.Once
 
 	observer SampleObserver
@@ -388,9 +566,74 @@ func (m *datasetCompaction) compact(ctx context.Context, w *Writer) (err error)
 
 	m.meta.Size = w.Offset() - off
 	m.meta.Labels = m.labels.Build()
+	if m.profileTypes != nil {
+		m.meta.Labels = m.retainProfileTypes(m.meta.Labels)
+	}
+
+	if m.downsampler != nil && m.profiles > 0 {
+		if err = m.writeDownsampled(w); err != nil {
+			return fmt.Errorf("failed to write downsampled profiles: %w", err)
+		}
+	}
 	return nil
 }
 
+// writeDownsampled writes the downsampled profile table right after the
+// dataset symbols: the downsampled dataset refers to the tsdb and symbols
+// sections of the raw dataset.
+func (m *datasetCompaction) writeDownsampled(w *Writer) (err error) {
+	toc := m.meta.TableOfContents
+	m.downsampled = &metastorev1.Dataset{
+		Format:          uint32(DatasetFormat2),
+		Tenant:          m.meta.Tenant,
+		Name:            m.meta.Name,
+		MinTime:         m.meta.MinTime,
+		MaxTime:         m.meta.MaxTime,
+		TableOfContents: []uint64{toc[1], toc[2], w.Offset()},
+	}
+	if _, err = io.Copy(w, bytes.NewReader(m.downsampler.buf.Bytes())); err != nil {
+		return err
+	}
+	m.downsampled.Size = w.Offset() - toc[1]
+	resolution := model.Duration(m.parent.downsampling.Resolution).String()
+	lb := metadata.NewLabelBuilder(m.parent.strings)
+	pairs := metadata.LabelPairs(m.meta.Labels)
+	for pairs.Next() {
+		p := pairs.At()
+		ls := make([]string, 0, len(p)+2)
+		for _, x := range p {
+			ls = append(ls, m.parent.strings.Strings[x])
+		}
+		ls = append(ls, metadata.LabelNameDownsamplingResolution, resolution)
+		lb.WithLabelSet(ls...)
+	}
+	m.downsampled.Labels = lb.Build()
+	return nil
+}
+
+// retainProfileTypes drops the label sets of the profile types that have no
+// rows written: all of them have been deleted by series tombstones. Label
+// sets without the profile type label are retained.
+func (m *datasetCompaction) retainProfileTypes(ls []int32) []int32 {
+	retained := make([]int32, 0, len(ls))
+	pairs := metadata.LabelPairs(ls)
+	for pairs.Next() {
+		p := pairs.At()
+		keep := true
+		for i := 0; i+1 < len(p); i += 2 {
+			if m.parent.strings.Strings[p[i]] == phlaremodel.LabelNameProfileType {
+				_, keep = m.profileTypes[m.parent.strings.Strings[p[i+1]]]
+				break
+			}
+		}
+		if keep {
+			retained = append(retained, int32(len(p)/2))
+			retained = append(retained, p...)
+		}
+	}
+	return retained
+}
+
 func (m *datasetCompaction) registerSampleObserver(observer SampleObserver) {
 	m.observer = observer
 }
@@ -405,6 +648,12 @@ func (m *datasetCompaction) open(ctx context.Context, w io.Writer) (err error) {
 
 	m.indexRewriter = newIndexRewriter()
 	m.symbolsRewriter = newSymbolsRewriter(m.observer)
+	if m.parent.tombstones != nil {
+		m.profileTypes = make(map[string]struct{})
+	}
+	if m.parent.downsampling.enabled() {
+		m.downsampler = newDownsampler(m.parent.downsampling, m.symbolsRewriter.w, pageBufferSize)
+	}
 
 	g, ctx := errgroup.WithContext(ctx)
 	for _, s := range m.datasets {
@@ -445,7 +694,11 @@ func (m *datasetCompaction) merge(ctx context.Context) (err error) {
 				return err
 			}
 		}
-		if err = m.writeRow(rows.At()); err != nil {
+		row := rows.At()
+		if m.parent.tombstones.deleted(row) {
+			continue
+		}
+		if err = m.writeRow(row); err != nil {
 			return err
 		}
 	}
@@ -457,11 +710,20 @@ func (m *datasetCompaction) writeRow(r ProfileEntry) (err error) {
 		observe := m.observer.Evaluate(r.View())
 		defer observe()
 	}
+	if m.profileTypes != nil && (m.lastFP != r.Fingerprint || len(m.profileTypes) == 0) {
+		m.profileTypes[r.Labels.Get(phlaremodel.LabelNameProfileType)] = struct{}{}
+		m.lastFP = r.Fingerprint
+	}
 	m.parent.addRowToDatasetIndex(r)
 	m.indexRewriter.rewriteRow(r)
 	if err = m.symbolsRewriter.rewriteRow(r); err != nil {
 		return err
 	}
+	if m.downsampler != nil {
+		if err = m.downsampler.writeRow(r); err != nil {
+			return err
+		}
+	}
 	return m.profilesWriter.writeRow(r)
 }
 
@@ -471,6 +733,9 @@ func (m *datasetCompaction) flush() (err error) {
 		merr.Add(m.symbolsRewriter.Flush())
 		merr.Add(m.indexRewriter.Flush())
 		merr.Add(m.profilesWriter.Close())
+		if m.downsampler != nil {
+			merr.Add(m.downsampler.Close())
+		}
 		m.samples = m.symbolsRewriter.samples
 		m.series = m.indexRewriter.NumSeries()
 		m.profiles = m.profilesWriter.profiles
@@ -484,6 +749,7 @@ func (m *datasetCompaction) close() error {
 	m.symbolsRewriter = nil
 	m.indexRewriter = nil
 	m.profilesWriter = nil
+	m.downsampler = nil
 	m.datasets = nil
 	return err
 }
@@ -2,14 +2,19 @@ package block_test
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/oklog/ulid/v2"
+	"github.com/parquet-go/parquet-go"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +24,7 @@ import (
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	"github.com/grafana/pyroscope/v2/pkg/block"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
 	"github.com/grafana/pyroscope/v2/pkg/metrics"
 	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
 	"github.com/grafana/pyroscope/v2/pkg/objstore"
@@ -412,3 +418,504 @@ func Test_Compact_PreservesSeriesLabels(t *testing.T) {
 	actual := collectSeriesLabels(ctx, t, dst, compacted)
 	require.Equal(t, expected, actual)
 }
+
+func Test_CompactBlocks_seriesTombstones(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	tombstones, err := block.ParseSeriesTombstones(
+		&metastorev1.SeriesTombstone{
+			Name:          "series-1",
+			Tenant:        "anonymous",
+			LabelSelector: `{service_name="pyroscope-test/alloy"}`,
+			StartTime:     0,
+			EndTime:       math.MaxInt32 * 1000,
+		},
+		// Does not match the tenant.
+		&metastorev1.SeriesTombstone{
+			Name:          "series-2",
+			Tenant:        "another-tenant",
+			LabelSelector: `{service_name="pyroscope-test/ingester"}`,
+			StartTime:     0,
+			EndTime:       math.MaxInt32 * 1000,
+		},
+	)
+	require.NoError(t, err)
+
+	dst, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compactedBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+		block.WithSeriesTombstones(tombstones...),
+	)
+
+	require.NoError(t, err)
+	require.Len(t, compactedBlocks, 1)
+	datasets := make([]string, 0, len(compactedBlocks[0].Datasets))
+	for _, ds := range compactedBlocks[0].Datasets {
+		if block.DatasetFormat(ds.Format) == block.DatasetFormat0 {
+			datasets = append(datasets, compactedBlocks[0].StringTable[ds.Name])
+		}
+	}
+	assert.Equal(t, []string{"pyroscope-test/ingester", "pyroscope-test/query-frontend"}, datasets)
+
+	// The deleted dataset is dropped from the block object.
+	attrs, err := dst.Attributes(ctx, block.ObjectPath(compactedBlocks[0]))
+	require.NoError(t, err)
+	assert.Equal(t, int64(compactedBlocks[0].Size), attrs.Size)
+
+	full, fullTempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	fullBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(full),
+		block.WithCompactionTempDir(fullTempdir),
+	)
+	require.NoError(t, err)
+	require.Len(t, fullBlocks, 1)
+	var deletedSize uint64
+	for _, ds := range fullBlocks[0].Datasets {
+		if fullBlocks[0].StringTable[ds.Name] == "pyroscope-test/alloy" {
+			deletedSize += ds.Size
+		}
+	}
+	require.NotZero(t, deletedSize)
+	assert.LessOrEqual(t, compactedBlocks[0].Size, fullBlocks[0].Size-deletedSize)
+
+	expected := collectSeriesLabels(ctx, t, full, fullBlocks)
+	for k := range expected {
+		if strings.Contains(k, "/pyroscope-test/alloy/") {
+			delete(expected, k)
+		}
+	}
+	assert.Equal(t, expected, collectSeriesLabels(ctx, t, dst, compactedBlocks))
+}
+
+func Test_CompactBlocks_seriesTombstones_profileTypes(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	// The profile type label set does not match any rows
+	// in the datasets, as if all of them have been deleted.
+	for _, md := range resp.Blocks {
+		st := metadata.NewStringTable()
+		st.Import(md)
+		for _, ds := range md.Datasets {
+			name := st.Lookup(ds.Name)
+			ds.Labels = metadata.NewLabelBuilder(st).
+				WithLabelSet(phlaremodel.LabelNameServiceName, name, phlaremodel.LabelNameProfileType, "deleted:cpu:nanoseconds:cpu:nanoseconds").
+				WithLabelSet(phlaremodel.LabelNameServiceName, name, metadata.LabelNameUnsymbolized, "true").
+				Build()
+		}
+		md.StringTable = st.Strings
+	}
+
+	tombstones, err := block.ParseSeriesTombstones(&metastorev1.SeriesTombstone{
+		Name:          "series-1",
+		Tenant:        "anonymous",
+		LabelSelector: `{service_name="pyroscope-test/alloy"}`,
+		StartTime:     0,
+		EndTime:       math.MaxInt32 * 1000,
+	})
+	require.NoError(t, err)
+
+	profileTypes := func(md *metastorev1.BlockMeta) (sets, types int) {
+		for _, ds := range md.Datasets {
+			if block.DatasetFormat(ds.Format) != block.DatasetFormat0 {
+				continue
+			}
+			pairs := metadata.LabelPairs(ds.Labels)
+			for pairs.Next() {
+				sets++
+				p := pairs.At()
+				for i := 0; i+1 < len(p); i += 2 {
+					if md.StringTable[p[i]] == phlaremodel.LabelNameProfileType {
+						types++
+					}
+				}
+			}
+		}
+		return sets, types
+	}
+
+	dst, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compactedBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+		block.WithSeriesTombstones(tombstones...),
+	)
+	require.NoError(t, err)
+	require.Len(t, compactedBlocks, 1)
+	sets, types := profileTypes(compactedBlocks[0])
+	assert.Equal(t, 2, sets) // __unsymbolized__ of the datasets retained.
+	assert.Zero(t, types)
+
+	full, fullTempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	fullBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(full),
+		block.WithCompactionTempDir(fullTempdir),
+	)
+	require.NoError(t, err)
+	require.Len(t, fullBlocks, 1)
+	sets, types = profileTypes(fullBlocks[0])
+	assert.Equal(t, 6, sets)
+	assert.Equal(t, 3, types)
+}
+
+func Test_CompactBlocks_downsampling(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	dst, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compactedBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+		block.WithDownsampling(block.DownsamplingConfig{
+			Resolution: time.Hour,
+			MaxDepth:   8,
+		}),
+	)
+	require.NoError(t, err)
+	require.Len(t, compactedBlocks, 1)
+
+	md := compactedBlocks[0]
+	obj := block.NewObject(dst, md)
+	require.NoError(t, obj.Open(ctx))
+	defer func() {
+		require.NoError(t, obj.Close())
+	}()
+
+	type datasetStats struct {
+		profiles int
+		total    int64
+	}
+	readDataset := func(meta *metastorev1.Dataset) (s datasetStats) {
+		ds := block.NewDataset(meta, obj)
+		require.NoError(t, ds.Open(ctx, block.SectionProfiles, block.SectionTSDB, block.SectionSymbols))
+		it, err := block.NewProfileRowIterator(ds)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, it.Close())
+		}()
+		for it.Next() {
+			s.profiles++
+			it.At().Row.ForStacktraceIdsAndValues(func(_ []parquet.Value, values []parquet.Value) {
+				for _, v := range values {
+					s.total += v.Int64()
+				}
+			})
+		}
+		require.NoError(t, it.Err())
+		return s
+	}
+
+	rawStats := make(map[string]datasetStats)
+	downsampled := make(map[string]datasetStats)
+	for _, ds := range md.Datasets {
+		name := md.StringTable[ds.Name]
+		switch block.DatasetFormat(ds.Format) {
+		case block.DatasetFormat0:
+			rawStats[name] = readDataset(ds)
+		case block.DatasetFormat2:
+			resolution, ok := metadata.DownsamplingResolution(md.StringTable, ds.Labels)
+			require.True(t, ok)
+			assert.Equal(t, time.Hour, resolution)
+			downsampled[name] = readDataset(ds)
+		}
+	}
+
+	require.NotEmpty(t, rawStats)
+	require.Len(t, downsampled, len(rawStats))
+	for name, r := range rawStats {
+		d := downsampled[name]
+		assert.Equal(t, r.total, d.total, name)
+		assert.LessOrEqual(t, d.profiles, r.profiles, name)
+	}
+
+	t.Run("Downsampled datasets are not compacted", func(t *testing.T) {
+		compacted, err := block.Compact(ctx, compactedBlocks, dst,
+			block.WithCompactionDestination(dst),
+			block.WithCompactionTempDir(tempdir),
+		)
+		require.NoError(t, err)
+		require.Len(t, compacted, 1)
+		for _, ds := range compacted[0].Datasets {
+			assert.NotEqual(t, block.DatasetFormat2, block.DatasetFormat(ds.Format))
+		}
+	})
+}
+
+func Test_CompactBlocks_dataset_split(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	datasets := func(blocks []*metastorev1.BlockMeta) map[string]int {
+		m := make(map[string]int)
+		for _, md := range blocks {
+			for _, ds := range md.Datasets {
+				if ds.Name != 0 && block.DatasetFormat(ds.Format) == block.DatasetFormat0 {
+					m[md.StringTable[ds.Tenant]+"/"+md.StringTable[ds.Name]]++
+				}
+			}
+		}
+		return m
+	}
+
+	dst, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compactedBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+		// Every dataset exceeds the threshold.
+		block.WithDatasetSplitThreshold(1),
+	)
+	require.NoError(t, err)
+
+	expected := datasets(resp.Blocks)
+	require.Len(t, compactedBlocks, len(expected))
+	for _, md := range compactedBlocks {
+		assert.Len(t, datasets([]*metastorev1.BlockMeta{md}), 1)
+	}
+	for k := range expected {
+		expected[k] = 1
+	}
+	assert.Equal(t, expected, datasets(compactedBlocks))
+}
+
+func Test_CompactBlocks_target_shard(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	const targetShard = 42
+	var level uint32
+	for _, md := range resp.Blocks {
+		require.NotEqual(t, uint32(targetShard), md.Shard)
+		level = max(level, md.CompactionLevel)
+	}
+
+	dst, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compactedBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+		block.WithTargetShard(targetShard),
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, compactedBlocks)
+
+	for _, md := range compactedBlocks {
+		assert.Equal(t, uint32(targetShard), md.Shard)
+		assert.Equal(t, level, md.CompactionLevel)
+		exists, err := dst.Exists(ctx, block.ObjectPath(md))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+func Test_CompactBlocks_rewrite(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	var level uint32
+	for _, md := range resp.Blocks {
+		level = max(level, md.CompactionLevel)
+	}
+
+	dst, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compactedBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+		block.WithRewrite(),
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, compactedBlocks)
+
+	for _, md := range compactedBlocks {
+		assert.Equal(t, resp.Blocks[0].Shard, md.Shard)
+		assert.Equal(t, level, md.CompactionLevel)
+		exists, err := dst.Exists(ctx, block.ObjectPath(md))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+func Test_CompactBlocks_storage_tier(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	hot, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	cold, _ := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compactedBlocks, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(hot),
+		block.WithCompactionColdStorage(cold),
+		block.WithCompactionStorageTier(metastorev1.StorageTier_STORAGE_TIER_COLD),
+		block.WithCompactionTempDir(tempdir),
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, compactedBlocks)
+
+	for _, md := range compactedBlocks {
+		assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, md.StorageTier)
+		exists, err := hot.Exists(ctx, block.ObjectPath(md))
+		require.NoError(t, err)
+		assert.False(t, exists)
+		obj, err := block.NewObjectFromPath(ctx, cold, block.ObjectPath(md))
+		require.NoError(t, err)
+		assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, obj.Metadata().StorageTier)
+	}
+
+	t.Run("Compact cold blocks to the hot tier", func(t *testing.T) {
+		compactedBlocks, err = block.Compact(ctx, compactedBlocks, hot,
+			block.WithCompactionColdStorage(cold),
+			block.WithCompactionTempDir(tempdir),
+		)
+		require.NoError(t, err)
+		require.NotEmpty(t, compactedBlocks)
+		for _, md := range compactedBlocks {
+			assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, md.StorageTier)
+			exists, err := hot.Exists(ctx, block.ObjectPath(md))
+			require.NoError(t, err)
+			assert.True(t, exists)
+		}
+	})
+
+	t.Run("Cold storage is not configured", func(t *testing.T) {
+		compactedBlocks, err = block.Compact(ctx, resp.Blocks, bucket,
+			block.WithCompactionDestination(hot),
+			block.WithCompactionStorageTier(metastorev1.StorageTier_STORAGE_TIER_COLD),
+			block.WithCompactionTempDir(tempdir),
+		)
+		require.NoError(t, err)
+		for _, md := range compactedBlocks {
+			assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, md.StorageTier)
+		}
+	})
+}
+
+func Test_CompactBlocks_replicas(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := testutil.NewFilesystemBucket(t, ctx, "testdata")
+
+	var resp metastorev1.GetBlockMetadataResponse
+	raw, err := os.ReadFile("testdata/block-metas.json")
+	require.NoError(t, err)
+	err = protojson.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	// With the replication factor of 2, each segment has
+	// a replica: a copy of the segment with a distinct ID.
+	src, _ := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	blocks := make([]*metastorev1.BlockMeta, 0, 2*len(resp.Blocks))
+	for _, md := range resp.Blocks {
+		replica := md.CloneVT()
+		replica.Id = ulid.MustNew(ulid.MustParse(md.Id).Time(), rand.Reader).String()
+		replica.Replica = 1
+		for _, b := range []*metastorev1.BlockMeta{md, replica} {
+			r, err := bucket.Get(ctx, block.ObjectPath(md))
+			require.NoError(t, err)
+			require.NoError(t, src.Upload(ctx, block.ObjectPath(b), r))
+			require.NoError(t, r.Close())
+			blocks = append(blocks, b)
+		}
+	}
+
+	type datasetStats struct {
+		profiles int
+		total    int64
+	}
+	collectStats := func(storage objstore.Bucket, metas []*metastorev1.BlockMeta) map[string]datasetStats {
+		stats := make(map[string]datasetStats)
+		for _, md := range metas {
+			assert.Zero(t, md.Replica)
+			obj := block.NewObject(storage, md)
+			require.NoError(t, obj.Open(ctx))
+			for _, meta := range md.Datasets {
+				if block.DatasetFormat(meta.Format) != block.DatasetFormat0 || meta.Name == 0 {
+					continue
+				}
+				ds := block.NewDataset(meta, obj)
+				require.NoError(t, ds.Open(ctx, block.SectionProfiles, block.SectionTSDB))
+				it, err := block.NewProfileRowIterator(ds)
+				require.NoError(t, err)
+				s := stats[md.StringTable[meta.Name]]
+				for it.Next() {
+					s.profiles++
+					s.total += it.At().Row.TotalValue()
+				}
+				require.NoError(t, it.Err())
+				require.NoError(t, it.Close())
+				stats[md.StringTable[meta.Name]] = s
+			}
+			require.NoError(t, obj.Close())
+		}
+		return stats
+	}
+
+	expectedDst, expectedTempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	expected, err := block.Compact(ctx, resp.Blocks, bucket,
+		block.WithCompactionDestination(expectedDst),
+		block.WithCompactionTempDir(expectedTempdir),
+	)
+	require.NoError(t, err)
+
+	dst, tempdir := testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compacted, err := block.Compact(ctx, blocks, src,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+	)
+	require.NoError(t, err)
+
+	expectedStats := collectStats(expectedDst, expected)
+	require.NotEmpty(t, expectedStats)
+	assert.Equal(t, expectedStats, collectStats(dst, compacted))
+	assert.Equal(t, collectSeriesLabels(ctx, t, expectedDst, expected), collectSeriesLabels(ctx, t, dst, compacted))
+
+	// The write succeeds once the quorum of replicas is reached: the
+	// primary replica of a segment may be missing. The profiles are
+	// restored from the replica compacted in the same job.
+	dst, tempdir = testutil.NewFilesystemBucket(t, ctx, t.TempDir())
+	compacted, err = block.Compact(ctx, blocks[1:], src,
+		block.WithCompactionDestination(dst),
+		block.WithCompactionTempDir(tempdir),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expectedStats, collectStats(dst, compacted))
+}
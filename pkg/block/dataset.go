@@ -23,6 +23,10 @@ type DatasetFormat uint32
 const (
 	DatasetFormat0 DatasetFormat = iota
 	DatasetFormat1
+	// DatasetFormat2 is a downsampled dataset. It shares the tsdb and
+	// symbols sections with the raw dataset it is derived from, and
+	// references its own profile table placed right after them.
+	DatasetFormat2
 )
 
 type Section uint32
@@ -50,6 +54,12 @@ type DatasetWeight struct {
 func WeightOf(ds *metastorev1.Dataset) DatasetWeight {
 	toc := ds.TableOfContents
 	switch {
+	case DatasetFormat(ds.Format) == DatasetFormat2 && len(toc) == 3: // tsdb, symbols, profiles
+		return DatasetWeight{
+			TSDBBytes:     toc[1] - toc[0],
+			SymbolsBytes:  toc[2] - toc[1],
+			ProfilesBytes: (toc[0] + ds.Size) - toc[2],
+		}
 	case len(toc) >= 3: // Format0: profiles, tsdb, symbols
 		return DatasetWeight{
 			ProfilesBytes: toc[1] - toc[0],
@@ -65,6 +75,22 @@ func WeightOf(ds *metastorev1.Dataset) DatasetWeight {
 	return DatasetWeight{}
 }
 
+// SectionsBytes returns the total size of the given sections.
+func (w DatasetWeight) SectionsBytes(sections ...Section) uint64 {
+	var n uint64
+	for _, s := range sections {
+		switch s {
+		case SectionProfiles:
+			n += w.ProfilesBytes
+		case SectionTSDB, SectionDatasetIndex:
+			n += w.TSDBBytes
+		case SectionSymbols:
+			n += w.SymbolsBytes
+		}
+	}
+	return n
+}
+
 // Add accumulates another DatasetWeight into this one.
 func (w *DatasetWeight) Add(other DatasetWeight) {
 	w.ProfilesBytes += other.ProfilesBytes
@@ -101,6 +127,11 @@ var (
 			SectionDatasetIndex: sectionDesc{index: 0, name: "dataset_tsdb_index"},
 			SectionTSDB:         sectionDesc{index: 0, name: "dataset_tsdb_index"},
 		},
+		DatasetFormat2: {
+			SectionTSDB:     sectionDesc{index: 0, name: "tsdb"},
+			SectionSymbols:  sectionDesc{index: 1, name: "symbols"},
+			SectionProfiles: sectionDesc{index: 2, name: "downsampled_profiles"},
+		},
 	}
 )
 
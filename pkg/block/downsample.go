@@ -0,0 +1,159 @@
+package block
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/common/model"
+
+	schemav1 "github.com/grafana/pyroscope/v2/pkg/phlaredb/schemas/v1"
+	"github.com/grafana/pyroscope/v2/pkg/phlaredb/symdb"
+)
+
+// DownsamplingConfig configures the downsampled datasets produced at
+// compaction.
+//
+// A downsampled dataset (DatasetFormat2) is written alongside the raw
+// dataset: profiles of each series are aggregated into a single profile
+// per Resolution interval. The downsampled dataset shares the TSDB index
+// and the symbols with the raw one; only the profile table is added.
+//
+// The aggregated profile is timestamped with the start of the interval.
+// Queries served from downsampled data therefore include or exclude the
+// whole interval at the query range boundaries: the result may be off by
+// up to one Resolution interval at each end of the range.
+type DownsamplingConfig struct {
+	// Resolution is the time interval profiles of a series are
+	// aggregated into. Downsampling is disabled if not positive.
+	Resolution time.Duration
+	// MaxDepth limits the stack trace depth of the downsampled profiles:
+	// only MaxDepth root-most frames are retained. Zero means no limit.
+	MaxDepth int
+}
+
+func (c DownsamplingConfig) enabled() bool { return c.Resolution > 0 }
+
+// WithDownsampling enables downsampling for the compacted datasets.
+func WithDownsampling(config DownsamplingConfig) CompactionOption {
+	return func(p *compactionConfig) {
+		p.downsampling = config
+	}
+}
+
+type downsampledProfileKey struct {
+	partition uint64
+	timestamp int64
+}
+
+type downsampledProfile struct {
+	total   uint64
+	samples map[uint32]uint64
+}
+
+// downsampler aggregates rewritten compaction rows into downsampled
+// profiles. Rows are expected in the compaction order: by series, then
+// by timestamp; the profiles of a series are written once it ends.
+type downsampler struct {
+	config  DownsamplingConfig
+	symbols *symdb.SymDB
+	buf     *bytes.Buffer
+	writer  *profilesWriter
+
+	started     bool
+	fingerprint model.Fingerprint
+	seriesIndex uint32
+	series      map[downsampledProfileKey]*downsampledProfile
+	batch       []schemav1.InMemoryProfile
+}
+
+func newDownsampler(config DownsamplingConfig, symbols *symdb.SymDB, pageBufferSize int) *downsampler {
+	buf := bytes.NewBuffer(make([]byte, 0, 1<<20))
+	return &downsampler{
+		config:  config,
+		symbols: symbols,
+		buf:     buf,
+		writer:  newProfileWriter(pageBufferSize, buf),
+		series:  make(map[downsampledProfileKey]*downsampledProfile),
+	}
+}
+
+// writeRow must be called after the row series index and the
+// stack trace IDs are rewritten.
+func (d *downsampler) writeRow(e ProfileEntry) error {
+	if !d.started || d.fingerprint != e.Fingerprint {
+		if err := d.flushSeries(); err != nil {
+			return err
+		}
+		d.started = true
+		d.fingerprint = e.Fingerprint
+	}
+	d.seriesIndex = e.Row.SeriesIndex()
+	k := downsampledProfileKey{
+		partition: e.Row.StacktracePartitionID(),
+		timestamp: e.Timestamp - e.Timestamp%int64(d.config.Resolution),
+	}
+	p, ok := d.series[k]
+	if !ok {
+		p = &downsampledProfile{samples: make(map[uint32]uint64)}
+		d.series[k] = p
+	}
+	partition := d.symbols.PartitionWriter(k.partition)
+	e.Row.ForStacktraceIdsAndValues(func(ids []parquet.Value, values []parquet.Value) {
+		for i, id := range ids {
+			v := values[i].Int64()
+			if v <= 0 {
+				continue
+			}
+			sid := partition.TruncateStacktrace(id.Uint32(), d.config.MaxDepth)
+			p.samples[sid] += uint64(v)
+			p.total += uint64(v)
+		}
+	})
+	return nil
+}
+
+// flushSeries writes the profiles of the current series. Note that every
+// series must have at least one profile in the table, even if empty: the
+// profile table is iterated along with the series of the TSDB index.
+func (d *downsampler) flushSeries() error {
+	if len(d.series) == 0 {
+		return nil
+	}
+	keys := make([]downsampledProfileKey, 0, len(d.series))
+	for k := range d.series {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b downsampledProfileKey) int {
+		if c := cmp.Compare(a.timestamp, b.timestamp); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.partition, b.partition)
+	})
+	d.batch = d.batch[:0]
+	for _, k := range keys {
+		p := d.series[k]
+		d.batch = append(d.batch, schemav1.InMemoryProfile{
+			SeriesIndex:         d.seriesIndex,
+			SeriesFingerprint:   d.fingerprint,
+			StacktracePartition: k.partition,
+			TotalValue:          p.total,
+			TimeNanos:           k.timestamp,
+			DurationNanos:       int64(d.config.Resolution),
+			Samples:             schemav1.NewSamplesFromMap(p.samples),
+		})
+	}
+	clear(d.series)
+	n, err := parquet.CopyRows(d.writer, schemav1.NewInMemoryProfilesRowReader(d.batch))
+	d.writer.profiles += uint64(n)
+	return err
+}
+
+func (d *downsampler) Close() error {
+	if err := d.flushSeries(); err != nil {
+		return err
+	}
+	return d.writer.Close()
+}
@@ -4,8 +4,10 @@ import (
 	goiter "iter"
 	"slices"
 	"strings"
+	"time"
 	"unsafe"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
@@ -19,6 +21,12 @@ const (
 	LabelNameTenantDataset     = "__tenant_dataset__"
 	LabelValueDatasetTSDBIndex = "dataset_tsdb_index"
 	LabelNameUnsymbolized      = "__unsymbolized__"
+	LabelNameBuildID           = "__build_id__"
+	LabelNameRelease           = "__release__"
+
+	// LabelNameDownsamplingResolution is only present in the labels
+	// of downsampled datasets, and specifies the resolution.
+	LabelNameDownsamplingResolution = "__downsampling_resolution__"
 )
 
 type LabelBuilder struct {
@@ -111,6 +119,27 @@ func FindDatasets(md *metastorev1.BlockMeta, matchers ...*labels.Matcher) goiter
 	}
 }
 
+// DownsamplingResolution returns the resolution of the downsampled dataset,
+// as specified in the dataset labels. The function returns false, if the
+// dataset is not downsampled.
+func DownsamplingResolution(strings []string, ls []int32) (time.Duration, bool) {
+	pairs := LabelPairs(ls)
+	for pairs.Next() {
+		p := pairs.At()
+		for i := 0; i+1 < len(p); i += 2 {
+			n, v := p[i], p[i+1]
+			if int(n) >= len(strings) || int(v) >= len(strings) {
+				continue
+			}
+			if strings[n] == LabelNameDownsamplingResolution {
+				d, err := model.ParseDuration(strings[v])
+				return time.Duration(d), err == nil && d > 0
+			}
+		}
+	}
+	return 0, false
+}
+
 func LabelPairs(ls []int32) iter.Iterator[[]int32] { return &labelPairs{labels: ls} }
 
 type labelPairs struct {
@@ -324,14 +353,16 @@ func (lm *LabelMatcher) checkMatches(pairs []int32) bool {
 
 type LabelsCollector struct {
 	strings *StringTable
-	dict    map[string]struct{}
+	dict    map[string]int // Label set -> index in counts.
+	counts  []int64
+	seen    []int
 	tmp     []int32
 	keys    []int32
 }
 
 func NewLabelsCollector(labels ...string) *LabelsCollector {
 	s := &LabelsCollector{
-		dict:    make(map[string]struct{}),
+		dict:    make(map[string]int),
 		strings: NewStringTable(),
 	}
 	s.keys = make([]int32, len(labels))
@@ -356,49 +387,98 @@ func (s *LabelsCollector) CollectMatches(lm *LabelMatcher) {
 		if !match {
 			continue
 		}
-		// Project values of the keep labels to tmp,
-		// and resolve their strings.
-		clear(s.tmp)
-		p := int32s(set)
-		// Note that we're using the matcher's keep labels
-		// and not local 'keys'.
-		for i, n := range lm.keep {
-			for k := 0; k < len(p); k += 2 {
-				if p[k] == n {
-					s.tmp[i] = p[k+1]
-					break
-				}
-			}
+		s.put(lm, int32s(set))
+	}
+}
+
+// CountMatches collects the label sets of the dataset that satisfy
+// the matcher, and counts the dataset once per distinct label set
+// collected: label sets that only differ in the labels that are not
+// collected are counted once.
+//
+// The matcher and collect MUST be configured to keep the same
+// set of labels, in the exact order.
+func (s *LabelsCollector) CountMatches(lm *LabelMatcher, labels []int32) {
+	if len(lm.keep) == 0 || lm.nomatch {
+		return
+	}
+	s.seen = s.seen[:0]
+	pairs := LabelPairs(labels)
+	for pairs.Next() {
+		p := pairs.At()
+		if !lm.MatchesPairs(p) {
+			continue
 		}
-		for i := range s.tmp {
-			s.tmp[i] = s.strings.Put(lm.strings[s.tmp[i]])
+		if i := s.put(lm, p); !slices.Contains(s.seen, i) {
+			s.seen = append(s.seen, i)
+			s.counts[i]++
 		}
-		// Check if we already saw the label set.
-		x := int32string(s.tmp)
-		if _, ok := s.dict[x]; ok {
-			continue
+	}
+}
+
+// put projects the label pairs to the collected labels,
+// and returns the index of the label set.
+func (s *LabelsCollector) put(lm *LabelMatcher, p []int32) int {
+	// Project values of the keep labels to tmp,
+	// and resolve their strings.
+	clear(s.tmp)
+	// Note that we're using the matcher's keep labels
+	// and not local 'keys'.
+	for i, n := range lm.keep {
+		for k := 0; k < len(p); k += 2 {
+			if p[k] == n {
+				s.tmp[i] = p[k+1]
+				break
+			}
 		}
-		s.dict[strings.Clone(x)] = struct{}{}
 	}
+	for i := range s.tmp {
+		s.tmp[i] = s.strings.Put(lm.strings[s.tmp[i]])
+	}
+	// Check if we already saw the label set.
+	x := int32string(s.tmp)
+	i, ok := s.dict[x]
+	if !ok {
+		i = len(s.counts)
+		s.counts = append(s.counts, 0)
+		s.dict[strings.Clone(x)] = i
+	}
+	return i
 }
 
 func (s *LabelsCollector) Unique() goiter.Seq[*typesv1.Labels] {
 	return func(yield func(*typesv1.Labels) bool) {
 		for k := range s.dict {
-			l := &typesv1.Labels{Labels: make([]*typesv1.LabelPair, len(s.keys))}
-			for i, v := range int32s(k) {
-				l.Labels[i] = &typesv1.LabelPair{
-					Name:  s.strings.Strings[s.keys[i]],
-					Value: s.strings.Strings[v],
-				}
+			if !yield(s.labels(k)) {
+				return
 			}
-			if !yield(l) {
+		}
+	}
+}
+
+// Counts returns the unique label sets along with the number
+// of datasets counted with CountMatches.
+func (s *LabelsCollector) Counts() goiter.Seq2[*typesv1.Labels, int64] {
+	return func(yield func(*typesv1.Labels, int64) bool) {
+		for k, i := range s.dict {
+			if !yield(s.labels(k), s.counts[i]) {
 				return
 			}
 		}
 	}
 }
 
+func (s *LabelsCollector) labels(k string) *typesv1.Labels {
+	l := &typesv1.Labels{Labels: make([]*typesv1.LabelPair, len(s.keys))}
+	for i, v := range int32s(k) {
+		l.Labels[i] = &typesv1.LabelPair{
+			Name:  s.strings.Strings[s.keys[i]],
+			Value: s.strings.Strings[v],
+		}
+	}
+	return l
+}
+
 func int32string(data []int32) string {
 	if len(data) == 0 {
 		return ""
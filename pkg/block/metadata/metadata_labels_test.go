@@ -147,6 +147,36 @@ func TestLabelMatcher_Collect(t *testing.T) {
 	assert.Len(t, matches, 0)
 }
 
+func TestLabelsCollector_CountMatches(t *testing.T) {
+	strings := NewStringTable()
+	setA := NewLabelBuilder(strings).
+		WithLabelSet("service_name", "service_a", "__profile_type__", "cpu:a").
+		WithLabelSet("service_name", "service_a", "__profile_type__", "cpu:b").
+		Build()
+
+	setB := NewLabelBuilder(strings).
+		WithLabelSet("service_name", "service_b", "__profile_type__", "cpu:a").
+		Build()
+
+	m := NewLabelMatcher(strings.Strings, []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, "__profile_type__", "cpu.*")},
+		"service_name")
+	assert.True(t, m.IsValid())
+
+	c := NewLabelsCollector("service_name")
+	// Label sets of a dataset that only differ in
+	// labels not collected are counted once.
+	c.CountMatches(m, setA)
+	c.CountMatches(m, setA)
+	c.CountMatches(m, setB)
+
+	counts := make(map[string]int64)
+	for l, n := range c.Counts() {
+		counts[l.Labels[0].Value] = n
+	}
+	assert.Equal(t, map[string]int64{"service_a": 2, "service_b": 1}, counts)
+}
+
 func Benchmark_LabelMatcher_Matches(b *testing.B) {
 	strings := NewStringTable()
 
@@ -305,6 +305,15 @@ func (obj *Object) IsNotExists(err error) bool {
 	return objstore.IsNotExist(obj.storage, err)
 }
 
+// StorageTierBucket returns the bucket of the given storage tier. If the
+// cold storage is not configured, all the objects are stored in the hot one.
+func StorageTierBucket(hot, cold objstore.Bucket, tier metastorev1.StorageTier) objstore.Bucket {
+	if cold != nil && tier == metastorev1.StorageTier_STORAGE_TIER_COLD {
+		return cold
+	}
+	return hot
+}
+
 // ObjectsFromMetas binds block metas to corresponding objects in the storage.
 func ObjectsFromMetas(storage objstore.Bucket, blocks []*metastorev1.BlockMeta, options ...ObjectOption) Objects {
 	objects := make([]*Object, len(blocks))
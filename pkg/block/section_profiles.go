@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
 
+	"github.com/google/uuid"
 	"github.com/parquet-go/parquet-go"
 	"github.com/prometheus/common/model"
 
@@ -342,11 +344,16 @@ func NewMergeRowProfileIterator(src []*Dataset) (iter.Iterator[ProfileEntry], er
 	}, nil
 }
 
+// DedupeProfileRowIterator skips duplicate profiles, e.g., copies
+// written to multiple segments by replicated pushes. Profiles of the
+// same series with the same timestamp are duplicates, unless they have
+// distinct IDs: profiles without ID are deduplicated by the timestamp.
 type DedupeProfileRowIterator struct {
 	iter.Iterator[ProfileEntry]
 
 	prevFP        model.Fingerprint
 	prevTimeNanos int64
+	prevIDs       []uuid.UUID
 }
 
 func (it *DedupeProfileRowIterator) Next() bool {
@@ -355,12 +362,18 @@ func (it *DedupeProfileRowIterator) Next() bool {
 			return false
 		}
 		currentProfile := it.At()
+		id := currentProfile.Row.ID()
 		if it.prevFP == currentProfile.Fingerprint && it.prevTimeNanos == currentProfile.Timestamp {
-			// skip duplicate profile
-			continue
+			if id == uuid.Nil || slices.Contains(it.prevIDs, id) {
+				// skip duplicate profile
+				continue
+			}
+			it.prevIDs = append(it.prevIDs, id)
+			return true
 		}
 		it.prevFP = currentProfile.Fingerprint
 		it.prevTimeNanos = currentProfile.Timestamp
+		it.prevIDs = append(it.prevIDs[:0], id)
 		return true
 	}
 }
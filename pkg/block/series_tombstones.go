@@ -0,0 +1,105 @@
+package block
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
+)
+
+// SeriesTombstone denotes series deleted within a time range.
+type SeriesTombstone struct {
+	Tenant   string
+	Matchers []*labels.Matcher
+	// Unix nano, inclusive.
+	StartTime int64
+	EndTime   int64
+}
+
+func ParseSeriesTombstones(tombstones ...*metastorev1.SeriesTombstone) ([]SeriesTombstone, error) {
+	parsed := make([]SeriesTombstone, 0, len(tombstones))
+	for _, t := range tombstones {
+		matchers, err := phlaremodel.ParseMetricSelector(t.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid series tombstone %q: %w", t.Name, err)
+		}
+		parsed = append(parsed, SeriesTombstone{
+			Tenant:    t.Tenant,
+			Matchers:  matchers,
+			StartTime: time.UnixMilli(t.StartTime).UnixNano(),
+			// The end time is inclusive: the whole millisecond is covered.
+			EndTime: time.UnixMilli(t.EndTime+1).UnixNano() - 1,
+		})
+	}
+	return parsed, nil
+}
+
+// Overlaps reports whether the tombstone time range overlaps
+// with the given one. The time is given in Unix nano, inclusive.
+func (t *SeriesTombstone) Overlaps(start, end int64) bool {
+	return t.StartTime <= end && start <= t.EndTime
+}
+
+// Covers reports whether the timestamp (Unix nano)
+// is within the tombstone time range.
+func (t *SeriesTombstone) Covers(ts int64) bool {
+	return t.StartTime <= ts && ts <= t.EndTime
+}
+
+func (t *SeriesTombstone) Matches(ls phlaremodel.Labels) bool {
+	for _, m := range t.Matchers {
+		if !m.Matches(ls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesTombstoneFilter drops rows of the series deleted. As rows are
+// ordered by series, the matching tombstones are only evaluated once
+// per series.
+type seriesTombstoneFilter struct {
+	tombstones []SeriesTombstone
+	matching   []*SeriesTombstone
+	currentFP  model.Fingerprint
+	started    bool
+}
+
+func newSeriesTombstoneFilter(tenant string, tombstones []SeriesTombstone) *seriesTombstoneFilter {
+	var f seriesTombstoneFilter
+	for _, t := range tombstones {
+		if t.Tenant == tenant {
+			f.tombstones = append(f.tombstones, t)
+		}
+	}
+	if len(f.tombstones) == 0 {
+		return nil
+	}
+	return &f
+}
+
+func (f *seriesTombstoneFilter) deleted(r ProfileEntry) bool {
+	if f == nil {
+		return false
+	}
+	if !f.started || f.currentFP != r.Fingerprint {
+		f.started = true
+		f.currentFP = r.Fingerprint
+		f.matching = f.matching[:0]
+		for i := range f.tombstones {
+			if f.tombstones[i].Matches(r.Labels) {
+				f.matching = append(f.matching, &f.tombstones[i])
+			}
+		}
+	}
+	for _, t := range f.matching {
+		if t.Covers(r.Timestamp) {
+			return true
+		}
+	}
+	return false
+}
@@ -37,6 +37,21 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 
 func (w *Writer) Offset() uint64 { return w.off }
 
+// Truncate discards the data written after the offset.
+func (w *Writer) Truncate(off uint64) error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	if err := w.f.Truncate(int64(off)); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(int64(off), 0); err != nil {
+		return err
+	}
+	w.off = off
+	return nil
+}
+
 func (w *Writer) Upload(ctx context.Context, bucket objstore.Bucket, path string) error {
 	if err := w.w.Flush(); err != nil {
 		return err
@@ -148,6 +148,27 @@ func TestWorker_MetadataFromStorage_SegmentPath(t *testing.T) {
 	require.Equal(t, "", metadata.Tenant(job.blocks[0]))
 }
 
+func TestWorker_MetadataFromStorage_ColdStorage(t *testing.T) {
+	hot := objstore.NewBucket(thanosstore.NewInMemBucket())
+	cold := objstore.NewBucket(thanosstore.NewInMemBucket())
+	writeBlockObject(t, hot, testBlockMeta("01J000000000000000000000G1", 1, 2, "tenant-a"))
+	md := testBlockMeta("01J000000000000000000000G2", 1, 2, "tenant-a")
+	md.StorageTier = metastorev1.StorageTier_STORAGE_TIER_COLD
+	writeBlockObject(t, cold, md)
+
+	w := newStorageMetadataWorker(t, hot)
+	w.cold = cold
+	job := testCompactionJob("tenant-a", 1, 2,
+		"01J000000000000000000000G1",
+		"01J000000000000000000000G2",
+		"01J000000000000000000000G3", // no such object
+	)
+	require.NoError(t, w.getBlockMetadata(log.NewNopLogger(), job))
+	require.Len(t, job.blocks, 2)
+	require.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, job.blocks[0].StorageTier)
+	require.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, job.blocks[1].StorageTier)
+}
+
 func TestWorker_MetadataFromStorage_FetchTimeout(t *testing.T) {
 	bucket := mockobjstore.NewMockBucket(t)
 	bucket.EXPECT().Attributes(mock.Anything, mock.Anything).RunAndReturn(
@@ -56,6 +56,16 @@ type Config struct {
 	MetadataSource       string         `yaml:"metadata_source" category:"advanced"`
 	MetadataFetchTimeout time.Duration  `yaml:"metadata_fetch_timeout" category:"advanced"`
 	MetricsExporter      metrics.Config `yaml:"metrics_exporter" category:"advanced"`
+
+	DownsamplingEnabled    bool          `yaml:"downsampling_enabled" category:"experimental"`
+	DownsamplingMinAge     time.Duration `yaml:"downsampling_min_age" category:"experimental"`
+	DownsamplingResolution time.Duration `yaml:"downsampling_resolution" category:"experimental"`
+	DownsamplingMaxDepth   int           `yaml:"downsampling_max_depth" category:"experimental"`
+
+	DatasetSplitThreshold uint64 `yaml:"dataset_split_threshold_bytes" category:"experimental"`
+
+	ColdStorageMinLevel int           `yaml:"cold_storage_min_level" category:"experimental"`
+	ColdStorageMinAge   time.Duration `yaml:"cold_storage_min_age" category:"experimental"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -69,9 +79,19 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.MetadataSource, prefix+"metadata-source", MetadataSourceMetastore, "Source of the compaction job source block metadata. Supported values: metastore, object-storage.")
 	f.DurationVar(&cfg.MetadataFetchTimeout, prefix+"metadata-fetch-timeout", 30*time.Second, "Timeout for reading the metadata of a single block from object storage. Only effective when metadata-source is object-storage. 0 disables the timeout.")
 	cfg.MetricsExporter.RegisterFlags(f)
+	f.BoolVar(&cfg.DownsamplingEnabled, prefix+"downsampling-enabled", false, "Enable the experimental downsampled datasets: compacted blocks with data older than downsampling-min-age also include aggregated profiles at the downsampling-resolution.")
+	f.DurationVar(&cfg.DownsamplingMinAge, prefix+"downsampling-min-age", 6*time.Hour, "Minimum age of the data to be downsampled at compaction.")
+	f.DurationVar(&cfg.DownsamplingResolution, prefix+"downsampling-resolution", time.Hour, "Time interval profiles of a series are aggregated into.")
+	f.IntVar(&cfg.DownsamplingMaxDepth, prefix+"downsampling-max-depth", 0, "Maximum stack trace depth of the downsampled profiles. Only the root-most frames are retained. 0 means no limit.")
+	f.Uint64Var(&cfg.DatasetSplitThreshold, prefix+"dataset-split-threshold-bytes", 0, "Datasets whose total size in the compaction job source blocks exceeds the threshold are compacted into separate blocks, so that a single hot dataset does not make the compacted block of the whole shard huge and slow to query. 0 to disable.")
+	f.IntVar(&cfg.ColdStorageMinLevel, prefix+"cold-storage-min-level", 0, "Compacted blocks of this level and above are written to the cold storage tier. Only effective if the cold storage is configured. 0 to disable.")
+	f.DurationVar(&cfg.ColdStorageMinAge, prefix+"cold-storage-min-age", 0, "Compacted blocks with all the data older than this are written to the cold storage tier. Only effective if the cold storage is configured. 0 to disable.")
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.DownsamplingEnabled && cfg.DownsamplingResolution <= 0 {
+		return fmt.Errorf("invalid compaction-worker.downsampling-resolution %v: must be positive", cfg.DownsamplingResolution)
+	}
 	switch cfg.MetadataSource {
 	case MetadataSourceMetastore, MetadataSourceObjectStorage:
 		return nil
@@ -88,6 +108,7 @@ type Worker struct {
 	config    Config
 	client    MetastoreClient
 	storage   objstore.Bucket
+	cold      objstore.Bucket
 	compactFn compactFunc
 	metrics   *workerMetrics
 
@@ -130,6 +151,7 @@ func New(
 	config Config,
 	client MetastoreClient,
 	storage objstore.Bucket,
+	coldStorage objstore.Bucket,
 	reg prometheus.Registerer,
 	ruler metrics.Ruler,
 	exporter metrics.Exporter,
@@ -144,6 +166,7 @@ func New(
 		logger:    logger,
 		client:    client,
 		storage:   storage,
+		cold:      coldStorage,
 		compactFn: block.Compact,
 		metrics:   newMetrics(reg),
 		ruler:     ruler,
@@ -401,9 +424,14 @@ func (w *Worker) runCompaction(job *compactionJob) {
 	sp.SetTag("Job", job.String())
 	sp.SetTag("Tenant", job.Tenant)
 	sp.SetTag("Shard", job.Shard)
+	sp.SetTag("TargetShard", job.TargetShard)
+	sp.SetTag("Rewrite", job.Rewrite)
+	sp.SetTag("Downsample", job.Downsample)
+	sp.SetTag("ColdStorage", job.ColdStorage)
 	sp.SetTag("CompactionLevel", job.CompactionLevel)
 	sp.SetTag("SourceBlocks", len(job.SourceBlocks))
 	sp.SetTag("Tombstones", len(job.Tombstones))
+	sp.SetTag("SeriesTombstones", len(job.SeriesTombstones))
 	defer sp.Finish()
 
 	logger := log.With(w.logger, "job", job.Name)
@@ -448,6 +476,20 @@ func (w *Worker) runCompaction(job *compactionJob) {
 		return
 	}
 
+	if job.Downsample && !w.config.DownsamplingEnabled {
+		// The job is abandoned: once the lease expires, it's reassigned,
+		// possibly to a worker that has downsampling enabled.
+		level.Error(logger).Log("msg", "downsampling is not enabled; skipping downsampling job")
+		return
+	}
+
+	if job.ColdStorage && w.cold == nil {
+		// Similarly, the job is abandoned and reassigned once the lease
+		// expires: the cold storage must be configured on all workers.
+		level.Error(logger).Log("msg", "cold storage is not configured; skipping cold storage job")
+		return
+	}
+
 	tempdir := filepath.Join(w.config.TempDir, job.Name)
 	sourcedir := filepath.Join(tempdir, "source")
 	options := []block.CompactionOption{
@@ -463,6 +505,46 @@ func (w *Worker) runCompaction(job *compactionJob) {
 		options = append(options, block.WithSampleObserver(observer))
 	}
 
+	if len(job.SeriesTombstones) > 0 {
+		// The rows of the deleted series are dropped when the blocks are
+		// rewritten. Tombstones are validated by the metastore, therefore
+		// we don't expect any errors here.
+		tombstones, err := block.ParseSeriesTombstones(job.SeriesTombstones...)
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to parse series tombstones", "err", err)
+		} else {
+			options = append(options, block.WithSeriesTombstones(tombstones...))
+		}
+	}
+
+	if w.shouldDownsample(job) {
+		options = append(options, block.WithDownsampling(block.DownsamplingConfig{
+			Resolution: w.config.DownsamplingResolution,
+			MaxDepth:   w.config.DownsamplingMaxDepth,
+		}))
+	}
+
+	if w.config.DatasetSplitThreshold > 0 {
+		options = append(options, block.WithDatasetSplitThreshold(w.config.DatasetSplitThreshold))
+	}
+
+	if job.TargetShard > 0 {
+		options = append(options, block.WithTargetShard(job.TargetShard))
+	}
+
+	if job.Rewrite {
+		options = append(options, block.WithRewrite())
+	}
+
+	if w.cold != nil {
+		tier := w.storageTier(job)
+		sp.SetTag("StorageTier", tier.String())
+		options = append(options,
+			block.WithCompactionColdStorage(w.cold),
+			block.WithCompactionStorageTier(tier),
+		)
+	}
+
 	compacted, err := w.compactFn(ctx, job.blocks, w.storage, options...)
 	defer func() {
 		if err = os.RemoveAll(tempdir); err != nil {
@@ -507,7 +589,7 @@ func (w *Worker) runCompaction(job *compactionJob) {
 		level.Warn(logger).Log("msg", "compaction cancelled")
 		statusName = statusCanceled
 
-	case objstore.IsNotExist(w.storage, err):
+	case objstore.IsNotExist(w.storage, err) || (w.cold != nil && objstore.IsNotExist(w.cold, err)):
 		level.Error(logger).Log("msg", "failed to find blocks", "err", err)
 		job.compacted = &metastorev1.CompactedBlocks{SourceBlocks: new(metastorev1.BlockList)}
 		statusName = statusBlockNotFound
@@ -530,6 +612,57 @@ func (w *Worker) buildSampleObserver(md *metastorev1.BlockMeta) *metrics.SampleO
 	return metrics.NewSampleObserver(recordingTime, w.exporter, w.ruler, pyroscopeInstanceLabel)
 }
 
+// shouldDownsample reports whether the compacted blocks should include
+// downsampled datasets: this is only the case if all the data of the
+// job has reached the minimum age. Blocks of the top compaction level
+// are not compacted again, therefore the metastore plans downsampling
+// jobs for them explicitly, once the data reaches the age.
+func (w *Worker) shouldDownsample(job *compactionJob) bool {
+	if !w.config.DownsamplingEnabled || len(job.blocks) == 0 {
+		return false
+	}
+	if job.Downsample {
+		return true
+	}
+	var maxTime int64
+	for _, b := range job.blocks {
+		maxTime = max(maxTime, b.MaxTime)
+	}
+	return time.Since(time.UnixMilli(maxTime)) >= w.config.DownsamplingMinAge
+}
+
+// storageTier returns the storage tier the compacted blocks are written
+// to: the cold tier is chosen if the output compaction level or the age
+// of the job data reaches the configured threshold. Blocks of the top
+// compaction level are not compacted again, therefore the metastore plans
+// jobs that move them to the cold tier explicitly. Blocks never move back
+// from the cold tier to the hot one.
+func (w *Worker) storageTier(job *compactionJob) metastorev1.StorageTier {
+	if job.ColdStorage {
+		return metastorev1.StorageTier_STORAGE_TIER_COLD
+	}
+	var compactionLevel uint32
+	var maxTime int64
+	for _, b := range job.blocks {
+		if b.StorageTier == metastorev1.StorageTier_STORAGE_TIER_COLD {
+			return metastorev1.StorageTier_STORAGE_TIER_COLD
+		}
+		compactionLevel = max(compactionLevel, b.CompactionLevel)
+		maxTime = max(maxTime, b.MaxTime)
+	}
+	if job.TargetShard == 0 && !job.Rewrite {
+		// Reshard and rewrite jobs preserve the compaction level.
+		compactionLevel++
+	}
+	if w.config.ColdStorageMinLevel > 0 && compactionLevel >= uint32(w.config.ColdStorageMinLevel) {
+		return metastorev1.StorageTier_STORAGE_TIER_COLD
+	}
+	if w.config.ColdStorageMinAge > 0 && time.Since(time.UnixMilli(maxTime)) >= w.config.ColdStorageMinAge {
+		return metastorev1.StorageTier_STORAGE_TIER_COLD
+	}
+	return metastorev1.StorageTier_STORAGE_TIER_HOT
+}
+
 func pyroscopeInstanceHash(shard uint32, createdBy uint32) string {
 	buf := make([]byte, 8)
 	binary.BigEndian.PutUint32(buf[0:4], shard)
@@ -599,10 +732,14 @@ func (w *Worker) readBlockMetadataFromStorage(logger log.Logger, job *compaction
 			}
 			path := block.BuildObjectPath(job.Tenant, job.Shard, job.CompactionLevel, b)
 			obj, err := block.NewObjectFromPath(readCtx, w.storage, path)
+			if w.cold != nil && objstore.IsNotExist(w.storage, err) {
+				// The block may be stored in the cold tier.
+				obj, err = block.NewObjectFromPath(readCtx, w.cold, path)
+			}
 			switch {
 			case err == nil:
 				blocks[i] = obj.Metadata()
-			case objstore.IsNotExist(w.storage, err):
+			case objstore.IsNotExist(w.storage, err) || (w.cold != nil && objstore.IsNotExist(w.cold, err)):
 				// The block has been deleted: drop it from the job,
 				// mirroring the metastore lookup semantics.
 				level.Warn(logger).Log("msg", "source block object not found", "block", b)
@@ -629,6 +766,7 @@ func (w *Worker) newDeleter(logger log.Logger, tombstone *metastorev1.Tombstones
 	return &deleter{
 		logger:    logger,
 		bucket:    w.storage,
+		cold:      w.cold,
 		metrics:   w.metrics,
 		tombstone: tombstone,
 	}
@@ -637,6 +775,7 @@ func (w *Worker) newDeleter(logger log.Logger, tombstone *metastorev1.Tombstones
 type deleter struct {
 	logger    log.Logger
 	bucket    objstore.Bucket
+	cold      objstore.Bucket
 	metrics   *workerMetrics
 	tombstone *metastorev1.Tombstones
 	wg        sync.WaitGroup
@@ -660,7 +799,8 @@ func (d *deleter) handleBlockTombstones(ctx context.Context, pool *deleterPool,
 		d.wg.Add(1)
 		pool.run(func() {
 			defer d.wg.Done()
-			d.delete(ctx, block.BuildObjectPath(t.Tenant, t.Shard, t.CompactionLevel, b))
+			// The tombstone does not specify the storage tier the block is stored in.
+			d.delete(ctx, block.BuildObjectPath(t.Tenant, t.Shard, t.CompactionLevel, b), d.buckets()...)
 		})
 	}
 }
@@ -674,7 +814,19 @@ func (d *deleter) handleShardTombstone(ctx context.Context, pool *deleterPool, t
 
 	logger := log.With(d.logger, "tombstone_name", t.Name)
 	level.Info(logger).Log("msg", "cleaning up shard", "max_time", maxTime, "dir", dir)
+	for _, bucket := range d.buckets() {
+		d.cleanupShard(ctx, pool, logger, bucket, dir, maxTime)
+	}
+}
 
+func (d *deleter) cleanupShard(
+	ctx context.Context,
+	pool *deleterPool,
+	logger log.Logger,
+	bucket objstore.Bucket,
+	dir string,
+	maxTime time.Time,
+) {
 	// Workaround for MinIO/S3 ListObjects: if we stop consuming before cancelling,
 	// the producer goroutine can block on a final send. Cancel first and keep
 	// draining so the producer exits cleanly. Thanos Iter does not drain on early
@@ -714,12 +866,12 @@ func (d *deleter) handleShardTombstone(ctx context.Context, pool *deleterPool, t
 		d.wg.Add(1)
 		pool.run(func() {
 			defer d.wg.Done()
-			d.delete(ctx, path)
+			d.delete(ctx, path, bucket)
 		})
 		return nil
 	}
 
-	if err := d.bucket.Iter(iterCtx, dir, deleteBlock, thanosstore.WithRecursiveIter()); err != nil {
+	if err := bucket.Iter(iterCtx, dir, deleteBlock, thanosstore.WithRecursiveIter()); err != nil {
 		if errors.Is(err, context.Canceled) {
 			// Expected when the iteration context is cancelled.
 			return
@@ -729,13 +881,29 @@ func (d *deleter) handleShardTombstone(ctx context.Context, pool *deleterPool, t
 	}
 }
 
-func (d *deleter) delete(ctx context.Context, path string) {
+// buckets returns the buckets of all the storage tiers.
+func (d *deleter) buckets() []objstore.Bucket {
+	if d.cold != nil {
+		return []objstore.Bucket{d.bucket, d.cold}
+	}
+	return []objstore.Bucket{d.bucket}
+}
+
+// delete removes the object from the first bucket it is found in.
+func (d *deleter) delete(ctx context.Context, path string, buckets ...objstore.Bucket) {
 	var statusName status
-	switch err := d.bucket.Delete(ctx, path); {
+	var bucket objstore.Bucket
+	var err error
+	for _, bucket = range buckets {
+		if err = bucket.Delete(ctx, path); err == nil || !objstore.IsNotExist(bucket, err) {
+			break
+		}
+	}
+	switch {
 	case err == nil:
 		statusName = statusSuccess
 
-	case objstore.IsNotExist(d.bucket, err):
+	case objstore.IsNotExist(bucket, err):
 		level.Info(d.logger).Log("msg", "block not found while attempting to delete it", "path", path, "err", err)
 		statusName = statusBlockNotFound
 
@@ -45,6 +45,7 @@ func createTestWorker(t *testing.T, client MetastoreClient, compactFn compactFun
 		config,
 		client,
 		bucket,
+		nil, // cold storage
 		prometheus.NewRegistry(),
 		nil, // ruler
 		nil, // exporter
@@ -461,6 +462,7 @@ func TestWorker_CleanupMaxDurationAtShutdown(t *testing.T) {
 		config,
 		client,
 		bucket,
+		nil, // cold storage
 		nil, // registry
 		nil, // ruler
 		nil, // exporter
@@ -525,3 +527,105 @@ func TestWorker_CleanupMaxDurationAtShutdown(t *testing.T) {
 
 	require.Equal(t, 2, int(blocksDeleted.Load()))
 }
+
+func TestWorker_StorageTier(t *testing.T) {
+	w := &Worker{config: Config{ColdStorageMinLevel: 3, ColdStorageMinAge: 24 * time.Hour}}
+	now := time.Now()
+	job := func(level uint32, targetShard uint32, maxTime time.Time) *compactionJob {
+		return &compactionJob{
+			CompactionJob: &metastorev1.CompactionJob{TargetShard: targetShard},
+			blocks: []*metastorev1.BlockMeta{
+				{CompactionLevel: level, MaxTime: maxTime.Add(-time.Hour).UnixMilli()},
+				{CompactionLevel: level, MaxTime: maxTime.UnixMilli()},
+			},
+		}
+	}
+
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, w.storageTier(job(1, 0, now)))
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, w.storageTier(job(2, 0, now)))
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, w.storageTier(job(2, 1, now)))
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, w.storageTier(job(1, 0, now.Add(-48*time.Hour))))
+
+	rewrite := job(2, 0, now)
+	rewrite.Rewrite = true
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, w.storageTier(rewrite))
+	rewrite.blocks[1].StorageTier = metastorev1.StorageTier_STORAGE_TIER_COLD
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, w.storageTier(rewrite))
+
+	move := job(2, 0, now)
+	move.Rewrite = true
+	move.ColdStorage = true
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, w.storageTier(move))
+
+	w.config = Config{}
+	assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, w.storageTier(job(5, 0, now.Add(-48*time.Hour))))
+}
+
+func TestWorker_ShouldDownsample(t *testing.T) {
+	w := &Worker{config: Config{DownsamplingEnabled: true, DownsamplingMinAge: 6 * time.Hour}}
+	now := time.Now()
+	job := func(maxTime time.Time, downsample bool) *compactionJob {
+		return &compactionJob{
+			CompactionJob: &metastorev1.CompactionJob{Rewrite: downsample, Downsample: downsample},
+			blocks: []*metastorev1.BlockMeta{
+				{MaxTime: maxTime.Add(-time.Hour).UnixMilli()},
+				{MaxTime: maxTime.UnixMilli()},
+			},
+		}
+	}
+
+	assert.False(t, w.shouldDownsample(job(now, false)))
+	assert.True(t, w.shouldDownsample(job(now.Add(-7*time.Hour), false)))
+	assert.True(t, w.shouldDownsample(job(now, true)))
+
+	w.config.DownsamplingEnabled = false
+	assert.False(t, w.shouldDownsample(job(now.Add(-7*time.Hour), false)))
+	assert.False(t, w.shouldDownsample(job(now, true)))
+}
+
+func TestWorker_DeleteColdStorageBlocks(t *testing.T) {
+	ctx := context.Background()
+	hot := objstore.NewBucket(thanosstore.NewInMemBucket())
+	cold := objstore.NewBucket(thanosstore.NewInMemBucket())
+	blocks := []string{
+		test.ULID("2024-01-01T10:00:00Z"),
+		test.ULID("2024-01-01T11:00:00Z"),
+		test.ULID("2024-01-01T12:00:00Z"),
+	}
+	paths := make([]string, len(blocks))
+	for i, b := range blocks {
+		paths[i] = block.BuildObjectPath("tenant-a", 1, 2, b)
+	}
+	require.NoError(t, hot.Upload(ctx, paths[0], strings.NewReader("block")))
+	require.NoError(t, cold.Upload(ctx, paths[1], strings.NewReader("block")))
+	require.NoError(t, cold.Upload(ctx, paths[2], strings.NewReader("block")))
+
+	w := createTestWorker(t, nil, nil, hot)
+	w.cold = cold
+	pool := newDeleterPool(2)
+	pool.add(w.newDeleter(log.NewNopLogger(), &metastorev1.Tombstones{
+		Blocks: &metastorev1.BlockTombstones{
+			Tenant:          "tenant-a",
+			Shard:           1,
+			CompactionLevel: 2,
+			Blocks:          blocks[:2],
+		},
+	}), time.Second)
+	pool.add(w.newDeleter(log.NewNopLogger(), &metastorev1.Tombstones{
+		Shard: &metastorev1.ShardTombstone{
+			Tenant:    "tenant-a",
+			Shard:     1,
+			Timestamp: test.Time("2024-01-01T12:00:00Z").UnixNano(),
+			Duration:  int64(time.Hour),
+		},
+	}), time.Second)
+	pool.close()
+
+	for _, b := range []objstore.Bucket{hot, cold} {
+		for _, path := range paths {
+			exists, err := b.Exists(ctx, path)
+			require.NoError(t, err)
+			assert.False(t, exists, path)
+		}
+	}
+}
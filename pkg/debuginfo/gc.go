@@ -0,0 +1,303 @@
+package debuginfo
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/thanos-io/objstore"
+
+	debuginfov1alpha1 "github.com/grafana/pyroscope/api/gen/proto/go/debuginfo/v1alpha1"
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner/retention"
+)
+
+type GCConfig struct {
+	Interval    time.Duration `yaml:"-" category:"advanced"`
+	GracePeriod time.Duration `yaml:"-" category:"advanced"`
+	DryRun      bool          `yaml:"-" category:"advanced"`
+}
+
+func (cfg *GCConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&cfg.Interval, prefix+"interval", 0, "Interval between debug info garbage collection runs. 0 to disable. Only blocks written with build ID labels are taken into account, therefore it should only be enabled once all the blocks within the retention period have them.")
+	f.DurationVar(&cfg.GracePeriod, prefix+"grace-period", 7*24*time.Hour, "Period after the upload during which debug info is never deleted, even if it is not referenced by any block.")
+	f.BoolVar(&cfg.DryRun, prefix+"dry-run", false, "Only log the debug info that would be deleted by the garbage collector.")
+}
+
+// MetadataLabelsQuerier provides access to the metadata labels
+// of the blocks in the metastore index.
+type MetadataLabelsQuerier interface {
+	QueryMetadataLabels(context.Context, *metastorev1.QueryMetadataLabelsRequest) (*metastorev1.QueryMetadataLabelsResponse, error)
+}
+
+// GCResult describes the outcome of a garbage collection pass for a tenant.
+type GCResult struct {
+	TenantID   string
+	Scanned    int
+	Referenced int
+	// Build IDs of the debug info files deleted, or,
+	// in the dry-run mode, that would have been deleted.
+	Deleted []string
+}
+
+// GarbageCollector deletes debug info files that are no longer referenced
+// by any block in the metastore index. A block references a debug info file
+// if any of its datasets has the build ID label (__build_id__) set: these
+// labels are attached to datasets that include unsymbolized locations.
+//
+// Only the blocks within the tenant retention period are queried: older
+// blocks are to be removed by the index cleaner.
+type GarbageCollector struct {
+	logger    log.Logger
+	config    GCConfig
+	bucket    objstore.Bucket
+	index     MetadataLabelsQuerier
+	overrides retention.Overrides
+
+	started bool
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+}
+
+// NewGarbageCollector creates a new debug info garbage collector.
+// Overrides are optional: if not set, the whole index is queried.
+func NewGarbageCollector(
+	logger log.Logger,
+	config GCConfig,
+	bucket objstore.Bucket,
+	index MetadataLabelsQuerier,
+	overrides retention.Overrides,
+) *GarbageCollector {
+	return &GarbageCollector{
+		logger:    log.With(logger, "component", "debuginfo-gc"),
+		config:    config,
+		bucket:    bucket,
+		index:     index,
+		overrides: overrides,
+	}
+}
+
+func (gc *GarbageCollector) Start() {
+	if gc.config.Interval == 0 {
+		return
+	}
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if gc.started {
+		gc.logger.Log("msg", "debuginfo garbage collector already started")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	gc.cancel = cancel
+	gc.started = true
+	go gc.loop(ctx)
+	gc.logger.Log("msg", "debuginfo garbage collector started")
+}
+
+func (gc *GarbageCollector) Stop() {
+	if gc.config.Interval == 0 {
+		return
+	}
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if !gc.started {
+		gc.logger.Log("msg", "debuginfo garbage collector already stopped")
+		return
+	}
+	if gc.cancel != nil {
+		gc.cancel()
+	}
+	gc.started = false
+	gc.logger.Log("msg", "debuginfo garbage collector stopped")
+}
+
+func (gc *GarbageCollector) loop(ctx context.Context) {
+	ticker := time.NewTicker(gc.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			switch _, err := gc.Collect(ctx); {
+			case err == nil:
+			case errors.Is(err, context.Canceled):
+				return
+			default:
+				level.Error(gc.logger).Log("msg", "debuginfo garbage collection failed", "err", err)
+			}
+		}
+	}
+}
+
+// Collect runs a garbage collection pass for all the tenants
+// that have debug info uploaded.
+func (gc *GarbageCollector) Collect(ctx context.Context) ([]*GCResult, error) {
+	tenants, err := gc.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*GCResult, 0, len(tenants))
+	for _, tenantID := range tenants {
+		r, err := gc.CollectTenant(ctx, tenantID)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return results, err
+			}
+			level.Error(gc.logger).Log("msg", "failed to collect tenant debuginfo", "tenant", tenantID, "err", err)
+			continue
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// CollectTenant runs a garbage collection pass for the given tenant.
+func (gc *GarbageCollector) CollectTenant(ctx context.Context, tenantID string) (*GCResult, error) {
+	now := time.Now()
+	referenced, err := gc.referencedBuildIDs(ctx, tenantID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referenced build IDs: %w", err)
+	}
+
+	logger := log.With(gc.logger, "tenant", tenantID)
+	result := &GCResult{TenantID: tenantID}
+	err = gc.bucket.Iter(ctx, path.Join(bucketPrefix, tenantID)+objstore.DirDelim, func(name string) error {
+		if !strings.HasSuffix(name, objstore.DirDelim) {
+			return nil
+		}
+		id, err := ValidateBuildID(path.Base(name))
+		if err != nil {
+			level.Warn(logger).Log("msg", "skipping debuginfo entry with invalid build ID", "name", name, "err", err)
+			return nil
+		}
+		result.Scanned++
+		if _, ok := referenced[strings.ToLower(id.buildID)]; ok {
+			result.Referenced++
+			return nil
+		}
+		collect, err := gc.shouldCollect(ctx, tenantID, id, now)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to check debuginfo", "build_id", id.buildID, "err", err)
+			return nil
+		}
+		if !collect {
+			return nil
+		}
+		if !gc.config.DryRun {
+			if err = gc.delete(ctx, tenantID, id); err != nil {
+				level.Warn(logger).Log("msg", "failed to delete debuginfo", "build_id", id.buildID, "err", err)
+				return nil
+			}
+		}
+		level.Info(logger).Log("msg", "unreferenced debuginfo deleted", "build_id", id.buildID, "dry_run", gc.config.DryRun)
+		result.Deleted = append(result.Deleted, id.buildID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	level.Info(logger).Log(
+		"msg", "debuginfo garbage collection completed",
+		"scanned", result.Scanned,
+		"referenced", result.Referenced,
+		"deleted", len(result.Deleted),
+		"dry_run", gc.config.DryRun,
+	)
+	return result, nil
+}
+
+func (gc *GarbageCollector) tenants(ctx context.Context) ([]string, error) {
+	var tenants []string
+	err := gc.bucket.Iter(ctx, bucketPrefix+objstore.DirDelim, func(name string) error {
+		if strings.HasSuffix(name, objstore.DirDelim) {
+			tenants = append(tenants, path.Base(name))
+		}
+		return nil
+	})
+	return tenants, err
+}
+
+func (gc *GarbageCollector) referencedBuildIDs(ctx context.Context, tenantID string, now time.Time) (map[string]struct{}, error) {
+	resp, err := gc.index.QueryMetadataLabels(ctx, &metastorev1.QueryMetadataLabelsRequest{
+		TenantId:  []string{tenantID},
+		StartTime: gc.retentionStart(tenantID, now).UnixMilli(),
+		EndTime:   now.UnixMilli(),
+		Query:     fmt.Sprintf(`{%s=~".+"}`, metadata.LabelNameBuildID),
+		Labels:    []string{metadata.LabelNameBuildID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]struct{}, len(resp.Labels))
+	for _, ls := range resp.Labels {
+		for _, l := range ls.Labels {
+			if l.Name == metadata.LabelNameBuildID && l.Value != "" {
+				referenced[strings.ToLower(l.Value)] = struct{}{}
+			}
+		}
+	}
+	return referenced, nil
+}
+
+func (gc *GarbageCollector) retentionStart(tenantID string, now time.Time) time.Time {
+	if gc.overrides == nil {
+		return time.UnixMilli(0)
+	}
+	defaults, overrides := gc.overrides.Retention()
+	period := defaults.RetentionPeriod
+	for t, o := range overrides {
+		if t == tenantID {
+			period = o.RetentionPeriod
+			break
+		}
+	}
+	if period <= 0 {
+		return time.UnixMilli(0)
+	}
+	return now.Add(-time.Duration(period))
+}
+
+// shouldCollect reports whether the unreferenced debug info can be
+// deleted: the upload must be finished (or stale) and older than
+// the grace period.
+func (gc *GarbageCollector) shouldCollect(ctx context.Context, tenantID string, id *ValidBuildID, now time.Time) (bool, error) {
+	md, err := fetchMetadata(ctx, gc.bucket, tenantID, id)
+	if err != nil {
+		return false, err
+	}
+	if md == nil {
+		// The upload has not been initiated properly:
+		// there is no metadata to tell how old it is.
+		return false, nil
+	}
+	deadline := now.Add(-gc.config.GracePeriod)
+	switch md.State {
+	case debuginfov1alpha1.ObjectMetadata_STATE_UPLOADED:
+		return md.FinishedAt.AsTime().Before(deadline), nil
+	case debuginfov1alpha1.ObjectMetadata_STATE_UPLOADING:
+		return md.StartedAt.AsTime().Before(deadline), nil
+	default:
+		return false, nil
+	}
+}
+
+func (gc *GarbageCollector) delete(ctx context.Context, tenantID string, id *ValidBuildID) error {
+	// The metadata object is deleted last: if the deletion fails midway,
+	// the entry is retried on the next run.
+	for _, objectPath := range []string{ObjectPath(tenantID, id), MetadataObjectPath(tenantID, id)} {
+		if err := gc.bucket.Delete(ctx, objectPath); err != nil && !gc.bucket.IsObjNotFoundErr(err) {
+			return fmt.Errorf("failed to delete debuginfo object %q: %w", objectPath, err)
+		}
+	}
+	return nil
+}
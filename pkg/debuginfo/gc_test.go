@@ -0,0 +1,133 @@
+package debuginfo
+
+import (
+	"bytes"
+	"context"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	debuginfov1alpha1 "github.com/grafana/pyroscope/api/gen/proto/go/debuginfo/v1alpha1"
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner/retention"
+	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/memory"
+)
+
+type mockMetadataLabelsQuerier struct {
+	buildIDs map[string][]string
+}
+
+func (m *mockMetadataLabelsQuerier) QueryMetadataLabels(_ context.Context, req *metastorev1.QueryMetadataLabelsRequest) (*metastorev1.QueryMetadataLabelsResponse, error) {
+	resp := new(metastorev1.QueryMetadataLabelsResponse)
+	for _, id := range m.buildIDs[req.TenantId[0]] {
+		resp.Labels = append(resp.Labels, &typesv1.Labels{Labels: []*typesv1.LabelPair{
+			{Name: metadata.LabelNameBuildID, Value: id},
+		}})
+	}
+	return resp, nil
+}
+
+type mockRetentionOverrides map[string]retention.Config
+
+func (m mockRetentionOverrides) Retention() (retention.Config, iter.Seq2[string, retention.Config]) {
+	return retention.Config{RetentionPeriod: model.Duration(24 * time.Hour)}, func(yield func(string, retention.Config) bool) {
+		for k, v := range m {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func TestGarbageCollector_CollectTenant(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+
+	bucket := memory.NewInMemBucket()
+	upload := func(tenantID, buildID string, md *debuginfov1alpha1.ObjectMetadata) {
+		id := mustValidateGnuBuildID(t, buildID)
+		s := &Store{bucket: bucket}
+		require.NoError(t, s.writeMetadata(ctx, tenantID, id, md))
+		require.NoError(t, bucket.Upload(ctx, ObjectPath(tenantID, id), bytes.NewReader([]byte("elf"))))
+	}
+	uploaded := func(ts time.Time) *debuginfov1alpha1.ObjectMetadata {
+		return &debuginfov1alpha1.ObjectMetadata{
+			State:      debuginfov1alpha1.ObjectMetadata_STATE_UPLOADED,
+			StartedAt:  timestamppb.New(ts),
+			FinishedAt: timestamppb.New(ts),
+		}
+	}
+
+	upload("tenant-a", "aaaa", uploaded(old))
+	upload("tenant-a", "BBBB", uploaded(old))
+	upload("tenant-a", "cccc", uploaded(now))
+	upload("tenant-a", "dddd", &debuginfov1alpha1.ObjectMetadata{
+		State:     debuginfov1alpha1.ObjectMetadata_STATE_UPLOADING,
+		StartedAt: timestamppb.New(old),
+	})
+	upload("tenant-b", "eeee", uploaded(old))
+
+	querier := &mockMetadataLabelsQuerier{buildIDs: map[string][]string{
+		"tenant-a": {"bbbb"},
+		"tenant-b": {"eeee"},
+	}}
+
+	t.Run("dry run", func(t *testing.T) {
+		gc := NewGarbageCollector(log.NewNopLogger(), GCConfig{GracePeriod: 24 * time.Hour, DryRun: true}, bucket, querier, nil)
+		r, err := gc.CollectTenant(ctx, "tenant-a")
+		require.NoError(t, err)
+		assert.Equal(t, 4, r.Scanned)
+		assert.Equal(t, 1, r.Referenced)
+		assert.Equal(t, []string{"aaaa", "dddd"}, r.Deleted)
+		exists, err := bucket.Exists(ctx, ObjectPath("tenant-a", mustValidateGnuBuildID(t, "aaaa")))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("collect", func(t *testing.T) {
+		gc := NewGarbageCollector(log.NewNopLogger(), GCConfig{GracePeriod: 24 * time.Hour}, bucket, querier, nil)
+		results, err := gc.Collect(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, []string{"aaaa", "dddd"}, results[0].Deleted)
+		assert.Empty(t, results[1].Deleted)
+
+		for _, buildID := range []string{"aaaa", "dddd"} {
+			id := mustValidateGnuBuildID(t, buildID)
+			for _, p := range []string{ObjectPath("tenant-a", id), MetadataObjectPath("tenant-a", id)} {
+				exists, err := bucket.Exists(ctx, p)
+				require.NoError(t, err)
+				assert.False(t, exists, p)
+			}
+		}
+		for _, buildID := range []string{"BBBB", "cccc"} {
+			exists, err := bucket.Exists(ctx, ObjectPath("tenant-a", mustValidateGnuBuildID(t, buildID)))
+			require.NoError(t, err)
+			assert.True(t, exists, buildID)
+		}
+	})
+}
+
+func TestGarbageCollector_RetentionPeriod(t *testing.T) {
+	now := time.Now()
+	overrides := mockRetentionOverrides{
+		"tenant-a": {RetentionPeriod: model.Duration(time.Hour)},
+		"tenant-b": {RetentionPeriod: 0},
+	}
+	gc := NewGarbageCollector(log.NewNopLogger(), GCConfig{}, nil, nil, overrides)
+	assert.Equal(t, now.Add(-time.Hour), gc.retentionStart("tenant-a", now))
+	assert.Equal(t, time.UnixMilli(0), gc.retentionStart("tenant-b", now))
+	assert.Equal(t, now.Add(-24*time.Hour), gc.retentionStart("tenant-c", now))
+
+	gc = NewGarbageCollector(log.NewNopLogger(), GCConfig{}, nil, nil, nil)
+	assert.Equal(t, time.UnixMilli(0), gc.retentionStart("tenant-a", now))
+}
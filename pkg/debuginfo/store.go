@@ -32,6 +32,7 @@ type Config struct {
 	MaxUploadSize     int64         `yaml:"-" category:"advanced"`
 	UploadStalePeriod time.Duration `yaml:"-" category:"advanced"`
 	UploadTimeout     time.Duration `yaml:"-" category:"advanced"`
+	GC                GCConfig      `yaml:"-"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -39,6 +40,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.Int64Var(&cfg.MaxUploadSize, "debug-info.max-upload-size", 1024*1024*1024, "Maximum size of a single debug info upload in bytes.")
 	f.DurationVar(&cfg.UploadStalePeriod, "debug-info.upload-stale-period", 5*time.Minute, "Period after which a pending upload is considered stale and can be retried.")
 	f.DurationVar(&cfg.UploadTimeout, "debug-info.upload-timeout", 2*time.Minute, "Timeout for a single debug info upload request. Overrides server HTTP write timeout for this handler.")
+	cfg.GC.RegisterFlagsWithPrefix("debug-info.gc.", f)
 }
 
 type Store struct {
@@ -127,14 +129,14 @@ func (s *Store) ListDebuginfo(ctx context.Context, req *connect.Request[debuginf
 
 	dir := "debug-info/" + tenantID + "/"
 
-	var buildIDs []*ValidGnuBuildID
+	var buildIDs []*ValidBuildID
 
 	err = s.bucket.Iter(ctx, dir, func(name string) error {
 		if !strings.HasSuffix(name, objstore.DirDelim) {
 			return nil
 		}
 
-		id, err := ValidateGnuBuildID(path.Base(name))
+		id, err := ValidateBuildID(path.Base(name))
 		if err != nil {
 			level.Warn(s.logger).Log("msg", "skipping debuginfo entry with invalid build ID", "name", name, "err", err)
 			return nil
@@ -185,7 +187,7 @@ func (s *Store) DeleteDebuginfo(
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	id, err := ValidateGnuBuildID(req.Msg.GetGnuBuildId())
+	id, err := ValidateBuildID(req.Msg.GetGnuBuildId())
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid gnu_build_id: %w", err))
 	}
@@ -281,13 +283,13 @@ func (s *Store) UploadHTTPHandler() http.Handler {
 		}
 
 		gnuBuildIDStr := mux.Vars(r)["gnu_build_id"]
-		id, err := ValidateGnuBuildID(gnuBuildIDStr)
+		id, err := ValidateBuildID(gnuBuildIDStr)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("invalid gnu_build_id: %v", err), http.StatusBadRequest)
 			return
 		}
 
-		l := log.With(s.logger, "gnu_build_id", id.gnuBuildID)
+		l := log.With(s.logger, "build_id", id.buildID)
 
 		md, err := s.fetchMetadata(ctx, tenantID, id)
 		if err != nil {
@@ -323,12 +325,12 @@ func (s *Store) UploadFinished(
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	id, err := ValidateGnuBuildID(req.Msg.GnuBuildId)
+	id, err := ValidateBuildID(req.Msg.GnuBuildId)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid gnu_build_id: %w", err))
 	}
 
-	l := log.With(s.logger, "gnu_build_id", id.gnuBuildID)
+	l := log.With(s.logger, "build_id", id.buildID)
 
 	md, err := s.fetchMetadata(ctx, tenantID, id)
 	if err != nil {
@@ -360,7 +362,7 @@ func (s *Store) UploadFinished(
 	return connect.NewResponse(&debuginfov1alpha1.UploadFinishedResponse{}), nil
 }
 
-func validateInit(init *debuginfov1alpha1.ShouldInitiateUploadRequest) (*ValidGnuBuildID, error) {
+func validateInit(init *debuginfov1alpha1.ShouldInitiateUploadRequest) (*ValidBuildID, error) {
 	if init == nil {
 		return nil, fmt.Errorf("first message expected to be init")
 	}
@@ -369,9 +371,11 @@ func validateInit(init *debuginfov1alpha1.ShouldInitiateUploadRequest) (*ValidGn
 	}
 	switch init.File.Type {
 	case debuginfov1alpha1.FileMetadata_TYPE_EXECUTABLE_FULL:
-		return ValidateGnuBuildID(init.File.GnuBuildId)
+		return ValidateBuildID(init.File.GnuBuildId)
 	case debuginfov1alpha1.FileMetadata_TYPE_EXECUTABLE_NO_TEXT:
-		return ValidateGnuBuildID(init.File.GnuBuildId)
+		return ValidateBuildID(init.File.GnuBuildId)
+	case debuginfov1alpha1.FileMetadata_TYPE_DEBUG_SYMBOLS:
+		return ValidateBuildID(init.File.GnuBuildId)
 	default:
 		return nil, fmt.Errorf("init.File.Type(%d) is not valid", init.File.Type)
 	}
@@ -381,10 +385,14 @@ func (s *Store) uploadIsStale(upload *debuginfov1alpha1.ObjectMetadata) bool {
 	return upload.StartedAt.AsTime().Add(s.cfg.UploadStalePeriod + 2*time.Minute).Before(time.Now())
 }
 
-func (s *Store) fetchMetadata(ctx context.Context, tenantID string, id *ValidGnuBuildID) (*debuginfov1alpha1.ObjectMetadata, error) {
-	r, err := s.bucket.Get(ctx, MetadataObjectPath(tenantID, id))
+func (s *Store) fetchMetadata(ctx context.Context, tenantID string, id *ValidBuildID) (*debuginfov1alpha1.ObjectMetadata, error) {
+	return fetchMetadata(ctx, s.bucket, tenantID, id)
+}
+
+func fetchMetadata(ctx context.Context, bucket objstore.BucketReader, tenantID string, id *ValidBuildID) (*debuginfov1alpha1.ObjectMetadata, error) {
+	r, err := bucket.Get(ctx, MetadataObjectPath(tenantID, id))
 	if err != nil {
-		if s.bucket.IsObjNotFoundErr(err) {
+		if bucket.IsObjNotFoundErr(err) {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("fetch debuginfo metadata from object storage: %w", err)
@@ -403,7 +411,7 @@ func (s *Store) fetchMetadata(ctx context.Context, tenantID string, id *ValidGnu
 	return dbginfo, nil
 }
 
-func (s *Store) writeMetadata(ctx context.Context, tenantID string, id *ValidGnuBuildID, md *debuginfov1alpha1.ObjectMetadata) error {
+func (s *Store) writeMetadata(ctx context.Context, tenantID string, id *ValidBuildID, md *debuginfov1alpha1.ObjectMetadata) error {
 	bs, err := protojson.Marshal(md)
 	if err != nil {
 		return fmt.Errorf("marshal debuginfo metadata: %w", err)
@@ -412,26 +420,89 @@ func (s *Store) writeMetadata(ctx context.Context, tenantID string, id *ValidGnu
 	return s.bucket.Upload(ctx, MetadataObjectPath(tenantID, id), bytes.NewReader(bs))
 }
 
-var gnuRegex = regexp.MustCompile("^[a-fA-F0-9]{2,40}$")
+// BuildIDType identifies the scheme of a build ID.
+type BuildIDType int
+
+const (
+	// BuildIDTypeGNU is the GNU build ID of ELF files, in hex.
+	BuildIDTypeGNU BuildIDType = iota
+	// BuildIDTypeMachO is the UUID of Mach-O files (LC_UUID),
+	// in the 8-4-4-4-12 hex digits form.
+	BuildIDTypeMachO
+	// BuildIDTypePE is the debug ID of PE and PDB files: the PDB GUID
+	// in the 8-4-4-4-12 hex digits form, followed by a hyphen and the
+	// age in hex.
+	BuildIDTypePE
+)
+
+func (t BuildIDType) String() string {
+	switch t {
+	case BuildIDTypeGNU:
+		return "gnu"
+	case BuildIDTypeMachO:
+		return "macho"
+	case BuildIDTypePE:
+		return "pe"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	gnuRegex   = regexp.MustCompile("^[a-fA-F0-9]{2,40}$")
+	machoRegex = regexp.MustCompile("^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}$")
+	peRegex    = regexp.MustCompile("^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}-[a-fA-F0-9]{1,8}$")
+)
+
+// BuildIDTypeOf returns the type of the build ID, based on its format.
+// Build IDs that are neither Mach-O UUIDs nor PE debug IDs are considered
+// GNU build IDs.
+func BuildIDTypeOf(buildID string) BuildIDType {
+	switch {
+	case machoRegex.MatchString(buildID):
+		return BuildIDTypeMachO
+	case peRegex.MatchString(buildID):
+		return BuildIDTypePE
+	default:
+		return BuildIDTypeGNU
+	}
+}
 
-type ValidGnuBuildID struct {
-	gnuBuildID string
+type ValidBuildID struct {
+	buildID string
+	typ     BuildIDType
+}
+
+func (id *ValidBuildID) String() string { return id.buildID }
+
+func (id *ValidBuildID) Type() BuildIDType { return id.typ }
+
+// ValidateBuildID validates a build ID of any of the supported types.
+// Mach-O UUIDs and PE debug IDs are case-insensitive: they are normalized
+// to lowercase, so that the same binary is always stored under the same key.
+func ValidateBuildID(buildID string) (*ValidBuildID, error) {
+	switch t := BuildIDTypeOf(buildID); t {
+	case BuildIDTypeMachO, BuildIDTypePE:
+		return &ValidBuildID{buildID: strings.ToLower(buildID), typ: t}, nil
+	default:
+		return ValidateGnuBuildID(buildID)
+	}
 }
 
-func ValidateGnuBuildID(gnuBuildID string) (*ValidGnuBuildID, error) {
+func ValidateGnuBuildID(gnuBuildID string) (*ValidBuildID, error) {
 	if !gnuRegex.MatchString(gnuBuildID) {
 		return nil, fmt.Errorf("invalid gnuBuildID %q", gnuBuildID)
 	}
 
-	return &ValidGnuBuildID{gnuBuildID}, nil
+	return &ValidBuildID{buildID: gnuBuildID, typ: BuildIDTypeGNU}, nil
 }
 
 const bucketPrefix = "debug-info"
 
-func ObjectPath(tenantID string, id *ValidGnuBuildID) string {
-	return path.Join(bucketPrefix, tenantID, id.gnuBuildID, "exe")
+func ObjectPath(tenantID string, id *ValidBuildID) string {
+	return path.Join(bucketPrefix, tenantID, id.buildID, "exe")
 }
 
-func MetadataObjectPath(tenantID string, id *ValidGnuBuildID) string {
-	return path.Join(bucketPrefix, tenantID, id.gnuBuildID, "metadata")
+func MetadataObjectPath(tenantID string, id *ValidBuildID) string {
+	return path.Join(bucketPrefix, tenantID, id.buildID, "metadata")
 }
@@ -32,7 +32,7 @@ func newTestStore(t *testing.T, cfg Config) (*Store, *memory.InMemBucket) {
 	return s, bucket
 }
 
-func mustValidateGnuBuildID(t *testing.T, id string) *ValidGnuBuildID {
+func mustValidateGnuBuildID(t *testing.T, id string) *ValidBuildID {
 	t.Helper()
 	v, err := ValidateGnuBuildID(id)
 	require.NoError(t, err)
@@ -71,12 +71,49 @@ func TestValidateGnuBuildID(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, id)
-				assert.Equal(t, tt.input, id.gnuBuildID)
+				assert.Equal(t, tt.input, id.buildID)
 			}
 		})
 	}
 }
 
+func TestValidateBuildID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		wantID   string
+		wantType BuildIDType
+		wantErr  bool
+	}{
+		{name: "gnu", input: "DEADbeef", wantID: "DEADbeef", wantType: BuildIDTypeGNU},
+		{name: "macho uuid", input: "2CFDDACB-28B2-34D7-9958-B93F1E318D67", wantID: "2cfddacb-28b2-34d7-9958-b93f1e318d67", wantType: BuildIDTypeMachO},
+		{name: "pe debug id", input: "12345678-1234-5678-0102-030405060708-A", wantID: "12345678-1234-5678-0102-030405060708-a", wantType: BuildIDTypePE},
+		{name: "pe debug id max age", input: "12345678-1234-5678-0102-030405060708-ffffffff", wantID: "12345678-1234-5678-0102-030405060708-ffffffff", wantType: BuildIDTypePE},
+		{name: "pe debug id age too long", input: "12345678-1234-5678-0102-030405060708-100000000", wantErr: true},
+		{name: "uuid without hyphens is gnu", input: "2cfddacb28b234d79958b93f1e318d67", wantID: "2cfddacb28b234d79958b93f1e318d67", wantType: BuildIDTypeGNU},
+		{name: "malformed uuid", input: "2cfddacb-28b2-34d7-9958", wantErr: true},
+		{name: "path traversal", input: "../2cfddacb-28b2-34d7-9958-b93f1e318d67", wantErr: true},
+		{name: "empty string", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			id, err := ValidateBuildID(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, id)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantID, id.String())
+			assert.Equal(t, tt.wantType, id.Type())
+		})
+	}
+}
+
 func TestValidateInit(t *testing.T) {
 	t.Parallel()
 
@@ -118,6 +155,16 @@ func TestValidateInit(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid debug symbols",
+			init: &debuginfov1alpha1.ShouldInitiateUploadRequest{
+				File: &debuginfov1alpha1.FileMetadata{
+					GnuBuildId: "12345678-1234-5678-0102-030405060708-1",
+					Type:       debuginfov1alpha1.FileMetadata_TYPE_DEBUG_SYMBOLS,
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid type",
 			init: &debuginfov1alpha1.ShouldInitiateUploadRequest{
@@ -166,7 +213,7 @@ func TestValidateInit(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, id)
-				assert.Equal(t, tt.init.File.GnuBuildId, id.gnuBuildID)
+				assert.Equal(t, tt.init.File.GnuBuildId, id.buildID)
 			}
 		})
 	}
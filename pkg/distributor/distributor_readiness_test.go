@@ -27,7 +27,7 @@ func newReadinessSegmentWriterClient(t *testing.T, ctx context.Context, logger l
 	grpcCfg.RegisterFlags(flag.NewFlagSet("", flag.PanicOnError))
 
 	swClient, err := segmentwriterclient.NewSegmentWriterClient(
-		grpcCfg, logger, nil, r, nil,
+		grpcCfg, logger, nil, r, nil, 1,
 	)
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, swClient.Service()))
@@ -2,20 +2,18 @@ package async
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
-	"connectrpc.com/connect"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"github.com/prometheus/common/model"
 	"google.golang.org/protobuf/proto"
 
-	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
 	"github.com/grafana/pyroscope/v2/pkg/tenant"
 )
@@ -25,11 +23,11 @@ type Limits interface {
 }
 
 // queryResult is the result of a query execution sent over a channel.
-// On success, Response carries the raw SelectMergeStacktracesResponse
-// from the wrapped handler (its Async field is unset); the
-// coordinator/store own request_id and status.
+// On success, Response carries the raw response from the wrapped handler
+// (its Async field is unset); the coordinator/store own request_id and
+// status.
 type queryResult struct {
-	Response *querierv1.SelectMergeStacktracesResponse
+	Response proto.Message
 	Err      error
 }
 
@@ -40,7 +38,8 @@ type Coordinator struct {
 	next   querierv1connect.QuerierServiceHandler
 
 	mu       sync.Mutex
-	inFlight map[string]int // tenantID -> count
+	inFlight map[string]int                // tenantID -> count
+	running  map[string]context.CancelFunc // requestID -> cancel
 
 	asyncQueriesCurrent *prometheus.GaugeVec
 	asyncQueriesMax     *prometheus.GaugeVec
@@ -53,6 +52,7 @@ func NewCoordinator(logger log.Logger, store *Store, limits Limits, next querier
 		limits:   limits,
 		next:     next,
 		inFlight: make(map[string]int),
+		running:  make(map[string]context.CancelFunc),
 		asyncQueriesCurrent: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
 			Name: "pyroscope_async_queries_in_progress",
 			Help: "Number of async queries currently in progress.",
@@ -86,14 +86,18 @@ func (c *Coordinator) tryAcquire(tenantID string) error {
 // Submit reserves the tenant's concurrency slot, strips the Async marker
 // from req, persists the resulting spec as a new in-progress query, and
 // dispatches it in the background. Returns the assigned request ID.
-func (c *Coordinator) Submit(ctx context.Context, tenantID string, req *querierv1.SelectMergeStacktracesRequest) (string, error) {
+func (c *Coordinator) Submit(ctx context.Context, tenantID string, req proto.Message) (string, error) {
+	queryType, err := queryTypeOf(req)
+	if err != nil {
+		return "", err
+	}
 	if err := c.tryAcquire(tenantID); err != nil {
 		return "", err
 	}
 
 	requestID := uuid.New().String()
-	spec := proto.Clone(req).(*querierv1.SelectMergeStacktracesRequest)
-	spec.Async = nil
+	spec := proto.Clone(req)
+	clearAsync(spec)
 
 	if err := c.store.create(ctx, tenantID, requestID, spec); err != nil {
 		c.decrement(tenantID)
@@ -102,15 +106,11 @@ func (c *Coordinator) Submit(ctx context.Context, tenantID string, req *querierv
 
 	// Logged before dispatch so a request's lines always start with its
 	// submission: a fast query can otherwise record its completion first.
-	level.Info(c.logger).Log("msg", "async query submitted",
+	level.Info(c.logger).Log(append([]any{"msg", "async query submitted",
 		"tenant", tenantID,
 		"request_id", requestID,
-		"profile_type", spec.GetProfileTypeID(),
-		"label_selector", spec.GetLabelSelector(),
-		"start", model.Time(spec.GetStart()).Time().String(),
-		"end", model.Time(spec.GetEnd()).Time().String(),
-		"query_window", model.Time(spec.GetEnd()).Sub(model.Time(spec.GetStart())).String(),
-	)
+		"query_type", queryType,
+	}, queryParams(spec)...)...)
 	c.dispatch(tenantID, requestID, spec)
 	return requestID, nil
 }
@@ -129,7 +129,7 @@ func (c *Coordinator) HasCapacity(tenantID string) bool {
 // at its concurrency limit) never rolls back the store's claim on the
 // record; it is simply retried by a later adoption scan once the lease
 // expires again.
-func (c *Coordinator) Dispatch(tenantID, requestID string, spec *querierv1.SelectMergeStacktracesRequest) {
+func (c *Coordinator) Dispatch(tenantID, requestID string, spec proto.Message) {
 	if err := c.tryAcquire(tenantID); err != nil {
 		level.Warn(c.logger).Log("msg", "skipping async query adoption: concurrency limit reached", "tenant", tenantID, "request_id", requestID, "err", err)
 		return
@@ -141,31 +141,38 @@ func (c *Coordinator) Dispatch(tenantID, requestID string, spec *querierv1.Selec
 // caller's context, so the query survives client disconnects and outlives
 // the scan tick that triggered an adoption. Submit and Dispatch both share
 // this path once their concurrency slot is reserved.
-func (c *Coordinator) dispatch(tenantID, requestID string, spec *querierv1.SelectMergeStacktracesRequest) {
-	queryCtx := tenant.InjectTenantID(context.Background(), tenantID)
+func (c *Coordinator) dispatch(tenantID, requestID string, spec proto.Message) {
+	queryCtx, cancel := context.WithCancel(tenant.InjectTenantID(context.Background(), tenantID))
 	resultCh := make(chan queryResult, 1)
 	started := time.Now()
 
+	c.mu.Lock()
+	c.running[requestID] = cancel
+	c.mu.Unlock()
+
 	// query goroutine: runs the spec end-to-end.
 	go func() {
-		resp, err := c.next.SelectMergeStacktraces(queryCtx, connect.NewRequest(spec))
+		queryType, err := queryTypeOf(spec)
 		if err != nil {
 			resultCh <- queryResult{Err: err}
 			return
 		}
-		resultCh <- queryResult{Response: resp.Msg}
+		resp, err := queryKinds[queryType].execute(queryCtx, c.next, spec)
+		resultCh <- queryResult{Response: resp, Err: err}
 	}()
 
 	// supervisor goroutine: renews the lease and records the outcome.
-	go c.awaitResult(tenantID, requestID, resultCh, started)
+	go c.awaitResult(queryCtx, tenantID, requestID, resultCh, started)
 }
 
 // awaitResult records the outcome of one execution attempt. started marks the
 // beginning of that attempt, not of the request: an adopted query is dispatched
 // afresh, so its duration excludes the dead owner's time. The client-observed
-// latency is the poll path's age field.
-func (c *Coordinator) awaitResult(tenantID, requestID string, resultCh <-chan queryResult, started time.Time) {
+// latency is the poll path's age field. queryCtx is only canceled before the
+// query returns if the query has been canceled.
+func (c *Coordinator) awaitResult(queryCtx context.Context, tenantID, requestID string, resultCh <-chan queryResult, started time.Time) {
 	defer c.decrement(tenantID)
+	defer c.stop(requestID)
 
 	ctx := tenant.InjectTenantID(context.Background(), tenantID)
 
@@ -176,6 +183,10 @@ func (c *Coordinator) awaitResult(tenantID, requestID string, resultCh <-chan qu
 		select {
 		case res := <-resultCh:
 			duration := time.Since(started).Round(time.Millisecond)
+			if res.Err != nil && queryCtx.Err() != nil {
+				level.Info(c.logger).Log("msg", "async query canceled", "tenant", tenantID, "request_id", requestID, "duration", duration)
+				return
+			}
 			if res.Err != nil {
 				level.Error(c.logger).Log("msg", "async query failed", "tenant", tenantID, "request_id", requestID, "duration", duration, "err", res.Err)
 				if storeErr := c.store.fail(ctx, tenantID, requestID, res.Err); storeErr != nil {
@@ -190,7 +201,13 @@ func (c *Coordinator) awaitResult(tenantID, requestID string, resultCh <-chan qu
 			}
 			return
 		case <-ticker.C:
-			if err := c.store.heartbeat(ctx, tenantID, requestID); err != nil {
+			err := c.store.heartbeat(ctx, tenantID, requestID)
+			switch {
+			case errors.Is(err, errQueryCanceled):
+				// Possibly canceled through another frontend: keep waiting
+				// for the query goroutine to exit.
+				c.stop(requestID)
+			case err != nil:
 				level.Warn(c.logger).Log("msg", "failed to update heartbeat", "tenant", tenantID, "request_id", requestID, "err", err)
 			}
 		}
@@ -207,8 +224,38 @@ func (c *Coordinator) decrement(tenantID string) {
 	c.mu.Unlock()
 }
 
+// stop cancels the execution of the query, if it runs on this frontend.
+func (c *Coordinator) stop(requestID string) {
+	c.mu.Lock()
+	cancel, ok := c.running[requestID]
+	delete(c.running, requestID)
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
 // PollQuery checks the status of an async query for the given tenant.
 // Returns nil if the request is not found (caller should return NotFound).
 func (c *Coordinator) PollQuery(ctx context.Context, tenantID, requestID string) (*Result, error) {
 	return c.store.get(ctx, tenantID, requestID)
 }
+
+// CancelQuery cancels an in-progress async query of the given tenant. The
+// execution is stopped right away if it runs on this frontend, and by its
+// owner's next heartbeat otherwise. Returns nil if the request is not found.
+func (c *Coordinator) CancelQuery(ctx context.Context, tenantID, requestID string) (*Metadata, error) {
+	meta, err := c.store.cancel(ctx, tenantID, requestID)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	if meta.Status == StatusCanceled {
+		c.stop(requestID)
+	}
+	return meta, nil
+}
+
+// ListQueries returns the async queries of the given tenant.
+func (c *Coordinator) ListQueries(ctx context.Context, tenantID string) ([]Metadata, error) {
+	return c.store.list(ctx, tenantID)
+}
@@ -12,15 +12,18 @@ import (
 	"github.com/thanos-io/objstore"
 	"google.golang.org/protobuf/proto"
 
+	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 )
 
 // fakeQuerierHandler is a QuerierServiceHandler that records every
 // SelectMergeStacktraces call it receives and returns a canned response or
-// error. If block is set, calls wait for it to close before returning,
-// letting tests hold a dispatched query open to exercise concurrency
-// limits.
+// error. If block is set, calls wait for it to close (or for the query to
+// be canceled) before returning, letting tests hold a dispatched query open
+// to exercise concurrency limits. SelectSeries and SelectMergeProfile
+// return fixed responses.
 type fakeQuerierHandler struct {
 	querierv1connect.UnimplementedQuerierServiceHandler
 
@@ -33,7 +36,7 @@ type fakeQuerierHandler struct {
 }
 
 func (f *fakeQuerierHandler) SelectMergeStacktraces(
-	_ context.Context,
+	ctx context.Context,
 	req *connect.Request[querierv1.SelectMergeStacktracesRequest],
 ) (*connect.Response[querierv1.SelectMergeStacktracesResponse], error) {
 	f.mu.Lock()
@@ -41,7 +44,11 @@ func (f *fakeQuerierHandler) SelectMergeStacktraces(
 	f.mu.Unlock()
 
 	if f.block != nil {
-		<-f.block
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
 	if f.err != nil {
 		return nil, f.err
@@ -49,6 +56,22 @@ func (f *fakeQuerierHandler) SelectMergeStacktraces(
 	return connect.NewResponse(f.resp), nil
 }
 
+func (f *fakeQuerierHandler) SelectSeries(
+	context.Context,
+	*connect.Request[querierv1.SelectSeriesRequest],
+) (*connect.Response[querierv1.SelectSeriesResponse], error) {
+	return connect.NewResponse(&querierv1.SelectSeriesResponse{
+		Series: []*typesv1.Series{{Points: []*typesv1.Point{{Timestamp: 1, Value: 2}}}},
+	}), nil
+}
+
+func (f *fakeQuerierHandler) SelectMergeProfile(
+	context.Context,
+	*connect.Request[querierv1.SelectMergeProfileRequest],
+) (*connect.Response[profilev1.Profile], error) {
+	return connect.NewResponse(&profilev1.Profile{Sample: []*profilev1.Sample{{Value: []int64{1}}}}), nil
+}
+
 func (f *fakeQuerierHandler) callsSnapshot() []*querierv1.SelectMergeStacktracesRequest {
 	f.mu.Lock()
 	defer f.mu.Unlock()
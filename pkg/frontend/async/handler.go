@@ -3,20 +3,35 @@ package async
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"connectrpc.com/connect"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/tenant"
+	"google.golang.org/protobuf/proto"
 
+	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
 )
 
+// SelectMergeProfile responds with a pprof profile, which has no room for
+// the async query metadata: it is returned in these headers instead.
+const (
+	HeaderAsyncRequestID = "Pyroscope-Async-Request-Id"
+	HeaderAsyncStatus    = "Pyroscope-Async-Status"
+	HeaderAsyncError     = "Pyroscope-Async-Error"
+)
+
+var _ querierv1connect.AsyncQueryServiceHandler = (*Handler)(nil)
+
 // Handler decorates a QuerierServiceHandler with async query support for
-// SelectMergeStacktraces. All other RPCs pass through to the embedded
-// handler unchanged.
+// SelectMergeStacktraces, SelectMergeProfile, SelectSeries, SelectHeatmap,
+// Diff and SelectMergeSpanProfile. All other RPCs pass through to the
+// embedded handler unchanged. Handler also implements the AsyncQueryService.
 type Handler struct {
 	querierv1connect.QuerierServiceHandler
 	logger      log.Logger
@@ -40,36 +55,177 @@ func NewHandler(logger log.Logger, next querierv1connect.QuerierServiceHandler,
 //   - Async.Type == FORCE with empty RequestId: dispatch in the
 //     background and return a response carrying only the Async
 //     metadata in IN_PROGRESS.
+//
+// The other async-capable RPCs behave the same way.
 func (h *Handler) SelectMergeStacktraces(
 	ctx context.Context,
 	req *connect.Request[querierv1.SelectMergeStacktracesRequest],
 ) (*connect.Response[querierv1.SelectMergeStacktracesResponse], error) {
-	async := req.Msg.GetAsync()
-	if async == nil || async.GetType() == querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_DISABLED {
-		return h.QuerierServiceHandler.SelectMergeStacktraces(ctx, req)
+	return handle(ctx, h, req, req.Msg.GetAsync(), h.QuerierServiceHandler.SelectMergeStacktraces,
+		func(resp *connect.Response[querierv1.SelectMergeStacktracesResponse], async *querierv1.AsyncQueryResponse) {
+			resp.Msg.Async = async
+		})
+}
+
+func (h *Handler) SelectMergeProfile(
+	ctx context.Context,
+	req *connect.Request[querierv1.SelectMergeProfileRequest],
+) (*connect.Response[profilev1.Profile], error) {
+	return handle(ctx, h, req, req.Msg.GetAsync(), h.QuerierServiceHandler.SelectMergeProfile,
+		func(resp *connect.Response[profilev1.Profile], async *querierv1.AsyncQueryResponse) {
+			resp.Header().Set(HeaderAsyncRequestID, async.RequestId)
+			resp.Header().Set(HeaderAsyncStatus, async.Status.String())
+			if async.ErrorMessage != "" {
+				resp.Header().Set(HeaderAsyncError, strconv.Quote(async.ErrorMessage))
+			}
+		})
+}
+
+func (h *Handler) SelectSeries(
+	ctx context.Context,
+	req *connect.Request[querierv1.SelectSeriesRequest],
+) (*connect.Response[querierv1.SelectSeriesResponse], error) {
+	return handle(ctx, h, req, req.Msg.GetAsync(), h.QuerierServiceHandler.SelectSeries,
+		func(resp *connect.Response[querierv1.SelectSeriesResponse], async *querierv1.AsyncQueryResponse) {
+			resp.Msg.Async = async
+		})
+}
+
+func (h *Handler) SelectHeatmap(
+	ctx context.Context,
+	req *connect.Request[querierv1.SelectHeatmapRequest],
+) (*connect.Response[querierv1.SelectHeatmapResponse], error) {
+	return handle(ctx, h, req, req.Msg.GetAsync(), h.QuerierServiceHandler.SelectHeatmap,
+		func(resp *connect.Response[querierv1.SelectHeatmapResponse], async *querierv1.AsyncQueryResponse) {
+			resp.Msg.Async = async
+		})
+}
+
+func (h *Handler) Diff(
+	ctx context.Context,
+	req *connect.Request[querierv1.DiffRequest],
+) (*connect.Response[querierv1.DiffResponse], error) {
+	return handle(ctx, h, req, req.Msg.GetAsync(), h.QuerierServiceHandler.Diff,
+		func(resp *connect.Response[querierv1.DiffResponse], async *querierv1.AsyncQueryResponse) {
+			resp.Msg.Async = async
+		})
+}
+
+func (h *Handler) SelectMergeSpanProfile(
+	ctx context.Context,
+	req *connect.Request[querierv1.SelectMergeSpanProfileRequest],
+) (*connect.Response[querierv1.SelectMergeSpanProfileResponse], error) {
+	return handle(ctx, h, req, req.Msg.GetAsync(), h.QuerierServiceHandler.SelectMergeSpanProfile,
+		func(resp *connect.Response[querierv1.SelectMergeSpanProfileResponse], async *querierv1.AsyncQueryResponse) {
+			resp.Msg.Async = async
+		})
+}
+
+func (h *Handler) ListAsyncQueries(
+	ctx context.Context,
+	_ *connect.Request[querierv1.ListAsyncQueriesRequest],
+) (*connect.Response[querierv1.ListAsyncQueriesResponse], error) {
+	tenantID, err := h.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := h.coordinator.ListQueries(ctx, tenantID)
+	if err != nil {
+		level.Warn(h.logger).Log("msg", "failed to list async queries", "tenant", tenantID, "err", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	resp := &querierv1.ListAsyncQueriesResponse{
+		Queries: make([]*querierv1.AsyncQueryInfo, 0, len(queries)),
 	}
+	for _, meta := range queries {
+		resp.Queries = append(resp.Queries, &querierv1.AsyncQueryInfo{
+			RequestId:    meta.RequestID,
+			QueryType:    string(meta.queryType()),
+			Status:       statusToProto(meta.Status),
+			ErrorMessage: meta.ErrorMessage,
+			CreatedAt:    meta.CreatedAt.UnixMilli(),
+		})
+	}
+	return connect.NewResponse(resp), nil
+}
 
-	if h.coordinator == nil {
-		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("async queries are disabled (set -query-frontend.async-queries-enabled=true)"))
+func (h *Handler) CancelAsyncQuery(
+	ctx context.Context,
+	req *connect.Request[querierv1.CancelAsyncQueryRequest],
+) (*connect.Response[querierv1.CancelAsyncQueryResponse], error) {
+	tenantID, err := h.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	requestID := req.Msg.GetRequestId()
+	logger := log.With(h.logger, "tenant", tenantID, "request_id", requestID)
+
+	meta, err := h.coordinator.CancelQuery(ctx, tenantID, requestID)
+	if errors.Is(err, errInvalidRequestID) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err != nil {
+		level.Warn(logger).Log("msg", "async query cancellation failed", "err", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
 	}
+	if meta == nil {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("async query not found"))
+	}
+	level.Info(logger).Log("msg", "async query cancellation requested", "status", string(meta.Status))
+	return connect.NewResponse(&querierv1.CancelAsyncQueryResponse{
+		Status: statusToProto(meta.Status),
+	}), nil
+}
 
+func (h *Handler) tenantID(ctx context.Context) (string, error) {
+	if h.coordinator == nil {
+		return "", connect.NewError(connect.CodeUnimplemented, errors.New("async queries are disabled (set -query-frontend.async-queries-enabled=true)"))
+	}
 	tenantIDs, err := tenant.TenantIDs(ctx)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return "", connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return tenant.JoinTenantIDs(tenantIDs), nil
+}
+
+// handle implements the async lifecycle for a single RPC: next runs the
+// query synchronously, and setAsync attaches the async metadata to the
+// response.
+func handle[Req, Resp any](
+	ctx context.Context,
+	h *Handler,
+	req *connect.Request[Req],
+	async *querierv1.AsyncQueryRequest,
+	next func(context.Context, *connect.Request[Req]) (*connect.Response[Resp], error),
+	setAsync func(*connect.Response[Resp], *querierv1.AsyncQueryResponse),
+) (*connect.Response[Resp], error) {
+	if async == nil || async.GetType() == querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_DISABLED {
+		return next(ctx, req)
+	}
+
+	tenantID, err := h.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spec := any(req.Msg).(proto.Message)
+	queryType, err := queryTypeOf(spec)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, err)
 	}
-	tenantID := tenant.JoinTenantIDs(tenantIDs)
 
 	if async.GetRequestId() != "" {
-		return h.poll(ctx, tenantID, async.GetRequestId())
+		return poll(ctx, h, tenantID, queryType, async.GetRequestId(), setAsync)
 	}
-	return h.submit(ctx, tenantID, req.Msg)
+	return submit(ctx, h, tenantID, spec, setAsync)
 }
 
-func (h *Handler) submit(
+func submit[Resp any](
 	ctx context.Context,
+	h *Handler,
 	tenantID string,
-	req *querierv1.SelectMergeStacktracesRequest,
-) (*connect.Response[querierv1.SelectMergeStacktracesResponse], error) {
+	req proto.Message,
+	setAsync func(*connect.Response[Resp], *querierv1.AsyncQueryResponse),
+) (*connect.Response[Resp], error) {
 	requestID, err := h.coordinator.Submit(ctx, tenantID, req)
 	if err != nil {
 		// No request ID exists yet, so this line is only findable by tenant:
@@ -77,19 +233,22 @@ func (h *Handler) submit(
 		level.Warn(h.logger).Log("msg", "async query submission rejected", "tenant", tenantID, "err", err)
 		return nil, connect.NewError(connect.CodeResourceExhausted, err)
 	}
-	return connect.NewResponse(&querierv1.SelectMergeStacktracesResponse{
-		Async: &querierv1.AsyncQueryResponse{
-			RequestId: requestID,
-			Status:    querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_IN_PROGRESS,
-		},
-	}), nil
+	resp := connect.NewResponse(new(Resp))
+	setAsync(resp, &querierv1.AsyncQueryResponse{
+		RequestId: requestID,
+		Status:    querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_IN_PROGRESS,
+	})
+	return resp, nil
 }
 
-func (h *Handler) poll(
+func poll[Resp any](
 	ctx context.Context,
+	h *Handler,
 	tenantID string,
+	queryType QueryType,
 	requestID string,
-) (*connect.Response[querierv1.SelectMergeStacktracesResponse], error) {
+	setAsync func(*connect.Response[Resp], *querierv1.AsyncQueryResponse),
+) (*connect.Response[Resp], error) {
 	logger := log.With(h.logger, "tenant", tenantID, "request_id", requestID)
 
 	result, err := h.coordinator.PollQuery(ctx, tenantID, requestID)
@@ -103,25 +262,24 @@ func (h *Handler) poll(
 		level.Warn(logger).Log("msg", "async query polled but not found")
 		return nil, connect.NewError(connect.CodeNotFound, errors.New("async query not found"))
 	}
+	if t := result.Metadata.queryType(); t != queryType {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("async query %s is a %s query", requestID, t))
+	}
 
-	resp := &querierv1.SelectMergeStacktracesResponse{
-		Async: &querierv1.AsyncQueryResponse{RequestId: requestID},
+	async := &querierv1.AsyncQueryResponse{
+		RequestId: requestID,
+		Status:    statusToProto(result.Metadata.Status),
 	}
+	resp := connect.NewResponse(new(Resp))
 	switch result.Metadata.Status {
-	case StatusInProgress:
-		resp.Async.Status = querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_IN_PROGRESS
 	case StatusSuccess:
-		resp.Async.Status = querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_SUCCESS
 		if result.Response != nil {
-			resp.Flamegraph = result.Response.Flamegraph
-			resp.Tree = result.Response.Tree
-			resp.Dot = result.Response.Dot
-			resp.Pprof = result.Response.Pprof
+			resp.Msg = any(result.Response).(*Resp)
 		}
-	case StatusFailure:
-		resp.Async.Status = querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_FAILURE
-		resp.Async.ErrorMessage = result.Metadata.ErrorMessage
+	case StatusFailure, StatusCanceled:
+		async.ErrorMessage = result.Metadata.ErrorMessage
 	}
+	setAsync(resp, async)
 
 	logger = log.With(logger,
 		"status", string(result.Metadata.Status),
@@ -135,5 +293,19 @@ func (h *Handler) poll(
 	default:
 		level.Info(logger).Log("msg", "async query result returned to client")
 	}
-	return connect.NewResponse(resp), nil
+	return resp, nil
+}
+
+func statusToProto(status Status) querierv1.AsyncQueryStatus {
+	switch status {
+	case StatusInProgress:
+		return querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_IN_PROGRESS
+	case StatusSuccess:
+		return querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_SUCCESS
+	case StatusFailure:
+		return querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_FAILURE
+	case StatusCanceled:
+		return querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_CANCELED
+	}
+	return querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_UNKNOWN
 }
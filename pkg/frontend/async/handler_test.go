@@ -3,6 +3,7 @@ package async
 import (
 	"context"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/go-kit/log"
@@ -12,8 +13,18 @@ import (
 
 	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/grafana/pyroscope/v2/pkg/tenant"
 )
 
+func pollRequest(requestID string) *connect.Request[querierv1.SelectMergeStacktracesRequest] {
+	return connect.NewRequest(&querierv1.SelectMergeStacktracesRequest{
+		Async: &querierv1.AsyncQueryRequest{
+			RequestId: requestID,
+			Type:      querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_FORCE,
+		},
+	})
+}
+
 func TestHandlerPollCopiesPprofResponse(t *testing.T) {
 	ctx := context.Background()
 	store := NewStore(log.NewNopLogger(), objstore.NewInMemBucket(), nil)
@@ -27,8 +38,8 @@ func TestHandlerPollCopiesPprofResponse(t *testing.T) {
 		Pprof: &querierv1.PprofProfile{Profile: want},
 	}))
 
-	handler := &Handler{logger: log.NewNopLogger(), coordinator: &Coordinator{store: store}}
-	resp, err := handler.poll(ctx, tenantID, requestID)
+	handler := NewHandler(log.NewNopLogger(), &fakeQuerierHandler{}, &Coordinator{store: store})
+	resp, err := handler.SelectMergeStacktraces(tenant.InjectTenantID(ctx, tenantID), pollRequest(requestID))
 
 	require.NoError(t, err)
 	require.Equal(t, querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_SUCCESS, resp.Msg.GetAsync().GetStatus())
@@ -45,9 +56,120 @@ func TestHandlerPollTenantIsolation(t *testing.T) {
 	)
 	require.NoError(t, store.create(ctx, tenantA, requestID, &querierv1.SelectMergeStacktracesRequest{}))
 
-	handler := &Handler{logger: log.NewNopLogger(), coordinator: &Coordinator{store: store}}
-	_, err := handler.poll(ctx, tenantB, requestID)
+	handler := NewHandler(log.NewNopLogger(), &fakeQuerierHandler{}, &Coordinator{store: store})
+	_, err := handler.SelectMergeStacktraces(tenant.InjectTenantID(ctx, tenantB), pollRequest(requestID))
 
 	require.Error(t, err)
 	require.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
 }
+
+func TestHandler_SelectSeriesSubmitAndPoll(t *testing.T) {
+	ctx := tenant.InjectTenantID(context.Background(), "tenant-a")
+	logger := log.NewNopLogger()
+	store := NewStore(logger, objstore.NewInMemBucket(), nil)
+	next := &fakeQuerierHandler{}
+	handler := NewHandler(logger, next, NewCoordinator(logger, store, unlimitedLimits{}, next, nil))
+
+	submitted, err := handler.SelectSeries(ctx, connect.NewRequest(&querierv1.SelectSeriesRequest{
+		Async: &querierv1.AsyncQueryRequest{Type: querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_FORCE},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_IN_PROGRESS, submitted.Msg.GetAsync().GetStatus())
+	require.Empty(t, submitted.Msg.GetSeries())
+	requestID := submitted.Msg.GetAsync().GetRequestId()
+
+	var resp *connect.Response[querierv1.SelectSeriesResponse]
+	require.Eventually(t, func() bool {
+		resp, err = handler.SelectSeries(ctx, connect.NewRequest(&querierv1.SelectSeriesRequest{
+			Async: &querierv1.AsyncQueryRequest{RequestId: requestID, Type: querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_FORCE},
+		}))
+		return err == nil && resp.Msg.GetAsync().GetStatus() == querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_SUCCESS
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, requestID, resp.Msg.GetAsync().GetRequestId())
+	require.Len(t, resp.Msg.GetSeries(), 1)
+
+	// The request ID can't be polled through another RPC.
+	_, err = handler.SelectMergeStacktraces(ctx, pollRequest(requestID))
+	require.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestHandler_SelectMergeProfileAsyncHeaders(t *testing.T) {
+	ctx := tenant.InjectTenantID(context.Background(), "tenant-a")
+	logger := log.NewNopLogger()
+	store := NewStore(logger, objstore.NewInMemBucket(), nil)
+	next := &fakeQuerierHandler{}
+	handler := NewHandler(logger, next, NewCoordinator(logger, store, unlimitedLimits{}, next, nil))
+
+	submitted, err := handler.SelectMergeProfile(ctx, connect.NewRequest(&querierv1.SelectMergeProfileRequest{
+		Async: &querierv1.AsyncQueryRequest{Type: querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_FORCE},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "ASYNC_QUERY_STATUS_IN_PROGRESS", submitted.Header().Get(HeaderAsyncStatus))
+	requestID := submitted.Header().Get(HeaderAsyncRequestID)
+	require.NotEmpty(t, requestID)
+
+	var resp *connect.Response[profilev1.Profile]
+	require.Eventually(t, func() bool {
+		resp, err = handler.SelectMergeProfile(ctx, connect.NewRequest(&querierv1.SelectMergeProfileRequest{
+			Async: &querierv1.AsyncQueryRequest{RequestId: requestID, Type: querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_FORCE},
+		}))
+		return err == nil && resp.Header().Get(HeaderAsyncStatus) == "ASYNC_QUERY_STATUS_SUCCESS"
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, resp.Msg.GetSample(), 1)
+}
+
+func TestHandler_CancelAndList(t *testing.T) {
+	ctx := tenant.InjectTenantID(context.Background(), "tenant-a")
+	logger := log.NewNopLogger()
+	store := NewStore(logger, objstore.NewInMemBucket(), nil)
+	block := make(chan struct{})
+	defer close(block)
+	next := &fakeQuerierHandler{resp: &querierv1.SelectMergeStacktracesResponse{}, block: block}
+	coordinator := NewCoordinator(logger, store, fixedLimits{max: 1}, next, nil)
+	handler := NewHandler(logger, next, coordinator)
+
+	submit := func() string {
+		resp, err := handler.SelectMergeStacktraces(ctx, connect.NewRequest(&querierv1.SelectMergeStacktracesRequest{
+			Async: &querierv1.AsyncQueryRequest{Type: querierv1.AsyncQueryType_ASYNC_QUERY_TYPE_FORCE},
+		}))
+		require.NoError(t, err)
+		return resp.Msg.GetAsync().GetRequestId()
+	}
+	first := submit()
+
+	canceled, err := handler.CancelAsyncQuery(ctx, connect.NewRequest(&querierv1.CancelAsyncQueryRequest{RequestId: first}))
+	require.NoError(t, err)
+	require.Equal(t, querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_CANCELED, canceled.Msg.GetStatus())
+
+	// The canceled query releases its concurrency slot.
+	require.Eventually(t, func() bool { return coordinator.HasCapacity("tenant-a") }, time.Second, 10*time.Millisecond)
+	polled, err := handler.SelectMergeStacktraces(ctx, pollRequest(first))
+	require.NoError(t, err)
+	require.Equal(t, querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_CANCELED, polled.Msg.GetAsync().GetStatus())
+
+	_, err = handler.CancelAsyncQuery(ctx, connect.NewRequest(&querierv1.CancelAsyncQueryRequest{RequestId: "not-a-uuid"}))
+	require.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	second := submit()
+	_, err = handler.CancelAsyncQuery(tenant.InjectTenantID(context.Background(), "tenant-b"),
+		connect.NewRequest(&querierv1.CancelAsyncQueryRequest{RequestId: second}))
+	require.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	listed, err := handler.ListAsyncQueries(ctx, connect.NewRequest(&querierv1.ListAsyncQueriesRequest{}))
+	require.NoError(t, err)
+	require.Len(t, listed.Msg.GetQueries(), 2)
+	statuses := map[string]querierv1.AsyncQueryStatus{}
+	for _, q := range listed.Msg.GetQueries() {
+		require.Equal(t, string(QuerySelectMergeStacktraces), q.GetQueryType())
+		statuses[q.GetRequestId()] = q.GetStatus()
+	}
+	require.Equal(t, map[string]querierv1.AsyncQueryStatus{
+		first:  querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_CANCELED,
+		second: querierv1.AsyncQueryStatus_ASYNC_QUERY_STATUS_IN_PROGRESS,
+	}, statuses)
+
+	listed, err = handler.ListAsyncQueries(tenant.InjectTenantID(context.Background(), "tenant-b"),
+		connect.NewRequest(&querierv1.ListAsyncQueriesRequest{}))
+	require.NoError(t, err)
+	require.Empty(t, listed.Msg.GetQueries())
+}
@@ -0,0 +1,118 @@
+package async
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
+)
+
+// QueryType identifies the QuerierService RPC an async query runs.
+type QueryType string
+
+const (
+	QuerySelectMergeStacktraces QueryType = "SelectMergeStacktraces"
+	QuerySelectMergeProfile     QueryType = "SelectMergeProfile"
+	QuerySelectSeries           QueryType = "SelectSeries"
+	QuerySelectHeatmap          QueryType = "SelectHeatmap"
+	QueryDiff                   QueryType = "Diff"
+	QuerySelectMergeSpanProfile QueryType = "SelectMergeSpanProfile"
+)
+
+// queryKind describes how the spec and the result of a query type are
+// decoded, and how the query is executed.
+type queryKind struct {
+	newSpec   func() proto.Message
+	newResult func() proto.Message
+	execute   func(context.Context, querierv1connect.QuerierServiceHandler, proto.Message) (proto.Message, error)
+}
+
+var queryKinds = map[QueryType]queryKind{
+	QuerySelectMergeStacktraces: newQueryKind(querierv1connect.QuerierServiceHandler.SelectMergeStacktraces),
+	QuerySelectMergeProfile:     newQueryKind(querierv1connect.QuerierServiceHandler.SelectMergeProfile),
+	QuerySelectSeries:           newQueryKind(querierv1connect.QuerierServiceHandler.SelectSeries),
+	QuerySelectHeatmap:          newQueryKind(querierv1connect.QuerierServiceHandler.SelectHeatmap),
+	QueryDiff:                   newQueryKind(querierv1connect.QuerierServiceHandler.Diff),
+	QuerySelectMergeSpanProfile: newQueryKind(querierv1connect.QuerierServiceHandler.SelectMergeSpanProfile),
+}
+
+func newQueryKind[
+	Req, Resp any,
+	ReqP interface {
+		*Req
+		proto.Message
+	},
+	RespP interface {
+		*Resp
+		proto.Message
+	},
+](
+	call func(querierv1connect.QuerierServiceHandler, context.Context, *connect.Request[Req]) (*connect.Response[Resp], error),
+) queryKind {
+	return queryKind{
+		newSpec:   func() proto.Message { return ReqP(new(Req)) },
+		newResult: func() proto.Message { return RespP(new(Resp)) },
+		execute: func(ctx context.Context, next querierv1connect.QuerierServiceHandler, spec proto.Message) (proto.Message, error) {
+			resp, err := call(next, ctx, connect.NewRequest((*Req)(spec.(ReqP))))
+			if err != nil {
+				return nil, err
+			}
+			return RespP(resp.Msg), nil
+		},
+	}
+}
+
+func queryTypeOf(spec proto.Message) (QueryType, error) {
+	switch spec.(type) {
+	case *querierv1.SelectMergeStacktracesRequest:
+		return QuerySelectMergeStacktraces, nil
+	case *querierv1.SelectMergeProfileRequest:
+		return QuerySelectMergeProfile, nil
+	case *querierv1.SelectSeriesRequest:
+		return QuerySelectSeries, nil
+	case *querierv1.SelectHeatmapRequest:
+		return QuerySelectHeatmap, nil
+	case *querierv1.DiffRequest:
+		return QueryDiff, nil
+	case *querierv1.SelectMergeSpanProfileRequest:
+		return QuerySelectMergeSpanProfile, nil
+	}
+	return "", fmt.Errorf("async queries are not supported for %T", spec)
+}
+
+// clearAsync removes the async field from the request: every request type
+// that supports async queries has one.
+func clearAsync(spec proto.Message) {
+	m := spec.ProtoReflect()
+	if fd := m.Descriptor().Fields().ByName("async"); fd != nil {
+		m.Clear(fd)
+	}
+}
+
+// queryParams returns the log fields describing the query.
+func queryParams(spec proto.Message) []any {
+	if diff, ok := spec.(*querierv1.DiffRequest); ok {
+		spec = diff.GetLeft()
+	}
+	q, ok := spec.(interface {
+		GetProfileTypeID() string
+		GetLabelSelector() string
+		GetStart() int64
+		GetEnd() int64
+	})
+	if !ok {
+		return nil
+	}
+	return []any{
+		"profile_type", q.GetProfileTypeID(),
+		"label_selector", q.GetLabelSelector(),
+		"start", model.Time(q.GetStart()).Time().String(),
+		"end", model.Time(q.GetEnd()).Time().String(),
+		"query_window", model.Time(q.GetEnd()).Sub(model.Time(q.GetStart())).String(),
+	}
+}
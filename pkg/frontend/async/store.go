@@ -10,6 +10,7 @@ import (
 	"math/rand/v2"
 	"os"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,8 +22,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/objstore"
 	"google.golang.org/protobuf/proto"
-
-	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
 )
 
 const (
@@ -39,6 +38,9 @@ const (
 )
 
 var (
+	// errInvalidRequestID indicates the request ID is not a UUID.
+	errInvalidRequestID = errors.New("invalid request ID")
+
 	// errSpecCorrupt indicates spec.pb exists but its contents don't decode.
 	// Unlike a transient read error, retrying can never fix this.
 	errSpecCorrupt = errors.New("spec is present but could not be decoded")
@@ -52,6 +54,10 @@ var (
 	// defaultMaxAdoptions times; adoption is refused and the query is marked
 	// failed instead of being claimed again.
 	errTooManyAdoptions = errors.New("query exceeded the maximum number of adoption attempts")
+
+	// errQueryCanceled is returned by heartbeat once the query has been
+	// canceled, telling its owner to stop executing it.
+	errQueryCanceled = errors.New("query canceled")
 )
 
 // Status represents the lifecycle state of an async query.
@@ -64,6 +70,8 @@ const (
 	StatusSuccess Status = "success"
 	// StatusFailure indicates the query failed; ErrorMessage holds the reason.
 	StatusFailure Status = "failure"
+	// StatusCanceled indicates the query was canceled before it completed.
+	StatusCanceled Status = "canceled"
 )
 
 // Metadata describes the state of a single async query. It is persisted as
@@ -77,13 +85,24 @@ type Metadata struct {
 	ErrorMessage  string    `json:"error_message,omitempty"`
 	Owner         string    `json:"owner,omitempty"`
 	AdoptionCount int       `json:"adoption_count,omitempty"`
+	// QueryType is empty in records created before queries other than
+	// SelectMergeStacktraces could run asynchronously.
+	QueryType QueryType `json:"query_type,omitempty"`
+}
+
+func (m *Metadata) queryType() QueryType {
+	if m.QueryType == "" {
+		return QuerySelectMergeStacktraces
+	}
+	return m.QueryType
 }
 
 // Result bundles a query's Metadata with its decoded Response. Response is only
-// populated when Metadata.Status is StatusSuccess.
+// populated when Metadata.Status is StatusSuccess; its type is the response
+// type of the query's RPC.
 type Result struct {
 	Metadata Metadata
-	Response *querierv1.SelectMergeStacktracesResponse
+	Response proto.Message
 }
 
 // Dispatcher re-runs a query that Store has adopted after its owner's lease
@@ -93,7 +112,7 @@ type Dispatcher interface {
 	// no guarantee that a following Dispatch call will actually run the
 	// query (Dispatch has its own gate and may still decline).
 	HasCapacity(tenantID string) bool
-	Dispatch(tenantID, requestID string, spec *querierv1.SelectMergeStacktracesRequest)
+	Dispatch(tenantID, requestID string, spec proto.Message)
 }
 
 // Store persists async query state and results in object storage. It also runs
@@ -158,7 +177,11 @@ func (s *Store) buildPath(tenantID, requestID, filename string) string {
 	return path.Join(storagePrefix, tenantID, requestID, filename)
 }
 
-func (s *Store) create(ctx context.Context, tenantID, requestID string, spec *querierv1.SelectMergeStacktracesRequest) error {
+func (s *Store) create(ctx context.Context, tenantID, requestID string, spec proto.Message) error {
+	queryType, err := queryTypeOf(spec)
+	if err != nil {
+		return err
+	}
 	data, err := proto.Marshal(spec)
 	if err != nil {
 		return fmt.Errorf("failed to marshal spec: %w", err)
@@ -179,20 +202,25 @@ func (s *Store) create(ctx context.Context, tenantID, requestID string, spec *qu
 		CreatedAt:     now,
 		LastHeartbeat: now,
 		Owner:         s.ownerID,
+		QueryType:     queryType,
 	}
 	return s.saveJSON(ctx, s.buildPath(tenantID, requestID, metadataFilename), meta)
 }
 
-func (s *Store) readSpec(ctx context.Context, tenantID, requestID string) (*querierv1.SelectMergeStacktracesRequest, error) {
+func (s *Store) readSpec(ctx context.Context, tenantID, requestID string, queryType QueryType) (proto.Message, error) {
+	kind, ok := queryKinds[queryType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown query type %q", errSpecCorrupt, queryType)
+	}
 	data, err := s.readRaw(ctx, s.buildPath(tenantID, requestID, specFilename))
 	if err != nil {
 		return nil, err
 	}
-	var spec querierv1.SelectMergeStacktracesRequest
-	if err := proto.Unmarshal(data, &spec); err != nil {
+	spec := kind.newSpec()
+	if err := proto.Unmarshal(data, spec); err != nil {
 		return nil, fmt.Errorf("%w: %v", errSpecCorrupt, err)
 	}
-	return &spec, nil
+	return spec, nil
 }
 
 // objectExists reports whether the object at path is confirmed present. Any
@@ -233,6 +261,9 @@ func (s *Store) heartbeat(ctx context.Context, tenantID, requestID string) error
 	if err := s.readJSON(ctx, metaPath, &meta); err != nil {
 		return err
 	}
+	if meta.Status == StatusCanceled {
+		return errQueryCanceled
+	}
 	if meta.Status != StatusInProgress {
 		// Already terminal: a lingering heartbeat from a still-alive duplicate
 		// execution (post-adoption) must not resurrect a completed/failed record.
@@ -249,7 +280,7 @@ func (s *Store) leaseExpired(meta *Metadata) bool {
 	return meta.Status == StatusInProgress && !meta.LastHeartbeat.IsZero() && time.Since(meta.LastHeartbeat) > s.leaseTimeout
 }
 
-func (s *Store) complete(ctx context.Context, tenantID, requestID string, resp *querierv1.SelectMergeStacktracesResponse) error {
+func (s *Store) complete(ctx context.Context, tenantID, requestID string, resp proto.Message) error {
 	data, err := proto.Marshal(resp)
 	if err != nil {
 		return fmt.Errorf("failed to marshal response: %w", err)
@@ -295,6 +326,79 @@ func (s *Store) fail(ctx context.Context, tenantID, requestID string, queryErr e
 	return s.saveJSON(ctx, metaPath, &meta)
 }
 
+// cancel marks an in-progress query as canceled. The query's owner learns
+// about it from its next heartbeat and stops the execution. Returns nil if
+// the request is not found. A query that has already completed keeps its
+// status: the returned Metadata reflects the status after the call.
+//
+// Like every other metadata write, the cancellation can be lost to a
+// concurrent heartbeat of the owner; cancellation is best-effort.
+func (s *Store) cancel(ctx context.Context, tenantID, requestID string) (*Metadata, error) {
+	if _, err := uuid.Parse(requestID); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidRequestID, err)
+	}
+	metaPath := s.buildPath(tenantID, requestID, metadataFilename)
+	var meta Metadata
+	if err := s.readJSON(ctx, metaPath, &meta); err != nil {
+		if s.bucket.IsObjNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if meta.TenantID != tenantID {
+		return nil, nil
+	}
+	if meta.Status != StatusInProgress {
+		return &meta, nil
+	}
+	// A result that already exists can't be un-written, see get().
+	exists, err := s.resultExists(ctx, tenantID, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check result existence: %w", err)
+	}
+	if exists {
+		s.healToSuccess(ctx, tenantID, requestID, meta)
+		meta.Status = StatusSuccess
+		return &meta, nil
+	}
+	meta.Status = StatusCanceled
+	meta.ErrorMessage = errQueryCanceled.Error()
+	if err := s.saveJSON(ctx, metaPath, &meta); err != nil {
+		return nil, fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// list returns the metadata of all the queries of the tenant, most recent
+// first. The statuses are the persisted ones: unlike get, list does not
+// reconcile them with the query results.
+func (s *Store) list(ctx context.Context, tenantID string) ([]Metadata, error) {
+	var queries []Metadata
+	err := s.bucket.Iter(ctx, path.Join(storagePrefix, tenantID)+"/", func(name string) error {
+		if !strings.HasSuffix(name, "/") {
+			return nil
+		}
+		var meta Metadata
+		if err := s.readJSON(ctx, path.Join(name, metadataFilename), &meta); err != nil {
+			if !s.bucket.IsObjNotFoundErr(err) {
+				level.Warn(s.logger).Log("msg", "failed to read async query metadata", "object", name, "err", err)
+			}
+			return nil
+		}
+		if meta.TenantID == tenantID {
+			queries = append(queries, meta)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list async queries: %w", err)
+	}
+	slices.SortFunc(queries, func(a, b Metadata) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return queries, nil
+}
+
 func (s *Store) markUnadoptable(ctx context.Context, tenantID, requestID string) error {
 	level.Warn(s.logger).Log("msg", "async query is unrecoverable, marking failed", "tenant", tenantID, "request_id", requestID)
 	return s.fail(ctx, tenantID, requestID, errOrphanedNoSpec)
@@ -310,16 +414,20 @@ func (s *Store) markTooManyAdoptions(ctx context.Context, tenantID, requestID st
 // already established (via meta.Status or result.pb's own existence) that
 // the query succeeded.
 func (s *Store) buildSuccessResult(ctx context.Context, tenantID, requestID string, meta Metadata) (*Result, error) {
+	kind, ok := queryKinds[meta.queryType()]
+	if !ok {
+		return nil, fmt.Errorf("unknown query type %q", meta.queryType())
+	}
 	data, err := s.readRaw(ctx, s.buildPath(tenantID, requestID, resultFilename))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read result: %w", err)
 	}
-	var resp querierv1.SelectMergeStacktracesResponse
-	if err := proto.Unmarshal(data, &resp); err != nil {
+	resp := kind.newResult()
+	if err := proto.Unmarshal(data, resp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal result: %w", err)
 	}
 	meta.Status = StatusSuccess
-	return &Result{Metadata: meta, Response: &resp}, nil
+	return &Result{Metadata: meta, Response: resp}, nil
 }
 
 // healToSuccess best-effort corrects metadata.json's Status once result.pb's
@@ -341,7 +449,7 @@ func (s *Store) healToSuccess(ctx context.Context, tenantID, requestID string, m
 
 func (s *Store) get(ctx context.Context, tenantID, requestID string) (*Result, error) {
 	if _, err := uuid.Parse(requestID); err != nil {
-		return nil, fmt.Errorf("invalid request ID: %w", err)
+		return nil, fmt.Errorf("%w: %w", errInvalidRequestID, err)
 	}
 
 	metaPath := s.buildPath(tenantID, requestID, metadataFilename)
@@ -553,7 +661,7 @@ func (s *Store) adopt(ctx context.Context, meta Metadata) {
 		return
 	}
 
-	spec, err := s.readSpec(ctx, meta.TenantID, meta.RequestID)
+	spec, err := s.readSpec(ctx, meta.TenantID, meta.RequestID, meta.queryType())
 	if err != nil {
 		if s.bucket.IsObjNotFoundErr(err) || errors.Is(err, errSpecCorrupt) {
 			if err2 := s.markUnadoptable(ctx, meta.TenantID, meta.RequestID); err2 != nil {
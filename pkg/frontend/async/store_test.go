@@ -37,7 +37,7 @@ func backdateHeartbeat(t *testing.T, ctx context.Context, store *Store, tenantID
 type fakeDispatchCall struct {
 	tenantID  string
 	requestID string
-	spec      *querierv1.SelectMergeStacktracesRequest
+	spec      proto.Message
 }
 
 // fakeDispatcher is a test double for Dispatcher. HasCapacity defaults to
@@ -52,7 +52,7 @@ type fakeDispatcher struct {
 	calls            []fakeDispatchCall
 	hasCapacityCalls int
 	atCapacity       bool // when true, HasCapacity reports no spare capacity; default (false) has capacity.
-	onDispatch       func(tenantID, requestID string, spec *querierv1.SelectMergeStacktracesRequest)
+	onDispatch       func(tenantID, requestID string, spec proto.Message)
 }
 
 func (f *fakeDispatcher) HasCapacity(tenantID string) bool {
@@ -62,7 +62,7 @@ func (f *fakeDispatcher) HasCapacity(tenantID string) bool {
 	return !f.atCapacity
 }
 
-func (f *fakeDispatcher) Dispatch(tenantID, requestID string, spec *querierv1.SelectMergeStacktracesRequest) {
+func (f *fakeDispatcher) Dispatch(tenantID, requestID string, spec proto.Message) {
 	f.mu.Lock()
 	f.calls = append(f.calls, fakeDispatchCall{tenantID: tenantID, requestID: requestID, spec: spec})
 	onDispatch := f.onDispatch
@@ -146,7 +146,7 @@ func TestStoreCreate_PersistsSpecBeforeMetadata(t *testing.T) {
 	_, err = store.bucket.Attributes(ctx, store.buildPath(tenantID, requestID, metadataFilename))
 	require.NoError(t, err)
 
-	gotSpec, err := store.readSpec(ctx, tenantID, requestID)
+	gotSpec, err := store.readSpec(ctx, tenantID, requestID, QuerySelectMergeStacktraces)
 	require.NoError(t, err)
 	require.True(t, proto.Equal(spec, gotSpec))
 
@@ -959,7 +959,7 @@ func TestStoreAdopt_ConcurrentRaceProducesSingleTerminalStatus(t *testing.T) {
 	var completeCalled atomic.Bool
 	var writesDone sync.WaitGroup
 	raceDispatcher := &fakeDispatcher{
-		onDispatch: func(_, _ string, _ *querierv1.SelectMergeStacktracesRequest) {
+		onDispatch: func(_, _ string, _ proto.Message) {
 			isFirstDispatch := dispatchCount.Inc() == 1
 			doComplete := isFirstDispatch == successWritesFirst
 			delay := time.Millisecond
@@ -1006,3 +1006,46 @@ func TestStoreAdopt_ConcurrentRaceProducesSingleTerminalStatus(t *testing.T) {
 	require.Equal(t, wantStatus, result.Metadata.Status,
 		"a real success must be reported regardless of write ordering")
 }
+
+func TestStoreHeartbeat_ReportsCancellation(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(log.NewNopLogger(), objstore.NewInMemBucket(), nil)
+	const tenantID = "tenant-a"
+	requestID := uuid.New().String()
+	require.NoError(t, store.create(ctx, tenantID, requestID, &querierv1.SelectSeriesRequest{}))
+
+	meta, err := store.cancel(ctx, tenantID, requestID)
+	require.NoError(t, err)
+	require.Equal(t, StatusCanceled, meta.Status)
+	require.ErrorIs(t, store.heartbeat(ctx, tenantID, requestID), errQueryCanceled)
+
+	// A canceled query is never adopted.
+	backdateHeartbeat(t, ctx, store, tenantID, requestID, time.Now().Add(-time.Hour))
+	dispatcher := &fakeDispatcher{}
+	store.SetDispatcher(dispatcher)
+	store.runAdoption(ctx)
+	require.Empty(t, dispatcher.calls)
+
+	result, err := store.get(ctx, tenantID, requestID)
+	require.NoError(t, err)
+	require.Equal(t, StatusCanceled, result.Metadata.Status)
+	require.Equal(t, QuerySelectSeries, result.Metadata.QueryType)
+}
+
+func TestStoreCancel_CompletedQueryKeepsStatus(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(log.NewNopLogger(), objstore.NewInMemBucket(), nil)
+	const tenantID = "tenant-a"
+	requestID := uuid.New().String()
+	require.NoError(t, store.create(ctx, tenantID, requestID, &querierv1.DiffRequest{}))
+	want := &querierv1.DiffResponse{Flamegraph: &querierv1.FlameGraphDiff{Total: 1}}
+	require.NoError(t, store.complete(ctx, tenantID, requestID, want))
+
+	meta, err := store.cancel(ctx, tenantID, requestID)
+	require.NoError(t, err)
+	require.Equal(t, StatusSuccess, meta.Status)
+
+	result, err := store.get(ctx, tenantID, requestID)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(want, result.Response))
+}
@@ -33,7 +33,9 @@ import (
 
 	"github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1/vcsv1connect"
 	"github.com/grafana/pyroscope/v2/pkg/frontend/frontendpb"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/resultscache"
 	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs"
+	vcsconfig "github.com/grafana/pyroscope/v2/pkg/frontend/vcs/config"
 	"github.com/grafana/pyroscope/v2/pkg/querier/stats"
 	"github.com/grafana/pyroscope/v2/pkg/scheduler/schedulerdiscovery"
 	"github.com/grafana/pyroscope/v2/pkg/util/connectgrpc"
@@ -50,10 +52,24 @@ type Config struct {
 	GRPCClientConfig  grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the query-frontends and the query-schedulers."`
 
 	// AsyncQueriesEnabled toggles the experimental async query path on
-	// SelectMergeStacktraces. Off by default; when false, the Async field
-	// on the request is rejected with Unimplemented.
+	// SelectMergeStacktraces, SelectMergeProfile, SelectSeries,
+	// SelectHeatmap, Diff and SelectMergeSpanProfile, and the
+	// AsyncQueryService. Off by default; when false, the Async field on the
+	// request is rejected with Unimplemented.
 	AsyncQueriesEnabled bool `yaml:"async_queries_enabled" category:"experimental"`
 
+	// ResultsCache configures the cache of time series and tree
+	// query results of the v2 read path.
+	ResultsCache resultscache.Config `yaml:"results_cache" category:"experimental"`
+
+	// ReplicasAddress is the DNS address the query frontend
+	// replicas of the v2 read path are discovered with.
+	ReplicasAddress string `yaml:"replicas_address" category:"experimental"`
+
+	// VCS configures the access to the repositories of source code
+	// providers with static tokens.
+	VCS vcsconfig.ProvidersConfig `yaml:"vcs" category:"experimental" doc:"hidden"`
+
 	// Used to find local IP address, that is sent to scheduler and querier-worker.
 	InfNames   []string `yaml:"instance_interface_names" category:"advanced" doc:"default=[<private network interfaces>]"`
 	Addr       string   `yaml:"instance_addr" category:"advanced"`
@@ -78,7 +94,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.StringVar(&cfg.Addr, "query-frontend.instance-addr", "", "IP address to advertise to the querier (via scheduler) (default is auto-detected from network interfaces).")
 	f.BoolVar(&cfg.EnableIPv6, "query-frontend.instance-enable-ipv6", false, "Enable using a IPv6 instance address. (default false)")
 	f.IntVar(&cfg.Port, "query-frontend.instance-port", 0, "Port to advertise to query-scheduler and querier (defaults to -server.http-listen-port).")
-	f.BoolVar(&cfg.AsyncQueriesEnabled, "query-frontend.async-queries-enabled", false, "Enable the experimental asynchronous query path on SelectMergeStacktraces (default false)")
+	f.BoolVar(&cfg.AsyncQueriesEnabled, "query-frontend.async-queries-enabled", false, "Enable the experimental asynchronous query path on the query APIs (default false)")
+	cfg.ResultsCache.RegisterFlagsWithPrefix("query-frontend.results-cache.", f)
+	f.StringVar(&cfg.ReplicasAddress, "query-frontend.replicas-address", "", "DNS address resolving to the HTTP addresses of all the query-frontend replicas, for example dns+query-frontend-headless:4040. The per-tenant bytes scanned budget is divided among the discovered replicas, and the running queries admin page lists and kills the queries of all of them. If empty, each replica assumes it is the only one.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-frontend.grpc-client-config", f)
 }
 
@@ -87,6 +105,14 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("scheduler address cannot be specified when query-scheduler service discovery mode is set to '%s'", cfg.QuerySchedulerDiscovery.Mode)
 	}
 
+	if err := cfg.VCS.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.ResultsCache.Validate(); err != nil {
+		return err
+	}
+
 	return cfg.GRPCClientConfig.Validate()
 }
 
@@ -120,6 +146,7 @@ type Limits interface {
 	QueryAnalysisEnabled(string) bool
 	SymbolizerEnabled(string) bool
 	QuerySanitizeOnMerge(string) bool
+	QueryDownsampledMinRange(string) time.Duration
 	QueryTreeEnabled(string) bool
 	SymbolRefTreesEnabled(string) bool
 	SymbolizerMaxUnresolvedLocations(string) int
@@ -171,7 +198,7 @@ func NewFrontend(cfg Config, limits Limits, log log.Logger, reg prometheus.Regis
 		schedulerWorkers:        schedulerWorkers,
 		schedulerWorkersWatcher: services.NewFailureWatcher(),
 		requests:                newRequestsInProgress(),
-		VCSServiceHandler:       vcs.New(log, reg),
+		VCSServiceHandler:       vcs.New(log, reg, cfg.VCS),
 	}
 	f.GRPCRoundTripper = &realFrontendRoundTripper{frontend: f}
 	// Randomize to avoid getting responses from queries sent before restart, which could lead to mixing results
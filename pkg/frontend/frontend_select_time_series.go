@@ -51,14 +51,16 @@ func (f *Frontend) SelectSeries(
 		r := intervals.At()
 		g.Go(func() error {
 			req := connectgrpc.CloneRequest(c, &querierv1.SelectSeriesRequest{
-				ProfileTypeID:      c.Msg.ProfileTypeID,
-				LabelSelector:      c.Msg.LabelSelector,
-				Start:              r.Start.UnixMilli(),
-				End:                r.End.UnixMilli(),
-				GroupBy:            c.Msg.GroupBy,
-				Step:               c.Msg.Step,
-				Aggregation:        c.Msg.Aggregation,
-				StackTraceSelector: c.Msg.StackTraceSelector,
+				ProfileTypeID:        c.Msg.ProfileTypeID,
+				LabelSelector:        c.Msg.LabelSelector,
+				Start:                r.Start.UnixMilli(),
+				End:                  r.End.UnixMilli(),
+				GroupBy:              c.Msg.GroupBy,
+				Step:                 c.Msg.Step,
+				Aggregation:          c.Msg.Aggregation,
+				StackTraceSelector:   c.Msg.StackTraceSelector,
+				ExemplarType:         c.Msg.ExemplarType,
+				MaxExemplarsPerPoint: c.Msg.MaxExemplarsPerPoint,
 			})
 			resp, err := connectgrpc.RoundTripUnary[
 				querierv1.SelectSeriesRequest,
@@ -17,3 +17,10 @@ func (f *Frontend) SelectHeatmap(
 ) (*connect.Response[querierv1.SelectHeatmapResponse], error) {
 	return nil, errNotAvailableInV1Frontend
 }
+
+func (f *Frontend) LabelValuesCardinality(
+	ctx context.Context,
+	c *connect.Request[querierv1.LabelValuesCardinalityRequest],
+) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	return nil, errNotAvailableInV1Frontend
+}
@@ -6,6 +6,7 @@ import (
 	"slices"
 
 	"connectrpc.com/connect"
+	"github.com/grafana/dskit/tenant"
 	"golang.org/x/sync/errgroup"
 
 	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
@@ -263,6 +264,23 @@ func (r *Router) AnalyzeQuery(
 	return connect.NewResponse(&querierv1.AnalyzeQueryResponse{}), nil
 }
 
+// LabelValuesCardinality is only served by the new query frontend, as the
+// cardinality is resolved from the metastore: the query is not split, and
+// the time range before the enablement of the query backend is ignored.
+func (r *Router) LabelValuesCardinality(
+	ctx context.Context,
+	c *connect.Request[querierv1.LabelValuesCardinalityRequest],
+) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if !r.overrides.ReadPathOverrides(tenantIDs[0]).EnableQueryBackend {
+		return r.oldFrontend.LabelValuesCardinality(ctx, c)
+	}
+	return r.newFrontend.LabelValuesCardinality(ctx, c)
+}
+
 func (r *Router) GetProfileStats(
 	ctx context.Context,
 	c *connect.Request[typesv1.GetProfileStatsRequest],
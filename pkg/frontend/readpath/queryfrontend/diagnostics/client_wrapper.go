@@ -170,5 +170,13 @@ func (w *Wrapper) AnalyzeQuery(ctx context.Context, req *connect.Request[querier
 	return resp, err
 }
 
+func (w *Wrapper) LabelValuesCardinality(ctx context.Context, req *connect.Request[querierv1.LabelValuesCardinalityRequest]) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	resp, err := w.client.LabelValuesCardinality(ctx, req)
+	if resp != nil {
+		flushDiagnostics(w, ctx, "LabelValuesCardinality", req, resp)
+	}
+	return resp, err
+}
+
 // Ensure Wrapper implements the interface
 var _ querierv1connect.QuerierServiceClient = (*Wrapper)(nil)
@@ -0,0 +1,117 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrQueryKilled is the cause of the cancellation
+// of a running query killed by an operator.
+var ErrQueryKilled = errors.New("query killed by an operator")
+
+// RunningQuery describes a query in flight.
+type RunningQuery struct {
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	LabelSelector  string    `json:"label_selector"`
+	StartTime      int64     `json:"start_time"`
+	EndTime        int64     `json:"end_time"`
+	QueryTypes     []string  `json:"query_types"`
+	Blocks         int       `json:"blocks"`
+	EstimatedBytes uint64    `json:"estimated_bytes"`
+	StartedAt      time.Time `json:"started_at"`
+	ElapsedMs      int64     `json:"elapsed_ms"`
+	// Replica is the address of the query frontend
+	// replica the query runs in, if known.
+	Replica string `json:"replica,omitempty"`
+}
+
+// RunningQueries is the registry of the queries
+// in flight in the query frontend.
+type RunningQueries struct {
+	mu      sync.Mutex
+	queries map[string]*runningQuery
+}
+
+type runningQuery struct {
+	RunningQuery
+	cancel context.CancelCauseFunc
+}
+
+func NewRunningQueries() *RunningQueries {
+	return &RunningQueries{queries: make(map[string]*runningQuery)}
+}
+
+// Register adds the query to the registry. The returned context is
+// cancelled if the query is killed; the query must be unregistered
+// with the returned function once it completes.
+func (r *RunningQueries) Register(ctx context.Context, q RunningQuery) (context.Context, *RunningQueryHandle, func()) {
+	if q.ID == "" {
+		q.ID = generateUUID()
+	}
+	if q.StartedAt.IsZero() {
+		q.StartedAt = time.Now()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	rq := &runningQuery{RunningQuery: q, cancel: cancel}
+	r.mu.Lock()
+	r.queries[q.ID] = rq
+	r.mu.Unlock()
+	done := func() {
+		r.mu.Lock()
+		delete(r.queries, q.ID)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, &RunningQueryHandle{registry: r, query: rq}, done
+}
+
+// List returns the queries in flight, the longest running first.
+func (r *RunningQueries) List() []*RunningQuery {
+	now := time.Now()
+	r.mu.Lock()
+	list := make([]*RunningQuery, 0, len(r.queries))
+	for _, rq := range r.queries {
+		q := rq.RunningQuery
+		q.QueryTypes = slices.Clone(q.QueryTypes)
+		q.ElapsedMs = now.Sub(q.StartedAt).Milliseconds()
+		list = append(list, &q)
+	}
+	r.mu.Unlock()
+	slices.SortFunc(list, func(a, b *RunningQuery) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return list
+}
+
+// Kill cancels the query with the given ID. It reports
+// whether the query has been found.
+func (r *RunningQueries) Kill(id string) bool {
+	r.mu.Lock()
+	rq, ok := r.queries[id]
+	r.mu.Unlock()
+	if ok {
+		rq.cancel(ErrQueryKilled)
+	}
+	return ok
+}
+
+// RunningQueryHandle updates the description of a registered query.
+type RunningQueryHandle struct {
+	registry *RunningQueries
+	query    *runningQuery
+}
+
+// SetPlan records the size of the query plan, once it is known.
+func (h *RunningQueryHandle) SetPlan(blocks int, estimatedBytes uint64) {
+	if h == nil {
+		return
+	}
+	h.registry.mu.Lock()
+	h.query.Blocks = blocks
+	h.query.EstimatedBytes = estimatedBytes
+	h.registry.mu.Unlock()
+}
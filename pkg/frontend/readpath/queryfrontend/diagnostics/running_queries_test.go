@@ -0,0 +1,43 @@
+package diagnostics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunningQueries(t *testing.T) {
+	r := NewRunningQueries()
+
+	ctx1, h1, done1 := r.Register(context.Background(), RunningQuery{
+		ID:         "query-1",
+		TenantID:   "tenant-1",
+		QueryTypes: []string{"QUERY_TREE"},
+		StartedAt:  time.Now().Add(-time.Minute),
+	})
+	ctx2, _, done2 := r.Register(context.Background(), RunningQuery{TenantID: "tenant-2"})
+	h1.SetPlan(10, 1<<20)
+
+	list := r.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "query-1", list[0].ID)
+	assert.Equal(t, 10, list[0].Blocks)
+	assert.Equal(t, uint64(1<<20), list[0].EstimatedBytes)
+	assert.GreaterOrEqual(t, list[0].ElapsedMs, time.Minute.Milliseconds())
+	assert.NotEmpty(t, list[1].ID)
+	assert.Equal(t, "tenant-2", list[1].TenantID)
+
+	assert.False(t, r.Kill("unknown"))
+	assert.True(t, r.Kill("query-1"))
+	assert.ErrorIs(t, context.Cause(ctx1), ErrQueryKilled)
+	assert.NoError(t, ctx2.Err())
+
+	done1()
+	done2()
+	assert.Empty(t, r.List())
+	assert.ErrorIs(t, ctx1.Err(), context.Canceled)
+	assert.ErrorIs(t, ctx2.Err(), context.Canceled)
+}
@@ -0,0 +1,222 @@
+package queryfrontend
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Queries are admitted based on their estimated cost: the number of bytes
+// the query is expected to scan, derived from the metadata of the blocks
+// it reads. A query is rejected if its estimate exceeds the per-query
+// limit, or if it does not fit into the remaining bytes scanned budget of
+// the tenant.
+//
+// The budget applies to a rolling window. When a query is admitted, its
+// estimate is reserved in the window, so that concurrent queries cannot
+// overcommit the budget. Once the query completes, the reservation is
+// replaced with the number of bytes actually fetched from object storage.
+//
+// The frontend replicas do not share the budget state: the budget of the
+// tenant is divided evenly among the replicas known to the frontend.
+//
+// Splits of the query served from the results cache do not scan any
+// data, and are not charged.
+
+// QueryBudgetLimits provides the per-tenant query cost limits.
+type QueryBudgetLimits interface {
+	QueryMaxEstimatedBytes(tenantID string) int64
+	QueryBytesScannedBudget(tenantID string) int64
+	QueryBytesScannedBudgetWindow(tenantID string) time.Duration
+}
+
+// SetQueryBudget enables the admission of queries based on their
+// estimated cost. The replicas may be nil, if the frontend is the
+// only one.
+func (q *QueryFrontend) SetQueryBudget(limits QueryBudgetLimits, replicas *Replicas) {
+	q.budget = newQueryBudget(limits, replicas, q.metrics.rejectedQueriesTotal)
+}
+
+// scannedBytesBuckets is the number of buckets
+// the bytes scanned budget window is divided into.
+const scannedBytesBuckets = 60
+
+const (
+	rejectReasonMaxEstimatedBytes  = "max_estimated_bytes"
+	rejectReasonBytesScannedBudget = "bytes_scanned_budget"
+)
+
+type queryBudget struct {
+	limits   QueryBudgetLimits
+	replicas *Replicas
+	rejected *prometheus.CounterVec
+
+	mu      sync.Mutex
+	tenants map[string]*scannedBytes
+}
+
+func newQueryBudget(limits QueryBudgetLimits, replicas *Replicas, rejected *prometheus.CounterVec) *queryBudget {
+	return &queryBudget{
+		limits:   limits,
+		replicas: replicas,
+		rejected: rejected,
+		tenants:  make(map[string]*scannedBytes),
+	}
+}
+
+// queryReservation is the estimated cost of an admitted
+// query, reserved in the budget windows of its tenants.
+type queryReservation struct {
+	budget   *queryBudget
+	tenants  []string
+	start    time.Time
+	estimate uint64
+}
+
+// admit reserves the estimated cost of the query in the budget of each
+// tenant, or returns a ResourceExhausted error if the query is over any
+// of the limits. The reservation must be released once the query
+// completes.
+func (b *queryBudget) admit(tenants []string, estimate uint64, now time.Time) (*queryReservation, error) {
+	for _, tenantID := range tenants {
+		if limit := b.limits.QueryMaxEstimatedBytes(tenantID); limit > 0 && estimate > uint64(limit) {
+			b.rejected.WithLabelValues(tenantID, rejectReasonMaxEstimatedBytes).Inc()
+			return nil, status.Error(codes.ResourceExhausted, fmt.Sprintf(
+				"the query exceeds the estimated bytes limit (estimated: %s, limit: %s); narrow the time range or the label selector",
+				humanize.Bytes(estimate), humanize.Bytes(uint64(limit))))
+		}
+	}
+
+	replicas := int64(b.replicas.Count())
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := &queryReservation{budget: b, start: now, estimate: estimate}
+	for _, tenantID := range tenants {
+		budget := b.limits.QueryBytesScannedBudget(tenantID)
+		if budget <= 0 {
+			continue
+		}
+		budget = max(budget/replicas, 1)
+		window := b.limits.QueryBytesScannedBudgetWindow(tenantID)
+		if window <= 0 {
+			continue
+		}
+		s := b.tenants[tenantID]
+		if s == nil {
+			s = new(scannedBytes)
+			b.tenants[tenantID] = s
+		}
+		used := s.total(now, window)
+		if used+estimate > uint64(budget) {
+			b.rejected.WithLabelValues(tenantID, rejectReasonBytesScannedBudget).Inc()
+			msg := fmt.Sprintf(
+				"the query exceeds the bytes scanned budget of the tenant (scanned in the last %s: %s, estimated: %s, budget: %s)",
+				window, humanize.Bytes(used), humanize.Bytes(estimate), humanize.Bytes(uint64(budget)))
+			if retry, ok := s.retryAfter(now, window, used+estimate-uint64(budget)); ok {
+				msg += fmt.Sprintf("; retry in %s", retry.Round(time.Second))
+			}
+			r.releaseLocked(0, now)
+			return nil, status.Error(codes.ResourceExhausted, msg)
+		}
+		s.bucket(now, window).bytes += estimate
+		r.tenants = append(r.tenants, tenantID)
+	}
+	return r, nil
+}
+
+// release replaces the reservation with the number of bytes the query
+// actually scanned.
+func (r *queryReservation) release(scanned uint64, now time.Time) {
+	if r == nil {
+		return
+	}
+	r.budget.mu.Lock()
+	defer r.budget.mu.Unlock()
+	r.releaseLocked(scanned, now)
+}
+
+func (r *queryReservation) releaseLocked(scanned uint64, now time.Time) {
+	for _, tenantID := range r.tenants {
+		s := r.budget.tenants[tenantID]
+		if s == nil {
+			continue
+		}
+		s.cancel(r.start, r.estimate)
+		if window := r.budget.limits.QueryBytesScannedBudgetWindow(tenantID); window > 0 && scanned > 0 {
+			s.bucket(now, window).bytes += scanned
+		}
+	}
+	r.tenants = nil
+}
+
+// scannedBytes is the number of bytes scanned by the queries of
+// a tenant, bucketed by time. The bucket width is derived from the
+// window, which may change at runtime: the buckets are then reused
+// as they expire.
+type scannedBytes struct {
+	buckets [scannedBytesBuckets]scannedBytesBucket
+}
+
+type scannedBytesBucket struct {
+	start time.Time
+	width time.Duration
+	bytes uint64
+}
+
+func (s *scannedBytes) bucket(now time.Time, window time.Duration) *scannedBytesBucket {
+	width := max(window/scannedBytesBuckets, time.Millisecond)
+	start := now.Truncate(width)
+	b := &s.buckets[(start.UnixNano()/int64(width))%scannedBytesBuckets]
+	if !b.start.Equal(start) || b.width != width {
+		*b = scannedBytesBucket{start: start, width: width}
+	}
+	return b
+}
+
+func (s *scannedBytes) total(now time.Time, window time.Duration) (total uint64) {
+	for _, b := range s.buckets {
+		if b.bytes > 0 && now.Sub(b.start) < window {
+			total += b.bytes
+		}
+	}
+	return total
+}
+
+// cancel removes the bytes reserved at the given time,
+// unless the bucket has already been reused.
+func (s *scannedBytes) cancel(at time.Time, n uint64) {
+	for i := range s.buckets {
+		b := &s.buckets[i]
+		if b.width > 0 && !at.Before(b.start) && at.Before(b.start.Add(b.width)) {
+			b.bytes -= min(b.bytes, n)
+			return
+		}
+	}
+}
+
+// retryAfter returns the time after which at least n bytes
+// leave the window, if they ever do.
+func (s *scannedBytes) retryAfter(now time.Time, window time.Duration, n uint64) (time.Duration, bool) {
+	live := make([]scannedBytesBucket, 0, scannedBytesBuckets)
+	for _, b := range s.buckets {
+		if b.bytes > 0 && now.Sub(b.start) < window {
+			live = append(live, b)
+		}
+	}
+	slices.SortFunc(live, func(a, b scannedBytesBucket) int {
+		return a.start.Compare(b.start)
+	})
+	var expired uint64
+	for _, b := range live {
+		if expired += b.bytes; expired >= n {
+			return b.start.Add(window).Sub(now), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,123 @@
+package queryfrontend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/pyroscope/v2/pkg/test"
+	"github.com/grafana/pyroscope/v2/pkg/util/servicediscovery"
+)
+
+type budgetLimits struct {
+	maxEstimatedBytes int64
+	budget            int64
+	window            time.Duration
+}
+
+func (l budgetLimits) QueryMaxEstimatedBytes(string) int64                { return l.maxEstimatedBytes }
+func (l budgetLimits) QueryBytesScannedBudget(string) int64               { return l.budget }
+func (l budgetLimits) QueryBytesScannedBudgetWindow(string) time.Duration { return l.window }
+
+func newTestQueryBudget(limits QueryBudgetLimits) *queryBudget {
+	return newQueryBudget(limits, nil, newQueryFrontendMetrics(prometheus.NewRegistry()).rejectedQueriesTotal)
+}
+
+func TestQueryBudget_MaxEstimatedBytes(t *testing.T) {
+	b := newTestQueryBudget(budgetLimits{maxEstimatedBytes: 100})
+	now := test.Time("2024-09-23T12:00:00Z")
+
+	r, err := b.admit([]string{"tenant-a"}, 100, now)
+	require.NoError(t, err)
+	r.release(100, now)
+
+	_, err = b.admit([]string{"tenant-a"}, 101, now)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "estimated bytes limit")
+}
+
+func TestQueryBudget_BytesScannedBudget(t *testing.T) {
+	b := newTestQueryBudget(budgetLimits{budget: 1000, window: time.Hour})
+	now := test.Time("2024-09-23T12:00:00Z")
+	tenants := []string{"tenant-a"}
+
+	// The estimate is reserved while the query is in flight.
+	r1, err := b.admit(tenants, 600, now)
+	require.NoError(t, err)
+	_, err = b.admit(tenants, 600, now)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The reservation is replaced with the bytes actually scanned.
+	r1.release(200, now.Add(10*time.Minute))
+	r2, err := b.admit(tenants, 600, now.Add(20*time.Minute))
+	require.NoError(t, err)
+	r2.release(700, now.Add(20*time.Minute))
+
+	// 900 bytes scanned within the window.
+	_, err = b.admit(tenants, 500, now.Add(30*time.Minute))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry in 50m0s")
+
+	// Other tenants are not affected.
+	_, err = b.admit([]string{"tenant-b"}, 500, now.Add(30*time.Minute))
+	require.NoError(t, err)
+
+	// The first query leaves the window.
+	_, err = b.admit(tenants, 300, now.Add(70*time.Minute))
+	require.NoError(t, err)
+	// The second one does too, but the estimate
+	// alone is over the budget.
+	_, err = b.admit(tenants, 1001, now.Add(90*time.Minute))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "retry in")
+}
+
+func TestQueryBudget_MultiTenant(t *testing.T) {
+	b := newTestQueryBudget(budgetLimits{budget: 1000, window: time.Hour})
+	now := test.Time("2024-09-23T12:00:00Z")
+
+	r, err := b.admit([]string{"tenant-b"}, 800, now)
+	require.NoError(t, err)
+	r.release(800, now)
+
+	// The query is rejected by the budget of tenant-b, and
+	// the reservation in the budget of tenant-a is cancelled.
+	_, err = b.admit([]string{"tenant-a", "tenant-b"}, 500, now)
+	require.Error(t, err)
+	r, err = b.admit([]string{"tenant-a"}, 1000, now)
+	require.NoError(t, err)
+	r.release(0, now)
+}
+
+func TestQueryBudget_DividedAmongReplicas(t *testing.T) {
+	replicas := NewReplicas()
+	replicas.InstanceAdded(servicediscovery.Instance{Address: "10.0.0.1:9095"})
+	replicas.InstanceAdded(servicediscovery.Instance{Address: "10.0.0.2:9095"})
+	b := newQueryBudget(
+		budgetLimits{budget: 1000, window: time.Hour},
+		replicas,
+		newQueryFrontendMetrics(prometheus.NewRegistry()).rejectedQueriesTotal,
+	)
+	now := test.Time("2024-09-23T12:00:00Z")
+	tenants := []string{"tenant-a"}
+
+	_, err := b.admit(tenants, 600, now)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	r, err := b.admit(tenants, 500, now)
+	require.NoError(t, err)
+	r.release(500, now)
+
+	replicas.InstanceRemoved(servicediscovery.Instance{Address: "10.0.0.2:9095"})
+	r, err = b.admit(tenants, 500, now)
+	require.NoError(t, err)
+	r.release(500, now)
+}
@@ -2,6 +2,7 @@ package queryfrontend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"slices"
@@ -28,6 +29,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
 	"github.com/grafana/pyroscope/v2/pkg/frontend"
 	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/diagnostics"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/resultscache"
 	"github.com/grafana/pyroscope/v2/pkg/model"
 	"github.com/grafana/pyroscope/v2/pkg/querybackend/queryplan"
 	"github.com/grafana/pyroscope/v2/pkg/util/spanlogger"
@@ -64,7 +66,12 @@ type QueryFrontend struct {
 	tenantServiceClient metastorev1.TenantServiceClient
 	querybackend        QueryBackend
 	symbolizer          Symbolizer
+	sourceMaps          SourceMapResolver
 	diagnosticsStore    DiagnosticsStore
+	runningQueries      *diagnostics.RunningQueries
+	resultsCache        resultscache.Cache
+	resultsCacheConfig  resultscache.Config
+	budget              *queryBudget
 	now                 func() time.Time
 
 	metrics *queryFrontendMetrics
@@ -75,6 +82,8 @@ type queryFrontendMetrics struct {
 	estimationAccuracyRatio prometheus.Histogram
 
 	symbolRefLocationsTotal *prometheus.CounterVec
+	resultsCacheSplitsTotal *prometheus.CounterVec
+	rejectedQueriesTotal    *prometheus.CounterVec
 }
 
 func newQueryFrontendMetrics(reg prometheus.Registerer) *queryFrontendMetrics {
@@ -118,12 +127,32 @@ func newQueryFrontendMetrics(reg prometheus.Registerer) *queryFrontendMetrics {
 			},
 			[]string{"result"},
 		),
+		resultsCacheSplitsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "pyroscope",
+				Subsystem: "query_frontend",
+				Name:      "results_cache_splits_total",
+				Help:      "Total number of query time splits served with the results cache enabled, by outcome (a cache hit, a miss, or uncached as too recent).",
+			},
+			[]string{"result"},
+		),
+		rejectedQueriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "pyroscope",
+				Subsystem: "query_frontend",
+				Name:      "rejected_queries_total",
+				Help:      "Total number of queries rejected before execution because their estimated cost is over a limit of the tenant, by reason.",
+			},
+			[]string{"tenant", "reason"},
+		),
 	}
 	if reg != nil {
 		reg.MustRegister(
 			m.fetchedBytesTotal,
 			m.estimationAccuracyRatio,
 			m.symbolRefLocationsTotal,
+			m.resultsCacheSplitsTotal,
+			m.rejectedQueriesTotal,
 		)
 	}
 	return m
@@ -147,12 +176,32 @@ func NewQueryFrontend(
 		querybackend:        querybackendClient,
 		symbolizer:          sym,
 		diagnosticsStore:    diagnosticsStore,
+		runningQueries:      diagnostics.NewRunningQueries(),
 		now:                 time.Now,
 		metrics:             newQueryFrontendMetrics(reg),
 	}
 	return qf
 }
 
+// RunningQueries returns the registry of the queries in flight.
+func (q *QueryFrontend) RunningQueries() *diagnostics.RunningQueries {
+	return q.runningQueries
+}
+
+// Parameters of the query plans built by the frontend.
+// TODO(kolesnikovae): Should be dynamic.
+const (
+	queryPlanMaxReads  = 4
+	queryPlanMaxMerges = 20
+)
+
+// buildQueryPlan builds the plan of the query over the blocks. All the
+// plans of the frontend must be built here, so that the query splits
+// served from the results cache are executed in the same way.
+func (q *QueryFrontend) buildQueryPlan(blocks []*metastorev1.BlockMeta, queries ...*queryv1.Query) *queryv1.QueryPlan {
+	return queryplan.Build(blocks, queryPlanMaxReads, queryPlanMaxMerges, queries...)
+}
+
 var xrand = rand.New(rand.NewSource(4349676827832284783))
 var xrandMutex = sync.Mutex{} // todo fix the race properly
 
@@ -193,14 +242,37 @@ func (q *QueryFrontend) doQuery(
 	}
 	span.SetTag("tenant_ids", tenants)
 
-	blocks, err := q.QueryMetadata(ctx, req)
+	running := diagnostics.RunningQuery{
+		TenantID:      tenant.JoinTenantIDs(tenants),
+		LabelSelector: req.LabelSelector,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+	}
+	if collectDiagnostics {
+		running.ID = diagCtx.ID
+	}
+	for _, query := range req.Query {
+		running.QueryTypes = append(running.QueryTypes, query.QueryType.String())
+	}
+	ctx, handle, done := q.runningQueries.Register(ctx, running)
+	defer done()
+
+	md, err := q.queryMetadata(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, killedError(ctx, err)
 	}
+	blocks := md.Blocks
 	span.SetTag("block_count", len(blocks))
+	span.SetTag("series_tombstones", len(md.SeriesTombstones))
 	if len(blocks) == 0 {
 		return new(queryv1.QueryResponse), nil
 	}
+	var resolution time.Duration
+	if queryplan.HasDownsampledDatasets(blocks) {
+		resolution = q.downsamplingResolution(tenants, req)
+		span.SetTag("downsampling_resolution", resolution.String())
+		queryplan.SelectDatasets(blocks, resolution)
+	}
 
 	// Measure bytes received from the metastore (serialized block metadata).
 	var metastoreBytes uint64
@@ -248,6 +320,7 @@ func (q *QueryFrontend) doQuery(
 		logArgs = append(logArgs, fmt.Sprintf("blocks_level_%d", lvl), count)
 	}
 	level.Info(q.logger).Log(logArgs...)
+	handle.SetPlan(len(blocks), weight.Total())
 
 	// Randomize the order of blocks to avoid hotspots.
 	xrandMutex.Lock()
@@ -255,14 +328,13 @@ func (q *QueryFrontend) doQuery(
 		blocks[i], blocks[j] = blocks[j], blocks[i]
 	})
 	xrandMutex.Unlock()
-	// TODO(kolesnikovae): Should be dynamic.
-	p := queryplan.Build(blocks, 4, 20)
+	p := q.buildQueryPlan(blocks, req.Query...)
 
 	backend := q.querybackend
 	if backendC != nil {
 		backend = backendC(ctx, backend, blocks)
 	}
-	resp, err := backend.Invoke(ctx, &queryv1.InvokeRequest{
+	invokeReq := &queryv1.InvokeRequest{
 		Tenant:        tenants,
 		StartTime:     req.StartTime,
 		EndTime:       req.EndTime,
@@ -271,11 +343,44 @@ func (q *QueryFrontend) doQuery(
 			SanitizeOnMerge:    q.limits.QuerySanitizeOnMerge(tenants[0]),
 			CollectDiagnostics: collectDiagnostics,
 		},
-		QueryPlan: p,
-		Query:     req.Query,
-	})
+		Query:            req.Query,
+		SeriesTombstones: md.SeriesTombstones,
+	}
+	// Results post-processed by the backend wrapper (e.g., symbolized in
+	// the frontend) are not cached: they may change while the blocks
+	// remain the same.
+	cached := q.resultsCache != nil &&
+		backend == q.querybackend &&
+		!collectDiagnostics &&
+		isResultsCacheable(req.Query)
+	estimate := weight.Total()
+	var splits []*resultsCacheSplit
+	if cached {
+		splits = q.lookupResultsCache(ctx, invokeReq, blocks, resolution)
+		estimate = resultsCacheMissWeight(splits)
+	}
+
+	// The reservation of the estimated cost is replaced with
+	// the bytes actually scanned once the query completes.
+	var scannedBytes uint64
+	if q.budget != nil {
+		reservation, err := q.budget.admit(tenants, estimate, q.now())
+		if err != nil {
+			span.LogError(err)
+			return nil, err
+		}
+		defer func() { reservation.release(scannedBytes, q.now()) }()
+	}
+
+	var resp *queryv1.InvokeResponse
+	if cached {
+		resp, err = q.invokeWithResultsCache(ctx, backend, invokeReq, splits)
+	} else {
+		invokeReq.QueryPlan = p
+		resp, err = q.invoke(ctx, backend, invokeReq)
+	}
 	if err != nil {
-		return nil, err
+		return nil, killedError(ctx, err)
 	}
 
 	// Emit per-tenant bytes metrics. Object storage bytes come from the
@@ -283,17 +388,20 @@ func (q *QueryFrontend) doQuery(
 	// Use dskit's JoinTenantIDs so the label matches the standard |-separated
 	// org ID format used elsewhere in the Grafana stack.
 	tenantLabel := tenant.JoinTenantIDs(tenants)
-	objectBytes := resp.GetDiagnostics().GetExecutionNode().GetStats().GetBytesFetched()
+	execStats := resp.GetDiagnostics().GetExecutionNode().GetStats()
+	objectBytes := execStats.GetBytesFetched()
+	scannedBytes = objectBytes
 	q.metrics.fetchedBytesTotal.WithLabelValues(tenantLabel, "object_storage").Add(float64(objectBytes))
 	q.metrics.fetchedBytesTotal.WithLabelValues(tenantLabel, "metastore").Add(float64(metastoreBytes))
 	// Record estimation accuracy: ratio of pre-execution weight to actual bytes
 	// fetched. Only observed when the backend fetched bytes to avoid division
-	// by zero (e.g. empty result sets).
-	if objectBytes > 0 {
+	// by zero (e.g. empty result sets), and when the results were not served
+	// from the cache.
+	if objectBytes > 0 && !cached {
 		q.metrics.estimationAccuracyRatio.Observe(float64(weight.Total()) / float64(objectBytes))
 	}
 	if qs := spanlogger.QueryStatsFromContext(ctx); qs != nil {
-		qs.ObjectStorageBytes += objectBytes
+		qs.AddExecutionStats(execStats)
 		qs.MetastoreBytes += metastoreBytes
 		qs.EstimatedBytes += weight.Total()
 	}
@@ -311,10 +419,42 @@ func (q *QueryFrontend) doQuery(
 	return &queryv1.QueryResponse{Reports: resp.Reports}, nil
 }
 
+// killedError replaces the error of a query killed by an operator:
+// the query backend only observes the cancellation.
+func killedError(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), diagnostics.ErrQueryKilled) {
+		return status.Error(codes.Canceled, diagnostics.ErrQueryKilled.Error())
+	}
+	return err
+}
+
 func (q *QueryFrontend) QueryMetadata(
 	ctx context.Context,
 	req *queryv1.QueryRequest,
-) (blocks []*metastorev1.BlockMeta, err error) {
+) ([]*metastorev1.BlockMeta, error) {
+	md, err := q.queryMetadata(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// Only raw datasets are exposed.
+	queryplan.SelectDatasets(md.Blocks, 0)
+	return md.Blocks, nil
+}
+
+// downsamplingResolution returns the coarsest resolution of downsampled
+// data the request can be served from. Zero means raw data only.
+func (q *QueryFrontend) downsamplingResolution(tenants []string, req *queryv1.QueryRequest) time.Duration {
+	minRange := q.limits.QueryDownsampledMinRange(tenants[0])
+	if minRange <= 0 || time.Duration(req.EndTime-req.StartTime)*time.Millisecond < minRange {
+		return 0
+	}
+	return queryplan.MaxDownsamplingResolution(req.Query...)
+}
+
+func (q *QueryFrontend) queryMetadata(
+	ctx context.Context,
+	req *queryv1.QueryRequest,
+) (md *metastorev1.QueryMetadataResponse, err error) {
 	span, ctx := tracing.StartSpanFromContext(ctx, "QueryFrontend.QueryMetadata")
 	defer func() {
 		if err != nil {
@@ -342,7 +482,7 @@ func (q *QueryFrontend) QueryMetadata(
 		TenantId:  tenants,
 		StartTime: req.StartTime,
 		EndTime:   req.EndTime,
-		Labels:    []string{metadata.LabelNameUnsymbolized},
+		Labels:    []string{metadata.LabelNameUnsymbolized, metadata.LabelNameRelease},
 	}
 
 	// Delete all matchers but service_name with strict match. If no matchers
@@ -361,14 +501,18 @@ func (q *QueryFrontend) QueryMetadata(
 			Value: metadata.LabelValueDatasetTSDBIndex,
 			Type:  labels.MatchEqual,
 		}}
+	} else {
+		// Downsampled datasets can only be matched by service_name:
+		// the tenant-wide dataset index only refers to raw datasets.
+		query.Labels = append(query.Labels, metadata.LabelNameDownsamplingResolution)
 	}
 
 	query.Query = matchersToLabelSelector(matchers)
-	md, err := q.metadataQueryClient.QueryMetadata(ctx, query)
+	md, err = q.metadataQueryClient.QueryMetadata(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	span.SetTag("blocks_count", len(md.Blocks))
 
-	return md.Blocks, nil
+	return md, nil
 }
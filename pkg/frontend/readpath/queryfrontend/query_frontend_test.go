@@ -44,7 +44,7 @@ func Test_QueryFrontend_QueryMetadata(t *testing.T) {
 			request: &metastorev1.QueryMetadataRequest{
 				TenantId: []string{"org"},
 				Query:    `{service_name="service-a"}`,
-				Labels:   []string{metadata.LabelNameUnsymbolized},
+				Labels:   []string{metadata.LabelNameUnsymbolized, metadata.LabelNameRelease, metadata.LabelNameDownsamplingResolution},
 			},
 			response: &metastorev1.QueryMetadataResponse{
 				Blocks: []*metastorev1.BlockMeta{{Id: "block_id_a"}},
@@ -55,7 +55,7 @@ func Test_QueryFrontend_QueryMetadata(t *testing.T) {
 			request: &metastorev1.QueryMetadataRequest{
 				TenantId: []string{"org"},
 				Query:    `{__tenant_dataset__="dataset_tsdb_index"}`,
-				Labels:   []string{metadata.LabelNameUnsymbolized, "__tenant_dataset__"},
+				Labels:   []string{metadata.LabelNameUnsymbolized, metadata.LabelNameRelease, "__tenant_dataset__"},
 			},
 			response: &metastorev1.QueryMetadataResponse{
 				Blocks: []*metastorev1.BlockMeta{{Id: "block_id_a"}},
@@ -66,7 +66,7 @@ func Test_QueryFrontend_QueryMetadata(t *testing.T) {
 			request: &metastorev1.QueryMetadataRequest{
 				TenantId: []string{"org"},
 				Query:    `{__tenant_dataset__="dataset_tsdb_index"}`,
-				Labels:   []string{metadata.LabelNameUnsymbolized, "__tenant_dataset__"},
+				Labels:   []string{metadata.LabelNameUnsymbolized, metadata.LabelNameRelease, "__tenant_dataset__"},
 			},
 			response: &metastorev1.QueryMetadataResponse{
 				Blocks: []*metastorev1.BlockMeta{{Id: "block_id_c"}},
@@ -77,7 +77,7 @@ func Test_QueryFrontend_QueryMetadata(t *testing.T) {
 			request: &metastorev1.QueryMetadataRequest{
 				TenantId: []string{"org"},
 				Query:    `{__tenant_dataset__="dataset_tsdb_index"}`,
-				Labels:   []string{metadata.LabelNameUnsymbolized, "__tenant_dataset__"},
+				Labels:   []string{metadata.LabelNameUnsymbolized, metadata.LabelNameRelease, "__tenant_dataset__"},
 			},
 			response: &metastorev1.QueryMetadataResponse{
 				Blocks: []*metastorev1.BlockMeta{{Id: "block_id_b"}},
@@ -88,7 +88,7 @@ func Test_QueryFrontend_QueryMetadata(t *testing.T) {
 			request: &metastorev1.QueryMetadataRequest{
 				TenantId: []string{"org"},
 				Query:    `{__tenant_dataset__="dataset_tsdb_index"}`,
-				Labels:   []string{metadata.LabelNameUnsymbolized, "__tenant_dataset__"},
+				Labels:   []string{metadata.LabelNameUnsymbolized, metadata.LabelNameRelease, "__tenant_dataset__"},
 			},
 			response: &metastorev1.QueryMetadataResponse{
 				Blocks: []*metastorev1.BlockMeta{{Id: "block_id_d"}},
@@ -0,0 +1,271 @@
+package queryfrontend
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/grafana/dskit/tracing"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/sync/errgroup"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
+	"github.com/grafana/pyroscope/v2/pkg/validation"
+)
+
+// The label values and their cardinality are resolved from the dataset
+// labels stored in the metastore: the cardinality of a value is the number
+// of datasets labeled with it. Blocks are not accessed, which makes the
+// call cheap enough for autocompletion, but only the labels recorded in
+// the dataset metadata (service name and profile type) are supported.
+// Requests for other labels, or with matchers on other labels, are
+// rejected: the dataset labels would never match them.
+//
+// If the results cache is enabled, the time range is split at the results
+// cache split interval boundaries, and the counts of each interval are
+// cached, as long as the interval ends before the max freshness period.
+// The cached counts do not depend on the prefix and regex filters, which
+// are applied by the frontend: the cache is shared by all the requests
+// issued while the user is typing. Compaction may change the number of
+// datasets in an interval, and the cached counts are only updated once
+// they expire: the cardinality is approximate by design. A dataset that
+// spans the interval boundary is counted in both intervals.
+
+// datasetLabelNames are the names of the labels recorded in the
+// dataset metadata that the cardinality can be resolved for.
+var datasetLabelNames = map[string]struct{}{
+	phlaremodel.LabelNameServiceName: {},
+	phlaremodel.LabelNameProfileType: {},
+}
+
+// labelValuesCardinalityKeyVersion must be changed if the
+// format of the key or of the cached value changes.
+const labelValuesCardinalityKeyVersion = 1
+
+type labelValuesCardinalitySplit struct {
+	startTime int64
+	endTime   int64
+	key       string
+	counts    *metastorev1.QueryMetadataLabelsResponse
+	hit       bool
+}
+
+func (q *QueryFrontend) LabelValuesCardinality(
+	ctx context.Context,
+	c *connect.Request[querierv1.LabelValuesCardinalityRequest],
+) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	empty, err := validation.SanitizeTimeRange(q.limits, tenantIDs, &c.Msg.Start, &c.Msg.End)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if empty {
+		return connect.NewResponse(&querierv1.LabelValuesCardinalityResponse{}), nil
+	}
+
+	if c.Msg.Name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+	}
+	if _, ok := datasetLabelNames[c.Msg.Name]; !ok {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("label %q is not supported: the cardinality is only available for dataset labels", c.Msg.Name))
+	}
+	var regex *labels.Matcher
+	if c.Msg.Regex != "" {
+		if regex, err = labels.NewMatcher(labels.MatchRegexp, c.Msg.Name, c.Msg.Regex); err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+	}
+	matchers, err := parseMatchers(c.Msg.Matchers)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("parsing label selector: %w", err))
+	}
+	for _, m := range matchers {
+		if _, ok := datasetLabelNames[m.Name]; !ok {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("matcher %s is not supported: only dataset labels can be matched", m))
+		}
+	}
+	labelSelector := matchersToLabelSelector(matchers)
+
+	counts, err := q.labelValuesCounts(ctx, tenantIDs, labelSelector, c.Msg)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*querierv1.LabelValueCardinality, 0, len(counts))
+	for v, n := range counts {
+		if !strings.HasPrefix(v, c.Msg.Prefix) || (regex != nil && !regex.Matches(v)) {
+			continue
+		}
+		values = append(values, &querierv1.LabelValueCardinality{Value: v, Cardinality: n})
+	}
+	slices.SortFunc(values, func(a, b *querierv1.LabelValueCardinality) int {
+		if x := cmp.Compare(b.Cardinality, a.Cardinality); x != 0 {
+			return x
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	resp := &querierv1.LabelValuesCardinalityResponse{Values: values}
+	if c.Msg.Limit > 0 && int64(len(values)) > c.Msg.Limit {
+		resp.Values = values[:c.Msg.Limit]
+		resp.Truncated = true
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// labelValuesCounts returns the number of datasets
+// labeled with each of the values of the label.
+func (q *QueryFrontend) labelValuesCounts(
+	ctx context.Context,
+	tenants []string,
+	labelSelector string,
+	req *querierv1.LabelValuesCardinalityRequest,
+) (counts map[string]int64, err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "QueryFrontend.labelValuesCounts")
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	splits := q.labelValuesCardinalitySplits(req.Start, req.End)
+	if q.resultsCache != nil {
+		maxEndTime := q.now().Add(-q.resultsCacheConfig.MaxFreshness).UnixMilli()
+		keys := make([]string, 0, len(splits))
+		for _, s := range splits {
+			if s.endTime <= maxEndTime {
+				s.key = labelValuesCardinalityKey(tenants, labelSelector, req.Name, s)
+				keys = append(keys, s.key)
+			}
+		}
+		if len(keys) > 0 {
+			found, err := q.resultsCache.Fetch(ctx, keys)
+			if err != nil {
+				level.Warn(q.logger).Log("msg", "failed to fetch label values cardinality from cache", "err", err)
+			}
+			for _, s := range splits {
+				b, ok := found[s.key]
+				if !ok || s.key == "" {
+					continue
+				}
+				cached := new(metastorev1.QueryMetadataLabelsResponse)
+				if err = cached.UnmarshalVT(b); err != nil {
+					level.Warn(q.logger).Log("msg", "failed to decode cached label values cardinality", "err", err)
+					continue
+				}
+				s.counts = cached
+				s.hit = true
+			}
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(resultsCacheConcurrency)
+	var hits int
+	for _, s := range splits {
+		if s.hit {
+			hits++
+			continue
+		}
+		g.Go(func() error {
+			resp, err := q.metadataQueryClient.QueryMetadataLabels(gctx, &metastorev1.QueryMetadataLabelsRequest{
+				TenantId:      tenants,
+				StartTime:     s.startTime,
+				EndTime:       s.endTime,
+				Query:         labelSelector,
+				Labels:        []string{req.Name},
+				CountDatasets: true,
+			})
+			if err != nil {
+				return err
+			}
+			s.counts = resp
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return nil, err
+	}
+	span.SetTag("results_cache_hits", hits)
+	span.SetTag("results_cache_misses", len(splits)-hits)
+
+	items := make(map[string][]byte)
+	counts = make(map[string]int64)
+	for _, s := range splits {
+		for i, ls := range s.counts.Labels {
+			if len(ls.Labels) != 1 || ls.Labels[0].Value == "" || i >= len(s.counts.DatasetCounts) {
+				continue
+			}
+			counts[ls.Labels[0].Value] += s.counts.DatasetCounts[i]
+		}
+		if s.key == "" || s.hit {
+			continue
+		}
+		b, err := s.counts.MarshalVT()
+		if err == nil && len(b) <= q.resultsCacheConfig.MaxItemSize {
+			items[s.key] = b
+		}
+	}
+	if len(items) > 0 {
+		if err = q.resultsCache.Store(ctx, items); err != nil {
+			level.Warn(q.logger).Log("msg", "failed to store label values cardinality in cache", "err", err)
+		}
+	}
+	return counts, nil
+}
+
+// labelValuesCardinalitySplits splits the time range at the boundaries
+// aligned to the results cache split interval. If the cache is disabled,
+// the time range is not split.
+func (q *QueryFrontend) labelValuesCardinalitySplits(startTime, endTime int64) []*labelValuesCardinalitySplit {
+	if q.resultsCache == nil {
+		return []*labelValuesCardinalitySplit{{startTime: startTime, endTime: endTime}}
+	}
+	interval := q.resultsCacheConfig.SplitInterval.Milliseconds()
+	var splits []*labelValuesCardinalitySplit
+	for b := startTime; b <= endTime; {
+		next := (b/interval + 1) * interval
+		s := &labelValuesCardinalitySplit{startTime: b, endTime: min(next-1, endTime)}
+		splits = append(splits, s)
+		b = next
+	}
+	return splits
+}
+
+// labelValuesCardinalityKey identifies the counts of the split.
+// Only the splits that are aligned to the interval at both ends
+// are shared by the requests with different time ranges.
+func labelValuesCardinalityKey(tenants []string, labelSelector, name string, s *labelValuesCardinalitySplit) string {
+	h := sha256.New()
+	writeInt := func(v int64) {
+		_ = binary.Write(h, binary.LittleEndian, v)
+	}
+	writeBytes := func(b []byte) {
+		writeInt(int64(len(b)))
+		_, _ = h.Write(b)
+	}
+	writeInt(labelValuesCardinalityKeyVersion)
+	writeInt(int64(len(tenants)))
+	for _, t := range tenants {
+		writeBytes([]byte(t))
+	}
+	writeBytes([]byte(labelSelector))
+	writeBytes([]byte(name))
+	writeInt(s.startTime)
+	writeInt(s.endTime)
+	return "label_values_cardinality:" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,131 @@
+package queryfrontend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/resultscache"
+	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
+	"github.com/grafana/pyroscope/v2/pkg/tenant"
+	"github.com/grafana/pyroscope/v2/pkg/test"
+	"github.com/grafana/pyroscope/v2/pkg/test/mocks/mockfrontend"
+	"github.com/grafana/pyroscope/v2/pkg/test/mocks/mockmetastorev1"
+)
+
+func TestQueryFrontend_LabelValuesCardinality(t *testing.T) {
+	const tenantID = "tenant1"
+	now := test.Time("2024-09-23T12:00:00Z")
+
+	mockLimits := mockfrontend.NewMockLimits(t)
+	mockLimits.On("MaxQueryLookback", tenantID).Return(time.Hour * 24 * 7).Maybe()
+	mockLimits.On("MaxQueryLength", tenantID).Return(time.Hour * 24 * 7).Maybe()
+
+	// Each time split has the same datasets.
+	var calls atomic.Int32
+	mockMetadataClient := mockmetastorev1.NewMockMetadataQueryServiceClient(t)
+	mockMetadataClient.On("QueryMetadataLabels", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, req *metastorev1.QueryMetadataLabelsRequest, _ ...grpc.CallOption) (*metastorev1.QueryMetadataLabelsResponse, error) {
+			calls.Inc()
+			assert.Equal(t, []string{tenantID}, req.TenantId)
+			assert.Equal(t, []string{phlaremodel.LabelNameServiceName}, req.Labels)
+			assert.Equal(t, `{__profile_type__="process_cpu:cpu:nanoseconds:cpu:nanoseconds"}`, req.Query)
+			assert.True(t, req.CountDatasets)
+			resp := new(metastorev1.QueryMetadataLabelsResponse)
+			for _, v := range []string{"service-a", "service-b", "other"} {
+				resp.Labels = append(resp.Labels, &typesv1.Labels{Labels: []*typesv1.LabelPair{
+					{Name: phlaremodel.LabelNameServiceName, Value: v},
+				}})
+			}
+			resp.DatasetCounts = []int64{2, 1, 3}
+			return resp, nil
+		})
+
+	qf := &QueryFrontend{
+		logger:              log.NewNopLogger(),
+		metadataQueryClient: mockMetadataClient,
+		limits:              mockLimits,
+		now:                 func() time.Time { return now },
+	}
+	qf.SetResultsCache(resultscache.Config{
+		SplitInterval: 12 * time.Hour,
+		MaxFreshness:  time.Hour,
+		MaxItemSize:   1 << 20,
+	}, resultscache.NewInMemory(128, time.Hour))
+
+	ctx := tenant.InjectTenantID(context.Background(), tenantID)
+	query := func(prefix, regex string, limit int64) *querierv1.LabelValuesCardinalityResponse {
+		resp, err := qf.LabelValuesCardinality(ctx, connect.NewRequest(&querierv1.LabelValuesCardinalityRequest{
+			Name:     phlaremodel.LabelNameServiceName,
+			Matchers: []string{`{__profile_type__="process_cpu:cpu:nanoseconds:cpu:nanoseconds"}`},
+			Start:    now.Add(-30 * time.Hour).UnixMilli(),
+			End:      now.Add(-time.Minute).UnixMilli(),
+			Prefix:   prefix,
+			Regex:    regex,
+			Limit:    limit,
+		}))
+		require.NoError(t, err)
+		return resp.Msg
+	}
+
+	// The time range is split at 12:00 and 00:00 UTC: 3 splits.
+	assert.Equal(t, &querierv1.LabelValuesCardinalityResponse{
+		Values: []*querierv1.LabelValueCardinality{
+			{Value: "other", Cardinality: 9},
+			{Value: "service-a", Cardinality: 6},
+		},
+		Truncated: true,
+	}, query("", "", 2))
+	assert.Equal(t, int32(3), calls.Load())
+
+	// The last split is too recent to be cached.
+	assert.Equal(t, &querierv1.LabelValuesCardinalityResponse{
+		Values: []*querierv1.LabelValueCardinality{
+			{Value: "service-a", Cardinality: 6},
+			{Value: "service-b", Cardinality: 3},
+		},
+	}, query("service", "", 0))
+	assert.Equal(t, int32(4), calls.Load())
+
+	assert.Equal(t, &querierv1.LabelValuesCardinalityResponse{
+		Values: []*querierv1.LabelValueCardinality{
+			{Value: "service-b", Cardinality: 3},
+		},
+	}, query("", ".*-b", 10))
+	assert.Equal(t, int32(5), calls.Load())
+
+	_, err := qf.LabelValuesCardinality(ctx, connect.NewRequest(&querierv1.LabelValuesCardinalityRequest{
+		Name:  phlaremodel.LabelNameServiceName,
+		Start: now.Add(-time.Hour).UnixMilli(),
+		End:   now.UnixMilli(),
+		Regex: "(",
+	}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	// Only dataset labels are recorded in the metadata:
+	// the other labels would never match.
+	for _, req := range []*querierv1.LabelValuesCardinalityRequest{
+		{Name: phlaremodel.LabelNameServiceName, Matchers: []string{`{namespace="ns"}`}},
+		{Name: phlaremodel.LabelNameServiceName, Matchers: []string{`{service_name="a", namespace!="ns"}`}},
+		{Name: "namespace"},
+	} {
+		req.Start = now.Add(-time.Hour).UnixMilli()
+		req.End = now.UnixMilli()
+		_, err = qf.LabelValuesCardinality(ctx, connect.NewRequest(req))
+		require.Error(t, err)
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	}
+	assert.Equal(t, int32(5), calls.Load())
+}
@@ -0,0 +1,33 @@
+package queryfrontend
+
+import (
+	"fmt"
+	"math"
+
+	"connectrpc.com/connect"
+
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	"github.com/grafana/pyroscope/v2/pkg/querybackend"
+)
+
+func validateSamplingRatio(ratio float64) error {
+	if math.IsNaN(ratio) || ratio < 0 || ratio > 1 {
+		return connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("sampling_ratio must be in the range (0, 1]: %v", ratio))
+	}
+	return nil
+}
+
+// querySampling returns nil if the result is exact: no profiles were skipped.
+func querySampling(s *queryv1.SamplingStats) *querierv1.QuerySampling {
+	if s == nil {
+		return nil
+	}
+	return &querierv1.QuerySampling{
+		SampleRate:      querybackend.SampleRate(s),
+		RelativeError:   querybackend.RelativeError(s),
+		ProfilesSampled: s.ProfilesSampled,
+		ProfilesTotal:   s.ProfilesTotal,
+	}
+}
@@ -116,6 +116,12 @@ func (q *QueryFrontend) selectMergeStacktracesPprof(
 			// capture if this pprof needs symbolization
 			func(ctx context.Context, upstream QueryBackend, blocks []*metastorev1.BlockMeta) QueryBackend {
 				shouldSymbolize = q.shouldSymbolize(ctx, tenantIDs, blocks)
+				if q.useSourceMaps(tenantIDs, blocks) {
+					return &backendSourceMapSymbolizer{
+						upstream: upstream,
+						resolver: q.sourceMaps,
+					}
+				}
 				return upstream
 			},
 		)
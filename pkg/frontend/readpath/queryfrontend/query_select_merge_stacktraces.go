@@ -23,6 +23,16 @@ func (q *QueryFrontend) SelectMergeStacktraces(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("span_selector and trace_id_selector cannot be combined"))
 	}
 
+	if err := validateSamplingRatio(c.Msg.GetSamplingRatio()); err != nil {
+		return nil, err
+	}
+	if r := c.Msg.GetSamplingRatio(); r > 0 && r < 1 {
+		switch c.Msg.Format {
+		case querierv1.ProfileFormat_PROFILE_FORMAT_DOT, querierv1.ProfileFormat_PROFILE_FORMAT_PPROF:
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("sampling_ratio is only supported with the flamegraph and tree formats"))
+		}
+	}
+
 	switch c.Msg.Format {
 	case querierv1.ProfileFormat_PROFILE_FORMAT_DOT:
 		return q.selectMergeStacktracesDot(ctx, c)
@@ -36,24 +46,30 @@ func (q *QueryFrontend) SelectMergeStacktraces(
 		}), nil
 	}
 
-	b, err := q.selectMergeStacktracesTree(ctx, c)
+	b, sampling, err := q.selectMergeStacktracesTree(ctx, c)
 	if err != nil {
 		return nil, err
 	}
-	var resp querierv1.SelectMergeStacktracesResponse
-	switch c.Msg.Format {
-	case querierv1.ProfileFormat_PROFILE_FORMAT_TREE:
-		resp.Tree = b
-	default:
-		t, err := phlaremodel.UnmarshalTree[phlaremodel.FunctionName, phlaremodel.FunctionNameI](b)
-		if err != nil {
-			return nil, err
-		}
-		resp.Flamegraph = phlaremodel.NewFlameGraph(t, c.Msg.GetMaxNodes())
+	resp := querierv1.SelectMergeStacktracesResponse{Sampling: querySampling(sampling)}
+	if resp.Tree, resp.Flamegraph, err = treeResponse(c.Msg, b); err != nil {
+		return nil, err
 	}
 	return connect.NewResponse(&resp), nil
 }
 
+// treeResponse returns either the tree or the flamegraph,
+// depending on the format requested.
+func treeResponse(req *querierv1.SelectMergeStacktracesRequest, b []byte) ([]byte, *querierv1.FlameGraph, error) {
+	if req.Format == querierv1.ProfileFormat_PROFILE_FORMAT_TREE {
+		return b, nil, nil
+	}
+	t, err := phlaremodel.UnmarshalTree[phlaremodel.FunctionName, phlaremodel.FunctionNameI](b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, phlaremodel.NewFlameGraph(t, req.GetMaxNodes()), nil
+}
+
 func (q *QueryFrontend) selectMergeStacktracesDot(
 	ctx context.Context,
 	c *connect.Request[querierv1.SelectMergeStacktracesRequest],
@@ -100,32 +116,32 @@ func (q *QueryFrontend) selectMergeStacktracesDot(
 func (q *QueryFrontend) selectMergeStacktracesTree(
 	ctx context.Context,
 	c *connect.Request[querierv1.SelectMergeStacktracesRequest],
-) (tree []byte, err error) {
+) (tree []byte, sampling *queryv1.SamplingStats, err error) {
 	tenantIDs, err := tenant.TenantIDs(ctx)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 	empty, err := validation.SanitizeTimeRange(q.limits, tenantIDs, &c.Msg.Start, &c.Msg.End)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 	if empty {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	maxNodes, err := validation.ValidateMaxNodes(q.limits, tenantIDs, c.Msg.GetMaxNodes())
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	_, err = phlaremodel.ParseProfileTypeSelector(c.Msg.ProfileTypeID)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	labelSelector, err := buildLabelSelectorWithProfileType(c.Msg.LabelSelector, c.Msg.ProfileTypeID)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 	useSymbolRefs := q.useSymbolRefTrees(tenantIDs)
 	treeQuery := &queryv1.TreeQuery{
@@ -134,6 +150,7 @@ func (q *QueryFrontend) selectMergeStacktracesTree(
 		ProfileIdSelector:  c.Msg.ProfileIdSelector,
 		TraceIdSelector:    c.Msg.TraceIdSelector,
 		SpanSelector:       c.Msg.SpanSelector,
+		SamplingRatio:      c.Msg.GetSamplingRatio(),
 	}
 	if useSymbolRefs {
 		q.symbolRefTreeQuery(treeQuery, tenantIDs)
@@ -149,27 +166,35 @@ func (q *QueryFrontend) selectMergeStacktracesTree(
 			}},
 		},
 		func(ctx context.Context, upstream QueryBackend, blocks []*metastorev1.BlockMeta) QueryBackend {
-			if useSymbolRefs {
+			// Source maps are applied to pprof results: symbol-ref trees
+			// are not used when JavaScript frames are to be symbolized.
+			useSourceMaps := q.useSourceMaps(tenantIDs, blocks)
+			if useSymbolRefs && !useSourceMaps {
 				return upstream
 			}
-			shouldSymbolize := q.shouldSymbolize(ctx, tenantIDs, blocks)
-			if !shouldSymbolize {
-				return upstream
+			if q.shouldSymbolize(ctx, tenantIDs, blocks) {
+				upstream = &backendTreeSymbolizer{
+					upstream:   upstream,
+					symbolizer: q.symbolizer,
+				}
 			}
-			return &backendTreeSymbolizer{
-				upstream:   upstream,
-				symbolizer: q.symbolizer,
+			if useSourceMaps {
+				upstream = &backendSourceMapSymbolizer{
+					upstream: upstream,
+					resolver: q.sourceMaps,
+				}
 			}
+			return upstream
 		},
 	)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if report == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 	if err := q.resolveSymbolRefs(ctx, report, maxNodes); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return report.Tree.Tree, nil
+	return report.Tree.Tree, report.Tree.Sampling, nil
 }
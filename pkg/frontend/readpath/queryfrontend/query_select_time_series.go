@@ -45,6 +45,10 @@ func (q *QueryFrontend) SelectSeries(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("step must be >= 1ms"))
 	}
 
+	if err = validateSamplingRatio(c.Msg.GetSamplingRatio()); err != nil {
+		return nil, err
+	}
+
 	stepMs := time.Duration(c.Msg.Step * float64(time.Second)).Milliseconds()
 	start := c.Msg.Start - stepMs
 
@@ -54,21 +58,29 @@ func (q *QueryFrontend) SelectSeries(
 	}
 
 	// TODO: Once queryCompact is fully rolled out, use it for all queries and remove queryStandard.
-	var series []*typesv1.Series
-	if c.Msg.GetExemplarType() == typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL {
-		series, err = q.queryCompact(ctx, start, c.Msg.End, labelSelector, c.Msg)
-	} else {
-		series, err = q.queryStandard(ctx, start, c.Msg.End, labelSelector, c.Msg)
+	var (
+		series   []*typesv1.Series
+		sampling *queryv1.SamplingStats
+	)
+	switch c.Msg.GetExemplarType() {
+	case typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL,
+		typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN:
+		series, sampling, err = q.queryCompact(ctx, start, c.Msg.End, labelSelector, c.Msg)
+	default:
+		series, sampling, err = q.queryStandard(ctx, start, c.Msg.End, labelSelector, c.Msg)
 	}
 	if err != nil {
 		return nil, err
 	}
 
 	series = timeseries.TopSeries(series, int(c.Msg.GetLimit()))
-	return connect.NewResponse(&querierv1.SelectSeriesResponse{Series: series}), nil
+	return connect.NewResponse(&querierv1.SelectSeriesResponse{
+		Series:   series,
+		Sampling: querySampling(sampling),
+	}), nil
 }
 
-func (q *QueryFrontend) queryStandard(ctx context.Context, start, end int64, labelSelector string, req *querierv1.SelectSeriesRequest) ([]*typesv1.Series, error) {
+func (q *QueryFrontend) queryStandard(ctx context.Context, start, end int64, labelSelector string, req *querierv1.SelectSeriesRequest) ([]*typesv1.Series, *queryv1.SamplingStats, error) {
 	report, err := q.querySingle(ctx, &queryv1.QueryRequest{
 		StartTime:     start,
 		EndTime:       end,
@@ -76,27 +88,29 @@ func (q *QueryFrontend) queryStandard(ctx context.Context, start, end int64, lab
 		Query: []*queryv1.Query{{
 			QueryType: queryv1.QueryType_QUERY_TIME_SERIES,
 			TimeSeries: &queryv1.TimeSeriesQuery{
-				Step:         req.GetStep(),
-				GroupBy:      req.GetGroupBy(),
-				Limit:        req.GetLimit(),
-				ExemplarType: req.GetExemplarType(),
+				Step:                 req.GetStep(),
+				GroupBy:              req.GetGroupBy(),
+				Limit:                req.GetLimit(),
+				ExemplarType:         req.GetExemplarType(),
+				SamplingRatio:        req.GetSamplingRatio(),
+				MaxExemplarsPerPoint: req.GetMaxExemplarsPerPoint(),
 			},
 		}},
 	}, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if report == nil || report.TimeSeries == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
-	return report.TimeSeries.TimeSeries, nil
+	return report.TimeSeries.TimeSeries, report.TimeSeries.Sampling, nil
 }
 
 // queryCompact uses the compact time series format with attribute table interning.
-// Currently only used for exemplar retrieval (EXEMPLAR_TYPE_INDIVIDUAL).
+// Currently only used for exemplar retrieval (EXEMPLAR_TYPE_INDIVIDUAL and EXEMPLAR_TYPE_SPAN).
 // The legacy queryStandard path is used for all other time series queries.
 // TODO: Migrate all queries to use queryCompact and remove queryStandard.
-func (q *QueryFrontend) queryCompact(ctx context.Context, start, end int64, labelSelector string, req *querierv1.SelectSeriesRequest) ([]*typesv1.Series, error) {
+func (q *QueryFrontend) queryCompact(ctx context.Context, start, end int64, labelSelector string, req *querierv1.SelectSeriesRequest) ([]*typesv1.Series, *queryv1.SamplingStats, error) {
 	report, err := q.querySingle(ctx, &queryv1.QueryRequest{
 		StartTime:     start,
 		EndTime:       end,
@@ -104,21 +118,24 @@ func (q *QueryFrontend) queryCompact(ctx context.Context, start, end int64, labe
 		Query: []*queryv1.Query{{
 			QueryType: queryv1.QueryType_QUERY_TIME_SERIES_COMPACT,
 			TimeSeriesCompact: &queryv1.TimeSeriesQuery{
-				Step:         req.GetStep(),
-				GroupBy:      req.GetGroupBy(),
-				Limit:        req.GetLimit(),
-				ExemplarType: req.GetExemplarType(),
+				Step:                 req.GetStep(),
+				GroupBy:              req.GetGroupBy(),
+				Limit:                req.GetLimit(),
+				ExemplarType:         req.GetExemplarType(),
+				SamplingRatio:        req.GetSamplingRatio(),
+				MaxExemplarsPerPoint: req.GetMaxExemplarsPerPoint(),
 			},
 		}},
 	}, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if report == nil || report.TimeSeriesCompact == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	return expandQuerySeries(report.TimeSeriesCompact.TimeSeries, report.TimeSeriesCompact.AttributeTable), nil
+	series := expandQuerySeries(report.TimeSeriesCompact.TimeSeries, report.TimeSeriesCompact.AttributeTable)
+	return series, report.TimeSeriesCompact.Sampling, nil
 }
 
 func expandQuerySeries(series []*queryv1.Series, table *queryv1.AttributeTable) []*typesv1.Series {
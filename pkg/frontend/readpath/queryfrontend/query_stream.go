@@ -0,0 +1,165 @@
+package queryfrontend
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/model/timeseries"
+	"github.com/grafana/pyroscope/v2/pkg/querybackend/queryplan"
+)
+
+var _ querierv1connect.StreamingQueryServiceHandler = (*QueryFrontend)(nil)
+
+// StreamingQueryBackend is implemented by the query backend clients
+// that can stream partial results.
+type StreamingQueryBackend interface {
+	InvokeStream(ctx context.Context, req *queryv1.InvokeRequest, fn func(*queryv1.InvokeStreamResponse) error) error
+}
+
+// partialResults receives the reports merged so far while the query is
+// executed. Partial results are only streamed if the query backend
+// supports it, and the results are not post-processed in the frontend
+// (e.g., symbolized), or served from the results cache.
+type partialResults struct {
+	send func([]*queryv1.Report, *querierv1.QueryProgress) error
+	// progress is the progress of the query, as of the
+	// last response received from the query backend.
+	progress *querierv1.QueryProgress
+}
+
+type partialResultsContextKey struct{}
+
+func withPartialResults(ctx context.Context, p *partialResults) context.Context {
+	return context.WithValue(ctx, partialResultsContextKey{}, p)
+}
+
+func partialResultsFromContext(ctx context.Context) *partialResults {
+	p, _ := ctx.Value(partialResultsContextKey{}).(*partialResults)
+	return p
+}
+
+// invoke executes the query plan, streaming partial
+// results if they have been requested.
+func (q *QueryFrontend) invoke(
+	ctx context.Context,
+	backend QueryBackend,
+	req *queryv1.InvokeRequest,
+) (*queryv1.InvokeResponse, error) {
+	partial := partialResultsFromContext(ctx)
+	if partial == nil {
+		return backend.Invoke(ctx, req)
+	}
+	s, ok := backend.(StreamingQueryBackend)
+	if !ok {
+		blocks := queryplan.CountBlocks(req.QueryPlan.Root)
+		resp, err := backend.Invoke(ctx, req)
+		if err == nil {
+			partial.progress = &querierv1.QueryProgress{BlocksDone: blocks, BlocksTotal: blocks}
+		}
+		return resp, err
+	}
+	var resp *queryv1.InvokeResponse
+	err := s.InvokeStream(ctx, req, func(r *queryv1.InvokeStreamResponse) error {
+		partial.progress = r.Progress
+		if r.Final {
+			resp = r.Response
+			return nil
+		}
+		return partial.send(r.GetResponse().GetReports(), r.Progress)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errors.New("query backend: stream closed without the final response")
+	}
+	return resp, nil
+}
+
+func findReport(reports []*queryv1.Report, types ...queryv1.ReportType) *queryv1.Report {
+	for _, r := range reports {
+		for _, t := range types {
+			if r.ReportType == t {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+func (q *QueryFrontend) SelectMergeStacktracesStream(
+	ctx context.Context,
+	c *connect.Request[querierv1.SelectMergeStacktracesRequest],
+	stream *connect.ServerStream[querierv1.SelectMergeStacktracesStreamResponse],
+) error {
+	switch c.Msg.Format {
+	case querierv1.ProfileFormat_PROFILE_FORMAT_DOT, querierv1.ProfileFormat_PROFILE_FORMAT_PPROF:
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("only the flamegraph and tree formats can be streamed"))
+	}
+	partial := &partialResults{
+		send: func(reports []*queryv1.Report, progress *querierv1.QueryProgress) error {
+			msg := &querierv1.SelectMergeStacktracesStreamResponse{Progress: progress}
+			// Trees with symbol references are only
+			// resolved once the query completes.
+			r := findReport(reports, queryv1.ReportType_REPORT_TREE)
+			if r != nil && r.Tree.SymbolRefs == nil && r.Tree.Symbols == nil {
+				var err error
+				if msg.Tree, msg.Flamegraph, err = treeResponse(c.Msg, r.Tree.Tree); err != nil {
+					return err
+				}
+			}
+			return stream.Send(msg)
+		},
+	}
+	resp, err := q.SelectMergeStacktraces(withPartialResults(ctx, partial), c)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&querierv1.SelectMergeStacktracesStreamResponse{
+		Flamegraph: resp.Msg.Flamegraph,
+		Tree:       resp.Msg.Tree,
+		Progress:   partial.progress,
+		Final:      true,
+	})
+}
+
+func (q *QueryFrontend) SelectSeriesStream(
+	ctx context.Context,
+	c *connect.Request[querierv1.SelectSeriesRequest],
+	stream *connect.ServerStream[querierv1.SelectSeriesStreamResponse],
+) error {
+	partial := &partialResults{
+		send: func(reports []*queryv1.Report, progress *querierv1.QueryProgress) error {
+			msg := &querierv1.SelectSeriesStreamResponse{Progress: progress}
+			r := findReport(reports,
+				queryv1.ReportType_REPORT_TIME_SERIES,
+				queryv1.ReportType_REPORT_TIME_SERIES_COMPACT,
+			)
+			var series []*typesv1.Series
+			switch {
+			case r == nil:
+			case r.TimeSeries != nil:
+				series = r.TimeSeries.TimeSeries
+			case r.TimeSeriesCompact != nil:
+				series = expandQuerySeries(r.TimeSeriesCompact.TimeSeries, r.TimeSeriesCompact.AttributeTable)
+			}
+			msg.Series = timeseries.TopSeries(series, int(c.Msg.GetLimit()))
+			return stream.Send(msg)
+		},
+	}
+	resp, err := q.SelectSeries(withPartialResults(ctx, partial), c)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&querierv1.SelectSeriesStreamResponse{
+		Series:   resp.Msg.Series,
+		Progress: partial.progress,
+		Final:    true,
+	})
+}
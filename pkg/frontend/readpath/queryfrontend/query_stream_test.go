@@ -0,0 +1,95 @@
+package queryfrontend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+)
+
+type unaryBackend struct{ resp *queryv1.InvokeResponse }
+
+func (b unaryBackend) Invoke(context.Context, *queryv1.InvokeRequest) (*queryv1.InvokeResponse, error) {
+	return b.resp, nil
+}
+
+type streamingBackend struct {
+	unaryBackend
+	stream []*queryv1.InvokeStreamResponse
+}
+
+func (b streamingBackend) InvokeStream(_ context.Context, _ *queryv1.InvokeRequest, fn func(*queryv1.InvokeStreamResponse) error) error {
+	for _, r := range b.stream {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestQueryFrontend_InvokeStream(t *testing.T) {
+	report := func(n int64) *queryv1.InvokeResponse {
+		return &queryv1.InvokeResponse{Reports: []*queryv1.Report{{
+			ReportType: queryv1.ReportType_REPORT_TREE,
+			Tree:       &queryv1.TreeReport{Tree: []byte{byte(n)}},
+		}}}
+	}
+	progress := func(done int64) *querierv1.QueryProgress {
+		return &querierv1.QueryProgress{BlocksDone: done, BlocksTotal: 4}
+	}
+	backend := streamingBackend{
+		stream: []*queryv1.InvokeStreamResponse{
+			{Response: report(1), Progress: progress(1)},
+			{Response: report(3), Progress: progress(3)},
+			{Response: report(4), Progress: progress(4), Final: true},
+		},
+	}
+	req := &queryv1.InvokeRequest{QueryPlan: &queryv1.QueryPlan{Root: &queryv1.QueryNode{
+		Type:   queryv1.QueryNode_READ,
+		Blocks: make([]*metastorev1.BlockMeta, 4),
+	}}}
+
+	var q QueryFrontend
+	t.Run("partial results not requested", func(t *testing.T) {
+		resp, err := q.invoke(context.Background(), backend, req)
+		require.NoError(t, err)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("streaming backend", func(t *testing.T) {
+		var done []int64
+		p := &partialResults{send: func(reports []*queryv1.Report, progress *querierv1.QueryProgress) error {
+			require.Len(t, reports, 1)
+			assert.Equal(t, []byte{byte(progress.BlocksDone)}, reports[0].Tree.Tree)
+			done = append(done, progress.BlocksDone)
+			return nil
+		}}
+		resp, err := q.invoke(withPartialResults(context.Background(), p), backend, req)
+		require.NoError(t, err)
+		assert.Equal(t, report(4), resp)
+		assert.Equal(t, []int64{1, 3}, done)
+		assert.Equal(t, progress(4), p.progress)
+	})
+
+	t.Run("unary backend", func(t *testing.T) {
+		p := &partialResults{send: func([]*queryv1.Report, *querierv1.QueryProgress) error {
+			t.Fatal("unexpected partial results")
+			return nil
+		}}
+		resp, err := q.invoke(withPartialResults(context.Background(), p), unaryBackend{resp: report(4)}, req)
+		require.NoError(t, err)
+		assert.Equal(t, report(4), resp)
+		assert.Equal(t, progress(4), p.progress)
+	})
+
+	t.Run("incomplete stream", func(t *testing.T) {
+		p := &partialResults{send: func([]*queryv1.Report, *querierv1.QueryProgress) error { return nil }}
+		_, err := q.invoke(withPartialResults(context.Background(), p), streamingBackend{stream: backend.stream[:2]}, req)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,61 @@
+package queryfrontend
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/grafana/pyroscope/v2/pkg/util/servicediscovery"
+)
+
+// Replicas keeps track of the query frontend replicas, as notified by
+// the service discovery. The frontends do not share any state: limits
+// that apply to a tenant as a whole are divided among the replicas, and
+// the requests that concern all the queries are sent to every replica.
+type Replicas struct {
+	mu        sync.RWMutex
+	addresses map[string]struct{}
+}
+
+func NewReplicas() *Replicas {
+	return &Replicas{addresses: make(map[string]struct{})}
+}
+
+func (r *Replicas) InstanceAdded(instance servicediscovery.Instance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addresses[instance.Address] = struct{}{}
+}
+
+func (r *Replicas) InstanceRemoved(instance servicediscovery.Instance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.addresses, instance.Address)
+}
+
+func (r *Replicas) InstanceChanged(servicediscovery.Instance) {}
+
+// Count returns the number of replicas. The frontend is considered
+// the only replica if none has been discovered.
+func (r *Replicas) Count() int {
+	if r == nil {
+		return 1
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return max(len(r.addresses), 1)
+}
+
+// Addresses returns the sorted addresses of the discovered replicas.
+func (r *Replicas) Addresses() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	addresses := make([]string, 0, len(r.addresses))
+	for a := range r.addresses {
+		addresses = append(addresses, a)
+	}
+	slices.Sort(addresses)
+	return addresses
+}
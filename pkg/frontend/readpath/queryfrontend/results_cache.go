@@ -0,0 +1,425 @@
+package queryfrontend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tracing"
+	"golang.org/x/sync/errgroup"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/resultscache"
+	"github.com/grafana/pyroscope/v2/pkg/model"
+	"github.com/grafana/pyroscope/v2/pkg/model/timeseries"
+	"github.com/grafana/pyroscope/v2/pkg/querybackend"
+)
+
+// The results of time series and tree queries are cached per time split:
+// the query time range is split at the boundaries aligned to the split
+// interval, and the report of each split is cached under a key that
+// includes the IDs of the blocks the split is served from. Blocks are
+// immutable, therefore a cached report remains valid until compaction
+// replaces its inputs: the key changes, and the report is computed anew.
+//
+// Only the splits that end before the max freshness period are cached:
+// recent time ranges are still being written to and compacted, and their
+// results are unlikely to be requested with the same set of blocks again.
+// On a partial hit, the missing splits are queried separately, and the
+// reports are stitched together.
+
+// SetResultsCache enables the cache of query results.
+func (q *QueryFrontend) SetResultsCache(config resultscache.Config, cache resultscache.Cache) {
+	q.resultsCacheConfig = config
+	q.resultsCache = cache
+}
+
+// resultsCacheConcurrency bounds the number of splits
+// of a single query that are queried concurrently.
+const resultsCacheConcurrency = 8
+
+// resultsCacheKeyVersion must be changed if the
+// format of the key or of the cached value changes.
+const resultsCacheKeyVersion = 1
+
+// isResultsCacheable reports whether the reports of the queries can be
+// stitched from the reports of the time splits. Symbol-ref and full
+// symbol trees carry the symbols referenced by the tree, and are not
+// cached.
+func isResultsCacheable(queries []*queryv1.Query) bool {
+	if len(queries) != 1 {
+		return false
+	}
+	switch query := queries[0]; query.QueryType {
+	case queryv1.QueryType_QUERY_TIME_SERIES:
+		return timeSeriesStep(query.TimeSeries) > 0
+	case queryv1.QueryType_QUERY_TREE:
+		if query.Tree.GetFullSymbols() { //nolint:staticcheck // bridges the deprecated full_symbols bool
+			return false
+		}
+		mode := query.Tree.GetSymbolMode()
+		return mode == queryv1.SymbolMode_SYMBOL_MODE_UNSPECIFIED || mode == queryv1.SymbolMode_SYMBOL_MODE_NAME
+	default:
+		return false
+	}
+}
+
+func timeSeriesStep(query *queryv1.TimeSeriesQuery) int64 {
+	return time.Duration(query.GetStep() * float64(time.Second)).Milliseconds()
+}
+
+type resultsCacheSplit struct {
+	// Time range of the split query.
+	startTime int64
+	endTime   int64
+	// Time series points outside of (from, to] belong
+	// to the adjacent splits, and are discarded.
+	from int64
+	to   int64
+
+	blocks []*metastorev1.BlockMeta
+	key    string
+	report *queryv1.Report
+	hit    bool
+}
+
+// resultsCacheSplits splits the request time range at the boundaries
+// aligned to the split interval.
+//
+// A time series point at t aggregates the samples in (t-step, t]; the
+// boundaries of time series splits are therefore moved to the query step
+// grid. Each split, except the first one, starts one step earlier, so that
+// the first point of the split is not affected by the samples of the
+// previous split: the point is discarded. Trees do not have such a grid,
+// and each split ends a millisecond before the next one starts.
+func (q *QueryFrontend) resultsCacheSplits(req *queryv1.InvokeRequest, blocks []*metastorev1.BlockMeta) []*resultsCacheSplit {
+	interval := q.resultsCacheConfig.SplitInterval.Milliseconds()
+	var step int64
+	if query := req.Query[0]; query.QueryType == queryv1.QueryType_QUERY_TIME_SERIES {
+		step = timeSeriesStep(query.TimeSeries)
+	}
+
+	boundaries := []int64{req.StartTime}
+	for aligned := (req.StartTime/interval + 1) * interval; aligned < req.EndTime; aligned += interval {
+		b := aligned
+		if step > 0 {
+			b = req.StartTime + (aligned-req.StartTime+step-1)/step*step
+		}
+		if b < req.EndTime && b > boundaries[len(boundaries)-1] {
+			boundaries = append(boundaries, b)
+		}
+	}
+
+	splits := make([]*resultsCacheSplit, len(boundaries))
+	for i, b := range boundaries {
+		s := &resultsCacheSplit{
+			startTime: b,
+			endTime:   req.EndTime,
+			from:      math.MinInt64,
+			to:        math.MaxInt64,
+		}
+		if i > 0 && step > 0 {
+			s.startTime = b - step
+			s.from = b
+		}
+		if i < len(boundaries)-1 {
+			next := boundaries[i+1]
+			s.endTime = next - 1
+			if step > 0 {
+				// The samples of the last millisecond of the
+				// point are included; the points after it are
+				// discarded.
+				s.endTime = next + 1
+				s.to = next
+			}
+		}
+		for _, md := range blocks {
+			if md.MinTime <= s.endTime && md.MaxTime >= s.startTime {
+				s.blocks = append(s.blocks, md)
+			}
+		}
+		splits[i] = s
+	}
+	return splits
+}
+
+// resultsCacheKey identifies the report of the split. Any parameter that
+// affects the result must be included.
+func resultsCacheKey(req *queryv1.InvokeRequest, s *resultsCacheSplit, resolution time.Duration) string {
+	h := sha256.New()
+	writeInt := func(v int64) {
+		_ = binary.Write(h, binary.LittleEndian, v)
+	}
+	writeBytes := func(b []byte) {
+		writeInt(int64(len(b)))
+		_, _ = h.Write(b)
+	}
+	writeInt(resultsCacheKeyVersion)
+	writeInt(int64(len(req.Tenant)))
+	for _, t := range req.Tenant {
+		writeBytes([]byte(t))
+	}
+	writeBytes([]byte(req.LabelSelector))
+	for _, query := range req.Query {
+		b, _ := query.MarshalVT()
+		writeBytes(b)
+	}
+	writeInt(int64(len(req.SeriesTombstones)))
+	for _, t := range req.SeriesTombstones {
+		b, _ := t.MarshalVT()
+		writeBytes(b)
+	}
+	var sanitize int64
+	if req.Options.GetSanitizeOnMerge() {
+		sanitize = 1
+	}
+	writeInt(sanitize)
+	writeInt(int64(resolution))
+	writeInt(s.startTime)
+	writeInt(s.endTime)
+	writeInt(s.from)
+	writeInt(s.to)
+	ids := make([]string, len(s.blocks))
+	for i, md := range s.blocks {
+		ids[i] = md.Id
+	}
+	slices.Sort(ids)
+	writeInt(int64(len(ids)))
+	for _, id := range ids {
+		writeBytes([]byte(id))
+	}
+	return "results:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// lookupResultsCache splits the query time range, and fetches the
+// cached reports of the splits.
+func (q *QueryFrontend) lookupResultsCache(
+	ctx context.Context,
+	req *queryv1.InvokeRequest,
+	blocks []*metastorev1.BlockMeta,
+	resolution time.Duration,
+) []*resultsCacheSplit {
+	span, ctx := tracing.StartSpanFromContext(ctx, "QueryFrontend.lookupResultsCache")
+	defer span.Finish()
+
+	splits := q.resultsCacheSplits(req, blocks)
+	maxEndTime := q.now().Add(-q.resultsCacheConfig.MaxFreshness).UnixMilli()
+	keys := make([]string, 0, len(splits))
+	for _, s := range splits {
+		if len(s.blocks) > 0 && s.endTime <= maxEndTime {
+			s.key = resultsCacheKey(req, s, resolution)
+			keys = append(keys, s.key)
+		}
+	}
+	if len(keys) == 0 {
+		return splits
+	}
+	found, err := q.resultsCache.Fetch(ctx, keys)
+	if err != nil {
+		level.Warn(q.logger).Log("msg", "failed to fetch results from cache", "err", err)
+	}
+	for _, s := range splits {
+		b, ok := found[s.key]
+		if !ok || s.key == "" {
+			continue
+		}
+		report := new(queryv1.Report)
+		if err = report.UnmarshalVT(b); err != nil {
+			level.Warn(q.logger).Log("msg", "failed to decode cached results", "err", err)
+			continue
+		}
+		s.report = report
+		s.hit = true
+	}
+	return splits
+}
+
+// resultsCacheMissWeight returns the estimated cost of the splits that
+// are not served from the cache. A block that overlaps several splits
+// is read once per split.
+func resultsCacheMissWeight(splits []*resultsCacheSplit) uint64 {
+	var weight block.DatasetWeight
+	for _, s := range splits {
+		if s.hit {
+			continue
+		}
+		for _, md := range s.blocks {
+			for _, ds := range md.Datasets {
+				weight.Add(block.WeightOf(ds))
+			}
+		}
+	}
+	return weight.Total()
+}
+
+// invokeWithResultsCache serves the query from the cached reports
+// of its time splits, querying the backend for the missing ones.
+func (q *QueryFrontend) invokeWithResultsCache(
+	ctx context.Context,
+	backend QueryBackend,
+	req *queryv1.InvokeRequest,
+	splits []*resultsCacheSplit,
+) (resp *queryv1.InvokeResponse, err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "QueryFrontend.invokeWithResultsCache")
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	var statsMu sync.Mutex
+	stats := new(queryv1.ExecutionStats)
+	reportType := querybackend.QueryReportType(req.Query[0].QueryType)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(resultsCacheConcurrency)
+	for _, s := range splits {
+		if s.hit || len(s.blocks) == 0 {
+			continue
+		}
+		g.Go(func() error {
+			split := req.CloneVT()
+			split.StartTime = s.startTime
+			split.EndTime = s.endTime
+			split.QueryPlan = q.buildQueryPlan(s.blocks, req.Query...)
+			resp, err := backend.Invoke(gctx, split)
+			if err != nil {
+				return err
+			}
+			statsMu.Lock()
+			querybackend.AddExecutionStats(stats, resp.GetDiagnostics().GetExecutionNode().GetStats())
+			statsMu.Unlock()
+			s.report = &queryv1.Report{ReportType: reportType}
+			for _, r := range resp.Reports {
+				if r.ReportType == reportType {
+					s.report = r
+					break
+				}
+			}
+			s.trim()
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return nil, err
+	}
+
+	items := make(map[string][]byte)
+	var hits, misses, uncached int
+	for _, s := range splits {
+		switch {
+		case len(s.blocks) == 0:
+		case s.hit:
+			hits++
+		case s.key != "":
+			misses++
+			b, err := s.report.MarshalVT()
+			if err == nil && len(b) <= q.resultsCacheConfig.MaxItemSize {
+				items[s.key] = b
+			}
+		default:
+			uncached++
+		}
+	}
+	span.SetTag("results_cache_hits", hits)
+	span.SetTag("results_cache_misses", misses)
+	span.SetTag("results_cache_uncached", uncached)
+	q.metrics.resultsCacheSplitsTotal.WithLabelValues("hit").Add(float64(hits))
+	q.metrics.resultsCacheSplitsTotal.WithLabelValues("miss").Add(float64(misses))
+	q.metrics.resultsCacheSplitsTotal.WithLabelValues("uncached").Add(float64(uncached))
+	stats.CacheHits = int64(hits)
+	stats.CacheMisses = int64(misses)
+	if len(items) > 0 {
+		if err = q.resultsCache.Store(ctx, items); err != nil {
+			level.Warn(q.logger).Log("msg", "failed to store results in cache", "err", err)
+		}
+	}
+
+	report, err := stitchReports(req.Query[0], splits)
+	if err != nil {
+		return nil, err
+	}
+	return &queryv1.InvokeResponse{
+		Reports: []*queryv1.Report{report},
+		Diagnostics: &queryv1.Diagnostics{
+			ExecutionNode: &queryv1.ExecutionNode{
+				Stats: stats,
+			},
+		},
+	}, nil
+}
+
+// trim discards the time series points that belong to the adjacent splits.
+func (s *resultsCacheSplit) trim() {
+	ts := s.report.GetTimeSeries()
+	if ts == nil {
+		return
+	}
+	series := ts.TimeSeries[:0]
+	for _, x := range ts.TimeSeries {
+		x.Points = slices.DeleteFunc(x.Points, func(p *typesv1.Point) bool {
+			return p.Timestamp <= s.from || p.Timestamp > s.to
+		})
+		if len(x.Points) > 0 {
+			series = append(series, x)
+		}
+	}
+	ts.TimeSeries = series
+}
+
+func stitchReports(query *queryv1.Query, splits []*resultsCacheSplit) (*queryv1.Report, error) {
+	switch query.QueryType {
+	case queryv1.QueryType_QUERY_TIME_SERIES:
+		m := timeseries.NewMerger(true)
+		var sampling *queryv1.SamplingStats
+		for _, s := range splits {
+			if r := s.report.GetTimeSeries(); r != nil {
+				m.MergeTimeSeries(r.TimeSeries)
+				sampling = querybackend.MergeSamplingStats(sampling, r.Sampling)
+			}
+		}
+		return &queryv1.Report{
+			ReportType: queryv1.ReportType_REPORT_TIME_SERIES,
+			TimeSeries: &queryv1.TimeSeriesReport{
+				Query:      query.TimeSeries.CloneVT(),
+				TimeSeries: m.TimeSeries(),
+				Sampling:   sampling,
+			},
+		}, nil
+
+	case queryv1.QueryType_QUERY_TREE:
+		m := model.NewTreeMerger[model.FunctionName, model.FunctionNameI]()
+		var sampling *queryv1.SamplingStats
+		for _, s := range splits {
+			r := s.report.GetTree()
+			sampling = querybackend.MergeSamplingStats(sampling, r.GetSampling())
+			if len(r.GetTree()) > 0 {
+				if err := m.MergeTreeBytes(r.Tree); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return &queryv1.Report{
+			ReportType: queryv1.ReportType_REPORT_TREE,
+			Tree: &queryv1.TreeReport{
+				Query:    query.Tree.CloneVT(),
+				Tree:     m.Tree().Bytes(query.Tree.GetMaxNodes(), nil),
+				Sampling: sampling,
+			},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("results of %s cannot be stitched", query.QueryType)
+	}
+}
@@ -0,0 +1,237 @@
+package queryfrontend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/resultscache"
+	"github.com/grafana/pyroscope/v2/pkg/model"
+	"github.com/grafana/pyroscope/v2/pkg/model/timeseries"
+	"github.com/grafana/pyroscope/v2/pkg/test"
+)
+
+// samplesBackend serves time series and tree queries from raw samples,
+// the same way the query backend aggregates them.
+type samplesBackend struct {
+	series []*typesv1.Series
+
+	mu       sync.Mutex
+	requests []*queryv1.InvokeRequest
+}
+
+func newSamplesBackend(start, end time.Time, interval time.Duration) *samplesBackend {
+	b := new(samplesBackend)
+	for _, service := range []string{"service-a", "service-b"} {
+		s := &typesv1.Series{Labels: []*typesv1.LabelPair{{Name: "service_name", Value: service}}}
+		for ts := start; !ts.After(end); ts = ts.Add(interval) {
+			s.Points = append(s.Points, &typesv1.Point{Timestamp: ts.UnixMilli(), Value: float64(ts.UnixMilli()%7 + 1)})
+		}
+		b.series = append(b.series, s)
+	}
+	return b
+}
+
+func (b *samplesBackend) Invoke(_ context.Context, req *queryv1.InvokeRequest) (*queryv1.InvokeResponse, error) {
+	b.mu.Lock()
+	b.requests = append(b.requests, req)
+	b.mu.Unlock()
+
+	var series []*typesv1.Series
+	for _, s := range b.series {
+		x := &typesv1.Series{Labels: s.Labels}
+		for _, p := range s.Points {
+			if p.Timestamp >= req.StartTime && p.Timestamp <= req.EndTime {
+				x.Points = append(x.Points, p.CloneVT())
+			}
+		}
+		if len(x.Points) > 0 {
+			series = append(series, x)
+		}
+	}
+
+	query := req.Query[0]
+	report := &queryv1.Report{ReportType: queryReportTypeOf(query)}
+	switch query.QueryType {
+	case queryv1.QueryType_QUERY_TIME_SERIES:
+		step := timeSeriesStep(query.TimeSeries)
+		sum := typesv1.TimeSeriesAggregationType_TIME_SERIES_AGGREGATION_TYPE_SUM
+		report.TimeSeries = &queryv1.TimeSeriesReport{
+			Query:      query.TimeSeries.CloneVT(),
+			TimeSeries: timeseries.RangeSeries(timeseries.NewTimeSeriesMergeIterator(series), req.StartTime+step, req.EndTime, step, &sum),
+		}
+	case queryv1.QueryType_QUERY_TREE:
+		tree := new(model.Tree[model.FunctionName, model.FunctionNameI])
+		for _, s := range series {
+			for _, p := range s.Points {
+				tree.InsertStack(int64(p.Value), "main", model.FunctionName(s.Labels[0].Value))
+			}
+		}
+		report.Tree = &queryv1.TreeReport{
+			Query: query.Tree.CloneVT(),
+			Tree:  tree.Bytes(query.Tree.GetMaxNodes(), nil),
+		}
+	}
+	return &queryv1.InvokeResponse{Reports: []*queryv1.Report{report}}, nil
+}
+
+func (b *samplesBackend) reset() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(b.requests)
+	b.requests = nil
+	return n
+}
+
+func queryReportTypeOf(query *queryv1.Query) queryv1.ReportType {
+	if query.QueryType == queryv1.QueryType_QUERY_TREE {
+		return queryv1.ReportType_REPORT_TREE
+	}
+	return queryv1.ReportType_REPORT_TIME_SERIES
+}
+
+func testBlocks(start, end time.Time, d time.Duration) []*metastorev1.BlockMeta {
+	var blocks []*metastorev1.BlockMeta
+	for ts := start; ts.Before(end); ts = ts.Add(d) {
+		blocks = append(blocks, &metastorev1.BlockMeta{
+			Id:       test.ULID(ts.Format(time.RFC3339)),
+			MinTime:  ts.UnixMilli(),
+			MaxTime:  ts.Add(d).UnixMilli() - 1,
+			Datasets: []*metastorev1.Dataset{{Size: 100, TableOfContents: []uint64{0, 10, 20}}},
+		})
+	}
+	return blocks
+}
+
+func TestQueryFrontend_ResultsCache(t *testing.T) {
+	now := test.Time("2024-09-23T12:00:00Z")
+	dataStart := now.Add(-72 * time.Hour)
+	backend := newSamplesBackend(dataStart, now, 10*time.Second)
+	blocks := testBlocks(dataStart, now.Add(time.Hour), 6*time.Hour)
+
+	for _, tc := range []struct {
+		name  string
+		query *queryv1.Query
+		// Time series splits overlap with the adjacent
+		// splits by one step, and include their blocks.
+		compactedMisses int
+	}{
+		{
+			name: "time series",
+			query: &queryv1.Query{
+				QueryType:  queryv1.QueryType_QUERY_TIME_SERIES,
+				TimeSeries: &queryv1.TimeSeriesQuery{Step: 15},
+			},
+			compactedMisses: 3,
+		},
+		{
+			name: "tree",
+			query: &queryv1.Query{
+				QueryType: queryv1.QueryType_QUERY_TREE,
+				Tree:      &queryv1.TreeQuery{MaxNodes: 16},
+			},
+			compactedMisses: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			qf := NewQueryFrontend(log.NewNopLogger(), nil, nil, nil, backend, nil, nil, nil)
+			qf.now = func() time.Time { return now }
+			qf.SetResultsCache(resultscache.Config{
+				SplitInterval: 12 * time.Hour,
+				MaxFreshness:  time.Hour,
+				MaxItemSize:   1 << 20,
+			}, resultscache.NewInMemory(128, time.Hour))
+
+			req := &queryv1.InvokeRequest{
+				Tenant:        []string{"tenant-a"},
+				StartTime:     now.Add(-50*time.Hour).UnixMilli() + 7,
+				EndTime:       now.Add(-time.Minute).UnixMilli(),
+				LabelSelector: "{}",
+				Query:         []*queryv1.Query{tc.query},
+			}
+			expected, err := backend.Invoke(context.Background(), req)
+			require.NoError(t, err)
+			backend.reset()
+
+			// assertStitched returns the estimated cost of the splits
+			// not served from the cache.
+			assertStitched := func(t *testing.T, blocks []*metastorev1.BlockMeta) uint64 {
+				splits := qf.lookupResultsCache(context.Background(), req, blocks, 0)
+				resp, err := qf.invokeWithResultsCache(context.Background(), backend, req.CloneVT(), splits)
+				require.NoError(t, err)
+				require.Len(t, resp.Reports, 1)
+				assertReportsEqual(t, expected.Reports[0], resp.Reports[0])
+				return resultsCacheMissWeight(splits)
+			}
+
+			// The time range is split at 00:00 and 12:00 UTC: 5 splits.
+			missed := assertStitched(t, blocks)
+			assert.Equal(t, 5, backend.reset())
+
+			// The last split is too recent to be cached:
+			// only its blocks are charged.
+			lastSplit := qf.resultsCacheSplits(req, blocks)[4]
+			assert.Equal(t, uint64(100*len(lastSplit.blocks)), assertStitched(t, blocks))
+			assert.Less(t, uint64(100*len(lastSplit.blocks)), missed)
+			assert.Equal(t, 1, backend.reset())
+
+			// The blocks of the third split have been compacted.
+			compacted := make([]*metastorev1.BlockMeta, 0, len(blocks))
+			for _, md := range blocks {
+				if md.MinTime >= now.Add(-36*time.Hour).UnixMilli() && md.MaxTime < now.Add(-24*time.Hour).UnixMilli() {
+					md = md.CloneVT()
+					md.Id = test.ULID(time.UnixMilli(md.MinTime).Add(time.Minute).Format(time.RFC3339))
+				}
+				compacted = append(compacted, md)
+			}
+			assertStitched(t, compacted)
+			assert.Equal(t, tc.compactedMisses+1, backend.reset())
+		})
+	}
+}
+
+func assertReportsEqual(t *testing.T, expected, actual *queryv1.Report) {
+	t.Helper()
+	assert.Equal(t, expected.ReportType, actual.ReportType)
+	switch expected.ReportType {
+	case queryv1.ReportType_REPORT_TIME_SERIES:
+		require.Equal(t, len(expected.TimeSeries.TimeSeries), len(actual.TimeSeries.TimeSeries))
+		for i, s := range expected.TimeSeries.TimeSeries {
+			assert.True(t, s.EqualVT(actual.TimeSeries.TimeSeries[i]), fmt.Sprintf("series %d differs", i))
+		}
+	case queryv1.ReportType_REPORT_TREE:
+		e, err := model.UnmarshalTree[model.FunctionName, model.FunctionNameI](expected.Tree.Tree)
+		require.NoError(t, err)
+		a, err := model.UnmarshalTree[model.FunctionName, model.FunctionNameI](actual.Tree.Tree)
+		require.NoError(t, err)
+		assert.Equal(t, e.String(), a.String())
+	}
+}
+
+func TestIsResultsCacheable(t *testing.T) {
+	assert.True(t, isResultsCacheable([]*queryv1.Query{{
+		QueryType:  queryv1.QueryType_QUERY_TIME_SERIES,
+		TimeSeries: &queryv1.TimeSeriesQuery{Step: 15},
+	}}))
+	assert.True(t, isResultsCacheable([]*queryv1.Query{{
+		QueryType: queryv1.QueryType_QUERY_TREE,
+		Tree:      &queryv1.TreeQuery{},
+	}}))
+	assert.False(t, isResultsCacheable([]*queryv1.Query{{
+		QueryType: queryv1.QueryType_QUERY_TREE,
+		Tree:      &queryv1.TreeQuery{SymbolMode: queryv1.SymbolMode_SYMBOL_MODE_REFS},
+	}}))
+	assert.False(t, isResultsCacheable([]*queryv1.Query{{
+		QueryType: queryv1.QueryType_QUERY_PPROF,
+		Pprof:     &queryv1.PprofQuery{},
+	}}))
+}
@@ -0,0 +1,45 @@
+package resultscache
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// Cache stores serialized query results. Values may be evicted
+// at any time; a cache error is never fatal for the query, and
+// the results are computed as if they were not found.
+type Cache interface {
+	// Fetch returns the values of the keys found in the cache.
+	Fetch(ctx context.Context, keys []string) (map[string][]byte, error)
+	// Store adds the values to the cache.
+	Store(ctx context.Context, items map[string][]byte) error
+}
+
+// InMemory is a Cache that keeps the most recently used
+// values in memory of the process.
+type InMemory struct {
+	lru *expirable.LRU[string, []byte]
+}
+
+func NewInMemory(maxItems int, ttl time.Duration) *InMemory {
+	return &InMemory{lru: expirable.NewLRU[string, []byte](max(maxItems, 1), nil, ttl)}
+}
+
+func (c *InMemory) Fetch(_ context.Context, keys []string) (map[string][]byte, error) {
+	found := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		if v, ok := c.lru.Get(k); ok {
+			found[k] = v
+		}
+	}
+	return found, nil
+}
+
+func (c *InMemory) Store(_ context.Context, items map[string][]byte) error {
+	for k, v := range items {
+		c.lru.Add(k, v)
+	}
+	return nil
+}
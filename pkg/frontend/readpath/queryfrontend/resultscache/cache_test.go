@@ -0,0 +1,62 @@
+package resultscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemory_FetchStore(t *testing.T) {
+	c := NewInMemory(2, time.Hour)
+	ctx := context.Background()
+	require.NoError(t, c.Store(ctx, map[string][]byte{"a": []byte("1"), "b": []byte("2")}))
+
+	found, err := c.Fetch(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, found)
+
+	// "b" is the least recently used item.
+	_, _ = c.Fetch(ctx, []string{"a"})
+	require.NoError(t, c.Store(ctx, map[string][]byte{"c": []byte("3")}))
+	found, err = c.Fetch(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("1"), "c": []byte("3")}, found)
+}
+
+func TestMemcached_FetchStore(t *testing.T) {
+	client := cache.NewMockCache()
+	c := newMemcached(client, time.Hour)
+	ctx := context.Background()
+	require.NoError(t, c.Store(ctx, map[string][]byte{"a": []byte("1"), "b": []byte("2")}))
+
+	found, err := c.Fetch(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, found)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  Config
+		invalid bool
+	}{
+		{name: "disabled"},
+		{name: "inmemory", config: Config{Backend: BackendInMemory, SplitInterval: time.Hour}},
+		{name: "no split interval", config: Config{Backend: BackendInMemory}, invalid: true},
+		{name: "no memcached addresses", config: Config{Backend: BackendMemcached, SplitInterval: time.Hour}, invalid: true},
+		{name: "unknown backend", config: Config{Backend: "redis", SplitInterval: time.Hour}, invalid: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.invalid {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package resultscache
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	BackendInMemory  = "inmemory"
+	BackendMemcached = "memcached"
+)
+
+var supportedBackends = []string{BackendInMemory, BackendMemcached}
+
+type Config struct {
+	Backend       string                      `yaml:"backend" category:"experimental"`
+	SplitInterval time.Duration               `yaml:"split_interval" category:"experimental"`
+	MaxFreshness  time.Duration               `yaml:"max_freshness" category:"experimental"`
+	TTL           time.Duration               `yaml:"ttl" category:"experimental"`
+	MaxItemSize   int                         `yaml:"max_item_size_bytes" category:"experimental"`
+	InMemory      InMemoryConfig              `yaml:"inmemory" category:"experimental"`
+	Memcached     cache.MemcachedClientConfig `yaml:"memcached" category:"experimental"`
+}
+
+type InMemoryConfig struct {
+	MaxItems int `yaml:"max_items" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Backend, prefix+"backend", "", fmt.Sprintf("Backend of the query results cache. Supported values are: %v. The cache is disabled if empty.", supportedBackends))
+	f.DurationVar(&cfg.SplitInterval, prefix+"split-interval", 24*time.Hour, "Queries are split by time into intervals aligned to this value; the results are cached per interval.")
+	f.DurationVar(&cfg.MaxFreshness, prefix+"max-freshness", 10*time.Minute, "Results of the intervals that end within this period before now are not cached.")
+	f.DurationVar(&cfg.TTL, prefix+"ttl", 24*time.Hour, "Time to live of the cached results.")
+	f.IntVar(&cfg.MaxItemSize, prefix+"max-item-size-bytes", 1<<20, "Results larger than this size are not cached.")
+	f.IntVar(&cfg.InMemory.MaxItems, prefix+"inmemory.max-items", 1024, "Maximum number of results kept in memory.")
+	cfg.Memcached.RegisterFlagsWithPrefix(prefix+"memcached.", f)
+}
+
+func (cfg *Config) Enabled() bool { return cfg.Backend != "" }
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.SplitInterval <= 0 {
+		return errors.New("results cache split interval must be positive")
+	}
+	switch cfg.Backend {
+	case BackendInMemory:
+	case BackendMemcached:
+		if len(cfg.Memcached.Addresses) == 0 {
+			return errors.New("memcached addresses are required")
+		}
+		if err := cfg.Memcached.Validate(); err != nil {
+			return fmt.Errorf("invalid memcached config: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported results cache backend %q", cfg.Backend)
+	}
+	return nil
+}
+
+// New creates the cache of the configured backend.
+func New(cfg Config, logger log.Logger, reg prometheus.Registerer) (Cache, error) {
+	switch cfg.Backend {
+	case BackendInMemory:
+		return NewInMemory(cfg.InMemory.MaxItems, cfg.TTL), nil
+	case BackendMemcached:
+		return NewMemcached(cfg.Memcached, cfg.TTL, logger, reg)
+	default:
+		return nil, fmt.Errorf("unsupported results cache backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,44 @@
+package resultscache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const memcachedCacheName = "query-frontend-results-cache"
+
+// Memcached is a Cache backed by the dskit memcached client.
+// Values are stored asynchronously: Store does not wait for
+// the values to be written, and never fails.
+type Memcached struct {
+	client cache.Cache
+	ttl    time.Duration
+}
+
+func NewMemcached(config cache.MemcachedClientConfig, ttl time.Duration, logger log.Logger, reg prometheus.Registerer) (*Memcached, error) {
+	client, err := cache.CreateClient(memcachedCacheName, cache.BackendConfig{
+		Backend:   cache.BackendMemcached,
+		Memcached: config,
+	}, logger, reg)
+	if err != nil {
+		return nil, err
+	}
+	return newMemcached(client, ttl), nil
+}
+
+func newMemcached(client cache.Cache, ttl time.Duration) *Memcached {
+	return &Memcached{client: client, ttl: ttl}
+}
+
+func (c *Memcached) Fetch(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return c.client.GetMulti(ctx, keys), nil
+}
+
+func (c *Memcached) Store(_ context.Context, items map[string][]byte) error {
+	c.client.SetMultiAsync(items, c.ttl)
+	return nil
+}
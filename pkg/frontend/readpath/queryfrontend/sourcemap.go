@@ -0,0 +1,234 @@
+package queryfrontend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/grafana/dskit/tracing"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/sync/errgroup"
+
+	googlev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/model"
+	"github.com/grafana/pyroscope/v2/pkg/pprof"
+	"github.com/grafana/pyroscope/v2/pkg/querybackend"
+)
+
+// SourceMapResolver symbolizes minified JavaScript frames using the
+// source maps uploaded for the release the profiles were collected from.
+type SourceMapResolver interface {
+	// ReleaseLabel is the series label that identifies the release.
+	ReleaseLabel() string
+	// MaxReleases is the maximum number of releases a query is split into.
+	MaxReleases() int
+	SymbolizePprof(ctx context.Context, release string, profile *googlev1.Profile) error
+}
+
+// SetSourceMapResolver enables symbolization of JavaScript frames
+// in tree and pprof queries.
+func (q *QueryFrontend) SetSourceMapResolver(r SourceMapResolver) {
+	q.sourceMaps = r
+}
+
+// sourceMapConcurrency bounds the number of per-release queries
+// a single query is split into that run concurrently.
+const sourceMapConcurrency = 4
+
+// backendSourceMapSymbolizer symbolizes the JavaScript frames of single
+// tree and pprof queries. Source maps are uploaded per release, while
+// query results are merged across series: the query is therefore split
+// into one pprof query per release, the results are symbolized with the
+// source maps of the release and merged back together. Queries matching
+// more releases than the resolver allows are not split: such results
+// are symbolized with the source maps uploaded without a release.
+type backendSourceMapSymbolizer struct {
+	upstream QueryBackend
+	resolver SourceMapResolver
+}
+
+func (b *backendSourceMapSymbolizer) Invoke(ctx context.Context, req *queryv1.InvokeRequest) (resp *queryv1.InvokeResponse, err error) {
+	if len(req.Query) != 1 {
+		return b.upstream.Invoke(ctx, req)
+	}
+	query := req.Query[0]
+	if query.QueryType != queryv1.QueryType_QUERY_TREE && query.QueryType != queryv1.QueryType_QUERY_PPROF {
+		return b.upstream.Invoke(ctx, req)
+	}
+
+	span, ctx := tracing.StartSpanFromContext(ctx, "backendSourceMapSymbolizer.Invoke")
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	var statsMu sync.Mutex
+	stats := new(queryv1.ExecutionStats)
+	releases, err := b.releases(ctx, req, stats)
+	if err != nil {
+		return nil, err
+	}
+	span.SetTag("releases", len(releases))
+
+	pprofQuery := query.Pprof
+	if query.QueryType == queryv1.QueryType_QUERY_TREE {
+		pprofQuery = &queryv1.PprofQuery{
+			MaxNodes:           query.Tree.GetMaxNodes(),
+			ProfileIdSelector:  query.Tree.GetProfileIdSelector(),
+			StackTraceSelector: query.Tree.GetStackTraceSelector(),
+			SpanSelector:       query.Tree.GetSpanSelector(),
+			TraceIdSelector:    query.Tree.GetTraceIdSelector(),
+		}
+	}
+
+	var merge pprof.ProfileMerge
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(sourceMapConcurrency)
+	for _, r := range releases {
+		g.Go(func() error {
+			split := req.CloneVT()
+			split.LabelSelector = r.selector
+			split.Query = []*queryv1.Query{{
+				QueryType: queryv1.QueryType_QUERY_PPROF,
+				Pprof:     pprofQuery.CloneVT(),
+			}}
+			resp, err := b.upstream.Invoke(gctx, split)
+			if err != nil {
+				return err
+			}
+			statsMu.Lock()
+			querybackend.AddExecutionStats(stats, resp.GetDiagnostics().GetExecutionNode().GetStats())
+			statsMu.Unlock()
+			if len(resp.Reports) != 1 {
+				return fmt.Errorf("query/report count mismatch: 1 query but %d reports", len(resp.Reports))
+			}
+			data := resp.Reports[0].GetPprof().GetPprof()
+			if data == nil {
+				return nil
+			}
+			var prof googlev1.Profile
+			if err = pprof.Unmarshal(data, &prof); err != nil {
+				return fmt.Errorf("failed to unmarshal profile: %w", err)
+			}
+			if err = b.resolver.SymbolizePprof(gctx, r.release, &prof); err != nil {
+				return fmt.Errorf("failed to symbolize JavaScript frames: %w", err)
+			}
+			return merge.Merge(&prof, false)
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return nil, err
+	}
+
+	prof := merge.Profile()
+	report := &queryv1.Report{ReportType: queryv1.ReportType_REPORT_PPROF}
+	if query.QueryType == queryv1.QueryType_QUERY_TREE {
+		tree, err := model.TreeFromBackendProfile(prof, query.Tree.GetMaxNodes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to build tree: %w", err)
+		}
+		report.ReportType = queryv1.ReportType_REPORT_TREE
+		report.Tree = &queryv1.TreeReport{Query: query.Tree.CloneVT(), Tree: tree}
+	} else {
+		data, err := pprof.Marshal(prof, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal profile: %w", err)
+		}
+		report.Pprof = &queryv1.PprofReport{Query: query.Pprof.CloneVT(), Pprof: data}
+	}
+
+	return &queryv1.InvokeResponse{
+		Reports: []*queryv1.Report{report},
+		Diagnostics: &queryv1.Diagnostics{
+			ExecutionNode: &queryv1.ExecutionNode{
+				Stats: stats,
+			},
+		},
+	}, nil
+}
+
+type releaseSelector struct {
+	release  string
+	selector string
+}
+
+// releases returns the label selectors the query is split into:
+// one per release, and one for the series without a release. The
+// execution stats of the label values query are added to stats.
+func (b *backendSourceMapSymbolizer) releases(ctx context.Context, req *queryv1.InvokeRequest, stats *queryv1.ExecutionStats) ([]releaseSelector, error) {
+	unsplit := []releaseSelector{{selector: req.LabelSelector}}
+	label := b.resolver.ReleaseLabel()
+	if label == "" {
+		return unsplit, nil
+	}
+
+	valuesReq := req.CloneVT()
+	valuesReq.Query = []*queryv1.Query{{
+		QueryType:   queryv1.QueryType_QUERY_LABEL_VALUES,
+		LabelValues: &queryv1.LabelValuesQuery{LabelName: label},
+	}}
+	resp, err := b.upstream.Invoke(ctx, valuesReq)
+	if err != nil {
+		return nil, err
+	}
+	querybackend.AddExecutionStats(stats, resp.GetDiagnostics().GetExecutionNode().GetStats())
+	var values []string
+	for _, r := range resp.Reports {
+		values = append(values, r.GetLabelValues().GetLabelValues()...)
+	}
+	if len(values) == 0 || len(values) > b.resolver.MaxReleases() {
+		return unsplit, nil
+	}
+
+	matchers, err := model.ParseMetricSelector(req.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	selectors := make([]releaseSelector, 0, len(values)+1)
+	// The empty value selects the series without the release label.
+	for _, v := range append([]string{""}, values...) {
+		m := labels.MustNewMatcher(labels.MatchEqual, label, v)
+		selectors = append(selectors, releaseSelector{
+			release:  v,
+			selector: matchersToLabelSelector(append(slices.Clip(matchers), m)),
+		})
+	}
+	return selectors, nil
+}
+
+// useSourceMaps determines whether JavaScript frames should be symbolized:
+// a source map resolver must be configured, every tenant on the request
+// must have symbolization enabled, and at least one of the datasets must
+// carry the release label: the segment writer records the releases of the
+// profiles in the dataset labels.
+//
+// Limitation: like shouldSymbolize, the function returns false for queries
+// served from the tenant-wide TSDB index blocks, which do not carry the
+// per-dataset labels.
+func (q *QueryFrontend) useSourceMaps(tenants []string, blocks []*metastorev1.BlockMeta) bool {
+	if q.sourceMaps == nil {
+		return false
+	}
+	for _, t := range tenants {
+		if !q.limits.SymbolizerEnabled(t) {
+			return false
+		}
+	}
+	return slices.ContainsFunc(blocks, hasReleases)
+}
+
+// hasReleases checks if a block has datasets with the release label.
+func hasReleases(block *metastorev1.BlockMeta) bool {
+	matcher, err := labels.NewMatcher(labels.MatchRegexp, metadata.LabelNameRelease, ".+")
+	if err != nil {
+		return false
+	}
+	return len(slices.Collect(metadata.FindDatasets(block, matcher))) > 0
+}
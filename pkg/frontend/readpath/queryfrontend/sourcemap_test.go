@@ -0,0 +1,163 @@
+package queryfrontend
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	googlev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/model"
+	"github.com/grafana/pyroscope/v2/pkg/pprof"
+	"github.com/grafana/pyroscope/v2/pkg/test/mocks/mockfrontend"
+)
+
+type queryBackendFunc func(context.Context, *queryv1.InvokeRequest) (*queryv1.InvokeResponse, error)
+
+func (f queryBackendFunc) Invoke(ctx context.Context, req *queryv1.InvokeRequest) (*queryv1.InvokeResponse, error) {
+	return f(ctx, req)
+}
+
+// testSourceMapResolver appends the release to the function names.
+type testSourceMapResolver struct {
+	maxReleases int
+}
+
+func (r *testSourceMapResolver) ReleaseLabel() string { return "service_version" }
+
+func (r *testSourceMapResolver) MaxReleases() int { return r.maxReleases }
+
+func (r *testSourceMapResolver) SymbolizePprof(_ context.Context, release string, p *googlev1.Profile) error {
+	for _, fn := range p.Function {
+		p.StringTable = append(p.StringTable, p.StringTable[fn.Name]+"@"+release)
+		fn.Name = int64(len(p.StringTable) - 1)
+	}
+	return nil
+}
+
+func testSourceMapBackend(t *testing.T, releases []string) (QueryBackend, func() []string) {
+	var (
+		mu        sync.Mutex
+		selectors []string
+	)
+	backend := queryBackendFunc(func(_ context.Context, req *queryv1.InvokeRequest) (*queryv1.InvokeResponse, error) {
+		require.Len(t, req.Query, 1)
+		q := req.Query[0]
+		if q.QueryType == queryv1.QueryType_QUERY_LABEL_VALUES {
+			assert.Equal(t, "service_version", q.LabelValues.LabelName)
+			return &queryv1.InvokeResponse{
+				Reports: []*queryv1.Report{{
+					LabelValues: &queryv1.LabelValuesReport{LabelValues: releases},
+				}},
+				Diagnostics: testDiagnostics(),
+			}, nil
+		}
+		require.Equal(t, queryv1.QueryType_QUERY_PPROF, q.QueryType)
+		mu.Lock()
+		selectors = append(selectors, req.LabelSelector)
+		mu.Unlock()
+		p := &googlev1.Profile{
+			SampleType:  []*googlev1.ValueType{{Type: 1, Unit: 2}},
+			StringTable: []string{"", "cpu", "nanoseconds", "fn"},
+			Function:    []*googlev1.Function{{Id: 1, Name: 3}},
+			Location:    []*googlev1.Location{{Id: 1, Line: []*googlev1.Line{{FunctionId: 1}}}},
+			Sample:      []*googlev1.Sample{{LocationId: []uint64{1}, Value: []int64{1}}},
+		}
+		b, err := pprof.Marshal(p, true)
+		require.NoError(t, err)
+		return &queryv1.InvokeResponse{
+			Reports: []*queryv1.Report{{
+				ReportType: queryv1.ReportType_REPORT_PPROF,
+				Pprof:      &queryv1.PprofReport{Pprof: b},
+			}},
+			Diagnostics: testDiagnostics(),
+		}, nil
+	})
+	return backend, func() []string {
+		sort.Strings(selectors)
+		return selectors
+	}
+}
+
+func testDiagnostics() *queryv1.Diagnostics {
+	return &queryv1.Diagnostics{ExecutionNode: &queryv1.ExecutionNode{
+		Stats: &queryv1.ExecutionStats{BytesFetched: 1},
+	}}
+}
+
+func TestBackendSourceMapSymbolizer_SplitsByRelease(t *testing.T) {
+	backend, selectors := testSourceMapBackend(t, []string{"v1", "v2"})
+	b := &backendSourceMapSymbolizer{upstream: backend, resolver: &testSourceMapResolver{maxReleases: 2}}
+
+	resp, err := b.Invoke(context.Background(), &queryv1.InvokeRequest{
+		LabelSelector: `{service_name="web"}`,
+		Query: []*queryv1.Query{{
+			QueryType: queryv1.QueryType_QUERY_PPROF,
+			Pprof:     &queryv1.PprofQuery{},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{service_name="web",service_version=""}`,
+		`{service_name="web",service_version="v1"}`,
+		`{service_name="web",service_version="v2"}`,
+	}, selectors())
+
+	require.Len(t, resp.Reports, 1)
+	var p googlev1.Profile
+	require.NoError(t, pprof.Unmarshal(resp.Reports[0].Pprof.Pprof, &p))
+	var names []string
+	for _, fn := range p.Function {
+		names = append(names, p.StringTable[fn.Name])
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"fn@", "fn@v1", "fn@v2"}, names)
+	// The label values query and the three split queries.
+	assert.Equal(t, uint64(4), resp.Diagnostics.GetExecutionNode().GetStats().GetBytesFetched())
+}
+
+func TestBackendSourceMapSymbolizer_TooManyReleases(t *testing.T) {
+	backend, selectors := testSourceMapBackend(t, []string{"v1", "v2", "v3"})
+	b := &backendSourceMapSymbolizer{upstream: backend, resolver: &testSourceMapResolver{maxReleases: 2}}
+
+	resp, err := b.Invoke(context.Background(), &queryv1.InvokeRequest{
+		LabelSelector: `{service_name="web"}`,
+		Query: []*queryv1.Query{{
+			QueryType: queryv1.QueryType_QUERY_TREE,
+			Tree:      &queryv1.TreeQuery{MaxNodes: 16},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{service_name="web"}`}, selectors())
+
+	require.Len(t, resp.Reports, 1)
+	assert.Equal(t, queryv1.ReportType_REPORT_TREE, resp.Reports[0].ReportType)
+	tree, err := model.UnmarshalTree[model.FunctionName, model.FunctionNameI](resp.Reports[0].Tree.Tree)
+	require.NoError(t, err)
+	assert.Contains(t, tree.String(), "fn@")
+}
+
+func TestQueryFrontend_useSourceMaps(t *testing.T) {
+	withRelease := &metastorev1.BlockMeta{
+		Datasets:    []*metastorev1.Dataset{{Labels: []int32{1, 1, 2}}},
+		StringTable: []string{"", metadata.LabelNameRelease, "v1"},
+	}
+	withoutRelease := &metastorev1.BlockMeta{
+		Datasets:    []*metastorev1.Dataset{{Labels: []int32{1, 1, 2}}},
+		StringTable: []string{"", metadata.LabelNameUnsymbolized, "true"},
+	}
+
+	limits := mockfrontend.NewMockLimits(t)
+	limits.On("SymbolizerEnabled", "tenant").Return(true)
+	q := &QueryFrontend{limits: limits, sourceMaps: &testSourceMapResolver{}}
+
+	assert.True(t, q.useSourceMaps([]string{"tenant"}, []*metastorev1.BlockMeta{withoutRelease, withRelease}))
+	assert.False(t, q.useSourceMaps([]string{"tenant"}, []*metastorev1.BlockMeta{withoutRelease}))
+	assert.False(t, q.useSourceMaps([]string{"tenant"}, nil))
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/grafana/dskit/tracing"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+)
+
+const (
+	bitbucketBaseURL = "https://bitbucket.org"
+	bitbucketAPIURL  = "https://api.bitbucket.org/2.0"
+)
+
+// BitbucketClient returns a client of the Bitbucket Cloud REST API. If the
+// username is set, the token is used as an app password or API token with
+// HTTP basic authentication, otherwise as an OAuth or access token. The API
+// URL defaults to https://api.bitbucket.org/2.0.
+func BitbucketClient(apiURL, username, token string, client *http.Client) *bitbucketClient {
+	if apiURL == "" {
+		apiURL = bitbucketAPIURL
+	}
+	auth := bearerToken(token)
+	if username != "" {
+		auth = basicAuth(username, token)
+	}
+	return &bitbucketClient{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		auth:       auth,
+		httpClient: client,
+	}
+}
+
+type bitbucketClient struct {
+	apiURL     string
+	auth       authorizer
+	httpClient *http.Client
+}
+
+type bitbucketLink struct {
+	Href string `json:"href"`
+}
+
+type bitbucketCommit struct {
+	Hash    string    `json:"hash"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+	Author  struct {
+		Raw  string `json:"raw"`
+		User *struct {
+			DisplayName string `json:"display_name"`
+			Nickname    string `json:"nickname"`
+			Links       struct {
+				Avatar bitbucketLink `json:"avatar"`
+			} `json:"links"`
+		} `json:"user"`
+	} `json:"author"`
+	Links struct {
+		HTML bitbucketLink `json:"html"`
+	} `json:"links"`
+}
+
+type bitbucketRepository struct {
+	MainBranch struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+}
+
+func (bb *bitbucketClient) repositoryURL(owner, repo string) string {
+	return bb.apiURL + "/repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+func (bb *bitbucketClient) GetCommit(ctx context.Context, owner, repo, ref string) (*vcsv1.CommitInfo, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "bitbucketClient.GetCommit")
+	defer sp.Finish()
+	sp.SetTag("owner", owner)
+	sp.SetTag("repo", repo)
+	sp.SetTag("ref", ref)
+
+	var commit bitbucketCommit
+	err := getJSON(ctx, bb.httpClient, bb.auth, bb.repositoryURL(owner, repo)+"/commit/"+url.PathEscape(ref), &commit)
+	if err != nil {
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return nil, notFoundAsConnectError(err)
+	}
+	if commit.Date.IsZero() {
+		err := connect.NewError(connect.CodeInternal, errors.New("commit contains no date"))
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return nil, err
+	}
+
+	commitInfo := &vcsv1.CommitInfo{
+		Sha:     commit.Hash,
+		Message: commit.Message,
+		Date:    commit.Date.Format(time.RFC3339),
+		URL:     commit.Links.HTML.Href,
+	}
+	// The author is only linked to an account if Bitbucket
+	// could match the email address of the commit.
+	if u := commit.Author.User; u != nil {
+		login := u.Nickname
+		if login == "" {
+			login = u.DisplayName
+		}
+		commitInfo.Author = &vcsv1.CommitAuthor{
+			Login:     login,
+			AvatarURL: u.Links.Avatar.Href,
+		}
+	}
+	return commitInfo, nil
+}
+
+func (bb *bitbucketClient) GetFile(ctx context.Context, req FileRequest) (File, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "bitbucketClient.GetFile")
+	defer sp.Finish()
+	sp.SetTag("owner", req.Owner)
+	sp.SetTag("repo", req.Repo)
+	sp.SetTag("path", req.Path)
+	sp.SetTag("ref", req.Ref)
+
+	ref := req.Ref
+	if ref == "" || ref == "HEAD" {
+		// The src endpoint requires a commit or a branch name.
+		var repository bitbucketRepository
+		if err := getJSON(ctx, bb.httpClient, bb.auth, bb.repositoryURL(req.Owner, req.Repo), &repository); err != nil {
+			sp.SetTag("error", true)
+			sp.SetTag("error.message", err.Error())
+			return File{}, err
+		}
+		ref = repository.MainBranch.Name
+	}
+
+	res, err := get(ctx, bb.httpClient, bb.auth, bb.repositoryURL(req.Owner, req.Repo)+"/src/"+url.PathEscape(ref)+"/"+escapePath(req.Path))
+	if err != nil {
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return File{}, err
+	}
+	defer res.Body.Close()
+
+	// Directories are listed as JSON documents.
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		err := connect.NewError(connect.CodeInvalidArgument, errors.New("path is not a file"))
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return File{}, err
+	}
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return File{}, err
+	}
+
+	return File{
+		Content: string(content),
+		URL:     fmt.Sprintf("%s/%s/%s/src/%s/%s", bitbucketBaseURL, req.Owner, req.Repo, ref, req.Path),
+	}, nil
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+)
+
+func TestBitbucketClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "user" || password != "app-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/2.0/repositories/workspace/repo":
+			_, _ = w.Write([]byte(`{"mainbranch": {"name": "main"}}`))
+		case "/2.0/repositories/workspace/repo/src/main/pkg/main.go":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("package main\n"))
+		case "/2.0/repositories/workspace/repo/src/main/pkg":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"values": []}`))
+		case "/2.0/repositories/workspace/repo/commit/main":
+			_, _ = w.Write([]byte(`{
+				"hash": "abc123",
+				"date": "2024-01-01T10:00:00+00:00",
+				"message": "test commit message",
+				"author": {
+					"raw": "Jane Doe <jane@example.com>",
+					"user": {
+						"display_name": "Jane Doe",
+						"nickname": "jane",
+						"links": {"avatar": {"href": "https://example.com/avatar.png"}}
+					}
+				},
+				"links": {"html": {"href": "https://bitbucket.org/workspace/repo/commits/abc123"}}
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	c := BitbucketClient(srv.URL+"/2.0", "user", "app-password", srv.Client())
+
+	for _, ref := range []string{"main", "HEAD"} {
+		file, err := c.GetFile(ctx, FileRequest{Owner: "workspace", Repo: "repo", Path: "pkg/main.go", Ref: ref})
+		require.NoError(t, err)
+		assert.Equal(t, File{
+			Content: "package main\n",
+			URL:     "https://bitbucket.org/workspace/repo/src/main/pkg/main.go",
+		}, file)
+	}
+
+	_, err := c.GetFile(ctx, FileRequest{Owner: "workspace", Repo: "repo", Path: "pkg", Ref: "main"})
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	_, err = c.GetFile(ctx, FileRequest{Owner: "workspace", Repo: "repo", Path: "missing.go", Ref: "main"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	commit, err := c.GetCommit(ctx, "workspace", "repo", "main")
+	require.NoError(t, err)
+	assert.Equal(t, &vcsv1.CommitInfo{
+		Sha:     "abc123",
+		Message: "test commit message",
+		Author: &vcsv1.CommitAuthor{
+			Login:     "jane",
+			AvatarURL: "https://example.com/avatar.png",
+		},
+		Date: "2024-01-01T10:00:00Z",
+		URL:  "https://bitbucket.org/workspace/repo/commits/abc123",
+	}, commit)
+
+	_, err = BitbucketClient(srv.URL+"/2.0", "", "token", srv.Client()).GetCommit(ctx, "workspace", "repo", "main")
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+}
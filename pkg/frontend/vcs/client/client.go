@@ -1,7 +1,18 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"connectrpc.com/connect"
+
+	"github.com/grafana/pyroscope/v2/pkg/util/connectgrpc"
 )
 
 var ErrNotFound = errors.New("file not found")
@@ -17,3 +28,80 @@ type FileRequest struct {
 	Path  string
 	Ref   string
 }
+
+// authorizer sets the credentials of a request.
+type authorizer func(*http.Request)
+
+// bearerToken authorizes requests with the token, if any.
+func bearerToken(token string) authorizer {
+	return func(req *http.Request) {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// basicAuth authorizes requests with the username and password, if any.
+func basicAuth(username, password string) authorizer {
+	return func(req *http.Request) {
+		if password != "" {
+			req.SetBasicAuth(username, password)
+		}
+	}
+}
+
+// get sends a GET request to the URL. The caller must close the body of
+// the response. Responses with a non-2xx status code are returned as
+// errors, with the exception of 404 which is reported as ErrNotFound.
+func get(ctx context.Context, httpClient *http.Client, auth authorizer, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	auth(req)
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 == 2 {
+		return res, nil
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, req.URL.Path)
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	code := connectgrpc.HTTPToCode(int32(res.StatusCode))
+	return nil, connect.NewError(code, fmt.Errorf("GET %s: %s: %s", req.URL.Path, res.Status, body))
+}
+
+// getJSON sends a GET request to the URL and decodes the JSON response into v.
+func getJSON(ctx context.Context, httpClient *http.Client, auth authorizer, url string, v any) error {
+	res, err := get(ctx, httpClient, auth, url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// notFoundAsConnectError converts ErrNotFound into a connect NotFound error.
+// Unlike files, missing commits are reported with connect codes.
+func notFoundAsConnectError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return connect.NewError(connect.CodeNotFound, err)
+	}
+	return err
+}
+
+// escapePath escapes the segments of a slash-separated path.
+func escapePath(p string) string {
+	segments := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
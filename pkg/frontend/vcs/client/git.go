@@ -0,0 +1,490 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/grafana/dskit/tracing"
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+	"github.com/grafana/pyroscope/v2/pkg/util/connectgrpc"
+)
+
+const (
+	gitUploadPackRequest = "application/x-git-upload-pack-request"
+	gitUploadPackResult  = "application/x-git-upload-pack-result"
+
+	// maxGitResponseSize limits the size of responses of the git server.
+	// Objects are fetched one at a time, and the largest response expected
+	// is a file.
+	maxGitResponseSize = 64 << 20
+
+	// gitObjectCacheSize is the maximum number of commits and trees
+	// cached by the client. Objects are immutable, and are shared by
+	// the requests for the files of the same directories.
+	gitObjectCacheSize = 1024
+)
+
+// GitClient returns a client of a git server that implements the smart
+// HTTP protocol version 2: repositories are addressed as
+// <base URL>/<owner>/<repo>.git. The username and token are sent with HTTP
+// basic authentication; the username defaults to "git".
+//
+// Files and commits are fetched with partial fetches, and do not require
+// a clone of the repository: the trees on the path of the file are fetched
+// one by one, without their entries, and then the file itself. The server
+// must support the "filter" fetch feature. Commit refs must be full object
+// IDs, branch or tag names.
+func GitClient(baseURL, username, token string, client *http.Client) *gitClient {
+	if username == "" {
+		username = "git"
+	}
+	objects, _ := lru.New[string, gitObject](gitObjectCacheSize)
+	return &gitClient{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		auth:         basicAuth(username, token),
+		httpClient:   client,
+		capabilities: make(map[string]map[string]string),
+		objects:      objects,
+	}
+}
+
+type gitClient struct {
+	baseURL    string
+	auth       authorizer
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	capabilities map[string]map[string]string
+
+	// Commits and trees, keyed by repository URL and object ID.
+	objects *lru.Cache[string, gitObject]
+}
+
+func (g *gitClient) repositoryURL(owner, repo string) string {
+	u := g.baseURL + "/" + escapePath(owner) + "/" + escapePath(repo)
+	if !strings.HasSuffix(u, ".git") {
+		u += ".git"
+	}
+	return u
+}
+
+func (g *gitClient) GetCommit(ctx context.Context, owner, repo, ref string) (*vcsv1.CommitInfo, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "gitClient.GetCommit")
+	defer sp.Finish()
+	sp.SetTag("owner", owner)
+	sp.SetTag("repo", repo)
+	sp.SetTag("ref", ref)
+
+	commit, err := g.getCommit(ctx, g.repositoryURL(owner, repo), ref)
+	if err != nil {
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return nil, notFoundAsConnectError(err)
+	}
+
+	commitInfo := &vcsv1.CommitInfo{
+		Sha:     commit.id,
+		Message: commit.message,
+		Date:    commit.authorDate.Format(time.RFC3339),
+	}
+	if commit.authorName != "" {
+		commitInfo.Author = &vcsv1.CommitAuthor{Login: commit.authorName}
+	}
+	return commitInfo, nil
+}
+
+func (g *gitClient) GetFile(ctx context.Context, req FileRequest) (File, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "gitClient.GetFile")
+	defer sp.Finish()
+	sp.SetTag("owner", req.Owner)
+	sp.SetTag("repo", req.Repo)
+	sp.SetTag("path", req.Path)
+	sp.SetTag("ref", req.Ref)
+
+	content, err := g.getFile(ctx, g.repositoryURL(req.Owner, req.Repo), req.Ref, req.Path)
+	if err != nil {
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return File{}, err
+	}
+	// Generic git servers have no canonical web URL for files.
+	return File{Content: string(content)}, nil
+}
+
+func (g *gitClient) getCommit(ctx context.Context, repoURL, ref string) (*gitCommit, error) {
+	caps, err := g.fetchCapabilities(ctx, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	id, err := g.resolveRef(ctx, repoURL, ref)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := g.getObject(ctx, repoURL, caps, id, gitObjectCommit)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommit(id, obj.data)
+}
+
+func (g *gitClient) getFile(ctx context.Context, repoURL, ref, path string) ([]byte, error) {
+	commit, err := g.getCommit(ctx, repoURL, ref)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := g.fetchCapabilities(ctx, repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	id := commit.tree
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		tree, err := g.getObject(ctx, repoURL, caps, id, gitObjectTree)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if id, ok = findTreeEntry(tree.data, name); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+	}
+
+	blob, err := g.getObject(ctx, repoURL, caps, id, gitObjectBlob)
+	if err != nil {
+		return nil, err
+	}
+	return blob.data, nil
+}
+
+// getObject returns the object of the given type. Commits and trees
+// are fetched without the objects they refer to, and are cached.
+func (g *gitClient) getObject(ctx context.Context, repoURL string, caps map[string]string, id string, typ gitObjectType) (gitObject, error) {
+	key := repoURL + "@" + id
+	if obj, ok := g.objects.Get(key); ok && obj.typ == typ {
+		return obj, nil
+	}
+	// Objects requested explicitly are not filtered out.
+	objects, err := g.fetch(ctx, repoURL, caps, id, typ == gitObjectCommit, "tree:0")
+	if err != nil {
+		return gitObject{}, err
+	}
+	obj, ok := objects[id]
+	if !ok {
+		return gitObject{}, fmt.Errorf("object %s is missing in the response", id)
+	}
+	if obj.typ != typ {
+		if typ == gitObjectBlob {
+			return gitObject{}, connect.NewError(connect.CodeInvalidArgument, errors.New("path is not a file"))
+		}
+		return gitObject{}, fmt.Errorf("object %s is a %s, not a %s", id, obj.typ, typ)
+	}
+	if typ != gitObjectBlob {
+		g.objects.Add(key, obj)
+	}
+	return obj, nil
+}
+
+// post sends a protocol version 2 command to the upload-pack service
+// of the repository. The caller must close the returned body.
+func (g *gitClient) post(ctx context.Context, repoURL string, body []byte) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, repoURL+"/git-upload-pack", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", gitUploadPackRequest)
+	req.Header.Set("Accept", gitUploadPackResult)
+	req.Header.Set("Git-Protocol", "version=2")
+	g.auth(req)
+	res, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, gitStatusError(req, res)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(res.Body, maxGitResponseSize), res.Body}, nil
+}
+
+func gitStatusError(req *http.Request, res *http.Response) error {
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, req.URL.Path)
+	}
+	code := connectgrpc.HTTPToCode(int32(res.StatusCode))
+	return connect.NewError(code, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, res.Status))
+}
+
+// fetchCapabilities returns the capabilities advertised by the server.
+func (g *gitClient) fetchCapabilities(ctx context.Context, repoURL string) (map[string]string, error) {
+	g.mu.Lock()
+	caps, ok := g.capabilities[repoURL]
+	g.mu.Unlock()
+	if ok {
+		return caps, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, repoURL+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Git-Protocol", "version=2")
+	g.auth(req)
+	res, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, gitStatusError(req, res)
+	}
+
+	r := bufio.NewReader(io.LimitReader(res.Body, 1<<20))
+	caps = make(map[string]string)
+	version := false
+	for {
+		kind, payload, err := readPkt(r)
+		if err != nil {
+			return nil, err
+		}
+		if kind == pktFlush {
+			if version {
+				break
+			}
+			// The smart HTTP service announcement
+			// precedes the capability advertisement.
+			continue
+		}
+		line := strings.TrimSuffix(string(payload), "\n")
+		switch {
+		case strings.HasPrefix(line, "# service="):
+		case line == "version 2":
+			version = true
+		case !version:
+			return nil, connect.NewError(connect.CodeUnimplemented, errors.New("git server does not support protocol version 2"))
+		default:
+			k, v, _ := strings.Cut(line, "=")
+			caps[k] = v
+		}
+	}
+	if f, ok := caps["object-format"]; ok && f != "sha1" {
+		return nil, connect.NewError(connect.CodeUnimplemented, fmt.Errorf("unsupported object format %q", f))
+	}
+
+	g.mu.Lock()
+	g.capabilities[repoURL] = caps
+	g.mu.Unlock()
+	return caps, nil
+}
+
+// resolveRef returns the ID of the commit the ref points to. In addition
+// to names of branches and tags, refs may be prefixed with "heads/" or
+// "tags/", or be full ref names.
+func (g *gitClient) resolveRef(ctx context.Context, repoURL, ref string) (string, error) {
+	if isObjectID(ref) {
+		return ref, nil
+	}
+	var candidates []string
+	switch {
+	case ref == "" || ref == "HEAD":
+		candidates = []string{"HEAD"}
+	case strings.HasPrefix(ref, "refs/"):
+		candidates = []string{ref}
+	case strings.HasPrefix(ref, "heads/"), strings.HasPrefix(ref, "tags/"):
+		candidates = []string{"refs/" + ref}
+	default:
+		candidates = []string{"refs/heads/" + ref, "refs/tags/" + ref}
+	}
+
+	var body bytes.Buffer
+	writePkt(&body, "command=ls-refs\n")
+	writeDelim(&body)
+	writePkt(&body, "peel\n")
+	for _, c := range candidates {
+		writePkt(&body, "ref-prefix "+c+"\n")
+	}
+	writeFlush(&body)
+
+	rc, err := g.post(ctx, repoURL, body.Bytes())
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	refs := make(map[string]string)
+	r := bufio.NewReader(rc)
+	for {
+		kind, payload, err := readPkt(r)
+		if err != nil {
+			return "", err
+		}
+		if kind == pktFlush {
+			break
+		}
+		// <oid> <name> [symref-target:<target>] [peeled:<oid>]
+		fields := strings.Fields(string(payload))
+		if len(fields) < 2 {
+			continue
+		}
+		id := fields[0]
+		for _, attr := range fields[2:] {
+			if peeled, ok := strings.CutPrefix(attr, "peeled:"); ok {
+				id = peeled
+			}
+		}
+		refs[fields[1]] = id
+	}
+	for _, c := range candidates {
+		if id, ok := refs[c]; ok {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w: ref %s", ErrNotFound, ref)
+}
+
+// fetch fetches the object and returns the objects of the response. If
+// shallow is set, the history of the commit is not fetched. The request
+// fails if the server does not support the filter: the response would
+// include all the objects the wanted one refers to.
+func (g *gitClient) fetch(ctx context.Context, repoURL string, caps map[string]string, id string, shallow bool, filter string) (gitObjects, error) {
+	features := strings.Fields(caps["fetch"])
+	if filter != "" && !hasFeature(features, "filter") {
+		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("git server does not support partial fetches (filter)"))
+	}
+	var body bytes.Buffer
+	writePkt(&body, "command=fetch\n")
+	writeDelim(&body)
+	writePkt(&body, "no-progress\n")
+	writePkt(&body, "want "+id+"\n")
+	if shallow && hasFeature(features, "shallow") {
+		writePkt(&body, "deepen 1\n")
+	}
+	if filter != "" {
+		writePkt(&body, "filter "+filter+"\n")
+	}
+	writePkt(&body, "done\n")
+	writeFlush(&body)
+
+	rc, err := g.post(ctx, repoURL, body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	r := bufio.NewReader(rc)
+	var (
+		section string
+		pack    bytes.Buffer
+	)
+	for {
+		kind, payload, err := readPkt(r)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case pktFlush, pktResponseEnd:
+			if section != "packfile" {
+				return nil, errors.New("git server response contains no packfile")
+			}
+			return parsePack(pack.Bytes())
+		case pktDelim:
+			section = ""
+			continue
+		}
+		if section == "" {
+			section = strings.TrimSuffix(string(payload), "\n")
+			continue
+		}
+		if section != "packfile" || len(payload) == 0 {
+			continue
+		}
+		// The packfile is multiplexed with progress and error messages.
+		switch payload[0] {
+		case 1:
+			pack.Write(payload[1:])
+		case 3:
+			return nil, fmt.Errorf("git server error: %s", bytes.TrimSpace(payload[1:]))
+		}
+	}
+}
+
+func hasFeature(features []string, name string) bool {
+	for _, f := range features {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isObjectID(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Packet lines, see https://git-scm.com/docs/protocol-common#_pkt_line_format.
+
+const (
+	pktData = iota
+	pktFlush
+	pktDelim
+	pktResponseEnd
+)
+
+func readPkt(r *bufio.Reader) (int, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("failed to read packet: %w", err)
+	}
+	n, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid packet length %q", header[:])
+	}
+	switch n {
+	case 0:
+		return pktFlush, nil, nil
+	case 1:
+		return pktDelim, nil, nil
+	case 2:
+		return pktResponseEnd, nil, nil
+	case 3:
+		return 0, nil, fmt.Errorf("invalid packet length %q", header[:])
+	}
+	payload := make([]byte, n-4)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return 0, nil, fmt.Errorf("failed to read packet: %w", err)
+	}
+	if msg, ok := bytes.CutPrefix(payload, []byte("ERR ")); ok {
+		return 0, nil, fmt.Errorf("git server error: %s", bytes.TrimSpace(msg))
+	}
+	return pktData, payload, nil
+}
+
+func writePkt(w *bytes.Buffer, line string) {
+	fmt.Fprintf(w, "%04x%s", len(line)+4, line)
+}
+
+func writeFlush(w *bytes.Buffer) { w.WriteString("0000") }
+
+func writeDelim(w *bytes.Buffer) { w.WriteString("0001") }
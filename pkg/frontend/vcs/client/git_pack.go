@@ -0,0 +1,366 @@
+package client
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Packfiles, see https://git-scm.com/docs/pack-format.
+
+type gitObjectType byte
+
+const (
+	gitObjectCommit   gitObjectType = 1
+	gitObjectTree     gitObjectType = 2
+	gitObjectBlob     gitObjectType = 3
+	gitObjectTag      gitObjectType = 4
+	gitObjectOfsDelta gitObjectType = 6
+	gitObjectRefDelta gitObjectType = 7
+)
+
+func (t gitObjectType) String() string {
+	switch t {
+	case gitObjectCommit:
+		return "commit"
+	case gitObjectTree:
+		return "tree"
+	case gitObjectBlob:
+		return "blob"
+	case gitObjectTag:
+		return "tag"
+	}
+	return "unknown"
+}
+
+type gitObject struct {
+	typ  gitObjectType
+	data []byte
+}
+
+// gitObjects are indexed by object ID.
+type gitObjects map[string]gitObject
+
+type packEntry struct {
+	typ  gitObjectType
+	data []byte // Delta instructions for deltified objects.
+	base string // Object ID of the base of REF_DELTA objects.
+	ofs  int    // Offset of the base of OFS_DELTA objects.
+
+	resolved *gitObject
+	indexed  bool
+}
+
+func parsePack(data []byte) (gitObjects, error) {
+	if len(data) < 12 || string(data[:4]) != "PACK" {
+		return nil, errors.New("invalid packfile header")
+	}
+	if v := binary.BigEndian.Uint32(data[4:8]); v != 2 && v != 3 {
+		return nil, fmt.Errorf("unsupported packfile version %d", v)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	r := bytes.NewReader(data)
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+	entries := make(map[int]*packEntry, count)
+	order := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		offset := len(data) - r.Len()
+		e, err := readPackEntry(r, offset)
+		if err != nil {
+			return nil, fmt.Errorf("packfile entry at offset %d: %w", offset, err)
+		}
+		entries[offset] = e
+		order = append(order, offset)
+	}
+
+	objects := make(gitObjects, count)
+	for _, offset := range order {
+		if e := entries[offset]; e.typ != gitObjectOfsDelta && e.typ != gitObjectRefDelta {
+			e.resolved = &gitObject{typ: e.typ, data: e.data}
+			e.indexed = true
+			objects[objectID(e.typ, e.data)] = *e.resolved
+		}
+	}
+	// Bases of REF_DELTA objects may be deltified themselves:
+	// resolve objects until there is no progress.
+	for pending := true; pending; {
+		pending = false
+		progress := false
+		for _, offset := range order {
+			e := entries[offset]
+			if e.indexed {
+				continue
+			}
+			obj, err := resolvePackEntry(entries, objects, e, 0)
+			if err != nil {
+				return nil, err
+			}
+			if obj == nil {
+				pending = true
+				continue
+			}
+			objects[objectID(obj.typ, obj.data)] = *obj
+			e.indexed = true
+			progress = true
+		}
+		if pending && !progress {
+			return nil, errors.New("packfile contains objects with missing bases")
+		}
+	}
+	return objects, nil
+}
+
+func readPackEntry(r *bytes.Reader, offset int) (*packEntry, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	e := &packEntry{typ: gitObjectType((c >> 4) & 7)}
+	size := uint64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = r.ReadByte(); err != nil {
+			return nil, err
+		}
+		size |= uint64(c&0x7f) << shift
+	}
+
+	switch e.typ {
+	case gitObjectCommit, gitObjectTree, gitObjectBlob, gitObjectTag:
+	case gitObjectOfsDelta:
+		if c, err = r.ReadByte(); err != nil {
+			return nil, err
+		}
+		ofs := int(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return nil, err
+			}
+			ofs = (ofs+1)<<7 | int(c&0x7f)
+		}
+		if e.ofs = offset - ofs; e.ofs < 0 {
+			return nil, errors.New("invalid delta base offset")
+		}
+	case gitObjectRefDelta:
+		var base [sha1.Size]byte
+		if _, err = io.ReadFull(r, base[:]); err != nil {
+			return nil, err
+		}
+		e.base = hex.EncodeToString(base[:])
+	default:
+		return nil, fmt.Errorf("invalid object type %d", e.typ)
+	}
+
+	// bytes.Reader implements io.ByteReader: the decompressor
+	// does not read past the end of the compressed data.
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if e.data, err = io.ReadAll(zr); err != nil {
+		return nil, err
+	}
+	if uint64(len(e.data)) != size {
+		return nil, fmt.Errorf("object size mismatch: expected %d, got %d", size, len(e.data))
+	}
+	return e, zr.Close()
+}
+
+// resolvePackEntry applies the deltas of the entry. It returns nil if
+// the base object is not resolved yet.
+func resolvePackEntry(entries map[int]*packEntry, objects gitObjects, e *packEntry, depth int) (*gitObject, error) {
+	if e.resolved != nil {
+		return e.resolved, nil
+	}
+	if depth > 64 {
+		return nil, errors.New("delta chain is too long")
+	}
+	var base *gitObject
+	switch e.typ {
+	case gitObjectOfsDelta:
+		b, ok := entries[e.ofs]
+		if !ok {
+			return nil, errors.New("invalid delta base offset")
+		}
+		var err error
+		if base, err = resolvePackEntry(entries, objects, b, depth+1); err != nil || base == nil {
+			return nil, err
+		}
+	case gitObjectRefDelta:
+		b, ok := objects[e.base]
+		if !ok {
+			return nil, nil
+		}
+		base = &b
+	}
+	data, err := applyDelta(base.data, e.data)
+	if err != nil {
+		return nil, err
+	}
+	e.resolved = &gitObject{typ: base.typ, data: data}
+	return e.resolved, nil
+}
+
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errInvalid := errors.New("invalid delta")
+	varint := func() (int, bool) {
+		var v, shift int
+		for len(delta) > 0 {
+			c := delta[0]
+			delta = delta[1:]
+			v |= int(c&0x7f) << shift
+			if c&0x80 == 0 {
+				return v, true
+			}
+			shift += 7
+		}
+		return 0, false
+	}
+	srcSize, ok := varint()
+	if !ok || srcSize != len(base) {
+		return nil, errInvalid
+	}
+	dstSize, ok := varint()
+	if !ok {
+		return nil, errInvalid
+	}
+
+	dst := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		switch {
+		case op&0x80 != 0:
+			// Copy from the base object.
+			var offset, size int
+			for i := 0; i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, errInvalid
+				}
+				if i < 4 {
+					offset |= int(delta[0]) << (8 * i)
+				} else {
+					size |= int(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > len(base) {
+				return nil, errInvalid
+			}
+			dst = append(dst, base[offset:offset+size]...)
+		case op != 0:
+			// Insert the literal data.
+			if int(op) > len(delta) {
+				return nil, errInvalid
+			}
+			dst = append(dst, delta[:op]...)
+			delta = delta[op:]
+		default:
+			return nil, errInvalid
+		}
+	}
+	if len(dst) != dstSize {
+		return nil, errInvalid
+	}
+	return dst, nil
+}
+
+func objectID(typ gitObjectType, data []byte) string {
+	h := sha1.New()
+	h.Write([]byte(typ.String() + " " + strconv.Itoa(len(data)) + "\x00"))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// findTreeEntry returns the object ID of the tree entry with the name.
+func findTreeEntry(tree []byte, name string) (string, bool) {
+	for len(tree) > 0 {
+		// <mode> SP <name> NUL <20-byte object ID>
+		sp := bytes.IndexByte(tree, ' ')
+		nul := bytes.IndexByte(tree, 0)
+		if sp < 0 || nul < sp || len(tree) < nul+1+sha1.Size {
+			return "", false
+		}
+		id := tree[nul+1 : nul+1+sha1.Size]
+		if string(tree[sp+1:nul]) == name {
+			return hex.EncodeToString(id), true
+		}
+		tree = tree[nul+1+sha1.Size:]
+	}
+	return "", false
+}
+
+type gitCommit struct {
+	id         string
+	tree       string
+	authorName string
+	authorDate time.Time
+	message    string
+}
+
+func parseCommit(id string, data []byte) (*gitCommit, error) {
+	headers, message, _ := strings.Cut(string(data), "\n\n")
+	c := &gitCommit{id: id, message: message}
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.tree = value
+		case "author":
+			// <name> <<email>> <unix timestamp> <timezone offset>
+			end := strings.LastIndexByte(value, '>')
+			start := strings.LastIndexByte(value[:max(end, 0)], '<')
+			if start < 0 || end < 0 {
+				return nil, fmt.Errorf("commit %s: invalid author", id)
+			}
+			c.authorName = strings.TrimSpace(value[:start])
+			date, err := parseGitDate(strings.TrimSpace(value[end+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("commit %s: %w", id, err)
+			}
+			c.authorDate = date
+		}
+	}
+	if c.tree == "" {
+		return nil, fmt.Errorf("commit %s has no tree", id)
+	}
+	return c, nil
+}
+
+func parseGitDate(s string) (time.Time, error) {
+	ts, tz, ok := strings.Cut(s, " ")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	t := time.Unix(sec, 0).UTC()
+	if !ok || len(tz) != 5 {
+		return t, nil
+	}
+	hours, err1 := strconv.Atoi(tz[1:3])
+	minutes, err2 := strconv.Atoi(tz[3:5])
+	if err1 != nil || err2 != nil {
+		return t, nil
+	}
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return t.In(time.FixedZone("", offset)), nil
+}
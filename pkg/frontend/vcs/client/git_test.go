@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, stdin []byte, args ...string) []byte {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"HOME="+dir,
+		"GIT_AUTHOR_NAME=Jane Doe",
+		"GIT_AUTHOR_EMAIL=jane@example.com",
+		"GIT_AUTHOR_DATE=1704103200 +0200",
+		"GIT_COMMITTER_NAME=Jane Doe",
+		"GIT_COMMITTER_EMAIL=jane@example.com",
+		"GIT_COMMITTER_DATE=1704103200 +0200",
+	)
+	out, err := cmd.Output()
+	require.NoError(t, err, "git %s", strings.Join(args, " "))
+	return out
+}
+
+// testGitRepository creates the repository <root>/owner/repo.git with two
+// commits on the main branch, and a tag on the first commit.
+func testGitRepository(t *testing.T) (root string, first, second string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root = t.TempDir()
+	work := filepath.Join(root, "work")
+	require.NoError(t, os.MkdirAll(filepath.Join(work, "pkg", "util"), 0o755))
+	runGit(t, work, nil, "init", "-q", "-b", "main")
+
+	content := strings.Repeat("package util\n\n// Hello returns a greeting.\n", 20)
+	require.NoError(t, os.WriteFile(filepath.Join(work, "pkg", "util", "util.go"), []byte(content), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("# repo\n"), 0o644))
+	runGit(t, work, nil, "add", ".")
+	runGit(t, work, nil, "commit", "-q", "-m", "first commit")
+	runGit(t, work, nil, "tag", "-a", "v1.0.0", "-m", "release")
+	first = strings.TrimSpace(string(runGit(t, work, nil, "rev-parse", "HEAD")))
+
+	require.NoError(t, os.WriteFile(filepath.Join(work, "pkg", "util", "util.go"), []byte(content+"func Hello() {}\n"), 0o644))
+	runGit(t, work, nil, "commit", "-q", "-am", "second commit\n\nwith a body")
+	second = strings.TrimSpace(string(runGit(t, work, nil, "rev-parse", "HEAD")))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "owner"), 0o755))
+	runGit(t, root, nil, "clone", "-q", "--bare", work, filepath.Join(root, "owner", "repo.git"))
+	runGit(t, filepath.Join(root, "owner", "repo.git"), nil, "config", "uploadpack.allowFilter", "true")
+	return root, first, second
+}
+
+// startGitServer serves the repositories of the root directory. The number
+// of fetch requests is recorded.
+func startGitServer(t *testing.T, root string) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	execPath := strings.TrimSpace(string(runGit(t, root, nil, "--exec-path")))
+	backend := &cgi.Handler{
+		Path:   filepath.Join(execPath, "git-http-backend"),
+		Stderr: io.Discard,
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + root,
+			"GIT_HTTP_EXPORT_ALL=1",
+			"GIT_CONFIG_NOSYSTEM=1",
+			"HOME=" + root,
+		},
+	}
+	var fetches atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, _ := r.BasicAuth(); password != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			fetches.Add(1)
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &fetches
+}
+
+func TestGitClient(t *testing.T) {
+	root, first, second := testGitRepository(t)
+	srv, _ := startGitServer(t, root)
+	ctx := context.Background()
+	c := GitClient(srv.URL, "", "token", srv.Client())
+
+	for _, ref := range []string{"", "HEAD", "main", "heads/main", "refs/heads/main", second} {
+		file, err := c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "repo", Path: "pkg/util/util.go", Ref: ref})
+		require.NoError(t, err, ref)
+		assert.True(t, strings.HasSuffix(file.Content, "func Hello() {}\n"), ref)
+	}
+
+	// Annotated tags are peeled to the commit.
+	for _, ref := range []string{"v1.0.0", "tags/v1.0.0", first} {
+		file, err := c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "repo", Path: "/pkg/util/util.go", Ref: ref})
+		require.NoError(t, err, ref)
+		assert.False(t, strings.Contains(file.Content, "func Hello"), ref)
+	}
+
+	_, err := c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "repo", Path: "pkg/missing.go", Ref: "main"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "repo", Path: "pkg/util", Ref: "main"})
+	assert.Error(t, err)
+	_, err = c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "repo", Path: "README.md", Ref: "missing"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "missing", Path: "README.md", Ref: "main"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	commit, err := c.GetCommit(ctx, "owner", "repo", "main")
+	require.NoError(t, err)
+	assert.Equal(t, second, commit.Sha)
+	assert.Equal(t, "second commit\n\nwith a body\n", commit.Message)
+	assert.Equal(t, "2024-01-01T12:00:00+02:00", commit.Date)
+	assert.Equal(t, "Jane Doe", commit.Author.Login)
+
+	commit, err = c.GetCommit(ctx, "owner", "repo", "v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, first, commit.Sha)
+
+	_, err = GitClient(srv.URL, "", "invalid", srv.Client()).GetCommit(ctx, "owner", "repo", "main")
+	assert.Error(t, err)
+}
+
+func TestGitClient_CachesCommitsAndTrees(t *testing.T) {
+	root, _, second := testGitRepository(t)
+	srv, fetches := startGitServer(t, root)
+	ctx := context.Background()
+	c := GitClient(srv.URL, "", "token", srv.Client())
+
+	// ls-refs, the commit, three trees and the file.
+	_, err := c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "repo", Path: "pkg/util/util.go", Ref: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), fetches.Load())
+
+	// Only the file is fetched: the commit and the trees are cached.
+	fetches.Store(0)
+	_, err = c.GetFile(ctx, FileRequest{Owner: "owner", Repo: "repo", Path: "pkg/util/util.go", Ref: second})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), fetches.Load())
+}
+
+func TestGitClient_FilterNotSupported(t *testing.T) {
+	root, _, _ := testGitRepository(t)
+	runGit(t, filepath.Join(root, "owner", "repo.git"), nil, "config", "uploadpack.allowFilter", "false")
+	srv, _ := startGitServer(t, root)
+	c := GitClient(srv.URL, "", "token", srv.Client())
+
+	_, err := c.GetFile(context.Background(), FileRequest{Owner: "owner", Repo: "repo", Path: "README.md", Ref: "main"})
+	assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+}
+
+func TestParsePack(t *testing.T) {
+	root, _, _ := testGitRepository(t)
+	repo := filepath.Join(root, "owner", "repo.git")
+	// Packing all the objects of the repository produces deltas
+	// between the versions of the file.
+	pack := runGit(t, repo, nil, "pack-objects", "--stdout", "--all", "--delta-base-offset")
+	objects, err := parsePack(pack)
+	require.NoError(t, err)
+
+	ids := strings.Fields(string(runGit(t, repo, nil, "cat-file", "--batch-all-objects", "--batch-check=%(objectname)")))
+	require.Len(t, objects, len(ids))
+	for _, id := range ids {
+		obj, ok := objects[id]
+		require.True(t, ok, id)
+		assert.Equal(t, string(runGit(t, repo, nil, "cat-file", obj.typ.String(), id)), string(obj.data), id)
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello, world")
+	// Copy "hello" (offset 0, size 5), insert "!", copy "world" (offset 7, size 5).
+	delta := []byte{12, 11, 0x90, 5, 1, '!', 0x91, 7, 5}
+	out, err := applyDelta(base, delta)
+	require.NoError(t, err)
+	assert.Equal(t, "hello!world", string(out))
+
+	_, err = applyDelta(base, []byte{11, 11, 0x90, 5})
+	assert.Error(t, err)
+	_, err = applyDelta(base, []byte{12, 5, 0x91, 10, 5})
+	assert.Error(t, err)
+}
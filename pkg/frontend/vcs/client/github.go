@@ -23,6 +23,16 @@ func GithubClient(ctx context.Context, token *oauth2.Token, client *http.Client)
 	}, nil
 }
 
+// GithubEnterpriseClient returns a client of a GitHub Enterprise Server
+// instance. The API URL is usually https://<host>/api/v3.
+func GithubEnterpriseClient(apiURL, token string, client *http.Client) (*githubClient, error) {
+	c, err := github.NewClient(client).WithAuthToken(token).WithEnterpriseURLs(apiURL, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	return &githubClient{repoService: c.Repositories}, nil
+}
+
 type repositoryService interface {
 	GetCommit(ctx context.Context, owner, repo, ref string, opts *github.ListOptions) (*github.RepositoryCommit, *github.Response, error)
 	GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error)
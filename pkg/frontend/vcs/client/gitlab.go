@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/grafana/dskit/tracing"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+)
+
+// GitLabClient returns a client of the GitLab REST API. The base URL is the
+// URL of the GitLab instance, e.g. https://gitlab.com, and the API URL
+// defaults to <base URL>/api/v4. Both personal access tokens and OAuth
+// tokens are accepted.
+func GitLabClient(baseURL, apiURL, token string, client *http.Client) *gitlabClient {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if apiURL == "" {
+		apiURL = baseURL + "/api/v4"
+	}
+	return &gitlabClient{
+		baseURL:    baseURL,
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		auth:       bearerToken(token),
+		httpClient: client,
+	}
+}
+
+type gitlabClient struct {
+	baseURL    string
+	apiURL     string
+	auth       authorizer
+	httpClient *http.Client
+}
+
+type gitlabCommit struct {
+	ID           string    `json:"id"`
+	Message      string    `json:"message"`
+	AuthorName   string    `json:"author_name"`
+	AuthoredDate time.Time `json:"authored_date"`
+	WebURL       string    `json:"web_url"`
+}
+
+type gitlabFile struct {
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// projectURL returns the API URL of the project. Owners may include
+// subgroups: the path of the project is URL-encoded as a whole.
+func (gl *gitlabClient) projectURL(owner, repo string) string {
+	return gl.apiURL + "/projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+func (gl *gitlabClient) GetCommit(ctx context.Context, owner, repo, ref string) (*vcsv1.CommitInfo, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "gitlabClient.GetCommit")
+	defer sp.Finish()
+	sp.SetTag("owner", owner)
+	sp.SetTag("repo", repo)
+	sp.SetTag("ref", ref)
+
+	var commit gitlabCommit
+	err := getJSON(ctx, gl.httpClient, gl.auth, gl.projectURL(owner, repo)+"/repository/commits/"+url.PathEscape(ref), &commit)
+	if err != nil {
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return nil, notFoundAsConnectError(err)
+	}
+	if commit.AuthoredDate.IsZero() {
+		err := connect.NewError(connect.CodeInternal, errors.New("commit contains no date"))
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return nil, err
+	}
+
+	commitInfo := &vcsv1.CommitInfo{
+		Sha:     commit.ID,
+		Message: commit.Message,
+		Date:    commit.AuthoredDate.Format(time.RFC3339),
+		URL:     commit.WebURL,
+	}
+	// GitLab does not link commits to user accounts.
+	if commit.AuthorName != "" {
+		commitInfo.Author = &vcsv1.CommitAuthor{Login: commit.AuthorName}
+	}
+	return commitInfo, nil
+}
+
+func (gl *gitlabClient) GetFile(ctx context.Context, req FileRequest) (File, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "gitlabClient.GetFile")
+	defer sp.Finish()
+	sp.SetTag("owner", req.Owner)
+	sp.SetTag("repo", req.Repo)
+	sp.SetTag("path", req.Path)
+	sp.SetTag("ref", req.Ref)
+
+	var file gitlabFile
+	u := gl.projectURL(req.Owner, req.Repo) + "/repository/files/" + url.PathEscape(req.Path) + "?ref=" + url.QueryEscape(req.Ref)
+	if err := getJSON(ctx, gl.httpClient, gl.auth, u, &file); err != nil {
+		sp.SetTag("error", true)
+		sp.SetTag("error.message", err.Error())
+		return File{}, err
+	}
+
+	content := file.Content
+	if file.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			sp.SetTag("error", true)
+			sp.SetTag("error.message", err.Error())
+			return File{}, err
+		}
+		content = string(decoded)
+	}
+
+	return File{
+		Content: content,
+		URL:     fmt.Sprintf("%s/%s/%s/-/blob/%s/%s", gl.baseURL, req.Owner, req.Repo, req.Ref, req.Path),
+	}, nil
+}
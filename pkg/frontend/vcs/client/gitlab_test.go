@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+)
+
+func TestGitLabClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsubgroup%2Frepo/repository/files/pkg%2Fmain.go":
+			if r.URL.Query().Get("ref") != "main" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"encoding": "base64",
+				"content":  base64.StdEncoding.EncodeToString([]byte("package main\n")),
+			})
+		case "/api/v4/projects/group%2Fsubgroup%2Frepo/repository/commits/main":
+			_, _ = w.Write([]byte(`{
+				"id": "abc123",
+				"message": "test commit message",
+				"author_name": "Jane Doe",
+				"authored_date": "2024-01-01T12:00:00.000+02:00",
+				"web_url": "https://gitlab.example.com/group/subgroup/repo/-/commit/abc123"
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	c := GitLabClient("https://gitlab.example.com", srv.URL+"/api/v4", "token", srv.Client())
+
+	file, err := c.GetFile(ctx, FileRequest{Owner: "group/subgroup", Repo: "repo", Path: "pkg/main.go", Ref: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, File{
+		Content: "package main\n",
+		URL:     "https://gitlab.example.com/group/subgroup/repo/-/blob/main/pkg/main.go",
+	}, file)
+
+	_, err = c.GetFile(ctx, FileRequest{Owner: "group/subgroup", Repo: "repo", Path: "pkg/main.go", Ref: "dev"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	commit, err := c.GetCommit(ctx, "group/subgroup", "repo", "main")
+	require.NoError(t, err)
+	assert.Equal(t, &vcsv1.CommitInfo{
+		Sha:     "abc123",
+		Message: "test commit message",
+		Author:  &vcsv1.CommitAuthor{Login: "Jane Doe"},
+		Date:    "2024-01-01T12:00:00+02:00",
+		URL:     "https://gitlab.example.com/group/subgroup/repo/-/commit/abc123",
+	}, commit)
+
+	_, err = c.GetCommit(ctx, "group/subgroup", "repo", "missing")
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	_, err = GitLabClient("https://gitlab.example.com", srv.URL+"/api/v4", "invalid", srv.Client()).GetCommit(ctx, "group/subgroup", "repo", "main")
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+}
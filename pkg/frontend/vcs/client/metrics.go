@@ -15,18 +15,43 @@ import (
 )
 
 var (
-	githubRouteMatchers = map[string]*regexp.Regexp{
+	apiRouteMatchers = map[string]*regexp.Regexp{
 		// Get repository contents.
 		// https://docs.github.com/en/rest/repos/contents?apiVersion=2022-11-28#get-repository-content
-		"/repos/{owner}/{repo}/contents/{path}": regexp.MustCompile(`^\/repos\/\S+\/\S+\/contents\/\S+$`),
+		"/repos/{owner}/{repo}/contents/{path}": regexp.MustCompile(`^(\/api\/v3)?\/repos\/\S+\/\S+\/contents\/\S+$`),
 
 		// Get a commit.
 		// https://docs.github.com/en/rest/commits/commits?apiVersion=2022-11-28#get-a-commit
-		"/repos/{owner}/{repo}/commits/{ref}": regexp.MustCompile(`^\/repos\/\S+\/\S+\/commits\/\S+$`),
+		"/repos/{owner}/{repo}/commits/{ref}": regexp.MustCompile(`^(\/api\/v3)?\/repos\/\S+\/\S+\/commits\/\S+$`),
 
 		// Refresh auth token.
 		// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/refreshing-user-access-tokens#refreshing-a-user-access-token-with-a-refresh-token
 		"/login/oauth/access_token": regexp.MustCompile(`^\/login\/oauth\/access_token$`),
+
+		// Get a file from a GitLab repository.
+		// https://docs.gitlab.com/api/repository_files/#get-file-from-repository
+		"/api/v4/projects/{id}/repository/files/{path}": regexp.MustCompile(`^\S*\/api\/v4\/projects\/\S+\/repository\/files\/\S+$`),
+
+		// Get a single GitLab commit.
+		// https://docs.gitlab.com/api/commits/#get-a-single-commit
+		"/api/v4/projects/{id}/repository/commits/{ref}": regexp.MustCompile(`^\S*\/api\/v4\/projects\/\S+\/repository\/commits\/\S+$`),
+
+		// Get a Bitbucket repository.
+		// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-repositories/#api-repositories-workspace-repo-slug-get
+		"/2.0/repositories/{workspace}/{repo}": regexp.MustCompile(`^\/2\.0\/repositories\/[^\/\s]+\/[^\/\s]+$`),
+
+		// Get file from a Bitbucket repository.
+		// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-source/#api-repositories-workspace-repo-slug-src-commit-path-get
+		"/2.0/repositories/{workspace}/{repo}/src/{ref}/{path}": regexp.MustCompile(`^\/2\.0\/repositories\/[^\/\s]+\/[^\/\s]+\/src\/\S+$`),
+
+		// Get a Bitbucket commit.
+		// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-commits/#api-repositories-workspace-repo-slug-commit-commit-get
+		"/2.0/repositories/{workspace}/{repo}/commit/{ref}": regexp.MustCompile(`^\/2\.0\/repositories\/[^\/\s]+\/[^\/\s]+\/commit\/\S+$`),
+
+		// Git smart HTTP protocol.
+		// https://git-scm.com/docs/http-protocol
+		"/{repo}/info/refs":       regexp.MustCompile(`^\S+\/info\/refs$`),
+		"/{repo}/git-upload-pack": regexp.MustCompile(`^\S+\/git-upload-pack$`),
 	}
 )
 
@@ -35,7 +60,7 @@ func InstrumentedHTTPClient(logger log.Logger, reg prometheus.Registerer) *http.
 		prometheus.HistogramOpts{
 			Namespace:                       "pyroscope",
 			Name:                            "vcs_github_request_duration",
-			Help:                            "Duration of GitHub API requests in seconds",
+			Help:                            "Duration of requests to the APIs of source code providers in seconds",
 			Buckets:                         prometheus.ExponentialBucketsRange(0.1, 10, 8),
 			NativeHistogramBucketFactor:     1.1,
 			NativeHistogramMaxBucketNumber:  50,
@@ -48,16 +73,16 @@ func InstrumentedHTTPClient(logger log.Logger, reg prometheus.Registerer) *http.
 		Timeout:   10 * time.Second,
 		Transport: http.DefaultTransport,
 	}
-	client := httputil.InstrumentedHTTPClient(defaultClient, withGitHubMetricsTransport(logger, apiDuration))
+	client := httputil.InstrumentedHTTPClient(defaultClient, withMetricsTransport(logger, apiDuration))
 	return client
 }
 
-// withGitHubMetricsTransport wraps a transport with a client to track GitHub
-// API usage.
-func withGitHubMetricsTransport(logger log.Logger, hv *prometheus.HistogramVec) httputil.RoundTripperInstrumentFunc {
+// withMetricsTransport wraps a transport with a client to track the API
+// usage of source code providers.
+func withMetricsTransport(logger log.Logger, hv *prometheus.HistogramVec) httputil.RoundTripperInstrumentFunc {
 	return func(next http.RoundTripper) http.RoundTripper {
 		return httputil.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
-			route := matchGitHubAPIRoute(req.URL.Path)
+			route := matchAPIRoute(req.URL.Path)
 			statusCode := ""
 			start := time.Now()
 
@@ -67,7 +92,7 @@ func withGitHubMetricsTransport(logger log.Logger, hv *prometheus.HistogramVec)
 			}
 
 			if route == "unknown_route" {
-				level.Warn(logger).Log("path", req.URL.Path, "msg", "unknown GitHub API route")
+				level.Warn(logger).Log("path", req.URL.Path, "msg", "unknown source code provider API route")
 			}
 			hv.WithLabelValues(req.Method, route, statusCode).Observe(time.Since(start).Seconds())
 
@@ -76,8 +101,8 @@ func withGitHubMetricsTransport(logger log.Logger, hv *prometheus.HistogramVec)
 	}
 }
 
-func matchGitHubAPIRoute(path string) string {
-	for route, regex := range githubRouteMatchers {
+func matchAPIRoute(path string) string {
+	for route, regex := range apiRouteMatchers {
 		if regex.MatchString(path) {
 			return route
 		}
@@ -6,7 +6,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func Test_matchGitHubAPIRoute(t *testing.T) {
+func Test_matchAPIRoute(t *testing.T) {
 	tests := []struct {
 		Name string
 		Path string
@@ -57,6 +57,46 @@ func Test_matchGitHubAPIRoute(t *testing.T) {
 			Path: "/login/oauth/access_token",
 			Want: "/login/oauth/access_token",
 		},
+		{
+			Name: "GetContents on GitHub Enterprise",
+			Path: "/api/v3/repos/grafana/pyroscope/contents/pkg/querier/querier.go",
+			Want: "/repos/{owner}/{repo}/contents/{path}",
+		},
+		{
+			Name: "GitLab file",
+			Path: "/api/v4/projects/group/subgroup/repo/repository/files/pkg/main.go",
+			Want: "/api/v4/projects/{id}/repository/files/{path}",
+		},
+		{
+			Name: "GitLab commit",
+			Path: "/gitlab/api/v4/projects/group/repo/repository/commits/main",
+			Want: "/api/v4/projects/{id}/repository/commits/{ref}",
+		},
+		{
+			Name: "Bitbucket repository",
+			Path: "/2.0/repositories/workspace/repo",
+			Want: "/2.0/repositories/{workspace}/{repo}",
+		},
+		{
+			Name: "Bitbucket file",
+			Path: "/2.0/repositories/workspace/repo/src/main/pkg/main.go",
+			Want: "/2.0/repositories/{workspace}/{repo}/src/{ref}/{path}",
+		},
+		{
+			Name: "Bitbucket commit",
+			Path: "/2.0/repositories/workspace/repo/commit/abcdef",
+			Want: "/2.0/repositories/{workspace}/{repo}/commit/{ref}",
+		},
+		{
+			Name: "git capabilities",
+			Path: "/owner/repo.git/info/refs",
+			Want: "/{repo}/info/refs",
+		},
+		{
+			Name: "git upload-pack",
+			Path: "/owner/repo.git/git-upload-pack",
+			Want: "/{repo}/git-upload-pack",
+		},
 		{
 			Name: "empty path",
 			Path: "",
@@ -71,7 +111,7 @@ func Test_matchGitHubAPIRoute(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
-			got := matchGitHubAPIRoute(tt.Path)
+			got := matchAPIRoute(tt.Path)
 			require.Equal(t, tt.Want, got)
 		})
 	}
@@ -19,6 +19,10 @@ const (
 	LanguageJava       = Language("java")
 	LanguagePython     = Language("python")
 	LanguageJavaScript = Language("javascript")
+	LanguageRust       = Language("rust")
+	LanguageCpp        = Language("cpp")
+	LanguageRuby       = Language("ruby")
+	LanguageDotNet     = Language("dotnet")
 )
 
 type Version string
@@ -33,6 +37,10 @@ var validLanguages = []Language{
 	LanguageJava,
 	LanguagePython,
 	LanguageJavaScript,
+	LanguageRust,
+	LanguageCpp,
+	LanguageRuby,
+	LanguageDotNet,
 }
 
 // PyroscopeConfig represents the structure of .pyroscope.yaml configuration file
@@ -62,8 +70,11 @@ type Match struct {
 
 // Source represents how mappings retrieve the source
 type Source struct {
-	Local  *LocalMappingConfig  `yaml:"local,omitempty"`
-	GitHub *GitHubMappingConfig `yaml:"github,omitempty"`
+	Local     *LocalMappingConfig     `yaml:"local,omitempty"`
+	GitHub    *GitHubMappingConfig    `yaml:"github,omitempty"`
+	GitLab    *GitLabMappingConfig    `yaml:"gitlab,omitempty"`
+	Bitbucket *BitbucketMappingConfig `yaml:"bitbucket,omitempty"`
+	Git       *GitMappingConfig       `yaml:"git,omitempty"`
 }
 
 // LocalMappingConfig contains configuration for local path mappings
@@ -79,6 +90,35 @@ type GitHubMappingConfig struct {
 	Path  string `yaml:"path"`
 }
 
+// GitLabMappingConfig contains configuration for GitLab repository mappings.
+// The host defaults to the host of the repository, if it is hosted on
+// GitLab, and to gitlab.com otherwise.
+type GitLabMappingConfig struct {
+	Host  string `yaml:"host"`
+	Owner string `yaml:"owner"`
+	Repo  string `yaml:"repo"`
+	Ref   string `yaml:"ref"`
+	Path  string `yaml:"path"`
+}
+
+// BitbucketMappingConfig contains configuration for Bitbucket repository mappings
+type BitbucketMappingConfig struct {
+	Owner string `yaml:"owner"`
+	Repo  string `yaml:"repo"`
+	Ref   string `yaml:"ref"`
+	Path  string `yaml:"path"`
+}
+
+// GitMappingConfig contains configuration for mappings to repositories of
+// generic git servers. The repository URL is https://<host>/<owner>/<repo>.git.
+type GitMappingConfig struct {
+	Host  string `yaml:"host"`
+	Owner string `yaml:"owner"`
+	Repo  string `yaml:"repo"`
+	Ref   string `yaml:"ref"`
+	Path  string `yaml:"path"`
+}
+
 // ParsePyroscopeConfig parses a configuration from bytes
 func ParsePyroscopeConfig(data []byte) (*PyroscopeConfig, error) {
 	var config PyroscopeConfig
@@ -151,6 +191,24 @@ func (m *Source) Validate() error {
 			errs = append(errs, err)
 		}
 	}
+	if m.GitLab != nil {
+		instances++
+		if err := m.GitLab.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if m.Bitbucket != nil {
+		instances++
+		if err := m.Bitbucket.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if m.Git != nil {
+		instances++
+		if err := m.Git.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
 	if instances == 0 {
 		errs = append(errs, errors.New("no source type supplied, you need to supply exactly one source type"))
@@ -169,6 +227,21 @@ func (m *LocalMappingConfig) Validate() error {
 	return nil
 }
 
+func (m *GitLabMappingConfig) Validate() error {
+	return nil
+}
+
+func (m *BitbucketMappingConfig) Validate() error {
+	return nil
+}
+
+func (m *GitMappingConfig) Validate() error {
+	if m.Host == "" {
+		return errors.New("git source requires a host")
+	}
+	return nil
+}
+
 type FileSpec struct {
 	Path         string
 	FunctionName string
@@ -190,6 +263,12 @@ func (c *PyroscopeConfig) FindMapping(file FileSpec) *MappingConfig {
 			bestMatchLen = result
 		}
 	}
+	// .NET function names are prefixed with the assembly name, e.g.
+	// "Example!Example.Orders.OrderService.Process()", so the prefixes
+	// are matched against the namespace-qualified name as well.
+	if i := strings.IndexByte(file.FunctionName, '!'); bestMatch == nil && i > 0 {
+		return c.FindMapping(FileSpec{Path: file.Path, FunctionName: file.FunctionName[i+1:]})
+	}
 	return bestMatch
 }
 
@@ -150,6 +150,86 @@ func TestParsePyroscopeConfig(t *testing.T) {
 		assert.Contains(t, err.Error(), "no source type supplied")
 	})
 
+	t.Run("valid gitlab, bitbucket and git sources", func(t *testing.T) {
+		yaml := `source_code:
+  mappings:
+    - language: java
+      path:
+        - prefix: com/example/lib
+      source:
+        gitlab:
+          host: gitlab.example.com
+          owner: platform/libs
+          repo: lib
+          ref: v1.0.0
+          path: src/main/java
+    - language: python
+      path:
+        - prefix: vendor
+      source:
+        bitbucket:
+          owner: workspace
+          repo: vendor
+          ref: main
+    - language: go
+      path:
+        - prefix: git.example.com/team/service
+      source:
+        git:
+          host: git.example.com
+          owner: team
+          repo: service
+          ref: main
+`
+		config, err := ParsePyroscopeConfig([]byte(yaml))
+		require.NoError(t, err)
+		require.Len(t, config.SourceCode.Mappings, 3)
+		assert.Equal(t, &GitLabMappingConfig{
+			Host:  "gitlab.example.com",
+			Owner: "platform/libs",
+			Repo:  "lib",
+			Ref:   "v1.0.0",
+			Path:  "src/main/java",
+		}, config.SourceCode.Mappings[0].Source.GitLab)
+		assert.Equal(t, &BitbucketMappingConfig{Owner: "workspace", Repo: "vendor", Ref: "main"}, config.SourceCode.Mappings[1].Source.Bitbucket)
+		assert.Equal(t, &GitMappingConfig{Host: "git.example.com", Owner: "team", Repo: "service", Ref: "main"}, config.SourceCode.Mappings[2].Source.Git)
+	})
+
+	t.Run("invalid - git source without host", func(t *testing.T) {
+		yaml := `source_code:
+  mappings:
+    - language: go
+      path:
+        - prefix: example.com/service
+      source:
+        git:
+          owner: team
+          repo: service
+`
+		_, err := ParsePyroscopeConfig([]byte(yaml))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "git source requires a host")
+	})
+
+	t.Run("invalid - multiple source types", func(t *testing.T) {
+		yaml := `source_code:
+  mappings:
+    - language: go
+      path:
+        - prefix: example.com/service
+      source:
+        github:
+          owner: team
+          repo: service
+        gitlab:
+          owner: team
+          repo: service
+`
+		_, err := ParsePyroscopeConfig([]byte(yaml))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than one source type supplied")
+	})
+
 	t.Run("invalid - missing path and function_name", func(t *testing.T) {
 		yaml := `source_code:
   mappings:
@@ -166,14 +246,11 @@ func TestParsePyroscopeConfig(t *testing.T) {
 	t.Run("invalid - unsupported language", func(t *testing.T) {
 		yaml := `source_code:
   mappings:
-    - language: rust
+    - language: cobol
       path:
         - prefix: src
       source:
-        github:
-          owner: rust-lang
-          repo: rust
-          ref: 1.75.0
+        local:
           path: src
 `
 		_, err := ParsePyroscopeConfig([]byte(yaml))
@@ -181,6 +258,46 @@ func TestParsePyroscopeConfig(t *testing.T) {
 		assert.Contains(t, err.Error(), "unsupported")
 	})
 
+	t.Run("valid rust, cpp, ruby and dotnet mappings", func(t *testing.T) {
+		yaml := `source_code:
+  mappings:
+    - language: rust
+      function_name:
+        - prefix: "tokio::"
+      source:
+        github:
+          owner: tokio-rs
+          repo: tokio
+          ref: tokio-1.35.1
+          path: tokio
+    - language: cpp
+      path:
+        - prefix: /build/src
+      source:
+        local:
+          path: src
+    - language: ruby
+      path:
+        - prefix: /app
+      source:
+        local:
+          path: ""
+    - language: dotnet
+      function_name:
+        - prefix: Example.Orders
+      source:
+        local:
+          path: src/Example.Orders
+`
+		config, err := ParsePyroscopeConfig([]byte(yaml))
+		require.NoError(t, err)
+		require.Len(t, config.SourceCode.Mappings, 4)
+		assert.Equal(t, "rust", config.SourceCode.Mappings[0].Language)
+		assert.Equal(t, "cpp", config.SourceCode.Mappings[1].Language)
+		assert.Equal(t, "ruby", config.SourceCode.Mappings[2].Language)
+		assert.Equal(t, "dotnet", config.SourceCode.Mappings[3].Language)
+	})
+
 	t.Run("invalid yaml syntax", func(t *testing.T) {
 		yaml := `source_code:
   mappings:
@@ -335,3 +452,22 @@ func TestFindMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestFindMapping_DotNetAssembly(t *testing.T) {
+	config := &PyroscopeConfig{
+		SourceCode: SourceCodeConfig{
+			Mappings: []MappingConfig{
+				{
+					Language:     "dotnet",
+					FunctionName: []Match{{Prefix: "Example.Orders"}},
+					Source:       Source{Local: &LocalMappingConfig{Path: "src/Example.Orders"}},
+				},
+			},
+		},
+	}
+
+	result := config.FindMapping(FileSpec{FunctionName: "Example.Orders!Example.Orders.OrderService.Process()"})
+	require.NotNil(t, result)
+	assert.Equal(t, "src/Example.Orders", result.Source.Local.Path)
+	assert.Nil(t, config.FindMapping(FileSpec{FunctionName: "System.Private.CoreLib!System.Threading.Thread.Sleep()"}))
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grafana/dskit/flagext"
+)
+
+// Provider is a source code provider.
+type Provider string
+
+const (
+	ProviderUnknown   = Provider("")
+	ProviderGitHub    = Provider("github")
+	ProviderGitLab    = Provider("gitlab")
+	ProviderBitbucket = Provider("bitbucket")
+	ProviderGit       = Provider("git")
+)
+
+var validProviders = []Provider{
+	ProviderGitHub,
+	ProviderGitLab,
+	ProviderBitbucket,
+	ProviderGit,
+}
+
+// DefaultHost returns the host of the public instance of the provider, if any.
+func (p Provider) DefaultHost() string {
+	switch p {
+	case ProviderGitHub:
+		return "github.com"
+	case ProviderGitLab:
+		return "gitlab.com"
+	case ProviderBitbucket:
+		return "bitbucket.org"
+	}
+	return ""
+}
+
+// ProvidersConfig configures the access to the repositories of source code
+// providers with static tokens, e.g. to self-hosted GitLab instances. Hosts
+// that are not configured are accessed with the GitHub OAuth token of the
+// user (github.com), or anonymously (gitlab.com and bitbucket.org).
+type ProvidersConfig struct {
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+// ProviderConfig configures the access to the repositories of a host.
+type ProviderConfig struct {
+	// Type is one of github, gitlab, bitbucket (Bitbucket Cloud) or git:
+	// a generic git server implementing the smart HTTP protocol version 2.
+	Type Provider `yaml:"type"`
+	// Host of the repository URLs, e.g. gitlab.example.com.
+	Host string `yaml:"host"`
+	// URL of the instance, defaults to https://<host>.
+	URL string `yaml:"url"`
+	// APIURL overrides the API URL of the provider: https://<host>/api/v3
+	// for GitHub Enterprise Server, https://<host>/api/v4 for GitLab, and
+	// https://api.bitbucket.org/2.0 for Bitbucket.
+	APIURL string `yaml:"api_url"`
+	// Username is used with the token for HTTP basic authentication by the
+	// bitbucket (app passwords) and git providers.
+	Username string         `yaml:"username"`
+	Token    flagext.Secret `yaml:"token"`
+}
+
+// Validate checks if the configuration is valid.
+func (c *ProvidersConfig) Validate() error {
+	var errs []error
+	hosts := make(map[string]struct{}, len(c.Providers))
+	for i, p := range c.Providers {
+		if err := p.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("vcs provider[%d]: %w", i, err))
+			continue
+		}
+		host := strings.ToLower(p.Host)
+		if _, ok := hosts[host]; ok {
+			errs = append(errs, fmt.Errorf("vcs provider[%d]: host '%s' is configured more than once", i, p.Host))
+		}
+		hosts[host] = struct{}{}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks if the configuration of the provider is valid.
+func (p *ProviderConfig) Validate() error {
+	if !slices.Contains(validProviders, p.Type) {
+		return fmt.Errorf("type '%s' unsupported, valid types are %v", p.Type, validProviders)
+	}
+	if p.Host == "" {
+		return errors.New("host is required")
+	}
+	return nil
+}
+
+// Find returns the configuration of the host, or nil if the host is not configured.
+func (c *ProvidersConfig) Find(host string) *ProviderConfig {
+	for i := range c.Providers {
+		if strings.EqualFold(c.Providers[i].Host, host) {
+			return &c.Providers[i]
+		}
+	}
+	return nil
+}
+
+// BaseURL returns the URL of the instance.
+func (p *ProviderConfig) BaseURL() string {
+	if p.URL != "" {
+		return strings.TrimSuffix(p.URL, "/")
+	}
+	return "https://" + p.Host
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func TestProvidersConfig(t *testing.T) {
+	var cfg ProvidersConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+providers:
+  - type: gitlab
+    host: gitlab.example.com
+    token: glpat-secret
+  - type: git
+    host: git.example.com
+    url: https://git.example.com/scm
+    username: ci
+    token: secret
+`), &cfg))
+	require.NoError(t, cfg.Validate())
+
+	p := cfg.Find("GitLab.example.com")
+	require.NotNil(t, p)
+	assert.Equal(t, ProviderGitLab, p.Type)
+	assert.Equal(t, "glpat-secret", p.Token.String())
+	assert.Equal(t, "https://gitlab.example.com", p.BaseURL())
+
+	p = cfg.Find("git.example.com")
+	require.NotNil(t, p)
+	assert.Equal(t, "https://git.example.com/scm", p.BaseURL())
+	assert.Nil(t, cfg.Find("github.com"))
+
+	for _, invalid := range []ProvidersConfig{
+		{Providers: []ProviderConfig{{Type: "svn", Host: "svn.example.com"}}},
+		{Providers: []ProviderConfig{{Type: ProviderGitLab}}},
+		{Providers: []ProviderConfig{
+			{Type: ProviderGitLab, Host: "gitlab.example.com"},
+			{Type: ProviderGit, Host: "GITLAB.example.com", Token: flagext.SecretWithValue("token")},
+		}},
+	} {
+		assert.Error(t, invalid.Validate())
+	}
+}
@@ -0,0 +1,153 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"connectrpc.com/connect"
+	giturl "github.com/kubescape/go-git-url"
+	bitbucketparser "github.com/kubescape/go-git-url/bitbucketparser/v1"
+	gitlabparser "github.com/kubescape/go-git-url/gitlabparser/v1"
+	"golang.org/x/oauth2"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/client"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/config"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/source"
+)
+
+// vcsClient is a client of a source code provider.
+type vcsClient interface {
+	GetFile(context.Context, client.FileRequest) (client.File, error)
+	GetCommit(context.Context, string, string, string) (*vcsv1.CommitInfo, error)
+}
+
+// repository is a repository URL parsed for its source code provider.
+type repository struct {
+	giturl.IGitURL
+	provider config.Provider
+	host     string
+}
+
+func (r *repository) GetProvider() string { return string(r.provider) }
+
+func (r *repository) GetHostName() string { return r.host }
+
+// parseRepositoryURL parses the URL of a repository hosted on a configured
+// host, or on a host of a known provider.
+func parseRepositoryURL(providers *config.ProvidersConfig, repositoryURL string) (*repository, error) {
+	host := repositoryHost(repositoryURL)
+	if p := providers.Find(host); p != nil {
+		var (
+			gitURL giturl.IGitURL
+			err    error
+		)
+		if p.Type == config.ProviderBitbucket {
+			gitURL, err = bitbucketparser.NewBitBucketParserWithURL(repositoryURL)
+		} else {
+			// The GitLab parser supports any host,
+			// and owners with multiple path segments.
+			gitURL, err = gitlabparser.NewGitLabParserWithURL(host, repositoryURL)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &repository{IGitURL: gitURL, provider: p.Type, host: host}, nil
+	}
+
+	gitURL, err := giturl.NewGitURL(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	switch provider := config.Provider(gitURL.GetProvider()); provider {
+	case config.ProviderGitHub, config.ProviderGitLab, config.ProviderBitbucket:
+		return &repository{IGitURL: gitURL, provider: provider, host: host}, nil
+	}
+	return nil, fmt.Errorf("%s repositories are not supported", gitURL.GetProvider())
+}
+
+// repositoryHost returns the host of a repository URL, which is either
+// an URL or uses the scp-like syntax: [user@]host:path.
+func repositoryHost(repositoryURL string) string {
+	if u, err := url.Parse(repositoryURL); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname())
+	}
+	host, _, ok := strings.Cut(repositoryURL, ":")
+	if !ok {
+		return ""
+	}
+	if i := strings.LastIndexByte(host, '@'); i >= 0 {
+		host = host[i+1:]
+	}
+	return strings.ToLower(host)
+}
+
+// clients creates the clients of the source code providers.
+type clients struct {
+	providers  *config.ProvidersConfig
+	httpClient *http.Client
+	// GitHub OAuth token of the user, if any.
+	githubToken *oauth2.Token
+}
+
+// client returns the client of the repositories hosted on the host.
+// Hosts that are not configured are accessed with the GitHub token of
+// the user (github.com) or anonymously (public GitLab and Bitbucket
+// repositories).
+func (c *clients) client(provider config.Provider, host string) (vcsClient, error) {
+	if host == "" {
+		host = provider.DefaultHost()
+	}
+	if p := c.providers.Find(host); p != nil {
+		if p.Type != provider {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("host %s is not a %s host", host, provider))
+		}
+		return c.configuredClient(p)
+	}
+
+	switch {
+	case provider == config.ProviderGitHub && host == provider.DefaultHost():
+		token := c.githubToken
+		if token == nil {
+			token = new(oauth2.Token)
+		}
+		return client.GithubClient(context.Background(), token, c.httpClient)
+	case provider == config.ProviderGitLab && gitlabparser.IsHostGitLab(host):
+		return client.GitLabClient("https://"+host, "", "", c.httpClient), nil
+	case provider == config.ProviderBitbucket && host == provider.DefaultHost():
+		return client.BitbucketClient("", "", "", c.httpClient), nil
+	}
+	return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("%s repositories on %s are not supported: the host is not configured", provider, host))
+}
+
+func (c *clients) configuredClient(p *config.ProviderConfig) (vcsClient, error) {
+	token := p.Token.String()
+	switch p.Type {
+	case config.ProviderGitHub:
+		if p.APIURL == "" && p.Host == p.Type.DefaultHost() {
+			return client.GithubClient(context.Background(), &oauth2.Token{AccessToken: token}, c.httpClient)
+		}
+		apiURL := p.APIURL
+		if apiURL == "" {
+			apiURL = p.BaseURL() + "/api/v3/"
+		}
+		return client.GithubEnterpriseClient(apiURL, token, c.httpClient)
+	case config.ProviderGitLab:
+		return client.GitLabClient(p.BaseURL(), p.APIURL, token, c.httpClient), nil
+	case config.ProviderBitbucket:
+		return client.BitbucketClient(p.APIURL, p.Username, token, c.httpClient), nil
+	case config.ProviderGit:
+		return client.GitClient(p.BaseURL(), p.Username, token, c.httpClient), nil
+	}
+	return nil, fmt.Errorf("unsupported provider %s", p.Type)
+}
+
+// sourceClients returns the clients of the sources of the file finder.
+func (c *clients) sourceClients() source.ClientFunc {
+	return func(provider config.Provider, host string) (source.VCSClient, error) {
+		return c.client(provider, host)
+	}
+}
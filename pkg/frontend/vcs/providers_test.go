@@ -0,0 +1,83 @@
+package vcs
+
+import (
+	"net/http"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/config"
+)
+
+func testProvidersConfig() *config.ProvidersConfig {
+	return &config.ProvidersConfig{
+		Providers: []config.ProviderConfig{
+			{Type: config.ProviderGitLab, Host: "code.example.com"},
+			{Type: config.ProviderGit, Host: "git.example.com"},
+			{Type: config.ProviderGitHub, Host: "github.example.com"},
+		},
+	}
+}
+
+func Test_parseRepositoryURL(t *testing.T) {
+	for _, tc := range []struct {
+		url      string
+		provider config.Provider
+		host     string
+		owner    string
+		repo     string
+		err      bool
+	}{
+		{url: "https://github.com/grafana/pyroscope", provider: config.ProviderGitHub, host: "github.com", owner: "grafana", repo: "pyroscope"},
+		{url: "git@github.com:grafana/pyroscope.git", provider: config.ProviderGitHub, host: "github.com", owner: "grafana", repo: "pyroscope"},
+		{url: "https://gitlab.com/group/subgroup/repo", provider: config.ProviderGitLab, host: "gitlab.com", owner: "group/subgroup", repo: "repo"},
+		{url: "https://bitbucket.org/workspace/repo", provider: config.ProviderBitbucket, host: "bitbucket.org", owner: "workspace", repo: "repo"},
+		{url: "https://code.example.com/group/subgroup/repo.git", provider: config.ProviderGitLab, host: "code.example.com", owner: "group/subgroup", repo: "repo"},
+		{url: "git@code.example.com:group/repo.git", provider: config.ProviderGitLab, host: "code.example.com", owner: "group", repo: "repo"},
+		{url: "https://git.example.com/team/service", provider: config.ProviderGit, host: "git.example.com", owner: "team", repo: "service"},
+		{url: "https://GitHub.Example.com/org/repo", provider: config.ProviderGitHub, host: "github.example.com", owner: "org", repo: "repo"},
+		{url: "https://dev.azure.com/org/project/_git/repo", err: true},
+		{url: "https://unknown.example.com/org/repo", err: true},
+	} {
+		t.Run(tc.url, func(t *testing.T) {
+			repo, err := parseRepositoryURL(testProvidersConfig(), tc.url)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, string(tc.provider), repo.GetProvider())
+			assert.Equal(t, tc.host, repo.GetHostName())
+			assert.Equal(t, tc.owner, repo.GetOwnerName())
+			assert.Equal(t, tc.repo, repo.GetRepoName())
+		})
+	}
+}
+
+func Test_clients_client(t *testing.T) {
+	c := &clients{providers: testProvidersConfig(), httpClient: http.DefaultClient}
+
+	for _, tc := range []struct {
+		provider config.Provider
+		host     string
+	}{
+		{provider: config.ProviderGitHub, host: ""},
+		{provider: config.ProviderGitLab, host: "gitlab.com"},
+		{provider: config.ProviderBitbucket, host: ""},
+		{provider: config.ProviderGitLab, host: "code.example.com"},
+		{provider: config.ProviderGit, host: "git.example.com"},
+		{provider: config.ProviderGitHub, host: "github.example.com"},
+	} {
+		vc, err := c.client(tc.provider, tc.host)
+		require.NoError(t, err, "%s %s", tc.provider, tc.host)
+		assert.NotNil(t, vc)
+	}
+
+	// Hosts must be configured for the provider.
+	_, err := c.client(config.ProviderGitHub, "code.example.com")
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	_, err = c.client(config.ProviderGit, "unknown.example.com")
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
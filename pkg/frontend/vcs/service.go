@@ -10,8 +10,6 @@ import (
 	"connectrpc.com/connect"
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/tracing"
-	giturl "github.com/kubescape/go-git-url"
-	"github.com/kubescape/go-git-url/apis"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/oauth2"
 
@@ -27,14 +25,16 @@ var _ vcsv1connect.VCSServiceHandler = (*Service)(nil)
 type Service struct {
 	logger     log.Logger
 	httpClient *http.Client
+	providers  config.ProvidersConfig
 }
 
-func New(logger log.Logger, reg prometheus.Registerer) *Service {
+func New(logger log.Logger, reg prometheus.Registerer, providers config.ProvidersConfig) *Service {
 	httpClient := client.InstrumentedHTTPClient(logger, reg)
 
 	return &Service{
 		logger:     logger,
 		httpClient: httpClient,
+		providers:  providers,
 	}
 }
 
@@ -157,36 +157,19 @@ func (q *Service) GetFile(ctx context.Context, req *connect.Request[vcsv1.GetFil
 	sp.SetTag("root_path", req.Msg.RootPath)
 	sp.SetTag("ref", req.Msg.Ref)
 
-	token, err := tokenFromRequest(ctx, req)
-	if err != nil {
-		q.logger.Log("err", err, "msg", "failed to extract token from request")
-		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid token"))
-	}
-
-	err = rejectExpiredToken(token)
+	repo, clients, err := q.repositoryClients(ctx, req, req.Msg.RepositoryURL)
 	if err != nil {
 		return nil, err
 	}
 
-	// initialize and parse the git repo URL
-	gitURL, err := giturl.NewGitURL(req.Msg.RepositoryURL)
-	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
-	}
-
-	if gitURL.GetProvider() != apis.ProviderGitHub.String() {
-		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("only GitHub repositories are supported"))
-	}
-
-	// todo: we can support multiple provider: bitbucket, gitlab, etc.
-	ghClient, err := client.GithubClient(ctx, token, q.httpClient)
+	repoClient, err := clients.client(repo.provider, repo.host)
 	if err != nil {
 		return nil, err
 	}
 
 	file, err := source.NewFileFinder(
-		ghClient,
-		gitURL,
+		repoClient,
+		repo,
 		config.FileSpec{
 			Path:         req.Msg.LocalPath,
 			FunctionName: req.Msg.FunctionName,
@@ -194,8 +177,8 @@ func (q *Service) GetFile(ctx context.Context, req *connect.Request[vcsv1.GetFil
 		req.Msg.RootPath,
 		req.Msg.Ref,
 		http.DefaultClient,
-		log.With(q.logger, "repo", gitURL.GetRepoName()),
-	).Find(ctx)
+		log.With(q.logger, "repo", repo.GetRepoName()),
+	).WithClients(clients.sourceClients()).Find(ctx)
 	if err != nil {
 		if errors.Is(err, client.ErrNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, err)
@@ -211,36 +194,21 @@ func (q *Service) GetCommit(ctx context.Context, req *connect.Request[vcsv1.GetC
 	sp.SetTag("repository_url", req.Msg.RepositoryURL)
 	sp.SetTag("ref", req.Msg.Ref)
 
-	token, err := tokenFromRequest(ctx, req)
-	if err != nil {
-		q.logger.Log("err", err, "msg", "failed to extract token from request")
-		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid token"))
-	}
-
-	err = rejectExpiredToken(token)
+	repo, clients, err := q.repositoryClients(ctx, req, req.Msg.RepositoryURL)
 	if err != nil {
 		return nil, err
 	}
 
-	gitURL, err := giturl.NewGitURL(req.Msg.RepositoryURL)
-	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
-	}
-
-	if gitURL.GetProvider() != apis.ProviderGitHub.String() {
-		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("only GitHub repositories are supported"))
-	}
-
-	ghClient, err := client.GithubClient(ctx, token, q.httpClient)
+	repoClient, err := clients.client(repo.provider, repo.host)
 	if err != nil {
 		return nil, err
 	}
 
-	owner := gitURL.GetOwnerName()
-	repo := gitURL.GetRepoName()
+	owner := repo.GetOwnerName()
+	repoName := repo.GetRepoName()
 	ref := req.Msg.GetRef()
 
-	commit, err := tryGetCommit(ctx, ghClient, owner, repo, ref)
+	commit, err := tryGetCommit(ctx, repoClient, owner, repoName, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -258,43 +226,28 @@ func (q *Service) GetCommits(ctx context.Context, req *connect.Request[vcsv1.Get
 	sp, ctx := tracing.StartSpanFromContext(ctx, "GetCommits")
 	defer sp.Finish()
 
-	token, err := tokenFromRequest(ctx, req)
-	if err != nil {
-		q.logger.Log("err", err, "msg", "failed to extract token from request")
-		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid token"))
-	}
-
-	err = rejectExpiredToken(token)
+	repo, clients, err := q.repositoryClients(ctx, req, req.Msg.RepositoryUrl)
 	if err != nil {
 		return nil, err
 	}
 
-	gitURL, err := giturl.NewGitURL(req.Msg.RepositoryUrl)
-	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
-	}
-
-	if gitURL.GetProvider() != apis.ProviderGitHub.String() {
-		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("only GitHub repositories are supported"))
-	}
-
-	ghClient, err := client.GithubClient(ctx, token, q.httpClient)
+	repoClient, err := clients.client(repo.provider, repo.host)
 	if err != nil {
 		return nil, err
 	}
 
-	owner := gitURL.GetOwnerName()
-	repo := gitURL.GetRepoName()
+	owner := repo.GetOwnerName()
+	repoName := repo.GetRepoName()
 	refs := req.Msg.Refs
 
-	commits, failedFetches, err := getCommits(ctx, ghClient, owner, repo, refs)
+	commits, failedFetches, err := getCommits(ctx, repoClient, owner, repoName, refs)
 	if err != nil {
-		q.logger.Log("err", err, "msg", "failed to get any commits", "owner", owner, "repo", repo)
+		q.logger.Log("err", err, "msg", "failed to get any commits", "owner", owner, "repo", repoName)
 		return nil, err
 	}
 
 	if len(failedFetches) > 0 {
-		q.logger.Log("warn", "partial success fetching commits", "owner", owner, "repo", repo, "successCount", len(commits), "failureCount", len(failedFetches))
+		q.logger.Log("warn", "partial success fetching commits", "owner", owner, "repo", repoName, "successCount", len(commits), "failureCount", len(failedFetches))
 		for _, fetchErr := range failedFetches {
 			q.logger.Log("err", fetchErr, "msg", "failed to fetch commit")
 		}
@@ -303,6 +256,43 @@ func (q *Service) GetCommits(ctx context.Context, req *connect.Request[vcsv1.Get
 	return connect.NewResponse(&vcsv1.GetCommitsResponse{Commits: commits}), nil
 }
 
+// repositoryClients parses the repository URL and returns the clients of
+// the source code providers for the request. Repositories on github.com
+// require the GitHub OAuth token of the user, unless a static token is
+// configured for the host. Otherwise, the token is optional and only used
+// to access files hosted on GitHub, such as standard libraries.
+func (q *Service) repositoryClients(ctx context.Context, req connect.AnyRequest, repositoryURL string) (*repository, *clients, error) {
+	repo, err := parseRepositoryURL(&q.providers, repositoryURL)
+	if err != nil {
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	c := &clients{
+		providers:  &q.providers,
+		httpClient: q.httpClient,
+	}
+	requiresToken := repo.provider == config.ProviderGitHub &&
+		repo.host == config.ProviderGitHub.DefaultHost() &&
+		q.providers.Find(repo.host) == nil
+
+	token, err := tokenFromRequest(ctx, req)
+	if err != nil {
+		if requiresToken {
+			q.logger.Log("err", err, "msg", "failed to extract token from request")
+			return nil, nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid token"))
+		}
+		return repo, c, nil
+	}
+	if err = rejectExpiredToken(token); err != nil {
+		if requiresToken {
+			return nil, nil, err
+		}
+		return repo, c, nil
+	}
+	c.githubToken = token
+	return repo, c, nil
+}
+
 func rejectExpiredToken(token *oauth2.Token) error {
 	if time.Now().After(token.Expiry) {
 		return connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("token is expired"))
@@ -3,6 +3,7 @@ package source
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,6 +25,10 @@ type VCSClient interface {
 	GetFile(ctx context.Context, req client.FileRequest) (client.File, error)
 }
 
+// ClientFunc returns the client of the repositories hosted on the host by
+// the source code provider. An empty host is the default host of the provider.
+type ClientFunc func(provider config.Provider, host string) (VCSClient, error)
+
 // FileFinder finds a file in a vcs repository.
 type FileFinder struct {
 	file          config.FileSpec
@@ -32,6 +37,7 @@ type FileFinder struct {
 
 	config     *config.PyroscopeConfig
 	client     VCSClient
+	clients    ClientFunc
 	httpClient *http.Client
 	logger     log.Logger
 }
@@ -52,6 +58,41 @@ func NewFileFinder(client VCSClient, repo giturl.IGitURL, file config.FileSpec,
 	}
 }
 
+// WithClients sets the function returning the clients of the repositories
+// hosted outside of the repository provider, e.g. the standard libraries
+// hosted on GitHub. By default, the repository client is used.
+func (ff *FileFinder) WithClients(clients ClientFunc) *FileFinder {
+	ff.clients = clients
+	return ff
+}
+
+// sourceClient returns the client of the repositories of the provider.
+func (ff FileFinder) sourceClient(provider config.Provider, host string) (VCSClient, error) {
+	if host == "" && ff.repo.GetProvider() == string(provider) {
+		host = ff.repo.GetHostName()
+	}
+	if host == "" {
+		host = provider.DefaultHost()
+	}
+	if ff.clients == nil || (ff.repo.GetProvider() == string(provider) && strings.EqualFold(ff.repo.GetHostName(), host)) {
+		return ff.client, nil
+	}
+	return ff.clients(provider, host)
+}
+
+// fetchGitHubFile fetches a file from a repository on github.com.
+func (ff FileFinder) fetchGitHubFile(ctx context.Context, req client.FileRequest) (*vcsv1.GetFileResponse, error) {
+	c, err := ff.sourceClient(config.ProviderGitHub, config.ProviderGitHub.DefaultHost())
+	if err != nil {
+		return nil, err
+	}
+	content, err := c.GetFile(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newFileResponse(content.Content, content.URL)
+}
+
 // Find returns the file content and URL.
 func (ff *FileFinder) Find(ctx context.Context) (*vcsv1.GetFileResponse, error) {
 	// first try to gather the config
@@ -77,6 +118,14 @@ func (ff *FileFinder) Find(ctx context.Context) (*vcsv1.GetFileResponse, error)
 		return ff.findPythonFile(ctx, mapping)
 	case config.LanguageJavaScript:
 		return ff.findJavaScriptFile(ctx, mapping)
+	case config.LanguageRust:
+		return ff.findRustFile(ctx, mapping)
+	case config.LanguageCpp:
+		return ff.findCppFile(ctx, mapping)
+	case config.LanguageRuby:
+		return ff.findRubyFile(ctx, mapping)
+	case config.LanguageDotNet:
+		return ff.findDotNetFile(ctx, mapping)
 	default:
 		return ff.findFallback(ctx)
 	}
@@ -93,12 +142,46 @@ func (ff FileFinder) findFallback(ctx context.Context) (*vcsv1.GetFileResponse,
 		return ff.findGoFile(ctx)
 	case isJavaScriptExtension(ext):
 		return ff.findJavaScriptFile(ctx)
+	case ext == ExtRust:
+		return ff.findRustFile(ctx)
+	case isCppExtension(ext):
+		return ff.findCppFile(ctx)
+	case ext == ExtRuby:
+		return ff.findRubyFile(ctx)
+	case ext == ExtCSharp || (ext == "" && isDotNetFunctionName(ff.file.FunctionName)):
+		return ff.findDotNetFile(ctx)
 	default:
 		// by default we return the file content at the given path without any processing.
 		return ff.fetchRepoFile(ctx, ff.file.Path, ff.ref)
 	}
 }
 
+// DetectLanguage returns the language of the file, detected from the file
+// extension or the function name format.
+func DetectLanguage(file config.FileSpec) config.Language {
+	ext := filepath.Ext(file.Path)
+	switch {
+	case ext == ExtGo || ext == ExtAsm:
+		return config.LanguageGo
+	case ext == ExtPython:
+		return config.LanguagePython
+	case ext == ExtJava:
+		return config.LanguageJava
+	case isJavaScriptExtension(ext):
+		return config.LanguageJavaScript
+	case ext == ExtRust:
+		return config.LanguageRust
+	case isCppExtension(ext):
+		return config.LanguageCpp
+	case ext == ExtRuby:
+		return config.LanguageRuby
+	case ext == ExtCSharp || (ext == "" && isDotNetFunctionName(file.FunctionName)):
+		return config.LanguageDotNet
+	default:
+		return config.LanguageUnknown
+	}
+}
+
 // loadConfig attempts to load .pyroscope.yaml from the repository root
 func (ff *FileFinder) loadConfig(ctx context.Context) {
 	sp, ctx := tracing.StartSpanFromContext(ctx, "FileFinder.loadConfig")
@@ -152,6 +235,61 @@ func (arg FileFinder) fetchRepoFile(ctx context.Context, path, ref string) (*vcs
 	return newFileResponse(content.Content, content.URL)
 }
 
+// relativeMappingPath returns the file path relative to the longest path
+// prefix of the mapping that matches it.
+func (ff FileFinder) relativeMappingPath(m *config.MappingConfig) (string, bool) {
+	prefix := -1
+	for _, p := range m.Path {
+		if strings.HasPrefix(ff.file.Path, p.Prefix) && len(p.Prefix) > prefix {
+			prefix = len(p.Prefix)
+		}
+	}
+	if prefix < 0 {
+		return "", false
+	}
+	return strings.TrimLeft(ff.file.Path[prefix:], "/"), true
+}
+
+// fetchFirstMappingFile returns the first file found in the mappings. The
+// path function returns the path of the file within the mapping source, or
+// false if the mapping does not apply to the file.
+func (ff FileFinder) fetchFirstMappingFile(ctx context.Context, mappings []*config.MappingConfig, path func(*config.MappingConfig) (string, bool)) (*vcsv1.GetFileResponse, bool) {
+	for _, m := range mappings {
+		p, ok := path(m)
+		if !ok {
+			continue
+		}
+		resp, err := ff.fetchMappingFile(ctx, m, p)
+		if err != nil {
+			if !errors.Is(err, client.ErrNotFound) {
+				level.Warn(ff.logger).Log("msg", "failed to fetch mapping file", "err", err)
+			}
+			continue
+		}
+		return resp, true
+	}
+	return nil, false
+}
+
+// tryFindRepoFile tries to find the file in the repo, under the rootPath, by
+// removing path segment after path segment, e.g. "/build/src/lib/a.c" is
+// looked up at "build/src/lib/a.c", "src/lib/a.c", "lib/a.c" and "a.c".
+// maxAttempts is the maximum number of lookups.
+func (ff FileFinder) tryFindRepoFile(ctx context.Context, path string, maxAttempts int) (*vcsv1.GetFileResponse, error) {
+	path = strings.TrimLeft(path, "/")
+	for attempt := 1; ; attempt++ {
+		resp, err := ff.fetchRepoFile(ctx, path, ff.ref)
+		if err == nil {
+			return resp, nil
+		}
+		i := strings.Index(path, "/")
+		if !errors.Is(err, client.ErrNotFound) || i < 0 || attempt >= maxAttempts {
+			return nil, err
+		}
+		path = path[i+1:]
+	}
+}
+
 // fetchURL fetches the file content from the given URL.
 func (ff FileFinder) fetchURL(ctx context.Context, url string, decodeBase64 bool) (*vcsv1.GetFileResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -194,20 +332,45 @@ func (ff FileFinder) fetchMappingFile(ctx context.Context, m *config.MappingConf
 		}
 		return ff.fetchRepoFile(ctx, path, ff.ref)
 	}
-	if s := m.Source.GitHub; s != nil {
-		if s.Path != "" {
-			path = filepath.Join(s.Path, path)
-		}
-		content, err := ff.client.GetFile(ctx, client.FileRequest{
-			Owner: s.Owner,
-			Repo:  s.Repo,
-			Ref:   s.Ref,
-			Path:  path,
-		})
-		if err != nil {
-			return nil, err
-		}
-		return newFileResponse(content.Content, content.URL)
+
+	var (
+		provider config.Provider
+		host     string
+		req      client.FileRequest
+		root     string
+	)
+	switch s := m.Source; {
+	case s.GitHub != nil:
+		provider = config.ProviderGitHub
+		req = client.FileRequest{Owner: s.GitHub.Owner, Repo: s.GitHub.Repo, Ref: s.GitHub.Ref}
+		root = s.GitHub.Path
+	case s.GitLab != nil:
+		provider, host = config.ProviderGitLab, s.GitLab.Host
+		req = client.FileRequest{Owner: s.GitLab.Owner, Repo: s.GitLab.Repo, Ref: s.GitLab.Ref}
+		root = s.GitLab.Path
+	case s.Bitbucket != nil:
+		provider = config.ProviderBitbucket
+		req = client.FileRequest{Owner: s.Bitbucket.Owner, Repo: s.Bitbucket.Repo, Ref: s.Bitbucket.Ref}
+		root = s.Bitbucket.Path
+	case s.Git != nil:
+		provider, host = config.ProviderGit, s.Git.Host
+		req = client.FileRequest{Owner: s.Git.Owner, Repo: s.Git.Repo, Ref: s.Git.Ref}
+		root = s.Git.Path
+	default:
+		return nil, fmt.Errorf("no supported source provided, file not resolvable")
+	}
+
+	req.Path = path
+	if root != "" {
+		req.Path = filepath.Join(root, path)
 	}
-	return nil, fmt.Errorf("no supported source provided, file not resolvable")
+	c, err := ff.sourceClient(provider, host)
+	if err != nil {
+		return nil, err
+	}
+	content, err := c.GetFile(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newFileResponse(content.Content, content.URL)
 }
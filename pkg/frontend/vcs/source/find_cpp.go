@@ -0,0 +1,103 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tracing"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/config"
+)
+
+const (
+	ExtC   = ".c"
+	ExtH   = ".h"
+	ExtCC  = ".cc"
+	ExtCPP = ".cpp"
+	ExtCXX = ".cxx"
+	ExtHH  = ".hh"
+	ExtHPP = ".hpp"
+	ExtHXX = ".hxx"
+)
+
+// buildRootPrefixes are build root prefixes stripped from the paths of
+// C/C++ files before they are looked up in the repository.
+var buildRootPrefixes = []string{
+	"/proc/self/cwd/", // Bazel
+}
+
+// systemIncludePrefixes are the prefixes of the system headers, which are
+// not part of the repository.
+var systemIncludePrefixes = []string{
+	"/usr/include/",
+	"/usr/local/include/",
+	"/usr/lib/gcc/",
+	"/usr/lib/llvm",
+}
+
+// isCppExtension returns true if the file extension is a C/C++ extension.
+func isCppExtension(ext string) bool {
+	switch ext {
+	case ExtC, ExtH, ExtCC, ExtCPP, ExtCXX, ExtHH, ExtHPP, ExtHXX:
+		return true
+	default:
+		return false
+	}
+}
+
+// stripBuildRoot removes the known build root prefixes from the path.
+func stripBuildRoot(path string) string {
+	for _, prefix := range buildRootPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
+
+func isSystemIncludePath(path string) bool {
+	for _, prefix := range systemIncludePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findCppFile finds a C/C++ file in a vcs repository.
+// Mappings are expected to match the build root, e.g. "/build/src", which is
+// stripped from the path. Without mappings, the leading path segments are
+// removed until the file is found in the repository.
+func (ff FileFinder) findCppFile(ctx context.Context, mappings ...*config.MappingConfig) (*vcsv1.GetFileResponse, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "findCppFile")
+	defer sp.Finish()
+	sp.SetTag("file.function_name", ff.file.FunctionName)
+	sp.SetTag("file.path", ff.file.Path)
+
+	path := stripBuildRoot(ff.file.Path)
+	resp, ok := ff.fetchFirstMappingFile(ctx, mappings, func(m *config.MappingConfig) (string, bool) {
+		if p, ok := ff.relativeMappingPath(m); ok {
+			return p, true
+		}
+		return strings.TrimLeft(path, "/"), true
+	})
+	if ok {
+		return resp, nil
+	}
+
+	if isSystemIncludePath(path) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("system header not mapped, file not resolvable"))
+	}
+
+	// Fallback to relative file path matching
+	f, err := ff.tryFindRepoFile(ctx, path, 30)
+	if err != nil {
+		level.Warn(ff.logger).Log("msg", "failed to fetch relative file", "err", err)
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("no mappings matched and relative path not found, file not resolvable"))
+	}
+	return f, nil
+}
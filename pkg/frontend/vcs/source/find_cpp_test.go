@@ -0,0 +1,23 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isCppExtension(t *testing.T) {
+	for _, ext := range []string{".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx"} {
+		assert.True(t, isCppExtension(ext), ext)
+	}
+	for _, ext := range []string{".go", ".s", ".rs", ""} {
+		assert.False(t, isCppExtension(ext), ext)
+	}
+}
+
+func Test_stripBuildRoot(t *testing.T) {
+	assert.Equal(t, "src/main.cc", stripBuildRoot("/proc/self/cwd/src/main.cc"))
+	assert.Equal(t, "/build/src/main.cc", stripBuildRoot("/build/src/main.cc"))
+	assert.True(t, isSystemIncludePath("/usr/include/c++/12/bits/stl_vector.h"))
+	assert.False(t, isSystemIncludePath("/build/include/api.h"))
+}
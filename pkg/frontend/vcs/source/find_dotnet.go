@@ -0,0 +1,149 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tracing"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/client"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/config"
+)
+
+const (
+	ExtCSharp = ".cs"
+
+	// maxDotNetPathAttempts is the maximum number of the candidate paths
+	// looked up in the repository.
+	maxDotNetPathAttempts = 12
+)
+
+// isDotNetFunctionName returns true if the function name has the format
+// reported by the .NET profiler: "Assembly!Namespace.Class.Method(args)".
+func isDotNetFunctionName(functionName string) bool {
+	i := strings.IndexByte(functionName, '!')
+	return i > 0 && strings.IndexByte(functionName[i+1:], '.') > 0
+}
+
+// dotNetTypeName returns the namespace-qualified name of the type declaring
+// the method, without the assembly, the arguments, the generic arity and
+// the nested types. For example, given
+// "Example!Example.Orders.OrderService+<>c.<Process>b__0(int32)",
+// it returns "Example.Orders.OrderService".
+func dotNetTypeName(functionName string) string {
+	name := functionName
+	if i := strings.IndexByte(name, '!'); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	// Nested and compiler generated types are declared in the file of the
+	// outermost type.
+	if i := strings.IndexByte(name, '+'); i >= 0 {
+		name = name[:i]
+	} else if i = strings.LastIndexByte(name, '.'); i >= 0 {
+		// Remove the method name.
+		name = name[:i]
+	}
+	if i := strings.IndexByte(name, '`'); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// convertDotNetFunctionNameToPaths returns the candidate paths of the file
+// declaring the method, by convention named after the type, in the order of
+// the most to the least specific. Projects are usually named after the root
+// namespace, with the folders following the remaining namespace segments.
+// For example, "Example.Orders.OrderService" results in:
+//   - "Example/Orders/OrderService.cs"
+//   - "Example.Orders/OrderService.cs"
+//   - "src/Example.Orders/OrderService.cs"
+//   - "Orders/OrderService.cs"
+//   - "OrderService.cs"
+func convertDotNetFunctionNameToPaths(functionName string) []string {
+	segments := strings.Split(dotNetTypeName(functionName), ".")
+	namespace, typeName := segments[:len(segments)-1], segments[len(segments)-1]
+	if typeName == "" {
+		return nil
+	}
+	join := func(dirs ...string) string {
+		return strings.Join(append(slices.Clip(dirs), typeName+ExtCSharp), "/")
+	}
+	paths := []string{join(namespace...)}
+	for i := 2; i <= len(namespace); i++ {
+		// The project is named after the first i segments of the namespace.
+		project := strings.Join(namespace[:i], ".")
+		dirs := append([]string{project}, namespace[i:]...)
+		paths = append(paths, join(dirs...), join(append([]string{"src"}, dirs...)...))
+	}
+	for i := 1; i <= len(namespace); i++ {
+		paths = append(paths, join(namespace[i:]...))
+	}
+	return paths
+}
+
+// findDotNetFile finds a C# file in a vcs repository.
+// When the profile has the file paths, they are resolved the same way as for
+// the other languages. Otherwise, the path is derived from the namespace and
+// the type name; mappings matching the namespace strip the matched prefix,
+// e.g. "Example.Orders" maps "Example.Orders.Services.OrderService" to
+// "Services/OrderService.cs" in the mapped source.
+func (ff FileFinder) findDotNetFile(ctx context.Context, mappings ...*config.MappingConfig) (*vcsv1.GetFileResponse, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "findDotNetFile")
+	defer sp.Finish()
+	sp.SetTag("file.function_name", ff.file.FunctionName)
+	sp.SetTag("file.path", ff.file.Path)
+
+	if ff.file.Path != "" {
+		resp, ok := ff.fetchFirstMappingFile(ctx, mappings, ff.relativeMappingPath)
+		if ok {
+			return resp, nil
+		}
+		f, err := ff.tryFindRepoFile(ctx, ff.file.Path, 30)
+		if err == nil {
+			return f, nil
+		}
+		level.Warn(ff.logger).Log("msg", "failed to fetch relative file", "err", err)
+	}
+
+	typeName := dotNetTypeName(ff.file.FunctionName)
+	for _, m := range mappings {
+		for _, fn := range m.FunctionName {
+			if !strings.HasPrefix(typeName, fn.Prefix) {
+				continue
+			}
+			path := strings.ReplaceAll(strings.TrimLeft(typeName[len(fn.Prefix):], "."), ".", "/") + ExtCSharp
+			resp, err := ff.fetchMappingFile(ctx, m, path)
+			if err != nil {
+				if !errors.Is(err, client.ErrNotFound) {
+					level.Warn(ff.logger).Log("msg", "failed to fetch mapping file", "err", err)
+				}
+				continue
+			}
+			return resp, nil
+		}
+	}
+
+	// Fallback to the namespace conventions
+	for i, path := range convertDotNetFunctionNameToPaths(ff.file.FunctionName) {
+		if i == maxDotNetPathAttempts {
+			break
+		}
+		f, err := ff.fetchRepoFile(ctx, path, ff.ref)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, client.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, connect.NewError(connect.CodeNotFound, errors.New("no mappings matched and namespace path not found, file not resolvable"))
+}
@@ -0,0 +1,48 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dotNetTypeName(t *testing.T) {
+	tests := []struct {
+		functionName string
+		expected     string
+	}{
+		{functionName: "Example!Example.Orders.OrderService.Process(int32)", expected: "Example.Orders.OrderService"},
+		{functionName: "Example!Example.Orders.OrderService+<>c.<Process>b__0()", expected: "Example.Orders.OrderService"},
+		{functionName: "System.Private.CoreLib!System.Collections.Generic.List`1.Add(!0)", expected: "System.Collections.Generic.List"},
+		{functionName: "Example.Orders.OrderService.Process", expected: "Example.Orders.OrderService"},
+		{functionName: "Program.Main", expected: "Program"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.functionName, func(t *testing.T) {
+			assert.Equal(t, tt.expected, dotNetTypeName(tt.functionName))
+		})
+	}
+}
+
+func Test_convertDotNetFunctionNameToPaths(t *testing.T) {
+	assert.Equal(t, []string{
+		"Example/Orders/Services/OrderService.cs",
+		"Example.Orders/Services/OrderService.cs",
+		"src/Example.Orders/Services/OrderService.cs",
+		"Example.Orders.Services/OrderService.cs",
+		"src/Example.Orders.Services/OrderService.cs",
+		"Orders/Services/OrderService.cs",
+		"Services/OrderService.cs",
+		"OrderService.cs",
+	}, convertDotNetFunctionNameToPaths("Example!Example.Orders.Services.OrderService.Process()"))
+
+	assert.Equal(t, []string{"Program.cs"}, convertDotNetFunctionNameToPaths("App!Program.Main()"))
+}
+
+func Test_isDotNetFunctionName(t *testing.T) {
+	assert.True(t, isDotNetFunctionName("Example!Example.Orders.OrderService.Process()"))
+	assert.False(t, isDotNetFunctionName("Example.Orders.OrderService.Process"))
+	assert.False(t, isDotNetFunctionName("org/example/App.main"))
+	assert.False(t, isDotNetFunctionName("!Process"))
+}
@@ -94,16 +94,12 @@ func (ff FileFinder) fetchGoStdlib(ctx context.Context, path string, version str
 		ref = "go" + version
 	}
 
-	content, err := ff.client.GetFile(ctx, client.FileRequest{
+	return ff.fetchGitHubFile(ctx, client.FileRequest{
 		Owner: "golang",
 		Repo:  "go",
 		Path:  filepath.Join("src", path),
 		Ref:   ref,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return newFileResponse(content.Content, content.URL)
 }
 
 func (ff FileFinder) fetchGoMod(ctx context.Context) (*modfile.File, error) {
@@ -144,8 +140,6 @@ func (ff FileFinder) fetchGithubModuleFile(ctx context.Context, mod golang.Modul
 	defer sp.Finish()
 	sp.SetTag("module_path", mod.Path)
 
-	// todo: what if this is not a github repo?
-	// 		VSClient should support querying multiple repo providers.
 	githubFile, err := mod.GithubFile()
 	if err != nil {
 		return nil, err
@@ -155,16 +149,12 @@ func (ff FileFinder) fetchGithubModuleFile(ctx context.Context, mod golang.Modul
 	sp.SetTag("path", githubFile.Path)
 	sp.SetTag("ref", githubFile.Ref)
 
-	content, err := ff.client.GetFile(ctx, client.FileRequest{
+	return ff.fetchGitHubFile(ctx, client.FileRequest{
 		Owner: githubFile.Owner,
 		Repo:  githubFile.Repo,
 		Path:  githubFile.Path,
 		Ref:   githubFile.Ref,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return newFileResponse(content.Content, content.URL)
 }
 
 func (ff FileFinder) fetchGoogleSourceDependencyFile(ctx context.Context, mod golang.Module) (*vcsv1.GetFileResponse, error) {
@@ -36,16 +36,12 @@ func (ff FileFinder) fetchPythonStdlib(ctx context.Context, path string, version
 		ref = version
 	}
 
-	content, err := ff.client.GetFile(ctx, client.FileRequest{
+	return ff.fetchGitHubFile(ctx, client.FileRequest{
 		Owner: "python",
 		Repo:  "cpython",
 		Path:  filepath.Join("Lib", path),
 		Ref:   ref,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return newFileResponse(content.Content, content.URL)
 }
 
 // isPythonStdlibPath returns the cleaned path of the standard library package with version, if detected.
@@ -0,0 +1,120 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tracing"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/client"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/config"
+)
+
+const (
+	ExtRuby = ".rb"
+)
+
+var (
+	// gemPathRegex matches the paths of installed gems and captures the gem
+	// name, version and the path within the gem.
+	// Example: "/usr/local/bundle/gems/rack-3.0.8/lib/rack/request.rb"
+	gemPathRegex = regexp.MustCompile(`/gems/([^/]+?)-(\d[^/-]*)(?:-[^/]+)?/(.+)$`)
+
+	// rubyStdlibRegex matches the paths of the Ruby standard library and
+	// captures the major and minor version.
+	// Example: "/usr/local/lib/ruby/3.2.0/net/http.rb" → version="3_2"
+	rubyStdlibRegex = regexp.MustCompile(`/lib/ruby/(\d+)\.(\d+)\.\d+/(.+)$`)
+)
+
+// gem is an installed Ruby gem.
+type gem struct {
+	Name    string
+	Version string
+	// Path of the file within the gem.
+	Path string
+}
+
+// parseGemPath returns the gem of a file in a gem directory.
+func parseGemPath(path string) (gem, bool) {
+	matches := gemPathRegex.FindAllStringSubmatch(path, -1)
+	if len(matches) == 0 {
+		return gem{}, false
+	}
+	// Take the last match to handle gems vendored into other gems.
+	m := matches[len(matches)-1]
+	return gem{Name: m[1], Version: m[2], Path: m[3]}, true
+}
+
+// isRubyStdlibPath returns the path of the file within the ruby/ruby
+// repository and the maintenance branch of the version, if detected.
+// For example, given "/usr/local/lib/ruby/3.2.0/net/http.rb",
+// it returns ("lib/net/http.rb", "ruby_3_2", true).
+func isRubyStdlibPath(path string) (string, string, bool) {
+	m := rubyStdlibRegex.FindStringSubmatch(path)
+	if m == nil {
+		return "", "", false
+	}
+	return filepath.Join("lib", m[3]), fmt.Sprintf("ruby_%s_%s", m[1], m[2]), true
+}
+
+func (ff FileFinder) fetchRubyStdlib(ctx context.Context, path string, ref string) (*vcsv1.GetFileResponse, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "fetchRubyStdlib")
+	defer sp.Finish()
+
+	return ff.fetchGitHubFile(ctx, client.FileRequest{
+		Owner: "ruby",
+		Repo:  "ruby",
+		Path:  path,
+		Ref:   ref,
+	})
+}
+
+// findRubyFile finds a Ruby file in a vcs repository.
+// Files of installed gems are resolved through the mappings: the path within
+// the gem is looked up in the mapped source.
+func (ff FileFinder) findRubyFile(ctx context.Context, mappings ...*config.MappingConfig) (*vcsv1.GetFileResponse, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "findRubyFile")
+	defer sp.Finish()
+	sp.SetTag("file.function_name", ff.file.FunctionName)
+	sp.SetTag("file.path", ff.file.Path)
+
+	g, isGem := parseGemPath(ff.file.Path)
+	if isGem {
+		sp.SetTag("gem.name", g.Name)
+		sp.SetTag("gem.version", g.Version)
+	}
+	resp, ok := ff.fetchFirstMappingFile(ctx, mappings, func(m *config.MappingConfig) (string, bool) {
+		if path, ok := ff.relativeMappingPath(m); ok {
+			return path, true
+		}
+		if isGem {
+			return g.Path, true
+		}
+		return strings.TrimLeft(ff.file.Path, "/"), true
+	})
+	if ok {
+		return resp, nil
+	}
+
+	if isGem {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("no mapping for gem %s, file not resolvable", g.Name))
+	}
+	if path, ref, ok := isRubyStdlibPath(ff.file.Path); ok {
+		return ff.fetchRubyStdlib(ctx, path, ref)
+	}
+
+	// Fallback to relative file path matching
+	f, err := ff.tryFindRepoFile(ctx, ff.file.Path, 30)
+	if err != nil {
+		level.Warn(ff.logger).Log("msg", "failed to fetch relative file", "err", err)
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("no mappings matched and relative path not found, file not resolvable"))
+	}
+	return f, nil
+}
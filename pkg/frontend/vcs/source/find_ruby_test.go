@@ -0,0 +1,87 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseGemPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		expected   gem
+		expectedOk bool
+	}{
+		{
+			name:       "bundler",
+			path:       "/usr/local/bundle/gems/rack-3.0.8/lib/rack/request.rb",
+			expected:   gem{Name: "rack", Version: "3.0.8", Path: "lib/rack/request.rb"},
+			expectedOk: true,
+		},
+		{
+			name:       "rubygems",
+			path:       "/usr/local/lib/ruby/gems/3.2.0/gems/activesupport-7.1.2/lib/active_support/notifications.rb",
+			expected:   gem{Name: "activesupport", Version: "7.1.2", Path: "lib/active_support/notifications.rb"},
+			expectedOk: true,
+		},
+		{
+			name:       "platform gem",
+			path:       "/home/app/.gem/ruby/3.2.0/gems/google-protobuf-3.25.1-x86_64-linux/lib/google/protobuf.rb",
+			expected:   gem{Name: "google-protobuf", Version: "3.25.1", Path: "lib/google/protobuf.rb"},
+			expectedOk: true,
+		},
+		{
+			name:       "application code",
+			path:       "/app/app/models/order.rb",
+			expectedOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, ok := parseGemPath(tt.path)
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expected, g)
+		})
+	}
+}
+
+func Test_isRubyStdlibPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		expectedPath string
+		expectedRef  string
+		expectedOk   bool
+	}{
+		{
+			name:         "system ruby",
+			path:         "/usr/lib/ruby/3.1.0/json/common.rb",
+			expectedPath: "lib/json/common.rb",
+			expectedRef:  "ruby_3_1",
+			expectedOk:   true,
+		},
+		{
+			name:         "rbenv",
+			path:         "/home/app/.rbenv/versions/3.2.2/lib/ruby/3.2.0/net/http.rb",
+			expectedPath: "lib/net/http.rb",
+			expectedRef:  "ruby_3_2",
+			expectedOk:   true,
+		},
+		{
+			name:       "application code",
+			path:       "/app/lib/tasks/import.rb",
+			expectedOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ref, ok := isRubyStdlibPath(tt.path)
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expectedPath, path)
+			assert.Equal(t, tt.expectedRef, ref)
+		})
+	}
+}
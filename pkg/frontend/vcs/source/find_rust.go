@@ -0,0 +1,126 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tracing"
+
+	vcsv1 "github.com/grafana/pyroscope/api/gen/proto/go/vcs/v1"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/client"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs/config"
+)
+
+const (
+	ExtRust = ".rs"
+)
+
+var (
+	// rustStdlibRegex matches the paths of the Rust standard library, which are
+	// remapped by the compiler to the commit hash of the toolchain.
+	// Example: "/rustc/90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf/library/std/src/rt.rs"
+	rustStdlibRegex = regexp.MustCompile(`/rustc/([0-9a-f]{40})/(.+)$`)
+
+	// cargoRegistryRegex matches the paths of crates downloaded from a cargo
+	// registry and captures the crate directory and the path within the crate.
+	// Example: "/root/.cargo/registry/src/index.crates.io-6f17d22bba15001f/tokio-1.35.1/src/runtime/mod.rs"
+	cargoRegistryRegex = regexp.MustCompile(`/registry/src/[^/]+/([^/]+)/(.+)$`)
+
+	// crateDirRegex splits a crate directory into the crate name and version.
+	crateDirRegex = regexp.MustCompile(`^(.+?)-(\d+\.\d+\.\d+\S*)$`)
+)
+
+// crate is a crate downloaded from a cargo registry.
+type crate struct {
+	Name    string
+	Version string
+	// Path of the file within the crate.
+	Path string
+}
+
+// isRustStdlibPath returns the path of the file within the rust-lang/rust
+// repository and the commit of the toolchain, if detected.
+// For example, given "/rustc/90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf/library/std/src/rt.rs",
+// it returns ("library/std/src/rt.rs", "90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf", true).
+func isRustStdlibPath(path string) (string, string, bool) {
+	m := rustStdlibRegex.FindStringSubmatch(path)
+	if m == nil {
+		return "", "", false
+	}
+	return m[2], m[1], true
+}
+
+// parseCargoRegistryPath returns the crate of a file in a cargo registry.
+func parseCargoRegistryPath(path string) (crate, bool) {
+	m := cargoRegistryRegex.FindStringSubmatch(path)
+	if m == nil {
+		return crate{}, false
+	}
+	dir := crateDirRegex.FindStringSubmatch(m[1])
+	if dir == nil {
+		return crate{}, false
+	}
+	return crate{Name: dir[1], Version: dir[2], Path: m[2]}, true
+}
+
+func (ff FileFinder) fetchRustStdlib(ctx context.Context, path string, commit string) (*vcsv1.GetFileResponse, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "fetchRustStdlib")
+	defer sp.Finish()
+
+	return ff.fetchGitHubFile(ctx, client.FileRequest{
+		Owner: "rust-lang",
+		Repo:  "rust",
+		Path:  path,
+		Ref:   commit,
+	})
+}
+
+// findRustFile finds a Rust file in a vcs repository.
+// Files of crates from a cargo registry are resolved through the mappings:
+// the path within the crate is looked up in the mapped source.
+func (ff FileFinder) findRustFile(ctx context.Context, mappings ...*config.MappingConfig) (*vcsv1.GetFileResponse, error) {
+	sp, ctx := tracing.StartSpanFromContext(ctx, "findRustFile")
+	defer sp.Finish()
+	sp.SetTag("file.function_name", ff.file.FunctionName)
+	sp.SetTag("file.path", ff.file.Path)
+
+	if path, commit, ok := isRustStdlibPath(ff.file.Path); ok {
+		return ff.fetchRustStdlib(ctx, path, commit)
+	}
+
+	c, isCrate := parseCargoRegistryPath(ff.file.Path)
+	if isCrate {
+		sp.SetTag("crate.name", c.Name)
+		sp.SetTag("crate.version", c.Version)
+	}
+	resp, ok := ff.fetchFirstMappingFile(ctx, mappings, func(m *config.MappingConfig) (string, bool) {
+		if path, ok := ff.relativeMappingPath(m); ok {
+			return path, true
+		}
+		// Mappings matching the function name, e.g. "tokio::".
+		if isCrate {
+			return c.Path, true
+		}
+		return strings.TrimLeft(ff.file.Path, "/"), true
+	})
+	if ok {
+		return resp, nil
+	}
+
+	if isCrate {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("no mapping for crate %s, file not resolvable", c.Name))
+	}
+
+	// Fallback to relative file path matching
+	f, err := ff.tryFindRepoFile(ctx, ff.file.Path, 30)
+	if err != nil {
+		level.Warn(ff.logger).Log("msg", "failed to fetch relative file", "err", err)
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("no mappings matched and relative path not found, file not resolvable"))
+	}
+	return f, nil
+}
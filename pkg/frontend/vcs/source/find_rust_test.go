@@ -0,0 +1,92 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isRustStdlibPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedPath   string
+		expectedCommit string
+		expectedOk     bool
+	}{
+		{
+			name:           "std",
+			path:           "/rustc/90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf/library/std/src/rt.rs",
+			expectedPath:   "library/std/src/rt.rs",
+			expectedCommit: "90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf",
+			expectedOk:     true,
+		},
+		{
+			name:           "core before the library directory",
+			path:           "/rustc/5e8c7af8c4a0a6d1b2ab2d8c3f9c8c5b5c3a8f2d/src/libcore/fmt/mod.rs",
+			expectedPath:   "src/libcore/fmt/mod.rs",
+			expectedCommit: "5e8c7af8c4a0a6d1b2ab2d8c3f9c8c5b5c3a8f2d",
+			expectedOk:     true,
+		},
+		{
+			name:       "short hash",
+			path:       "/rustc/90b35a62/library/std/src/rt.rs",
+			expectedOk: false,
+		},
+		{
+			name:       "application code",
+			path:       "src/main.rs",
+			expectedOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, commit, ok := isRustStdlibPath(tt.path)
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expectedPath, path)
+			assert.Equal(t, tt.expectedCommit, commit)
+		})
+	}
+}
+
+func Test_parseCargoRegistryPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		expected   crate
+		expectedOk bool
+	}{
+		{
+			name:       "sparse index",
+			path:       "/root/.cargo/registry/src/index.crates.io-6f17d22bba15001f/tokio-1.35.1/src/runtime/mod.rs",
+			expected:   crate{Name: "tokio", Version: "1.35.1", Path: "src/runtime/mod.rs"},
+			expectedOk: true,
+		},
+		{
+			name:       "git index and dashes in crate name",
+			path:       "/usr/local/cargo/registry/src/github.com-1ecc6299db9ec823/serde-json-core-0.5.1/src/de/mod.rs",
+			expected:   crate{Name: "serde-json-core", Version: "0.5.1", Path: "src/de/mod.rs"},
+			expectedOk: true,
+		},
+		{
+			name:       "pre-release version",
+			path:       "/home/user/.cargo/registry/src/index.crates.io-6f17d22bba15001f/hyper-1.0.0-rc.4/src/lib.rs",
+			expected:   crate{Name: "hyper", Version: "1.0.0-rc.4", Path: "src/lib.rs"},
+			expectedOk: true,
+		},
+		{
+			name:       "application code",
+			path:       "/app/src/main.rs",
+			expectedOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := parseCargoRegistryPath(tt.path)
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expected, c)
+		})
+	}
+}
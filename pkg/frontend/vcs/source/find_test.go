@@ -190,6 +190,55 @@ source_code:
           path: src/paymentservice
 `
 
+const rustPyroscopeYAML = `---
+source_code:
+  mappings:
+    - function_name:
+        - prefix: "tokio::"
+      language: rust
+      source:
+        github:
+          owner: tokio-rs
+          repo: tokio
+          ref: tokio-1.35.1
+          path: tokio
+`
+
+const cppPyroscopeYAML = `---
+source_code:
+  mappings:
+    - path:
+        - prefix: /build/src
+      language: cpp
+      source:
+        local:
+          path: src
+`
+
+const rubyPyroscopeYAML = `---
+source_code:
+  mappings:
+    - path:
+        - prefix: /usr/local/bundle/gems/rack-3.0.8
+      language: ruby
+      source:
+        github:
+          owner: rack
+          repo: rack
+          ref: v3.0.8
+`
+
+const dotnetPyroscopeYAML = `---
+source_code:
+  mappings:
+    - function_name:
+        - prefix: Example.Orders
+      language: dotnet
+      source:
+        local:
+          path: src/Example.Orders
+`
+
 // TestFileFinder_Find tests the complete happy path integration for find.go using table-driven tests
 func TestFileFinder_Find(t *testing.T) {
 	tests := []struct {
@@ -574,6 +623,193 @@ require (
 			expectedURL:     "https://github.com/grafana/pyroscope/blob/main/examples/nodejs-app/server.js",
 			expectedError:   false,
 		},
+		// Rust tests
+		{
+			name: "rust/stdlib",
+			fileSpec: config.FileSpec{
+				FunctionName: "std::rt::lang_start_internal",
+				Path:         "/rustc/90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf/library/std/src/rt.rs",
+			},
+			ref: "main",
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{
+						Owner: "rust-lang",
+						Repo:  "rust",
+						Ref:   "90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf",
+						Path:  "library/std/src/rt.rs",
+					},
+					content: "// CONTENT rt.rs",
+				},
+			},
+			expectedContent: "// CONTENT rt.rs",
+			expectedURL:     "https://github.com/rust-lang/rust/blob/90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf/library/std/src/rt.rs",
+		},
+		{
+			name: "rust/mapped-crate",
+			fileSpec: config.FileSpec{
+				FunctionName: "tokio::runtime::scheduler::multi_thread::worker::run",
+				Path:         "/root/.cargo/registry/src/index.crates.io-6f17d22bba15001f/tokio-1.35.1/src/runtime/scheduler/multi_thread/worker.rs",
+			},
+			ref:           "main",
+			pyroscopeYAML: rustPyroscopeYAML,
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{
+						Owner: "tokio-rs",
+						Repo:  "tokio",
+						Ref:   "tokio-1.35.1",
+						Path:  "tokio/src/runtime/scheduler/multi_thread/worker.rs",
+					},
+					content: "// CONTENT worker.rs",
+				},
+			},
+			expectedContent: "// CONTENT worker.rs",
+			expectedURL:     "https://github.com/tokio-rs/tokio/blob/tokio-1.35.1/tokio/src/runtime/scheduler/multi_thread/worker.rs",
+		},
+		{
+			name: "rust/relative-path",
+			fileSpec: config.FileSpec{
+				FunctionName: "app::handler::serve",
+				Path:         "/home/runner/work/app/app/src/handler.rs",
+			},
+			rootPath: "services/app",
+			ref:      "main",
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{Path: "services/app/src/handler.rs"},
+					content: "// CONTENT handler.rs",
+				},
+			},
+			expectedContent: "// CONTENT handler.rs",
+			expectedURL:     "https://github.com/grafana/pyroscope/blob/main/services/app/src/handler.rs",
+		},
+		// C/C++ tests
+		{
+			name: "cpp/mapped-build-root",
+			fileSpec: config.FileSpec{
+				FunctionName: "server::Connection::read",
+				Path:         "/build/src/net/connection.cc",
+			},
+			ref:           "main",
+			pyroscopeYAML: cppPyroscopeYAML,
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{Path: "src/net/connection.cc"},
+					content: "// CONTENT connection.cc",
+				},
+			},
+			expectedContent: "// CONTENT connection.cc",
+			expectedURL:     "https://github.com/grafana/pyroscope/blob/main/src/net/connection.cc",
+		},
+		{
+			name: "cpp/bazel-build-root",
+			fileSpec: config.FileSpec{
+				FunctionName: "compress",
+				Path:         "/proc/self/cwd/lib/zip/compress.c",
+			},
+			ref: "main",
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{Path: "lib/zip/compress.c"},
+					content: "// CONTENT compress.c",
+				},
+			},
+			expectedContent: "// CONTENT compress.c",
+			expectedURL:     "https://github.com/grafana/pyroscope/blob/main/lib/zip/compress.c",
+		},
+		// Ruby tests
+		{
+			name: "ruby/mapped-gem",
+			fileSpec: config.FileSpec{
+				FunctionName: "Rack::Request#params",
+				Path:         "/usr/local/bundle/gems/rack-3.0.8/lib/rack/request.rb",
+			},
+			ref:           "main",
+			pyroscopeYAML: rubyPyroscopeYAML,
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{
+						Owner: "rack",
+						Repo:  "rack",
+						Ref:   "v3.0.8",
+						Path:  "lib/rack/request.rb",
+					},
+					content: "# CONTENT request.rb",
+				},
+			},
+			expectedContent: "# CONTENT request.rb",
+			expectedURL:     "https://github.com/rack/rack/blob/v3.0.8/lib/rack/request.rb",
+		},
+		{
+			name: "ruby/stdlib",
+			fileSpec: config.FileSpec{
+				FunctionName: "Net::HTTP#request",
+				Path:         "/usr/local/lib/ruby/3.2.0/net/http.rb",
+			},
+			ref: "main",
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{
+						Owner: "ruby",
+						Repo:  "ruby",
+						Ref:   "ruby_3_2",
+						Path:  "lib/net/http.rb",
+					},
+					content: "# CONTENT http.rb",
+				},
+			},
+			expectedContent: "# CONTENT http.rb",
+			expectedURL:     "https://github.com/ruby/ruby/blob/ruby_3_2/lib/net/http.rb",
+		},
+		{
+			name: "ruby/relative-path",
+			fileSpec: config.FileSpec{
+				FunctionName: "OrdersController#create",
+				Path:         "/rails/app/controllers/orders_controller.rb",
+			},
+			ref: "main",
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{Path: "app/controllers/orders_controller.rb"},
+					content: "# CONTENT orders_controller.rb",
+				},
+			},
+			expectedContent: "# CONTENT orders_controller.rb",
+			expectedURL:     "https://github.com/grafana/pyroscope/blob/main/app/controllers/orders_controller.rb",
+		},
+		// .NET tests
+		{
+			name: "dotnet/mapped-namespace",
+			fileSpec: config.FileSpec{
+				FunctionName: "Example.Orders!Example.Orders.Services.OrderService+<>c.<Process>b__0(int32)",
+			},
+			ref:           "main",
+			pyroscopeYAML: dotnetPyroscopeYAML,
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{Path: "src/Example.Orders/Services/OrderService.cs"},
+					content: "// CONTENT OrderService.cs",
+				},
+			},
+			expectedContent: "// CONTENT OrderService.cs",
+			expectedURL:     "https://github.com/grafana/pyroscope/blob/main/src/Example.Orders/Services/OrderService.cs",
+		},
+		{
+			name: "dotnet/namespace-convention",
+			fileSpec: config.FileSpec{
+				FunctionName: "Example.Billing!Example.Billing.Invoices.InvoiceRenderer`1.Render()",
+			},
+			ref: "main",
+			mockFiles: []mockFileResponse{
+				{
+					request: client.FileRequest{Path: "src/Example.Billing/Invoices/InvoiceRenderer.cs"},
+					content: "// CONTENT InvoiceRenderer.cs",
+				},
+			},
+			expectedContent: "// CONTENT InvoiceRenderer.cs",
+			expectedURL:     "https://github.com/grafana/pyroscope/blob/main/src/Example.Billing/Invoices/InvoiceRenderer.cs",
+		},
 		{
 			name: "fallback/unknown-file-extension",
 			fileSpec: config.FileSpec{
@@ -815,3 +1051,99 @@ source_code:
 		})
 	}
 }
+
+// TestFileFinder_Find_SourceProviders tests that files of mappings and
+// standard libraries are fetched from the source code provider hosting them.
+func TestFileFinder_Find_SourceProviders(t *testing.T) {
+	const pyroscopeYAML = `---
+source_code:
+  mappings:
+    - function_name:
+        - prefix: java
+      language: java
+      source:
+        github:
+          owner: openjdk
+          repo: jdk
+          ref: jdk-17+0
+          path: src/java.base/share/classes
+    - function_name:
+        - prefix: com/example/lib
+      language: java
+      source:
+        gitlab:
+          owner: platform/libs
+          repo: lib
+          ref: v1.0.0
+          path: src/main/java
+    - function_name:
+        - prefix: org/example/vendor
+      language: java
+      source:
+        git:
+          host: git.vendor.com
+          owner: vendor
+          repo: sdk
+          ref: main
+`
+	repoClient := newMockVCSClient().addFiles(
+		mockFileResponse{
+			request: client.FileRequest{Owner: "group/team", Repo: "app", Path: ".pyroscope.yaml"},
+			content: pyroscopeYAML,
+		},
+		mockFileResponse{
+			request: client.FileRequest{Owner: "platform/libs", Repo: "lib", Ref: "v1.0.0", Path: "src/main/java/com/example/lib/Client.java"},
+			content: "# Content of Client.java",
+		},
+	)
+	githubClient := newMockVCSClient().addFiles(
+		mockFileResponse{
+			request: client.FileRequest{Owner: "openjdk", Repo: "jdk", Ref: "jdk-17+0", Path: "src/java.base/share/classes/java/util/ArrayList.java"},
+			content: "# Content of ArrayList.java",
+		},
+	)
+	vendorClient := newMockVCSClient().addFiles(
+		mockFileResponse{
+			request: client.FileRequest{Owner: "vendor", Repo: "sdk", Path: "org/example/vendor/Sdk.java"},
+			content: "# Content of Sdk.java",
+		},
+	)
+	clients := func(provider config.Provider, host string) (VCSClient, error) {
+		switch {
+		case provider == config.ProviderGitHub && host == "github.com":
+			return githubClient, nil
+		case provider == config.ProviderGit && host == "git.vendor.com":
+			return vendorClient, nil
+		}
+		return nil, fmt.Errorf("unexpected provider %s on %s", provider, host)
+	}
+
+	repoURL, err := giturl.NewGitURL("https://gitlab.example.com/group/team/app")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		functionName    string
+		expectedContent string
+	}{
+		{functionName: "java/util/ArrayList.add", expectedContent: "# Content of ArrayList.java"},
+		// The GitLab mapping defaults to the host of the repository.
+		{functionName: "com/example/lib/Client.call", expectedContent: "# Content of Client.java"},
+		{functionName: "org/example/vendor/Sdk.init", expectedContent: "# Content of Sdk.java"},
+	} {
+		t.Run(tc.functionName, func(t *testing.T) {
+			response, err := NewFileFinder(
+				repoClient,
+				repoURL,
+				config.FileSpec{FunctionName: tc.functionName},
+				"",
+				"main",
+				&http.Client{},
+				log.NewNopLogger(),
+			).WithClients(clients).Find(context.Background())
+			require.NoError(t, err)
+			content, err := base64.StdEncoding.DecodeString(response.Content)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContent, string(content))
+		})
+	}
+}
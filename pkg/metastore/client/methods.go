@@ -128,6 +128,12 @@ func (c *Client) AddBlock(ctx context.Context, in *metastorev1.AddBlockRequest,
 	})
 }
 
+func (c *Client) AddRecoveredBlock(ctx context.Context, in *metastorev1.AddBlockRequest, opts ...grpc.CallOption) (*metastorev1.AddBlockResponse, error) {
+	return invoke(ctx, c, func(ctx context.Context, instance instance) (*metastorev1.AddBlockResponse, error) {
+		return instance.AddRecoveredBlock(ctx, in, opts...)
+	})
+}
+
 func (c *Client) GetBlockMetadata(ctx context.Context, in *metastorev1.GetBlockMetadataRequest, opts ...grpc.CallOption) (*metastorev1.GetBlockMetadataResponse, error) {
 	return invoke(ctx, c, func(ctx context.Context, instance instance) (*metastorev1.GetBlockMetadataResponse, error) {
 		return instance.GetBlockMetadata(ctx, in, opts...)
@@ -170,6 +176,12 @@ func (c *Client) DeleteTenant(ctx context.Context, in *metastorev1.DeleteTenantR
 	})
 }
 
+func (c *Client) DeleteSeries(ctx context.Context, in *metastorev1.DeleteSeriesRequest, opts ...grpc.CallOption) (*metastorev1.DeleteSeriesResponse, error) {
+	return invoke(ctx, c, func(ctx context.Context, instance instance) (*metastorev1.DeleteSeriesResponse, error) {
+		return instance.DeleteSeries(ctx, in, opts...)
+	})
+}
+
 func (c *Client) ReadIndex(ctx context.Context, in *raftnodepb.ReadIndexRequest, opts ...grpc.CallOption) (*raftnodepb.ReadIndexResponse, error) {
 	return invoke(ctx, c, func(ctx context.Context, instance instance) (*raftnodepb.ReadIndexResponse, error) {
 		return instance.ReadIndex(ctx, in, opts...)
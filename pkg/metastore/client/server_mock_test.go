@@ -54,6 +54,10 @@ func (m *mockServer) DeleteTenant(ctx context.Context, request *metastorev1.Dele
 	return m.tenant.DeleteTenant(ctx, request)
 }
 
+func (m *mockServer) DeleteSeries(ctx context.Context, request *metastorev1.DeleteSeriesRequest) (*metastorev1.DeleteSeriesResponse, error) {
+	return m.tenant.DeleteSeries(ctx, request)
+}
+
 func (m *mockServer) PollCompactionJobs(ctx context.Context, request *metastorev1.PollCompactionJobsRequest) (*metastorev1.PollCompactionJobsResponse, error) {
 	return m.compactor.PollCompactionJobs(ctx, request)
 }
@@ -62,6 +66,10 @@ func (m *mockServer) AddBlock(ctx context.Context, request *metastorev1.AddBlock
 	return m.metastore.AddBlock(ctx, request)
 }
 
+func (m *mockServer) AddRecoveredBlock(ctx context.Context, request *metastorev1.AddBlockRequest) (*metastorev1.AddBlockResponse, error) {
+	return m.metastore.AddRecoveredBlock(ctx, request)
+}
+
 func (m *mockServer) GetBlockMetadata(ctx context.Context, request *metastorev1.GetBlockMetadataRequest) (*metastorev1.GetBlockMetadataResponse, error) {
 	return m.metastore.GetBlockMetadata(ctx, request)
 }
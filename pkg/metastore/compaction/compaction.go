@@ -6,7 +6,9 @@ import (
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/block"
 	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
 )
 
 type Compactor interface {
@@ -39,6 +41,8 @@ type Scheduler interface {
 	// UpdateSchedule adds new jobs and updates the state of existing ones.
 	// Implementation: This method must be idempotent.
 	UpdateSchedule(*bbolt.Tx, *raft_log.CompactionPlanUpdate) error
+	// ListJobPlans lists the plans of all the jobs in the schedule.
+	ListJobPlans(*bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan]
 }
 
 // Schedule prepares changes to the compaction plan based on status updates
@@ -71,15 +75,34 @@ type BlockEntry struct {
 	Tenant     string
 	Shard      uint32
 	Level      uint32
+	// Size of the block, and the total size of the TSDB
+	// indexes and symbols of the block datasets, in bytes.
+	Size        uint64
+	IndexSize   uint64
+	SymbolsSize uint64
+	// End of the replication window of the segment, in milliseconds
+	// since epoch. 0 if the block does not belong to a window.
+	ReplicationWindow int64
 }
 
 func NewBlockEntry(cmd *raft.Log, md *metastorev1.BlockMeta) BlockEntry {
+	var w block.DatasetWeight
+	for _, ds := range md.Datasets {
+		if block.DatasetFormat(ds.Format) != block.DatasetFormat2 {
+			// Downsampled datasets share sections with the raw ones.
+			w.Add(block.WeightOf(ds))
+		}
+	}
 	return BlockEntry{
-		Index:      cmd.Index,
-		AppendedAt: cmd.AppendedAt.UnixNano(),
-		ID:         md.Id,
-		Tenant:     metadata.Tenant(md),
-		Shard:      md.Shard,
-		Level:      md.CompactionLevel,
+		Index:             cmd.Index,
+		AppendedAt:        cmd.AppendedAt.UnixNano(),
+		ID:                md.Id,
+		Tenant:            metadata.Tenant(md),
+		Shard:             md.Shard,
+		Level:             md.CompactionLevel,
+		Size:              md.Size,
+		IndexSize:         w.TSDBBytes,
+		SymbolsSize:       w.SymbolsBytes,
+		ReplicationWindow: md.ReplicationWindow,
 	}
 }
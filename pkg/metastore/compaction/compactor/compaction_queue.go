@@ -20,6 +20,11 @@ type compactionKey struct {
 	tenant string
 	shard  uint32
 	level  uint32
+	// Replication window of the segments (L0 blocks) written by
+	// replicated pushes. The blocks of a window are compacted in
+	// a single job, so that all the replicas of a profile are
+	// deduplicated, regardless of which of them succeeded.
+	window int64
 }
 
 type compactionQueue struct {
@@ -86,13 +91,37 @@ type blockRef struct {
 }
 
 type blockEntry struct {
-	id    string // Block ID.
-	index uint64 // Index of the command in the raft log.
+	id    string    // Block ID.
+	index uint64    // Index of the command in the raft log.
+	size  blockSize // Block size breakdown.
+}
+
+type blockSize struct {
+	total   uint64
+	index   uint64
+	symbols uint64
+}
+
+func (s blockSize) add(x blockSize) blockSize {
+	return blockSize{
+		total:   s.total + x.total,
+		index:   s.index + x.index,
+		symbols: s.symbols + x.symbols,
+	}
+}
+
+func (s blockSize) sub(x blockSize) blockSize {
+	return blockSize{
+		total:   s.total - x.total,
+		index:   s.index - x.index,
+		symbols: s.symbols - x.symbols,
+	}
 }
 
 type batch struct {
 	flush  sync.Once
 	size   uint32
+	bytes  blockSize
 	blocks []blockEntry
 	// Reference to the parent.
 	staged *stagedBlocks
@@ -137,11 +166,24 @@ func (q *compactionQueue) push(e compaction.BlockEntry) bool {
 		tenant: e.Tenant,
 		shard:  e.Shard,
 		level:  e.Level,
+		window: e.ReplicationWindow,
 	})
 	staged.updatedAt = e.AppendedAt
+	if e.ReplicationWindow > 0 {
+		// The batch of the replication window is only flushed by age,
+		// counting from the end of the window: segment writers accept
+		// pushes of the window for a grace period after it ends, which
+		// must be shorter than the max age of the level.
+		staged.updatedAt = max(e.AppendedAt, e.ReplicationWindow*1e6)
+	}
 	pushed := staged.push(blockEntry{
 		id:    e.ID,
 		index: e.Index,
+		size: blockSize{
+			total:   e.Size,
+			index:   e.IndexSize,
+			symbols: e.SymbolsSize,
+		},
 	})
 	heap.Fix(level.updates, staged.heapIndex)
 	level.flushOldest(e.AppendedAt)
@@ -184,7 +226,10 @@ func (q *blockQueue) stagedBlocks(k compactionKey) *stagedBlocks {
 		q.staged[k] = staged
 		heap.Push(q.updates, staged)
 		q.globalStats.AddQueues(k, 1)
-		if q.registerer != nil {
+		// Queues of replication windows are short-lived, and share
+		// the labels with the queue of the compaction key: they are
+		// only accounted in the global stats.
+		if q.registerer != nil && k.window == 0 {
 			staged.collector = newQueueStatsCollector(staged)
 			util.RegisterOrGet(q.registerer, staged.collector)
 		}
@@ -215,6 +260,7 @@ func (s *stagedBlocks) push(block blockEntry) bool {
 		s.batch.createdAt = s.updatedAt
 	}
 	s.batch.size++
+	s.batch.bytes = s.batch.bytes.add(block.size)
 	s.stats.blocks.Add(1)
 	s.queue.globalStats.AddBlocks(s.key, 1)
 	if s.queue.config.exceedsMaxSize(s.batch) ||
@@ -258,6 +304,7 @@ func (s *stagedBlocks) delete(block string) blockEntry {
 	e := ref.batch.blocks[ref.index]
 	ref.batch.blocks[ref.index] = zeroBlockEntry
 	ref.batch.size--
+	ref.batch.bytes = ref.batch.bytes.sub(e.size)
 	s.stats.blocks.Add(-1)
 	s.queue.globalStats.AddBlocks(s.key, -1)
 	if ref.batch.size == 0 {
@@ -478,7 +525,7 @@ func (it *blockIter) more() bool {
 	return it.i < len(it.batch.blocks)
 }
 
-func (it *blockIter) peek() (string, bool) {
+func (it *blockIter) peek() (blockEntry, bool) {
 	for it.batch != nil {
 		if it.i >= len(it.batch.blocks) {
 			it.setBatch(it.batch.next)
@@ -489,9 +536,9 @@ func (it *blockIter) peek() (string, bool) {
 			it.i++
 			continue
 		}
-		return entry.id, true
+		return entry, true
 	}
-	return "", false
+	return zeroBlockEntry, false
 }
 
 func (it *blockIter) advance() {
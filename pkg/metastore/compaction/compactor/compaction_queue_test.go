@@ -127,7 +127,7 @@ func TestBlockQueue_Linking(t *testing.T) {
 					assert.Equal(t, expected, collected)
 					break
 				}
-				collected = append(collected, b)
+				collected = append(collected, b.id)
 				iter.advance()
 			}
 		}
@@ -229,7 +229,7 @@ func TestBlockQueue_FlushByAge(t *testing.T) {
 		batches = append(batches, b.blocks...)
 	}
 
-	expected := []blockEntry{{"1", 1}, {"2", 2}, {"3", 3}, {"4", 4}}
+	expected := []blockEntry{{id: "1", index: 1}, {id: "2", index: 2}, {id: "3", index: 3}, {id: "4", index: 4}}
 	// "5" remains staged as we need another push to evict it.
 	assert.Equal(t, expected, batches)
 
@@ -12,6 +12,13 @@ type Config struct {
 	CleanupDelay       time.Duration
 	CleanupJobMinLevel int32
 	CleanupJobMaxLevel int32
+
+	// Limits of the compaction job source blocks, and therefore of the
+	// compacted block. Index size is used as a proxy for the series
+	// cardinality of the block.
+	MaxBlockSize   uint64 `yaml:"compaction_max_block_size_bytes" category:"experimental"`
+	MaxIndexSize   uint64 `yaml:"compaction_max_index_size_bytes" category:"experimental"`
+	MaxSymbolsSize uint64 `yaml:"compaction_max_symbols_size_bytes" category:"experimental"`
 }
 
 type LevelConfig struct {
@@ -22,6 +29,9 @@ type LevelConfig struct {
 func DefaultConfig() Config {
 	return Config{
 		Levels: []LevelConfig{
+			// L0 MaxAge must exceed the time segment writers accept
+			// pushes after the end of a replication window (10s), plus
+			// the time it takes to flush the segments of the window.
 			{MaxBlocks: 20, MaxAge: int64(1 * 36 * time.Second)},
 			{MaxBlocks: 10, MaxAge: int64(2 * 360 * time.Second)},
 			{MaxBlocks: 10, MaxAge: int64(3 * 3600 * time.Second)},
@@ -34,20 +44,37 @@ func DefaultConfig() Config {
 	}
 }
 
-func (c *Config) RegisterFlagsWithPrefix(string, *flag.FlagSet) {
+func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	// NOTE(kolesnikovae): I'm not sure if making this configurable
 	// is a good idea; however, we might want to add a flag to tune
 	// the parameters based on e.g., segment size or max duration.
 	*c = DefaultConfig()
+	f.Uint64Var(&c.MaxBlockSize, prefix+"compaction-max-block-size-bytes", 0, "Maximum size of a compacted block. Once the total size of the compaction job source blocks reaches the limit, the job is planned, and no more blocks are added to it. 0 to disable.")
+	f.Uint64Var(&c.MaxIndexSize, prefix+"compaction-max-index-size-bytes", 0, "Maximum total size of the TSDB indexes of the compaction job source blocks. The index size reflects the number of series in the block. 0 to disable.")
+	f.Uint64Var(&c.MaxSymbolsSize, prefix+"compaction-max-symbols-size-bytes", 0, "Maximum total size of the symbol tables of the compaction job source blocks. 0 to disable.")
 }
 
 // exceedsSize is called after the block has been added to the batch.
 // If the function returns true, the batch is flushed to the global
-// queue and becomes available for compaction.
+// queue and becomes available for compaction. Batches of replication
+// windows are never flushed by size: they are compacted in one job.
 func (c *Config) exceedsMaxSize(b *batch) bool {
-	return uint(b.size) >= c.maxBlocks(b.staged.key.level)
+	if b.staged.key.window > 0 {
+		return false
+	}
+	return uint(b.size) >= c.maxBlocks(b.staged.key.level) || c.exceedsMaxBytes(b.bytes)
+}
+
+// exceedsMaxBytes reports whether the blocks of the given
+// total size exceed any of the block size limits.
+func (c *Config) exceedsMaxBytes(s blockSize) bool {
+	return exceedsLimit(s.total, c.MaxBlockSize) ||
+		exceedsLimit(s.index, c.MaxIndexSize) ||
+		exceedsLimit(s.symbols, c.MaxSymbolsSize)
 }
 
+func exceedsLimit(v, limit uint64) bool { return limit > 0 && v > limit }
+
 // exceedsAge reports whether the batch update time is older than the
 // maximum age for the level threshold. The function is used in two
 // cases: if the batch is not flushed to the global queue and is the
@@ -75,6 +102,10 @@ func (c *Config) maxAge(l uint32) int64 {
 	return 0
 }
 
+// TopLevel returns the compaction level of blocks
+// that are not compacted any further.
+func (c *Config) TopLevel() uint32 { return uint32(len(c.Levels)) }
+
 func (c *Config) maxLevel() uint32 {
 	// Assuming that there is at least one level.
 	return uint32(len(c.Levels) - 1)
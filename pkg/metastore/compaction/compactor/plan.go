@@ -49,6 +49,7 @@ type jobPlan struct {
 	name       string
 	minT       int64
 	maxT       int64
+	size       blockSize
 	tombstones []*metastorev1.Tombstones
 	blocks     []string
 }
@@ -109,7 +110,8 @@ func (p *plan) nextJob() *jobPlan {
 			}
 			if !job.tryAdd(block) {
 				// We may not want to add a bock to the job if it extends the
-				// compacted block time range beyond the desired limit.
+				// compacted block time range beyond the desired limit, or
+				// if the compacted block would exceed the size limits.
 				// In this case, we need to force compaction of incomplete job.
 				force = true
 				break
@@ -187,19 +189,31 @@ func (job *jobPlan) reset(k compactionKey) {
 	job.blocks = job.blocks[:0]
 	job.minT = math.MaxInt64
 	job.maxT = math.MinInt64
+	job.size = blockSize{}
 }
 
-func (job *jobPlan) tryAdd(block string) bool {
-	t := util.ULIDStringUnixNano(block)
-	if len(job.blocks) > 0 && !job.isInAllowedTimeRange(t) {
+func (job *jobPlan) tryAdd(block blockEntry) bool {
+	t := util.ULIDStringUnixNano(block.id)
+	// All the blocks of a replication window are compacted together,
+	// regardless of the limits: otherwise, the replicas of the same
+	// profiles would end up in distinct blocks.
+	if len(job.blocks) > 0 && job.window == 0 && (!job.isInAllowedTimeRange(t) || !job.isInAllowedSize(block.size)) {
 		return false
 	}
-	job.blocks = append(job.blocks, block)
+	job.blocks = append(job.blocks, block.id)
 	job.maxT = max(job.maxT, t)
 	job.minT = min(job.minT, t)
+	job.size = job.size.add(block.size)
 	return true
 }
 
+// isInAllowedSize reports whether the block can be added to the job
+// without exceeding the size limits. Note that a block that exceeds
+// the limits on its own is still compacted, in a separate job.
+func (job *jobPlan) isInAllowedSize(s blockSize) bool {
+	return !job.config.exceedsMaxBytes(job.size.add(s))
+}
+
 func (job *jobPlan) isInAllowedTimeRange(t int64) bool {
 	if age := job.config.maxAge(job.config.maxLevel()); age > 0 {
 		//          minT        maxT
@@ -216,6 +230,11 @@ func (job *jobPlan) isInAllowedTimeRange(t int64) bool {
 }
 
 func (job *jobPlan) isComplete() bool {
+	if job.window > 0 {
+		// The job is complete once all the blocks
+		// of the replication window are added.
+		return false
+	}
 	return uint(len(job.blocks)) >= job.config.maxBlocks(job.level)
 }
 
@@ -223,6 +242,7 @@ func (job *jobPlan) finalize() {
 	nameJob(job)
 	job.minT = 0
 	job.maxT = 0
+	job.size = blockSize{}
 	job.config = nil
 }
 
@@ -1,11 +1,13 @@
 package compactor
 
 import (
+	"crypto/rand"
 	"fmt"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -315,6 +317,50 @@ func TestPlan_compact_by_time(t *testing.T) {
 	assert.Equal(t, expected, planned)
 }
 
+func TestPlan_replication_window(t *testing.T) {
+	c := NewCompactor(Config{
+		Levels: []LevelConfig{
+			{MaxBlocks: 3, MaxAge: int64(10 * time.Second)},
+		},
+	}, nil, nil, nil)
+
+	// Segments of all the replicas of the window: the primary replica
+	// segments may be missing. The segment writers flush the segments
+	// of the window shortly after it ends.
+	const window int64 = 1_000_000 // End of the window, in ms.
+	w := window * int64(time.Millisecond)
+	for i, appendedAt := range []int64{
+		w - int64(2*time.Second),
+		w - int64(time.Second),
+		w,
+		w + int64(time.Second),
+		w + int64(3*time.Second),
+	} {
+		c.enqueue(compaction.BlockEntry{
+			Index:             uint64(i),
+			AppendedAt:        appendedAt,
+			ID:                strconv.Itoa(i),
+			Shard:             1,
+			ReplicationWindow: window,
+		})
+	}
+
+	// The max age is counted from the end of the window: the
+	// blocks are not compacted even though the batch is full.
+	p := &plan{compactor: c, blocks: newBlockIter(), now: w + int64(5*time.Second)}
+	assert.Nil(t, p.nextJob())
+
+	// The next push flushes the batch of the window.
+	now := w + int64(11*time.Second)
+	c.enqueue(compaction.BlockEntry{Index: 5, AppendedAt: now, ID: "5", Tenant: "A"})
+	p = &plan{compactor: c, blocks: newBlockIter(), now: now}
+	job := p.nextJob()
+	require.NotNil(t, job)
+	assert.Equal(t, compactionKey{shard: 1, window: window}, job.compactionKey)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4"}, job.blocks)
+	assert.Nil(t, p.nextJob())
+}
+
 func TestPlan_time_split(t *testing.T) {
 	s := DefaultConfig()
 	// To skip tombstones for simplicity.
@@ -413,3 +459,159 @@ func TestPlan_remove_staged_batch_corrupts_queue(t *testing.T) {
 
 	require.Nil(t, p1.nextJob(), "A single job is expected.")
 }
+
+// planSimulator simulates the compaction process: blocks are added to
+// the queue, the planned jobs are completed immediately, and compacted
+// blocks are added to the queue of the next level.
+type planSimulator struct {
+	compactor *Compactor
+	now       time.Time
+	index     uint64
+	queued    map[string]compaction.BlockEntry
+	compacted []simulatedBlock
+}
+
+type simulatedBlock struct {
+	compaction.BlockEntry
+	sources int
+}
+
+func newPlanSimulator(config Config) *planSimulator {
+	return &planSimulator{
+		compactor: NewCompactor(config, nil, nil, nil),
+		now:       test.Time("2024-09-23T00:00:00Z"),
+		queued:    make(map[string]compaction.BlockEntry),
+	}
+}
+
+func (s *planSimulator) add(e compaction.BlockEntry) {
+	s.index++
+	s.now = s.now.Add(time.Second)
+	e.Index = s.index
+	e.AppendedAt = s.now.UnixNano()
+	if e.ID == "" {
+		e.ID = ulid.MustNew(ulid.Timestamp(s.now), rand.Reader).String()
+	}
+	if int(e.Level) < len(s.compactor.config.Levels) {
+		s.queued[e.ID] = e
+		s.compactor.enqueue(e)
+	}
+}
+
+// run plans and completes jobs until the queue has no jobs to offer.
+func (s *planSimulator) run() {
+	for {
+		p := &plan{compactor: s.compactor, blocks: newBlockIter(), now: s.now.UnixNano()}
+		var jobs []*jobPlan
+		for j := p.nextJob(); j != nil; j = p.nextJob() {
+			jobs = append(jobs, j)
+		}
+		if len(jobs) == 0 {
+			return
+		}
+		for _, j := range jobs {
+			s.complete(j)
+		}
+	}
+}
+
+func (s *planSimulator) complete(job *jobPlan) {
+	out := compaction.BlockEntry{
+		Tenant: job.tenant,
+		Shard:  job.shard,
+		Level:  job.level + 1,
+		ID:     ulid.MustNew(ulid.MustParse(job.blocks[0]).Time(), rand.Reader).String(),
+	}
+	staged := s.compactor.queue.blockQueue(job.level).stagedBlocks(job.compactionKey)
+	for _, id := range job.blocks {
+		e := s.queued[id]
+		delete(s.queued, id)
+		staged.delete(id)
+		out.Size += e.Size
+		out.IndexSize += e.IndexSize
+		out.SymbolsSize += e.SymbolsSize
+	}
+	s.compacted = append(s.compacted, simulatedBlock{BlockEntry: out, sources: len(job.blocks)})
+	s.add(out)
+}
+
+func TestPlan_size_limits_simulation(t *testing.T) {
+	const MiB = 1 << 20
+	config := Config{
+		Levels: []LevelConfig{
+			{MaxBlocks: 20},
+			{MaxBlocks: 10},
+			{MaxBlocks: 10},
+		},
+	}
+
+	// Shard 1 receives data of a noisy service:
+	// the blocks are large and have many series.
+	simulate := func(config Config) *planSimulator {
+		s := newPlanSimulator(config)
+		for i := 0; i < 2000; i++ {
+			s.add(compaction.BlockEntry{Tenant: "A", Shard: 0, Size: MiB, IndexSize: MiB / 16, SymbolsSize: MiB / 4})
+			s.add(compaction.BlockEntry{Tenant: "A", Shard: 1, Size: 16 * MiB, IndexSize: 4 * MiB, SymbolsSize: 4 * MiB})
+			s.run()
+		}
+		return s
+	}
+
+	maxSize := func(s *planSimulator, shard uint32) (size, index uint64) {
+		for _, b := range s.compacted {
+			if b.Shard == shard {
+				size = max(size, b.Size)
+				index = max(index, b.IndexSize)
+			}
+		}
+		return size, index
+	}
+
+	t.Run("without limits", func(t *testing.T) {
+		s := simulate(config)
+		size, index := maxSize(s, 1)
+		assert.Greater(t, size, uint64(1000*MiB))
+		assert.Greater(t, index, uint64(100*MiB))
+	})
+
+	t.Run("with limits", func(t *testing.T) {
+		limited := config
+		limited.MaxBlockSize = 64 * MiB
+		limited.MaxIndexSize = 8 * MiB
+		s := simulate(limited)
+
+		for _, b := range s.compacted {
+			if b.sources > 1 {
+				assert.LessOrEqual(t, b.Size, limited.MaxBlockSize)
+				assert.LessOrEqual(t, b.IndexSize, limited.MaxIndexSize)
+			}
+			switch {
+			case b.Shard == 0 && b.Level == 1:
+				// The quiet shard is not affected at L0.
+				assert.Equal(t, 20, b.sources)
+			case b.Shard == 1 && b.Level == 1:
+				// Index size limit is reached first.
+				assert.Equal(t, 2, b.sources)
+			}
+		}
+
+		size, index := maxSize(s, 1)
+		assert.Equal(t, uint64(32*MiB), size)
+		assert.Equal(t, uint64(8*MiB), index)
+		size, _ = maxSize(s, 0)
+		assert.Equal(t, uint64(60*MiB), size)
+
+		// All the blocks must be accounted: either compacted to
+		// the last level, or still queued.
+		var total uint64
+		for _, b := range s.compacted {
+			if int(b.Level) == len(limited.Levels) {
+				total += b.Size
+			}
+		}
+		for _, e := range s.queued {
+			total += e.Size
+		}
+		assert.Equal(t, uint64(2000*(MiB+16*MiB)), total)
+	})
+}
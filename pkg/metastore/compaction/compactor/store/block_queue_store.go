@@ -13,7 +13,15 @@ import (
 
 var ErrInvalidBlockEntry = errors.New("invalid block entry")
 
-var blockQueueBucketName = []byte("compaction_block_queue")
+var (
+	blockQueueBucketName = []byte("compaction_block_queue")
+	// Block sizes and replication windows are stored in a separate
+	// bucket: the entries of the queue bucket keep the layout known to
+	// the previous versions, which ignore the bucket. The entries share
+	// the keys of the queue entries; entries of blocks removed from the
+	// queue by a previous version are never read, and only occupy space.
+	blockSizesBucketName = []byte("compaction_block_sizes")
+)
 
 // BlockQueueStore provides methods to store and retrieve block queues.
 // The store is optimized for two cases: load the entire queue (preserving
@@ -26,45 +34,72 @@ var blockQueueBucketName = []byte("compaction_block_queue")
 // always ordered in ascending order by index and use the same cursor when
 // removing entries from the database:
 // DeleteEntry(*bbolt.Tx, ...store.BlockEntry) error
-type BlockQueueStore struct{ bucketName []byte }
+type BlockQueueStore struct {
+	bucketName      []byte
+	sizesBucketName []byte
+}
 
 func NewBlockQueueStore() *BlockQueueStore {
-	return &BlockQueueStore{bucketName: blockQueueBucketName}
+	return &BlockQueueStore{
+		bucketName:      blockQueueBucketName,
+		sizesBucketName: blockSizesBucketName,
+	}
 }
 
 func (s BlockQueueStore) CreateBuckets(tx *bbolt.Tx) error {
-	_, err := tx.CreateBucketIfNotExists(s.bucketName)
+	if _, err := tx.CreateBucketIfNotExists(s.bucketName); err != nil {
+		return err
+	}
+	_, err := tx.CreateBucketIfNotExists(s.sizesBucketName)
 	return err
 }
 
 func (s BlockQueueStore) StoreEntry(tx *bbolt.Tx, entry compaction.BlockEntry) error {
 	e := marshalBlockEntry(entry)
-	return tx.Bucket(s.bucketName).Put(e.Key, e.Value)
+	if err := tx.Bucket(s.bucketName).Put(e.Key, e.Value); err != nil {
+		return err
+	}
+	if entry.Size == 0 && entry.IndexSize == 0 && entry.SymbolsSize == 0 && entry.ReplicationWindow == 0 {
+		return nil
+	}
+	return tx.Bucket(s.sizesBucketName).Put(e.Key, marshalBlockSizes(entry))
 }
 
 func (s BlockQueueStore) DeleteEntry(tx *bbolt.Tx, index uint64, id string) error {
-	return tx.Bucket(s.bucketName).Delete(marshalBlockEntryKey(index, id))
+	k := marshalBlockEntryKey(index, id)
+	if err := tx.Bucket(s.bucketName).Delete(k); err != nil {
+		return err
+	}
+	return tx.Bucket(s.sizesBucketName).Delete(k)
 }
 
 func (s BlockQueueStore) ListEntries(tx *bbolt.Tx) iter.Iterator[compaction.BlockEntry] {
-	return newBlockEntriesIterator(tx.Bucket(s.bucketName))
+	return newBlockEntriesIterator(tx.Bucket(s.bucketName), tx.Bucket(s.sizesBucketName))
 }
 
 type blockEntriesIterator struct {
-	iter *store.CursorIterator
-	cur  compaction.BlockEntry
-	err  error
+	iter  *store.CursorIterator
+	sizes *bbolt.Bucket
+	cur   compaction.BlockEntry
+	err   error
 }
 
-func newBlockEntriesIterator(bucket *bbolt.Bucket) *blockEntriesIterator {
-	return &blockEntriesIterator{iter: store.NewCursorIter(bucket.Cursor())}
+func newBlockEntriesIterator(bucket, sizes *bbolt.Bucket) *blockEntriesIterator {
+	return &blockEntriesIterator{
+		iter:  store.NewCursorIter(bucket.Cursor()),
+		sizes: sizes,
+	}
 }
 
 func (x *blockEntriesIterator) Next() bool {
 	if x.err != nil || !x.iter.Next() {
 		return false
 	}
-	x.err = unmarshalBlockEntry(&x.cur, x.iter.At())
+	kv := x.iter.At()
+	if x.err = unmarshalBlockEntry(&x.cur, kv); x.err != nil {
+		return false
+	}
+	x.err = unmarshalBlockSizes(&x.cur, x.sizes.Get(kv.Key))
 	return x.err == nil
 }
 
@@ -89,6 +124,52 @@ func marshalBlockEntry(e compaction.BlockEntry) store.KV {
 	return store.KV{Key: k, Value: b}
 }
 
+// The block sizes value layout:
+//
+//	size (8) | index_size (8) | symbols_size (8) | [replication_window (8)]
+//
+// The replication window is only stored if the block belongs to one.
+const (
+	blockSizesSize       = 8 + 8 + 8
+	blockSizesWindowSize = blockSizesSize + 8
+)
+
+func marshalBlockSizes(e compaction.BlockEntry) []byte {
+	n := blockSizesSize
+	if e.ReplicationWindow != 0 {
+		n = blockSizesWindowSize
+	}
+	b := make([]byte, n)
+	binary.BigEndian.PutUint64(b[0:8], e.Size)
+	binary.BigEndian.PutUint64(b[8:16], e.IndexSize)
+	binary.BigEndian.PutUint64(b[16:24], e.SymbolsSize)
+	if e.ReplicationWindow != 0 {
+		binary.BigEndian.PutUint64(b[24:32], uint64(e.ReplicationWindow))
+	}
+	return b
+}
+
+// unmarshalBlockSizes sets the block sizes and the replication
+// window. The value is nil if the sizes are unknown: the entry was
+// stored before the sizes were introduced.
+func unmarshalBlockSizes(dst *compaction.BlockEntry, b []byte) error {
+	dst.Size, dst.IndexSize, dst.SymbolsSize = 0, 0, 0
+	dst.ReplicationWindow = 0
+	if b == nil {
+		return nil
+	}
+	if len(b) < blockSizesSize {
+		return ErrInvalidBlockEntry
+	}
+	dst.Size = binary.BigEndian.Uint64(b[0:8])
+	dst.IndexSize = binary.BigEndian.Uint64(b[8:16])
+	dst.SymbolsSize = binary.BigEndian.Uint64(b[16:24])
+	if len(b) >= blockSizesWindowSize {
+		dst.ReplicationWindow = int64(binary.BigEndian.Uint64(b[24:32]))
+	}
+	return nil
+}
+
 func marshalBlockEntryKey(index uint64, id string) []byte {
 	b := make([]byte, 8+len(id))
 	binary.BigEndian.PutUint64(b, index)
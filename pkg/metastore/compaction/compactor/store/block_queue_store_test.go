@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/binary"
 	"strconv"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/store"
 	"github.com/grafana/pyroscope/v2/pkg/test"
 )
 
@@ -23,12 +25,18 @@ func TestBlockQueueStore_StoreEntry(t *testing.T) {
 	entries := make([]compaction.BlockEntry, 1000)
 	for i := range entries {
 		entries[i] = compaction.BlockEntry{
-			Index:      uint64(i),
-			ID:         strconv.Itoa(i),
-			AppendedAt: time.Now().UnixNano(),
-			Level:      uint32(i % 3),
-			Shard:      uint32(i % 8),
-			Tenant:     strconv.Itoa(i % 4),
+			Index:       uint64(i),
+			ID:          strconv.Itoa(i),
+			AppendedAt:  time.Now().UnixNano(),
+			Level:       uint32(i % 3),
+			Shard:       uint32(i % 8),
+			Tenant:      strconv.Itoa(i % 4),
+			Size:        uint64(i) << 20,
+			IndexSize:   uint64(i) << 10,
+			SymbolsSize: uint64(i) << 12,
+		}
+		if i%2 == 0 {
+			entries[i].ReplicationWindow = int64(i) * 5000
 		}
 	}
 	for i := range entries {
@@ -102,3 +110,57 @@ func TestBlockQueueStore_DeleteEntry(t *testing.T) {
 	assert.Nil(t, iter.Close())
 	require.NoError(t, tx.Rollback())
 }
+
+func TestBlockQueueStore_LegacyEntry(t *testing.T) {
+	db := test.BoltDB(t)
+
+	s := NewBlockQueueStore()
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateBuckets(tx))
+
+	// The layout of the queue entries is not changed: entries stored
+	// before the block sizes were introduced don't have the sizes, and
+	// the previous versions can read the entries stored by this one.
+	value := make([]byte, 16+len("tenant"))
+	binary.BigEndian.PutUint64(value[0:8], 42)
+	binary.BigEndian.PutUint32(value[8:12], 2)
+	binary.BigEndian.PutUint32(value[12:16], 3)
+	copy(value[16:], "tenant")
+	legacy := store.KV{Key: marshalBlockEntryKey(1, "legacy"), Value: value}
+	require.NoError(t, tx.Bucket(blockQueueBucketName).Put(legacy.Key, legacy.Value))
+
+	e := compaction.BlockEntry{
+		Index:      2,
+		ID:         "block",
+		AppendedAt: 42,
+		Level:      2,
+		Shard:      3,
+		Tenant:     "tenant",
+		Size:       1 << 20,
+	}
+	require.NoError(t, s.StoreEntry(tx, e))
+	assert.Equal(t, value, tx.Bucket(blockQueueBucketName).Get(marshalBlockEntryKey(2, "block")))
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tx.Rollback())
+	}()
+	iter := s.ListEntries(tx)
+	require.True(t, iter.Next())
+	assert.Equal(t, compaction.BlockEntry{
+		Index:      1,
+		ID:         "legacy",
+		AppendedAt: 42,
+		Level:      2,
+		Shard:      3,
+		Tenant:     "tenant",
+	}, iter.At())
+	require.True(t, iter.Next())
+	assert.Equal(t, e, iter.At())
+	assert.False(t, iter.Next())
+	assert.NoError(t, iter.Err())
+	assert.NoError(t, iter.Close())
+}
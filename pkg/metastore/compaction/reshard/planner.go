@@ -0,0 +1,261 @@
+package reshard
+
+import (
+	"flag"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	pyroiter "github.com/grafana/pyroscope/v2/pkg/iter"
+	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
+)
+
+// Shard assignment is decided at write time: when the placement of a tenant
+// changes (e.g., the number of shards allocated to a dataset is reduced),
+// the blocks written before the change remain in the shards they were
+// written to. Compaction never merges blocks of different shards, therefore
+// queries over long time ranges have to fan out to every shard the tenant
+// has ever been placed to.
+//
+// Resharding moves the historical blocks of a tenant to the shards of its
+// current placement: the blocks are rewritten by compaction workers as part
+// of regular compaction jobs, with the target shard specified. The jobs are
+// planned by the leader and added to the compaction schedule via Raft.
+
+type Config struct {
+	Interval        time.Duration `yaml:"compaction_reshard_interval" category:"experimental"`
+	PlacementWindow time.Duration `yaml:"compaction_reshard_placement_window" category:"experimental"`
+	MaxJobs         int           `yaml:"compaction_reshard_max_jobs" category:"experimental"`
+	MaxBlocksPerJob int           `yaml:"compaction_reshard_max_blocks_per_job" category:"experimental"`
+}
+
+func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&c.Interval, prefix+"compaction-reshard-interval", 0, "Interval at which the leader plans jobs that move the blocks of a tenant to the shards of its current placement. Only blocks of the top compaction level are moved. 0 to disable.")
+	f.DurationVar(&c.PlacementWindow, prefix+"compaction-reshard-placement-window", 12*time.Hour, "The current placement of a tenant is determined by the shards the tenant has written to within this period. Blocks created before the window are moved to the shards of the current placement.")
+	f.IntVar(&c.MaxJobs, prefix+"compaction-reshard-max-jobs", 16, "Maximum number of reshard jobs planned at once.")
+	f.IntVar(&c.MaxBlocksPerJob, prefix+"compaction-reshard-max-blocks-per-job", 10, "Maximum number of blocks moved by a single reshard job.")
+}
+
+// Planner creates compaction jobs that move the blocks
+// of tenants to the shards of their current placement.
+type Planner interface {
+	// CreateJobs examines the provided partitions and returns
+	// plans of the jobs to be added to the compaction schedule.
+	CreateJobs(*bbolt.Tx, iter.Seq[indexstore.Partition]) []*raft_log.CompactionJobPlan
+}
+
+type IndexReader interface {
+	ShardBlocks(*bbolt.Tx, indexstore.Partition, string, uint32) iter.Seq2[*metastorev1.BlockMeta, error]
+}
+
+type JobLister interface {
+	ListJobPlans(*bbolt.Tx) pyroiter.Iterator[*raft_log.CompactionJobPlan]
+}
+
+type shardKey struct {
+	tenant string
+	shard  uint32
+}
+
+// ActiveShards is the set of tenant shards
+// that have reshard jobs in the schedule.
+type ActiveShards map[shardKey]struct{}
+
+func ListActiveShards(tx *bbolt.Tx, jobs JobLister) (ActiveShards, error) {
+	active := make(ActiveShards)
+	plans := jobs.ListJobPlans(tx)
+	defer func() {
+		_ = plans.Close()
+	}()
+	for plans.Next() {
+		if plan := plans.At(); plan.TargetShard > 0 {
+			active[shardKey{tenant: plan.Tenant, shard: plan.Shard}] = struct{}{}
+		}
+	}
+	return active, plans.Err()
+}
+
+func (s ActiveShards) Contains(tenant string, shard uint32) bool {
+	_, ok := s[shardKey{tenant: tenant, shard: shard}]
+	return ok
+}
+
+// PlacementPlanner plans reshard jobs for tenant shards of the partitions
+// created before the placement window. A shard is moved if the tenant has
+// not written to it within the window; the target shard is chosen among
+// the shards the tenant has written to, deterministically.
+//
+// Only blocks of the top compaction level (minLevel and above) are moved:
+// blocks of lower levels are still subject to compaction. Tenant shards
+// that already have reshard jobs in the schedule are skipped.
+type PlacementPlanner struct {
+	logger   log.Logger
+	config   Config
+	index    IndexReader
+	jobs     JobLister
+	minLevel uint32
+	now      time.Time
+
+	planned []*raft_log.CompactionJobPlan
+}
+
+func NewPlacementPlanner(
+	logger log.Logger,
+	config Config,
+	index IndexReader,
+	jobs JobLister,
+	minLevel uint32,
+	now time.Time,
+) *PlacementPlanner {
+	return &PlacementPlanner{
+		logger:   logger,
+		config:   config,
+		index:    index,
+		jobs:     jobs,
+		minLevel: minLevel,
+		now:      now,
+	}
+}
+
+func (p *PlacementPlanner) CreateJobs(tx *bbolt.Tx, partitions iter.Seq[indexstore.Partition]) []*raft_log.CompactionJobPlan {
+	p.planned = nil
+	windowStart := p.now.Add(-p.config.PlacementWindow)
+	placement := make(map[string][]uint32)
+	var historical []indexstore.Partition
+	for partition := range partitions {
+		if !partition.EndTime().After(windowStart) {
+			historical = append(historical, partition)
+			continue
+		}
+		q := partition.Query(tx)
+		if q == nil {
+			continue
+		}
+		for tenant := range q.Tenants() {
+			for shard := range q.Shards(tenant) {
+				placement[tenant] = append(placement[tenant], shard.Shard)
+			}
+		}
+	}
+	for tenant, shards := range placement {
+		slices.Sort(shards)
+		placement[tenant] = slices.Compact(shards)
+	}
+
+	active, err := ListActiveShards(tx, p.jobs)
+	if err != nil {
+		level.Error(p.logger).Log("msg", "failed to list compaction jobs", "err", err)
+		return nil
+	}
+
+	for _, partition := range historical {
+		q := partition.Query(tx)
+		if q == nil {
+			continue
+		}
+		for tenant := range q.Tenants() {
+			shards := placement[tenant]
+			if tenant == "" || len(shards) == 0 {
+				// Anonymous tenant blocks are never resharded. If the
+				// tenant does not have recent data, there is no placement
+				// the blocks could be moved to.
+				continue
+			}
+			for shard := range q.Shards(tenant) {
+				if _, found := slices.BinarySearch(shards, shard.Shard); found {
+					continue
+				}
+				if active.Contains(tenant, shard.Shard) {
+					continue
+				}
+				target := shards[shard.Shard%uint32(len(shards))]
+				if err = p.planShard(tx, partition, tenant, shard.Shard, target); err != nil {
+					level.Error(p.logger).Log(
+						"msg", "failed to plan reshard jobs",
+						"partition", partition.String(),
+						"tenant", tenant,
+						"shard", shard.Shard,
+						"err", err,
+					)
+					continue
+				}
+				if p.limitReached() {
+					return p.planned
+				}
+			}
+		}
+	}
+
+	return p.planned
+}
+
+func (p *PlacementPlanner) planShard(tx *bbolt.Tx, partition indexstore.Partition, tenant string, shard, target uint32) error {
+	levels := make(map[uint32][]string)
+	for md, err := range p.index.ShardBlocks(tx, partition, tenant, shard) {
+		if err != nil {
+			return err
+		}
+		if md.CompactionLevel >= p.minLevel {
+			levels[md.CompactionLevel] = append(levels[md.CompactionLevel], md.Id)
+		}
+	}
+	maxBlocks := max(1, p.config.MaxBlocksPerJob)
+	for _, l := range slices.Sorted(maps.Keys(levels)) {
+		for blocks := range slices.Chunk(levels[l], maxBlocks) {
+			if p.limitReached() {
+				return nil
+			}
+			job := &raft_log.CompactionJobPlan{
+				Tenant:          tenant,
+				Shard:           shard,
+				CompactionLevel: l,
+				SourceBlocks:    blocks,
+				TargetShard:     target,
+			}
+			job.Name = jobName(job)
+			p.planned = append(p.planned, job)
+			level.Debug(p.logger).Log(
+				"msg", "planned reshard job",
+				"job", job.Name,
+				"blocks", len(blocks),
+				"target_shard", target,
+			)
+		}
+	}
+	return nil
+}
+
+func (p *PlacementPlanner) limitReached() bool {
+	return p.config.MaxJobs > 0 && len(p.planned) >= p.config.MaxJobs
+}
+
+// jobName follows the naming of regular compaction jobs,
+// with the target shard appended.
+func jobName(job *raft_log.CompactionJobPlan) string {
+	buf := make([]byte, 0, 512)
+	for _, b := range job.SourceBlocks {
+		buf = append(buf, b...)
+	}
+	var name strings.Builder
+	name.WriteString(fmt.Sprintf("%x", xxhash.Sum64(buf)))
+	name.WriteString("-T")
+	name.WriteString(job.Tenant)
+	name.WriteString("-S")
+	name.WriteString(strconv.FormatUint(uint64(job.Shard), 10))
+	name.WriteString("-L")
+	name.WriteString(strconv.FormatUint(uint64(job.CompactionLevel), 10))
+	name.WriteString("-R")
+	name.WriteString(strconv.FormatUint(uint64(job.TargetShard), 10))
+	return name.String()
+}
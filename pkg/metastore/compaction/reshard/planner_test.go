@@ -0,0 +1,143 @@
+package reshard
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index"
+	"github.com/grafana/pyroscope/v2/pkg/test"
+	"github.com/grafana/pyroscope/v2/pkg/util"
+)
+
+type jobsMock []*raft_log.CompactionJobPlan
+
+func (m jobsMock) ListJobPlans(*bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan] {
+	return iter.NewSliceIterator(m)
+}
+
+func testBlock(id string, tenant string, shard, level uint32) *metastorev1.BlockMeta {
+	md := &metastorev1.BlockMeta{
+		Id:              test.ULID(id),
+		Shard:           shard,
+		CompactionLevel: level,
+		StringTable:     []string{""},
+	}
+	if tenant != "" {
+		md.Tenant = 1
+		md.StringTable = append(md.StringTable, tenant)
+	}
+	return md
+}
+
+func TestPlacementPlanner_CreateJobs(t *testing.T) {
+	db := test.BoltDB(t)
+	idx := index.NewIndex(util.Logger, index.NewStore(), index.DefaultConfig, nil)
+	require.NoError(t, db.Update(idx.Init))
+
+	blocks := []*metastorev1.BlockMeta{
+		// Historical partition.
+		testBlock("2024-09-11T01:00:00.001Z", "tenant-a", 1, 3),
+		testBlock("2024-09-11T01:00:00.002Z", "tenant-a", 1, 3),
+		testBlock("2024-09-11T01:00:00.003Z", "tenant-a", 1, 3),
+		testBlock("2024-09-11T01:00:00.004Z", "tenant-a", 1, 1),
+		testBlock("2024-09-11T01:00:00.005Z", "tenant-a", 2, 3),
+		testBlock("2024-09-11T01:00:00.006Z", "tenant-a", 3, 3),
+		testBlock("2024-09-11T01:00:00.007Z", "tenant-b", 1, 3),
+		testBlock("2024-09-11T01:00:00.008Z", "", 1, 0),
+		// Current placement.
+		testBlock("2024-09-12T07:00:00.001Z", "tenant-a", 3, 0),
+		testBlock("2024-09-12T07:00:00.002Z", "tenant-a", 4, 0),
+		testBlock("2024-09-12T07:00:00.003Z", "", 1, 0),
+	}
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		for _, md := range blocks {
+			if err := idx.InsertBlock(tx, md.CloneVT()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	config := Config{
+		PlacementWindow: 12 * time.Hour,
+		MaxBlocksPerJob: 2,
+	}
+	now := test.Time("2024-09-12T12:00:00Z")
+	createJobs := func(config Config, jobs jobsMock) []*raft_log.CompactionJobPlan {
+		var planned []*raft_log.CompactionJobPlan
+		require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+			p := NewPlacementPlanner(util.Logger, config, idx, jobs, 3, now)
+			planned = p.CreateJobs(tx, idx.Partitions(tx))
+			return nil
+		}))
+		return planned
+	}
+
+	t.Run("blocks are moved to the current placement", func(t *testing.T) {
+		planned := createJobs(config, nil)
+		require.Len(t, planned, 3)
+		slices.SortFunc(planned, func(a, b *raft_log.CompactionJobPlan) int {
+			return slices.Compare(a.SourceBlocks, b.SourceBlocks)
+		})
+
+		expected := []*raft_log.CompactionJobPlan{
+			{
+				Tenant:          "tenant-a",
+				Shard:           1,
+				CompactionLevel: 3,
+				SourceBlocks:    []string{blocks[0].Id, blocks[1].Id},
+				TargetShard:     4,
+			},
+			{
+				Tenant:          "tenant-a",
+				Shard:           1,
+				CompactionLevel: 3,
+				SourceBlocks:    []string{blocks[2].Id},
+				TargetShard:     4,
+			},
+			{
+				Tenant:          "tenant-a",
+				Shard:           2,
+				CompactionLevel: 3,
+				SourceBlocks:    []string{blocks[4].Id},
+				TargetShard:     3,
+			},
+		}
+		for i := range expected {
+			assert.NotEmpty(t, planned[i].Name)
+			expected[i].Name = planned[i].Name
+			assert.Equal(t, expected[i], planned[i])
+		}
+		assert.NotEqual(t, planned[0].Name, planned[1].Name)
+	})
+
+	t.Run("shards with reshard jobs are skipped", func(t *testing.T) {
+		jobs := jobsMock{
+			{Name: "regular", Tenant: "tenant-a", Shard: 2},
+			{Name: "reshard", Tenant: "tenant-a", Shard: 1, TargetShard: 4},
+		}
+		planned := createJobs(config, jobs)
+		require.Len(t, planned, 1)
+		assert.Equal(t, uint32(2), planned[0].Shard)
+	})
+
+	t.Run("max jobs", func(t *testing.T) {
+		c := config
+		c.MaxJobs = 1
+		assert.Len(t, createJobs(c, nil), 1)
+	})
+
+	t.Run("no historical partitions", func(t *testing.T) {
+		c := config
+		c.PlacementWindow = 48 * time.Hour
+		assert.Empty(t, createJobs(c, nil))
+	})
+}
@@ -0,0 +1,119 @@
+package reshard
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/pyroscope/v2/pkg/metastore/raftnode"
+)
+
+type Index interface {
+	ReshardBlocks(context.Context, Planner) error
+}
+
+// Resharder periodically plans jobs that move the blocks of tenants
+// to the shards of their current placement. It runs on the leader.
+type Resharder struct {
+	logger   log.Logger
+	config   Config
+	index    Index
+	reader   IndexReader
+	jobs     JobLister
+	minLevel uint32
+
+	started bool
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+}
+
+func NewResharder(
+	logger log.Logger,
+	config Config,
+	index Index,
+	reader IndexReader,
+	jobs JobLister,
+	minLevel uint32,
+) *Resharder {
+	return &Resharder{
+		logger:   logger,
+		config:   config,
+		index:    index,
+		reader:   reader,
+		jobs:     jobs,
+		minLevel: minLevel,
+	}
+}
+
+func (r *Resharder) Start() {
+	if r.config.Interval == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		r.logger.Log("msg", "resharder already started")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.started = true
+	go r.loop(ctx)
+	r.logger.Log("msg", "resharder started")
+}
+
+func (r *Resharder) Stop() {
+	if r.config.Interval == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.started {
+		r.logger.Log("msg", "resharder already stopped")
+		return
+	}
+	r.cancel()
+	r.started = false
+	r.logger.Log("msg", "resharder stopped")
+}
+
+func (r *Resharder) loop(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.reshard(ctx) {
+				return
+			}
+		}
+	}
+}
+
+// reshard returns false if the context has been canceled.
+func (r *Resharder) reshard(ctx context.Context) bool {
+	planner := NewPlacementPlanner(
+		log.With(r.logger, "component", "reshard-planner"),
+		r.config,
+		r.reader,
+		r.jobs,
+		r.minLevel,
+		time.Now(),
+	)
+	switch err := r.index.ReshardBlocks(ctx, planner); {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		return false
+	case raftnode.IsRaftLeadershipError(err):
+		level.Warn(r.logger).Log("msg", "leadership change; resharding interrupted", "err", err)
+	default:
+		level.Error(r.logger).Log("msg", "failed to plan reshard jobs", "err", err)
+	}
+	return true
+}
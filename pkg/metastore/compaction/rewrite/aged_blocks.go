@@ -0,0 +1,196 @@
+package rewrite
+
+import (
+	"iter"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/block"
+	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
+)
+
+// Compaction workers add downsampled datasets to the compacted blocks, and
+// write them to the cold storage tier, once all the data of the job has
+// reached the minimum age. Blocks of the top compaction level are often
+// created before that, and are not compacted again: such blocks are
+// rewritten by jobs planned by the leader.
+
+type IndexReader interface {
+	ShardBlocks(*bbolt.Tx, indexstore.Partition, string, uint32) iter.Seq2[*metastorev1.BlockMeta, error]
+}
+
+// AgedBlockPlanner plans jobs that rewrite blocks of the top compaction
+// level (minLevel and above) once all their data has reached the minimum
+// age. A job rewrites a single block; blocks that are sources of other
+// jobs in the schedule are skipped.
+type AgedBlockPlanner struct {
+	logger   log.Logger
+	index    IndexReader
+	jobs     JobLister
+	minLevel uint32
+	minAge   time.Duration
+	maxJobs  int
+	now      time.Time
+
+	// needsRewrite reports whether the block is to be rewritten.
+	needsRewrite func(*metastorev1.BlockMeta) bool
+	// setJobOptions marks the plan of the job.
+	setJobOptions func(*raft_log.CompactionJobPlan)
+
+	planned []*raft_log.CompactionJobPlan
+}
+
+// NewDownsamplingPlanner returns a planner of jobs that add downsampled
+// datasets to the aged blocks that have no downsampled datasets yet.
+func NewDownsamplingPlanner(
+	logger log.Logger,
+	config Config,
+	index IndexReader,
+	jobs JobLister,
+	minLevel uint32,
+	now time.Time,
+) *AgedBlockPlanner {
+	return &AgedBlockPlanner{
+		logger:        logger,
+		index:         index,
+		jobs:          jobs,
+		minLevel:      minLevel,
+		minAge:        config.DownsamplingMinAge,
+		maxJobs:       config.DownsamplingMaxJobs,
+		now:           now,
+		needsRewrite:  needsDownsampling,
+		setJobOptions: func(job *raft_log.CompactionJobPlan) { job.Downsample = true },
+	}
+}
+
+// NewColdStoragePlanner returns a planner of jobs that move the aged
+// blocks of the hot storage tier to the cold one.
+func NewColdStoragePlanner(
+	logger log.Logger,
+	config Config,
+	index IndexReader,
+	jobs JobLister,
+	minLevel uint32,
+	now time.Time,
+) *AgedBlockPlanner {
+	return &AgedBlockPlanner{
+		logger:   logger,
+		index:    index,
+		jobs:     jobs,
+		minLevel: minLevel,
+		minAge:   config.ColdStorageMinAge,
+		maxJobs:  config.ColdStorageMaxJobs,
+		now:      now,
+		needsRewrite: func(md *metastorev1.BlockMeta) bool {
+			return md.StorageTier != metastorev1.StorageTier_STORAGE_TIER_COLD
+		},
+		setJobOptions: func(job *raft_log.CompactionJobPlan) { job.ColdStorage = true },
+	}
+}
+
+func (p *AgedBlockPlanner) CreateJobs(tx *bbolt.Tx, partitions iter.Seq[indexstore.Partition]) []*raft_log.CompactionJobPlan {
+	p.planned = nil
+	active, err := ListActiveBlocks(tx, p.jobs)
+	if err != nil {
+		level.Error(p.logger).Log("msg", "failed to list compaction jobs", "err", err)
+		return nil
+	}
+	maxTime := p.now.Add(-p.minAge)
+	for partition := range partitions {
+		// Partitions are ordered by time: blocks of the next
+		// partitions are not expected to have aged enough.
+		if !partition.StartTime().Before(maxTime) {
+			break
+		}
+		q := partition.Query(tx)
+		if q == nil {
+			continue
+		}
+		for tenant := range q.Tenants() {
+			if tenant == "" {
+				// Anonymous tenant blocks are never compacted
+				// to the top level.
+				continue
+			}
+			for shard := range q.Shards(tenant) {
+				if !time.UnixMilli(shard.ShardIndex.MinTime).Before(maxTime) {
+					continue
+				}
+				if err = p.planShard(tx, partition, tenant, shard.Shard, maxTime, active); err != nil {
+					level.Error(p.logger).Log(
+						"msg", "failed to plan jobs",
+						"partition", partition.String(),
+						"tenant", tenant,
+						"shard", shard.Shard,
+						"err", err,
+					)
+					continue
+				}
+				if p.limitReached() {
+					return p.planned
+				}
+			}
+		}
+	}
+	return p.planned
+}
+
+func (p *AgedBlockPlanner) planShard(
+	tx *bbolt.Tx,
+	partition indexstore.Partition,
+	tenant string,
+	shard uint32,
+	maxTime time.Time,
+	active ActiveBlocks,
+) error {
+	for md, err := range p.index.ShardBlocks(tx, partition, tenant, shard) {
+		if err != nil {
+			return err
+		}
+		if p.limitReached() {
+			return nil
+		}
+		if md.CompactionLevel < p.minLevel || active.Contains(md.Id) {
+			continue
+		}
+		if !time.UnixMilli(md.MaxTime).Before(maxTime) || !p.needsRewrite(md) {
+			continue
+		}
+		job := &raft_log.CompactionJobPlan{
+			Tenant:          tenant,
+			Shard:           shard,
+			CompactionLevel: md.CompactionLevel,
+			SourceBlocks:    []string{md.Id},
+			Rewrite:         true,
+		}
+		p.setJobOptions(job)
+		job.Name = JobName(job)
+		p.planned = append(p.planned, job)
+		level.Debug(p.logger).Log("msg", "planned rewrite job", "job", job.Name)
+	}
+	return nil
+}
+
+func (p *AgedBlockPlanner) limitReached() bool {
+	return p.maxJobs > 0 && len(p.planned) >= p.maxJobs
+}
+
+// needsDownsampling reports whether the block has
+// datasets but none of them is downsampled.
+func needsDownsampling(md *metastorev1.BlockMeta) bool {
+	var datasets int
+	for _, ds := range md.Datasets {
+		switch block.DatasetFormat(ds.Format) {
+		case block.DatasetFormat0:
+			datasets++
+		case block.DatasetFormat2:
+			return false
+		}
+	}
+	return datasets > 0
+}
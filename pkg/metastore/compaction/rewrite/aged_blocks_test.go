@@ -0,0 +1,225 @@
+package rewrite
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/block"
+	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
+	"github.com/grafana/pyroscope/v2/pkg/test"
+	"github.com/grafana/pyroscope/v2/pkg/util"
+)
+
+// indexMock keeps the blocks in memory: the index
+// package cannot be imported here because of the cycle.
+type indexMock map[string][]*metastorev1.BlockMeta
+
+func indexMockKey(p indexstore.Partition, tenant string, shard uint32) string {
+	return fmt.Sprintf("%s/%s/%d", p.String(), tenant, shard)
+}
+
+func (m indexMock) ShardBlocks(_ *bbolt.Tx, p indexstore.Partition, tenant string, shard uint32) iter.Seq2[*metastorev1.BlockMeta, error] {
+	return func(yield func(*metastorev1.BlockMeta, error) bool) {
+		for _, md := range m[indexMockKey(p, tenant, shard)] {
+			if !yield(md, nil) {
+				return
+			}
+		}
+	}
+}
+
+func testBlock(id string, tenant string, shard, level uint32, formats ...block.DatasetFormat) *metastorev1.BlockMeta {
+	md := &metastorev1.BlockMeta{
+		Id:              test.ULID(id),
+		Shard:           shard,
+		CompactionLevel: level,
+		MinTime:         test.UnixMilli(id),
+		MaxTime:         test.Time(id).Add(time.Hour).UnixMilli(),
+		StringTable:     []string{""},
+	}
+	if tenant != "" {
+		md.Tenant = 1
+		md.StringTable = append(md.StringTable, tenant, "dataset")
+	}
+	for _, f := range formats {
+		md.Datasets = append(md.Datasets, &metastorev1.Dataset{
+			Tenant:  md.Tenant,
+			Name:    2,
+			MinTime: md.MinTime,
+			MaxTime: md.MaxTime,
+			Format:  uint32(f),
+		})
+	}
+	return md
+}
+
+func createTestIndex(t *testing.T, blocks []*metastorev1.BlockMeta) (*bbolt.DB, *indexstore.IndexStore, indexMock) {
+	db := test.BoltDB(t)
+	store := indexstore.NewIndexStore()
+	require.NoError(t, db.Update(store.CreateBuckets))
+	idx := make(indexMock)
+	const partitionDuration = 6 * time.Hour
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		shards := make(map[string]*indexstore.Shard)
+		for _, md := range blocks {
+			p := indexstore.NewPartition(time.UnixMilli(md.MinTime).Truncate(partitionDuration), partitionDuration)
+			tenant := md.StringTable[md.Tenant]
+			k := indexMockKey(p, tenant, md.Shard)
+			s, ok := shards[k]
+			if !ok {
+				s = indexstore.NewShard(p, tenant, md.Shard)
+				shards[k] = s
+			}
+			idx[k] = append(idx[k], md)
+			if err := s.Store(tx, md.CloneVT()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	return db, store, idx
+}
+
+func sortJobs(planned []*raft_log.CompactionJobPlan) {
+	slices.SortFunc(planned, func(a, b *raft_log.CompactionJobPlan) int {
+		return slices.Compare(a.SourceBlocks, b.SourceBlocks)
+	})
+}
+
+func TestDownsamplingPlanner_CreateJobs(t *testing.T) {
+	blocks := []*metastorev1.BlockMeta{
+		testBlock("2024-09-11T01:00:00.001Z", "tenant-a", 1, 3, block.DatasetFormat0),
+		testBlock("2024-09-11T01:00:00.002Z", "tenant-a", 1, 3, block.DatasetFormat0, block.DatasetFormat2),
+		testBlock("2024-09-11T01:00:00.003Z", "tenant-a", 1, 1, block.DatasetFormat0),
+		testBlock("2024-09-11T01:00:00.004Z", "tenant-a", 2, 3, block.DatasetFormat0),
+		testBlock("2024-09-11T01:00:00.005Z", "tenant-b", 1, 3, block.DatasetFormat0),
+		testBlock("2024-09-11T01:00:00.006Z", "", 1, 0, block.DatasetFormat1),
+		// Data has not reached the minimum age.
+		testBlock("2024-09-12T07:00:00.001Z", "tenant-a", 1, 3, block.DatasetFormat0),
+	}
+	db, store, idx := createTestIndex(t, blocks)
+
+	config := Config{DownsamplingMinAge: 6 * time.Hour}
+	now := test.Time("2024-09-12T12:00:00Z")
+	createJobs := func(config Config, jobs jobsMock) []*raft_log.CompactionJobPlan {
+		var planned []*raft_log.CompactionJobPlan
+		require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+			p := NewDownsamplingPlanner(util.Logger, config, idx, jobs, 3, now)
+			planned = p.CreateJobs(tx, store.Partitions(tx))
+			return nil
+		}))
+		sortJobs(planned)
+		return planned
+	}
+
+	t.Run("aged top level blocks are downsampled", func(t *testing.T) {
+		planned := createJobs(config, nil)
+		expected := []*raft_log.CompactionJobPlan{
+			{
+				Tenant:          "tenant-a",
+				Shard:           1,
+				CompactionLevel: 3,
+				SourceBlocks:    []string{blocks[0].Id},
+				Rewrite:         true,
+				Downsample:      true,
+			},
+			{
+				Tenant:          "tenant-a",
+				Shard:           2,
+				CompactionLevel: 3,
+				SourceBlocks:    []string{blocks[3].Id},
+				Rewrite:         true,
+				Downsample:      true,
+			},
+			{
+				Tenant:          "tenant-b",
+				Shard:           1,
+				CompactionLevel: 3,
+				SourceBlocks:    []string{blocks[4].Id},
+				Rewrite:         true,
+				Downsample:      true,
+			},
+		}
+		require.Len(t, planned, len(expected))
+		for i := range expected {
+			expected[i].Name = JobName(expected[i])
+			assert.Equal(t, expected[i], planned[i])
+		}
+	})
+
+	t.Run("blocks of in-flight jobs are skipped", func(t *testing.T) {
+		jobs := jobsMock{{Name: "a", SourceBlocks: []string{blocks[0].Id}}}
+		planned := createJobs(config, jobs)
+		require.Len(t, planned, 2)
+		assert.Equal(t, []string{blocks[3].Id}, planned[0].SourceBlocks)
+	})
+
+	t.Run("max jobs", func(t *testing.T) {
+		c := config
+		c.DownsamplingMaxJobs = 1
+		assert.Len(t, createJobs(c, nil), 1)
+	})
+
+	t.Run("min age", func(t *testing.T) {
+		c := config
+		c.DownsamplingMinAge = 48 * time.Hour
+		assert.Empty(t, createJobs(c, nil))
+	})
+}
+
+func TestColdStoragePlanner_CreateJobs(t *testing.T) {
+	blocks := []*metastorev1.BlockMeta{
+		testBlock("2024-09-11T01:00:00.001Z", "tenant-a", 1, 3, block.DatasetFormat0),
+		testBlock("2024-09-11T01:00:00.002Z", "tenant-a", 1, 3, block.DatasetFormat0, block.DatasetFormat2),
+		testBlock("2024-09-11T01:00:00.003Z", "tenant-a", 1, 3, block.DatasetFormat0),
+		testBlock("2024-09-11T01:00:00.004Z", "tenant-a", 1, 2, block.DatasetFormat0),
+		// Data has not reached the minimum age.
+		testBlock("2024-09-12T07:00:00.001Z", "tenant-a", 1, 3, block.DatasetFormat0),
+	}
+	blocks[2].StorageTier = metastorev1.StorageTier_STORAGE_TIER_COLD
+	db, store, idx := createTestIndex(t, blocks)
+
+	config := Config{ColdStorageMinAge: 24 * time.Hour}
+	now := test.Time("2024-09-12T12:00:00Z")
+	var planned []*raft_log.CompactionJobPlan
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		p := NewColdStoragePlanner(util.Logger, config, idx, jobsMock{}, 3, now)
+		planned = p.CreateJobs(tx, store.Partitions(tx))
+		return nil
+	}))
+	sortJobs(planned)
+
+	expected := []*raft_log.CompactionJobPlan{
+		{
+			Tenant:          "tenant-a",
+			Shard:           1,
+			CompactionLevel: 3,
+			SourceBlocks:    []string{blocks[0].Id},
+			Rewrite:         true,
+			ColdStorage:     true,
+		},
+		{
+			Tenant:          "tenant-a",
+			Shard:           1,
+			CompactionLevel: 3,
+			SourceBlocks:    []string{blocks[1].Id},
+			Rewrite:         true,
+			ColdStorage:     true,
+		},
+	}
+	require.Len(t, planned, len(expected))
+	for i := range expected {
+		expected[i].Name = JobName(expected[i])
+		assert.Equal(t, expected[i], planned[i])
+	}
+}
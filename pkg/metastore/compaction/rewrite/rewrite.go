@@ -0,0 +1,99 @@
+package rewrite
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"go.etcd.io/bbolt"
+
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	pyroiter "github.com/grafana/pyroscope/v2/pkg/iter"
+	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
+)
+
+// Blocks of the top compaction level are never compacted again. Changes
+// that have to be applied to such blocks after they have been created
+// (e.g., removal of the data that has passed the retention period) are
+// applied by rewrite jobs: the source blocks are rewritten by compaction
+// workers in place, with the shard and the compaction level preserved.
+// Series tombstones overlapping with the source blocks are attached to
+// the job at assignment, as for regular compaction jobs.
+//
+// The jobs are planned by the leader and added to the compaction schedule
+// via Raft. A block may only be a source of a single job at a time.
+
+// Planner creates jobs that rewrite blocks in place.
+type Planner interface {
+	// CreateJobs examines the provided partitions and returns
+	// plans of the jobs to be added to the compaction schedule.
+	CreateJobs(*bbolt.Tx, iter.Seq[indexstore.Partition]) []*raft_log.CompactionJobPlan
+}
+
+type JobLister interface {
+	ListJobPlans(*bbolt.Tx) pyroiter.Iterator[*raft_log.CompactionJobPlan]
+}
+
+// ActiveBlocks is the set of blocks that are
+// sources of the jobs in the compaction schedule.
+type ActiveBlocks map[string]struct{}
+
+func ListActiveBlocks(tx *bbolt.Tx, jobs JobLister) (ActiveBlocks, error) {
+	active := make(ActiveBlocks)
+	plans := jobs.ListJobPlans(tx)
+	defer func() {
+		_ = plans.Close()
+	}()
+	for plans.Next() {
+		active.Add(plans.At().SourceBlocks...)
+	}
+	return active, plans.Err()
+}
+
+func (s ActiveBlocks) Add(blocks ...string) {
+	for _, b := range blocks {
+		s[b] = struct{}{}
+	}
+}
+
+func (s ActiveBlocks) Contains(block string) bool {
+	_, ok := s[block]
+	return ok
+}
+
+func (s ActiveBlocks) ContainsAny(blocks []string) bool {
+	for _, b := range blocks {
+		if s.Contains(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// JobName follows the naming of regular compaction jobs,
+// with the rewrite marker appended. Downsampling and cold
+// storage jobs are marked additionally.
+func JobName(job *raft_log.CompactionJobPlan) string {
+	buf := make([]byte, 0, 512)
+	for _, b := range job.SourceBlocks {
+		buf = append(buf, b...)
+	}
+	var name strings.Builder
+	name.WriteString(fmt.Sprintf("%x", xxhash.Sum64(buf)))
+	name.WriteString("-T")
+	name.WriteString(job.Tenant)
+	name.WriteString("-S")
+	name.WriteString(strconv.FormatUint(uint64(job.Shard), 10))
+	name.WriteString("-L")
+	name.WriteString(strconv.FormatUint(uint64(job.CompactionLevel), 10))
+	name.WriteString("-W")
+	if job.Downsample {
+		name.WriteString("D")
+	}
+	if job.ColdStorage {
+		name.WriteString("C")
+	}
+	return name.String()
+}
@@ -0,0 +1,60 @@
+package rewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+)
+
+type jobsMock []*raft_log.CompactionJobPlan
+
+func (m jobsMock) ListJobPlans(*bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan] {
+	return iter.NewSliceIterator(m)
+}
+
+func TestListActiveBlocks(t *testing.T) {
+	jobs := jobsMock{
+		{Name: "a", SourceBlocks: []string{"1", "2"}},
+		{Name: "b", SourceBlocks: []string{"3"}, TargetShard: 2},
+		{Name: "c", SourceBlocks: []string{"4"}, Rewrite: true},
+	}
+
+	active, err := ListActiveBlocks(nil, jobs)
+	require.NoError(t, err)
+	for _, b := range []string{"1", "2", "3", "4"} {
+		assert.True(t, active.Contains(b), b)
+	}
+	assert.False(t, active.Contains("5"))
+	assert.False(t, active.ContainsAny([]string{"5", "6"}))
+	assert.True(t, active.ContainsAny([]string{"5", "4"}))
+
+	active.Add("5")
+	assert.True(t, active.Contains("5"))
+}
+
+func TestJobName(t *testing.T) {
+	job := &raft_log.CompactionJobPlan{
+		Tenant:          "tenant-a",
+		Shard:           1,
+		CompactionLevel: 3,
+		SourceBlocks:    []string{"1", "2"},
+		Rewrite:         true,
+	}
+	name := JobName(job)
+	assert.Regexp(t, `^[0-9a-f]+-Ttenant-a-S1-L3-W$`, name)
+
+	job.SourceBlocks = []string{"1", "3"}
+	assert.NotEqual(t, name, JobName(job))
+
+	job.Downsample = true
+	assert.Regexp(t, `^[0-9a-f]+-Ttenant-a-S1-L3-WD$`, JobName(job))
+
+	job.Downsample = false
+	job.ColdStorage = true
+	assert.Regexp(t, `^[0-9a-f]+-Ttenant-a-S1-L3-WC$`, JobName(job))
+}
@@ -0,0 +1,153 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/pyroscope/v2/pkg/metastore/raftnode"
+)
+
+type Config struct {
+	DownsamplingInterval time.Duration `yaml:"compaction_downsampling_interval" category:"experimental"`
+	DownsamplingMinAge   time.Duration `yaml:"compaction_downsampling_min_age" category:"experimental"`
+	DownsamplingMaxJobs  int           `yaml:"compaction_downsampling_max_jobs" category:"experimental"`
+
+	ColdStorageInterval time.Duration `yaml:"compaction_cold_storage_interval" category:"experimental"`
+	ColdStorageMinAge   time.Duration `yaml:"compaction_cold_storage_min_age" category:"experimental"`
+	ColdStorageMaxJobs  int           `yaml:"compaction_cold_storage_max_jobs" category:"experimental"`
+}
+
+func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&c.DownsamplingInterval, prefix+"compaction-downsampling-interval", 0, "Interval at which the leader plans jobs that add downsampled datasets to the blocks of the top compaction level, which are not compacted again. Compaction workers must have downsampling enabled. 0 to disable.")
+	f.DurationVar(&c.DownsamplingMinAge, prefix+"compaction-downsampling-min-age", 6*time.Hour, "Minimum age of the data of the blocks to be downsampled. Should match compaction-worker.downsampling-min-age.")
+	f.IntVar(&c.DownsamplingMaxJobs, prefix+"compaction-downsampling-max-jobs", 16, "Maximum number of downsampling jobs planned at once.")
+	f.DurationVar(&c.ColdStorageInterval, prefix+"compaction-cold-storage-interval", 0, "Interval at which the leader plans jobs that move the blocks of the top compaction level, which are not compacted again, to the cold storage tier. Compaction workers must have the cold storage configured. 0 to disable.")
+	f.DurationVar(&c.ColdStorageMinAge, prefix+"compaction-cold-storage-min-age", 24*time.Hour, "Minimum age of the data of the blocks to be moved to the cold storage tier. Should match compaction-worker.cold-storage-min-age.")
+	f.IntVar(&c.ColdStorageMaxJobs, prefix+"compaction-cold-storage-max-jobs", 16, "Maximum number of cold storage jobs planned at once.")
+}
+
+type Index interface {
+	RewriteBlocks(context.Context, Planner) error
+}
+
+// Rewriter periodically plans jobs that rewrite the blocks
+// of the top compaction level. It runs on the leader.
+type Rewriter struct {
+	logger     log.Logger
+	interval   time.Duration
+	index      Index
+	newPlanner func(now time.Time) Planner
+
+	started bool
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+}
+
+// NewDownsampler returns a rewriter that adds downsampled
+// datasets to the aged blocks of the top compaction level.
+func NewDownsampler(
+	logger log.Logger,
+	config Config,
+	index Index,
+	reader IndexReader,
+	jobs JobLister,
+	minLevel uint32,
+) *Rewriter {
+	logger = log.With(logger, "component", "downsampler")
+	return &Rewriter{
+		logger:   logger,
+		interval: config.DownsamplingInterval,
+		index:    index,
+		newPlanner: func(now time.Time) Planner {
+			return NewDownsamplingPlanner(logger, config, reader, jobs, minLevel, now)
+		},
+	}
+}
+
+// NewColdStorageMover returns a rewriter that moves the aged blocks
+// of the top compaction level to the cold storage tier.
+func NewColdStorageMover(
+	logger log.Logger,
+	config Config,
+	index Index,
+	reader IndexReader,
+	jobs JobLister,
+	minLevel uint32,
+) *Rewriter {
+	logger = log.With(logger, "component", "cold-storage-mover")
+	return &Rewriter{
+		logger:   logger,
+		interval: config.ColdStorageInterval,
+		index:    index,
+		newPlanner: func(now time.Time) Planner {
+			return NewColdStoragePlanner(logger, config, reader, jobs, minLevel, now)
+		},
+	}
+}
+
+func (r *Rewriter) Start() {
+	if r.interval == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		r.logger.Log("msg", "rewriter already started")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.started = true
+	go r.loop(ctx)
+	r.logger.Log("msg", "rewriter started")
+}
+
+func (r *Rewriter) Stop() {
+	if r.interval == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.started {
+		r.logger.Log("msg", "rewriter already stopped")
+		return
+	}
+	r.cancel()
+	r.started = false
+	r.logger.Log("msg", "rewriter stopped")
+}
+
+func (r *Rewriter) loop(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.rewrite(ctx) {
+				return
+			}
+		}
+	}
+}
+
+// rewrite returns false if the context has been canceled.
+func (r *Rewriter) rewrite(ctx context.Context) bool {
+	switch err := r.index.RewriteBlocks(ctx, r.newPlanner(time.Now())); {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		return false
+	case raftnode.IsRaftLeadershipError(err):
+		level.Warn(r.logger).Log("msg", "leadership change; rewrite interrupted", "err", err)
+	default:
+		level.Error(r.logger).Log("msg", "failed to plan rewrite jobs", "err", err)
+	}
+	return true
+}
@@ -34,6 +34,7 @@ type JobStore interface {
 	StoreJobPlan(*bbolt.Tx, *raft_log.CompactionJobPlan) error
 	GetJobPlan(tx *bbolt.Tx, name string) (*raft_log.CompactionJobPlan, error)
 	DeleteJobPlan(tx *bbolt.Tx, name string) error
+	ListJobPlans(*bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan]
 
 	StoreJobState(*bbolt.Tx, *raft_log.CompactionJobState) error
 	DeleteJobState(tx *bbolt.Tx, name string) error
@@ -144,6 +145,10 @@ func (sc *Scheduler) UpdateSchedule(tx *bbolt.Tx, update *raft_log.CompactionPla
 	return nil
 }
 
+func (sc *Scheduler) ListJobPlans(tx *bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan] {
+	return sc.store.ListJobPlans(tx)
+}
+
 func (sc *Scheduler) Init(tx *bbolt.Tx) error {
 	return sc.store.CreateBuckets(tx)
 }
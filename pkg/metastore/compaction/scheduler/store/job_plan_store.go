@@ -7,6 +7,7 @@ import (
 	"go.etcd.io/bbolt"
 
 	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/store"
 )
 
@@ -45,3 +46,43 @@ func (s JobPlanStore) GetJobPlan(tx *bbolt.Tx, name string) (*raft_log.Compactio
 func (s JobPlanStore) DeleteJobPlan(tx *bbolt.Tx, name string) error {
 	return tx.Bucket(s.bucketName).Delete([]byte(name))
 }
+
+func (s JobPlanStore) ListJobPlans(tx *bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan] {
+	return newJobPlanIterator(tx.Bucket(s.bucketName))
+}
+
+type jobPlanIterator struct {
+	iter *store.CursorIterator
+	cur  *raft_log.CompactionJobPlan
+	err  error
+}
+
+func newJobPlanIterator(bucket *bbolt.Bucket) *jobPlanIterator {
+	return &jobPlanIterator{iter: store.NewCursorIter(bucket.Cursor())}
+}
+
+func (x *jobPlanIterator) Next() bool {
+	if x.err != nil || !x.iter.Next() {
+		return false
+	}
+	e := x.iter.At()
+	var v raft_log.CompactionJobPlan
+	x.err = v.UnmarshalVT(e.Value)
+	if x.err != nil {
+		x.err = fmt.Errorf("%w: %v", ErrInvalidJobPlan, x.err)
+		return false
+	}
+	x.cur = &v
+	return true
+}
+
+func (x *jobPlanIterator) At() *raft_log.CompactionJobPlan { return x.cur }
+
+func (x *jobPlanIterator) Close() error { return x.iter.Close() }
+
+func (x *jobPlanIterator) Err() error {
+	if err := x.iter.Err(); err != nil {
+		return err
+	}
+	return x.err
+}
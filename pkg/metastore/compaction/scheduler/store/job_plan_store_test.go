@@ -19,6 +19,7 @@ func TestJobPlanStore(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, s.CreateBuckets(tx))
 	assert.NoError(t, s.StoreJobPlan(tx, &raft_log.CompactionJobPlan{Name: "1"}))
+	assert.NoError(t, s.StoreJobPlan(tx, &raft_log.CompactionJobPlan{Name: "3", TargetShard: 2}))
 	require.NoError(t, tx.Commit())
 
 	s = NewJobStore()
@@ -30,6 +31,14 @@ func TestJobPlanStore(t *testing.T) {
 	state, err = s.GetJobPlan(tx, "1")
 	require.NoError(t, err)
 	assert.Equal(t, "1", state.Name)
+	plans := s.ListJobPlans(tx)
+	var names []string
+	for plans.Next() {
+		names = append(names, plans.At().Name)
+	}
+	require.NoError(t, plans.Err())
+	require.NoError(t, plans.Close())
+	assert.Equal(t, []string{"1", "3"}, names)
 	require.NoError(t, tx.Rollback())
 
 	tx, err = db.Begin(true)
@@ -11,6 +11,9 @@ import (
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/reshard"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/rewrite"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner/retention"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/tracing"
 )
 
@@ -18,30 +21,50 @@ type IndexReplacer interface {
 	ReplaceBlocks(*bbolt.Tx, *metastorev1.CompactedBlocks) error
 }
 
+type CompactionIndex interface {
+	IndexReplacer
+	IndexBlockFinder
+}
+
+type SeriesTombstoneReader interface {
+	ListSeriesTombstones(tx *bbolt.Tx, tenant string, start, end int64) ([]*metastorev1.SeriesTombstone, error)
+}
+
+type SeriesTombstoneReplacer interface {
+	SeriesTombstoneReader
+	ReplaceBlocks(tx *bbolt.Tx, token uint64, compacted *metastorev1.CompactedBlocks) error
+}
+
 type CompactionCommandHandler struct {
-	logger     log.Logger
-	index      IndexReplacer
-	compactor  compaction.Compactor
-	planner    compaction.Planner
-	scheduler  compaction.Scheduler
-	tombstones Tombstones
+	logger           log.Logger
+	index            CompactionIndex
+	compactor        compaction.Compactor
+	planner          compaction.Planner
+	scheduler        compaction.Scheduler
+	tombstones       Tombstones
+	seriesTombstones SeriesTombstoneReplacer
+	overrides        retention.Overrides
 }
 
 func NewCompactionCommandHandler(
 	logger log.Logger,
-	index IndexReplacer,
+	index CompactionIndex,
 	compactor compaction.Compactor,
 	planner compaction.Planner,
 	scheduler compaction.Scheduler,
 	tombstones Tombstones,
+	seriesTombstones SeriesTombstoneReplacer,
+	overrides retention.Overrides,
 ) *CompactionCommandHandler {
 	return &CompactionCommandHandler{
-		logger:     logger,
-		index:      index,
-		compactor:  compactor,
-		planner:    planner,
-		scheduler:  scheduler,
-		tombstones: tombstones,
+		logger:           logger,
+		index:            index,
+		compactor:        compactor,
+		planner:          planner,
+		scheduler:        scheduler,
+		tombstones:       tombstones,
+		seriesTombstones: seriesTombstones,
+		overrides:        overrides,
 	}
 }
 
@@ -105,6 +128,7 @@ func (h *CompactionCommandHandler) GetCompactionPlanUpdate(
 	// the high-priority job influx. As of now, we assign jobs before creating
 	// ones. If we change it, we need to make sure that the Schedule
 	// implementation allows doing this.
+	var retentionTombstones *retention.DatasetRetentionTombstones
 	for assigned := 0; assigned < capacity; assigned++ {
 		job, err := schedule.AssignJob()
 		if err != nil {
@@ -112,6 +136,22 @@ func (h *CompactionCommandHandler) GetCompactionPlanUpdate(
 			return nil, err
 		}
 		if job != nil {
+			// The rows of the deleted series are dropped by the worker
+			// when the blocks are rewritten. The job plan is only sent
+			// to the worker and is not persisted with the tombstones.
+			job.Plan.SeriesTombstones, err = h.listSeriesTombstones(tx, job.Plan)
+			if err != nil {
+				level.Error(h.logger).Log("msg", "failed to list series tombstones", "err", err)
+				return nil, err
+			}
+			// The data that has passed the dataset retention period is
+			// dropped the same way. The command timestamp is used to
+			// make sure the plan is identical on all the replicas.
+			if retentionTombstones == nil {
+				retentionTombstones = retention.NewDatasetRetentionTombstones(h.overrides, cmd.AppendedAt)
+			}
+			job.Plan.SeriesTombstones = append(job.Plan.SeriesTombstones,
+				retentionTombstones.SeriesTombstones(job.Plan.Tenant)...)
 			p.AssignedJobs = append(p.AssignedJobs, job)
 		}
 	}
@@ -215,6 +255,12 @@ func (h *CompactionCommandHandler) UpdateCompactionPlan(
 			level.Error(h.logger).Log("msg", "failed to replace blocks", "err", err)
 			return nil, err
 		}
+		// The job token is the raft log index the job was assigned at:
+		// the series tombstones created before are applied to the blocks.
+		if err = h.seriesTombstones.ReplaceBlocks(tx, job.State.Token, compacted); err != nil {
+			level.Error(h.logger).Log("msg", "failed to update series tombstones", "err", err)
+			return nil, err
+		}
 	}
 
 	span.SetTag("new_jobs", len(req.PlanUpdate.NewJobs))
@@ -223,6 +269,156 @@ func (h *CompactionCommandHandler) UpdateCompactionPlan(
 	return &raft_log.UpdateCompactionPlanResponse{PlanUpdate: req.PlanUpdate}, nil
 }
 
+// AddReshardJobs adds jobs that move blocks between shards to the
+// compaction schedule. Jobs for tenant shards that already have reshard
+// jobs in the schedule are ignored: the blocks may be already moved. Jobs
+// whose source blocks are sources of other jobs are ignored as well.
+func (h *CompactionCommandHandler) AddReshardJobs(
+	ctx context.Context, tx *bbolt.Tx, cmd *raft.Log, req *raft_log.AddReshardJobsRequest,
+) (resp *raft_log.AddReshardJobsResponse, err error) {
+	span, _ := tracing.StartSpanFromContext(ctx, "raft.AddReshardJobs")
+	span.SetTag("jobs", len(req.Jobs))
+	span.SetTag("raft_log_index", cmd.Index)
+	span.SetTag("raft_log_term", cmd.Term)
+	span.SetTag("request_term", req.Term)
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	if req.Term != cmd.Term {
+		level.Warn(h.logger).Log(
+			"msg", "rejecting reshard jobs; term mismatch: leader has changed",
+			"current_term", cmd.Term,
+			"request_term", req.Term,
+		)
+		return new(raft_log.AddReshardJobsResponse), nil
+	}
+
+	active, err := reshard.ListActiveShards(tx, h.scheduler)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "failed to list compaction jobs", "err", err)
+		return nil, err
+	}
+	activeBlocks, err := rewrite.ListActiveBlocks(tx, h.scheduler)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "failed to list compaction jobs", "err", err)
+		return nil, err
+	}
+
+	schedule := h.scheduler.NewSchedule(tx, cmd)
+	p := new(raft_log.CompactionPlanUpdate)
+	for _, plan := range req.Jobs {
+		if plan.TargetShard == 0 || plan.TargetShard == plan.Shard || plan.Rewrite ||
+			active.Contains(plan.Tenant, plan.Shard) || activeBlocks.ContainsAny(plan.SourceBlocks) {
+			level.Warn(h.logger).Log("msg", "reshard job rejected", "job", plan.Name)
+			continue
+		}
+		state := schedule.AddJob(plan)
+		if state == nil {
+			level.Warn(h.logger).Log("msg", "reshard job rejected by scheduler", "job", plan.Name)
+			break
+		}
+		activeBlocks.Add(plan.SourceBlocks...)
+		p.NewJobs = append(p.NewJobs, &raft_log.NewCompactionJob{
+			State: state,
+			Plan:  plan,
+		})
+	}
+
+	if err = h.scheduler.UpdateSchedule(tx, p); err != nil {
+		level.Error(h.logger).Log("msg", "failed to update compaction schedule", "err", err)
+		return nil, err
+	}
+
+	span.SetTag("new_jobs", len(p.NewJobs))
+	return new(raft_log.AddReshardJobsResponse), nil
+}
+
+// AddRewriteJobs adds jobs that rewrite blocks in place to the compaction
+// schedule. Jobs whose source blocks are sources of other jobs in the
+// schedule are ignored: the blocks may be already replaced.
+func (h *CompactionCommandHandler) AddRewriteJobs(
+	ctx context.Context, tx *bbolt.Tx, cmd *raft.Log, req *raft_log.AddRewriteJobsRequest,
+) (resp *raft_log.AddRewriteJobsResponse, err error) {
+	span, _ := tracing.StartSpanFromContext(ctx, "raft.AddRewriteJobs")
+	span.SetTag("jobs", len(req.Jobs))
+	span.SetTag("raft_log_index", cmd.Index)
+	span.SetTag("raft_log_term", cmd.Term)
+	span.SetTag("request_term", req.Term)
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	if req.Term != cmd.Term {
+		level.Warn(h.logger).Log(
+			"msg", "rejecting rewrite jobs; term mismatch: leader has changed",
+			"current_term", cmd.Term,
+			"request_term", req.Term,
+		)
+		return new(raft_log.AddRewriteJobsResponse), nil
+	}
+
+	active, err := rewrite.ListActiveBlocks(tx, h.scheduler)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "failed to list compaction jobs", "err", err)
+		return nil, err
+	}
+
+	schedule := h.scheduler.NewSchedule(tx, cmd)
+	p := new(raft_log.CompactionPlanUpdate)
+	for _, plan := range req.Jobs {
+		if !plan.Rewrite || plan.TargetShard > 0 || len(plan.SourceBlocks) == 0 || active.ContainsAny(plan.SourceBlocks) {
+			level.Warn(h.logger).Log("msg", "rewrite job rejected", "job", plan.Name)
+			continue
+		}
+		state := schedule.AddJob(plan)
+		if state == nil {
+			level.Warn(h.logger).Log("msg", "rewrite job rejected by scheduler", "job", plan.Name)
+			break
+		}
+		active.Add(plan.SourceBlocks...)
+		p.NewJobs = append(p.NewJobs, &raft_log.NewCompactionJob{
+			State: state,
+			Plan:  plan,
+		})
+	}
+
+	if err = h.scheduler.UpdateSchedule(tx, p); err != nil {
+		level.Error(h.logger).Log("msg", "failed to update compaction schedule", "err", err)
+		return nil, err
+	}
+
+	span.SetTag("new_jobs", len(p.NewJobs))
+	return new(raft_log.AddRewriteJobsResponse), nil
+}
+
+// listSeriesTombstones returns the series tombstones
+// overlapping with the time range of the job source blocks.
+func (h *CompactionCommandHandler) listSeriesTombstones(tx *bbolt.Tx, plan *raft_log.CompactionJobPlan) ([]*metastorev1.SeriesTombstone, error) {
+	blocks, err := h.index.GetBlocks(tx, &metastorev1.BlockList{
+		Tenant: plan.Tenant,
+		Shard:  plan.Shard,
+		Blocks: plan.SourceBlocks,
+	})
+	if err != nil || len(blocks) == 0 {
+		return nil, err
+	}
+	start, end := blocks[0].MinTime, blocks[0].MaxTime
+	for _, b := range blocks[1:] {
+		start = min(start, b.MinTime)
+		end = max(end, b.MaxTime)
+	}
+	return h.seriesTombstones.ListSeriesTombstones(tx, plan.Tenant, start, end)
+}
+
 func blockTombstonesForCompletedJob(job *raft_log.CompletedCompactionJob) *metastorev1.Tombstones {
 	source := job.CompactedBlocks.SourceBlocks
 	return &metastorev1.Tombstones{
@@ -123,12 +123,17 @@ func (svc *CompactionService) PollCompactionJobs(
 		})
 		job := assigned.Plan
 		workerResp.CompactionJobs = append(workerResp.CompactionJobs, &metastorev1.CompactionJob{
-			Name:            job.Name,
-			Shard:           job.Shard,
-			Tenant:          job.Tenant,
-			CompactionLevel: job.CompactionLevel,
-			SourceBlocks:    job.SourceBlocks,
-			Tombstones:      job.Tombstones,
+			Name:             job.Name,
+			Shard:            job.Shard,
+			Tenant:           job.Tenant,
+			CompactionLevel:  job.CompactionLevel,
+			SourceBlocks:     job.SourceBlocks,
+			Tombstones:       job.Tombstones,
+			SeriesTombstones: job.SeriesTombstones,
+			TargetShard:      job.TargetShard,
+			Rewrite:          job.Rewrite,
+			Downsample:       job.Downsample,
+			ColdStorage:      job.ColdStorage,
 		})
 		// Assigned jobs are not written to the raft log (only the assignments):
 		// from our perspective (scheduler and planner) these are just job updates.
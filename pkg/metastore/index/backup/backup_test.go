@@ -0,0 +1,395 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/memory"
+	"github.com/grafana/pyroscope/v2/pkg/test"
+	"github.com/grafana/pyroscope/v2/pkg/test/mocks/mockdlq"
+)
+
+func testBlocks() []*metastorev1.BlockMeta {
+	return []*metastorev1.BlockMeta{
+		{
+			Id:          test.ULID("2024-09-23T01:00:00Z"),
+			Shard:       1,
+			MinTime:     1,
+			MaxTime:     2,
+			StringTable: []string{""},
+		},
+		{
+			Id:              test.ULID("2024-09-23T02:00:00Z"),
+			Tenant:          1,
+			Shard:           2,
+			CompactionLevel: 1,
+			MinTime:         1,
+			MaxTime:         2,
+			Datasets:        []*metastorev1.Dataset{{Tenant: 1, Name: 2}},
+			StringTable:     []string{"", "tenant-a", "service"},
+		},
+	}
+}
+
+type indexMock struct {
+	blocks     []*metastorev1.BlockMeta
+	tombstones []*metastorev1.Tombstones
+}
+
+func (m indexMock) ExportIndex(_ context.Context, fn func(*metastorev1.BlockMeta) error, tombstones func(*metastorev1.Tombstones) error) error {
+	for _, md := range m.blocks {
+		if err := fn(md); err != nil {
+			return err
+		}
+	}
+	for _, t := range m.tombstones {
+		if err := tombstones(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testTombstones() []*metastorev1.Tombstones {
+	return []*metastorev1.Tombstones{
+		{Blocks: &metastorev1.BlockTombstones{Name: "a", Shard: 1, Blocks: []string{test.ULID("2024-09-23T01:00:00Z")}}},
+		{Shard: &metastorev1.ShardTombstone{Name: "b", Shard: 2, Tenant: "tenant-a", Duration: int64(time.Hour)}},
+	}
+}
+
+func TestExport_ReadWrite(t *testing.T) {
+	blocks := testBlocks()
+	tombstones := testTombstones()
+	var buf bytes.Buffer
+	w := NewExportWriter(&buf)
+	for _, md := range blocks {
+		require.NoError(t, w.Write(md))
+	}
+	for _, x := range tombstones {
+		require.NoError(t, w.WriteTombstones(x))
+	}
+	require.Error(t, w.Write(blocks[0]))
+	require.NoError(t, w.Close())
+	exported := buf.Bytes()
+
+	var actual []*metastorev1.BlockMeta
+	var actualTombstones []*metastorev1.Tombstones
+	require.NoError(t, ReadExport(bytes.NewReader(exported), func(md *metastorev1.BlockMeta) error {
+		actual = append(actual, md)
+		return nil
+	}, func(x *metastorev1.Tombstones) error {
+		actualTombstones = append(actualTombstones, x)
+		return nil
+	}))
+	require.Len(t, actual, len(blocks))
+	for i := range blocks {
+		assert.True(t, blocks[i].EqualVT(actual[i]))
+	}
+	require.Len(t, actualTombstones, len(tombstones))
+	for i := range tombstones {
+		assert.True(t, tombstones[i].EqualVT(actualTombstones[i]))
+	}
+
+	// The terminating entry is missing.
+	err := ReadExport(bytes.NewReader(exported[:len(exported)-2]), func(*metastorev1.BlockMeta) error { return nil }, nil)
+	require.ErrorIs(t, err, ErrIncompleteExport)
+}
+
+func TestExport_WithoutTombstones(t *testing.T) {
+	blocks := testBlocks()
+	var buf bytes.Buffer
+	w := NewExportWriter(&buf)
+	for _, md := range blocks {
+		require.NoError(t, w.Write(md))
+	}
+	require.NoError(t, w.Close())
+
+	read := func(exported []byte) (n int, err error) {
+		err = ReadExport(bytes.NewReader(exported), func(*metastorev1.BlockMeta) error {
+			n++
+			return nil
+		}, func(*metastorev1.Tombstones) error {
+			return errors.New("unexpected tombstones")
+		})
+		return n, err
+	}
+	n, err := read(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, len(blocks), n)
+
+	// Exports created before tombstones were included
+	// have a single terminating entry.
+	n, err = read(buf.Bytes()[:buf.Len()-1])
+	require.NoError(t, err)
+	assert.Equal(t, len(blocks), n)
+}
+
+func TestExporter_Retention(t *testing.T) {
+	bucket := memory.NewInMemBucket()
+	e := NewExporter(test.NewTestingLogger(t), Config{ExportRetention: 2}, indexMock{blocks: testBlocks()}, bucket, prometheus.NewRegistry())
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, e.Export(ctx))
+	}
+
+	exports, err := ListExports(ctx, bucket)
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+	assert.Equal(t, 3.0, testutil.ToFloat64(e.metrics.exports.WithLabelValues("success")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(e.metrics.exportBlocks))
+
+	rc, err := bucket.Get(ctx, exports[1])
+	require.NoError(t, err)
+	defer rc.Close()
+	var n int
+	require.NoError(t, ReadExport(rc, func(*metastorev1.BlockMeta) error {
+		n++
+		return nil
+	}, nil))
+	assert.Equal(t, 2, n)
+}
+
+func uploadBlock(t *testing.T, bucket *memory.InMemBucket, md *metastorev1.BlockMeta) {
+	var buf bytes.Buffer
+	buf.WriteString("block data")
+	md = md.CloneVT()
+	md.MetadataOffset = uint64(buf.Len())
+	require.NoError(t, metadata.Encode(&buf, md))
+	require.NoError(t, bucket.Upload(context.Background(), block.ObjectPath(md), &buf))
+}
+
+func TestRebuilder_Rebuild(t *testing.T) {
+	blocks := testBlocks()
+	ctx := context.Background()
+
+	t.Run("from objects", func(t *testing.T) {
+		bucket := memory.NewInMemBucket()
+		for _, md := range blocks {
+			uploadBlock(t, bucket, md)
+		}
+		require.NoError(t, bucket.Upload(ctx, "blocks/1/tenant-a/unexpected.bin", bytes.NewReader(nil)))
+
+		var mu sync.Mutex
+		added := make(map[string]*metastorev1.BlockMeta)
+		srv := mockdlq.NewMockMetastore(t)
+		srv.On("AddRecoveredBlock", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				md := args.Get(1).(*metastorev1.AddBlockRequest).Block
+				mu.Lock()
+				added[md.Id] = md
+				mu.Unlock()
+			}).
+			Return(new(metastorev1.AddBlockResponse), nil)
+
+		r := NewRebuilder(test.NewTestingLogger(t), Config{}, srv, bucket, nil, prometheus.NewRegistry())
+		stats, err := r.Rebuild(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RebuildStats{Objects: 2, Added: 2}, stats)
+		for _, md := range blocks {
+			require.Contains(t, added, md.Id)
+			assert.Equal(t, metadata.Tenant(md), metadata.Tenant(added[md.Id]))
+			assert.Equal(t, md.Shard, added[md.Id].Shard)
+			assert.NotZero(t, added[md.Id].Size)
+		}
+	})
+
+	t.Run("from export", func(t *testing.T) {
+		bucket := memory.NewInMemBucket()
+		for _, md := range blocks {
+			uploadBlock(t, bucket, md)
+		}
+		// The export includes a block that does not exist anymore,
+		// and the metadata that differs from the one stored in the
+		// object, to ensure the export is preferred.
+		var exported indexMock
+		for _, md := range blocks {
+			md = md.CloneVT()
+			md.Size = 42
+			exported.blocks = append(exported.blocks, md)
+		}
+		exported.blocks = append(exported.blocks, &metastorev1.BlockMeta{
+			Id:          test.ULID("2024-09-23T03:00:00Z"),
+			Shard:       3,
+			StringTable: []string{""},
+		})
+		e := NewExporter(test.NewTestingLogger(t), Config{}, exported, bucket, prometheus.NewRegistry())
+		require.NoError(t, e.Export(ctx))
+
+		var mu sync.Mutex
+		var added []*metastorev1.BlockMeta
+		srv := mockdlq.NewMockMetastore(t)
+		srv.On("AddRecoveredBlock", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				mu.Lock()
+				added = append(added, args.Get(1).(*metastorev1.AddBlockRequest).Block)
+				mu.Unlock()
+			}).
+			Return(new(metastorev1.AddBlockResponse), nil)
+
+		r := NewRebuilder(test.NewTestingLogger(t), Config{}, srv, bucket, nil, prometheus.NewRegistry())
+		stats, err := r.Rebuild(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RebuildStats{Objects: 2, Exported: 3, Added: 2}, stats)
+		require.Len(t, added, 2)
+		for _, md := range added {
+			assert.Equal(t, uint64(42), md.Size)
+		}
+	})
+
+	t.Run("deleted blocks", func(t *testing.T) {
+		bucket := memory.NewInMemBucket()
+		for _, md := range blocks {
+			uploadBlock(t, bucket, md)
+		}
+		// A block of the truncated shard created after the tombstone.
+		live := &metastorev1.BlockMeta{
+			Id:              test.ULID("2024-09-23T04:00:00Z"),
+			Tenant:          1,
+			Shard:           2,
+			CompactionLevel: 1,
+			StringTable:     []string{"", "tenant-a"},
+		}
+		uploadBlock(t, bucket, live)
+		// The first block is a compacted segment, and the second one
+		// belongs to the shard truncated by the retention policy.
+		tombstones := []*metastorev1.Tombstones{
+			{Blocks: &metastorev1.BlockTombstones{Name: "a", Shard: 1, Blocks: []string{blocks[0].Id}}},
+			{Shard: &metastorev1.ShardTombstone{
+				Name:      "b",
+				Shard:     2,
+				Tenant:    "tenant-a",
+				Timestamp: test.Time("2024-09-23T02:00:00Z").UnixNano(),
+				Duration:  int64(time.Hour),
+			}},
+		}
+		e := NewExporter(test.NewTestingLogger(t), Config{}, indexMock{tombstones: tombstones}, bucket, prometheus.NewRegistry())
+		require.NoError(t, e.Export(ctx))
+
+		srv := mockdlq.NewMockMetastore(t)
+		srv.On("AddRecoveredBlock", mock.Anything, mock.MatchedBy(func(req *metastorev1.AddBlockRequest) bool {
+			return req.Block.Id == live.Id
+		})).Return(new(metastorev1.AddBlockResponse), nil).Once()
+
+		r := NewRebuilder(test.NewTestingLogger(t), Config{}, srv, bucket, nil, prometheus.NewRegistry())
+		stats, err := r.Rebuild(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RebuildStats{Objects: 3, Added: 1, Deleted: 2}, stats)
+	})
+
+	t.Run("cold storage tier", func(t *testing.T) {
+		bucket := memory.NewInMemBucket()
+		cold := memory.NewInMemBucket()
+		uploadBlock(t, bucket, blocks[0])
+		uploadBlock(t, cold, blocks[1])
+		// The storage tier of the exported metadata does not match
+		// the bucket: the metadata is read from the object instead.
+		e := NewExporter(test.NewTestingLogger(t), Config{}, indexMock{blocks: blocks}, bucket, prometheus.NewRegistry())
+		require.NoError(t, e.Export(ctx))
+
+		var mu sync.Mutex
+		added := make(map[string]*metastorev1.BlockMeta)
+		srv := mockdlq.NewMockMetastore(t)
+		srv.On("AddRecoveredBlock", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				md := args.Get(1).(*metastorev1.AddBlockRequest).Block
+				mu.Lock()
+				added[md.Id] = md
+				mu.Unlock()
+			}).
+			Return(new(metastorev1.AddBlockResponse), nil)
+
+		r := NewRebuilder(test.NewTestingLogger(t), Config{}, srv, bucket, cold, prometheus.NewRegistry())
+		stats, err := r.Rebuild(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RebuildStats{Objects: 2, Exported: 2, Added: 2}, stats)
+		require.Len(t, added, 2)
+		assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_HOT, added[blocks[0].Id].StorageTier)
+		assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, added[blocks[1].Id].StorageTier)
+	})
+
+	t.Run("sources of compacted blocks", func(t *testing.T) {
+		bucket := memory.NewInMemBucket()
+		segment := func(id string, minTime, maxTime int64) *metastorev1.BlockMeta {
+			return &metastorev1.BlockMeta{
+				Id:          id,
+				Shard:       1,
+				MinTime:     minTime,
+				MaxTime:     maxTime,
+				Datasets:    []*metastorev1.Dataset{{Tenant: 1, Name: 2, MinTime: minTime, MaxTime: maxTime}},
+				StringTable: []string{"", "tenant-a", "service"},
+			}
+		}
+		// The source segment is present in the export: the compaction
+		// job has been completed after the export was created.
+		source := segment(test.ULID("2024-09-23T01:00:00Z"), 10, 20)
+		e := NewExporter(test.NewTestingLogger(t), Config{}, indexMock{blocks: []*metastorev1.BlockMeta{source}}, bucket, prometheus.NewRegistry())
+		require.NoError(t, e.Export(ctx))
+
+		compacted := &metastorev1.BlockMeta{
+			Id:              test.ULID("2024-09-23T01:00:00Z"),
+			Tenant:          1,
+			Shard:           1,
+			CompactionLevel: 1,
+			MinTime:         10,
+			MaxTime:         20,
+			Datasets:        []*metastorev1.Dataset{{Tenant: 1, Name: 2, MinTime: 10, MaxTime: 20}},
+			StringTable:     []string{"", "tenant-a", "service"},
+		}
+		// The segment created after the compacted block sources.
+		newer := segment(test.ULID("2024-09-23T02:00:00Z"), 30, 40)
+		for _, md := range []*metastorev1.BlockMeta{source, compacted, newer} {
+			uploadBlock(t, bucket, md)
+		}
+
+		var mu sync.Mutex
+		var added []string
+		srv := mockdlq.NewMockMetastore(t)
+		srv.On("AddRecoveredBlock", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				mu.Lock()
+				added = append(added, args.Get(1).(*metastorev1.AddBlockRequest).Block.Id)
+				mu.Unlock()
+			}).
+			Return(new(metastorev1.AddBlockResponse), nil)
+
+		r := NewRebuilder(test.NewTestingLogger(t), Config{}, srv, bucket, nil, prometheus.NewRegistry())
+		stats, err := r.Rebuild(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RebuildStats{Objects: 3, Exported: 1, Added: 2, Compacted: 1}, stats)
+		assert.ElementsMatch(t, []string{compacted.Id, newer.Id}, added)
+	})
+
+	t.Run("rejected and failed blocks", func(t *testing.T) {
+		bucket := memory.NewInMemBucket()
+		uploadBlock(t, bucket, blocks[0])
+		srv := mockdlq.NewMockMetastore(t)
+		srv.On("AddRecoveredBlock", mock.Anything, mock.Anything).
+			Return(nil, status.Error(codes.InvalidArgument, "invalid"))
+		r := NewRebuilder(test.NewTestingLogger(t), Config{}, srv, bucket, nil, prometheus.NewRegistry())
+		stats, err := r.Rebuild(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RebuildStats{Objects: 1, Skipped: 1}, stats)
+
+		srv = mockdlq.NewMockMetastore(t)
+		srv.On("AddRecoveredBlock", mock.Anything, mock.Anything).
+			Return(nil, status.Error(codes.Unavailable, "unavailable"))
+		r = NewRebuilder(test.NewTestingLogger(t), Config{}, srv, bucket, nil, prometheus.NewRegistry())
+		_, err = r.Rebuild(ctx)
+		require.Error(t, err)
+	})
+}
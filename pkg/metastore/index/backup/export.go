@@ -0,0 +1,332 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+)
+
+// The index export is a logical copy of the metastore index: unlike raft
+// snapshots, it does not depend on the FSM storage format, and can be used
+// to rebuild the index from scratch (see Rebuilder).
+//
+// Exports are stored in the bucket as:
+//
+//	metastore-export/<ulid>.bin
+//
+// The object is a sequence of block metadata entries, each prefixed with
+// its size (uvarint). Every entry includes its own string table. The
+// sequence is terminated with an entry of zero size, which allows to
+// detect incomplete exports.
+//
+// The block metadata is followed by the tombstones of the index, in the
+// same format: the blocks and shards listed in the tombstones are to be
+// deleted, and must not be added to the index when it is rebuilt. Exports
+// created before tombstones were included end after the block metadata.
+
+const (
+	DirNameExport = "metastore-export"
+	exportFileExt = ".bin"
+
+	maxExportEntrySize = 64 << 20
+)
+
+var ErrIncompleteExport = errors.New("incomplete index export")
+
+type Config struct {
+	ExportInterval     time.Duration `yaml:"export_interval" category:"experimental"`
+	ExportRetention    int           `yaml:"export_retention" category:"experimental"`
+	RebuildFromStorage bool          `yaml:"rebuild_from_storage" category:"experimental"`
+}
+
+func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&c.ExportInterval, prefix+"export-interval", 0, "Interval at which the leader exports the index to the object storage. The export can be used to rebuild the index if all the metastore replicas are lost. 0 to disable.")
+	f.IntVar(&c.ExportRetention, prefix+"export-retention", 3, "Number of the most recent index exports to keep in the object storage.")
+	f.BoolVar(&c.RebuildFromStorage, prefix+"rebuild-from-storage", false, "Rebuild the index from the object storage when the node becomes the leader: the metadata of every segment and block is read from the latest index export or from the object itself, and is added to the index. Blocks that are already present in the index, or listed in the tombstones of the export, are skipped. Intended for disaster recovery; the option should be disabled once the index is rebuilt.")
+}
+
+// ExportPath returns the object path of the index export.
+func ExportPath(id ulid.ULID) string {
+	return DirNameExport + "/" + id.String() + exportFileExt
+}
+
+// ExportWriter writes block metadata entries and tombstones in the
+// export format. All the block metadata entries must be written before
+// the tombstones.
+type ExportWriter struct {
+	w          io.Writer
+	buf        []byte
+	n          int
+	tombstones bool
+}
+
+func NewExportWriter(w io.Writer) *ExportWriter {
+	return &ExportWriter{w: w}
+}
+
+type exportEntry interface {
+	SizeVT() int
+	MarshalToSizedBufferVT([]byte) (int, error)
+}
+
+func (w *ExportWriter) Write(md *metastorev1.BlockMeta) error {
+	if w.tombstones {
+		return errors.New("block metadata must be written before tombstones")
+	}
+	if err := w.write(md); err != nil {
+		return err
+	}
+	w.n++
+	return nil
+}
+
+func (w *ExportWriter) WriteTombstones(t *metastorev1.Tombstones) error {
+	if !w.tombstones {
+		// Terminate the block metadata section.
+		if _, err := w.w.Write([]byte{0}); err != nil {
+			return err
+		}
+		w.tombstones = true
+	}
+	return w.write(t)
+}
+
+func (w *ExportWriter) write(e exportEntry) error {
+	size := e.SizeVT()
+	w.buf = binary.AppendUvarint(w.buf[:0], uint64(size))
+	n := len(w.buf)
+	w.buf = slices.Grow(w.buf, size)[:n+size]
+	if _, err := e.MarshalToSizedBufferVT(w.buf[n:]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+// Close writes the terminating entries. It does not close the
+// underlying writer.
+func (w *ExportWriter) Close() error {
+	terminator := []byte{0}
+	if !w.tombstones {
+		terminator = []byte{0, 0}
+	}
+	_, err := w.w.Write(terminator)
+	return err
+}
+
+// ReadExport calls fn for each block metadata entry of the export, and
+// tombstones for each tombstone entry, if it is not nil.
+func ReadExport(r io.Reader, fn func(*metastorev1.BlockMeta) error, tombstones func(*metastorev1.Tombstones) error) error {
+	if tombstones == nil {
+		tombstones = func(*metastorev1.Tombstones) error { return nil }
+	}
+	br := bufio.NewReader(r)
+	var buf []byte
+	err := readExportEntries(br, &buf, func(b []byte) error {
+		md := new(metastorev1.BlockMeta)
+		if err := md.UnmarshalVT(b); err != nil {
+			return fmt.Errorf("failed to decode block metadata: %w", err)
+		}
+		return fn(md)
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = br.Peek(1); errors.Is(err, io.EOF) {
+		// The export does not include tombstones.
+		return nil
+	}
+	return readExportEntries(br, &buf, func(b []byte) error {
+		t := new(metastorev1.Tombstones)
+		if err := t.UnmarshalVT(b); err != nil {
+			return fmt.Errorf("failed to decode tombstones: %w", err)
+		}
+		return tombstones(t)
+	})
+}
+
+func readExportEntries(br *bufio.Reader, buf *[]byte, fn func([]byte) error) error {
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrIncompleteExport, err)
+		}
+		if size == 0 {
+			return nil
+		}
+		if size > maxExportEntrySize {
+			return fmt.Errorf("%w: entry size %d exceeds limit", ErrIncompleteExport, size)
+		}
+		*buf = slices.Grow((*buf)[:0], int(size))[:size]
+		if _, err = io.ReadFull(br, *buf); err != nil {
+			return fmt.Errorf("%w: %w", ErrIncompleteExport, err)
+		}
+		if err = fn(*buf); err != nil {
+			return err
+		}
+	}
+}
+
+// ListExports returns the paths of the index exports found in
+// the bucket, ordered by creation time.
+func ListExports(ctx context.Context, bucket objstore.BucketReader) ([]string, error) {
+	var exports []string
+	err := bucket.Iter(ctx, DirNameExport, func(path string) error {
+		name := strings.TrimSuffix(strings.TrimPrefix(path, DirNameExport+"/"), exportFileExt)
+		if _, err := ulid.Parse(name); err == nil && strings.HasSuffix(path, exportFileExt) {
+			exports = append(exports, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(exports)
+	return exports, nil
+}
+
+type Index interface {
+	ExportIndex(context.Context, func(*metastorev1.BlockMeta) error, func(*metastorev1.Tombstones) error) error
+}
+
+// Exporter periodically exports the index to the object storage,
+// and removes exports that exceed the retention.
+type Exporter struct {
+	config  Config
+	logger  log.Logger
+	index   Index
+	bucket  objstore.Bucket
+	metrics *exportMetrics
+
+	started bool
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+}
+
+func NewExporter(logger log.Logger, config Config, index Index, bucket objstore.Bucket, reg prometheus.Registerer) *Exporter {
+	return &Exporter{
+		config:  config,
+		logger:  logger,
+		index:   index,
+		bucket:  bucket,
+		metrics: newExportMetrics(reg),
+	}
+}
+
+func (e *Exporter) Start() {
+	if e.config.ExportInterval == 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.started {
+		e.logger.Log("msg", "index exporter already started")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.started = true
+	go e.loop(ctx)
+	e.logger.Log("msg", "index exporter started")
+}
+
+func (e *Exporter) Stop() {
+	if e.config.ExportInterval == 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.started {
+		e.logger.Log("msg", "index exporter already stopped")
+		return
+	}
+	e.cancel()
+	e.started = false
+	e.logger.Log("msg", "index exporter stopped")
+}
+
+func (e *Exporter) loop(ctx context.Context) {
+	ticker := time.NewTicker(e.config.ExportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Export(ctx); err != nil && ctx.Err() == nil {
+				level.Error(e.logger).Log("msg", "failed to export index", "err", err)
+			}
+		}
+	}
+}
+
+// Export uploads a new index export, and removes the obsolete ones.
+func (e *Exporter) Export(ctx context.Context) error {
+	start := time.Now()
+	// The export is buffered in memory: the index is read within
+	// a single transaction, which should not be held while the
+	// data is uploaded.
+	var buf bytes.Buffer
+	w := NewExportWriter(&buf)
+	if err := e.index.ExportIndex(ctx, w.Write, w.WriteTombstones); err != nil {
+		e.metrics.exports.WithLabelValues("failure").Inc()
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		e.metrics.exports.WithLabelValues("failure").Inc()
+		return err
+	}
+	path := ExportPath(ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader))
+	size := buf.Len()
+	if err := e.bucket.Upload(ctx, path, &buf); err != nil {
+		e.metrics.exports.WithLabelValues("failure").Inc()
+		return fmt.Errorf("failed to upload index export: %w", err)
+	}
+	e.metrics.exports.WithLabelValues("success").Inc()
+	e.metrics.exportBlocks.Set(float64(w.n))
+	e.metrics.exportSize.Set(float64(size))
+	e.metrics.exportTimestamp.SetToCurrentTime()
+	level.Info(e.logger).Log(
+		"msg", "index exported",
+		"path", path,
+		"blocks", w.n,
+		"size", size,
+		"duration", time.Since(start),
+	)
+	return e.deleteObsolete(ctx)
+}
+
+func (e *Exporter) deleteObsolete(ctx context.Context) error {
+	exports, err := ListExports(ctx, e.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to list index exports: %w", err)
+	}
+	retain := max(1, e.config.ExportRetention)
+	if len(exports) <= retain {
+		return nil
+	}
+	for _, path := range exports[:len(exports)-retain] {
+		if err = e.bucket.Delete(ctx, path); err != nil && !e.bucket.IsObjNotFoundErr(err) {
+			level.Warn(e.logger).Log("msg", "failed to delete obsolete index export", "path", path, "err", err)
+		}
+	}
+	return nil
+}
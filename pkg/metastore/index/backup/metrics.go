@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type exportMetrics struct {
+	exports         *prometheus.CounterVec
+	exportBlocks    prometheus.Gauge
+	exportSize      prometheus.Gauge
+	exportTimestamp prometheus.Gauge
+}
+
+func newExportMetrics(reg prometheus.Registerer) *exportMetrics {
+	m := &exportMetrics{
+		exports: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "index_exports_total",
+				Help: "Total number of index exports by status.",
+			},
+			[]string{"status"},
+		),
+		exportBlocks: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "index_export_blocks",
+			Help: "Number of blocks in the last index export.",
+		}),
+		exportSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "index_export_size_bytes",
+			Help: "Size of the last index export.",
+		}),
+		exportTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "index_export_last_success_timestamp_seconds",
+			Help: "Timestamp of the last successful index export.",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(
+			m.exports,
+			m.exportBlocks,
+			m.exportSize,
+			m.exportTimestamp,
+		)
+	}
+
+	return m
+}
+
+type rebuildMetrics struct {
+	blocks *prometheus.CounterVec
+}
+
+func newRebuildMetrics(reg prometheus.Registerer) *rebuildMetrics {
+	m := &rebuildMetrics{
+		blocks: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "index_rebuild_blocks_total",
+				Help: "Total number of blocks processed by the index rebuild by status.",
+			},
+			[]string{"status"},
+		),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.blocks)
+	}
+
+	return m
+}
@@ -0,0 +1,495 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/raftnode"
+	phlareobj "github.com/grafana/pyroscope/v2/pkg/objstore"
+)
+
+const (
+	defaultRebuildConcurrency = 16
+	rebuildRetryInterval      = time.Minute
+)
+
+type Metastore interface {
+	AddRecoveredBlock(context.Context, *metastorev1.AddBlockRequest) (*metastorev1.AddBlockResponse, error)
+}
+
+// RebuildStats summarizes the outcome of the index rebuild.
+type RebuildStats struct {
+	Objects  int64 // Segments and blocks found in the bucket.
+	Exported int64 // Metadata entries found in the index export.
+	Added    int64 // Blocks added to the index.
+	Deleted  int64 // Objects listed in the tombstones of the export.
+	Skipped  int64 // Objects that could not be read or were rejected.
+	// Source blocks of compacted blocks found in the bucket.
+	Compacted int64
+}
+
+// Rebuilder reconstructs the metastore index from the object storage.
+//
+// Every segment and block found in the bucket, and every block found in
+// the cold storage tier bucket, if configured, is added to the index via
+// the AddRecoveredBlock call: index partitions, shards and their string
+// tables are built as if the blocks were just written, and the blocks are
+// scheduled for compaction; unlike regular blocks, recovered blocks are
+// not accounted in the placement stats. The metadata entries are taken
+// from the latest index export, if it is available; otherwise, the
+// metadata is decoded from the object itself. Entries of the export
+// that refer to objects that do not exist anymore are ignored.
+//
+// Objects listed in the tombstones of the export are not added: these
+// are source blocks of completed compaction jobs, and blocks of shards
+// truncated by the retention policy, that have not been deleted from the
+// bucket yet. The metastore also skips blocks listed in its own
+// tombstones.
+//
+// Sources of compaction jobs completed after the export was created are
+// not listed in its tombstones, and may still be present in the bucket.
+// Compacted blocks do not reference their sources, therefore a block is
+// skipped if it is covered by a compacted block that is not present in
+// the export: see compactedSources for details.
+//
+// The rebuild is idempotent: blocks that are already present in the index
+// are skipped by the metastore.
+type Rebuilder struct {
+	logger      log.Logger
+	bucket      phlareobj.Bucket
+	cold        phlareobj.Bucket
+	metastore   Metastore
+	metrics     *rebuildMetrics
+	concurrency int
+
+	// Whether the rebuild should run on the leader (recovery mode).
+	enabled bool
+	done    bool
+	started bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+}
+
+// NewRebuilder creates a new index rebuilder. The cold
+// storage tier bucket is optional and may be nil.
+func NewRebuilder(logger log.Logger, config Config, metastore Metastore, bucket, cold objstore.Bucket, reg prometheus.Registerer) *Rebuilder {
+	r := &Rebuilder{
+		logger:      logger,
+		bucket:      phlareobj.NewBucket(bucket),
+		metastore:   metastore,
+		metrics:     newRebuildMetrics(reg),
+		concurrency: defaultRebuildConcurrency,
+		enabled:     config.RebuildFromStorage,
+	}
+	if cold != nil {
+		r.cold = phlareobj.NewBucket(cold)
+	}
+	return r
+}
+
+// SetConcurrency sets the number of objects processed concurrently.
+func (r *Rebuilder) SetConcurrency(n int) { r.concurrency = max(1, n) }
+
+// Start runs the rebuild in the background, if the recovery mode is
+// enabled. The rebuild is retried until it succeeds; once it succeeds,
+// it is not repeated, unless the process is restarted.
+func (r *Rebuilder) Start() {
+	if !r.enabled {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started || r.done {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.started = true
+	r.wg.Add(1)
+	go r.run(ctx)
+	level.Info(r.logger).Log("msg", "index rebuild started")
+}
+
+func (r *Rebuilder) Stop() {
+	if !r.enabled {
+		return
+	}
+	r.mu.Lock()
+	if !r.started {
+		r.mu.Unlock()
+		return
+	}
+	r.cancel()
+	r.started = false
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+func (r *Rebuilder) run(ctx context.Context) {
+	defer r.wg.Done()
+	for {
+		stats, err := r.Rebuild(ctx)
+		if err == nil {
+			level.Info(r.logger).Log(
+				"msg", "index rebuild completed",
+				"objects", stats.Objects,
+				"exported", stats.Exported,
+				"added", stats.Added,
+				"deleted", stats.Deleted,
+				"skipped", stats.Skipped,
+				"compacted", stats.Compacted,
+			)
+			r.mu.Lock()
+			r.done = true
+			r.mu.Unlock()
+			return
+		}
+		if ctx.Err() != nil || raftnode.IsRaftLeadershipError(err) {
+			level.Warn(r.logger).Log("msg", "index rebuild interrupted", "err", err)
+			return
+		}
+		level.Error(r.logger).Log("msg", "index rebuild failed; to be retried", "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rebuildRetryInterval):
+		}
+	}
+}
+
+// Rebuild adds all the segments and blocks found in the buckets to the index.
+func (r *Rebuilder) Rebuild(ctx context.Context) (stats RebuildStats, err error) {
+	exported, err := r.readLatestExport(ctx)
+	if err != nil {
+		// The export is optional: the metadata can be read from objects.
+		level.Warn(r.logger).Log("msg", "failed to read index export; reading metadata from objects", "err", err)
+		exported = newExport()
+	}
+	stats.Exported = int64(len(exported.blocks))
+
+	blocks, err := r.listBlocks(ctx, exported, &stats)
+	if err != nil {
+		return stats, err
+	}
+
+	compacted := compactedSources(blocks, exported)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(r.concurrency)
+	var added, skipped atomic.Int64
+	for _, b := range blocks {
+		if _, ok := compacted[b.md.Id]; ok {
+			r.metrics.blocks.WithLabelValues("compacted").Inc()
+			stats.Compacted++
+			continue
+		}
+		g.Go(func() error {
+			ok, err := r.addBlock(gctx, b.path, b.md)
+			switch {
+			case err != nil:
+				return err
+			case ok:
+				added.Add(1)
+			default:
+				skipped.Add(1)
+			}
+			return nil
+		})
+	}
+	err = g.Wait()
+	stats.Added = added.Load()
+	stats.Skipped += skipped.Load()
+	return stats, err
+}
+
+type recoveredBlock struct {
+	path string
+	md   *metastorev1.BlockMeta
+}
+
+// listBlocks returns the metadata of the segments and blocks found in the
+// buckets, except for the objects listed in the tombstones of the export.
+func (r *Rebuilder) listBlocks(ctx context.Context, exported *export, stats *RebuildStats) ([]recoveredBlock, error) {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(r.concurrency)
+	var (
+		mu     sync.Mutex
+		blocks []recoveredBlock
+		err    error
+	)
+	for _, dir := range r.dirs() {
+		err = dir.bucket.Iter(gctx, dir.path, func(path string) error {
+			if !strings.HasSuffix(path, "/"+block.FileNameDataObject) {
+				return nil
+			}
+			stats.Objects++
+			if exported.deleted(path) {
+				r.metrics.blocks.WithLabelValues("deleted").Inc()
+				stats.Deleted++
+				return nil
+			}
+			g.Go(func() error {
+				md, err := r.readBlock(gctx, dir.bucket, dir.tier, path, exported)
+				if err != nil || md == nil {
+					return err
+				}
+				mu.Lock()
+				blocks = append(blocks, recoveredBlock{path: path, md: md})
+				mu.Unlock()
+				return nil
+			})
+			return nil
+		}, objstore.WithRecursiveIter())
+		if err != nil {
+			break
+		}
+	}
+	if waitErr := g.Wait(); err == nil {
+		err = waitErr
+	}
+	stats.Skipped = stats.Objects - stats.Deleted - int64(len(blocks))
+	return blocks, err
+}
+
+type storageDir struct {
+	bucket phlareobj.Bucket
+	tier   metastorev1.StorageTier
+	path   string
+}
+
+// dirs returns the directories to look for the objects in. Segments
+// are never stored in the cold storage tier, only compacted blocks.
+func (r *Rebuilder) dirs() []storageDir {
+	hot := metastorev1.StorageTier_STORAGE_TIER_HOT
+	dirs := []storageDir{
+		{bucket: r.bucket, tier: hot, path: block.DirNameSegment},
+		{bucket: r.bucket, tier: hot, path: block.DirNameBlock},
+	}
+	if r.cold != nil {
+		cold := metastorev1.StorageTier_STORAGE_TIER_COLD
+		dirs = append(dirs, storageDir{bucket: r.cold, tier: cold, path: block.DirNameBlock})
+	}
+	return dirs
+}
+
+// readBlock returns the metadata of the object, or nil if the object
+// cannot be read.
+func (r *Rebuilder) readBlock(
+	ctx context.Context,
+	bucket phlareobj.Bucket,
+	tier metastorev1.StorageTier,
+	path string,
+	exported *export,
+) (*metastorev1.BlockMeta, error) {
+	id, err := block.ParseBlockIDFromPath(path)
+	if err != nil {
+		r.metrics.blocks.WithLabelValues("invalid_path").Inc()
+		level.Warn(r.logger).Log("msg", "unexpected object; skipping", "path", path, "err", err)
+		return nil, nil
+	}
+	md, ok := exported.blocks[id.String()]
+	if ok && block.ObjectPath(md) == path && md.StorageTier == tier {
+		return md, nil
+	}
+	obj, err := block.NewObjectFromPath(ctx, bucket, path)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		r.metrics.blocks.WithLabelValues("read_error").Inc()
+		level.Warn(r.logger).Log("msg", "failed to read block metadata; skipping", "path", path, "err", err)
+		return nil, nil
+	}
+	md = obj.Metadata()
+	// The storage tier is determined by the bucket
+	// the object is found in.
+	md.StorageTier = tier
+	return md, nil
+}
+
+func (r *Rebuilder) addBlock(ctx context.Context, path string, md *metastorev1.BlockMeta) (bool, error) {
+	switch _, err := r.metastore.AddRecoveredBlock(ctx, &metastorev1.AddBlockRequest{Block: md}); {
+	case err == nil:
+		r.metrics.blocks.WithLabelValues("success").Inc()
+		return true, nil
+	case status.Code(err) == codes.InvalidArgument:
+		r.metrics.blocks.WithLabelValues("invalid_metadata").Inc()
+		level.Warn(r.logger).Log("msg", "block metadata rejected by metastore; skipping", "path", path, "err", err)
+		return false, nil
+	default:
+		r.metrics.blocks.WithLabelValues("metastore_error").Inc()
+		return false, fmt.Errorf("failed to add block %s: %w", path, err)
+	}
+}
+
+// compactedSources returns the IDs of the blocks that have been compacted
+// into other blocks found in the buckets, but are not listed in the
+// tombstones of the export: the compaction jobs completed after the
+// export was created.
+//
+// A compacted block C is created after the export, if it is not present in
+// the export, and its ID is based on the timestamp of its oldest source.
+// Therefore, a block is considered a source of C if, for every tenant the
+// block has data of, there is a block C of the same tenant and shard, with
+// a higher compaction level, the ID timestamp not after the block's one,
+// and the time range covering the time range of the tenant data.
+func compactedSources(blocks []recoveredBlock, exported *export) map[string]struct{} {
+	type tenantShard struct {
+		tenant string
+		shard  uint32
+	}
+	compacted := make(map[tenantShard][]*metastorev1.BlockMeta)
+	for _, b := range blocks {
+		if b.md.CompactionLevel == 0 || b.md.Tenant == 0 {
+			continue
+		}
+		if _, ok := exported.blocks[b.md.Id]; ok {
+			continue
+		}
+		k := tenantShard{tenant: b.md.StringTable[b.md.Tenant], shard: b.md.Shard}
+		compacted[k] = append(compacted[k], b.md)
+	}
+	sources := make(map[string]struct{})
+	if len(compacted) == 0 {
+		return sources
+	}
+	for _, b := range blocks {
+		id, err := ulid.Parse(b.md.Id)
+		if err != nil {
+			continue
+		}
+		tenants := tenantTimeRanges(b.md)
+		covered := len(tenants) > 0
+		for tenant, tr := range tenants {
+			k := tenantShard{tenant: tenant, shard: b.md.Shard}
+			if !slices.ContainsFunc(compacted[k], func(c *metastorev1.BlockMeta) bool {
+				cid, err := ulid.Parse(c.Id)
+				return err == nil &&
+					c.CompactionLevel > b.md.CompactionLevel &&
+					cid.Time() <= id.Time() &&
+					c.MinTime <= tr[0] && tr[1] <= c.MaxTime
+			}) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			sources[b.md.Id] = struct{}{}
+		}
+	}
+	return sources
+}
+
+// tenantTimeRanges returns the time range of the data of each
+// tenant the block has data of.
+func tenantTimeRanges(md *metastorev1.BlockMeta) map[string][2]int64 {
+	ranges := make(map[string][2]int64)
+	for _, ds := range md.Datasets {
+		if int(ds.Tenant) >= len(md.StringTable) {
+			continue
+		}
+		tenant := md.StringTable[ds.Tenant]
+		tr, ok := ranges[tenant]
+		if !ok {
+			tr = [2]int64{ds.MinTime, ds.MaxTime}
+		}
+		ranges[tenant] = [2]int64{min(tr[0], ds.MinTime), max(tr[1], ds.MaxTime)}
+	}
+	return ranges
+}
+
+func (r *Rebuilder) readLatestExport(ctx context.Context) (*export, error) {
+	exports, err := ListExports(ctx, r.bucket)
+	if err != nil || len(exports) == 0 {
+		return newExport(), err
+	}
+	path := exports[len(exports)-1]
+	rc, err := r.bucket.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	x := newExport()
+	if err = ReadExport(rc, x.addBlock, x.addTombstones); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	level.Info(r.logger).Log(
+		"msg", "using index export",
+		"path", path,
+		"blocks", len(x.blocks),
+		"block_tombstones", len(x.tombstones),
+		"shard_tombstones", len(x.shards),
+	)
+	return x, nil
+}
+
+// export is the content of the index export used for the rebuild.
+type export struct {
+	blocks map[string]*metastorev1.BlockMeta
+	// IDs of the blocks listed in the block tombstones.
+	tombstones map[string]struct{}
+	// Shard directories truncated by the retention policy, and the
+	// time before which all the objects in the directory are deleted.
+	shards map[string]time.Time
+}
+
+func newExport() *export {
+	return &export{
+		blocks:     make(map[string]*metastorev1.BlockMeta),
+		tombstones: make(map[string]struct{}),
+		shards:     make(map[string]time.Time),
+	}
+}
+
+func (x *export) addBlock(md *metastorev1.BlockMeta) error {
+	x.blocks[md.Id] = md
+	return nil
+}
+
+func (x *export) addTombstones(t *metastorev1.Tombstones) error {
+	if b := t.GetBlocks(); b != nil {
+		for _, id := range b.Blocks {
+			x.tombstones[id] = struct{}{}
+		}
+	}
+	if s := t.GetShard(); s != nil {
+		dir := block.BuildObjectDir(s.Tenant, s.Shard)
+		maxTime := time.Unix(0, s.Timestamp).Add(time.Duration(s.Duration))
+		if maxTime.After(x.shards[dir]) {
+			x.shards[dir] = maxTime
+		}
+	}
+	return nil
+}
+
+// deleted reports whether the object is listed in the tombstones.
+func (x *export) deleted(path string) bool {
+	id, err := block.ParseBlockIDFromPath(path)
+	if err != nil {
+		return false
+	}
+	if _, ok := x.tombstones[id.String()]; ok {
+		return true
+	}
+	// The object path is <dir>/<id>/<file>.
+	dir := strings.TrimSuffix(path, id.String()+"/"+block.FileNameDataObject)
+	maxTime, ok := x.shards[dir]
+	return ok && time.UnixMilli(int64(id.Time())).Before(maxTime)
+}
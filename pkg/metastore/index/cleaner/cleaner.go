@@ -10,12 +10,14 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/rewrite"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner/retention"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/raftnode"
 )
 
 type Index interface {
 	TruncateIndex(context.Context, retention.Policy) error
+	RewriteBlocks(context.Context, rewrite.Planner) error
 }
 
 type Config struct {
@@ -33,25 +35,40 @@ func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 }
 
 // Cleaner is responsible for periodically cleaning up
-// the index by applying retention policies. As of now,
-// it only applies the time-based retention policy.
+// the index by applying retention policies: the time-based
+// retention policy, and the dataset retention policy.
+//
+// Blocks of the top compaction level (minLevel and above) in which only
+// some of the datasets have passed the retention period are rewritten by
+// compaction workers.
 type Cleaner struct {
 	logger    log.Logger
 	overrides retention.Overrides
 	config    Config
 	index     Index
+	jobs      rewrite.JobLister
+	minLevel  uint32
 
 	started bool
 	cancel  context.CancelFunc
 	mu      sync.Mutex
 }
 
-func NewCleaner(logger log.Logger, overrides retention.Overrides, config Config, index Index) *Cleaner {
+func NewCleaner(
+	logger log.Logger,
+	overrides retention.Overrides,
+	config Config,
+	index Index,
+	jobs rewrite.JobLister,
+	minLevel uint32,
+) *Cleaner {
 	return &Cleaner{
 		logger:    logger,
 		overrides: overrides,
 		config:    config,
 		index:     index,
+		jobs:      jobs,
+		minLevel:  minLevel,
 	}
 }
 
@@ -97,22 +114,65 @@ func (c *Cleaner) loop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			rp := retention.NewTimeBasedRetentionPolicy(
-				log.With(c.logger, "component", "retention-policy"),
-				c.overrides,
-				c.config.CleanupMaxPartitions,
-				c.config.CleanupGracePeriod,
-				time.Now(),
-			)
-			switch err := c.index.TruncateIndex(ctx, rp); {
-			case err == nil:
-			case errors.Is(err, context.Canceled):
+			if !c.cleanup(ctx) {
 				return
-			case raftnode.IsRaftLeadershipError(err):
-				level.Warn(c.logger).Log("msg", "leadership change; cleanup interrupted", "err", err)
-			default:
-				level.Error(c.logger).Log("msg", "cleanup attempt failed", "err", err)
 			}
 		}
 	}
 }
+
+// cleanup applies the retention policies in turn. The time-based policy
+// deletes whole tenant shards, while the dataset retention policy deletes
+// individual blocks. Then blocks that include expired datasets but can't
+// be deleted are scheduled for rewrite. It returns false if the context
+// has been canceled.
+func (c *Cleaner) cleanup(ctx context.Context) bool {
+	now := time.Now()
+	policies := []retention.Policy{
+		retention.NewTimeBasedRetentionPolicy(
+			log.With(c.logger, "component", "retention-policy"),
+			c.overrides,
+			c.config.CleanupMaxPartitions,
+			c.config.CleanupGracePeriod,
+			now,
+		),
+		retention.NewDatasetRetentionPolicy(
+			log.With(c.logger, "component", "dataset-retention-policy"),
+			c.overrides,
+			c.config.CleanupMaxPartitions,
+			c.config.CleanupGracePeriod,
+			now,
+		),
+	}
+	for _, rp := range policies {
+		switch err := c.index.TruncateIndex(ctx, rp); {
+		case err == nil:
+		case errors.Is(err, context.Canceled):
+			return false
+		case raftnode.IsRaftLeadershipError(err):
+			level.Warn(c.logger).Log("msg", "leadership change; cleanup interrupted", "err", err)
+			return true
+		default:
+			level.Error(c.logger).Log("msg", "cleanup attempt failed", "err", err)
+		}
+	}
+	planner := retention.NewDatasetRewritePlanner(
+		log.With(c.logger, "component", "dataset-rewrite-planner"),
+		c.overrides,
+		c.jobs,
+		c.minLevel,
+		c.config.CleanupMaxPartitions,
+		c.config.CleanupGracePeriod,
+		now,
+	)
+	switch err := c.index.RewriteBlocks(ctx, planner); {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		return false
+	case raftnode.IsRaftLeadershipError(err):
+		level.Warn(c.logger).Log("msg", "leadership change; cleanup interrupted", "err", err)
+	default:
+		level.Error(c.logger).Log("msg", "failed to plan rewrite jobs", "err", err)
+	}
+	return true
+}
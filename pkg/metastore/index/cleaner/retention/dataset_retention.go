@@ -0,0 +1,482 @@
+package retention
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/block"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/rewrite"
+	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
+	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
+)
+
+// DatasetRetention defines the retention period for the data matching the
+// label selector. The policies can only shorten the retention: the tenant
+// retention period applies to all the data regardless of the policies. If
+// multiple policies match the data, the shortest retention period applies.
+//
+// The selector may only refer to the dataset labels: service_name and
+// __profile_type__. Otherwise, the data could not be identified without
+// reading the blocks.
+type DatasetRetention struct {
+	Selector        string         `yaml:"selector"`
+	RetentionPeriod model.Duration `yaml:"retention_period"`
+}
+
+var datasetLabels = []string{
+	phlaremodel.LabelNameServiceName,
+	phlaremodel.LabelNameProfileType,
+}
+
+func (c *Config) Validate() error {
+	for i, r := range c.DatasetRetention {
+		matchers, err := phlaremodel.ParseMetricSelector(r.Selector)
+		if err != nil {
+			return fmt.Errorf("dataset retention policy at pos %d is not valid: %w", i, err)
+		}
+		for _, m := range matchers {
+			if !slices.Contains(datasetLabels, m.Name) {
+				return fmt.Errorf("dataset retention policy at pos %d is not valid: label %q is not a dataset label %q", i, m.Name, datasetLabels)
+			}
+		}
+		if r.RetentionPeriod <= 0 {
+			return fmt.Errorf("dataset retention policy at pos %d is not valid: retention period must be positive", i)
+		}
+	}
+	return nil
+}
+
+// DatasetRetentionTombstones creates series tombstones for the data that
+// has passed the dataset retention period. The tombstones are not stored:
+// they are only passed to the compaction jobs, so that the data is dropped
+// when the blocks are compacted. Blocks at the top compaction level are not
+// compacted again: they are deleted by the dataset retention policy once
+// all the datasets expire, or rewritten by the jobs planned with
+// DatasetRewritePlanner if only some of them have.
+//
+// The tenant policies are resolved once, when the instance is created:
+// the instance is to be used for all the jobs of a planning pass.
+type DatasetRetentionTombstones struct {
+	defaults  []DatasetRetention
+	overrides map[string][]DatasetRetention
+	now       time.Time
+}
+
+func NewDatasetRetentionTombstones(overrides Overrides, now time.Time) *DatasetRetentionTombstones {
+	defaults, tenantOverrides := overrides.Retention()
+	t := DatasetRetentionTombstones{
+		defaults:  defaults.DatasetRetention,
+		overrides: make(map[string][]DatasetRetention),
+		now:       now,
+	}
+	for tenantID, override := range tenantOverrides {
+		t.overrides[tenantID] = override.DatasetRetention
+	}
+	return &t
+}
+
+// SeriesTombstones returns series tombstones for the data
+// of the tenant that has passed the retention period.
+func (t *DatasetRetentionTombstones) SeriesTombstones(tenant string) []*metastorev1.SeriesTombstone {
+	if tenant == "" {
+		// Data of the anonymous tenant is only present in L0 blocks,
+		// which are compacted before any retention period expires.
+		return nil
+	}
+	policies, ok := t.overrides[tenant]
+	if !ok {
+		policies = t.defaults
+	}
+	var tombstones []*metastorev1.SeriesTombstone
+	for i, r := range policies {
+		tombstones = append(tombstones, &metastorev1.SeriesTombstone{
+			Name:          "retention-" + strconv.Itoa(i),
+			Tenant:        tenant,
+			LabelSelector: r.Selector,
+			StartTime:     0,
+			EndTime:       t.now.Add(-time.Duration(r.RetentionPeriod)).UnixMilli(),
+			CreatedAt:     t.now.UnixMilli(),
+		})
+	}
+	return tombstones
+}
+
+// DatasetRetentionPolicy deletes blocks in which all the datasets have
+// passed the retention period defined by the dataset retention policies.
+//
+// A dataset has a label set per profile type; a dataset only qualifies if
+// the label sets of all its profile types satisfy the selectors of expired
+// policies. Blocks are deleted entirely: blocks in which only some of the
+// datasets have expired are rewritten by DatasetRewritePlanner jobs.
+type DatasetRetentionPolicy struct {
+	logger        log.Logger
+	gracePeriod   time.Duration
+	maxTombstones int
+
+	defaults   []datasetRetentionMarker
+	overrides  map[string][]datasetRetentionMarker
+	lastMarker time.Time
+	tombstones []*metastorev1.Tombstones
+}
+
+type datasetRetentionMarker struct {
+	matchers  []*labels.Matcher
+	timestamp time.Time
+}
+
+func NewDatasetRetentionPolicy(
+	logger log.Logger,
+	overrides Overrides,
+	maxTombstones int,
+	gracePeriod time.Duration,
+	now time.Time,
+) *DatasetRetentionPolicy {
+	defaults, tenantOverrides := overrides.Retention()
+	rp := DatasetRetentionPolicy{
+		logger:        logger,
+		overrides:     make(map[string][]datasetRetentionMarker),
+		tombstones:    make([]*metastorev1.Tombstones, 0, maxTombstones),
+		maxTombstones: maxTombstones,
+		gracePeriod:   gracePeriod,
+	}
+	rp.defaults = rp.markers(defaults.DatasetRetention, now)
+	for tenantID, override := range tenantOverrides {
+		rp.overrides[tenantID] = rp.markers(override.DatasetRetention, now)
+	}
+	return &rp
+}
+
+func (rp *DatasetRetentionPolicy) markers(policies []DatasetRetention, now time.Time) []datasetRetentionMarker {
+	markers := make([]datasetRetentionMarker, 0, len(policies))
+	for _, p := range policies {
+		if p.RetentionPeriod <= 0 {
+			continue
+		}
+		matchers, err := phlaremodel.ParseMetricSelector(p.Selector)
+		if err != nil {
+			level.Warn(rp.logger).Log("msg", "invalid dataset retention policy selector", "selector", p.Selector, "err", err)
+			continue
+		}
+		m := datasetRetentionMarker{
+			matchers:  matchers,
+			timestamp: now.Add(-time.Duration(p.RetentionPeriod)),
+		}
+		if m.timestamp.After(rp.lastMarker) {
+			rp.lastMarker = m.timestamp
+		}
+		markers = append(markers, m)
+	}
+	return markers
+}
+
+func (rp *DatasetRetentionPolicy) tenantMarkers(tenant string) []datasetRetentionMarker {
+	if m, ok := rp.overrides[tenant]; ok {
+		return m
+	}
+	return rp.defaults
+}
+
+func (rp *DatasetRetentionPolicy) CreateTombstones(tx *bbolt.Tx, partitions iter.Seq[indexstore.Partition]) []*metastorev1.Tombstones {
+	rp.tombstones = rp.tombstones[:0]
+	for shard, markers := range rp.shards(tx, partitions) {
+		if len(rp.tombstones) >= rp.maxTombstones {
+			break
+		}
+		rp.createTombstones(tx, shard, markers)
+	}
+	return rp.tombstones
+}
+
+// shards iterates over the tenant shards that may include data
+// that has passed the retention period of the tenant policies.
+func (rp *DatasetRetentionPolicy) shards(tx *bbolt.Tx, partitions iter.Seq[indexstore.Partition]) iter.Seq2[*indexstore.Shard, []datasetRetentionMarker] {
+	return func(yield func(*indexstore.Shard, []datasetRetentionMarker) bool) {
+		if rp.lastMarker.IsZero() {
+			level.Debug(rp.logger).Log("msg", "no dataset retention policies defined, skipping")
+			return
+		}
+		for p := range partitions {
+			// Partitions are ordered by time: if the partition has not passed
+			// the retention period of any policy, the next ones haven't either.
+			// See TimeBasedRetentionPolicy for the grace period rationale.
+			if !p.EndTime().Add(rp.gracePeriod).Before(rp.lastMarker) {
+				return
+			}
+			q := p.Query(tx)
+			if q == nil {
+				continue
+			}
+			for tenant := range q.Tenants() {
+				if tenant == "" {
+					continue
+				}
+				markers := rp.tenantMarkers(tenant)
+				if len(markers) == 0 {
+					continue
+				}
+				if !rp.tenantShards(tx, q, tenant, markers, yield) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (rp *DatasetRetentionPolicy) tenantShards(
+	tx *bbolt.Tx,
+	q *indexstore.PartitionQuery,
+	tenant string,
+	markers []datasetRetentionMarker,
+	yield func(*indexstore.Shard, []datasetRetentionMarker) bool,
+) bool {
+	var lastMarker time.Time
+	for _, m := range markers {
+		if m.timestamp.After(lastMarker) {
+			lastMarker = m.timestamp
+		}
+	}
+	for s := range q.Shards(tenant) {
+		if !time.UnixMilli(s.ShardIndex.MinTime).Before(lastMarker) {
+			// No blocks in the shard may have passed the retention period.
+			continue
+		}
+		shard, err := indexstore.NewIndexStore().LoadShard(tx, q.Partition, tenant, s.Shard)
+		if err != nil || shard == nil {
+			level.Warn(rp.logger).Log("msg", "cannot load shard, skipping", "tenant", tenant, "shard", s.Shard, "err", err)
+			continue
+		}
+		if !yield(shard, markers) {
+			return false
+		}
+	}
+	return true
+}
+
+func (rp *DatasetRetentionPolicy) createTombstones(tx *bbolt.Tx, shard *indexstore.Shard, markers []datasetRetentionMarker) {
+	blocks := shard.Blocks(tx)
+	if blocks == nil {
+		return
+	}
+	defer func() {
+		_ = blocks.Close()
+	}()
+	// Tombstones are created per compaction level.
+	expired := make(map[uint32][]string)
+	for blocks.Next() {
+		var md metastorev1.BlockMeta
+		if err := md.UnmarshalVT(blocks.At().Value); err != nil {
+			continue
+		}
+		if rp.blockExpired(shard.StringTable.Strings, &md, markers) {
+			expired[md.CompactionLevel] = append(expired[md.CompactionLevel], md.Id)
+		}
+	}
+	levels := make([]uint32, 0, len(expired))
+	for l := range expired {
+		levels = append(levels, l)
+	}
+	slices.Sort(levels)
+	for _, l := range levels {
+		ids := expired[l]
+		name := shard.TombstoneName() + "-retention-" + ids[0]
+		level.Debug(rp.logger).Log("msg", "creating tombstone", "name", name, "blocks", len(ids))
+		rp.tombstones = append(rp.tombstones, &metastorev1.Tombstones{
+			Blocks: &metastorev1.BlockTombstones{
+				Name:            name,
+				Shard:           shard.Shard,
+				Tenant:          shard.Tenant,
+				CompactionLevel: l,
+				Blocks:          ids,
+			},
+		})
+	}
+}
+
+func (rp *DatasetRetentionPolicy) blockExpired(strings []string, md *metastorev1.BlockMeta, markers []datasetRetentionMarker) bool {
+	var datasets int
+	for _, ds := range md.Datasets {
+		if block.DatasetFormat(ds.Format) != block.DatasetFormat0 {
+			// The tenant-wide dataset index is only
+			// relevant if there are other datasets.
+			continue
+		}
+		expired, total := datasetExpired(strings, ds, markers)
+		if total == 0 || expired < total {
+			return false
+		}
+		datasets++
+	}
+	return datasets > 0
+}
+
+// blockPartiallyExpired reports whether any of the block datasets
+// includes a profile type that has passed the retention period.
+func blockPartiallyExpired(strings []string, md *metastorev1.BlockMeta, markers []datasetRetentionMarker) bool {
+	for _, ds := range md.Datasets {
+		if block.DatasetFormat(ds.Format) != block.DatasetFormat0 {
+			continue
+		}
+		if expired, _ := datasetExpired(strings, ds, markers); expired > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// datasetExpired returns the number of the dataset label sets that satisfy
+// the selector of a policy the dataset has passed the retention period of,
+// and the total number of the label sets considered.
+//
+// Only label sets of profile types are considered: the segment writer adds
+// label sets that annotate the dataset (e.g., __unsymbolized__), which do
+// not identify any data. If the dataset has no profile type label sets,
+// all the sets are considered.
+func datasetExpired(strings []string, ds *metastorev1.Dataset, markers []datasetRetentionMarker) (expired, total int) {
+	maxTime := time.UnixMilli(ds.MaxTime)
+	sets := make([][]int32, 0, 4)
+	pairs := metadata.LabelPairs(ds.Labels)
+	for pairs.Next() {
+		sets = append(sets, pairs.At())
+	}
+	profileTypes := slices.DeleteFunc(slices.Clone(sets), func(p []int32) bool {
+		_, ok := lookupLabel(strings, p, phlaremodel.LabelNameProfileType)
+		return !ok
+	})
+	if len(profileTypes) > 0 {
+		sets = profileTypes
+	}
+	for _, p := range sets {
+		for _, m := range markers {
+			if maxTime.Before(m.timestamp) && labelSetMatches(strings, p, m.matchers) {
+				expired++
+				break
+			}
+		}
+	}
+	return expired, len(sets)
+}
+
+// labelSetMatches reports whether the label set satisfies the matchers.
+// A label referred by a matcher must be present in the label set,
+// otherwise the set is considered not matching.
+func labelSetMatches(strings []string, pairs []int32, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		value, ok := lookupLabel(strings, pairs, m.Name)
+		if !ok || !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func lookupLabel(strings []string, pairs []int32, name string) (string, bool) {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		n, v := pairs[i], pairs[i+1]
+		if int(n) < len(strings) && strings[n] == name && int(v) < len(strings) {
+			return strings[v], true
+		}
+	}
+	return "", false
+}
+
+// DatasetRewritePlanner plans jobs that rewrite blocks of the top compaction
+// level in which some of the datasets or profile types have passed the
+// retention period: such blocks are not compacted again, and the data would
+// otherwise be kept until all the datasets of the block expire. The data is
+// dropped by the series tombstones of the dataset retention policies, which
+// are attached to the jobs at assignment.
+//
+// A job rewrites a single block. Blocks that are sources of other jobs in
+// the schedule are skipped.
+type DatasetRewritePlanner struct {
+	policy   *DatasetRetentionPolicy
+	jobs     rewrite.JobLister
+	minLevel uint32
+	maxJobs  int
+
+	planned []*raft_log.CompactionJobPlan
+}
+
+func NewDatasetRewritePlanner(
+	logger log.Logger,
+	overrides Overrides,
+	jobs rewrite.JobLister,
+	minLevel uint32,
+	maxJobs int,
+	gracePeriod time.Duration,
+	now time.Time,
+) *DatasetRewritePlanner {
+	return &DatasetRewritePlanner{
+		policy:   NewDatasetRetentionPolicy(logger, overrides, maxJobs, gracePeriod, now),
+		jobs:     jobs,
+		minLevel: minLevel,
+		maxJobs:  maxJobs,
+	}
+}
+
+func (p *DatasetRewritePlanner) CreateJobs(tx *bbolt.Tx, partitions iter.Seq[indexstore.Partition]) []*raft_log.CompactionJobPlan {
+	p.planned = p.planned[:0]
+	active, err := rewrite.ListActiveBlocks(tx, p.jobs)
+	if err != nil {
+		level.Error(p.policy.logger).Log("msg", "failed to list compaction jobs", "err", err)
+		return nil
+	}
+	for shard, markers := range p.policy.shards(tx, partitions) {
+		if len(p.planned) >= p.maxJobs {
+			break
+		}
+		p.createJobs(tx, shard, markers, active)
+	}
+	return p.planned
+}
+
+func (p *DatasetRewritePlanner) createJobs(
+	tx *bbolt.Tx,
+	shard *indexstore.Shard,
+	markers []datasetRetentionMarker,
+	active rewrite.ActiveBlocks,
+) {
+	blocks := shard.Blocks(tx)
+	if blocks == nil {
+		return
+	}
+	defer func() {
+		_ = blocks.Close()
+	}()
+	for blocks.Next() && len(p.planned) < p.maxJobs {
+		var md metastorev1.BlockMeta
+		if err := md.UnmarshalVT(blocks.At().Value); err != nil {
+			continue
+		}
+		if md.CompactionLevel < p.minLevel || active.Contains(md.Id) {
+			continue
+		}
+		strings := shard.StringTable.Strings
+		if p.policy.blockExpired(strings, &md, markers) || !blockPartiallyExpired(strings, &md, markers) {
+			// Expired blocks are deleted by the dataset retention policy.
+			continue
+		}
+		job := &raft_log.CompactionJobPlan{
+			Tenant:          shard.Tenant,
+			Shard:           shard.Shard,
+			CompactionLevel: md.CompactionLevel,
+			SourceBlocks:    []string{md.Id},
+			Rewrite:         true,
+		}
+		job.Name = rewrite.JobName(job)
+		p.planned = append(p.planned, job)
+		level.Debug(p.policy.logger).Log("msg", "planned rewrite job", "job", job.Name)
+	}
+}
@@ -0,0 +1,281 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
+	"github.com/grafana/pyroscope/v2/pkg/test"
+)
+
+func TestDatasetRetentionPolicy(t *testing.T) {
+	now := test.Time("2024-01-01T00:00:00Z")
+	overrides := &mockOverrides{
+		defaultConfig: Config{
+			DatasetRetention: []DatasetRetention{
+				{Selector: `{service_name="dev-app"}`, RetentionPeriod: model.Duration(24 * time.Hour)},
+			},
+		},
+		overrides: map[string]Config{
+			// No dataset retention policies.
+			"tenant-2": {},
+		},
+	}
+
+	type dataset struct {
+		name    string
+		maxTime time.Time
+	}
+	type testBlock struct {
+		tenant    string
+		createdAt time.Time
+		datasets  []dataset
+	}
+
+	blocks := []testBlock{
+		{
+			tenant:    "tenant-1",
+			createdAt: now.Add(-48 * time.Hour),
+			datasets:  []dataset{{name: "dev-app", maxTime: now.Add(-48 * time.Hour)}},
+		},
+		{
+			tenant:    "tenant-1",
+			createdAt: now.Add(-48 * time.Hour),
+			datasets: []dataset{
+				{name: "dev-app", maxTime: now.Add(-48 * time.Hour)},
+				{name: "prod-app", maxTime: now.Add(-48 * time.Hour)},
+			},
+		},
+		{
+			tenant:    "tenant-1",
+			createdAt: now.Add(-2 * time.Hour),
+			datasets:  []dataset{{name: "dev-app", maxTime: now.Add(-2 * time.Hour)}},
+		},
+		{
+			tenant:    "tenant-2",
+			createdAt: now.Add(-48 * time.Hour),
+			datasets:  []dataset{{name: "dev-app", maxTime: now.Add(-48 * time.Hour)}},
+		},
+	}
+
+	db := test.BoltDB(t)
+	store := indexstore.NewIndexStore()
+	require.NoError(t, db.Update(store.CreateBuckets))
+	defer db.Close()
+
+	const partitionDuration = 6 * time.Hour
+	ids := make([]string, len(blocks))
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		for i, b := range blocks {
+			strings := metadata.NewStringTable()
+			md := &metastorev1.BlockMeta{
+				Id:              test.ULID(b.createdAt.Format(time.RFC3339)),
+				Tenant:          strings.Put(b.tenant),
+				Shard:           1,
+				CompactionLevel: 1,
+				MinTime:         b.createdAt.Add(-time.Hour).UnixMilli(),
+				MaxTime:         b.createdAt.UnixMilli(),
+			}
+			for _, ds := range b.datasets {
+				md.Datasets = append(md.Datasets, &metastorev1.Dataset{
+					Tenant:  md.Tenant,
+					Name:    strings.Put(ds.name),
+					MaxTime: ds.maxTime.UnixMilli(),
+					Labels: metadata.NewLabelBuilder(strings).
+						WithLabelSet("service_name", ds.name, "__profile_type__", "cpu").
+						Build(),
+				})
+			}
+			md.StringTable = strings.Strings
+			ids[i] = md.Id
+			p := indexstore.NewPartition(b.createdAt.Truncate(partitionDuration), partitionDuration)
+			s, err := store.LoadShard(tx, p, b.tenant, 1)
+			require.NoError(t, err)
+			if s == nil {
+				s = indexstore.NewShard(p, b.tenant, 1)
+			}
+			require.NoError(t, s.Store(tx, md))
+		}
+		return nil
+	}))
+
+	policy := NewDatasetRetentionPolicy(log.NewNopLogger(), overrides, 10, time.Hour, now)
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		tombstones := policy.CreateTombstones(tx, store.Partitions(tx))
+		require.Len(t, tombstones, 1)
+		b := tombstones[0].Blocks
+		require.NotNil(t, b)
+		assert.Equal(t, "tenant-1", b.Tenant)
+		assert.Equal(t, uint32(1), b.Shard)
+		assert.Equal(t, uint32(1), b.CompactionLevel)
+		assert.Equal(t, []string{ids[0]}, b.Blocks)
+		return nil
+	}))
+}
+
+type jobsMock []*raft_log.CompactionJobPlan
+
+func (m jobsMock) ListJobPlans(*bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan] {
+	return iter.NewSliceIterator(m)
+}
+
+func TestDatasetRewritePlanner(t *testing.T) {
+	now := test.Time("2024-01-01T00:00:00Z")
+	overrides := &mockOverrides{
+		defaultConfig: Config{
+			DatasetRetention: []DatasetRetention{
+				{Selector: `{service_name="dev-app"}`, RetentionPeriod: model.Duration(24 * time.Hour)},
+				{Selector: `{service_name="app", __profile_type__="memory"}`, RetentionPeriod: model.Duration(24 * time.Hour)},
+			},
+		},
+	}
+
+	type dataset struct {
+		name         string
+		profileTypes []string
+	}
+	type testBlock struct {
+		level    uint32
+		datasets []dataset
+	}
+
+	blocks := []testBlock{
+		// Partially expired: rewritten.
+		{level: 2, datasets: []dataset{{"dev-app", []string{"cpu"}}, {"prod-app", []string{"cpu"}}}},
+		// Expired: deleted.
+		{level: 2, datasets: []dataset{{"dev-app", []string{"cpu", "memory"}}}},
+		// Not expired.
+		{level: 2, datasets: []dataset{{"prod-app", []string{"cpu"}}}},
+		// Profile type expired: rewritten.
+		{level: 2, datasets: []dataset{{"app", []string{"cpu", "memory"}}}},
+		// All profile types expired: deleted.
+		{level: 2, datasets: []dataset{{"app", []string{"memory"}}}},
+		// Partially expired, but not at the top level.
+		{level: 1, datasets: []dataset{{"dev-app", []string{"cpu"}}, {"prod-app", []string{"cpu"}}}},
+		// Partially expired, but is a source of a job.
+		{level: 2, datasets: []dataset{{"dev-app", []string{"cpu"}}, {"prod-app", []string{"cpu"}}}},
+	}
+
+	db := test.BoltDB(t)
+	store := indexstore.NewIndexStore()
+	require.NoError(t, db.Update(store.CreateBuckets))
+	defer db.Close()
+
+	const partitionDuration = 6 * time.Hour
+	createdAt := now.Add(-48 * time.Hour)
+	p := indexstore.NewPartition(createdAt.Truncate(partitionDuration), partitionDuration)
+	ids := make([]string, len(blocks))
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		s := indexstore.NewShard(p, "tenant-1", 1)
+		for i, b := range blocks {
+			strings := metadata.NewStringTable()
+			md := &metastorev1.BlockMeta{
+				Id:              test.ULID(createdAt.Add(time.Duration(i) * time.Second).Format(time.RFC3339)),
+				Tenant:          strings.Put("tenant-1"),
+				Shard:           1,
+				CompactionLevel: b.level,
+				MinTime:         createdAt.Add(-time.Hour).UnixMilli(),
+				MaxTime:         createdAt.UnixMilli(),
+			}
+			for _, ds := range b.datasets {
+				lb := metadata.NewLabelBuilder(strings)
+				for _, pt := range ds.profileTypes {
+					lb.WithLabelSet("service_name", ds.name, "__profile_type__", pt)
+				}
+				// Annotation label sets do not identify any data.
+				lb.WithLabelSet("service_name", ds.name, metadata.LabelNameUnsymbolized, "true")
+				md.Datasets = append(md.Datasets, &metastorev1.Dataset{
+					Tenant:  md.Tenant,
+					Name:    strings.Put(ds.name),
+					MaxTime: createdAt.UnixMilli(),
+					Labels:  lb.Build(),
+				})
+			}
+			md.StringTable = strings.Strings
+			ids[i] = md.Id
+			require.NoError(t, s.Store(tx, md))
+		}
+		return nil
+	}))
+
+	jobs := jobsMock{{Name: "active", SourceBlocks: []string{ids[6]}}}
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		policy := NewDatasetRetentionPolicy(log.NewNopLogger(), overrides, 10, time.Hour, now)
+		tombstones := policy.CreateTombstones(tx, store.Partitions(tx))
+		require.Len(t, tombstones, 1)
+		assert.Equal(t, []string{ids[1], ids[4]}, tombstones[0].Blocks.Blocks)
+
+		planner := NewDatasetRewritePlanner(log.NewNopLogger(), overrides, jobs, 2, 10, time.Hour, now)
+		planned := planner.CreateJobs(tx, store.Partitions(tx))
+		require.Len(t, planned, 2)
+		for i, id := range []string{ids[0], ids[3]} {
+			assert.Equal(t, []string{id}, planned[i].SourceBlocks)
+			assert.Equal(t, "tenant-1", planned[i].Tenant)
+			assert.Equal(t, uint32(1), planned[i].Shard)
+			assert.Equal(t, uint32(2), planned[i].CompactionLevel)
+			assert.True(t, planned[i].Rewrite)
+			assert.Zero(t, planned[i].TargetShard)
+		}
+
+		planner = NewDatasetRewritePlanner(log.NewNopLogger(), overrides, jobs, 2, 1, time.Hour, now)
+		assert.Len(t, planner.CreateJobs(tx, store.Partitions(tx)), 1)
+		return nil
+	}))
+}
+
+func TestDatasetRetentionTombstones(t *testing.T) {
+	now := test.Time("2024-01-01T00:00:00Z")
+	overrides := &mockOverrides{
+		defaultConfig: Config{
+			DatasetRetention: []DatasetRetention{
+				{Selector: `{service_name="dev-app"}`, RetentionPeriod: model.Duration(24 * time.Hour)},
+			},
+		},
+		overrides: map[string]Config{
+			"tenant-2": {},
+		},
+	}
+
+	policies := NewDatasetRetentionTombstones(overrides, now)
+	tombstones := policies.SeriesTombstones("tenant-1")
+	require.Len(t, tombstones, 1)
+	assert.Equal(t, "tenant-1", tombstones[0].Tenant)
+	assert.Equal(t, `{service_name="dev-app"}`, tombstones[0].LabelSelector)
+	assert.Equal(t, now.Add(-24*time.Hour).UnixMilli(), tombstones[0].EndTime)
+
+	assert.Empty(t, policies.SeriesTombstones("tenant-2"))
+	assert.Empty(t, policies.SeriesTombstones(""))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{DatasetRetention: []DatasetRetention{
+		{Selector: `{service_name=~"checkout.*"}`, RetentionPeriod: model.Duration(time.Hour)},
+		{Selector: `{service_name="a", __profile_type__=~"memory:.*"}`, RetentionPeriod: model.Duration(time.Hour)},
+	}}
+	assert.NoError(t, valid.Validate())
+
+	invalidLabel := Config{DatasetRetention: []DatasetRetention{
+		{Selector: `{service_name="a", env="dev"}`, RetentionPeriod: model.Duration(time.Hour)},
+	}}
+	assert.Error(t, invalidLabel.Validate())
+
+	invalidSelector := Config{DatasetRetention: []DatasetRetention{
+		{Selector: `{service_name=`, RetentionPeriod: model.Duration(time.Hour)},
+	}}
+	assert.Error(t, invalidSelector.Validate())
+
+	invalidPeriod := Config{DatasetRetention: []DatasetRetention{
+		{Selector: `{service_name="a"}`},
+	}}
+	assert.Error(t, invalidPeriod.Validate())
+}
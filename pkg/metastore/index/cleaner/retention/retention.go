@@ -24,7 +24,8 @@ type Policy interface {
 }
 
 type Config struct {
-	RetentionPeriod model.Duration `yaml:"retention_period" doc:"hidden"`
+	RetentionPeriod  model.Duration     `yaml:"retention_period" doc:"hidden"`
+	DatasetRetention []DatasetRetention `yaml:"dataset_retention" doc:"hidden"`
 }
 
 type Overrides interface {
@@ -18,6 +18,7 @@ import (
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index/backup"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/dlq"
 	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
@@ -33,6 +34,7 @@ type Config struct {
 
 	Cleaner  cleaner.Config `yaml:",inline"`
 	Recovery dlq.Config     `yaml:",inline"`
+	Backup   backup.Config  `yaml:",inline"`
 
 	partitionDuration     time.Duration
 	queryLookaroundPeriod time.Duration
@@ -63,6 +65,7 @@ var DefaultConfig = Config{
 func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	cfg.Recovery.RegisterFlagsWithPrefix(prefix, f)
 	cfg.Cleaner.RegisterFlagsWithPrefix(prefix, f)
+	cfg.Backup.RegisterFlagsWithPrefix(prefix, f)
 	f.IntVar(&cfg.ShardCacheSize, prefix+"shard-cache-size", DefaultConfig.ShardCacheSize, "Maximum number of shards to keep in memory")
 	f.IntVar(&cfg.BlockWriteCacheSize, prefix+"block-write-cache-size", DefaultConfig.BlockWriteCacheSize, "Maximum number of written blocks to keep in memory")
 	f.IntVar(&cfg.BlockReadCacheSize, prefix+"block-read-cache-size", DefaultConfig.BlockReadCacheSize, "Maximum number of read blocks to keep in memory")
@@ -150,7 +153,11 @@ func (i *Index) ReplaceBlocks(tx *bbolt.Tx, compacted *metastorev1.CompactedBloc
 			return err
 		}
 	}
-	for p, list := range i.partitionedList(compacted.SourceBlocks) {
+	return i.DeleteBlocks(tx, compacted.SourceBlocks)
+}
+
+func (i *Index) DeleteBlocks(tx *bbolt.Tx, blocks *metastorev1.BlockList) error {
+	for p, list := range i.partitionedList(blocks) {
 		err := i.shards.update(tx, p, list.Tenant, list.Shard, func(s *indexstore.Shard) error {
 			if err := s.Delete(tx, list.Blocks...); err != nil {
 				return err
@@ -191,6 +198,60 @@ func (i *Index) Partitions(tx *bbolt.Tx) iter.Seq[indexstore.Partition] {
 	return i.store.Partitions(tx)
 }
 
+// Blocks iterates over all the blocks in the index. Each metadata entry
+// has its own string table. The shards are loaded from the store bypassing
+// the cache, therefore the function is not intended for the query path.
+func (i *Index) Blocks(tx *bbolt.Tx) iter.Seq2[*metastorev1.BlockMeta, error] {
+	return func(yield func(*metastorev1.BlockMeta, error) bool) {
+		for p := range i.store.Partitions(tx) {
+			q := p.Query(tx)
+			if q == nil {
+				continue
+			}
+			for tenant := range q.Tenants() {
+				for shard := range q.Shards(tenant) {
+					for md, err := range i.ShardBlocks(tx, p, tenant, shard.Shard) {
+						if !yield(md, err) || err != nil {
+							return
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// ShardBlocks iterates over the blocks of the tenant shard in the partition.
+// Similarly to Blocks, the shard is loaded from the store bypassing the cache.
+func (i *Index) ShardBlocks(tx *bbolt.Tx, p indexstore.Partition, tenant string, shard uint32) iter.Seq2[*metastorev1.BlockMeta, error] {
+	return func(yield func(*metastorev1.BlockMeta, error) bool) {
+		s, err := i.store.LoadShard(tx, p, tenant, shard)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if s == nil {
+			return
+		}
+		blocks := s.Blocks(tx)
+		if blocks == nil {
+			return
+		}
+		for blocks.Next() {
+			kv := blocks.At()
+			var md metastorev1.BlockMeta
+			if err = md.UnmarshalVT(kv.Value); err != nil {
+				yield(nil, fmt.Errorf("failed to decode block %s metadata: %w", kv.Key, err))
+				return
+			}
+			s.StringTable.Export(&md)
+			if !yield(&md, nil) {
+				return
+			}
+		}
+	}
+}
+
 func (i *Index) DeleteShard(tx *bbolt.Tx, key indexstore.Partition, tenant string, shard uint32) error {
 	if err := i.store.DeleteShard(tx, key, tenant, shard); err != nil {
 		return err
@@ -277,6 +338,38 @@ func (i *Index) QueryMetadataLabels(tx *bbolt.Tx, ctx context.Context, query Met
 	return l, nil
 }
 
+// QueryMetadataLabelCounts is like QueryMetadataLabels, but it also returns
+// the number of datasets labeled with each of the label sets: the counts
+// are returned in the same order as the labels.
+func (i *Index) QueryMetadataLabelCounts(tx *bbolt.Tx, ctx context.Context, query MetadataQuery) ([]*typesv1.Labels, []int64, error) {
+	q, err := newMetadataQuery(i, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	q.countDatasets = true
+	c, err := newMetadataLabelQuerier(tx, q).queryLabels(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	type labelCount struct {
+		labels *typesv1.Labels
+		count  int64
+	}
+	var counts []labelCount
+	for l, n := range c.Counts() {
+		counts = append(counts, labelCount{labels: l, count: n})
+	}
+	slices.SortFunc(counts, func(a, b labelCount) int {
+		return model.CompareLabels(a.labels, b.labels)
+	})
+	l := make([]*typesv1.Labels, len(counts))
+	n := make([]int64, len(counts))
+	for j, c := range counts {
+		l[j], n[j] = c.labels, c.count
+	}
+	return l, n, nil
+}
+
 func (i *Index) partitionedList(list *metastorev1.BlockList) map[indexstore.Partition]*metastorev1.BlockList {
 	partitions := make(map[indexstore.Partition]*metastorev1.BlockList)
 	for _, b := range list.Blocks {
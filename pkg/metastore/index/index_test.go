@@ -9,6 +9,7 @@ import (
 	"go.etcd.io/bbolt"
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
 	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
 	"github.com/grafana/pyroscope/v2/pkg/test"
 	"github.com/grafana/pyroscope/v2/pkg/util"
@@ -411,3 +412,63 @@ func TestIndex_GetTenantStats(t *testing.T) {
 	}))
 
 }
+
+func TestIndex_Blocks(t *testing.T) {
+	db := test.BoltDB(t)
+	idx := NewIndex(util.Logger, NewStore(), DefaultConfig, nil)
+	require.NoError(t, db.Update(idx.Init))
+
+	blocks := []*metastorev1.BlockMeta{
+		{
+			Id:          test.ULID("2024-09-11T07:00:00.001Z"),
+			Tenant:      1,
+			Shard:       1,
+			CreatedBy:   2,
+			Datasets:    []*metastorev1.Dataset{{Tenant: 1, Name: 3}},
+			StringTable: []string{"", "tenant-a", "ingester", "service-a"},
+		},
+		{
+			Id:          test.ULID("2024-09-11T07:00:00.002Z"),
+			Tenant:      1,
+			Shard:       1,
+			CreatedBy:   2,
+			Datasets:    []*metastorev1.Dataset{{Tenant: 1, Name: 3}},
+			StringTable: []string{"", "tenant-a", "ingester", "service-b"},
+		},
+		{
+			Id:          test.ULID("2024-09-12T07:00:00.001Z"),
+			Shard:       2,
+			CreatedBy:   1,
+			StringTable: []string{"", "segment-writer"},
+		},
+	}
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		for _, b := range blocks {
+			if err := idx.InsertBlock(tx, b.CloneVT()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	actual := make(map[string]*metastorev1.BlockMeta)
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		for md, err := range idx.Blocks(tx) {
+			if err != nil {
+				return err
+			}
+			actual[md.Id] = md
+		}
+		return nil
+	}))
+	require.Len(t, actual, len(blocks))
+	for _, b := range blocks {
+		md := actual[b.Id]
+		require.NotNil(t, md)
+		assert.Equal(t, metadata.Tenant(b), metadata.Tenant(md))
+		assert.Equal(t, b.StringTable[b.CreatedBy], md.StringTable[md.CreatedBy])
+		for i, ds := range b.Datasets {
+			assert.Equal(t, b.StringTable[ds.Name], md.StringTable[md.Datasets[i].Name])
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"go.etcd.io/bbolt"
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block"
 	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
 	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
@@ -32,6 +33,9 @@ type MetadataQuery struct {
 	EndTime   time.Time
 	Tenant    []string
 	Labels    []string
+	// Label sets of the deleted series are excluded
+	// from the metadata labels query results.
+	SeriesTombstones []*metastorev1.SeriesTombstone
 }
 
 func (q *MetadataQuery) String() string {
@@ -50,6 +54,10 @@ type metadataQuery struct {
 	matchers  []*labels.Matcher
 	labels    []string
 	index     *Index
+	// Tombstones of the series excluded from the labels query.
+	tombstones []block.SeriesTombstone
+	// Whether the datasets labeled with each label set are counted.
+	countDatasets bool
 }
 
 func newMetadataQuery(index *Index, query MetadataQuery) (*metadataQuery, error) {
@@ -60,12 +68,17 @@ func newMetadataQuery(index *Index, query MetadataQuery) (*metadataQuery, error)
 	if err != nil {
 		return nil, &InvalidQueryError{Query: query, Err: fmt.Errorf("failed to parse label matcher: %w", err)}
 	}
+	tombstones, err := block.ParseSeriesTombstones(query.SeriesTombstones...)
+	if err != nil {
+		return nil, err
+	}
 	q := &metadataQuery{
-		startTime: query.StartTime,
-		endTime:   query.EndTime,
-		index:     index,
-		matchers:  matchers,
-		labels:    query.Labels,
+		startTime:  query.StartTime,
+		endTime:    query.EndTime,
+		index:      index,
+		matchers:   matchers,
+		labels:     query.Labels,
+		tombstones: tombstones,
 	}
 	q.buildTenantMap(query.Tenant)
 	return q, nil
@@ -145,6 +158,14 @@ func (q *blockMetadataQuerier) collectBlockMetadata(s *store.Shard) error {
 			continue
 		}
 		md := q.shards.index.blocks.getOrCreate(s, kv)
+		if md.Replica > 0 {
+			// Only the primary replica is queried: replicas hold
+			// duplicates that are removed at compaction. All the
+			// segments of a replication window are compacted in one
+			// job, therefore profiles the primary replica failed to
+			// store become visible once the window is compacted.
+			continue
+		}
 		if m := q.collectMatched(s.StringTable, matcher, md); m != nil {
 			q.metas = append(q.metas, m)
 		}
@@ -202,6 +223,7 @@ func cloneBlockMetadataForQuery(b *metastorev1.BlockMeta) *metastorev1.BlockMeta
 		CreatedBy:       b.CreatedBy,
 		MetadataOffset:  b.MetadataOffset,
 		Size:            b.Size,
+		StorageTier:     b.StorageTier,
 		//	Datasets:        b.Datasets,
 		//	StringTable:     b.StringTable,
 	}
@@ -232,6 +254,7 @@ type metadataLabelQuerier struct {
 	query  *metadataQuery
 	shards *shardIterator
 	labels *metadata.LabelsCollector
+	live   []int32
 }
 
 func (q *metadataLabelQuerier) queryLabels(ctx context.Context) (*metadata.LabelsCollector, error) {
@@ -265,12 +288,21 @@ func (q *metadataLabelQuerier) collectLabels(s *store.Shard) error {
 			continue
 		}
 		md := q.shards.index.blocks.getOrCreate(s, kv)
+		if md.Replica > 0 {
+			continue
+		}
 		for _, ds := range md.Datasets {
 			if _, ok := q.query.tenantMap[s.StringTable.Lookup(ds.Tenant)]; !ok {
 				continue
 			}
-			if q.query.overlapsUnixMilli(ds.MinTime, ds.MaxTime) {
-				matcher.Matches(ds.Labels)
+			if !q.query.overlapsUnixMilli(ds.MinTime, ds.MaxTime) {
+				continue
+			}
+			ls := q.liveLabels(s, ds)
+			if q.query.countDatasets {
+				q.labels.CountMatches(matcher, ls)
+			} else {
+				matcher.Matches(ls)
 			}
 		}
 	}
@@ -278,6 +310,57 @@ func (q *metadataLabelQuerier) collectLabels(s *store.Shard) error {
 	return nil
 }
 
+// liveLabels returns the dataset label sets, excluding those of the
+// series deleted within the queried time range of the dataset. A label
+// set is only excluded if it includes all the labels the tombstone
+// selects by: otherwise, it may refer to series not deleted.
+func (q *metadataLabelQuerier) liveLabels(s *store.Shard, ds *metastorev1.Dataset) []int32 {
+	if len(q.query.tombstones) == 0 {
+		return ds.Labels
+	}
+	tenant := s.StringTable.Lookup(ds.Tenant)
+	start := max(time.UnixMilli(ds.MinTime).UnixNano(), q.query.startTime.UnixNano())
+	end := min(time.UnixMilli(ds.MaxTime).UnixNano(), q.query.endTime.UnixNano())
+	var tombstones []*block.SeriesTombstone
+	for i := range q.query.tombstones {
+		t := &q.query.tombstones[i]
+		if t.Tenant == tenant && t.Covers(start) && t.Covers(end) {
+			tombstones = append(tombstones, t)
+		}
+	}
+	if len(tombstones) == 0 {
+		return ds.Labels
+	}
+	q.live = q.live[:0]
+	pairs := metadata.LabelPairs(ds.Labels)
+	for pairs.Next() {
+		p := pairs.At()
+		if !slices.ContainsFunc(tombstones, func(t *block.SeriesTombstone) bool {
+			return deletesLabelSet(t, s.StringTable.Strings, p)
+		}) {
+			q.live = append(q.live, int32(len(p)/2))
+			q.live = append(q.live, p...)
+		}
+	}
+	return q.live
+}
+
+func deletesLabelSet(t *block.SeriesTombstone, strings []string, pairs []int32) bool {
+	for _, m := range t.Matchers {
+		var found bool
+		for k := 0; k < len(pairs); k += 2 {
+			if strings[pairs[k]] == m.Name {
+				found = m.Matches(strings[pairs[k+1]])
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 type shardIterator struct {
 	tx        *bbolt.Tx
 	index     *Index
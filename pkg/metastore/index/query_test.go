@@ -277,6 +277,22 @@ func TestIndex_Query(t *testing.T) {
 			}}}, labels)
 		})
 
+		t.Run("LabelCounts", func(t *testing.T) {
+			labels, counts, err := index.QueryMetadataLabelCounts(tx, ctx, MetadataQuery{
+				Expr:      "{}",
+				StartTime: time.UnixMilli(minT),
+				EndTime:   time.UnixMilli(maxT),
+				Tenant:    []string{"tenant-a", "tenant-b"},
+				Labels:    []string{model.LabelNameServiceName},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []*typesv1.Labels{
+				{Labels: []*typesv1.LabelPair{{Name: model.LabelNameServiceName, Value: "dataset-a"}}},
+				{Labels: []*typesv1.LabelPair{{Name: model.LabelNameServiceName, Value: "dataset-b"}}},
+			}, labels)
+			assert.Equal(t, []int64{3, 1}, counts)
+		})
+
 		t.Run("LabelsTenantFilter", func(t *testing.T) {
 			labels, err := index.QueryMetadataLabels(tx, ctx, MetadataQuery{
 				Expr:      "{}",
@@ -403,6 +419,232 @@ func TestIndex_QueryMetadata_StaleReadCacheReloadsShard(t *testing.T) {
 	})
 }
 
+func TestIndex_Query_ReplicationFactor(t *testing.T) {
+	db := test.BoltDB(t)
+	idx := NewIndex(util.Logger, NewStore(), DefaultConfig, nil)
+	require.NoError(t, db.Update(idx.Init))
+
+	const tenant = "tenant-a"
+	minT := test.UnixMilli("2024-09-23T08:00:00.000Z")
+	maxT := test.UnixMilli("2024-09-23T09:00:00.000Z")
+
+	// With the replication factor of 2, each profile is written
+	// to two segments of the same shard: the primary replica and
+	// its copy.
+	segment := func(id string, replica uint32) *metastorev1.BlockMeta {
+		return &metastorev1.BlockMeta{
+			Id:      id,
+			Tenant:  1,
+			Shard:   1,
+			Replica: replica,
+			MinTime: minT,
+			MaxTime: maxT,
+			Datasets: []*metastorev1.Dataset{{
+				Tenant:  1,
+				Name:    2,
+				MinTime: minT,
+				MaxTime: maxT,
+				Labels:  []int32{1, 3, 2},
+			}},
+			StringTable: []string{"", tenant, "service-a", "service_name"},
+		}
+	}
+	primary := segment(test.ULID("2024-09-23T08:00:00.001Z"), 0)
+	replica := segment(test.ULID("2024-09-23T08:00:00.002Z"), 1)
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		if err := idx.InsertBlock(tx, primary.CloneVT()); err != nil {
+			return err
+		}
+		return idx.InsertBlock(tx, replica.CloneVT())
+	}))
+
+	query := MetadataQuery{
+		Expr:      `{service_name="service-a"}`,
+		StartTime: time.UnixMilli(minT),
+		EndTime:   time.UnixMilli(maxT),
+		Tenant:    []string{tenant},
+		Labels:    []string{"service_name"},
+	}
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		found, err := idx.QueryMetadata(tx, context.Background(), query)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, primary.Id, found[0].Id)
+
+		_, counts, err := idx.QueryMetadataLabelCounts(tx, context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1}, counts)
+
+		// Replicas are still available to compaction.
+		found, err = idx.GetBlocks(tx, &metastorev1.BlockList{
+			Tenant: tenant,
+			Shard:  1,
+			Blocks: []string{primary.Id, replica.Id},
+		})
+		require.NoError(t, err)
+		assert.Len(t, found, 2)
+		return nil
+	}))
+}
+
+func TestIndex_Query_StorageTier(t *testing.T) {
+	db := test.BoltDB(t)
+	idx := NewIndex(util.Logger, NewStore(), DefaultConfig, nil)
+	require.NoError(t, db.Update(idx.Init))
+
+	const tenant = "tenant-a"
+	minT := test.UnixMilli("2024-09-23T08:00:00.000Z")
+	maxT := test.UnixMilli("2024-09-23T09:00:00.000Z")
+	md := &metastorev1.BlockMeta{
+		Id:              test.ULID("2024-09-23T08:00:00.001Z"),
+		Tenant:          1,
+		Shard:           1,
+		CompactionLevel: 3,
+		StorageTier:     metastorev1.StorageTier_STORAGE_TIER_COLD,
+		MinTime:         minT,
+		MaxTime:         maxT,
+		Datasets: []*metastorev1.Dataset{{
+			Tenant:  1,
+			Name:    2,
+			MinTime: minT,
+			MaxTime: maxT,
+			Labels:  []int32{1, 3, 2},
+		}},
+		StringTable: []string{"", tenant, "service-a", "service_name"},
+	}
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		return idx.InsertBlock(tx, md.CloneVT())
+	}))
+
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		found, err := idx.QueryMetadata(tx, context.Background(), MetadataQuery{
+			Expr:      `{service_name="service-a"}`,
+			StartTime: time.UnixMilli(minT),
+			EndTime:   time.UnixMilli(maxT),
+			Tenant:    []string{tenant},
+		})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, metastorev1.StorageTier_STORAGE_TIER_COLD, found[0].StorageTier)
+		return nil
+	}))
+}
+
+func TestIndex_QueryMetadataLabels_SeriesTombstones(t *testing.T) {
+	db := test.BoltDB(t)
+	idx := NewIndex(util.Logger, NewStore(), DefaultConfig, nil)
+	require.NoError(t, db.Update(idx.Init))
+
+	const tenant = "tenant-a"
+	minT := test.UnixMilli("2024-09-23T08:00:00.000Z")
+	maxT := test.UnixMilli("2024-09-23T09:00:00.000Z")
+	md := &metastorev1.BlockMeta{
+		Id:      test.ULID("2024-09-23T08:00:00.001Z"),
+		Tenant:  1,
+		Shard:   1,
+		MinTime: minT,
+		MaxTime: maxT,
+		Datasets: []*metastorev1.Dataset{{
+			Tenant:  1,
+			Name:    2,
+			MinTime: minT,
+			MaxTime: maxT,
+			// {service_name="service-a"}, {service_name="service-b"}
+			Labels: []int32{1, 3, 2, 1, 3, 4},
+		}},
+		StringTable: []string{"", tenant, "service-a", "service_name", "service-b"},
+	}
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		return idx.InsertBlock(tx, md.CloneVT())
+	}))
+
+	tombstone := func(selector string, start, end int64) *metastorev1.SeriesTombstone {
+		return &metastorev1.SeriesTombstone{
+			Name:          "tombstone",
+			Tenant:        tenant,
+			LabelSelector: selector,
+			StartTime:     start,
+			EndTime:       end,
+		}
+	}
+	serviceNames := func(ls []*typesv1.Labels) []string {
+		var names []string
+		for _, l := range ls {
+			for _, p := range l.Labels {
+				if p.Name == "service_name" {
+					names = append(names, p.Value)
+				}
+			}
+		}
+		return names
+	}
+
+	for _, tc := range []struct {
+		name       string
+		tombstones []*metastorev1.SeriesTombstone
+		expected   []string
+		counts     []int64
+	}{
+		{
+			name:     "no tombstones",
+			expected: []string{"service-a", "service-b"},
+			counts:   []int64{1, 1},
+		},
+		{
+			name:       "deleted series",
+			tombstones: []*metastorev1.SeriesTombstone{tombstone(`{service_name="service-b"}`, minT, maxT)},
+			expected:   []string{"service-a"},
+			counts:     []int64{1},
+		},
+		{
+			name:       "partially deleted series",
+			tombstones: []*metastorev1.SeriesTombstone{tombstone(`{service_name="service-b"}`, minT+1, maxT)},
+			expected:   []string{"service-a", "service-b"},
+			counts:     []int64{1, 1},
+		},
+		{
+			name:       "tombstone selects by labels not in the label set",
+			tombstones: []*metastorev1.SeriesTombstone{tombstone(`{service_name="service-b", pod="pod-a"}`, minT, maxT)},
+			expected:   []string{"service-a", "service-b"},
+			counts:     []int64{1, 1},
+		},
+		{
+			name: "another tenant",
+			tombstones: []*metastorev1.SeriesTombstone{{
+				Name:          "tombstone",
+				Tenant:        "tenant-b",
+				LabelSelector: `{service_name="service-b"}`,
+				StartTime:     minT,
+				EndTime:       maxT,
+			}},
+			expected: []string{"service-a", "service-b"},
+			counts:   []int64{1, 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			query := MetadataQuery{
+				Expr:             `{service_name=~".+"}`,
+				StartTime:        time.UnixMilli(minT),
+				EndTime:          time.UnixMilli(maxT),
+				Tenant:           []string{tenant},
+				Labels:           []string{"service_name"},
+				SeriesTombstones: tc.tombstones,
+			}
+			require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+				found, err := idx.QueryMetadataLabels(tx, context.Background(), query)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, serviceNames(found))
+
+				found, counts, err := idx.QueryMetadataLabelCounts(tx, context.Background(), query)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, serviceNames(found))
+				assert.Equal(t, tc.counts, counts)
+				return nil
+			}))
+		})
+	}
+}
+
 func TestIndex_QueryConcurrency(t *testing.T) {
 	const N = 10
 	for i := 0; i < N && !t.Failed(); i++ {
@@ -331,3 +331,34 @@ func TestIndexStore_DeleteShard(t *testing.T) {
 		assertPartition(t, db, store, p2, true)
 	})
 }
+
+func TestShard_Blocks(t *testing.T) {
+	db := test.BoltDB(t)
+	store := NewIndexStore()
+	require.NoError(t, db.Update(store.CreateBuckets))
+
+	p := NewPartition(test.Time("2024-01-01T10:00:00.000Z"), 6*time.Hour)
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		s := NewShard(p, testTenant, 1)
+		for _, id := range []string{"block1", "block2"} {
+			md := &metastorev1.BlockMeta{Id: id, Tenant: 1, Shard: 1, StringTable: []string{"", testTenant}}
+			if err := s.Store(tx, md); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// The shard index and string table are not included.
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		s, err := store.LoadShard(tx, p, testTenant, 1)
+		require.NoError(t, err)
+		blocks := s.Blocks(tx)
+		var keys []string
+		for blocks.Next() {
+			keys = append(keys, string(blocks.At().Key))
+		}
+		assert.Equal(t, []string{"block1", "block2"}, keys)
+		return nil
+	}))
+}
@@ -0,0 +1,225 @@
+package tombstones
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/oklog/ulid/v2"
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index/tombstones/store"
+)
+
+type SeriesTombstoneStore interface {
+	StoreSeriesTombstone(*bbolt.Tx, store.SeriesTombstoneEntry) error
+	DeleteSeriesTombstone(*bbolt.Tx, store.SeriesTombstoneEntry) error
+	ListEntries(tx *bbolt.Tx, tenant string) iter.Iterator[store.SeriesTombstoneEntry]
+	StoreBlocks(*bbolt.Tx, store.SeriesTombstoneEntry, ...store.SeriesTombstoneBlock) error
+	DeleteBlock(*bbolt.Tx, store.SeriesTombstoneEntry, string) error
+	HasBlock(*bbolt.Tx, store.SeriesTombstoneEntry, string) bool
+	ListBlocks(*bbolt.Tx, store.SeriesTombstoneEntry) iter.Iterator[store.SeriesTombstoneBlock]
+	CreateBuckets(*bbolt.Tx) error
+}
+
+// SeriesTombstones keeps track of the series deleted by label selector.
+//
+// Unlike the block and shard tombstones, series tombstones do not refer
+// to objects: the data remains in the blocks until they are rewritten by
+// compaction, and queries have to filter it out. The number of series
+// tombstones is expected to be small, therefore they are not cached in
+// memory, and are accessed within the transaction directly.
+//
+// A tombstone keeps track of the blocks that may include the deleted
+// series: the blocks present in the index at the time the tombstone is
+// created. Once all of them have been rewritten by compaction jobs that
+// were assigned after the tombstone had been created, or deleted, the
+// tombstone is removed.
+type SeriesTombstones struct {
+	store SeriesTombstoneStore
+}
+
+func NewSeriesTombstones(store SeriesTombstoneStore) *SeriesTombstones {
+	return &SeriesTombstones{store: store}
+}
+
+func NewSeriesTombstoneStore() *store.SeriesTombstoneStore {
+	return store.NewSeriesTombstoneStore()
+}
+
+// AddSeriesTombstone stores the tombstone along with the blocks that may
+// include the deleted series. The tombstone name and the creation time are
+// derived from the raft log entry.
+func (x *SeriesTombstones) AddSeriesTombstone(tx *bbolt.Tx, cmd *raft.Log, t *metastorev1.SeriesTombstone, blocks []*metastorev1.BlockMeta) error {
+	t.Name = "series-" + strconv.FormatUint(cmd.Index, 10)
+	t.CreatedAt = cmd.AppendedAt.UnixMilli()
+	entry := store.SeriesTombstoneEntry{
+		Index:           cmd.Index,
+		SeriesTombstone: t,
+	}
+	if err := x.store.StoreSeriesTombstone(tx, entry); err != nil {
+		return err
+	}
+	return x.store.StoreBlocks(tx, entry, seriesTombstoneBlocks(blocks)...)
+}
+
+// ReplaceBlocks updates the tombstones when a compaction job completes.
+// The token is the raft log index at which the job was assigned: if the
+// tombstone had been created before that, the deleted series have been
+// dropped from the compacted blocks. Otherwise, the compacted blocks may
+// still include the series and replace the source blocks in the tombstone.
+// Tombstones without blocks are deleted.
+func (x *SeriesTombstones) ReplaceBlocks(tx *bbolt.Tx, token uint64, compacted *metastorev1.CompactedBlocks) error {
+	// Source blocks of the anonymous tenant may include
+	// data of any tenant, therefore all tombstones are checked.
+	entries, err := x.listEntries(tx, compacted.SourceBlocks.Tenant)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		var replaced bool
+		for _, b := range compacted.SourceBlocks.Blocks {
+			if !x.store.HasBlock(tx, e, b) {
+				continue
+			}
+			if err = x.store.DeleteBlock(tx, e, b); err != nil {
+				return err
+			}
+			replaced = true
+		}
+		if replaced && e.Index >= token {
+			var blocks []*metastorev1.BlockMeta
+			for _, b := range compacted.NewBlocks {
+				if metadata.Tenant(b) == e.Tenant {
+					blocks = append(blocks, b)
+				}
+			}
+			if err = x.store.StoreBlocks(tx, e, seriesTombstoneBlocks(blocks)...); err != nil {
+				return err
+			}
+		}
+		if err = x.deleteApplied(tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBlocks removes the blocks deleted by the block and shard tombstones
+// from the series tombstones. Tombstones without blocks are deleted.
+func (x *SeriesTombstones) DeleteBlocks(tx *bbolt.Tx, tombstones ...*metastorev1.Tombstones) error {
+	if len(tombstones) == 0 {
+		return nil
+	}
+	entries, err := x.listEntries(tx, "")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		var deleted []string
+		blocks := x.store.ListBlocks(tx, e)
+		for blocks.Next() {
+			if b := blocks.At(); isBlockDeleted(b, tombstones) {
+				deleted = append(deleted, b.ID)
+			}
+		}
+		if err = blocks.Err(); err != nil {
+			return err
+		}
+		for _, b := range deleted {
+			if err = x.store.DeleteBlock(tx, e, b); err != nil {
+				return err
+			}
+		}
+		if err = x.deleteApplied(tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isBlockDeleted(b store.SeriesTombstoneBlock, tombstones []*metastorev1.Tombstones) bool {
+	for _, t := range tombstones {
+		if bt := t.Blocks; bt != nil && bt.Tenant == b.Tenant && bt.Shard == b.Shard {
+			if slices.Contains(bt.Blocks, b.ID) {
+				return true
+			}
+		}
+		if st := t.Shard; st != nil && st.Tenant == b.Tenant && st.Shard == b.Shard {
+			// Shard tombstones delete the blocks of the index partition,
+			// which is determined by the block ULID timestamp.
+			ts := int64(ulid.MustParse(b.ID).Time()) * int64(time.Millisecond)
+			if st.Timestamp <= ts && ts < st.Timestamp+st.Duration {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteApplied deletes the tombstone if it has no blocks left.
+func (x *SeriesTombstones) deleteApplied(tx *bbolt.Tx, e store.SeriesTombstoneEntry) error {
+	blocks := x.store.ListBlocks(tx, e)
+	pending := blocks.Next()
+	if err := blocks.Err(); err != nil {
+		return err
+	}
+	_ = blocks.Close()
+	if pending {
+		return nil
+	}
+	return x.store.DeleteSeriesTombstone(tx, e)
+}
+
+func (x *SeriesTombstones) listEntries(tx *bbolt.Tx, tenant string) ([]store.SeriesTombstoneEntry, error) {
+	entries := x.store.ListEntries(tx, tenant)
+	defer func() {
+		_ = entries.Close()
+	}()
+	var list []store.SeriesTombstoneEntry
+	for entries.Next() {
+		list = append(list, entries.At())
+	}
+	return list, entries.Err()
+}
+
+func seriesTombstoneBlocks(blocks []*metastorev1.BlockMeta) []store.SeriesTombstoneBlock {
+	list := make([]store.SeriesTombstoneBlock, len(blocks))
+	for i, b := range blocks {
+		list[i] = store.SeriesTombstoneBlock{
+			Tenant: metadata.Tenant(b),
+			Shard:  b.Shard,
+			ID:     b.Id,
+		}
+	}
+	return list
+}
+
+// ListSeriesTombstones returns tombstones of the tenant overlapping with
+// the time range (Unix epoch in milliseconds, inclusive). If the tenant
+// is empty, tombstones of all tenants are returned.
+func (x *SeriesTombstones) ListSeriesTombstones(tx *bbolt.Tx, tenant string, start, end int64) ([]*metastorev1.SeriesTombstone, error) {
+	entries := x.store.ListEntries(tx, tenant)
+	defer func() {
+		_ = entries.Close()
+	}()
+	var tombstones []*metastorev1.SeriesTombstone
+	for entries.Next() {
+		e := entries.At()
+		if e.StartTime <= end && start <= e.EndTime {
+			tombstones = append(tombstones, e.SeriesTombstone)
+		}
+	}
+	return tombstones, entries.Err()
+}
+
+func (x *SeriesTombstones) Init(tx *bbolt.Tx) error {
+	return x.store.CreateBuckets(tx)
+}
+
+// Restore is a no-op: the state is not kept in memory.
+func (x *SeriesTombstones) Restore(*bbolt.Tx) error { return nil }
@@ -0,0 +1,136 @@
+package tombstones
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/test"
+)
+
+func TestSeriesTombstones(t *testing.T) {
+	db := test.BoltDB(t)
+	ts := NewSeriesTombstones(NewSeriesTombstoneStore())
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, ts.Init(tx))
+
+	now := time.UnixMilli(1000)
+	for i, x := range []*metastorev1.SeriesTombstone{
+		{Tenant: "tenant-a", LabelSelector: `{service_name="a"}`, StartTime: 100, EndTime: 200},
+		{Tenant: "tenant-a", LabelSelector: `{service_name="b"}`, StartTime: 300, EndTime: 400},
+		{Tenant: "tenant-b", LabelSelector: `{service_name="a"}`, StartTime: 100, EndTime: 400},
+		// Tenant ID that is a prefix of another one.
+		{Tenant: "tenant", LabelSelector: `{service_name="a"}`, StartTime: 100, EndTime: 400},
+	} {
+		cmd := &raft.Log{Index: uint64(i + 1), AppendedAt: now}
+		require.NoError(t, ts.AddSeriesTombstone(tx, cmd, x, nil))
+	}
+	require.NoError(t, tx.Commit())
+
+	list := func(tenant string, start, end int64) []string {
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, tx.Rollback())
+		}()
+		tombstones, err := ts.ListSeriesTombstones(tx, tenant, start, end)
+		require.NoError(t, err)
+		names := make([]string, 0, len(tombstones))
+		for _, x := range tombstones {
+			assert.Equal(t, now.UnixMilli(), x.CreatedAt)
+			names = append(names, x.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"series-1", "series-2"}, list("tenant-a", 0, 1000))
+	assert.Equal(t, []string{"series-1"}, list("tenant-a", 0, 100))
+	assert.Equal(t, []string{"series-2"}, list("tenant-a", 400, 1000))
+	assert.Empty(t, list("tenant-a", 201, 299))
+	assert.Equal(t, []string{"series-4"}, list("tenant", 0, 1000))
+	assert.Equal(t, []string{"series-4", "series-1", "series-2", "series-3"}, list("", 0, 1000))
+	assert.Empty(t, list("tenant-c", 0, 1000))
+}
+
+func TestSeriesTombstones_Blocks(t *testing.T) {
+	db := test.BoltDB(t)
+	ts := NewSeriesTombstones(NewSeriesTombstoneStore())
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tx.Rollback())
+	}()
+	require.NoError(t, ts.Init(tx))
+
+	blockMeta := func(tenant string, shard uint32, id string) *metastorev1.BlockMeta {
+		md := &metastorev1.BlockMeta{Id: id, Shard: shard, StringTable: []string{""}}
+		if tenant != "" {
+			md.Tenant = 1
+			md.StringTable = append(md.StringTable, tenant)
+		}
+		return md
+	}
+
+	l0 := test.ULID("2024-09-23T08:00:00.000Z")
+	l1 := test.ULID("2024-09-23T08:00:01.000Z")
+	l1b := test.ULID("2024-09-23T09:00:00.000Z")
+	require.NoError(t, ts.AddSeriesTombstone(tx, &raft.Log{Index: 10},
+		&metastorev1.SeriesTombstone{Tenant: "tenant-a", StartTime: 100, EndTime: 200},
+		[]*metastorev1.BlockMeta{blockMeta("", 1, l0), blockMeta("tenant-a", 1, l1b)},
+	))
+
+	list := func() []string {
+		tombstones, err := ts.ListSeriesTombstones(tx, "", 0, 1000)
+		require.NoError(t, err)
+		names := make([]string, 0, len(tombstones))
+		for _, x := range tombstones {
+			names = append(names, x.Name)
+		}
+		return names
+	}
+
+	// The job was assigned before the tombstone was created:
+	// the compacted block may still include the deleted series.
+	require.NoError(t, ts.ReplaceBlocks(tx, 5, &metastorev1.CompactedBlocks{
+		SourceBlocks: &metastorev1.BlockList{Shard: 1, Blocks: []string{l0}},
+		NewBlocks: []*metastorev1.BlockMeta{
+			blockMeta("tenant-a", 1, l1),
+			blockMeta("tenant-b", 1, test.ULID("2024-09-23T08:00:02.000Z")),
+		},
+	}))
+	assert.Equal(t, []string{"series-10"}, list())
+
+	// The job was assigned after the tombstone was created.
+	require.NoError(t, ts.ReplaceBlocks(tx, 20, &metastorev1.CompactedBlocks{
+		SourceBlocks: &metastorev1.BlockList{Tenant: "tenant-a", Shard: 1, Blocks: []string{l1}},
+		NewBlocks:    []*metastorev1.BlockMeta{blockMeta("tenant-a", 1, test.ULID("2024-09-23T08:00:03.000Z"))},
+	}))
+	assert.Equal(t, []string{"series-10"}, list())
+
+	// The last block is deleted by the retention policy.
+	require.NoError(t, ts.DeleteBlocks(tx, &metastorev1.Tombstones{
+		Shard: &metastorev1.ShardTombstone{
+			Tenant:    "tenant-a",
+			Shard:     1,
+			Timestamp: test.Time("2024-09-23T08:00:00.000Z").UnixNano(),
+			Duration:  int64(time.Hour),
+		},
+	}))
+	assert.Equal(t, []string{"series-10"}, list())
+	require.NoError(t, ts.DeleteBlocks(tx, &metastorev1.Tombstones{
+		Shard: &metastorev1.ShardTombstone{
+			Tenant:    "tenant-a",
+			Shard:     1,
+			Timestamp: test.Time("2024-09-23T09:00:00.000Z").UnixNano(),
+			Duration:  int64(time.Hour),
+		},
+	}))
+	assert.Empty(t, list())
+}
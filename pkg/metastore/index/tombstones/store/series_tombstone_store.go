@@ -0,0 +1,209 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.etcd.io/bbolt"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/store"
+)
+
+var ErrInvalidSeriesTombstoneEntry = errors.New("invalid series tombstone entry")
+
+var (
+	seriesTombstoneBucketName      = []byte("series_tombstones")
+	seriesTombstoneBlockBucketName = []byte("series_tombstone_blocks")
+)
+
+// SeriesTombstoneEntry is stored under the key composed of the tenant ID,
+// and the raft log index of the command that created the tombstone:
+// tenant | 0x00 | index (big endian). Therefore, entries of a tenant
+// are iterated in the order of creation.
+type SeriesTombstoneEntry struct {
+	Index uint64
+	*metastorev1.SeriesTombstone
+}
+
+// SeriesTombstoneBlock is a block that may still include the series
+// deleted by the tombstone. Blocks are stored in a separate bucket, under
+// the tombstone key with the block ID appended; the value holds the shard
+// (big endian) and the tenant of the block.
+type SeriesTombstoneBlock struct {
+	Tenant string
+	Shard  uint32
+	ID     string
+}
+
+type SeriesTombstoneStore struct {
+	bucketName      []byte
+	blockBucketName []byte
+}
+
+func NewSeriesTombstoneStore() *SeriesTombstoneStore {
+	return &SeriesTombstoneStore{
+		bucketName:      seriesTombstoneBucketName,
+		blockBucketName: seriesTombstoneBlockBucketName,
+	}
+}
+
+func (s *SeriesTombstoneStore) CreateBuckets(tx *bbolt.Tx) error {
+	if _, err := tx.CreateBucketIfNotExists(s.bucketName); err != nil {
+		return err
+	}
+	_, err := tx.CreateBucketIfNotExists(s.blockBucketName)
+	return err
+}
+
+func (s *SeriesTombstoneStore) StoreSeriesTombstone(tx *bbolt.Tx, entry SeriesTombstoneEntry) error {
+	b := make([]byte, entry.SizeVT())
+	_, _ = entry.MarshalToSizedBufferVT(b)
+	return tx.Bucket(s.bucketName).Put(marshalSeriesTombstoneKey(entry.Tenant, entry.Index), b)
+}
+
+// DeleteSeriesTombstone deletes the tombstone and its blocks.
+func (s *SeriesTombstoneStore) DeleteSeriesTombstone(tx *bbolt.Tx, entry SeriesTombstoneEntry) error {
+	key := marshalSeriesTombstoneKey(entry.Tenant, entry.Index)
+	blocks := tx.Bucket(s.blockBucketName)
+	var keys [][]byte
+	cursor := store.NewCursorIter(blocks.Cursor())
+	cursor.Prefix = key
+	for cursor.Next() {
+		keys = append(keys, cursor.At().Key)
+	}
+	for _, k := range keys {
+		if err := blocks.Delete(k); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(s.bucketName).Delete(key)
+}
+
+func (s *SeriesTombstoneStore) StoreBlocks(tx *bbolt.Tx, entry SeriesTombstoneEntry, blocks ...SeriesTombstoneBlock) error {
+	bucket := tx.Bucket(s.blockBucketName)
+	prefix := marshalSeriesTombstoneKey(entry.Tenant, entry.Index)
+	for _, b := range blocks {
+		v := make([]byte, 4+len(b.Tenant))
+		binary.BigEndian.PutUint32(v, b.Shard)
+		copy(v[4:], b.Tenant)
+		if err := bucket.Put(append(slices.Clip(prefix), b.ID...), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SeriesTombstoneStore) DeleteBlock(tx *bbolt.Tx, entry SeriesTombstoneEntry, block string) error {
+	key := append(marshalSeriesTombstoneKey(entry.Tenant, entry.Index), block...)
+	return tx.Bucket(s.blockBucketName).Delete(key)
+}
+
+func (s *SeriesTombstoneStore) HasBlock(tx *bbolt.Tx, entry SeriesTombstoneEntry, block string) bool {
+	key := append(marshalSeriesTombstoneKey(entry.Tenant, entry.Index), block...)
+	return tx.Bucket(s.blockBucketName).Get(key) != nil
+}
+
+// ListBlocks lists the blocks of the tombstone.
+func (s *SeriesTombstoneStore) ListBlocks(tx *bbolt.Tx, entry SeriesTombstoneEntry) iter.Iterator[SeriesTombstoneBlock] {
+	cursor := store.NewCursorIter(tx.Bucket(s.blockBucketName).Cursor())
+	cursor.Prefix = marshalSeriesTombstoneKey(entry.Tenant, entry.Index)
+	return &seriesTombstoneBlocksIterator{iter: cursor}
+}
+
+// ListEntries lists series tombstones of the tenant.
+// If the tenant is empty, all the entries are listed.
+func (s *SeriesTombstoneStore) ListEntries(tx *bbolt.Tx, tenant string) iter.Iterator[SeriesTombstoneEntry] {
+	cursor := store.NewCursorIter(tx.Bucket(s.bucketName).Cursor())
+	if tenant != "" {
+		cursor.Prefix = seriesTombstoneKeyPrefix(tenant)
+	}
+	return &seriesTombstoneEntriesIterator{iter: cursor}
+}
+
+type seriesTombstoneEntriesIterator struct {
+	iter *store.CursorIterator
+	cur  SeriesTombstoneEntry
+	err  error
+}
+
+func (x *seriesTombstoneEntriesIterator) Next() bool {
+	if x.err != nil || !x.iter.Next() {
+		return false
+	}
+	x.err = unmarshalSeriesTombstoneEntry(&x.cur, x.iter.At())
+	return x.err == nil
+}
+
+func (x *seriesTombstoneEntriesIterator) At() SeriesTombstoneEntry { return x.cur }
+
+func (x *seriesTombstoneEntriesIterator) Close() error { return x.iter.Close() }
+
+func (x *seriesTombstoneEntriesIterator) Err() error {
+	if err := x.iter.Err(); err != nil {
+		return err
+	}
+	return x.err
+}
+
+type seriesTombstoneBlocksIterator struct {
+	iter *store.CursorIterator
+	cur  SeriesTombstoneBlock
+	err  error
+}
+
+func (x *seriesTombstoneBlocksIterator) Next() bool {
+	if x.err != nil || !x.iter.Next() {
+		return false
+	}
+	kv := x.iter.At()
+	if len(kv.Value) < 4 {
+		x.err = ErrInvalidSeriesTombstoneEntry
+		return false
+	}
+	x.cur = SeriesTombstoneBlock{
+		Tenant: string(kv.Value[4:]),
+		Shard:  binary.BigEndian.Uint32(kv.Value),
+		ID:     string(kv.Key[len(x.iter.Prefix):]),
+	}
+	return true
+}
+
+func (x *seriesTombstoneBlocksIterator) At() SeriesTombstoneBlock { return x.cur }
+
+func (x *seriesTombstoneBlocksIterator) Close() error { return x.iter.Close() }
+
+func (x *seriesTombstoneBlocksIterator) Err() error {
+	if err := x.iter.Err(); err != nil {
+		return err
+	}
+	return x.err
+}
+
+func seriesTombstoneKeyPrefix(tenant string) []byte {
+	b := make([]byte, len(tenant)+1)
+	copy(b, tenant)
+	return b
+}
+
+func marshalSeriesTombstoneKey(tenant string, index uint64) []byte {
+	b := make([]byte, len(tenant)+1+8)
+	copy(b, tenant)
+	binary.BigEndian.PutUint64(b[len(tenant)+1:], index)
+	return b
+}
+
+func unmarshalSeriesTombstoneEntry(e *SeriesTombstoneEntry, kv store.KV) error {
+	if len(kv.Key) < 9 {
+		return ErrInvalidSeriesTombstoneEntry
+	}
+	e.Index = binary.BigEndian.Uint64(kv.Key[len(kv.Key)-8:])
+	e.SeriesTombstone = new(metastorev1.SeriesTombstone)
+	if err := e.UnmarshalVT(kv.Value); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSeriesTombstoneEntry, err)
+	}
+	return nil
+}
@@ -163,6 +163,20 @@ func (x *Tombstones) deleteBlockTombstones(t *metastorev1.BlockTombstones) {
 	}
 }
 
+// ExportTombstones calls fn for each tombstone stored in the transaction.
+func (x *Tombstones) ExportTombstones(tx *bbolt.Tx, fn func(*metastorev1.Tombstones) error) error {
+	entries := x.store.ListEntries(tx)
+	defer func() {
+		_ = entries.Close()
+	}()
+	for entries.Next() {
+		if err := fn(entries.At().Tombstones); err != nil {
+			return err
+		}
+	}
+	return entries.Err()
+}
+
 func (x *Tombstones) Init(tx *bbolt.Tx) error {
 	return x.store.CreateBuckets(tx)
 }
@@ -96,3 +96,36 @@ func countTombstones(ts *Tombstones) int {
 	}
 	return c
 }
+
+func TestTombstonesExport(t *testing.T) {
+	db := test.BoltDB(t)
+	ts := NewTombstones(store.NewTombstoneStore(), nil)
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, ts.Init(tx))
+
+	expected := []*metastorev1.Tombstones{
+		{Blocks: &metastorev1.BlockTombstones{Name: "a", Tenant: "tenant", Shard: 1, Blocks: []string{"block-1"}}},
+		{Shard: &metastorev1.ShardTombstone{Name: "b", Tenant: "tenant", Shard: 2, Timestamp: 1, Duration: 2}},
+	}
+	for i, x := range expected {
+		cmd := &raft.Log{Index: uint64(i + 1), AppendedAt: time.Now()}
+		require.NoError(t, ts.AddTombstones(tx, cmd, x))
+	}
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tx.Rollback())
+	}()
+	var exported []*metastorev1.Tombstones
+	require.NoError(t, ts.ExportTombstones(tx, func(x *metastorev1.Tombstones) error {
+		exported = append(exported, x)
+		return nil
+	}))
+	require.Len(t, exported, len(expected))
+	for i := range expected {
+		assert.True(t, expected[i].EqualVT(exported[i]))
+	}
+}
@@ -13,6 +13,7 @@ import (
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/rewrite"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index"
 	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/tracing"
@@ -24,11 +25,19 @@ type IndexInserter interface {
 
 type IndexDeleter interface {
 	DeleteShard(tx *bbolt.Tx, partition indexstore.Partition, tenant string, shard uint32) error
+	DeleteBlocks(tx *bbolt.Tx, blocks *metastorev1.BlockList) error
+}
+
+type IndexMetadataQuerier interface {
+	QueryMetadata(*bbolt.Tx, context.Context, index.MetadataQuery) ([]*metastorev1.BlockMeta, error)
 }
 
 type IndexWriter interface {
 	IndexInserter
 	IndexDeleter
+	// The index is queried to find the blocks
+	// that may include the deleted series.
+	IndexMetadataQuerier
 }
 
 type Tombstones interface {
@@ -37,24 +46,35 @@ type Tombstones interface {
 	Exists(tenant string, shard uint32, block string) bool
 }
 
+type SeriesTombstoneWriter interface {
+	AddSeriesTombstone(*bbolt.Tx, *raft.Log, *metastorev1.SeriesTombstone, []*metastorev1.BlockMeta) error
+	DeleteBlocks(*bbolt.Tx, ...*metastorev1.Tombstones) error
+}
+
 type IndexCommandHandler struct {
-	logger     log.Logger
-	index      IndexWriter
-	tombstones Tombstones
-	compactor  compaction.Compactor
+	logger           log.Logger
+	index            IndexWriter
+	tombstones       Tombstones
+	seriesTombstones SeriesTombstoneWriter
+	compactor        compaction.Compactor
+	jobs             rewrite.JobLister
 }
 
 func NewIndexCommandHandler(
 	logger log.Logger,
 	index IndexWriter,
 	tombstones Tombstones,
+	seriesTombstones SeriesTombstoneWriter,
 	compactor compaction.Compactor,
+	jobs rewrite.JobLister,
 ) *IndexCommandHandler {
 	return &IndexCommandHandler{
-		logger:     logger,
-		index:      index,
-		tombstones: tombstones,
-		compactor:  compactor,
+		logger:           logger,
+		index:            index,
+		tombstones:       tombstones,
+		seriesTombstones: seriesTombstones,
+		compactor:        compactor,
+		jobs:             jobs,
 	}
 }
 
@@ -126,7 +146,12 @@ func (m *IndexCommandHandler) TruncateIndex(ctx context.Context, tx *bbolt.Tx, c
 		)
 		return new(raft_log.TruncateIndexResponse), nil
 	}
-	for _, tombstone := range req.Tombstones {
+	tombstones, err := m.skipActiveBlocks(tx, req.Tombstones)
+	if err != nil {
+		level.Error(m.logger).Log("msg", "failed to list compaction jobs", "err", err)
+		return nil, err
+	}
+	for _, tombstone := range tombstones {
 		// Although it's not strictly necessary, we may pass any tombstones
 		// to TruncateIndex, and the Partition member may be missing.
 		if p := tombstone.Shard; p != nil {
@@ -139,10 +164,109 @@ func (m *IndexCommandHandler) TruncateIndex(ctx context.Context, tx *bbolt.Tx, c
 				return nil, err
 			}
 		}
+		// Blocks are deleted by the dataset retention policy.
+		if b := tombstone.Blocks; b != nil {
+			list := &metastorev1.BlockList{
+				Tenant: b.Tenant,
+				Shard:  b.Shard,
+				Blocks: b.Blocks,
+			}
+			if err = m.index.DeleteBlocks(tx, list); err != nil {
+				level.Error(m.logger).Log("msg", "failed to delete blocks", "err", err)
+				return nil, err
+			}
+		}
 		if err = m.tombstones.AddTombstones(tx, cmd, tombstone); err != nil {
 			level.Error(m.logger).Log("msg", "failed to add partition tombstone", "err", err)
 			return nil, err
 		}
 	}
+	// Series tombstones no longer need to track the deleted blocks.
+	if err = m.seriesTombstones.DeleteBlocks(tx, tombstones...); err != nil {
+		level.Error(m.logger).Log("msg", "failed to update series tombstones", "err", err)
+		return nil, err
+	}
 	return new(raft_log.TruncateIndexResponse), nil
 }
+
+// skipActiveBlocks excludes blocks that are sources of compaction jobs
+// in the schedule from the block tombstones: the blocks would be replaced
+// by the job output once the job completes. The output blocks are subject
+// to the retention policy as well. Tombstones left empty are dropped.
+func (m *IndexCommandHandler) skipActiveBlocks(tx *bbolt.Tx, tombstones []*metastorev1.Tombstones) ([]*metastorev1.Tombstones, error) {
+	var active rewrite.ActiveBlocks
+	filtered := make([]*metastorev1.Tombstones, 0, len(tombstones))
+	for _, t := range tombstones {
+		b := t.Blocks
+		if b == nil {
+			filtered = append(filtered, t)
+			continue
+		}
+		if active == nil {
+			var err error
+			if active, err = rewrite.ListActiveBlocks(tx, m.jobs); err != nil {
+				return nil, err
+			}
+		}
+		blocks := make([]string, 0, len(b.Blocks))
+		for _, id := range b.Blocks {
+			if !active.Contains(id) {
+				blocks = append(blocks, id)
+			}
+		}
+		if len(blocks) == len(b.Blocks) {
+			filtered = append(filtered, t)
+			continue
+		}
+		level.Warn(m.logger).Log(
+			"msg", "skipping deletion of blocks that are sources of compaction jobs",
+			"tombstone", b.Name,
+			"skipped", len(b.Blocks)-len(blocks),
+		)
+		if len(blocks) == 0 {
+			continue
+		}
+		c := t.CloneVT()
+		c.Blocks.Blocks = blocks
+		filtered = append(filtered, c)
+	}
+	return filtered, nil
+}
+
+func (m *IndexCommandHandler) DeleteSeries(ctx context.Context, tx *bbolt.Tx, cmd *raft.Log, req *raft_log.DeleteSeriesRequest) (resp *raft_log.DeleteSeriesResponse, err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "raft.DeleteSeries")
+	span.SetTag("tenant_id", req.Tombstone.GetTenant())
+	span.SetTag("label_selector", req.Tombstone.GetLabelSelector())
+	span.SetTag("raft_log_index", cmd.Index)
+	span.SetTag("raft_log_term", cmd.Term)
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	if req.Tombstone == nil {
+		// Invalid requests are rejected by the service.
+		return new(raft_log.DeleteSeriesResponse), nil
+	}
+	// The tombstone keeps track of the blocks that may include the
+	// deleted series: it is removed once all of them are rewritten.
+	blocks, err := m.index.QueryMetadata(tx, ctx, index.MetadataQuery{
+		Expr:      "{}",
+		StartTime: time.UnixMilli(req.Tombstone.StartTime),
+		EndTime:   time.UnixMilli(req.Tombstone.EndTime),
+		Tenant:    []string{req.Tombstone.Tenant},
+	})
+	if err != nil {
+		level.Error(m.logger).Log("msg", "failed to query blocks", "err", err)
+		return nil, err
+	}
+	span.SetTag("blocks", len(blocks))
+	if err = m.seriesTombstones.AddSeriesTombstone(tx, cmd, req.Tombstone, blocks); err != nil {
+		level.Error(m.logger).Log("msg", "failed to add series tombstone", "err", err)
+		return nil, err
+	}
+	return &raft_log.DeleteSeriesResponse{Tombstone: req.Tombstone}, nil
+}
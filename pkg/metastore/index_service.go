@@ -15,6 +15,8 @@ import (
 	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
 	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
 	"github.com/grafana/pyroscope/v2/pkg/iter"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/reshard"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/rewrite"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/fsm"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner/retention"
 	indexstore "github.com/grafana/pyroscope/v2/pkg/metastore/index/store"
@@ -37,9 +39,20 @@ type IndexPartitionLister interface {
 	Partitions(*bbolt.Tx) goiter.Seq[indexstore.Partition]
 }
 
+type IndexBlockIterator interface {
+	// Blocks provide access to all blocks in the index.
+	Blocks(*bbolt.Tx) goiter.Seq2[*metastorev1.BlockMeta, error]
+}
+
 type IndexReader interface {
 	IndexBlockFinder
 	IndexPartitionLister
+	IndexBlockIterator
+}
+
+type TombstoneExporter interface {
+	// ExportTombstones calls fn for each tombstone stored in the index.
+	ExportTombstones(*bbolt.Tx, func(*metastorev1.Tombstones) error) error
 }
 
 func NewIndexService(
@@ -47,25 +60,28 @@ func NewIndexService(
 	raft Raft,
 	state State,
 	index IndexReader,
+	tombstones TombstoneExporter,
 	stats PlacementStats,
 ) *IndexService {
 	return &IndexService{
-		logger: logger,
-		raft:   raft,
-		state:  state,
-		index:  index,
-		stats:  stats,
+		logger:     logger,
+		raft:       raft,
+		state:      state,
+		index:      index,
+		tombstones: tombstones,
+		stats:      stats,
 	}
 }
 
 type IndexService struct {
 	metastorev1.IndexServiceServer
 
-	logger log.Logger
-	raft   Raft
-	state  State
-	index  IndexReader
-	stats  PlacementStats
+	logger     log.Logger
+	raft       Raft
+	state      State
+	index      IndexReader
+	tombstones TombstoneExporter
+	stats      PlacementStats
 }
 
 func (svc *IndexService) AddBlock(
@@ -85,10 +101,13 @@ func (svc *IndexService) AddBlock(
 		span.SetTag("block_id", block.GetId())
 		span.SetTag("shard", block.GetShard())
 		span.SetTag("compaction_level", block.GetCompactionLevel())
+		span.SetTag("replica", block.GetReplica())
 	}
 
 	defer func() {
-		if err == nil {
+		// Replicas duplicate the data of the primary replica
+		// and must not affect the placement of the datasets.
+		if err == nil && req.Block.GetReplica() == 0 {
 			svc.stats.RecordStats(statsFromMetadata(req.Block))
 		}
 	}()
@@ -240,3 +259,112 @@ func (svc *IndexService) TruncateIndex(ctx context.Context, rp retention.Policy)
 	}
 	return nil
 }
+
+// ReshardBlocks proposes the jobs created by the planner. The jobs move
+// blocks between shards and are added to the compaction schedule.
+func (svc *IndexService) ReshardBlocks(ctx context.Context, planner reshard.Planner) (err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "IndexService.ReshardBlocks")
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	var req raft_log.AddReshardJobsRequest
+	read := func(tx *bbolt.Tx, r raftnode.ReadIndex) {
+		req.Jobs = planner.CreateJobs(tx, svc.index.Partitions(tx))
+		req.Term = r.Term // The leader may change after we read the index.
+	}
+	if readErr := svc.state.ConsistentRead(ctx, read); readErr != nil {
+		return status.Error(codes.Unavailable, readErr.Error())
+	}
+
+	span.SetTag("job_count", len(req.Jobs))
+	span.SetTag("term", req.Term)
+
+	if len(req.Jobs) == 0 {
+		return nil
+	}
+	if _, err = svc.raft.Propose(ctx, fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_ADD_RESHARD_JOBS), &req); err != nil {
+		if !raftnode.IsRaftLeadershipError(err) {
+			level.Error(svc.logger).Log("msg", "failed to add reshard jobs", "err", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// RewriteBlocks proposes the jobs created by the planner. The jobs rewrite
+// blocks in place and are added to the compaction schedule.
+func (svc *IndexService) RewriteBlocks(ctx context.Context, planner rewrite.Planner) (err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "IndexService.RewriteBlocks")
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	var req raft_log.AddRewriteJobsRequest
+	read := func(tx *bbolt.Tx, r raftnode.ReadIndex) {
+		req.Jobs = planner.CreateJobs(tx, svc.index.Partitions(tx))
+		req.Term = r.Term // The leader may change after we read the index.
+	}
+	if readErr := svc.state.ConsistentRead(ctx, read); readErr != nil {
+		return status.Error(codes.Unavailable, readErr.Error())
+	}
+
+	span.SetTag("job_count", len(req.Jobs))
+	span.SetTag("term", req.Term)
+
+	if len(req.Jobs) == 0 {
+		return nil
+	}
+	if _, err = svc.raft.Propose(ctx, fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_ADD_REWRITE_JOBS), &req); err != nil {
+		if !raftnode.IsRaftLeadershipError(err) {
+			level.Error(svc.logger).Log("msg", "failed to add rewrite jobs", "err", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// ExportIndex calls fn for each block in the index, and then tombstones
+// for each tombstone. The index is read within a single consistent read
+// transaction, therefore the functions should not block for long.
+func (svc *IndexService) ExportIndex(
+	ctx context.Context,
+	fn func(*metastorev1.BlockMeta) error,
+	tombstones func(*metastorev1.Tombstones) error,
+) (err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "IndexService.ExportIndex")
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	var blocks int
+	read := func(tx *bbolt.Tx, _ raftnode.ReadIndex) {
+		for md, iterErr := range svc.index.Blocks(tx) {
+			if err = iterErr; err == nil {
+				err = fn(md)
+			}
+			if err != nil {
+				return
+			}
+			blocks++
+		}
+		err = svc.tombstones.ExportTombstones(tx, tombstones)
+	}
+	if readErr := svc.state.ConsistentRead(ctx, read); readErr != nil {
+		return status.Error(codes.Unavailable, readErr.Error())
+	}
+	span.SetTag("block_count", blocks)
+	return err
+}
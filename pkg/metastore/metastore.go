@@ -18,10 +18,14 @@ import (
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/debuginfo"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/compactor"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/reshard"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/rewrite"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/compaction/scheduler"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/fsm"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/index/backup"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/cleaner/retention"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/index/dlq"
@@ -42,6 +46,11 @@ type Config struct {
 	Index            index.Config      `yaml:"index" category:"advanced"`
 	Compactor        compactor.Config  `yaml:",inline" category:"advanced"`
 	Scheduler        scheduler.Config  `yaml:",inline" category:"advanced"`
+	Reshard          reshard.Config    `yaml:",inline" category:"experimental"`
+	Rewrite          rewrite.Config    `yaml:",inline" category:"experimental"`
+
+	// DebugInfoGC is set from the debug info configuration.
+	DebugInfoGC debuginfo.GCConfig `yaml:"-"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -53,6 +62,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.FSM.RegisterFlagsWithPrefix(prefix, f)
 	cfg.Compactor.RegisterFlagsWithPrefix(prefix, f)
 	cfg.Scheduler.RegisterFlagsWithPrefix(prefix, f)
+	cfg.Reshard.RegisterFlagsWithPrefix(prefix, f)
+	cfg.Rewrite.RegisterFlagsWithPrefix(prefix, f)
 	cfg.Index.RegisterFlagsWithPrefix(prefix+"index.", f)
 }
 
@@ -80,16 +91,23 @@ type Metastore struct {
 	contextRegistry *tracing.ContextRegistry
 	raftNodeClient  raftnodepb.RaftNodeServiceClient
 
-	bucket    objstore.Bucket
-	placement *placement.Manager
-	recovery  *dlq.Recovery
-	cleaner   *cleaner.Cleaner
+	bucket      objstore.Bucket
+	placement   *placement.Manager
+	recovery    *dlq.Recovery
+	cleaner     *cleaner.Cleaner
+	exporter    *backup.Exporter
+	rebuilder   *backup.Rebuilder
+	gc          *debuginfo.GarbageCollector
+	resharder   *reshard.Resharder
+	downsampler *rewrite.Rewriter
+	mover       *rewrite.Rewriter
 
 	index        *index.Index
 	indexHandler *IndexCommandHandler
 	indexService *IndexService
 
 	tombstones        *tombstones.Tombstones
+	seriesTombstones  *tombstones.SeriesTombstones
 	compactor         *compactor.Compactor
 	scheduler         *scheduler.Scheduler
 	compactionHandler *CompactionCommandHandler
@@ -116,6 +134,7 @@ func New(
 	healthService health.Service,
 	client raftnodepb.RaftNodeServiceClient,
 	bucket objstore.Bucket,
+	coldBucket objstore.Bucket,
 	placementMgr *placement.Manager,
 ) (*Metastore, error) {
 	m := &Metastore{
@@ -138,27 +157,38 @@ func New(
 	// Initialization of the base components.
 	m.index = index.NewIndex(m.logger, index.NewStore(), config.Index, m.reg)
 	m.tombstones = tombstones.NewTombstones(tombstones.NewStore(), m.reg)
+	m.seriesTombstones = tombstones.NewSeriesTombstones(tombstones.NewSeriesTombstoneStore())
 	m.compactor = compactor.NewCompactor(config.Compactor, compactor.NewStore(), m.tombstones, m.reg)
 	m.scheduler = scheduler.NewScheduler(config.Scheduler, scheduler.NewStore(), m.reg)
 
 	// FSM handlers that utilize the components.
-	m.indexHandler = NewIndexCommandHandler(m.logger, m.index, m.tombstones, m.compactor)
+	m.indexHandler = NewIndexCommandHandler(m.logger, m.index, m.tombstones, m.seriesTombstones, m.compactor, m.scheduler)
 	fsm.RegisterRaftCommandHandler(m.fsm,
 		fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_ADD_BLOCK_METADATA),
 		m.indexHandler.AddBlock)
 	fsm.RegisterRaftCommandHandler(m.fsm,
 		fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_TRUNCATE_INDEX),
 		m.indexHandler.TruncateIndex)
+	fsm.RegisterRaftCommandHandler(m.fsm,
+		fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_DELETE_SERIES),
+		m.indexHandler.DeleteSeries)
 
-	m.compactionHandler = NewCompactionCommandHandler(m.logger, m.index, m.compactor, m.compactor, m.scheduler, m.tombstones)
+	m.compactionHandler = NewCompactionCommandHandler(m.logger, m.index, m.compactor, m.compactor, m.scheduler, m.tombstones, m.seriesTombstones, m.overrides)
 	fsm.RegisterRaftCommandHandler(m.fsm,
 		fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_GET_COMPACTION_PLAN_UPDATE),
 		m.compactionHandler.GetCompactionPlanUpdate)
 	fsm.RegisterRaftCommandHandler(m.fsm,
 		fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_UPDATE_COMPACTION_PLAN),
 		m.compactionHandler.UpdateCompactionPlan)
+	fsm.RegisterRaftCommandHandler(m.fsm,
+		fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_ADD_RESHARD_JOBS),
+		m.compactionHandler.AddReshardJobs)
+	fsm.RegisterRaftCommandHandler(m.fsm,
+		fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_ADD_REWRITE_JOBS),
+		m.compactionHandler.AddRewriteJobs)
 
 	m.fsm.RegisterRestorer(m.tombstones)
+	m.fsm.RegisterRestorer(m.seriesTombstones)
 	m.fsm.RegisterRestorer(m.compactor)
 	m.fsm.RegisterRestorer(m.scheduler)
 	m.fsm.RegisterRestorer(m.index)
@@ -175,11 +205,17 @@ func New(
 	// Services should be registered after FSM and Raft have been initialized.
 	// Services provide an interface to interact with the metastore components.
 	m.compactionService = NewCompactionService(m.logger, m.raft)
-	m.indexService = NewIndexService(m.logger, m.raft, m.leaderRead, m.index, m.placement)
-	m.tenantService = NewTenantService(m.logger, m.followerRead, m.index)
-	m.queryService = NewQueryService(m.logger, m.followerRead, m.index)
+	m.indexService = NewIndexService(m.logger, m.raft, m.leaderRead, m.index, m.tombstones, m.placement)
+	m.tenantService = NewTenantService(m.logger, m.raft, m.followerRead, m.index)
+	m.queryService = NewQueryService(m.logger, m.followerRead, m.index, m.seriesTombstones)
 	m.recovery = dlq.NewRecovery(logger, config.Index.Recovery, m.indexService, bucket, m.reg)
-	m.cleaner = cleaner.NewCleaner(m.logger, m.overrides, config.Index.Cleaner, m.indexService)
+	m.cleaner = cleaner.NewCleaner(m.logger, m.overrides, config.Index.Cleaner, m.indexService, m.scheduler, config.Compactor.TopLevel())
+	m.exporter = backup.NewExporter(logger, config.Index.Backup, m.indexService, bucket, m.reg)
+	m.rebuilder = backup.NewRebuilder(logger, config.Index.Backup, m.indexService, bucket, coldBucket, m.reg)
+	m.gc = debuginfo.NewGarbageCollector(m.logger, config.DebugInfoGC, bucket, m.queryService, m.overrides)
+	m.resharder = reshard.NewResharder(m.logger, config.Reshard, m.indexService, m.index, m.scheduler, config.Compactor.TopLevel())
+	m.downsampler = rewrite.NewDownsampler(m.logger, config.Rewrite, m.indexService, m.index, m.scheduler, config.Compactor.TopLevel())
+	m.mover = rewrite.NewColdStorageMover(m.logger, config.Rewrite, m.indexService, m.index, m.scheduler, config.Compactor.TopLevel())
 
 	// These are the services that only run on the raft leader.
 	// Keep in mind that the node may not be the leader at the moment the
@@ -187,6 +223,12 @@ func New(
 	m.raft.RunOnLeader(m.recovery)
 	m.raft.RunOnLeader(m.placement)
 	m.raft.RunOnLeader(m.cleaner)
+	m.raft.RunOnLeader(m.exporter)
+	m.raft.RunOnLeader(m.rebuilder)
+	m.raft.RunOnLeader(m.gc)
+	m.raft.RunOnLeader(m.resharder)
+	m.raft.RunOnLeader(m.downsampler)
+	m.raft.RunOnLeader(m.mover)
 
 	m.service = services.NewBasicService(m.starting, m.running, m.stopping)
 	return m, nil
@@ -21,25 +21,29 @@ import (
 type IndexQuerier interface {
 	QueryMetadata(*bbolt.Tx, context.Context, index.MetadataQuery) ([]*metastorev1.BlockMeta, error)
 	QueryMetadataLabels(*bbolt.Tx, context.Context, index.MetadataQuery) ([]*typesv1.Labels, error)
+	QueryMetadataLabelCounts(*bbolt.Tx, context.Context, index.MetadataQuery) ([]*typesv1.Labels, []int64, error)
 }
 
 type QueryService struct {
 	metastorev1.MetadataQueryServiceServer
 
-	logger log.Logger
-	state  State
-	index  IndexQuerier
+	logger     log.Logger
+	state      State
+	index      IndexQuerier
+	tombstones SeriesTombstoneReader
 }
 
 func NewQueryService(
 	logger log.Logger,
 	state State,
 	index IndexQuerier,
+	tombstones SeriesTombstoneReader,
 ) *QueryService {
 	return &QueryService{
-		logger: logger,
-		state:  state,
-		index:  index,
+		logger:     logger,
+		state:      state,
+		index:      index,
+		tombstones: tombstones,
 	}
 }
 
@@ -95,7 +99,22 @@ func (svc *QueryService) queryMetadata(
 	})
 	if err == nil {
 		span.SetTag("result_count", len(metas))
-		return &metastorev1.QueryMetadataResponse{Blocks: metas}, nil
+		resp = &metastorev1.QueryMetadataResponse{Blocks: metas}
+		if len(metas) == 0 {
+			return resp, nil
+		}
+		// Deleted series must be filtered out by the query backend
+		// until the blocks are rewritten by compaction.
+		for _, tenant := range req.TenantId {
+			tombstones, listErr := svc.tombstones.ListSeriesTombstones(tx, tenant, req.StartTime, req.EndTime)
+			if listErr != nil {
+				level.Error(svc.logger).Log("msg", "failed to list series tombstones", "err", listErr)
+				return nil, status.Error(codes.Internal, listErr.Error())
+			}
+			resp.SeriesTombstones = append(resp.SeriesTombstones, tombstones...)
+		}
+		span.SetTag("series_tombstones", len(resp.SeriesTombstones))
+		return resp, nil
 	}
 	var invalid *index.InvalidQueryError
 	if errors.As(err, &invalid) {
@@ -149,16 +168,32 @@ func (svc *QueryService) queryMetadataLabels(
 		span.Finish()
 	}()
 
-	labels, err := svc.index.QueryMetadataLabels(tx, ctx, index.MetadataQuery{
+	query := index.MetadataQuery{
 		Tenant:    req.TenantId,
 		StartTime: time.UnixMilli(req.StartTime),
 		EndTime:   time.UnixMilli(req.EndTime),
 		Expr:      req.Query,
 		Labels:    req.Labels,
-	})
+	}
+	// Unlike block metadata, label sets are resolved here,
+	// therefore deleted series are excluded by the index.
+	for _, tenant := range req.TenantId {
+		tombstones, listErr := svc.tombstones.ListSeriesTombstones(tx, tenant, req.StartTime, req.EndTime)
+		if listErr != nil {
+			level.Error(svc.logger).Log("msg", "failed to list series tombstones", "err", listErr)
+			return nil, status.Error(codes.Internal, listErr.Error())
+		}
+		query.SeriesTombstones = append(query.SeriesTombstones, tombstones...)
+	}
+	resp = new(metastorev1.QueryMetadataLabelsResponse)
+	if req.CountDatasets {
+		resp.Labels, resp.DatasetCounts, err = svc.index.QueryMetadataLabelCounts(tx, ctx, query)
+	} else {
+		resp.Labels, err = svc.index.QueryMetadataLabels(tx, ctx, query)
+	}
 	if err == nil {
-		span.SetTag("result_count", len(labels))
-		return &metastorev1.QueryMetadataLabelsResponse{Labels: labels}, nil
+		span.SetTag("result_count", len(resp.Labels))
+		return resp, nil
 	}
 	var invalid *index.InvalidQueryError
 	if errors.As(err, &invalid) {
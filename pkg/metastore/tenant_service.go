@@ -2,15 +2,21 @@ package metastore
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/tracing"
 	"go.etcd.io/bbolt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1/raft_log"
+	"github.com/grafana/pyroscope/v2/pkg/metastore/fsm"
 	"github.com/grafana/pyroscope/v2/pkg/metastore/raftnode"
+	"github.com/grafana/pyroscope/v2/pkg/model"
 )
 
 type TenantIndex interface {
@@ -22,17 +28,20 @@ type TenantService struct {
 	metastorev1.TenantServiceServer
 
 	logger log.Logger
+	raft   Raft
 	state  State
 	index  TenantIndex
 }
 
 func NewTenantService(
 	logger log.Logger,
+	raft Raft,
 	state State,
 	index TenantIndex,
 ) *TenantService {
 	return &TenantService{
 		logger: logger,
+		raft:   raft,
 		state:  state,
 		index:  index,
 	}
@@ -99,3 +108,75 @@ func (svc *TenantService) DeleteTenant(
 	// TODO(kolesnikovae): Implement.
 	return new(metastorev1.DeleteTenantResponse), nil
 }
+
+func (svc *TenantService) DeleteSeries(
+	ctx context.Context,
+	req *metastorev1.DeleteSeriesRequest,
+) (resp *metastorev1.DeleteSeriesResponse, err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "TenantService.DeleteSeries")
+	defer func() {
+		if err != nil {
+			span.LogError(err)
+			span.SetError()
+		}
+		span.Finish()
+	}()
+
+	span.SetTag("tenant_id", req.GetTenantId())
+	span.SetTag("label_selector", req.GetLabelSelector())
+	span.SetTag("start_time", req.GetStartTime())
+	span.SetTag("end_time", req.GetEndTime())
+
+	if err = validateDeleteSeriesRequest(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	cmd := fsm.RaftLogEntryType(raft_log.RaftCommand_RAFT_COMMAND_DELETE_SERIES)
+	proposal := &raft_log.DeleteSeriesRequest{
+		Tombstone: &metastorev1.SeriesTombstone{
+			Tenant:        req.TenantId,
+			LabelSelector: req.LabelSelector,
+			StartTime:     req.StartTime,
+			EndTime:       req.EndTime,
+		},
+	}
+	proposeResp, err := svc.raft.Propose(ctx, cmd, proposal)
+	if err != nil {
+		if !raftnode.IsRaftLeadershipError(err) {
+			level.Error(svc.logger).Log("msg", "failed to delete series", "err", err)
+		}
+		return nil, err
+	}
+
+	tombstone := proposeResp.(*raft_log.DeleteSeriesResponse).GetTombstone()
+	level.Info(svc.logger).Log(
+		"msg", "series tombstone created",
+		"tenant", req.TenantId,
+		"label_selector", req.LabelSelector,
+		"start_time", req.StartTime,
+		"end_time", req.EndTime,
+		"tombstone", tombstone.GetName(),
+	)
+	return &metastorev1.DeleteSeriesResponse{Tombstone: tombstone}, nil
+}
+
+func validateDeleteSeriesRequest(req *metastorev1.DeleteSeriesRequest) error {
+	if req.TenantId == "" {
+		return errors.New("tenant_id is required")
+	}
+	if req.StartTime > req.EndTime {
+		return errors.New("start_time must not be after end_time")
+	}
+	matchers, err := model.ParseMetricSelector(req.LabelSelector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+	// A selector that matches all the series is not allowed: the tenant
+	// data should be deleted with DeleteTenant instead.
+	for _, m := range matchers {
+		if !m.Matches("") {
+			return nil
+		}
+	}
+	return errors.New("label selector must not match all series")
+}
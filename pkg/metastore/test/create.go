@@ -99,7 +99,7 @@ func NewMetastoreSet(t *testing.T, cfg *metastore.Config, n int, bucket objstore
 			validation.MockDefaultOverrides(),
 			placement.NewStore(bucket),
 		)
-		m, err := metastore.New(configs[i], validation.MockDefaultOverrides(), logger, registry, health.NoOpService, client, bucket, placementManager)
+		m, err := metastore.New(configs[i], validation.MockDefaultOverrides(), logger, registry, health.NoOpService, client, bucket, nil, placementManager)
 		require.NoError(t, err)
 		m.Register(server)
 
@@ -156,7 +156,7 @@ func ResolveExemplars(exemplars []*queryv1.Exemplar, table *queryv1.AttributeTab
 	}
 	result := make([]*typesv1.Exemplar, 0, len(exemplars))
 	for _, ex := range exemplars {
-		if e := resolveExemplar(ex.Timestamp, ex.Value, ex.ProfileId, ex.SpanId, "", ex.AttributeRefs, table); e != nil {
+		if e := resolveExemplar(ex.Timestamp, ex.Value, ex.ProfileId, ex.SpanId, ex.TraceId, ex.AttributeRefs, table); e != nil {
 			result = append(result, e)
 		}
 	}
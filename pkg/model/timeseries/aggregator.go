@@ -4,9 +4,21 @@ import (
 	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 )
 
-// DefaultMaxExemplarsPerPoint is the default maximum number of exemplars tracked per point.
-// TODO: make it configurable via tenant limits.
-const DefaultMaxExemplarsPerPoint = 1
+const (
+	// DefaultMaxExemplarsPerPoint is the default maximum number of exemplars tracked per point.
+	DefaultMaxExemplarsPerPoint = 1
+	// MaxExemplarsPerPoint is the upper bound of the number of exemplars per point a query may request.
+	MaxExemplarsPerPoint = 100
+)
+
+// ExemplarsPerPoint returns the number of exemplars to keep per point
+// for the requested number: non-positive values select the default.
+func ExemplarsPerPoint(n int64) int {
+	if n <= 0 {
+		return DefaultMaxExemplarsPerPoint
+	}
+	return int(min(n, MaxExemplarsPerPoint))
+}
 
 type Aggregator interface {
 	Add(ts int64, point *Value)
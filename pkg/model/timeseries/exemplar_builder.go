@@ -9,6 +9,7 @@ import (
 
 	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
+	"github.com/grafana/pyroscope/v2/pkg/model/attributetable"
 )
 
 // exemplarBuilder builds exemplars for a single time series.
@@ -21,6 +22,8 @@ type exemplarBuilder struct {
 type exemplar struct {
 	timestamp   int64
 	profileID   string
+	spanID      uint64
+	traceID     phlaremodel.TraceID
 	labelSetRef int
 	value       int64
 }
@@ -39,7 +42,28 @@ func (eb *exemplarBuilder) Add(fp model.Fingerprint, labels phlaremodel.Labels,
 	if profileID == "" {
 		return
 	}
+	eb.add(fp, labels, exemplar{
+		timestamp: ts,
+		profileID: profileID,
+		value:     value,
+	})
+}
+
+// AddSpan adds an exemplar of a span with its full labels.
+func (eb *exemplarBuilder) AddSpan(fp model.Fingerprint, labels phlaremodel.Labels, ts int64, profileID string, span SpanValue) {
+	if span.SpanID == 0 {
+		return
+	}
+	eb.add(fp, labels, exemplar{
+		timestamp: ts,
+		profileID: profileID,
+		spanID:    span.SpanID,
+		traceID:   span.TraceID,
+		value:     span.Value,
+	})
+}
 
+func (eb *exemplarBuilder) add(fp model.Fingerprint, labels phlaremodel.Labels, e exemplar) {
 	labelSetIdx, exists := eb.labelSetIndex[uint64(fp)]
 	if !exists {
 		eb.labelSets = append(eb.labelSets, labels.Clone())
@@ -47,12 +71,8 @@ func (eb *exemplarBuilder) Add(fp model.Fingerprint, labels phlaremodel.Labels,
 		eb.labelSetIndex[uint64(fp)] = labelSetIdx
 	}
 
-	eb.exemplars = append(eb.exemplars, exemplar{
-		timestamp:   ts,
-		profileID:   profileID,
-		labelSetRef: labelSetIdx,
-		value:       value,
-	})
+	e.labelSetRef = labelSetIdx
+	eb.exemplars = append(eb.exemplars, e)
 }
 
 // Count returns the number of raw exemplars added.
@@ -61,7 +81,7 @@ func (eb *exemplarBuilder) Count() int {
 }
 
 // Build returns the final exemplars, sorted and deduplicated.
-// Exemplars with the same (profileID, timestamp) are merged by intersecting their labels.
+// Exemplars with the same (profileID, spanID, timestamp) are merged by intersecting their labels.
 func (eb *exemplarBuilder) Build() []*typesv1.Exemplar {
 	if len(eb.exemplars) == 0 {
 		return nil
@@ -71,14 +91,17 @@ func (eb *exemplarBuilder) Build() []*typesv1.Exemplar {
 		if c := cmp.Compare(a.timestamp, b.timestamp); c != 0 {
 			return c
 		}
-		return strings.Compare(a.profileID, b.profileID)
+		if c := strings.Compare(a.profileID, b.profileID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.spanID, b.spanID)
 	})
 
 	return eb.deduplicateAndIntersect()
 }
 
-// deduplicateAndIntersect merges exemplars with the same profileID, timestamp by intersecting their label sets.
-// When multiple exemplars exist for the same (profileID, timestamp), we sum their values.
+// deduplicateAndIntersect merges exemplars with the same profileID, spanID, timestamp by intersecting their label sets.
+// When multiple exemplars exist for the same (profileID, spanID, timestamp), we sum their values.
 func (eb *exemplarBuilder) deduplicateAndIntersect() []*typesv1.Exemplar {
 	result := make([]*typesv1.Exemplar, 0, len(eb.exemplars))
 
@@ -91,6 +114,7 @@ func (eb *exemplarBuilder) deduplicateAndIntersect() []*typesv1.Exemplar {
 		j := i + 1
 		for j < len(eb.exemplars) &&
 			eb.exemplars[j].profileID == curr.profileID &&
+			eb.exemplars[j].spanID == curr.spanID &&
 			eb.exemplars[j].timestamp == curr.timestamp {
 			labelSetsToIntersect = append(labelSetsToIntersect, eb.labelSets[eb.exemplars[j].labelSetRef])
 			sumValue += eb.exemplars[j].value
@@ -99,15 +123,72 @@ func (eb *exemplarBuilder) deduplicateAndIntersect() []*typesv1.Exemplar {
 
 		finalLabels := phlaremodel.IntersectAll(labelSetsToIntersect)
 
-		result = append(result, &typesv1.Exemplar{
+		ex := &typesv1.Exemplar{
 			Timestamp: curr.timestamp,
 			ProfileId: curr.profileID,
+			SpanId:    attributetable.SpanIDToHex(curr.spanID),
 			Value:     sumValue,
 			Labels:    finalLabels,
-		})
+		}
+		if curr.traceID != (phlaremodel.TraceID{}) {
+			ex.TraceId = curr.traceID.String()
+		}
+		result = append(result, ex)
 
 		i = j
 	}
 
 	return result
 }
+
+// SpanValue is the total value of the samples of a span within a profile.
+type SpanValue struct {
+	SpanID  uint64
+	TraceID phlaremodel.TraceID
+	Value   int64
+}
+
+// SpanValues sums the sample values of a single profile by span.
+// The zero value is ready to use.
+type SpanValues struct {
+	index map[uint64]int
+	spans []SpanValue
+}
+
+// Add adds the value of a sample. Samples without a span are ignored.
+func (s *SpanValues) Add(spanID uint64, traceID phlaremodel.TraceID, value int64) {
+	if spanID == 0 {
+		return
+	}
+	if s.index == nil {
+		s.index = make(map[uint64]int)
+	}
+	i, ok := s.index[spanID]
+	if !ok {
+		i = len(s.spans)
+		s.index[spanID] = i
+		s.spans = append(s.spans, SpanValue{SpanID: spanID})
+	}
+	if s.spans[i].TraceID == (phlaremodel.TraceID{}) {
+		s.spans[i].TraceID = traceID
+	}
+	s.spans[i].Value += value
+}
+
+// Top returns up to n spans with the highest values. No spans can be
+// added after the call, and the result is only valid until Reset.
+func (s *SpanValues) Top(n int) []SpanValue {
+	slices.SortFunc(s.spans, func(a, b SpanValue) int {
+		if c := cmp.Compare(b.Value, a.Value); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SpanID, b.SpanID)
+	})
+	return s.spans[:min(n, len(s.spans))]
+}
+
+// Reset prepares SpanValues for the next profile.
+func (s *SpanValues) Reset() {
+	clear(s.index)
+	s.spans = s.spans[:0]
+}
@@ -142,7 +142,7 @@ func mergeAnnotations(a, b []*typesv1.ProfileAnnotation) []*typesv1.ProfileAnnot
 }
 
 // mergeExemplars combines two exemplar lists.
-// For exemplars with the same profileID, it keeps the highest value and intersects labels.
+// For exemplars with the same profileID and spanID, it keeps the highest value and intersects labels.
 func mergeExemplars(a, b []*typesv1.Exemplar) []*typesv1.Exemplar {
 	if len(a) == 0 {
 		return b
@@ -151,23 +151,28 @@ func mergeExemplars(a, b []*typesv1.Exemplar) []*typesv1.Exemplar {
 		return a
 	}
 
+	type exemplarKey struct {
+		profileID string
+		spanID    string
+	}
 	type exemplarGroup struct {
 		exemplar  *typesv1.Exemplar
 		labelSets []phlaremodel.Labels
 	}
-	byProfileID := make(map[string]*exemplarGroup)
+	byKey := make(map[exemplarKey]*exemplarGroup)
 
 	for _, ex := range a {
-		byProfileID[ex.ProfileId] = &exemplarGroup{
+		byKey[exemplarKey{ex.ProfileId, ex.SpanId}] = &exemplarGroup{
 			exemplar:  ex,
 			labelSets: []phlaremodel.Labels{ex.Labels},
 		}
 	}
 
 	for _, ex := range b {
-		existing, found := byProfileID[ex.ProfileId]
+		k := exemplarKey{ex.ProfileId, ex.SpanId}
+		existing, found := byKey[k]
 		if !found {
-			byProfileID[ex.ProfileId] = &exemplarGroup{
+			byKey[k] = &exemplarGroup{
 				exemplar:  ex,
 				labelSets: []phlaremodel.Labels{phlaremodel.Labels(ex.Labels)},
 			}
@@ -179,8 +184,8 @@ func mergeExemplars(a, b []*typesv1.Exemplar) []*typesv1.Exemplar {
 		}
 	}
 
-	result := make([]*typesv1.Exemplar, 0, len(byProfileID))
-	for _, group := range byProfileID {
+	result := make([]*typesv1.Exemplar, 0, len(byKey))
+	for _, group := range byKey {
 		ex := group.exemplar
 		if len(group.labelSets) > 1 {
 			ex.Labels = phlaremodel.IntersectAll(group.labelSets)
@@ -189,7 +194,10 @@ func mergeExemplars(a, b []*typesv1.Exemplar) []*typesv1.Exemplar {
 	}
 
 	slices.SortFunc(result, func(a, b *typesv1.Exemplar) int {
-		return strings.Compare(a.ProfileId, b.ProfileId)
+		if c := strings.Compare(a.ProfileId, b.ProfileId); c != 0 {
+			return c
+		}
+		return strings.Compare(a.SpanId, b.SpanId)
 	})
 
 	return result
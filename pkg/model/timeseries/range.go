@@ -15,11 +15,12 @@ import (
 // Series contains points spaced by step from start to end.
 // Profiles from the same step are aggregated into one point.
 func RangeSeries(it iter.Iterator[Value], start, end, step int64, aggregation *typesv1.TimeSeriesAggregationType) []*typesv1.Series {
-	return rangeSeriesWithLimit(it, start, end, step, aggregation, DefaultMaxExemplarsPerPoint)
+	return RangeSeriesWithExemplarLimit(it, start, end, step, aggregation, DefaultMaxExemplarsPerPoint)
 }
 
-// rangeSeriesWithLimit is an internal function that allows specifying maxExemplarsPerPoint.
-func rangeSeriesWithLimit(it iter.Iterator[Value], start, end, step int64, aggregation *typesv1.TimeSeriesAggregationType, maxExemplarsPerPoint int) []*typesv1.Series {
+// RangeSeriesWithExemplarLimit is RangeSeries that keeps up to maxExemplarsPerPoint
+// exemplars with the highest values per point.
+func RangeSeriesWithExemplarLimit(it iter.Iterator[Value], start, end, step int64, aggregation *typesv1.TimeSeriesAggregationType, maxExemplarsPerPoint int) []*typesv1.Series {
 	defer it.Close()
 	seriesMap := make(map[uint64]*typesv1.Series)
 	aggregators := make(map[uint64]Aggregator)
@@ -94,7 +95,7 @@ func selectTopNExemplarsProto(exemplars []*typesv1.Exemplar, maxExemplars int) [
 		return exemplars
 	}
 
-	slices.SortFunc(exemplars, func(a, b *typesv1.Exemplar) int {
+	slices.SortStableFunc(exemplars, func(a, b *typesv1.Exemplar) int {
 		return cmp.Compare(b.Value, a.Value)
 	})
 	return exemplars[:maxExemplars]
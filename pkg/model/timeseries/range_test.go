@@ -330,7 +330,7 @@ func Test_RangeSeriesWithExemplars(t *testing.T) {
 			iter := NewTimeSeriesMergeIterator(tc.series)
 			var result []*typesv1.Series
 			if tc.maxExemplars > 0 {
-				result = rangeSeriesWithLimit(iter, tc.start, tc.end, tc.step, tc.aggregation, tc.maxExemplars)
+				result = RangeSeriesWithExemplarLimit(iter, tc.start, tc.end, tc.step, tc.aggregation, tc.maxExemplars)
 			} else {
 				result = RangeSeries(iter, tc.start, tc.end, tc.step, tc.aggregation)
 			}
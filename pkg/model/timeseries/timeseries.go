@@ -1,7 +1,7 @@
 // Package timeseries provides types for building and aggregating time series data.
 //
 // NOTE: This is the old time series implementation using string labels.
-// Currently used for all time series queries except exemplar retrieval.
+// Currently used for all time series queries except exemplar retrieval in v2.
 // Over time, we want to migrate to pkg/model/timeseriescompact which uses
 // attribute table interning for better performance, and remove this package.
 package timeseries
@@ -70,14 +70,34 @@ func (s *Builder) Add(fp model.Fingerprint, lbs phlaremodel.Labels, ts int64, va
 	})
 
 	if profileID != "" {
-		eb := s.exemplarBuilders[string(s.labelBuf)]
-		if eb == nil {
-			eb = newExemplarBuilder()
-			s.exemplarBuilders[string(s.labelBuf)] = eb
-		}
 		exemplarLabels := lbs.WithoutLabels(s.by...)
-		eb.Add(fp, exemplarLabels, ts, profileID, int64(value))
+		s.exemplarBuilder().Add(fp, exemplarLabels, ts, profileID, int64(value))
+	}
+}
+
+// AddSpanExemplars adds exemplars referring to the spans of a profile
+// added with Add. The exemplar value is the span contribution to the point.
+func (s *Builder) AddSpanExemplars(fp model.Fingerprint, lbs phlaremodel.Labels, ts int64, profileID string, spans []SpanValue) {
+	if len(spans) == 0 {
+		return
+	}
+	s.labelBuf = lbs.BytesWithLabels(s.labelBuf, s.by...)
+	eb := s.exemplarBuilder()
+	exemplarLabels := lbs.WithoutLabels(s.by...)
+	for _, span := range spans {
+		eb.AddSpan(fp, exemplarLabels, ts, profileID, span)
+	}
+}
+
+// exemplarBuilder returns the exemplar builder of the series
+// identified by the current labelBuf.
+func (s *Builder) exemplarBuilder() *exemplarBuilder {
+	eb := s.exemplarBuilders[string(s.labelBuf)]
+	if eb == nil {
+		eb = newExemplarBuilder()
+		s.exemplarBuilders[string(s.labelBuf)] = eb
 	}
+	return eb
 }
 
 // Build returns the time series without exemplars.
@@ -253,6 +253,64 @@ func TestBuilder_MultipleSeries(t *testing.T) {
 	assert.Equal(t, "staging-profile", stagingSeries.Points[0].Exemplars[0].ProfileId)
 }
 
+func TestBuilder_SpanExemplars(t *testing.T) {
+	builder := NewBuilder("service_name")
+	labels := phlaremodel.Labels{
+		{Name: "service_name", Value: "api"},
+		{Name: "pod", Value: "pod-123"},
+	}
+
+	var spans SpanValues
+	traceID := phlaremodel.TraceID{15: 1}
+	spans.Add(0xa, traceID, 10)
+	spans.Add(0xb, phlaremodel.TraceID{}, 30)
+	spans.Add(0xa, phlaremodel.TraceID{}, 5)
+	spans.Add(0, traceID, 100)
+
+	builder.Add(1, labels, 1000, 145.0, schemav1.Annotations{}, "")
+	builder.AddSpanExemplars(1, labels, 1000, "profile-1", spans.Top(2))
+
+	series := builder.BuildWithExemplars()
+	require.Len(t, series, 1)
+	require.Len(t, series[0].Points, 1)
+	exemplars := series[0].Points[0].Exemplars
+	require.Len(t, exemplars, 2)
+	sort.Slice(exemplars, func(i, j int) bool {
+		return exemplars[i].Value > exemplars[j].Value
+	})
+
+	assert.Equal(t, "0b00000000000000", exemplars[0].SpanId)
+	assert.Equal(t, "", exemplars[0].TraceId)
+	assert.Equal(t, int64(30), exemplars[0].Value)
+	assert.Equal(t, "0a00000000000000", exemplars[1].SpanId)
+	assert.Equal(t, "00000000000000000000000000000001", exemplars[1].TraceId)
+	assert.Equal(t, int64(15), exemplars[1].Value)
+	for _, e := range exemplars {
+		assert.Equal(t, "profile-1", e.ProfileId)
+		assert.Equal(t, int64(1000), e.Timestamp)
+		assert.Equal(t, "pod-123", findLabelValue(e.Labels, "pod"))
+	}
+}
+
+func TestSpanValues_Top(t *testing.T) {
+	var spans SpanValues
+	spans.Add(3, phlaremodel.TraceID{}, 1)
+	spans.Add(2, phlaremodel.TraceID{}, 2)
+	spans.Add(1, phlaremodel.TraceID{}, 2)
+
+	top := spans.Top(2)
+	require.Len(t, top, 2)
+	// Ties are broken by span ID.
+	assert.Equal(t, uint64(1), top[0].SpanID)
+	assert.Equal(t, uint64(2), top[1].SpanID)
+	assert.Len(t, spans.Top(10), 3)
+
+	spans.Reset()
+	assert.Empty(t, spans.Top(10))
+	spans.Add(3, phlaremodel.TraceID{}, 4)
+	assert.Equal(t, []SpanValue{{SpanID: 3, Value: 4}}, spans.Top(10))
+}
+
 func findLabelValue(labels []*typesv1.LabelPair, name string) string {
 	for _, lp := range labels {
 		if lp.Name == name {
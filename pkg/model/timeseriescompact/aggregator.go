@@ -15,6 +15,7 @@ type Aggregator struct {
 	annotationRefs []int64
 	exemplars      []*queryv1.Exemplar
 	hasData        bool
+	maxExemplars   int
 }
 
 // Add adds a point to the aggregator.
@@ -38,7 +39,11 @@ func (a *Aggregator) GetAndReset() *queryv1.Point {
 		pt.AnnotationRefs = DedupeRefs(a.annotationRefs)
 	}
 	if len(a.exemplars) > 0 {
-		pt.Exemplars = SelectTopExemplars(a.exemplars, timeseries.DefaultMaxExemplarsPerPoint)
+		maxExemplars := a.maxExemplars
+		if maxExemplars <= 0 {
+			maxExemplars = timeseries.DefaultMaxExemplarsPerPoint
+		}
+		pt.Exemplars = SelectTopExemplars(a.exemplars, maxExemplars)
 	}
 
 	// Reset
@@ -78,14 +83,14 @@ func SelectTopExemplars(exemplars []*queryv1.Exemplar, n int) []*queryv1.Exempla
 	if len(exemplars) <= n {
 		return exemplars
 	}
-	sort.Slice(exemplars, func(i, j int) bool {
+	sort.SliceStable(exemplars, func(i, j int) bool {
 		return exemplars[i].Value > exemplars[j].Value
 	})
 	return exemplars[:n]
 }
 
 // MergeExemplars combines two exemplar lists.
-// For exemplars with the same profileID, it keeps the highest value and intersects attribute refs.
+// For exemplars with the same profileID and spanID, it keeps the highest value and intersects attribute refs.
 func MergeExemplars(a, b []*queryv1.Exemplar) []*queryv1.Exemplar {
 	if len(a) == 0 {
 		return b
@@ -94,23 +99,28 @@ func MergeExemplars(a, b []*queryv1.Exemplar) []*queryv1.Exemplar {
 		return a
 	}
 
+	type exemplarKey struct {
+		profileID string
+		spanID    string
+	}
 	type exemplarGroup struct {
 		exemplar *queryv1.Exemplar
 		refSets  [][]int64
 	}
-	byProfileID := make(map[string]*exemplarGroup, len(a)+len(b))
+	byKey := make(map[exemplarKey]*exemplarGroup, len(a)+len(b))
 
 	for _, ex := range a {
-		byProfileID[ex.ProfileId] = &exemplarGroup{
+		byKey[exemplarKey{ex.ProfileId, ex.SpanId}] = &exemplarGroup{
 			exemplar: ex,
 			refSets:  [][]int64{ex.AttributeRefs},
 		}
 	}
 
 	for _, ex := range b {
-		existing, found := byProfileID[ex.ProfileId]
+		k := exemplarKey{ex.ProfileId, ex.SpanId}
+		existing, found := byKey[k]
 		if !found {
-			byProfileID[ex.ProfileId] = &exemplarGroup{
+			byKey[k] = &exemplarGroup{
 				exemplar: ex,
 				refSets:  [][]int64{ex.AttributeRefs},
 			}
@@ -122,8 +132,8 @@ func MergeExemplars(a, b []*queryv1.Exemplar) []*queryv1.Exemplar {
 		}
 	}
 
-	result := make([]*queryv1.Exemplar, 0, len(byProfileID))
-	for _, group := range byProfileID {
+	result := make([]*queryv1.Exemplar, 0, len(byKey))
+	for _, group := range byKey {
 		ex := group.exemplar
 		if len(group.refSets) > 1 {
 			ex.AttributeRefs = IntersectRefs(group.refSets)
@@ -131,7 +141,12 @@ func MergeExemplars(a, b []*queryv1.Exemplar) []*queryv1.Exemplar {
 		result = append(result, ex)
 	}
 
-	sort.Slice(result, func(i, j int) bool { return result[i].ProfileId < result[j].ProfileId })
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ProfileId != result[j].ProfileId {
+			return result[i].ProfileId < result[j].ProfileId
+		}
+		return result[i].SpanId < result[j].SpanId
+	})
 	return result
 }
 
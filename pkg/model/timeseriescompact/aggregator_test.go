@@ -37,6 +37,19 @@ func TestMergeExemplars_SameProfile_KeepsHigherValue(t *testing.T) {
 	assert.Equal(t, int64(200), result[0].Value) // Higher value kept
 }
 
+func TestMergeExemplars_SameProfile_DifferentSpans(t *testing.T) {
+	a := []*queryv1.Exemplar{{ProfileId: "prof-1", SpanId: "span-2", Value: 100}}
+	b := []*queryv1.Exemplar{{ProfileId: "prof-1", SpanId: "span-1", Value: 200, TraceId: "trace-1"}}
+
+	result := MergeExemplars(a, b)
+	require.Len(t, result, 2)
+
+	// Sorted by profile ID, then by span ID
+	assert.Equal(t, "span-1", result[0].SpanId)
+	assert.Equal(t, "trace-1", result[0].TraceId)
+	assert.Equal(t, "span-2", result[1].SpanId)
+}
+
 func TestMergeExemplars_SameProfile_IntersectsRefs(t *testing.T) {
 	// Same profile with different attribute refs should intersect
 	a := []*queryv1.Exemplar{{ProfileId: "prof-1", Value: 100, AttributeRefs: []int64{1, 2, 3}}}
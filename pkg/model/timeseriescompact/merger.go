@@ -66,6 +66,7 @@ func (m *Merger) MergeReport(r *queryv1.TimeSeriesCompactReport) {
 							Timestamp:     ex.Timestamp,
 							ProfileId:     ex.ProfileId,
 							SpanId:        ex.SpanId,
+							TraceId:       ex.TraceId,
 							Value:         ex.Value,
 							AttributeRefs: remap.Refs(ex.AttributeRefs),
 						}
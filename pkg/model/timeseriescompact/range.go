@@ -7,8 +7,9 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/iter"
 )
 
-// RangeSeries aggregates compact points into time steps.
-func RangeSeries(it iter.Iterator[CompactValue], start, end, step int64) []*queryv1.Series {
+// RangeSeries aggregates compact points into time steps, keeping up to
+// maxExemplarsPerPoint exemplars with the highest values per point.
+func RangeSeries(it iter.Iterator[CompactValue], start, end, step int64, maxExemplarsPerPoint int) []*queryv1.Series {
 	defer it.Close()
 
 	seriesMap := make(map[string]*queryv1.Series)
@@ -30,7 +31,7 @@ Outer:
 
 			agg, ok := aggregators[key]
 			if !ok {
-				agg = &Aggregator{}
+				agg = &Aggregator{maxExemplars: maxExemplarsPerPoint}
 				aggregators[key] = agg
 				seriesRefs[key] = point.SeriesRefs
 			}
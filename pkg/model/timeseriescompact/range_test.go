@@ -12,7 +12,7 @@ import (
 
 func TestRangeSeries_EmptyIterator(t *testing.T) {
 	it := iter.NewEmptyIterator[CompactValue]()
-	series := RangeSeries(it, 0, 1000, 100)
+	series := RangeSeries(it, 0, 1000, 100, 1)
 	assert.Nil(t, series)
 }
 
@@ -29,7 +29,7 @@ func TestRangeSeries_SinglePoint(t *testing.T) {
 		}},
 	})
 
-	series := RangeSeries(m.Iterator(), 0, 1000, 1000)
+	series := RangeSeries(m.Iterator(), 0, 1000, 1000, 1)
 	require.Len(t, series, 1)
 	require.Len(t, series[0].Points, 1)
 	assert.Equal(t, int64(1000), series[0].Points[0].Timestamp)
@@ -54,7 +54,7 @@ func TestRangeSeries_AggregatesWithinStep(t *testing.T) {
 	})
 
 	// Step of 1000 should aggregate all points into one
-	series := RangeSeries(m.Iterator(), 0, 1000, 1000)
+	series := RangeSeries(m.Iterator(), 0, 1000, 1000, 1)
 	require.Len(t, series, 1)
 	require.Len(t, series[0].Points, 1)
 	assert.Equal(t, 60.0, series[0].Points[0].Value)
@@ -78,7 +78,7 @@ func TestRangeSeries_MultipleSteps(t *testing.T) {
 		}},
 	})
 
-	series := RangeSeries(m.Iterator(), 0, 3000, 1000)
+	series := RangeSeries(m.Iterator(), 0, 3000, 1000, 1)
 	require.Len(t, series, 1)
 	require.Len(t, series[0].Points, 3)
 
@@ -122,7 +122,7 @@ func TestRangeSeries_MultipleSeries(t *testing.T) {
 		}},
 	})
 
-	series := RangeSeries(m.Iterator(), 0, 1000, 1000)
+	series := RangeSeries(m.Iterator(), 0, 1000, 1000, 1)
 	require.Len(t, series, 2)
 
 	// Each series should have one point
@@ -151,7 +151,7 @@ func TestRangeSeries_WithAnnotations(t *testing.T) {
 		}},
 	})
 
-	series := RangeSeries(m.Iterator(), 0, 1000, 1000)
+	series := RangeSeries(m.Iterator(), 0, 1000, 1000, 1)
 	require.Len(t, series, 1)
 	require.Len(t, series[0].Points, 1)
 
@@ -192,7 +192,7 @@ func TestRangeSeries_WithExemplars(t *testing.T) {
 		}},
 	})
 
-	series := RangeSeries(m.Iterator(), 0, 1000, 1000)
+	series := RangeSeries(m.Iterator(), 0, 1000, 1000, 1)
 	require.Len(t, series, 1)
 	require.Len(t, series[0].Points, 1)
 
@@ -204,6 +204,45 @@ func TestRangeSeries_WithExemplars(t *testing.T) {
 	assert.Equal(t, "prof-1", point.Exemplars[0].ProfileId)
 }
 
+func TestRangeSeries_ExemplarLimit(t *testing.T) {
+	m := NewMerger()
+	m.MergeReport(&queryv1.TimeSeriesCompactReport{
+		AttributeTable: &queryv1.AttributeTable{
+			Keys:   []string{"service"},
+			Values: []string{"api"},
+		},
+		TimeSeries: []*queryv1.Series{{
+			AttributeRefs: []int64{0},
+			Points: []*queryv1.Point{
+				{
+					Timestamp: 100,
+					Value:     100,
+					Exemplars: []*queryv1.Exemplar{
+						{ProfileId: "prof-1", SpanId: "span-1", Value: 60},
+						{ProfileId: "prof-1", SpanId: "span-2", Value: 40},
+					},
+				},
+				{
+					Timestamp: 200,
+					Value:     50,
+					Exemplars: []*queryv1.Exemplar{
+						{ProfileId: "prof-2", SpanId: "span-3", Value: 50},
+					},
+				},
+			},
+		}},
+	})
+
+	series := RangeSeries(m.Iterator(), 0, 1000, 1000, 2)
+	require.Len(t, series, 1)
+	require.Len(t, series[0].Points, 1)
+
+	point := series[0].Points[0]
+	require.Len(t, point.Exemplars, 2)
+	assert.Equal(t, "span-1", point.Exemplars[0].SpanId)
+	assert.Equal(t, "span-3", point.Exemplars[1].SpanId)
+}
+
 func TestRangeSeries_DifferentTimestampsNoCorruption(t *testing.T) {
 	m := NewMerger()
 	m.MergeReport(&queryv1.TimeSeriesCompactReport{
@@ -221,7 +260,7 @@ func TestRangeSeries_DifferentTimestampsNoCorruption(t *testing.T) {
 		}},
 	})
 
-	series := RangeSeries(m.Iterator(), 1000, 3000, 1000)
+	series := RangeSeries(m.Iterator(), 1000, 3000, 1000, 1)
 	require.Len(t, series, 1)
 	require.Len(t, series[0].Points, 3)
 
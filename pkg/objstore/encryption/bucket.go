@@ -0,0 +1,289 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/thanos-io/objstore"
+
+	phlareobj "github.com/grafana/pyroscope/v2/pkg/objstore"
+)
+
+const defaultCacheSize = 4 << 10
+
+// Bucket is an objstore.Bucket wrapper that encrypts objects on upload
+// and decrypts them on read (envelope encryption): every object is
+// encrypted with a unique data key, which in turn is encrypted with the
+// tenant key managed by the KeyProvider. Objects that were written
+// before the encryption was enabled are read as is.
+//
+// Ranged reads are supported: only the chunks covering the requested
+// range are fetched and decrypted. The data keys are cached per object,
+// therefore a ranged read does not involve the key provider, nor does it
+// fetch the object header, once the object has been accessed. Objects
+// that are not encrypted are not cached: the object might be overwritten
+// by another process.
+//
+// Object attributes report the plaintext size. Note, however, that the
+// sizes reported by IterWithAttributes are the sizes of the stored
+// (encrypted) objects.
+type Bucket struct {
+	bucket    phlareobj.Bucket
+	keys      KeyProvider
+	chunkSize int64
+	cache     *lru.Cache[string, *objectKey]
+}
+
+// objectKey is the decoded header of an object.
+type objectKey struct {
+	encrypted bool
+	chunkSize int64
+	aead      cipher.AEAD
+}
+
+func NewBucket(bucket phlareobj.Bucket, keys KeyProvider) *Bucket {
+	return newBucket(bucket, keys, defaultChunkSize)
+}
+
+func newBucket(bucket phlareobj.Bucket, keys KeyProvider, chunkSize int64) *Bucket {
+	cache, _ := lru.New[string, *objectKey](defaultCacheSize)
+	return &Bucket{
+		bucket:    bucket,
+		keys:      keys,
+		chunkSize: chunkSize,
+		cache:     cache,
+	}
+}
+
+// TenantFromObjectPath returns the tenant the object belongs to, based
+// on the storage layout. Multi-tenant objects (segments) and objects
+// outside the known layout belong to no tenant, and are encrypted with
+// the default key:
+//
+//	segments/<shard>/anonymous/<block>/block.bin  (multi-tenant)
+//	blocks/<shard>/<tenant>/<block>/block.bin
+//	dlq/<shard>/<tenant>/<block>/block.bin
+//	debug-info/<tenant>/...
+//	<tenant>/phlaredb/...                         (v1 blocks)
+//	<tenant>/adhoc/...                            (ad-hoc profiles)
+func TenantFromObjectPath(name string) string {
+	parts := strings.SplitN(name, "/", 4)
+	switch {
+	case len(parts) < 2:
+		return ""
+	case parts[0] == "segments":
+		return ""
+	case parts[0] == "blocks" || parts[0] == "dlq":
+		if len(parts) < 3 || parts[2] == "anonymous" {
+			return ""
+		}
+		return parts[2]
+	case parts[0] == "debug-info":
+		return parts[1]
+	case parts[1] == "phlaredb" || parts[1] == "adhoc":
+		return parts[0]
+	default:
+		return ""
+	}
+}
+
+func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader, opts ...objstore.ObjectUploadOption) error {
+	dk, err := b.keys.GenerateDataKey(ctx, TenantFromObjectPath(name))
+	if err != nil {
+		return fmt.Errorf("generating data key: %w", err)
+	}
+	h := header{
+		chunkSize:    b.chunkSize,
+		keyID:        dk.KeyID,
+		encryptedKey: dk.Encrypted,
+	}
+	hb, err := h.marshal()
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dk.Plaintext)
+	if err != nil {
+		return err
+	}
+	b.cache.Remove(name)
+	return b.bucket.Upload(ctx, name, newEncryptingReader(r, aead, hb, b.chunkSize), opts...)
+}
+
+func (b *Bucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	rc, err := b.bucket.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	hb := make([]byte, headerSize)
+	n, err := io.ReadFull(rc, hb)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		_ = rc.Close()
+		return nil, err
+	}
+	if !isEncrypted(hb[:n]) {
+		return readCloser{
+			Reader: io.MultiReader(bytes.NewReader(hb[:n]), rc),
+			Closer: rc,
+		}, nil
+	}
+	k, err := b.objectKey(ctx, name, hb[:n])
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	return b.newDecryptingReader(name, rc, k, 0, 0, -1), nil
+}
+
+func (b *Bucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if off < 0 {
+		return nil, fmt.Errorf("invalid offset %d", off)
+	}
+	k, err := b.objectKeyFromStorage(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !k.encrypted {
+		return b.bucket.GetRange(ctx, name, off, length)
+	}
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	encChunkSize := k.chunkSize + tagSize
+	first := off / k.chunkSize
+	encOff := headerSize + first*encChunkSize
+	encLen := int64(-1)
+	if length > 0 {
+		last := (off + length - 1) / k.chunkSize
+		encLen = (last - first + 1) * encChunkSize
+	}
+	rc, err := b.bucket.GetRange(ctx, name, encOff, encLen)
+	if err != nil {
+		return nil, err
+	}
+	return b.newDecryptingReader(name, rc, k, uint64(first), off-first*k.chunkSize, length), nil
+}
+
+func (b *Bucket) newDecryptingReader(name string, rc io.ReadCloser, k *objectKey, index uint64, skip, length int64) io.ReadCloser {
+	r := newDecryptingReader(rc, k.aead, k.chunkSize, index, skip, length)
+	// The object might have been overwritten with a new data key.
+	r.onCorrupted = func() { b.cache.Remove(name) }
+	return r
+}
+
+func (b *Bucket) ReaderAt(ctx context.Context, name string) (phlareobj.ReaderAtCloser, error) {
+	// Reads are served by GetRange, which decrypts the chunks.
+	return (&phlareobj.ReaderAtBucket{Bucket: b}).ReaderAt(ctx, name)
+}
+
+func (b *Bucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	attrs, err := b.bucket.Attributes(ctx, name)
+	if err != nil {
+		return attrs, err
+	}
+	k, err := b.objectKeyFromStorage(ctx, name)
+	if err != nil {
+		return attrs, err
+	}
+	if k.encrypted {
+		attrs.Size = plaintextSize(attrs.Size, k.chunkSize)
+	}
+	return attrs, nil
+}
+
+func (b *Bucket) Delete(ctx context.Context, name string) error {
+	b.cache.Remove(name)
+	return b.bucket.Delete(ctx, name)
+}
+
+func (b *Bucket) objectKeyFromStorage(ctx context.Context, name string) (*objectKey, error) {
+	if k, ok := b.cache.Get(name); ok {
+		return k, nil
+	}
+	rc, err := b.bucket.GetRange(ctx, name, 0, headerSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	hb, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return b.objectKey(ctx, name, hb)
+}
+
+func (b *Bucket) objectKey(ctx context.Context, name string, hb []byte) (*objectKey, error) {
+	if k, ok := b.cache.Get(name); ok {
+		return k, nil
+	}
+	k := new(objectKey)
+	if isEncrypted(hb) {
+		var h header
+		if err := h.unmarshal(hb); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		dk, err := b.keys.DecryptDataKey(ctx, h.keyID, h.encryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting data key of %s: %w", name, err)
+		}
+		if k.aead, err = newAEAD(dk); err != nil {
+			return nil, err
+		}
+		k.encrypted = true
+		k.chunkSize = h.chunkSize
+		b.cache.Add(name, k)
+	}
+	return k, nil
+}
+
+func (b *Bucket) Close() error { return b.bucket.Close() }
+
+func (b *Bucket) Name() string { return b.bucket.Name() }
+
+func (b *Bucket) Provider() objstore.ObjProvider { return b.bucket.Provider() }
+
+func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	return b.bucket.Iter(ctx, dir, f, options...)
+}
+
+func (b *Bucket) IterWithAttributes(ctx context.Context, dir string, f func(attrs objstore.IterObjectAttributes) error, options ...objstore.IterOption) error {
+	return b.bucket.IterWithAttributes(ctx, dir, f, options...)
+}
+
+func (b *Bucket) SupportedIterOptions() []objstore.IterOptionType {
+	return b.bucket.SupportedIterOptions()
+}
+
+func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
+	return b.bucket.Exists(ctx, name)
+}
+
+func (b *Bucket) IsObjNotFoundErr(err error) bool { return b.bucket.IsObjNotFoundErr(err) }
+
+func (b *Bucket) IsAccessDeniedErr(err error) bool { return b.bucket.IsAccessDeniedErr(err) }
+
+func (b *Bucket) ReaderWithExpectedErrs(fn phlareobj.IsOpFailureExpectedFunc) phlareobj.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+func (b *Bucket) WithExpectedErrs(fn phlareobj.IsOpFailureExpectedFunc) phlareobj.Bucket {
+	if ib, ok := b.bucket.(phlareobj.InstrumentedBucket); ok {
+		return &Bucket{
+			bucket:    ib.WithExpectedErrs(fn),
+			keys:      b.keys,
+			chunkSize: b.chunkSize,
+			cache:     b.cache,
+		}
+	}
+	return b
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
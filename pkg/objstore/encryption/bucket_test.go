@@ -0,0 +1,215 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	phlareobj "github.com/grafana/pyroscope/v2/pkg/objstore"
+	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/memory"
+)
+
+func randomBytes(t *testing.T, n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return b
+}
+
+func newTestKeyProvider(t *testing.T, tenants ...string) *FileKeyProvider {
+	keys := map[string][]byte{DefaultKeyID: randomBytes(t, DataKeySize)}
+	for _, tenant := range tenants {
+		keys[tenant] = randomBytes(t, DataKeySize)
+	}
+	p, err := NewStaticKeyProvider(keys)
+	require.NoError(t, err)
+	return p
+}
+
+func newTestBucket(t *testing.T, chunkSize int64) (*Bucket, *memory.InMemBucket) {
+	inner := memory.NewInMemBucket()
+	return newBucket(phlareobj.NewBucket(inner), newTestKeyProvider(t, "tenant-a"), chunkSize), inner
+}
+
+func readAll(t *testing.T) func(io.ReadCloser, error) []byte {
+	return func(rc io.ReadCloser, err error) []byte {
+		require.NoError(t, err)
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		return b
+	}
+}
+
+func Test_Bucket_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for _, size := range []int{0, 1, 99, 100, 101, 1000, 1050} {
+		bkt, inner := newTestBucket(t, 100)
+		data := randomBytes(t, size)
+		const name = "blocks/1/tenant-a/block/block.bin"
+		require.NoError(t, bkt.Upload(ctx, name, bytes.NewReader(data)))
+
+		stored := readAll(t)(inner.Get(ctx, name))
+		assert.NotContains(t, string(stored), string(data[:min(size, 16)]))
+		assert.Equal(t, data, readAll(t)(bkt.Get(ctx, name)), "size %d", size)
+
+		attrs, err := bkt.Attributes(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, int64(size), attrs.Size, "size %d", size)
+	}
+}
+
+func Test_Bucket_GetRange(t *testing.T) {
+	ctx := context.Background()
+	bkt, _ := newTestBucket(t, 100)
+	data := randomBytes(t, 1050)
+	const name = "segments/1/anonymous/block/block.bin"
+	require.NoError(t, bkt.Upload(ctx, name, bytes.NewReader(data)))
+
+	for _, tc := range []struct{ off, length int64 }{
+		{0, 1},
+		{0, 100},
+		{99, 2},
+		{150, 300},
+		{1000, 50},
+		{1049, 1},
+		{500, -1},
+		{0, 2000},
+		{1040, 100},
+	} {
+		end := int64(len(data))
+		if tc.length >= 0 {
+			end = min(end, tc.off+tc.length)
+		}
+		assert.Equal(t, data[tc.off:end], readAll(t)(bkt.GetRange(ctx, name, tc.off, tc.length)), "%+v", tc)
+	}
+
+	r, err := bkt.ReaderAt(ctx, name)
+	require.NoError(t, err)
+	defer r.Close()
+	buf := make([]byte, 250)
+	_, err = r.ReadAt(buf, 333)
+	require.NoError(t, err)
+	assert.Equal(t, data[333:583], buf)
+}
+
+func Test_Bucket_PlaintextObjects(t *testing.T) {
+	ctx := context.Background()
+	bkt, inner := newTestBucket(t, 100)
+	data := randomBytes(t, 1000)
+	const name = "blocks/1/tenant-a/legacy/block.bin"
+	require.NoError(t, inner.Upload(ctx, name, bytes.NewReader(data)))
+
+	assert.Equal(t, data, readAll(t)(bkt.Get(ctx, name)))
+	assert.Equal(t, data[150:450], readAll(t)(bkt.GetRange(ctx, name, 150, 300)))
+	attrs, err := bkt.Attributes(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), attrs.Size)
+
+	require.NoError(t, inner.Upload(ctx, "small", bytes.NewReader([]byte("abc"))))
+	assert.Equal(t, []byte("abc"), readAll(t)(bkt.Get(ctx, "small")))
+}
+
+func Test_Bucket_Tampering(t *testing.T) {
+	ctx := context.Background()
+	bkt, inner := newTestBucket(t, 100)
+	data := randomBytes(t, 1000)
+	const name = "blocks/1/tenant-a/block/block.bin"
+	require.NoError(t, bkt.Upload(ctx, name, bytes.NewReader(data)))
+	stored := readAll(t)(inner.Get(ctx, name))
+
+	t.Run("modified chunk", func(t *testing.T) {
+		modified := bytes.Clone(stored)
+		modified[headerSize+150] ^= 1
+		require.NoError(t, inner.Upload(ctx, name, bytes.NewReader(modified)))
+		rc, err := bkt.Get(ctx, name)
+		require.NoError(t, err)
+		_, err = io.ReadAll(rc)
+		require.ErrorIs(t, err, ErrCorrupted)
+		rc, err = bkt.GetRange(ctx, name, 0, 100)
+		require.NoError(t, err)
+		_, err = io.ReadAll(rc)
+		require.NoError(t, err)
+	})
+
+	t.Run("truncated at chunk boundary", func(t *testing.T) {
+		truncated := stored[:headerSize+5*(100+tagSize)]
+		require.NoError(t, inner.Upload(ctx, name, bytes.NewReader(truncated)))
+		rc, err := bkt.Get(ctx, name)
+		require.NoError(t, err)
+		_, err = io.ReadAll(rc)
+		require.ErrorIs(t, err, ErrTruncated)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		require.NoError(t, inner.Upload(ctx, name, bytes.NewReader(stored)))
+		other := NewBucket(phlareobj.NewBucket(inner), newTestKeyProvider(t))
+		_, err := other.Get(ctx, name)
+		require.Error(t, err)
+	})
+}
+
+func Test_Bucket_TenantKeys(t *testing.T) {
+	ctx := context.Background()
+	bkt, inner := newTestBucket(t, 100)
+	for name, keyID := range map[string]string{
+		"blocks/1/tenant-a/block/block.bin":    "tenant-a",
+		"blocks/1/tenant-b/block/block.bin":    DefaultKeyID,
+		"segments/1/anonymous/block/block.bin": DefaultKeyID,
+	} {
+		require.NoError(t, bkt.Upload(ctx, name, bytes.NewReader([]byte("data"))))
+		var h header
+		require.NoError(t, h.unmarshal(readAll(t)(inner.Get(ctx, name))))
+		assert.Equal(t, keyID, h.keyID, name)
+	}
+}
+
+func Test_TenantFromObjectPath(t *testing.T) {
+	for path, tenant := range map[string]string{
+		"segments/1/anonymous/block/block.bin":  "",
+		"blocks/1/tenant-a/block/block.bin":     "tenant-a",
+		"blocks/1/anonymous/block/block.bin":    "",
+		"dlq/1/tenant-a/block/metadata.pb":      "tenant-a",
+		"debug-info/tenant-a/build-id/elf":      "tenant-a",
+		"tenant-a/phlaredb/block/meta.json":     "tenant-a",
+		"tenant-a/adhoc/profile.pb.gz":          "tenant-a",
+		"tenant-a/something-else/profile.pb.gz": "",
+		"object":                                "",
+	} {
+		assert.Equal(t, tenant, TenantFromObjectPath(path), path)
+	}
+}
+
+func Test_FileKeyProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	keys := "keys:\n" +
+		"  default: " + base64.StdEncoding.EncodeToString(randomBytes(t, DataKeySize)) + "\n" +
+		"  tenant-a: " + base64.StdEncoding.EncodeToString(randomBytes(t, DataKeySize)) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(keys), 0o600))
+
+	p, err := NewFileKeyProvider(path)
+	require.NoError(t, err)
+	dk, err := p.GenerateDataKey(ctx, "tenant-a")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", dk.KeyID)
+	plaintext, err := p.DecryptDataKey(ctx, dk.KeyID, dk.Encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, dk.Plaintext, plaintext)
+
+	// The data key is bound to the key ID.
+	_, err = p.DecryptDataKey(ctx, DefaultKeyID, dk.Encrypted)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("keys:\n  tenant-a: AAAA\n"), 0o600))
+	_, err = NewFileKeyProvider(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package encryption
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+const KeyProviderFile = "file"
+
+var supportedKeyProviders = []string{KeyProviderFile}
+
+type Config struct {
+	Enabled     bool                  `yaml:"enabled" category:"experimental"`
+	KeyProvider string                `yaml:"key_provider" category:"experimental"`
+	File        FileKeyProviderConfig `yaml:"file" category:"experimental"`
+}
+
+type FileKeyProviderConfig struct {
+	Path string `yaml:"path" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable client-side encryption of objects stored in the backend storage. Objects written before the encryption was enabled remain readable.")
+	f.StringVar(&cfg.KeyProvider, prefix+"key-provider", KeyProviderFile, fmt.Sprintf("Key provider used to encrypt data keys. Supported values are: %v.", supportedKeyProviders))
+	f.StringVar(&cfg.File.Path, prefix+"file.path", "", "Path to the YAML file with base64-encoded 256-bit keys: per-tenant keys, and the 'default' key used for other tenants and multi-tenant objects.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	switch cfg.KeyProvider {
+	case KeyProviderFile:
+		if cfg.File.Path == "" {
+			return errors.New("encryption keys file path is required")
+		}
+	default:
+		return fmt.Errorf("unsupported key provider %q", cfg.KeyProvider)
+	}
+	return nil
+}
+
+// NewKeyProvider creates the key provider configured.
+func NewKeyProvider(cfg Config) (KeyProvider, error) {
+	switch cfg.KeyProvider {
+	case KeyProviderFile:
+		return NewFileKeyProvider(cfg.File.Path)
+	default:
+		return nil, fmt.Errorf("unsupported key provider %q", cfg.KeyProvider)
+	}
+}
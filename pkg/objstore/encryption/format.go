@@ -0,0 +1,266 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypted object layout:
+//
+//	| header (512 bytes) | chunk 0 | chunk 1 | ... | chunk N |
+//
+// The header is fixed-size, which makes it possible to map a plaintext
+// range to the ciphertext range without reading the header first:
+//
+//	| magic (8) | chunk size u32 | key ID len u16 | key ID | data key len u16 | data key | zero padding |
+//
+// The object is split into chunks of the given plaintext size; each chunk
+// is encrypted with AES-256-GCM and carries its own authentication tag.
+// Therefore, any range of the object can be read and authenticated
+// without reading the whole object. The chunk nonce is derived from the
+// chunk index; the nonce of the last chunk is marked, which allows to
+// detect truncated objects. An empty object consists of a single empty
+// chunk.
+
+const (
+	headerSize       = 512
+	defaultChunkSize = 64 << 10
+	tagSize          = 16
+	nonceSize        = 12
+)
+
+var magic = []byte("PYROENC1")
+
+var (
+	ErrTruncated = errors.New("encrypted object is truncated")
+	ErrCorrupted = errors.New("encrypted object is corrupted")
+)
+
+type header struct {
+	chunkSize    int64
+	keyID        string
+	encryptedKey []byte
+}
+
+func (h *header) marshal() ([]byte, error) {
+	b := make([]byte, headerSize)
+	copy(b, magic)
+	off := len(magic)
+	binary.BigEndian.PutUint32(b[off:], uint32(h.chunkSize))
+	off += 4
+	if off+2+len(h.keyID)+2+len(h.encryptedKey) > headerSize {
+		return nil, fmt.Errorf("key ID and data key do not fit the header")
+	}
+	binary.BigEndian.PutUint16(b[off:], uint16(len(h.keyID)))
+	off += 2
+	off += copy(b[off:], h.keyID)
+	binary.BigEndian.PutUint16(b[off:], uint16(len(h.encryptedKey)))
+	off += 2
+	copy(b[off:], h.encryptedKey)
+	return b, nil
+}
+
+// isEncrypted reports whether b starts with the header magic.
+func isEncrypted(b []byte) bool { return bytes.HasPrefix(b, magic) }
+
+func (h *header) unmarshal(b []byte) error {
+	if len(b) < headerSize || !isEncrypted(b) {
+		return fmt.Errorf("%w: invalid header", ErrCorrupted)
+	}
+	b = b[len(magic):headerSize]
+	h.chunkSize = int64(binary.BigEndian.Uint32(b))
+	if h.chunkSize == 0 {
+		return fmt.Errorf("%w: invalid chunk size", ErrCorrupted)
+	}
+	b = b[4:]
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n+2 {
+		return fmt.Errorf("%w: invalid key ID", ErrCorrupted)
+	}
+	h.keyID = string(b[2 : 2+n])
+	b = b[2+n:]
+	n = int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return fmt.Errorf("%w: invalid data key", ErrCorrupted)
+	}
+	h.encryptedKey = bytes.Clone(b[2 : 2+n])
+	return nil
+}
+
+// plaintextSize returns the plaintext size of an encrypted object
+// of the given size.
+func plaintextSize(size, chunkSize int64) int64 {
+	n := size - headerSize
+	if n <= 0 {
+		return 0
+	}
+	chunks := (n + chunkSize + tagSize - 1) / (chunkSize + tagSize)
+	return max(0, n-chunks*tagSize)
+}
+
+func chunkNonce(nonce []byte, index uint64, last bool) []byte {
+	clear(nonce)
+	if last {
+		nonce[0] = 1
+	}
+	binary.BigEndian.PutUint64(nonce[4:], index)
+	return nonce
+}
+
+// encryptingReader produces the encrypted object from the plaintext.
+type encryptingReader struct {
+	src   *bufio.Reader
+	aead  cipher.AEAD
+	nonce []byte
+	plain []byte
+	buf   []byte
+	out   []byte
+	index uint64
+	done  bool
+}
+
+func newEncryptingReader(src io.Reader, aead cipher.AEAD, h []byte, chunkSize int64) *encryptingReader {
+	return &encryptingReader{
+		src:   bufio.NewReaderSize(src, int(chunkSize)),
+		aead:  aead,
+		nonce: make([]byte, nonceSize),
+		plain: make([]byte, chunkSize),
+		buf:   make([]byte, 0, chunkSize+tagSize),
+		out:   h,
+	}
+}
+
+func (r *encryptingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *encryptingReader) next() error {
+	n, err := io.ReadFull(r.src, r.plain)
+	switch {
+	case err == nil:
+		// The chunk is the last one if there is no more data.
+		if _, err = r.src.Peek(1); err == io.EOF {
+			r.done = true
+		} else if err != nil {
+			return err
+		}
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		r.done = true
+	default:
+		return err
+	}
+	r.out = r.aead.Seal(r.buf[:0], chunkNonce(r.nonce, r.index, r.done), r.plain[:n], nil)
+	r.index++
+	return nil
+}
+
+// decryptingReader reads the plaintext from the encrypted chunks,
+// starting from the given chunk.
+type decryptingReader struct {
+	src       io.ReadCloser
+	aead      cipher.AEAD
+	nonce     []byte
+	chunkSize int64
+	buf       []byte
+	plain     []byte
+	out       []byte
+	index     uint64
+	skip      int64 // Bytes to skip in the first chunk.
+	remaining int64 // Bytes to return; negative means no limit.
+	last      bool
+
+	onCorrupted func()
+}
+
+func newDecryptingReader(src io.ReadCloser, aead cipher.AEAD, chunkSize int64, index uint64, skip, length int64) *decryptingReader {
+	return &decryptingReader{
+		src:       src,
+		aead:      aead,
+		nonce:     make([]byte, nonceSize),
+		chunkSize: chunkSize,
+		buf:       make([]byte, chunkSize+tagSize),
+		plain:     make([]byte, 0, chunkSize),
+		index:     index,
+		skip:      skip,
+		remaining: length,
+	}
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	for len(r.out) == 0 {
+		if r.last {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	if r.remaining >= 0 && int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	if r.remaining > 0 {
+		r.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *decryptingReader) next() error {
+	n, err := io.ReadFull(r.src, r.buf)
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		if n < tagSize {
+			// The object ended before the last chunk.
+			return ErrTruncated
+		}
+	default:
+		return err
+	}
+	chunk := r.buf[:n]
+	// The last chunk may be of the full size, therefore we can't tell
+	// whether it's the last one until we try to open it. Note that the
+	// chunk is not decrypted in place: it may need to be opened twice.
+	plain, err := r.aead.Open(r.plain[:0], chunkNonce(r.nonce, r.index, false), chunk, nil)
+	if err != nil {
+		if plain, err = r.aead.Open(r.plain[:0], chunkNonce(r.nonce, r.index, true), chunk, nil); err != nil {
+			if r.onCorrupted != nil {
+				r.onCorrupted()
+			}
+			return fmt.Errorf("%w: chunk %d: %w", ErrCorrupted, r.index, err)
+		}
+		r.last = true
+	} else if int64(len(plain)) < r.chunkSize {
+		// Only the last chunk may be shorter.
+		return ErrTruncated
+	}
+	r.index++
+	if r.skip > 0 {
+		s := min(r.skip, int64(len(plain)))
+		plain = plain[s:]
+		r.skip -= s
+	}
+	r.out = plain
+	return nil
+}
+
+func (r *decryptingReader) Close() error { return r.src.Close() }
@@ -0,0 +1,144 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// DataKeySize is the size of data encryption keys (AES-256).
+const DataKeySize = 32
+
+// DataKey is a data encryption key (DEK) used to encrypt a single object.
+type DataKey struct {
+	// KeyID identifies the key encryption key (KEK) the data key is
+	// encrypted with. It is stored in the object header as is.
+	KeyID string
+	// Plaintext is the data key used to encrypt the object.
+	// It is never stored.
+	Plaintext []byte
+	// Encrypted is the data key encrypted with the KEK.
+	// It is stored in the object header.
+	Encrypted []byte
+}
+
+// KeyProvider manages key encryption keys (KEK). The provider generates
+// a new data key for every object, and decrypts the data keys of objects
+// being read. Implementations may be backed by a KMS service, therefore
+// the data keys are cached on the read path.
+type KeyProvider interface {
+	// GenerateDataKey returns a new data key for the tenant. An empty
+	// tenant ID denotes multi-tenant objects, such as segments.
+	GenerateDataKey(ctx context.Context, tenantID string) (DataKey, error)
+	// DecryptDataKey returns the plaintext of the encrypted data key.
+	DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
+
+// DefaultKeyID is the ID of the key used for tenants that do not have
+// a dedicated key, and for multi-tenant objects.
+const DefaultKeyID = "default"
+
+// FileKeyProvider is a KeyProvider that loads the key encryption keys
+// from a local YAML file. It is primarily intended for testing and for
+// deployments where keys are distributed as secrets. The file format:
+//
+//	keys:
+//	  default: <base64-encoded 32 bytes>
+//	  tenant-a: <base64-encoded 32 bytes>
+//
+// Tenants without a dedicated key use the default one. Data keys are
+// encrypted with AES-256-GCM.
+type FileKeyProvider struct {
+	keys map[string]cipher.AEAD
+}
+
+type keysFile struct {
+	Keys map[string]string `yaml:"keys"`
+}
+
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading keys file: %w", err)
+	}
+	var f keysFile
+	if err = yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parsing keys file: %w", err)
+	}
+	keys := make(map[string][]byte, len(f.Keys))
+	for id, k := range f.Keys {
+		if keys[id], err = base64.StdEncoding.DecodeString(k); err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+	}
+	return NewStaticKeyProvider(keys)
+}
+
+// NewStaticKeyProvider creates a FileKeyProvider from the given keys.
+// The default key (DefaultKeyID) is required.
+func NewStaticKeyProvider(keys map[string][]byte) (*FileKeyProvider, error) {
+	if _, ok := keys[DefaultKeyID]; !ok {
+		return nil, fmt.Errorf("the %q key is required", DefaultKeyID)
+	}
+	p := &FileKeyProvider{keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, k := range keys {
+		if len(k) != DataKeySize {
+			return nil, fmt.Errorf("invalid key %q: expected %d bytes, got %d", id, DataKeySize, len(k))
+		}
+		aead, err := newAEAD(k)
+		if err != nil {
+			return nil, err
+		}
+		p.keys[id] = aead
+	}
+	return p, nil
+}
+
+func (p *FileKeyProvider) GenerateDataKey(_ context.Context, tenantID string) (DataKey, error) {
+	keyID := tenantID
+	kek, ok := p.keys[keyID]
+	if !ok || keyID == "" {
+		keyID = DefaultKeyID
+		kek = p.keys[keyID]
+	}
+	dk := DataKey{
+		KeyID:     keyID,
+		Plaintext: make([]byte, DataKeySize),
+	}
+	if _, err := rand.Read(dk.Plaintext); err != nil {
+		return DataKey{}, err
+	}
+	nonce := make([]byte, kek.NonceSize(), kek.NonceSize()+DataKeySize+kek.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return DataKey{}, err
+	}
+	dk.Encrypted = kek.Seal(nonce, nonce, dk.Plaintext, []byte(keyID))
+	return dk, nil
+}
+
+func (p *FileKeyProvider) DecryptDataKey(_ context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	kek, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	if len(encrypted) < kek.NonceSize() {
+		return nil, errors.New("invalid encrypted data key")
+	}
+	nonce, sealed := encrypted[:kek.NonceSize()], encrypted[kek.NonceSize():]
+	return kek.Open(nil, nonce, sealed, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -16,13 +16,16 @@ type Admin struct {
 	handlers *Handlers
 }
 
-func NewAdmin(metastoreClient MetastoreClient, bucket objstore.Bucket, logger log.Logger) (*Admin, error) {
+// NewAdmin creates the blocks admin tool. The cold storage
+// tier bucket is optional and may be nil.
+func NewAdmin(metastoreClient MetastoreClient, bucket, coldBucket objstore.Bucket, logger log.Logger) (*Admin, error) {
 	a := &Admin{
 		logger: logger,
 		handlers: &Handlers{
 			Logger:          logger,
 			MetastoreClient: metastoreClient,
 			Bucket:          bucket,
+			ColdBucket:      coldBucket,
 		},
 	}
 	a.Service = services.NewBasicService(nil, a.running, nil)
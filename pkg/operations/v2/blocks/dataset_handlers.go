@@ -198,7 +198,7 @@ func (h *Handlers) CreateDatasetTSDBIndexHandler() func(http.ResponseWriter, *ht
 }
 
 func (h *Handlers) readTSDBIndex(ctx context.Context, blockMeta *metastorev1.BlockMeta, dataset *metastorev1.Dataset) (*tsdbIndexInfo, error) {
-	obj := block.NewObject(h.Bucket, blockMeta)
+	obj := h.newObject(blockMeta)
 	if err := obj.Open(ctx); err != nil {
 		return nil, fmt.Errorf("failed to open block object: %w", err)
 	}
@@ -385,7 +385,7 @@ func (h *Handlers) CreateDatasetSymbolsHandler() func(http.ResponseWriter, *http
 }
 
 func (h *Handlers) readSymbols(ctx context.Context, blockMeta *metastorev1.BlockMeta, dataset *metastorev1.Dataset, page, pageSize int) (*symbolsInfo, error) {
-	obj := block.NewObject(h.Bucket, blockMeta)
+	obj := h.newObject(blockMeta)
 	if err := obj.Open(ctx); err != nil {
 		return nil, fmt.Errorf("failed to open block object: %w", err)
 	}
@@ -15,6 +15,7 @@ import (
 	"google.golang.org/grpc"
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block"
 	"github.com/grafana/pyroscope/v2/pkg/objstore"
 	httputil "github.com/grafana/pyroscope/v2/pkg/util/http"
 )
@@ -28,7 +29,14 @@ type MetastoreClient interface {
 type Handlers struct {
 	MetastoreClient MetastoreClient
 	Bucket          objstore.Bucket
-	Logger          log.Logger
+	// ColdBucket is the bucket of the cold storage tier, if configured.
+	ColdBucket objstore.Bucket
+	Logger     log.Logger
+}
+
+// newObject binds the block to the bucket of its storage tier.
+func (h *Handlers) newObject(md *metastorev1.BlockMeta) *block.Object {
+	return block.NewObject(block.StorageTierBucket(h.Bucket, h.ColdBucket, md.StorageTier), md)
 }
 
 func (h *Handlers) CreateIndexHandler() func(http.ResponseWriter, *http.Request) {
@@ -88,7 +88,7 @@ func (h *Handlers) CreateDatasetProfilesHandler() func(http.ResponseWriter, *htt
 }
 
 func (h *Handlers) readProfilesFromDataset(ctx context.Context, blockMeta *metastorev1.BlockMeta, dataset *metastorev1.Dataset, page, pageSize int) ([]profileInfo, int, error) {
-	obj := block.NewObject(h.Bucket, blockMeta)
+	obj := h.newObject(blockMeta)
 	if err := obj.Open(ctx); err != nil {
 		return nil, 0, fmt.Errorf("failed to open block object: %w", err)
 	}
@@ -320,7 +320,7 @@ func (h *Handlers) buildProfileResolver(
 	dataset *metastorev1.Dataset,
 	rowNum int64,
 ) (*symdb.Resolver, int64, *profileMetadata, error) {
-	obj := block.NewObject(h.Bucket, blockMeta)
+	obj := h.newObject(blockMeta)
 	if err := obj.Open(ctx); err != nil {
 		return nil, 0, nil, fmt.Errorf("failed to open block object: %w", err)
 	}
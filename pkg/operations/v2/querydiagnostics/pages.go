@@ -4,6 +4,10 @@ import (
 	_ "embed"
 	"html/template"
 	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/diagnostics"
 )
 
 //go:embed query_diagnostics.gohtml
@@ -12,13 +16,22 @@ var diagnosticsPageHtml string
 //go:embed diagnostics_list.gohtml
 var diagnosticsListPageHtml string
 
+//go:embed running_queries.gohtml
+var runningQueriesPageHtml string
+
 type pageContent struct {
 	Now time.Time
 }
 
+type runningQueriesPageContent struct {
+	Queries []*diagnostics.RunningQuery
+	Now     time.Time
+}
+
 type templates struct {
 	diagnosticsTemplate     *template.Template
 	diagnosticsListTemplate *template.Template
+	runningQueriesTemplate  *template.Template
 }
 
 var pageTemplates = initTemplates()
@@ -30,8 +43,22 @@ func initTemplates() *templates {
 	diagnosticsListTemplate := template.New("diagnostics-list")
 	template.Must(diagnosticsListTemplate.Parse(diagnosticsListPageHtml))
 
+	runningQueriesTemplate := template.New("running-queries").Funcs(template.FuncMap{
+		"formatTimestamp": func(ms int64) string {
+			return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+		},
+		"formatBytes": func(n uint64) string {
+			return humanize.Bytes(n)
+		},
+		"formatElapsed": func(ms int64) string {
+			return (time.Duration(ms) * time.Millisecond).Round(time.Second).String()
+		},
+	})
+	template.Must(runningQueriesTemplate.Parse(runningQueriesPageHtml))
+
 	return &templates{
 		diagnosticsTemplate:     diagnosticsTemplate,
 		diagnosticsListTemplate: diagnosticsListTemplate,
+		runningQueriesTemplate:  runningQueriesTemplate,
 	}
 }
@@ -0,0 +1,237 @@
+package querydiagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/diagnostics"
+	httputil "github.com/grafana/pyroscope/v2/pkg/util/http"
+)
+
+const (
+	runningQueriesAPIPath = "/query-diagnostics/api/running-queries"
+	// localParam restricts the request to the queries
+	// of the query frontend instance that serves it.
+	localParam = "local"
+	// replicaRequestTimeout is the timeout of the requests
+	// sent to the other query frontend replicas.
+	replicaRequestTimeout = 5 * time.Second
+)
+
+type RunningQueries interface {
+	List() []*diagnostics.RunningQuery
+	Kill(id string) bool
+}
+
+// Replicas provides the addresses of the query frontend replicas.
+type Replicas interface {
+	Addresses() []string
+}
+
+// RunningQueriesAdmin serves the queries in flight in the query
+// frontend. Each query frontend instance only tracks its own queries:
+// if the replicas are known, the requests are sent to all of them, and
+// the responses are merged. Otherwise, only the local queries are served.
+type RunningQueriesAdmin struct {
+	logger   log.Logger
+	queries  RunningQueries
+	replicas Replicas
+	client   *http.Client
+}
+
+func NewRunningQueriesAdmin(logger log.Logger, queries RunningQueries, replicas Replicas) *RunningQueriesAdmin {
+	return &RunningQueriesAdmin{
+		logger:   logger,
+		queries:  queries,
+		replicas: replicas,
+		client:   &http.Client{Timeout: replicaRequestTimeout},
+	}
+}
+
+// RunningQueriesHandler returns an HTTP handler for the running queries
+// page. A POST request with the "kill" form field cancels the query.
+func (a *RunningQueriesAdmin) RunningQueriesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if id := r.FormValue("kill"); id != "" {
+				a.kill(r.Context(), id, false)
+			}
+			w.Header().Set("Location", "#")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+		content := runningQueriesPageContent{
+			Queries: a.list(r.Context(), false),
+			Now:     time.Now().UTC(),
+		}
+		if err := pageTemplates.runningQueriesTemplate.Execute(w, content); err != nil {
+			httputil.Error(w, err)
+		}
+	})
+}
+
+// RunningQueriesAPIHandler returns a JSON API handler for listing running queries.
+func (a *RunningQueriesAdmin) RunningQueriesAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		queries := a.list(r.Context(), r.URL.Query().Has(localParam))
+		if err := json.NewEncoder(w).Encode(queries); err != nil {
+			httputil.Error(w, err)
+		}
+	})
+}
+
+// KillQueryAPIHandler returns a JSON API handler for killing a running query.
+func (a *RunningQueriesAdmin) KillQueryAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract ID from path: /query-diagnostics/api/running-queries/{id}
+		path := r.URL.Path
+		prefix := runningQueriesAPIPath + "/"
+		if !strings.HasPrefix(path, prefix) {
+			http.Error(w, `{"error":"invalid path"}`, http.StatusBadRequest)
+			return
+		}
+		id := strings.TrimPrefix(path, prefix)
+		if id == "" {
+			http.Error(w, `{"error":"id parameter required"}`, http.StatusBadRequest)
+			return
+		}
+
+		if !a.kill(r.Context(), id, r.URL.Query().Has(localParam)) {
+			http.Error(w, fmt.Sprintf(`{"error":"query not found: %s"}`, id), http.StatusNotFound)
+			return
+		}
+		response := map[string]string{"id": id}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			httputil.Error(w, err)
+		}
+	})
+}
+
+func (a *RunningQueriesAdmin) replicaAddresses(local bool) []string {
+	if local || a.replicas == nil {
+		return nil
+	}
+	return a.replicas.Addresses()
+}
+
+// list returns the queries running in all the replicas, the longest
+// running first. Replicas that cannot be reached are skipped.
+func (a *RunningQueriesAdmin) list(ctx context.Context, local bool) []*diagnostics.RunningQuery {
+	addresses := a.replicaAddresses(local)
+	if len(addresses) == 0 {
+		return a.queries.List()
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		queries []*diagnostics.RunningQuery
+	)
+	for _, addr := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var replicaQueries []*diagnostics.RunningQuery
+			if err := a.do(ctx, http.MethodGet, addr, runningQueriesAPIPath, &replicaQueries); err != nil {
+				level.Warn(a.logger).Log("msg", "failed to list running queries of replica", "replica", addr, "err", err)
+				return
+			}
+			for _, q := range replicaQueries {
+				q.Replica = addr
+			}
+			mu.Lock()
+			queries = append(queries, replicaQueries...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	slices.SortFunc(queries, func(a, b *diagnostics.RunningQuery) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return queries
+}
+
+// kill cancels the query in the replica that runs it.
+// It reports whether the query has been found.
+func (a *RunningQueriesAdmin) kill(ctx context.Context, id string, local bool) bool {
+	addresses := a.replicaAddresses(local)
+	if len(addresses) == 0 {
+		if !a.queries.Kill(id) {
+			return false
+		}
+		level.Info(a.logger).Log("msg", "killed running query", "id", id)
+		return true
+	}
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		found bool
+	)
+	for _, addr := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := a.do(ctx, http.MethodDelete, addr, runningQueriesAPIPath+"/"+url.PathEscape(id), nil)
+			if err == nil {
+				mu.Lock()
+				found = true
+				mu.Unlock()
+				return
+			}
+			if !errorIsNotFound(err) {
+				level.Warn(a.logger).Log("msg", "failed to kill running query in replica", "replica", addr, "id", id, "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+	return found
+}
+
+type replicaStatusError int
+
+func (e replicaStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", int(e))
+}
+
+func errorIsNotFound(err error) bool {
+	return err == replicaStatusError(http.StatusNotFound)
+}
+
+// do sends the request to the replica, restricted to its local queries,
+// and decodes the JSON response into v, if it is not nil.
+func (a *RunningQueriesAdmin) do(ctx context.Context, method, addr, path string, v any) error {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     addr,
+		Path:     path,
+		RawQuery: url.Values{localParam: []string{"true"}}.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return replicaStatusError(resp.StatusCode)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,60 @@
+package querydiagnostics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/diagnostics"
+)
+
+type staticReplicas []string
+
+func (r staticReplicas) Addresses() []string { return r }
+
+// newReplica starts a query frontend replica serving the running
+// queries API, and returns its address.
+func newReplica(t *testing.T, queries *diagnostics.RunningQueries) string {
+	a := NewRunningQueriesAdmin(log.NewNopLogger(), queries, nil)
+	mux := http.NewServeMux()
+	mux.Handle(runningQueriesAPIPath, a.RunningQueriesAPIHandler())
+	mux.Handle(runningQueriesAPIPath+"/", a.KillQueryAPIHandler())
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func TestRunningQueriesAdmin_FanOut(t *testing.T) {
+	ctx := context.Background()
+	queriesA := diagnostics.NewRunningQueries()
+	queriesB := diagnostics.NewRunningQueries()
+	_, _, doneA := queriesA.Register(ctx, diagnostics.RunningQuery{ID: "a", StartedAt: time.Now().Add(-time.Minute)})
+	defer doneA()
+	ctxB, _, doneB := queriesB.Register(ctx, diagnostics.RunningQuery{ID: "b", StartedAt: time.Now()})
+	defer doneB()
+
+	addrA := newReplica(t, queriesA)
+	addrB := newReplica(t, queriesB)
+	a := NewRunningQueriesAdmin(log.NewNopLogger(), diagnostics.NewRunningQueries(), staticReplicas{addrA, addrB})
+
+	list := a.list(ctx, false)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].ID)
+	assert.Equal(t, addrA, list[0].Replica)
+	assert.Equal(t, "b", list[1].ID)
+	assert.Equal(t, addrB, list[1].Replica)
+
+	// The local registry of the instance serving the request is empty.
+	assert.Empty(t, a.list(ctx, true))
+
+	assert.True(t, a.kill(ctx, "b", false))
+	assert.ErrorIs(t, context.Cause(ctxB), diagnostics.ErrQueryKilled)
+	assert.False(t, a.kill(ctx, "c", false))
+}
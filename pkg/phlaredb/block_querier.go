@@ -585,13 +585,13 @@ type Querier interface {
 
 	MergeByStacktraces(ctx context.Context, rows iter.Iterator[Profile], maxNodes int64) (*phlaremodel.FunctionNameTree, error)
 	MergeBySpans(ctx context.Context, rows iter.Iterator[Profile], spans phlaremodel.SpanSelector) (*phlaremodel.FunctionNameTree, error)
-	MergeByLabels(ctx context.Context, rows iter.Iterator[Profile], s *typesv1.StackTraceSelector, by ...string) ([]*typesv1.Series, error)
+	MergeByLabels(ctx context.Context, rows iter.Iterator[Profile], s *typesv1.StackTraceSelector, e SeriesExemplars, by ...string) ([]*typesv1.Series, error)
 	MergePprof(ctx context.Context, rows iter.Iterator[Profile], maxNodes int64, s *typesv1.StackTraceSelector) (*profilev1.Profile, error)
 	Series(ctx context.Context, params *ingestv1.SeriesRequest) ([]*typesv1.Labels, error)
 
 	SelectMatchingProfiles(ctx context.Context, params *ingestv1.SelectProfilesRequest) (iter.Iterator[Profile], error)
 	SelectMergeByStacktraces(ctx context.Context, params *ingestv1.SelectProfilesRequest, maxNodes int64) (*phlaremodel.FunctionNameTree, error)
-	SelectMergeByLabels(ctx context.Context, params *ingestv1.SelectProfilesRequest, s *typesv1.StackTraceSelector, by ...string) ([]*typesv1.Series, error)
+	SelectMergeByLabels(ctx context.Context, params *ingestv1.SelectProfilesRequest, s *typesv1.StackTraceSelector, e SeriesExemplars, by ...string) ([]*typesv1.Series, error)
 	SelectMergeBySpans(ctx context.Context, params *ingestv1.SelectSpanProfileRequest) (*phlaremodel.FunctionNameTree, error)
 	SelectMergePprof(ctx context.Context, params *ingestv1.SelectProfilesRequest, maxNodes int64, s *typesv1.StackTraceSelector) (*profilev1.Profile, error)
 
@@ -600,6 +600,28 @@ type Querier interface {
 	LabelNames(ctx context.Context, req *connect.Request[typesv1.LabelNamesRequest]) (*connect.Response[typesv1.LabelNamesResponse], error)
 }
 
+// SeriesExemplars specifies the exemplars attached to the points of the
+// series merged by labels. The zero value disables exemplars, which are
+// not collected if a stack trace selector is specified. Non-positive
+// MaxPerPoint selects the default number of exemplars per point.
+type SeriesExemplars struct {
+	Type        typesv1.ExemplarType
+	MaxPerPoint int
+}
+
+func (e SeriesExemplars) enabled() bool {
+	return e.Type == typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL ||
+		e.Type == typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN
+}
+
+func (e SeriesExemplars) spans() bool {
+	return e.Type == typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN
+}
+
+func (e SeriesExemplars) maxPerPoint() int {
+	return timeseries.ExemplarsPerPoint(int64(e.MaxPerPoint))
+}
+
 type TimeBounded interface {
 	Bounds() (model.Time, model.Time)
 }
@@ -1085,6 +1107,10 @@ func MergeProfilesLabels(ctx context.Context, stream *connect.BidiStream[ingestv
 	request := r.Request
 	by := r.By
 	sort.Strings(by)
+	exemplars := SeriesExemplars{
+		Type:        r.ExemplarType,
+		MaxPerPoint: int(r.MaxExemplarsPerPoint),
+	}
 	sp.SetTag("start", model.Time(request.Start).Time().String())
 	sp.SetTag("end", model.Time(request.End).Time().String())
 	sp.SetTag("selector", request.LabelSelector)
@@ -1114,7 +1140,7 @@ func MergeProfilesLabels(ctx context.Context, stream *connect.BidiStream[ingestv
 		for _, querier := range queriers {
 			querier := querier
 			g.Go(util.RecoverPanic(func() error {
-				merge, err := querier.SelectMergeByLabels(ctx, request, r.StackTraceSelector, by...)
+				merge, err := querier.SelectMergeByLabels(ctx, request, r.StackTraceSelector, exemplars, by...)
 				if err != nil {
 					return err
 				}
@@ -1156,6 +1182,7 @@ func MergeProfilesLabels(ctx context.Context, stream *connect.BidiStream[ingestv
 				merge, err := querier.MergeByLabels(ctx,
 					iter.NewSliceIterator(querier.Sort(selectedProfiles[i])),
 					r.StackTraceSelector,
+					exemplars,
 					by...)
 				if err != nil {
 					return err
@@ -1617,6 +1644,7 @@ func (b *singleBlockQuerier) SelectMergeByLabels(
 	ctx context.Context,
 	params *ingestv1.SelectProfilesRequest,
 	sts *typesv1.StackTraceSelector,
+	exemplars SeriesExemplars,
 	by ...string,
 ) ([]*typesv1.Series, error) {
 	sp, ctx := tracing.StartSpanFromContext(ctx, "SelectMergeByLabels - Block")
@@ -1647,10 +1675,17 @@ func (b *singleBlockQuerier) SelectMergeByLabels(
 		chks       = make([]index.ChunkMeta, 1)
 		lblsPerRef = make(map[int64]labelsInfo)
 		lbls       = make(phlaremodel.Labels, 0, 6)
+		// Exemplars retain all the labels of the profile series.
+		allLabels = exemplars.enabled() && len(sts.GetCallSite()) == 0
 	)
 	// get all relevant labels/fingerprints
 	for postings.Next() {
-		fp, err := b.index.SeriesBy(postings.At(), &lbls, &chks, by...)
+		var fp uint64
+		if allLabels {
+			fp, err = b.index.Series(postings.At(), &lbls, &chks)
+		} else {
+			fp, err = b.index.SeriesBy(postings.At(), &lbls, &chks, by...)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -1680,7 +1715,7 @@ func (b *singleBlockQuerier) SelectMergeByLabels(
 		}
 		rows := profileBatchIteratorBySeriesIndex(it, lblsPerRef)
 		defer rows.Close()
-		return mergeByLabels[Profile](ctx, profiles.file, columnName, rows, by...)
+		return mergeByLabels[Profile](ctx, profiles.file, columnName, rows, exemplars, by...)
 	}
 
 	if b.meta.Version < 2 {
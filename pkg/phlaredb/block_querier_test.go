@@ -1213,7 +1213,7 @@ func TestSelectMergeLabels(t *testing.T) {
 		},
 		Start: 0,
 		End:   int64(model.TimeFromUnixNano(math.MaxInt64)),
-	}, nil, "job")
+	}, nil, SeriesExemplars{}, "job")
 	require.NoError(t, err)
 	expected := []*typesv1.Series{
 		{
@@ -1233,6 +1233,55 @@ func TestSelectMergeLabels(t *testing.T) {
 	require.NoError(t, querier.Close())
 }
 
+func TestSelectMergeLabels_Exemplars(t *testing.T) {
+	ctx := context.Background()
+
+	querier := newBlock(t, func() (res []*testhelper.ProfileBuilder) {
+		for i := int64(1); i < 4; i++ {
+			res = append(res, testhelper.NewProfileBuilder(int64(time.Second)*i).
+				CPUProfile().
+				WithLabels("job", "a", "instance", "1").
+				ForStacktraceString("foo", "bar").AddSamples(1),
+				testhelper.NewProfileBuilder(int64(time.Second)*i).
+					CPUProfile().
+					WithLabels("job", "a", "instance", "2").
+					ForStacktraceString("foo", "bar").AddSamples(2))
+		}
+		return res
+	})
+
+	err := querier.Open(ctx)
+	require.NoError(t, err)
+
+	merge, err := querier.SelectMergeByLabels(ctx, &ingesterv1.SelectProfilesRequest{
+		LabelSelector: `{}`,
+		Type: &typesv1.ProfileType{
+			ID:         "process_cpu:cpu:nanoseconds:cpu:nanoseconds",
+			Name:       "process_cpu",
+			SampleType: "cpu",
+			SampleUnit: "nanoseconds",
+			PeriodType: "cpu",
+			PeriodUnit: "nanoseconds",
+		},
+		Start: 0,
+		End:   int64(model.TimeFromUnixNano(math.MaxInt64)),
+	}, nil, SeriesExemplars{Type: typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL}, "job")
+	require.NoError(t, err)
+	require.Len(t, merge, 1)
+	require.Len(t, merge[0].Points, 3)
+	for _, p := range merge[0].Points {
+		assert.Equal(t, float64(3), p.Value)
+		// Exemplars of the profiles merged into the point keep the
+		// labels that are not used for aggregation.
+		require.Len(t, p.Exemplars, 2)
+		for _, e := range p.Exemplars {
+			assert.NotEmpty(t, e.ProfileId)
+			assert.Equal(t, e.Value == 2, phlaremodel.Labels(e.Labels).Get("instance") == "2")
+		}
+	}
+	require.NoError(t, querier.Close())
+}
+
 func TestSelectMergeLabels_StackTraceSelector(t *testing.T) {
 	ctx := context.Background()
 
@@ -1270,7 +1319,7 @@ func TestSelectMergeLabels_StackTraceSelector(t *testing.T) {
 			{Name: "foo"},
 			{Name: "bar"},
 		},
-	}, "job")
+	}, SeriesExemplars{}, "job")
 	require.NoError(t, err)
 	expected := []*typesv1.Series{
 		{
@@ -271,11 +271,11 @@ func (q *headOnDiskQuerier) MergePprof(ctx context.Context, rows iter.Iterator[P
 	return r.Pprof()
 }
 
-func (q *headOnDiskQuerier) MergeByLabels(ctx context.Context, rows iter.Iterator[Profile], sts *typesv1.StackTraceSelector, by ...string) ([]*typesv1.Series, error) {
+func (q *headOnDiskQuerier) MergeByLabels(ctx context.Context, rows iter.Iterator[Profile], sts *typesv1.StackTraceSelector, exemplars SeriesExemplars, by ...string) ([]*typesv1.Series, error) {
 	sp, ctx := tracing.StartSpanFromContext(ctx, "MergeByLabels - HeadOnDisk")
 	defer sp.Finish()
 	if len(sts.GetCallSite()) == 0 {
-		return mergeByLabels(ctx, q.rowGroup(), "TotalValue", rows, by...)
+		return mergeByLabels(ctx, q.rowGroup(), "TotalValue", rows, exemplars, by...)
 	}
 	r := symdb.NewResolver(ctx, q.head.symdb,
 		symdb.WithResolverStackTraceSelector(sts))
@@ -283,7 +283,7 @@ func (q *headOnDiskQuerier) MergeByLabels(ctx context.Context, rows iter.Iterato
 	return mergeByLabelsWithStackTraceSelector(ctx, q.rowGroup(), rows, r, by...)
 }
 
-func (q *headOnDiskQuerier) SelectMergeByLabels(ctx context.Context, params *ingestv1.SelectProfilesRequest, sts *typesv1.StackTraceSelector, by ...string) ([]*typesv1.Series, error) {
+func (q *headOnDiskQuerier) SelectMergeByLabels(ctx context.Context, params *ingestv1.SelectProfilesRequest, sts *typesv1.StackTraceSelector, exemplars SeriesExemplars, by ...string) ([]*typesv1.Series, error) {
 	sp, ctx := tracing.StartSpanFromContext(ctx, "SelectMergeByLabels - HeadOnDisk")
 	defer sp.Finish()
 
@@ -307,7 +307,7 @@ func (q *headOnDiskQuerier) SelectMergeByLabels(ctx context.Context, params *ing
 	if len(sts.GetCallSite()) == 0 {
 		rows := profileBatchIteratorByFingerprints(it, labelsPerFP)
 		defer rows.Close()
-		return mergeByLabels[Profile](ctx, q.rowGroup(), "TotalValue", rows, by...)
+		return mergeByLabels[Profile](ctx, q.rowGroup(), "TotalValue", rows, exemplars, by...)
 	}
 
 	r := symdb.NewResolver(ctx, q.head.symdb,
@@ -595,6 +595,7 @@ func (q *headInMemoryQuerier) MergeByLabels(
 	ctx context.Context,
 	rows iter.Iterator[Profile],
 	sts *typesv1.StackTraceSelector,
+	exemplars SeriesExemplars,
 	by ...string,
 ) ([]*typesv1.Series, error) {
 	sp, _ := tracing.StartSpanFromContext(ctx, "MergeByLabels - HeadInMemory")
@@ -602,12 +603,13 @@ func (q *headInMemoryQuerier) MergeByLabels(
 
 	seriesBuilder := timeseries.NewBuilder(by...)
 	if len(sts.GetCallSite()) == 0 {
+		var spanValues timeseries.SpanValues
 		for rows.Next() {
 			p, ok := rows.At().(ProfileWithLabels)
 			if !ok {
 				return nil, errors.New("expected ProfileWithLabels")
 			}
-			seriesBuilder.Add(p.Fingerprint(), p.Labels(), int64(p.Timestamp()), float64(p.Total()), p.Annotations(), "")
+			addInMemoryProfile(seriesBuilder, p.Fingerprint(), p.Labels(), p.profile, exemplars, &spanValues)
 		}
 	} else {
 		r := symdb.NewResolver(ctx, q.head.symdb,
@@ -629,7 +631,9 @@ func (q *headInMemoryQuerier) MergeByLabels(
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-
+	if exemplars.enabled() && len(sts.GetCallSite()) == 0 {
+		return seriesBuilder.BuildWithExemplars(), nil
+	}
 	return seriesBuilder.Build(), nil
 }
 
@@ -637,6 +641,7 @@ func (q *headInMemoryQuerier) SelectMergeByLabels(
 	ctx context.Context,
 	params *ingestv1.SelectProfilesRequest,
 	sts *typesv1.StackTraceSelector,
+	exemplars SeriesExemplars,
 	by ...string,
 ) ([]*typesv1.Series, error) {
 	sp, ctx := tracing.StartSpanFromContext(ctx, "SelectMergeByLabels - HeadInMemory")
@@ -660,6 +665,7 @@ func (q *headInMemoryQuerier) SelectMergeByLabels(
 	defer index.mutex.RUnlock()
 
 	if len(sts.GetCallSite()) == 0 {
+		var spanValues timeseries.SpanValues
 		for _, fp := range ids {
 			profileSeries, ok := index.profilesPerFP[fp]
 			if !ok {
@@ -672,9 +678,12 @@ func (q *headInMemoryQuerier) SelectMergeByLabels(
 				if p.Timestamp() > end {
 					break
 				}
-				seriesBuilder.Add(fp, profileSeries.lbs, int64(p.Timestamp()), float64(p.Total()), p.Annotations, "")
+				addInMemoryProfile(seriesBuilder, fp, profileSeries.lbs, p, exemplars, &spanValues)
 			}
 		}
+		if exemplars.enabled() {
+			return seriesBuilder.BuildWithExemplars(), nil
+		}
 	} else {
 		r := symdb.NewResolver(ctx, q.head.symdb,
 			symdb.WithResolverStackTraceSelector(sts))
@@ -702,6 +711,38 @@ func (q *headInMemoryQuerier) SelectMergeByLabels(
 	return seriesBuilder.Build(), nil
 }
 
+// addInMemoryProfile adds the total value of the profile to the series,
+// along with the profile or its top spans as exemplars, if requested.
+func addInMemoryProfile(
+	seriesBuilder *timeseries.Builder,
+	fp model.Fingerprint,
+	lbs phlaremodel.Labels,
+	p *schemav1.InMemoryProfile,
+	exemplars SeriesExemplars,
+	spanValues *timeseries.SpanValues,
+) {
+	ts := int64(p.Timestamp())
+	if !exemplars.enabled() {
+		seriesBuilder.Add(fp, lbs, ts, float64(p.Total()), p.Annotations, "")
+		return
+	}
+	id := p.ID.String()
+	if !exemplars.spans() {
+		seriesBuilder.Add(fp, lbs, ts, float64(p.Total()), p.Annotations, id)
+		return
+	}
+	seriesBuilder.Add(fp, lbs, ts, float64(p.Total()), p.Annotations, "")
+	for i, spanID := range p.Samples.Spans {
+		var traceID phlaremodel.TraceID
+		if i < len(p.Samples.TraceIDs) {
+			traceID = p.Samples.TraceIDs[i]
+		}
+		spanValues.Add(spanID, traceID, int64(p.Samples.Values[i]))
+	}
+	seriesBuilder.AddSpanExemplars(fp, lbs, ts, id, spanValues.Top(exemplars.maxPerPoint()))
+	spanValues.Reset()
+}
+
 func (q *headInMemoryQuerier) Series(ctx context.Context, params *ingestv1.SeriesRequest) ([]*typesv1.Labels, error) {
 	res, err := q.head.Series(ctx, connect.NewRequest(params))
 	if err != nil {
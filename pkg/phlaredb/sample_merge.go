@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/grafana/dskit/runutil"
 	"github.com/grafana/dskit/tracing"
 	"github.com/parquet-go/parquet-go"
@@ -60,7 +61,7 @@ func (b *singleBlockQuerier) MergePprof(ctx context.Context, rows iter.Iterator[
 	return r.Pprof()
 }
 
-func (b *singleBlockQuerier) MergeByLabels(ctx context.Context, rows iter.Iterator[Profile], sts *typesv1.StackTraceSelector, by ...string) ([]*typesv1.Series, error) {
+func (b *singleBlockQuerier) MergeByLabels(ctx context.Context, rows iter.Iterator[Profile], sts *typesv1.StackTraceSelector, exemplars SeriesExemplars, by ...string) ([]*typesv1.Series, error) {
 	sp, ctx := tracing.StartSpanFromContext(ctx, "MergeByLabels - Block")
 	defer sp.Finish()
 	sp.SetTag("block ULID", b.meta.ULID.String())
@@ -77,7 +78,7 @@ func (b *singleBlockQuerier) MergeByLabels(ctx context.Context, rows iter.Iterat
 		if b.meta.Version == 1 {
 			columnName = "Samples.list.element.Value"
 		}
-		return mergeByLabels(ctx, b.profileSourceTable().file, columnName, rows, by...)
+		return mergeByLabels(ctx, b.profileSourceTable().file, columnName, rows, exemplars, by...)
 	}
 	r := symdb.NewResolver(ctx, b.symbols,
 		symdb.WithResolverStackTraceSelector(sts))
@@ -164,6 +165,7 @@ func mergeByLabels[T Profile](
 	profileSource Source,
 	columnName string,
 	rows iter.Iterator[T],
+	exemplars SeriesExemplars,
 	by ...string,
 ) ([]*typesv1.Series, error) {
 	column, err := v1.ResolveColumnByPath(profileSource.Schema(), strings.Split(columnName, "."))
@@ -175,18 +177,51 @@ func mergeByLabels[T Profile](
 	annotationKeysColumn, _ := v1.ResolveColumnByPath(profileSource.Schema(), v1.AnnotationKeyColumnPath)
 	annotationValuesColumn, _ := v1.ResolveColumnByPath(profileSource.Schema(), v1.AnnotationValueColumnPath)
 
+	columns := []int{
+		column.ColumnIndex,
+		annotationKeysColumn.ColumnIndex,
+		annotationValuesColumn.ColumnIndex,
+	}
+	// Exemplars refer to the profile by its ID, and span exemplars
+	// refer to the spans that contribute most to the profile value.
+	var (
+		exemplarOffset = len(columns)
+		withSpans      bool
+		samples        v1.SampleColumns
+		spanValues     timeseries.SpanValues
+	)
+	if exemplars.enabled() {
+		idColumn, err := v1.ResolveColumnByPath(profileSource.Schema(), []string{v1.IDColumnName})
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, idColumn.ColumnIndex)
+		if exemplars.spans() {
+			if err = samples.Resolve(profileSource.Schema()); err != nil {
+				return nil, err
+			}
+			// Blocks written before the span support have no span column.
+			withSpans = samples.HasSpanID()
+		}
+		if withSpans {
+			columns = append(columns, samples.SpanID.ColumnIndex, samples.Value.ColumnIndex)
+			if samples.HasTraceID() {
+				columns = append(columns, samples.TraceID.ColumnIndex)
+			}
+		}
+	}
+
 	profiles := query.NewRepeatedRowIterator(
 		ctx,
 		rows,
 		profileSource.RowGroups(),
-		column.ColumnIndex,
-		annotationKeysColumn.ColumnIndex,
-		annotationValuesColumn.ColumnIndex,
+		columns...,
 	)
 	defer runutil.CloseWithErrCapture(&err, profiles, "failed to close profile stream")
 
 	seriesBuilder := timeseries.NewBuilder(by...)
 
+	var profileID uuid.UUID
 	for profiles.Next() {
 		values := profiles.At()
 		p := values.Row
@@ -195,7 +230,7 @@ func mergeByLabels[T Profile](
 			Keys:   make([]string, 0),
 			Values: make([]string, 0),
 		}
-		for _, e := range values.Values {
+		for _, e := range values.Values[:exemplarOffset] {
 			if e[0].Column() == column.ColumnIndex && e[0].Kind() == parquet.Int64 {
 				total += e[0].Int64()
 			} else if e[0].Column() == annotationKeysColumn.ColumnIndex && e[0].Kind() == parquet.ByteArray {
@@ -204,14 +239,35 @@ func mergeByLabels[T Profile](
 				annotations.Values = append(annotations.Values, e[0].String())
 			}
 		}
-		seriesBuilder.Add(
-			p.Fingerprint(),
-			p.Labels(),
-			int64(p.Timestamp()),
-			float64(total),
-			annotations,
-			"",
-		)
+		if !exemplars.enabled() {
+			seriesBuilder.Add(p.Fingerprint(), p.Labels(), int64(p.Timestamp()), float64(total), annotations, "")
+			continue
+		}
+		var id string
+		if b := values.Values[exemplarOffset][0].Bytes(); len(b) == len(profileID) {
+			copy(profileID[:], b)
+			id = profileID.String()
+		}
+		if !withSpans {
+			seriesBuilder.Add(p.Fingerprint(), p.Labels(), int64(p.Timestamp()), float64(total), annotations, id)
+			continue
+		}
+		seriesBuilder.Add(p.Fingerprint(), p.Labels(), int64(p.Timestamp()), float64(total), annotations, "")
+		spanColumns := values.Values[exemplarOffset+1:]
+		for i := range spanColumns[0] {
+			var traceID phlaremodel.TraceID
+			if len(spanColumns) > 2 && i < len(spanColumns[2]) {
+				if b := spanColumns[2][i].Bytes(); len(b) == len(traceID) {
+					copy(traceID[:], b)
+				}
+			}
+			spanValues.Add(spanColumns[0][i].Uint64(), traceID, spanColumns[1][i].Int64())
+		}
+		seriesBuilder.AddSpanExemplars(p.Fingerprint(), p.Labels(), int64(p.Timestamp()), id, spanValues.Top(exemplars.maxPerPoint()))
+		spanValues.Reset()
+	}
+	if exemplars.enabled() {
+		return seriesBuilder.BuildWithExemplars(), profiles.Err()
 	}
 	return seriesBuilder.Build(), profiles.Err()
 }
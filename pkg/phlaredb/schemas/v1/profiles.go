@@ -83,6 +83,7 @@ var (
 	sampleTraceIDColumnPath      = strings.Split("Samples.list.element.TraceID", ".")
 
 	maxProfileRow               parquet.Row
+	idColIndex                  int
 	seriesIndexColIndex         int
 	stacktraceIDColIndex        int
 	valueColIndex               int
@@ -108,6 +109,11 @@ func init() {
 		SeriesIndex: math.MaxUint32,
 		TimeNanos:   math.MaxInt64,
 	}, maxProfileRow)
+	idCol, ok := ProfilesSchema.Lookup(IDColumnName)
+	if !ok {
+		panic(fmt.Errorf("ID column not found"))
+	}
+	idColIndex = idCol.ColumnIndex
 	seriesCol, ok := ProfilesSchema.Lookup(SeriesIndexColumnName)
 	if !ok {
 		panic(fmt.Errorf("SeriesIndex index column not found"))
@@ -778,6 +784,13 @@ func lessProfileRows(r1, r2 parquet.Row) bool {
 
 type ProfileRow parquet.Row
 
+// ID returns the unique identifier of the profile.
+// uuid.Nil is returned if the profile has no ID.
+func (p ProfileRow) ID() (id uuid.UUID) {
+	copy(id[:], p[idColIndex].ByteArray())
+	return id
+}
+
 func (p ProfileRow) SeriesIndex() uint32 {
 	return p[seriesIndexColIndex].Uint32()
 }
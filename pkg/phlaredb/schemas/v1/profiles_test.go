@@ -310,6 +310,13 @@ func TestProfileRowMutateValues(t *testing.T) {
 	require.Equal(t, []uint32{1, 1, 1, 1, 1}, ids)
 }
 
+func TestProfileRowID(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	rows := generateProfileRow([]InMemoryProfile{{ID: id, SeriesIndex: 1}, {SeriesIndex: 1}})
+	require.Equal(t, id, ProfileRow(rows[0]).ID())
+	require.Equal(t, uuid.Nil, ProfileRow(rows[1]).ID())
+}
+
 func BenchmarkProfileRows(b *testing.B) {
 	a := generateProfileRow([]InMemoryProfile{{SeriesIndex: 1, TimeNanos: 1}})[0]
 	a1 := generateProfileRow([]InMemoryProfile{{SeriesIndex: 1, TimeNanos: 2}})[0]
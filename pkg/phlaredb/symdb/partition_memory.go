@@ -37,6 +37,19 @@ func (p *PartitionWriter) LookupLocations(dst []uint64, stacktraceID uint32) []u
 	return p.stacktraces.tree.resolveUint64(dst, stacktraceID)
 }
 
+// TruncateStacktrace returns the ID of the stack trace that only includes
+// up to maxDepth root-most frames of the given stack trace. The stack trace
+// is returned as is, if maxDepth is not positive or the stack trace is not
+// deeper than maxDepth.
+func (p *PartitionWriter) TruncateStacktrace(stacktraceID uint32, maxDepth int) uint32 {
+	if stacktraceID == 0 || maxDepth <= 0 {
+		return stacktraceID
+	}
+	p.stacktraces.m.RLock()
+	defer p.stacktraces.m.RUnlock()
+	return p.stacktraces.tree.truncate(stacktraceID, maxDepth)
+}
+
 func newStacktraces() *stacktraces {
 	p := &stacktraces{
 		hashToIdx: make(map[uint64]uint32),
@@ -45,6 +45,25 @@ func Test_Stacktrace_append_existing(t *testing.T) {
 	assert.Equal(t, []uint32{5, 6}, sids)
 }
 
+func Test_Stacktrace_truncate(t *testing.T) {
+	db := NewSymDB(new(Config))
+	w := db.PartitionWriter(0)
+	sids := make([]uint32, 2)
+	w.AppendStacktraces(sids, []*schemav1.Stacktrace{
+		{LocationIDs: []uint64{5, 4, 3, 2, 1}},
+		{LocationIDs: []uint64{3, 2, 1}},
+	})
+
+	truncated := w.TruncateStacktrace(sids[0], 3)
+	assert.Equal(t, sids[1], truncated)
+	assert.Equal(t, []uint64{3, 2, 1}, w.LookupLocations(nil, truncated))
+
+	assert.Equal(t, sids[0], w.TruncateStacktrace(sids[0], 5))
+	assert.Equal(t, sids[0], w.TruncateStacktrace(sids[0], 0))
+	assert.Equal(t, []uint64{1}, w.LookupLocations(nil, w.TruncateStacktrace(sids[0], 1)))
+	assert.Equal(t, uint32(0), w.TruncateStacktrace(0, 1))
+}
+
 func Test_Stacktraces_memory_resolve_pprof(t *testing.T) {
 	p, err := pprof.OpenFile("testdata/profile.pb.gz")
 	require.NoError(t, err)
@@ -219,6 +219,20 @@ func (r *Resolver) withPartitionSamples(partition uint64, fn func(*SampleAppende
 	fn(p.samples)
 }
 
+// StackTraces returns the number of distinct stack traces
+// added to the resolver: these are to be symbolized.
+func (r *Resolver) StackTraces() int {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	var n int
+	for _, p := range r.p {
+		p.m.Lock()
+		n += p.samples.Len()
+		p.m.Unlock()
+	}
+	return n
+}
+
 func (r *Resolver) CallSiteValues(values *CallSiteValues, partition uint64, samples schemav1.Samples) error {
 	p := r.partition(partition)
 	if err := p.fetch(r.ctx); err != nil {
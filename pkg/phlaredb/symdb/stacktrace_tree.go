@@ -114,6 +114,23 @@ func (t *stacktraceTree) resolveUint64(dst []uint64, id uint32) []uint64 {
 	return dst
 }
 
+// truncate returns the ancestor of the node, such that the
+// path from the ancestor to the root is at most maxDepth long.
+func (t *stacktraceTree) truncate(id uint32, maxDepth int) uint32 {
+	if id >= uint32(len(t.nodes)) {
+		return id
+	}
+	var depth int
+	for i := int32(id); i > 0; i = t.nodes[i].p {
+		depth++
+	}
+	i := int32(id)
+	for ; depth > maxDepth; depth-- {
+		i = t.nodes[i].p
+	}
+	return uint32(i)
+}
+
 func (t *stacktraceTree) Nodes() []Node {
 	dst := make([]Node, len(t.nodes))
 	for i := 0; i < len(dst) && i < len(t.nodes); i++ { // BCE
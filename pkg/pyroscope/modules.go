@@ -43,7 +43,9 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/embedded/grafana"
 	"github.com/grafana/pyroscope/v2/pkg/featureflags"
 	"github.com/grafana/pyroscope/v2/pkg/ingester"
+	phlareobj "github.com/grafana/pyroscope/v2/pkg/objstore"
 	objstoreclient "github.com/grafana/pyroscope/v2/pkg/objstore/client"
+	"github.com/grafana/pyroscope/v2/pkg/objstore/encryption"
 	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/filesystem"
 	"github.com/grafana/pyroscope/v2/pkg/operations"
 	blocksv2 "github.com/grafana/pyroscope/v2/pkg/operations/v2/blocks"
@@ -53,6 +55,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/querybackend"
 	"github.com/grafana/pyroscope/v2/pkg/scheduler"
 	"github.com/grafana/pyroscope/v2/pkg/settings"
+	"github.com/grafana/pyroscope/v2/pkg/sourcemap"
 	"github.com/grafana/pyroscope/v2/pkg/storegateway"
 	"github.com/grafana/pyroscope/v2/pkg/usagestats"
 	"github.com/grafana/pyroscope/v2/pkg/util"
@@ -110,6 +113,7 @@ const (
 	QueryDiagnosticsStore string = "query-diagnostics-store"
 	QueryDiagnosticsAdmin string = "query-diagnostics-admin"
 	AsyncQueryStore       string = "async-query-store"
+	QueryFrontendReplicas string = "query-frontend-replicas"
 )
 
 var objectStoreTypeStats = usagestats.NewString("store_object_type")
@@ -341,6 +345,11 @@ func (f *Pyroscope) initDistributor() (services.Service, error) {
 	} else {
 		f.API.RegisterDebugInfo(store, store.UploadHTTPHandler())
 	}
+	if store, err := sourcemap.NewStore(f.logger, f.storageBucket, f.Cfg.SourceMaps); err != nil {
+		return nil, err
+	} else {
+		f.API.RegisterSourceMaps(store)
+	}
 	return d, nil
 }
 
@@ -400,15 +409,15 @@ func (f *Pyroscope) initStorage() (_ services.Service, err error) {
 			level.Warn(f.logger).
 				Log("msg", "when running with storage.backend 'filesystem' it is important that all replicas/components share the same filesystem")
 		}
-		b, err := objstoreclient.NewBucket(
-			f.context(),
-			cfg,
-			"storage",
-		)
-		if err != nil {
+		if f.storageBucket, err = f.newStorageBucket(cfg, "storage"); err != nil {
 			return nil, fmt.Errorf("unable to initialise bucket: %w", err)
 		}
-		f.storageBucket = b
+	}
+
+	if cfg := f.Cfg.Storage.Cold; cfg.Backend != objstoreclient.None {
+		if f.coldStorageBucket, err = f.newStorageBucket(cfg, "storage-cold"); err != nil {
+			return nil, fmt.Errorf("unable to initialise cold storage bucket: %w", err)
+		}
 	}
 
 	if !slices.Contains(f.Cfg.Target, All) && f.storageBucket == nil {
@@ -418,6 +427,21 @@ func (f *Pyroscope) initStorage() (_ services.Service, err error) {
 	return nil, nil
 }
 
+func (f *Pyroscope) newStorageBucket(cfg objstoreclient.Config, name string) (phlareobj.Bucket, error) {
+	b, err := objstoreclient.NewBucket(f.context(), cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	if enc := f.Cfg.Storage.Encryption; enc.Enabled {
+		keys, err := encryption.NewKeyProvider(enc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialise encryption key provider: %w", err)
+		}
+		b = encryption.NewBucket(b, keys)
+	}
+	return b, nil
+}
+
 // TODO: This should be passed to all other services and could also be used to signal shutdown
 func (f *Pyroscope) context() context.Context {
 	phlarectx := phlarecontext.WithLogger(context.Background(), f.logger)
@@ -647,7 +671,7 @@ func (f *Pyroscope) initAdmin() (services.Service, error) {
 	}
 	level.Info(f.logger).Log("msg", "initializing v2 admin (metastore-based)")
 
-	a, err := blocksv2.NewAdmin(f.metastoreClient, f.storageBucket, f.logger)
+	a, err := blocksv2.NewAdmin(f.metastoreClient, f.storageBucket, f.coldStorageBucket, f.logger)
 	if err != nil {
 		level.Info(f.logger).Log("msg", "failed to initialize v2 admin", "err", err)
 		return nil, nil
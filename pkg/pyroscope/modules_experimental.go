@@ -24,6 +24,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath"
 	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend"
 	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/diagnostics"
+	"github.com/grafana/pyroscope/v2/pkg/frontend/readpath/queryfrontend/resultscache"
 	"github.com/grafana/pyroscope/v2/pkg/frontend/vcs"
 	"github.com/grafana/pyroscope/v2/pkg/metastore"
 	metastoreadmin "github.com/grafana/pyroscope/v2/pkg/metastore/admin"
@@ -37,9 +38,11 @@ import (
 	segmentwriterclient "github.com/grafana/pyroscope/v2/pkg/segmentwriter/client"
 	placement "github.com/grafana/pyroscope/v2/pkg/segmentwriter/client/distributor/placement/adaptiveplacement"
 	recordingrulesclient "github.com/grafana/pyroscope/v2/pkg/settings/recording/client"
+	"github.com/grafana/pyroscope/v2/pkg/sourcemap"
 	"github.com/grafana/pyroscope/v2/pkg/symbolizer"
 	"github.com/grafana/pyroscope/v2/pkg/util"
 	"github.com/grafana/pyroscope/v2/pkg/util/health"
+	"github.com/grafana/pyroscope/v2/pkg/util/servicediscovery"
 	"github.com/grafana/pyroscope/v2/pkg/util/spanlogger"
 )
 
@@ -94,6 +97,16 @@ func (f *Pyroscope) initQueryFrontendV2() (services.Service, error) {
 		f.queryDiagnosticsStore,
 		f.reg,
 	)
+	f.setSourceMapResolver()
+	if err := f.setResultsCache(); err != nil {
+		return nil, err
+	}
+	f.queryFrontend.SetQueryBudget(f.Overrides, f.queryFrontendReplicas)
+	f.API.RegisterRunningQueriesAdmin(querydiagnostics.NewRunningQueriesAdmin(
+		log.With(f.logger, "component", "running-queries-admin"),
+		f.queryFrontend.RunningQueries(),
+		f.queryFrontendReplicas,
+	))
 
 	// Wrap the query frontend: diagnostics wrapper -> spanlogger wrapper -> query frontend
 	handler := diagnostics.NewWrapper(
@@ -112,19 +125,23 @@ func (f *Pyroscope) initQueryFrontendV2() (services.Service, error) {
 			f.reg,
 		)
 		f.asyncQueryStore.SetDispatcher(coordinator)
-		querierHandler = asyncquery.NewHandler(
+		asyncHandler := asyncquery.NewHandler(
 			log.With(f.logger, "component", "async-query-handler"),
 			querierHandler,
 			coordinator,
 		)
+		f.API.RegisterAsyncQueryServiceHandler(asyncHandler)
+		querierHandler = asyncHandler
 	}
 
 	vcsService := vcs.New(
 		log.With(f.logger, "component", "vcs-service"),
 		f.reg,
+		f.Cfg.Frontend.VCS,
 	)
 
 	f.API.RegisterQuerierServiceHandler(querierHandler)
+	f.API.RegisterStreamingQueryServiceHandler(f.queryFrontend)
 	f.API.RegisterPyroscopeHandlers(handler)
 	f.API.RegisterVCSServiceHandler(vcsService)
 
@@ -156,6 +173,16 @@ func (f *Pyroscope) initQueryFrontendV12() (services.Service, error) {
 		nil,
 		f.reg,
 	)
+	f.setSourceMapResolver()
+	if err := f.setResultsCache(); err != nil {
+		return nil, err
+	}
+	f.queryFrontend.SetQueryBudget(f.Overrides, f.queryFrontendReplicas)
+	f.API.RegisterRunningQueriesAdmin(querydiagnostics.NewRunningQueriesAdmin(
+		log.With(f.logger, "component", "running-queries-admin"),
+		f.queryFrontend.RunningQueries(),
+		f.queryFrontendReplicas,
+	))
 
 	resolver := readpath.NewMetastoreSplitTimeResolver(f.metastoreClient, time.Minute)
 
@@ -174,6 +201,7 @@ func (f *Pyroscope) initQueryFrontendV12() (services.Service, error) {
 	vcsService := vcs.New(
 		log.With(f.logger, "component", "vcs-service"),
 		f.reg,
+		f.Cfg.Frontend.VCS,
 	)
 
 	f.API.RegisterFrontendForQuerierHandler(f.frontend)
@@ -210,6 +238,18 @@ func (f *Pyroscope) initAsyncQueryStore() (services.Service, error) {
 	return f.asyncQueryStore, nil
 }
 
+// queryFrontendReplicasLookupPeriod is the interval
+// at which the query frontend replicas are resolved.
+const queryFrontendReplicasLookupPeriod = 10 * time.Second
+
+func (f *Pyroscope) initQueryFrontendReplicas() (services.Service, error) {
+	f.queryFrontendReplicas = queryfrontend.NewReplicas()
+	if f.Cfg.Frontend.ReplicasAddress == "" {
+		return nil, nil
+	}
+	return servicediscovery.NewDNS(f.Cfg.Frontend.ReplicasAddress, queryFrontendReplicasLookupPeriod, f.queryFrontendReplicas)
+}
+
 func (f *Pyroscope) initSegmentWriterRing() (_ services.Service, err error) {
 	if err = f.Cfg.SegmentWriter.Validate(); err != nil {
 		return nil, err
@@ -234,6 +274,9 @@ func (f *Pyroscope) initSegmentWriter() (services.Service, error) {
 	if err := f.Cfg.SegmentWriter.Validate(); err != nil {
 		return nil, err
 	}
+	if f.Cfg.SourceMaps.Enabled {
+		f.Cfg.SegmentWriter.ReleaseLabel = f.Cfg.SourceMaps.ReleaseLabel
+	}
 
 	logger := log.With(f.logger, "component", "segment-writer")
 	healthService := health.NewGRPCHealthService(f.healthServer, logger, "pyroscope.segment-writer")
@@ -266,6 +309,7 @@ func (f *Pyroscope) initSegmentWriterClient() (_ services.Service, err error) {
 		logger, f.reg,
 		f.segmentWriterRing,
 		placement,
+		f.Cfg.SegmentWriter.WriteReplicationFactor,
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	)
 	if err != nil {
@@ -302,6 +346,7 @@ func (f *Pyroscope) initCompactionWorker() (svc services.Service, err error) {
 		f.Cfg.CompactionWorker,
 		f.metastoreClient,
 		f.storageBucket,
+		f.coldStorageBucket,
 		registerer,
 		ruler,
 		exporter,
@@ -318,6 +363,10 @@ func (f *Pyroscope) initMetastore() (services.Service, error) {
 		return nil, err
 	}
 
+	if f.Cfg.DebugInfo.Enabled {
+		f.Cfg.Metastore.DebugInfoGC = f.Cfg.DebugInfo.GC
+	}
+
 	logger := log.With(f.logger, "component", "metastore")
 	healthService := health.NewGRPCHealthService(f.healthServer, logger, "pyroscope.metastore")
 	registerer := prometheus.WrapRegistererWithPrefix("pyroscope_metastore_", f.reg)
@@ -329,6 +378,7 @@ func (f *Pyroscope) initMetastore() (services.Service, error) {
 		healthService,
 		f.metastoreClient,
 		f.storageBucket,
+		f.coldStorageBucket,
 		f.placementManager,
 	)
 	if err != nil {
@@ -381,7 +431,7 @@ func (f *Pyroscope) initQueryBackend() (services.Service, error) {
 		return nil, err
 	}
 	logger := log.With(f.logger, "component", "query-backend")
-	blockReader := querybackend.NewBlockReader(f.logger, f.storageBucket, f.reg, f.Overrides)
+	blockReader := querybackend.NewBlockReader(f.logger, f.storageBucket, f.coldStorageBucket, f.reg, f.Overrides)
 	b, err := querybackend.New(
 		f.Cfg.QueryBackend,
 		logger,
@@ -448,6 +498,34 @@ func (f *Pyroscope) initSymbolizer() (services.Service, error) {
 	return nil, nil
 }
 
+func (f *Pyroscope) setSourceMapResolver() {
+	if !f.Cfg.SourceMaps.Enabled || f.storageBucket == nil {
+		return
+	}
+	f.queryFrontend.SetSourceMapResolver(sourcemap.NewResolver(
+		f.logger,
+		f.storageBucket,
+		f.Cfg.SourceMaps,
+		f.reg,
+	))
+}
+
+func (f *Pyroscope) setResultsCache() error {
+	if !f.Cfg.Frontend.ResultsCache.Enabled() {
+		return nil
+	}
+	cache, err := resultscache.New(
+		f.Cfg.Frontend.ResultsCache,
+		log.With(f.logger, "component", "query-results-cache"),
+		f.reg,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create query results cache: %w", err)
+	}
+	f.queryFrontend.SetResultsCache(f.Cfg.Frontend.ResultsCache, cache)
+	return nil
+}
+
 func (f *Pyroscope) initQueryDiagnosticsStore() (services.Service, error) {
 	if f.storageBucket == nil {
 		return nil, nil
@@ -57,6 +57,7 @@ import (
 	metastoreclient "github.com/grafana/pyroscope/v2/pkg/metastore/client"
 	phlareobj "github.com/grafana/pyroscope/v2/pkg/objstore"
 	objstoreclient "github.com/grafana/pyroscope/v2/pkg/objstore/client"
+	"github.com/grafana/pyroscope/v2/pkg/objstore/encryption"
 	"github.com/grafana/pyroscope/v2/pkg/operations/v2/querydiagnostics"
 	"github.com/grafana/pyroscope/v2/pkg/phlaredb"
 	"github.com/grafana/pyroscope/v2/pkg/querier"
@@ -70,6 +71,7 @@ import (
 	placement "github.com/grafana/pyroscope/v2/pkg/segmentwriter/client/distributor/placement/adaptiveplacement"
 	"github.com/grafana/pyroscope/v2/pkg/settings"
 	recordingrulesclient "github.com/grafana/pyroscope/v2/pkg/settings/recording/client"
+	"github.com/grafana/pyroscope/v2/pkg/sourcemap"
 	"github.com/grafana/pyroscope/v2/pkg/storegateway"
 	"github.com/grafana/pyroscope/v2/pkg/symbolizer"
 	"github.com/grafana/pyroscope/v2/pkg/tenant"
@@ -77,6 +79,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/usagestats"
 	"github.com/grafana/pyroscope/v2/pkg/util"
 	"github.com/grafana/pyroscope/v2/pkg/util/cli"
+	"github.com/grafana/pyroscope/v2/pkg/util/fieldcategory"
 	httputil "github.com/grafana/pyroscope/v2/pkg/util/http"
 	"github.com/grafana/pyroscope/v2/pkg/validation"
 	"github.com/grafana/pyroscope/v2/pkg/validation/exporter"
@@ -118,8 +121,9 @@ type Config struct {
 	ConfigFile      string `yaml:"-"`
 	ConfigExpandEnv bool   `yaml:"-"`
 
-	DebugInfo debuginfo.Config    `yaml:"-"`
-	SetFlags  map[string]struct{} `yaml:"-"`
+	DebugInfo  debuginfo.Config    `yaml:"-"`
+	SourceMaps sourcemap.Config    `yaml:"-"`
+	SetFlags   map[string]struct{} `yaml:"-"`
 
 	// Legacy v1
 	Querier        querier.Config      `yaml:"querier,omitempty"`
@@ -137,11 +141,24 @@ func newDefaultConfig() *Config {
 }
 
 type StorageConfig struct {
-	Bucket objstoreclient.Config `yaml:",inline"`
+	Bucket     objstoreclient.Config `yaml:",inline"`
+	Encryption encryption.Config     `yaml:"encryption"`
+	Cold       objstoreclient.Config `yaml:"cold" doc:"description=Storage of the cold tier: compacted blocks are written to it if they reach the compaction-worker.cold-storage-min-level or compaction-worker.cold-storage-min-age. Disabled unless the backend is configured."`
 }
 
 func (c *StorageConfig) RegisterFlags(f *flag.FlagSet) {
 	c.Bucket.RegisterFlagsWithPrefix("storage.", f)
+	c.Encryption.RegisterFlagsWithPrefix("storage.encryption.", f)
+	c.Cold.RegisterFlagsWithPrefixAndDefaultBackend("storage.cold.", objstoreclient.None, f)
+	// The cold storage tier is experimental: the backend
+	// configuration flags cannot be tagged directly.
+	cold := make(map[string]fieldcategory.Category)
+	f.VisitAll(func(fl *flag.Flag) {
+		if strings.HasPrefix(fl.Name, "storage.cold.") {
+			cold[fl.Name] = fieldcategory.Experimental
+		}
+	})
+	fieldcategory.AddOverrides(cold)
 }
 
 // AdminServerMode controls how the optional admin HTTP server behaves.
@@ -354,6 +371,7 @@ func (c *Config) registerServerFlagsWithChangedDefaultValues(fs *flag.FlagSet) {
 	c.LimitsConfig.Symbolizer.RegisterFlags(throwaway)
 	c.Symbolizer.RegisterFlags(throwaway)
 	c.DebugInfo.RegisterFlags(throwaway)
+	c.SourceMaps.RegisterFlags(throwaway)
 
 	// Legacy modules
 	c.QueryScheduler.RegisterFlags(throwaway, log.NewLogfmtLogger(os.Stderr))
@@ -435,6 +453,14 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.Storage.Encryption.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Storage.Cold.Validate(util.Logger); err != nil {
+		return err
+	}
+
 	if err := c.TenantSettings.Validate(); err != nil {
 		return err
 	}
@@ -511,6 +537,8 @@ type Pyroscope struct {
 	TenantLimits validation.TenantLimits
 
 	storageBucket phlareobj.Bucket
+	// Optional bucket of the cold storage tier.
+	coldStorageBucket phlareobj.Bucket
 
 	grpcGatewayMux *grpcgw.ServeMux
 
@@ -527,6 +555,7 @@ type Pyroscope struct {
 	metastoreClient       *metastoreclient.Client
 	metastoreAdmin        *metastoreadmin.Admin
 	queryFrontend         *queryfrontend.QueryFrontend
+	queryFrontendReplicas *queryfrontend.Replicas
 	queryBackendClient    *querybackendclient.Client
 	compactionWorker      *compactionworker.Worker
 	healthServer          *health.Server
@@ -623,6 +652,7 @@ func (f *Pyroscope) setupModuleManager() error {
 	mm.RegisterModule(QueryDiagnosticsStore, f.initQueryDiagnosticsStore, modules.UserInvisibleModule)
 	mm.RegisterModule(QueryDiagnosticsAdmin, f.initQueryDiagnosticsAdmin, modules.UserInvisibleModule)
 	mm.RegisterModule(AsyncQueryStore, f.initAsyncQueryStore, modules.UserInvisibleModule)
+	mm.RegisterModule(QueryFrontendReplicas, f.initQueryFrontendReplicas, modules.UserInvisibleModule)
 
 	// Add dependencies
 	deps := map[string][]string{
@@ -650,7 +680,7 @@ func (f *Pyroscope) setupModuleManager() error {
 		SegmentWriterRing:     {Overrides, API, MemberlistKV},
 		SegmentWriterClient:   {Overrides, API, SegmentWriterRing, PlacementAgent},
 		CompactionWorker:      {Overrides, API, Storage, MetastoreClient, RecordingRulesClient},
-		QueryFrontend:         {OverridesExporter, API, MemberlistKV, UsageReport, Version, FeatureFlags, MetastoreClient, QueryBackendClient, Symbolizer, QueryDiagnosticsStore, AsyncQueryStore},
+		QueryFrontend:         {OverridesExporter, API, MemberlistKV, UsageReport, Version, FeatureFlags, MetastoreClient, QueryBackendClient, Symbolizer, QueryDiagnosticsStore, AsyncQueryStore, QueryFrontendReplicas},
 		QueryBackend:          {Overrides, API, Storage, QueryBackendClient},
 		QueryDiagnosticsStore: {Storage},
 		QueryDiagnosticsAdmin: {QueryDiagnosticsStore, API, MetastoreClient},
@@ -668,6 +698,7 @@ func (f *Pyroscope) setupModuleManager() error {
 		EmbeddedGrafana:       {API},
 		FeatureFlags:          {API},
 		AsyncQueryStore:       {Storage},
+		QueryFrontendReplicas: {},
 	}
 
 	if f.Cfg.ArchitectureStorage == V1V2Dual || f.Cfg.ArchitectureStorage == V1 {
@@ -694,6 +725,7 @@ func (f *Pyroscope) setupModuleManager() error {
 	if f.Cfg.ArchitectureStorage == V1 {
 		// disable v2 features
 		f.Cfg.DebugInfo.Enabled = false
+		f.Cfg.SourceMaps.Enabled = false
 
 		// remove v2 modules if using legacy storage
 		v2Modules := []string{QueryBackend, SegmentWriter, CompactionWorker, Metastore}
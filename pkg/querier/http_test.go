@@ -158,6 +158,10 @@ func (m *mockQuerierClient) AnalyzeQuery(context.Context, *connect.Request[queri
 	return nil, nil
 }
 
+func (m *mockQuerierClient) LabelValuesCardinality(context.Context, *connect.Request[querierv1.LabelValuesCardinalityRequest]) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	return nil, nil
+}
+
 func (m *mockQuerierClient) SelectHeatmap(context.Context, *connect.Request[querierv1.SelectHeatmapRequest]) (*connect.Response[querierv1.SelectHeatmapResponse], error) {
 	return nil, nil
 }
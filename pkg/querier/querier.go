@@ -859,8 +859,10 @@ func (sq storeQuery) MergeSeriesRequest(req *querierv1.SelectSeriesRequest, prof
 			End:           int64(sq.end),
 			Aggregation:   req.Aggregation,
 		},
-		By:                 req.GroupBy,
-		StackTraceSelector: req.StackTraceSelector,
+		By:                   req.GroupBy,
+		StackTraceSelector:   req.StackTraceSelector,
+		ExemplarType:         req.ExemplarType,
+		MaxExemplarsPerPoint: req.GetMaxExemplarsPerPoint(),
 	}
 }
 
@@ -1066,10 +1068,12 @@ func (q *Querier) SelectSeries(ctx context.Context, req *connect.Request[querier
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("step must be >= 1ms"))
 	}
 
-	// SelectSeries (v1 API) does not support exemplars
-	if req.Msg.ExemplarType == typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL ||
-		req.Msg.ExemplarType == typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN {
-		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("exemplars are not supported in SelectSeries API, use the v2 query API instead"))
+	// Exemplars refer to whole profiles or spans, and do not
+	// make sense for the values of a subset of stack traces.
+	if (req.Msg.ExemplarType == typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL ||
+		req.Msg.ExemplarType == typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN) &&
+		len(req.Msg.StackTraceSelector.GetCallSite()) > 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("exemplars are not supported with a stack trace selector"))
 	}
 
 	stepMs := time.Duration(req.Msg.Step * float64(time.Second)).Milliseconds()
@@ -1098,7 +1102,8 @@ func (q *Querier) SelectSeries(ctx context.Context, req *connect.Request[querier
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	result := timeseries.RangeSeries(it, req.Msg.Start, req.Msg.End, stepMs, req.Msg.Aggregation)
+	maxExemplars := timeseries.ExemplarsPerPoint(req.Msg.GetMaxExemplarsPerPoint())
+	result := timeseries.RangeSeriesWithExemplarLimit(it, req.Msg.Start, req.Msg.End, stepMs, req.Msg.Aggregation, maxExemplars)
 	if it.Err() != nil {
 		return nil, connect.NewError(connect.CodeInternal, it.Err())
 	}
@@ -1112,6 +1117,10 @@ func (q *Querier) SelectHeatmap(ctx context.Context, req *connect.Request[querie
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("SelectHeatmap not implemented in old querier"))
 }
 
+func (q *Querier) LabelValuesCardinality(ctx context.Context, req *connect.Request[querierv1.LabelValuesCardinalityRequest]) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("LabelValuesCardinality not implemented in old querier"))
+}
+
 func (q *Querier) selectSeries(ctx context.Context, req *connect.Request[querierv1.SelectSeriesRequest], plan map[string]*blockPlanEntry) ([]ResponseFromReplica[clientpool.BidiClientMergeProfilesLabels], error) {
 	stepMs := time.Duration(req.Msg.Step * float64(time.Second)).Milliseconds()
 	sort.Strings(req.Msg.GroupBy)
@@ -1134,8 +1143,10 @@ func (q *Querier) selectSeries(ctx context.Context, req *connect.Request[querier
 				Type:          profileType,
 				Aggregation:   req.Msg.Aggregation,
 			},
-			By:                 req.Msg.GroupBy,
-			StackTraceSelector: req.Msg.StackTraceSelector,
+			By:                   req.Msg.GroupBy,
+			StackTraceSelector:   req.Msg.StackTraceSelector,
+			ExemplarType:         req.Msg.ExemplarType,
+			MaxExemplarsPerPoint: req.Msg.GetMaxExemplarsPerPoint(),
 		}, plan)
 	}
 
@@ -956,6 +956,30 @@ func TestSelectSeries(t *testing.T) {
 	}
 }
 
+func TestSelectSeries_RejectsExemplarsWithStackTraceSelector(t *testing.T) {
+	for _, exemplarType := range []typesv1.ExemplarType{
+		typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL,
+		typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN,
+	} {
+		t.Run(exemplarType.String(), func(t *testing.T) {
+			querier := &Querier{logger: log.NewNopLogger()}
+			_, err := querier.SelectSeries(context.Background(), connect.NewRequest(&querierv1.SelectSeriesRequest{
+				ProfileTypeID: "process_cpu:cpu:nanoseconds:cpu:nanoseconds",
+				LabelSelector: "{}",
+				Start:         1000,
+				End:           2000,
+				Step:          1,
+				ExemplarType:  exemplarType,
+				StackTraceSelector: &typesv1.StackTraceSelector{
+					CallSite: []*typesv1.Location{{Name: "foo"}},
+				},
+			}))
+			require.Error(t, err)
+			require.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+		})
+	}
+}
+
 type fakeQuerierIngester struct {
 	mock.Mock
 	testhelper.FakePoolClient
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"connectrpc.com/connect"
@@ -26,11 +25,14 @@ type Config struct {
 	Address          string            `yaml:"address" category:"advanced"`
 	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the query-frontends and the query-schedulers."`
 	ClientTimeout    time.Duration     `yaml:"client_timeout" category:"advanced"`
+
+	PartialResultsInterval time.Duration `yaml:"partial_results_interval" category:"advanced"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.Address, "query-backend.address", "localhost:9095", "")
 	f.DurationVar(&cfg.ClientTimeout, "query-backend.client-timeout", 30*time.Second, "Timeout for query-backend client requests.")
+	f.DurationVar(&cfg.PartialResultsInterval, "query-backend.partial-results-interval", defaultPartialResultsInterval, "Minimum interval between two partial results sent by a merge node when results are streamed.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-backend.grpc-client-config", f)
 }
 
@@ -45,6 +47,13 @@ type QueryHandler interface {
 	Invoke(context.Context, *queryv1.InvokeRequest) (*queryv1.InvokeResponse, error)
 }
 
+// StreamingQueryHandler is implemented by the query handlers that can
+// stream partial results. The last response passed to the function is
+// the complete one.
+type StreamingQueryHandler interface {
+	InvokeStream(context.Context, *queryv1.InvokeRequest, func(*queryv1.InvokeStreamResponse) error) error
+}
+
 type QueryBackend struct {
 	service services.Service
 	queryv1.QueryBackendServiceServer
@@ -88,14 +97,21 @@ func (q *QueryBackend) Invoke(
 ) (*queryv1.InvokeResponse, error) {
 	span, ctx := tracing.StartSpanFromContext(ctx, "QueryBackend.Invoke")
 	defer span.Finish()
+	return q.invoke(ctx, req, nil)
+}
 
+func (q *QueryBackend) invoke(
+	ctx context.Context,
+	req *queryv1.InvokeRequest,
+	partial *partialResults,
+) (*queryv1.InvokeResponse, error) {
 	collectDiag := req.Options != nil && req.Options.CollectDiagnostics
 	startTime := time.Now()
 
 	var resp *queryv1.InvokeResponse
 	var err error
 	var childNodes []*queryv1.ExecutionNode
-	var mergeBytes uint64
+	var mergeStats *queryv1.ExecutionStats
 
 	// Capture the node type before merge() sets QueryPlan to nil.
 	root := req.QueryPlan.Root
@@ -103,7 +119,7 @@ func (q *QueryBackend) Invoke(
 
 	switch nodeType {
 	case queryv1.QueryNode_MERGE:
-		resp, childNodes, mergeBytes, err = q.merge(ctx, req, root.Children, collectDiag)
+		resp, childNodes, mergeStats, err = q.merge(ctx, req, root.Children, collectDiag, partial)
 	case queryv1.QueryNode_READ:
 		resp, err = q.read(ctx, req, root.Blocks)
 	default:
@@ -114,15 +130,14 @@ func (q *QueryBackend) Invoke(
 		return nil, err
 	}
 
-	// For MERGE nodes, unconditionally expose the summed BytesFetched so the
-	// query-frontend counter is correct even when diagnostics are not collected
-	// (the common production case).  For READ nodes, BlockReader already set
-	// the ExecutionNode.
+	// For MERGE nodes, unconditionally expose the summed BytesFetched and
+	// resource usage counters so the query-frontend counters are correct even
+	// when diagnostics are not collected (the common production case).  For
+	// READ nodes, BlockReader already set the ExecutionNode.
 	if nodeType == queryv1.QueryNode_MERGE {
 		if resp.Diagnostics == nil {
 			resp.Diagnostics = &queryv1.Diagnostics{}
 		}
-		stats := &queryv1.ExecutionStats{BytesFetched: mergeBytes}
 		if collectDiag {
 			resp.Diagnostics.ExecutionNode = &queryv1.ExecutionNode{
 				Type:        nodeType,
@@ -130,11 +145,11 @@ func (q *QueryBackend) Invoke(
 				StartTimeNs: startTime.UnixNano(),
 				EndTimeNs:   time.Now().UnixNano(),
 				Children:    childNodes,
-				Stats:       stats,
+				Stats:       mergeStats,
 			}
 		} else {
 			resp.Diagnostics.ExecutionNode = &queryv1.ExecutionNode{
-				Stats: stats,
+				Stats: mergeStats,
 			}
 		}
 	}
@@ -147,14 +162,18 @@ func (q *QueryBackend) merge(
 	request *queryv1.InvokeRequest,
 	children []*queryv1.QueryNode,
 	collectDiag bool,
-) (*queryv1.InvokeResponse, []*queryv1.ExecutionNode, uint64, error) {
+	partial *partialResults,
+) (*queryv1.InvokeResponse, []*queryv1.ExecutionNode, *queryv1.ExecutionStats, error) {
 	request.QueryPlan = nil
 	m := newAggregator(request)
 	g, ctx := errgroup.WithContext(ctx)
+	if partial != nil {
+		partial.init(request, len(children))
+	}
 
 	childExecNodes := make([]*queryv1.ExecutionNode, len(children))
 	var mu sync.Mutex
-	var totalBytesFetched atomic.Uint64
+	stats := new(queryv1.ExecutionStats)
 
 	for i, child := range children {
 		idx := i
@@ -164,23 +183,29 @@ func (q *QueryBackend) merge(
 		}
 		g.Go(util.RecoverPanic(func() error {
 			// TODO: Speculative retry.
-			resp, err := q.backendClient.Invoke(ctx, req)
+			var resp *queryv1.InvokeResponse
+			var err error
+			if partial != nil {
+				resp, err = q.invokeChildStream(ctx, req, idx, partial)
+			} else {
+				resp, err = q.backendClient.Invoke(ctx, req)
+			}
 			if err != nil {
 				return err
 			}
-			// Always accumulate bytes regardless of collectDiag so the
-			// query-frontend counter is correct in the common (non-diagnostic) path.
-			totalBytesFetched.Add(resp.GetDiagnostics().GetExecutionNode().GetStats().GetBytesFetched())
+			// Always accumulate the stats regardless of collectDiag so the
+			// query-frontend counters are correct in the common (non-diagnostic) path.
+			mu.Lock()
+			AddExecutionStats(stats, resp.GetDiagnostics().GetExecutionNode().GetStats())
 			if collectDiag && resp.Diagnostics != nil && resp.Diagnostics.ExecutionNode != nil {
-				mu.Lock()
 				childExecNodes[idx] = resp.Diagnostics.ExecutionNode
-				mu.Unlock()
 			}
+			mu.Unlock()
 			return m.aggregateResponse(resp, nil)
 		}))
 	}
 	if err := g.Wait(); err != nil {
-		return nil, nil, 0, err
+		return nil, nil, nil, err
 	}
 
 	var executionNodes []*queryv1.ExecutionNode
@@ -191,7 +216,7 @@ func (q *QueryBackend) merge(
 	}
 
 	resp := m.response()
-	return resp, executionNodes, totalBytesFetched.Load(), nil
+	return resp, executionNodes, stats, nil
 }
 
 func (q *QueryBackend) read(
@@ -0,0 +1,188 @@
+package querybackend
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/tracing"
+
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	"github.com/grafana/pyroscope/v2/pkg/querybackend/queryplan"
+)
+
+// Partial results are streamed while the query plan is executed: each merge
+// node keeps the latest partial response of every child node, and
+// periodically merges them into a snapshot, which is sent to the caller
+// along with the number of blocks processed so far. Nested merge nodes stream
+// their snapshots to the parent node in the same way. A snapshot is only
+// built if the response of a child has changed since the last one: the
+// number of blocks processed by the child tells whether it has.
+//
+// Only the time series and tree reports are included in the snapshots. The
+// snapshots are built with dedicated aggregators: the final response is
+// merged from the complete responses of the child nodes, exactly as Invoke
+// does it.
+
+// defaultPartialResultsInterval is the default minimum
+// interval between two snapshots sent by a merge node.
+const defaultPartialResultsInterval = time.Second
+
+var partialReportTypes = map[queryv1.ReportType]struct{}{
+	queryv1.ReportType_REPORT_TREE:                {},
+	queryv1.ReportType_REPORT_TIME_SERIES:         {},
+	queryv1.ReportType_REPORT_TIME_SERIES_COMPACT: {},
+}
+
+func (q *QueryBackend) InvokeStream(
+	req *queryv1.InvokeRequest,
+	stream queryv1.QueryBackendService_InvokeStreamServer,
+) error {
+	span, ctx := tracing.StartSpanFromContext(stream.Context(), "QueryBackend.InvokeStream")
+	defer span.Finish()
+	return q.invokeStream(ctx, req, stream.Send)
+}
+
+func (q *QueryBackend) invokeStream(
+	ctx context.Context,
+	req *queryv1.InvokeRequest,
+	send func(*queryv1.InvokeStreamResponse) error,
+) error {
+	total := queryplan.CountBlocks(req.GetQueryPlan().GetRoot())
+	partial := newPartialResults(total, q.config.PartialResultsInterval, send)
+	resp, err := q.invoke(ctx, req, partial)
+	if err != nil {
+		return err
+	}
+	return partial.sendFinal(resp)
+}
+
+func (q *QueryBackend) invokeChildStream(
+	ctx context.Context,
+	req *queryv1.InvokeRequest,
+	child int,
+	partial *partialResults,
+) (*queryv1.InvokeResponse, error) {
+	// Read nodes only respond once all the blocks are read: they're invoked
+	// with the unary call, which is subject to the concurrency limit.
+	s, ok := q.backendClient.(StreamingQueryHandler)
+	if !ok || req.QueryPlan.Root.Type != queryv1.QueryNode_MERGE {
+		resp, err := q.backendClient.Invoke(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, partial.update(child, resp, queryplan.CountBlocks(req.QueryPlan.Root), true)
+	}
+	var resp *queryv1.InvokeResponse
+	err := s.InvokeStream(ctx, req, func(r *queryv1.InvokeStreamResponse) error {
+		if r.Final {
+			resp = r.Response
+		}
+		return partial.update(child, r.Response, r.GetProgress().GetBlocksDone(), r.Final)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errors.New("query backend: stream closed without the final response")
+	}
+	return resp, nil
+}
+
+type partialResults struct {
+	send     func(*queryv1.InvokeStreamResponse) error
+	total    int64
+	interval time.Duration
+
+	mu       sync.Mutex
+	request  *queryv1.InvokeRequest
+	children []childResults
+	lastSent time.Time
+	changed  bool
+}
+
+type childResults struct {
+	done    int64
+	reports []*queryv1.Report
+}
+
+func newPartialResults(total int64, interval time.Duration, send func(*queryv1.InvokeStreamResponse) error) *partialResults {
+	return &partialResults{
+		send:     send,
+		total:    total,
+		interval: interval,
+		lastSent: time.Now(),
+	}
+}
+
+// init is called by the merge node once the number of children is known.
+func (p *partialResults) init(request *queryv1.InvokeRequest, children int) {
+	p.request = request
+	p.children = make([]childResults, children)
+}
+
+// update records the latest response of the child node, and sends the
+// snapshot of the results merged so far, unless one has been sent recently,
+// or none of the child responses has changed since then.
+//
+// Intermediate responses are owned by the partial results and are kept as
+// is: they are only copied when a snapshot is built, because the aggregators
+// modify the reports in place. The final response of the child is also
+// merged into the final response of the node, therefore it is copied once,
+// when received.
+func (p *partialResults) update(child int, resp *queryv1.InvokeResponse, done int64, final bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c := p.children[child]; c.done < done {
+		var reports []*queryv1.Report
+		for _, r := range resp.GetReports() {
+			if _, ok := partialReportTypes[r.ReportType]; ok {
+				if final {
+					r = r.CloneVT()
+				}
+				reports = append(reports, r)
+			}
+		}
+		p.children[child] = childResults{done: done, reports: reports}
+		p.changed = true
+	}
+	now := time.Now()
+	if !p.changed || now.Sub(p.lastSent) < p.interval {
+		return nil
+	}
+	snapshot, err := p.snapshot()
+	if err != nil {
+		return err
+	}
+	p.lastSent = now
+	p.changed = false
+	return p.send(snapshot)
+}
+
+func (p *partialResults) snapshot() (*queryv1.InvokeStreamResponse, error) {
+	a := newAggregator(p.request)
+	var done int64
+	for _, c := range p.children {
+		done += c.done
+		for _, r := range c.reports {
+			// The same reports may be included in the next snapshot.
+			if err := a.aggregateReport(r.CloneVT()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &queryv1.InvokeStreamResponse{
+		Response: a.response(),
+		Progress: &querierv1.QueryProgress{BlocksDone: done, BlocksTotal: p.total},
+	}, nil
+}
+
+func (p *partialResults) sendFinal(resp *queryv1.InvokeResponse) error {
+	return p.send(&queryv1.InvokeStreamResponse{
+		Response: resp,
+		Progress: &querierv1.QueryProgress{BlocksDone: p.total, BlocksTotal: p.total},
+		Final:    true,
+	})
+}
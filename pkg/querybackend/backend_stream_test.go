@@ -0,0 +1,169 @@
+package querybackend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/querybackend/queryplan"
+)
+
+// sumAggregator sums the value carried by the reports.
+type sumAggregator struct {
+	mu  sync.Mutex
+	sum float64
+}
+
+func (a *sumAggregator) aggregate(r *queryv1.Report) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sum += reportValue(r)
+	return nil
+}
+
+func (a *sumAggregator) build() *queryv1.Report {
+	return valueReport(0, a.sum)
+}
+
+func valueReport(t queryv1.ReportType, v float64) *queryv1.Report {
+	return &queryv1.Report{
+		ReportType: t,
+		TimeSeries: &queryv1.TimeSeriesReport{
+			TimeSeries: []*typesv1.Series{{Points: []*typesv1.Point{{Value: v}}}},
+		},
+	}
+}
+
+func reportValue(r *queryv1.Report) float64 {
+	return r.GetTimeSeries().GetTimeSeries()[0].GetPoints()[0].GetValue()
+}
+
+// blockCounter responds with the number of blocks read.
+type blockCounter struct{ reportType queryv1.ReportType }
+
+func (c blockCounter) Invoke(_ context.Context, req *queryv1.InvokeRequest) (*queryv1.InvokeResponse, error) {
+	n := float64(len(req.QueryPlan.Root.Blocks))
+	return &queryv1.InvokeResponse{Reports: []*queryv1.Report{valueReport(c.reportType, n)}}, nil
+}
+
+// localBackend invokes the query backend in-process.
+type localBackend struct{ *QueryBackend }
+
+func (b localBackend) InvokeStream(ctx context.Context, req *queryv1.InvokeRequest, fn func(*queryv1.InvokeStreamResponse) error) error {
+	return b.invokeStream(ctx, req, fn)
+}
+
+func TestQueryBackend_InvokeStream(t *testing.T) {
+	reportType := queryv1.ReportType(998)
+	registerAggregator(reportType, func(*queryv1.InvokeRequest) aggregator { return new(sumAggregator) }, false)
+	partialReportTypes[reportType] = struct{}{}
+	defer func() {
+		aggregatorMutex.Lock()
+		delete(aggregators, reportType)
+		aggregatorMutex.Unlock()
+		delete(partialReportTypes, reportType)
+	}()
+
+	b, err := New(Config{PartialResultsInterval: 0}, log.NewNopLogger(), nil, nil, blockCounter{reportType: reportType})
+	require.NoError(t, err)
+	b.backendClient = localBackend{b}
+
+	const blockCount = 50
+	blocks := make([]*metastorev1.BlockMeta, blockCount)
+	for i := range blocks {
+		blocks[i] = &metastorev1.BlockMeta{Id: fmt.Sprintf("block-%d", i)}
+	}
+	plan := queryplan.Build(blocks, 2, 3)
+
+	var responses []*queryv1.InvokeStreamResponse
+	err = b.invokeStream(context.Background(), &queryv1.InvokeRequest{QueryPlan: plan}, func(r *queryv1.InvokeStreamResponse) error {
+		responses = append(responses, r)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Greater(t, len(responses), 1)
+
+	var done int64
+	for i, r := range responses {
+		assert.Equal(t, int64(blockCount), r.Progress.BlocksTotal)
+		assert.GreaterOrEqual(t, r.Progress.BlocksDone, done)
+		done = r.Progress.BlocksDone
+		assert.Equal(t, i == len(responses)-1, r.Final)
+		// Each block counts as one: the snapshot
+		// covers exactly the blocks processed.
+		var sum float64
+		for _, x := range r.Response.Reports {
+			sum += reportValue(x)
+		}
+		assert.Equal(t, float64(r.Progress.BlocksDone), sum)
+	}
+	assert.Equal(t, int64(blockCount), done)
+}
+
+func TestPartialResults_SkipsUnchangedChildren(t *testing.T) {
+	reportType := queryv1.ReportType(997)
+	registerAggregator(reportType, func(*queryv1.InvokeRequest) aggregator { return new(sumAggregator) }, false)
+	partialReportTypes[reportType] = struct{}{}
+	defer func() {
+		aggregatorMutex.Lock()
+		delete(aggregators, reportType)
+		aggregatorMutex.Unlock()
+		delete(partialReportTypes, reportType)
+	}()
+
+	var sent []float64
+	p := newPartialResults(4, 0, func(r *queryv1.InvokeStreamResponse) error {
+		sent = append(sent, reportValue(r.Response.Reports[0]))
+		return nil
+	})
+	p.init(&queryv1.InvokeRequest{}, 2)
+	resp := func(v float64) *queryv1.InvokeResponse {
+		return &queryv1.InvokeResponse{Reports: []*queryv1.Report{valueReport(reportType, v)}}
+	}
+
+	require.NoError(t, p.update(0, resp(1), 1, false))
+	require.NoError(t, p.update(1, resp(2), 2, false))
+	// The child has not processed any new block.
+	require.NoError(t, p.update(1, resp(2), 2, false))
+	require.NoError(t, p.update(0, resp(2), 2, false))
+	assert.Equal(t, []float64{1, 3, 4}, sent)
+}
+
+func TestPartialResults_CopiesFinalResponses(t *testing.T) {
+	reportType := queryv1.ReportType(996)
+	registerAggregator(reportType, func(*queryv1.InvokeRequest) aggregator { return new(sumAggregator) }, false)
+	partialReportTypes[reportType] = struct{}{}
+	defer func() {
+		aggregatorMutex.Lock()
+		delete(aggregators, reportType)
+		aggregatorMutex.Unlock()
+		delete(partialReportTypes, reportType)
+	}()
+
+	var sent []float64
+	p := newPartialResults(4, 0, func(r *queryv1.InvokeStreamResponse) error {
+		sent = append(sent, reportValue(r.Response.Reports[0]))
+		return nil
+	})
+	p.init(&queryv1.InvokeRequest{}, 2)
+	resp := func(v float64) *queryv1.InvokeResponse {
+		return &queryv1.InvokeResponse{Reports: []*queryv1.Report{valueReport(reportType, v)}}
+	}
+
+	final := resp(2)
+	require.NoError(t, p.update(0, final, 2, true))
+	// The final response is merged into the final
+	// response of the node, which modifies it.
+	final.Reports[0].TimeSeries.TimeSeries[0].Points[0].Value = 10
+	require.NoError(t, p.update(1, resp(1), 1, false))
+	require.NoError(t, p.update(1, resp(2), 2, false))
+	assert.Equal(t, []float64{2, 3, 4}, sent)
+}
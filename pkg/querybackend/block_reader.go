@@ -55,6 +55,8 @@ type Overrides interface {
 type BlockReader struct {
 	log     log.Logger
 	storage objstore.Bucket
+	// Optional bucket of the cold storage tier.
+	cold objstore.Bucket
 
 	metrics  *metrics
 	hostname string
@@ -69,11 +71,12 @@ type BlockReader struct {
 	//    Instead, they should share the processing pipeline, if possible.
 }
 
-func NewBlockReader(logger log.Logger, storage objstore.Bucket, reg prometheus.Registerer, overrides Overrides) *BlockReader {
+func NewBlockReader(logger log.Logger, storage, coldStorage objstore.Bucket, reg prometheus.Registerer, overrides Overrides) *BlockReader {
 	hostname, _ := os.Hostname()
 	return &BlockReader{
 		log:       logger,
 		storage:   storage,
+		cold:      coldStorage,
 		metrics:   newMetrics(reg),
 		hostname:  hostname,
 		Overrides: overrides,
@@ -112,11 +115,16 @@ func (b *BlockReader) Invoke(
 	}
 
 	weightCollector := &queryWeightCollector{}
+	resourceCollector := &queryResourceCollector{}
 
 	// Per-invocation byte counter: only the successful response carries the
 	// total, so retried calls from the query-frontend never double-count.
 	var fetchedBytes atomic.Uint64
 	countingStorage := objstore.NewCountingBucket(b.storage, &fetchedBytes)
+	var countingColdStorage *objstore.CountingBucket
+	if b.cold != nil {
+		countingColdStorage = objstore.NewCountingBucket(b.cold, &fetchedBytes)
+	}
 
 	var blocksCount, datasetsCount int64
 	for _, md := range req.QueryPlan.Root.Blocks {
@@ -131,17 +139,22 @@ func (b *BlockReader) Invoke(
 		}
 		blocksCount++
 		datasetsCount += int64(len(md.Datasets))
-		obj := block.NewObject(countingStorage, md)
+		storage := countingStorage
+		if countingColdStorage != nil && md.StorageTier == metastorev1.StorageTier_STORAGE_TIER_COLD {
+			storage = countingColdStorage
+		}
+		obj := block.NewObject(storage, md)
 		g.Go(util.RecoverPanic((&blockContext{
-			ctx:             ctx,
-			log:             b.log,
-			req:             r,
-			agg:             agg,
-			obj:             obj,
-			grp:             g,
-			execCollector:   blockExecCollector,
-			weightCollector: weightCollector,
-			includeStripped: includeStripped,
+			ctx:               ctx,
+			log:               b.log,
+			req:               r,
+			agg:               agg,
+			obj:               obj,
+			grp:               g,
+			execCollector:     blockExecCollector,
+			weightCollector:   weightCollector,
+			resourceCollector: resourceCollector,
+			includeStripped:   includeStripped,
 		}).execute))
 	}
 
@@ -153,6 +166,9 @@ func (b *BlockReader) Invoke(
 	// may still be draining a GetRange reader after the errgroup returned.
 	// This ensures fetchedBytes is stable before we sample it below.
 	countingStorage.Wait()
+	if countingColdStorage != nil {
+		countingColdStorage.Wait()
+	}
 
 	if weightCollector.datasetsCount > 0 {
 		traceID, _ := tracing.ExtractTraceID(ctx)
@@ -183,6 +199,7 @@ func (b *BlockReader) Invoke(
 	stats := &queryv1.ExecutionStats{
 		BytesFetched: fetchedBytes.Load(),
 	}
+	resourceCollector.collect(stats)
 	if collectDiag {
 		stats.BlocksRead = blocksCount
 		stats.DatasetsProcessed = datasetsCount
@@ -204,10 +221,11 @@ func (b *BlockReader) Invoke(
 }
 
 type request struct {
-	src       *queryv1.InvokeRequest
-	matchers  []*labels.Matcher
-	startTime int64 // Unix nano.
-	endTime   int64 // Unix nano.
+	src        *queryv1.InvokeRequest
+	matchers   []*labels.Matcher
+	startTime  int64 // Unix nano.
+	endTime    int64 // Unix nano.
+	tombstones []block.SeriesTombstone
 }
 
 func (r *request) setTraceTags(span *tracing.Span) {
@@ -241,11 +259,16 @@ func validateRequest(req *queryv1.InvokeRequest) (*request, error) {
 	if err != nil {
 		return nil, fmt.Errorf("label selection is invalid: %w", err)
 	}
+	tombstones, err := block.ParseSeriesTombstones(req.SeriesTombstones...)
+	if err != nil {
+		return nil, err
+	}
 	r := request{
-		src:       req,
-		matchers:  matchers,
-		startTime: model.Time(req.StartTime).UnixNano(),
-		endTime:   model.Time(req.EndTime).UnixNano(),
+		src:        req,
+		matchers:   matchers,
+		startTime:  model.Time(req.StartTime).UnixNano(),
+		endTime:    model.Time(req.EndTime).UnixNano(),
+		tombstones: tombstones,
 	}
 	return &r, nil
 }
@@ -286,6 +309,54 @@ func (c *queryWeightCollector) addDatasets(datasets []*metastorev1.Dataset) {
 	c.mu.Unlock()
 }
 
+// queryResourceCollector accumulates the resources consumed by the queries
+// of a single invocation: queries of all the blocks and datasets update it
+// concurrently.
+type queryResourceCollector struct {
+	rowsScanned     atomic.Int64
+	profilesScanned atomic.Int64
+	stackTraces     atomic.Int64
+
+	mu sync.Mutex
+	// Total size of the dataset sections opened concurrently.
+	memory     uint64
+	peakMemory uint64
+}
+
+func (c *queryResourceCollector) acquire(n uint64) {
+	c.mu.Lock()
+	c.memory += n
+	c.peakMemory = max(c.peakMemory, c.memory)
+	c.mu.Unlock()
+}
+
+func (c *queryResourceCollector) release(n uint64) {
+	c.mu.Lock()
+	c.memory -= n
+	c.mu.Unlock()
+}
+
+func (c *queryResourceCollector) collect(stats *queryv1.ExecutionStats) {
+	stats.RowsScanned = c.rowsScanned.Load()
+	stats.ProfilesScanned = c.profilesScanned.Load()
+	stats.StackTraces = c.stackTraces.Load()
+	c.mu.Lock()
+	stats.PeakMemoryBytes = c.peakMemory
+	c.mu.Unlock()
+}
+
+// AddExecutionStats adds the resource usage counters of src to dst.
+// The peak memory usage is the maximum of the estimates.
+func AddExecutionStats(dst, src *queryv1.ExecutionStats) {
+	dst.BytesFetched += src.GetBytesFetched()
+	dst.RowsScanned += src.GetRowsScanned()
+	dst.ProfilesScanned += src.GetProfilesScanned()
+	dst.StackTraces += src.GetStackTraces()
+	dst.PeakMemoryBytes = max(dst.PeakMemoryBytes, src.GetPeakMemoryBytes())
+	dst.CacheHits += src.GetCacheHits()
+	dst.CacheMisses += src.GetCacheMisses()
+}
+
 // blockExecutionCollector collects per-block execution stats in a thread-safe manner.
 type blockExecutionCollector struct {
 	mu         sync.Mutex
@@ -53,7 +53,7 @@ func (s *testSuite) SetupSuite() {
 func (s *testSuite) SetupTest() {
 	s.ctx = context.Background()
 	s.logger = test.NewTestingLogger(s.T())
-	s.reader = NewBlockReader(s.logger, &objstore.ReaderAtBucket{Bucket: s.bucket}, nil, validation.MockDefaultOverrides())
+	s.reader = NewBlockReader(s.logger, &objstore.ReaderAtBucket{Bucket: s.bucket}, nil, nil, validation.MockDefaultOverrides())
 	s.meta = make([]*metastorev1.BlockMeta, len(s.blocks))
 	for i, b := range s.blocks {
 		s.meta[i] = b.CloneVT()
@@ -143,6 +143,39 @@ func (s *testSuite) Test_QueryTree_All() {
 	s.Assert().Equal(string(expected), tree.String())
 }
 
+func (s *testSuite) Test_QueryTree_ColdStorage() {
+	expected, err := os.ReadFile("testdata/fixtures/tree_16.txt")
+	s.Require().NoError(err)
+
+	// The hot bucket is empty: all the blocks are stored in the cold tier.
+	hot := &objstore.ReaderAtBucket{Bucket: memory.NewInMemBucket()}
+	cold := &objstore.ReaderAtBucket{Bucket: s.bucket}
+	reader := NewBlockReader(s.logger, hot, cold, nil, validation.MockDefaultOverrides())
+	for _, b := range s.plan.Root.Blocks {
+		b.StorageTier = metastorev1.StorageTier_STORAGE_TIER_COLD
+	}
+
+	resp, err := reader.Invoke(s.ctx, &queryv1.InvokeRequest{
+		EndTime:       time.Now().UnixMilli(),
+		LabelSelector: "{}",
+		QueryPlan:     s.plan,
+		Query: []*queryv1.Query{{
+			QueryType: queryv1.QueryType_QUERY_TREE,
+			Tree:      &queryv1.TreeQuery{MaxNodes: 16},
+		}},
+		Tenant: s.tenant,
+	})
+
+	s.Require().NoError(err)
+	s.Require().NotNil(resp)
+	s.Require().Len(resp.Reports, 1)
+	tree, err := phlaremodel.UnmarshalTree[phlaremodel.FunctionName, phlaremodel.FunctionNameI](resp.Reports[0].Tree.Tree)
+	s.Require().NoError(err)
+
+	s.Assert().Equal(string(expected), tree.String())
+	s.Assert().NotZero(resp.Diagnostics.ExecutionNode.Stats.BytesFetched)
+}
+
 func (s *testSuite) Test_QueryTree_Filter() {
 	expected, err := os.ReadFile("testdata/fixtures/tree_16_slow.txt")
 	s.Require().NoError(err)
@@ -285,6 +318,51 @@ func (s *testSuite) Test_QueryTimeSeries() {
 	s.Assert().JSONEq(string(expected), string(actual))
 }
 
+func (s *testSuite) Test_QueryTimeSeries_Sampling() {
+	invoke := func(ratio float64) *queryv1.TimeSeriesReport {
+		resp, err := s.reader.Invoke(s.ctx, &queryv1.InvokeRequest{
+			StartTime: startTime.UnixMilli(),
+			EndTime:   startTime.Add(time.Hour).UnixMilli(),
+			Query: []*queryv1.Query{{
+				QueryType: queryv1.QueryType_QUERY_TIME_SERIES,
+				TimeSeries: &queryv1.TimeSeriesQuery{
+					Step:          30.0,
+					SamplingRatio: ratio,
+				},
+			}},
+			QueryPlan:     s.plan,
+			LabelSelector: "{}",
+			Tenant:        s.tenant,
+		})
+		s.Require().NoError(err)
+		s.Require().Len(resp.Reports, 1)
+		s.Require().NotNil(resp.Reports[0].TimeSeries)
+		return resp.Reports[0].TimeSeries
+	}
+	total := func(r *queryv1.TimeSeriesReport) (v float64) {
+		for _, x := range r.TimeSeries {
+			for _, p := range x.Points {
+				v += p.Value
+			}
+		}
+		return v
+	}
+
+	exact := invoke(0)
+	s.Assert().Nil(exact.Sampling)
+
+	approx := invoke(0.5)
+	s.Require().NotNil(approx.Sampling)
+	s.Assert().Positive(approx.Sampling.ProfilesSampled)
+	s.Assert().Less(approx.Sampling.ProfilesSampled, approx.Sampling.ProfilesTotal)
+	relativeError := RelativeError(approx.Sampling)
+	s.Assert().Positive(relativeError)
+	s.Assert().InEpsilon(total(exact), total(approx), 4*relativeError)
+
+	// The sample is deterministic.
+	s.Assert().True(approx.EqualVT(invoke(0.5)))
+}
+
 // When there is only one report we don't run the aggregate method. This check ensures that the timeseries, is still correctly formatted.
 func (s *testSuite) Test_QueryTimeSeriesOneReport() {
 	query := &queryv1.Query{
@@ -546,6 +624,29 @@ func (s *testSuite) Test_BytesFetched_ConsistentAcrossInvocations() {
 	s.Assert().InEpsilon(float64(first), float64(second), 0.10)
 }
 
+func (s *testSuite) Test_ResourceStats_Populated() {
+	resp, err := s.reader.Invoke(s.ctx, &queryv1.InvokeRequest{
+		EndTime:       time.Now().UnixMilli(),
+		LabelSelector: "{}",
+		QueryPlan:     s.plan,
+		Query: []*queryv1.Query{{
+			QueryType: queryv1.QueryType_QUERY_TREE,
+			Tree:      &queryv1.TreeQuery{MaxNodes: 16},
+		}},
+		Tenant: s.tenant,
+	})
+	s.Require().NoError(err)
+	stats := resp.GetDiagnostics().GetExecutionNode().GetStats()
+	s.Require().NotNil(stats)
+	s.Assert().Greater(stats.ProfilesScanned, int64(0))
+	s.Assert().GreaterOrEqual(stats.RowsScanned, stats.ProfilesScanned)
+	s.Assert().Greater(stats.StackTraces, int64(0))
+	s.Assert().Greater(stats.PeakMemoryBytes, uint64(0))
+	// Read nodes have no results cache.
+	s.Assert().Zero(stats.CacheHits)
+	s.Assert().Zero(stats.CacheMisses)
+}
+
 func (s *testSuite) Test_SpanAndTraceSelector_Combined_Errors() {
 	// No public RPC sets both; both being set is an internal-plan bug.
 	span := []string{fixtureMatchingSpanID}
@@ -784,6 +885,39 @@ func (s *testSuite) Test_SpanHeatmapIncludesTraceID() {
 	s.Fail("span heatmap did not include the fixture trace ID")
 }
 
+func (s *testSuite) Test_TimeSeriesSpanExemplarsIncludeTraceID() {
+	resp, err := s.reader.Invoke(s.ctx, &queryv1.InvokeRequest{
+		StartTime:     startTime.UnixMilli(),
+		EndTime:       startTime.Add(5 * time.Minute).UnixMilli(),
+		LabelSelector: "{}",
+		QueryPlan:     s.plan,
+		Query: []*queryv1.Query{{
+			QueryType: queryv1.QueryType_QUERY_TIME_SERIES,
+			TimeSeries: &queryv1.TimeSeriesQuery{
+				Step:                 30.0,
+				ExemplarType:         typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN,
+				MaxExemplarsPerPoint: 100,
+			},
+		}},
+		Tenant: s.tenant,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(resp.Reports, 1)
+
+	for _, series := range resp.Reports[0].TimeSeries.TimeSeries {
+		for _, point := range series.Points {
+			for _, e := range point.Exemplars {
+				s.Require().NotEmpty(e.SpanId)
+				if e.TraceId == fixtureMatchingTraceID {
+					s.Require().NotEmpty(e.ProfileId)
+					return
+				}
+			}
+		}
+	}
+	s.Fail("time series span exemplars did not include the fixture trace ID")
+}
+
 func (s *testSuite) Test_TraceSelector() {
 	baselineTree, err := os.ReadFile("testdata/fixtures/tree_16.txt")
 	s.Require().NoError(err)
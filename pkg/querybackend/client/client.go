@@ -2,7 +2,9 @@ package querybackendclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/grafana/dskit/grpcclient"
@@ -51,6 +53,27 @@ func (b *Client) Invoke(ctx context.Context, req *queryv1.InvokeRequest) (*query
 	return b.grpcClient.Invoke(ctx, req)
 }
 
+func (b *Client) InvokeStream(ctx context.Context, req *queryv1.InvokeRequest, fn func(*queryv1.InvokeStreamResponse) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := b.grpcClient.InvokeStream(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(resp); err != nil {
+			return err
+		}
+	}
+}
+
 const grpcServiceConfigTemplate = `{
     "loadBalancingPolicy":"round_robin",
     "methodConfig": [{
@@ -83,15 +83,16 @@ func registerQueryType(
 }
 
 type blockContext struct {
-	ctx             context.Context
-	log             log.Logger
-	req             *request
-	agg             *reportAggregator
-	obj             *block.Object
-	grp             *errgroup.Group
-	execCollector   *blockExecutionCollector
-	weightCollector *queryWeightCollector
-	includeStripped bool
+	ctx               context.Context
+	log               log.Logger
+	req               *request
+	agg               *reportAggregator
+	obj               *block.Object
+	grp               *errgroup.Group
+	execCollector     *blockExecutionCollector
+	weightCollector   *queryWeightCollector
+	resourceCollector *queryResourceCollector
+	includeStripped   bool
 }
 
 func (b *blockContext) execute() error {
@@ -117,12 +118,18 @@ func (b *blockContext) execute() error {
 	md := b.obj.Metadata()
 	for _, ds := range md.Datasets {
 		q := b.newQueryContext(ds)
+		// The sections of the dataset are shared by the queries,
+		// and are held in memory until all of them complete.
+		size := block.WeightOf(ds).SectionsBytes(q.sections()...)
+		b.resourceCollector.acquire(size)
 		for _, query := range b.req.src.Query {
 			q.grp.Go(util.RecoverPanic(func() error {
 				return q.execute(query)
 			}))
 		}
-		if err := q.grp.Wait(); err != nil {
+		err := q.grp.Wait()
+		b.resourceCollector.release(size)
+		if err != nil {
 			return err
 		}
 	}
@@ -178,9 +185,10 @@ func (b *blockContext) datasetIndices() []*metastorev1.Dataset {
 
 	// If the query only requires TSDB data, we can serve it directly
 	// from each Format1 dataset's TSDB section (which is aliased to the
-	// dataset_index) without resolving real datasets.
+	// dataset_index) without resolving real datasets. Series tombstones
+	// can't be applied to the dataset index, though.
 	s := (&queryContext{blockContext: b}).sections()
-	indexOnly := len(s) == 1 && s[0] == block.SectionTSDB
+	indexOnly := len(s) == 1 && s[0] == block.SectionTSDB && len(b.req.tombstones) == 0
 	if indexOnly {
 		oteltrace.SpanFromContext(b.ctx).SetAttributes(attribute.Bool("dataset_index_query_index_only", indexOnly))
 		return nil
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/block"
 	"github.com/grafana/pyroscope/v2/pkg/model"
 	"github.com/grafana/pyroscope/v2/pkg/phlaredb"
+	"github.com/grafana/pyroscope/v2/pkg/phlaredb/tsdb/index"
 )
 
 func init() {
@@ -24,12 +25,15 @@ func init() {
 }
 
 func queryLabelNames(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
+	deleted, err := getDeletedSeries(q)
+	if err != nil {
+		return nil, err
+	}
 	var names []string
-	var err error
-	if len(q.req.matchers) == 0 {
+	if len(q.req.matchers) == 0 && deleted == nil {
 		names, err = q.ds.Index().LabelNames()
 	} else {
-		names, err = labelNamesForMatchers(q.ds.Index(), q.req.matchers)
+		names, err = labelNamesForMatchers(q.ds.Index(), q.req.matchers, deleted)
 	}
 	if err != nil {
 		return nil, err
@@ -43,13 +47,21 @@ func queryLabelNames(q *queryContext, query *queryv1.Query) (*queryv1.Report, er
 	return resp, nil
 }
 
-func labelNamesForMatchers(reader phlaredb.IndexReader, matchers []*labels.Matcher) ([]string, error) {
-	postings, err := phlaredb.PostingsForMatchers(reader, nil, matchers...)
+func labelNamesForMatchers(reader phlaredb.IndexReader, matchers []*labels.Matcher, deleted *deletedSeries) ([]string, error) {
+	postings, err := getPostings(reader, matchers...)
 	if err != nil {
 		return nil, err
 	}
 	l := make(map[string]struct{})
+	chunks := make([]index.ChunkMeta, 1)
 	for postings.Next() {
+		skip, err := deleted.seriesDeleted(reader, postings.At(), &chunks)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			continue
+		}
 		var n []string
 		if n, err = reader.LabelNamesFor(postings.At()); err != nil {
 			return nil, err
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/block"
 	"github.com/grafana/pyroscope/v2/pkg/model"
 	"github.com/grafana/pyroscope/v2/pkg/phlaredb"
+	"github.com/grafana/pyroscope/v2/pkg/phlaredb/tsdb/index"
 )
 
 func init() {
@@ -26,12 +27,15 @@ func init() {
 }
 
 func queryLabelValues(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
+	deleted, err := getDeletedSeries(q)
+	if err != nil {
+		return nil, err
+	}
 	var values []string
-	var err error
-	if len(q.req.matchers) == 0 {
+	if len(q.req.matchers) == 0 && deleted == nil {
 		values, err = q.ds.Index().LabelValues(query.LabelValues.LabelName)
 	} else {
-		values, err = labelValuesForMatchers(q.ds.Index(), query.LabelValues.LabelName, q.req.matchers)
+		values, err = labelValuesForMatchers(q.ds.Index(), query.LabelValues.LabelName, q.req.matchers, deleted)
 	}
 	if err != nil {
 		return nil, err
@@ -45,13 +49,21 @@ func queryLabelValues(q *queryContext, query *queryv1.Query) (*queryv1.Report, e
 	return resp, nil
 }
 
-func labelValuesForMatchers(reader phlaredb.IndexReader, name string, matchers []*labels.Matcher) ([]string, error) {
-	postings, err := phlaredb.PostingsForMatchers(reader, nil, matchers...)
+func labelValuesForMatchers(reader phlaredb.IndexReader, name string, matchers []*labels.Matcher, deleted *deletedSeries) ([]string, error) {
+	postings, err := getPostings(reader, matchers...)
 	if err != nil {
 		return nil, err
 	}
 	l := make(map[string]struct{})
+	chunks := make([]index.ChunkMeta, 1)
 	for postings.Next() {
+		skip, err := deleted.seriesDeleted(reader, postings.At(), &chunks)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			continue
+		}
 		var v string
 		if v, err = reader.LabelValueFor(postings.At(), name); err != nil {
 			if errors.Is(err, storage.ErrNotFound) {
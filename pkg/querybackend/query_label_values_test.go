@@ -2,9 +2,11 @@ package querybackend
 
 import (
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
 )
 
@@ -95,3 +97,50 @@ func (s *testSuite) Test_LabelValues_NoMatcher_BufferReuse() {
 		s.Assert().Equal(want, r.got)
 	}
 }
+
+func (s *testSuite) Test_LabelNamesAndValues_SeriesTombstones() {
+	endTime := time.Now().UnixMilli()
+	invoke := func(selector string, query *queryv1.Query, tombstones ...*metastorev1.SeriesTombstone) *queryv1.Report {
+		resp, err := s.reader.Invoke(s.ctx, &queryv1.InvokeRequest{
+			EndTime:          endTime,
+			LabelSelector:    selector,
+			QueryPlan:        s.plan.CloneVT(),
+			Query:            []*queryv1.Query{query},
+			Tenant:           s.tenant,
+			SeriesTombstones: tombstones,
+		})
+		s.Require().NoError(err)
+		s.Require().Len(resp.Reports, 1)
+		return resp.Reports[0]
+	}
+	labelValues := &queryv1.Query{
+		QueryType:   queryv1.QueryType_QUERY_LABEL_VALUES,
+		LabelValues: &queryv1.LabelValuesQuery{LabelName: "service_name"},
+	}
+	labelNames := &queryv1.Query{
+		QueryType:  queryv1.QueryType_QUERY_LABEL_NAMES,
+		LabelNames: &queryv1.LabelNamesQuery{},
+	}
+
+	values := invoke("{}", labelValues).LabelValues.LabelValues
+	s.Require().NotEmpty(values)
+	deleted, remaining := values[0], values[1:]
+	selector := fmt.Sprintf(`{service_name=%q}`, deleted)
+	s.Require().NotEmpty(invoke(selector, labelNames).LabelNames.LabelNames)
+
+	var tombstones []*metastorev1.SeriesTombstone
+	for _, tenant := range slices.Compact(slices.Sorted(slices.Values(s.tenant))) {
+		tombstones = append(tombstones, &metastorev1.SeriesTombstone{
+			Name:          "tombstone",
+			Tenant:        tenant,
+			LabelSelector: selector,
+			EndTime:       endTime,
+		})
+	}
+
+	values = invoke("{}", labelValues, tombstones...).LabelValues.LabelValues
+	s.Assert().NotContains(values, deleted)
+	s.Assert().ElementsMatch(remaining, values)
+	s.Assert().Empty(invoke(selector, labelNames, tombstones...).LabelNames.LabelNames)
+	s.Assert().Empty(invoke(selector, labelValues, tombstones...).LabelValues.LabelValues)
+}
@@ -143,6 +143,7 @@ func queryPprof(q *queryContext, query *queryv1.Query) (*queryv1.Report, error)
 	if err = profiles.Err(); err != nil {
 		return nil, err
 	}
+	q.resourceCollector.stackTraces.Add(int64(resolver.StackTraces()))
 
 	profile, err := resolver.Pprof()
 	if err != nil {
@@ -3,6 +3,7 @@ package querybackend
 import (
 	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	"github.com/parquet-go/parquet-go"
@@ -28,6 +29,8 @@ type ProfileEntry struct {
 	Labels      phlaremodel.Labels
 	Partition   uint64
 	ID          string
+
+	seriesIndex uint32
 }
 
 func (e ProfileEntry) RowNumber() int64 { return e.RowNum }
@@ -100,6 +103,7 @@ type iteratorOpts struct {
 	fetchProfileIDs   bool
 	fetchPartition    bool
 	excludeSampled    bool
+	sampler           *profileSampler
 }
 
 func iteratorOptsFromOptions(options []profileIteratorOption) iteratorOpts {
@@ -171,6 +175,17 @@ func profileEntryIterator(q *queryContext, options ...profileIteratorOption) (it
 	if err != nil {
 		return nil, err
 	}
+	deleted, err := getDeletedSeries(q)
+	if err != nil {
+		return nil, err
+	}
+	for id := range series {
+		// There's no need to read profiles of the series
+		// deleted entirely within the query time range.
+		if deleted.deleted(id) {
+			delete(series, id)
+		}
+	}
 
 	columns := queryColumns{
 		{schemav1.SeriesIndexColumnName, parquetquery.NewMapPredicate(series), 10},
@@ -228,6 +243,7 @@ func profileEntryIterator(q *queryContext, options ...profileIteratorOption) (it
 				Timestamp:   model.TimeFromUnixNano(buf[1][0].Int64()),
 				Fingerprint: x.fingerprint,
 				Labels:      x.labels,
+				seriesIndex: buf[0][0].Uint32(),
 			}
 			for _, proc := range processor {
 				proc(buf, &e)
@@ -236,7 +252,42 @@ func profileEntryIterator(q *queryContext, options ...profileIteratorOption) (it
 		},
 		func([]ProfileEntry) {},
 	)
-	return entries, nil
+	var it iter.Iterator[ProfileEntry] = &profileEntryCounter{
+		Iterator: entries,
+		counter:  &q.resourceCollector.rowsScanned,
+	}
+	if deleted != nil {
+		it = &deletedProfilesFilter{Iterator: it, deleted: deleted}
+	}
+	if opts.sampler != nil {
+		it = &sampledProfilesFilter{Iterator: it, sampler: opts.sampler}
+	}
+	it = &profileEntryCounter{
+		Iterator: it,
+		counter:  &q.resourceCollector.profilesScanned,
+	}
+	return it, nil
+}
+
+// profileEntryCounter counts the entries read from the iterator,
+// and adds the count to the counter when the iterator is closed.
+type profileEntryCounter struct {
+	iter.Iterator[ProfileEntry]
+	counter *atomic.Int64
+	n       atomic.Int64
+}
+
+func (c *profileEntryCounter) Next() bool {
+	if c.Iterator.Next() {
+		c.n.Add(1)
+		return true
+	}
+	return false
+}
+
+func (c *profileEntryCounter) Close() error {
+	c.counter.Add(c.n.Swap(0))
+	return c.Iterator.Close()
 }
 
 type series struct {
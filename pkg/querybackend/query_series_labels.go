@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/block"
 	phlaremodel "github.com/grafana/pyroscope/v2/pkg/model"
 	"github.com/grafana/pyroscope/v2/pkg/phlaredb"
+	"github.com/grafana/pyroscope/v2/pkg/phlaredb/tsdb/index"
 )
 
 func init() {
@@ -27,7 +28,11 @@ func init() {
 }
 
 func querySeriesLabels(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
-	series, err := getSeriesLabels(q.ds.Index(), q.req.matchers, query.SeriesLabels.LabelNames...)
+	deleted, err := getDeletedSeries(q)
+	if err != nil {
+		return nil, err
+	}
+	series, err := getSeriesLabels(q.ds.Index(), q.req.matchers, deleted, query.SeriesLabels.LabelNames...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +74,7 @@ func (a *seriesLabelsAggregator) build() *queryv1.Report {
 	}
 }
 
-func getSeriesLabels(reader phlaredb.IndexReader, matchers []*labels.Matcher, by ...string) ([]*typesv1.Labels, error) {
+func getSeriesLabels(reader phlaredb.IndexReader, matchers []*labels.Matcher, deleted *deletedSeries, by ...string) ([]*typesv1.Labels, error) {
 	names, err := reader.LabelNames()
 	if err != nil {
 		return nil, err
@@ -99,8 +104,16 @@ func getSeriesLabels(reader phlaredb.IndexReader, matchers []*labels.Matcher, by
 	for i := range names {
 		ls[i] = new(typesv1.LabelPair)
 	}
+	chunks := make([]index.ChunkMeta, 1)
 
 	for postings.Next() {
+		skip, err := deleted.seriesDeleted(reader, postings.At(), &chunks)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			continue
+		}
 		var j int
 		var v string
 		for i := range names {
@@ -51,14 +51,21 @@ func init() {
 type timeSeriesQueryResult struct {
 	series        []*typesv1.Series
 	exemplarCount int
+	sampling      *queryv1.SamplingStats
 }
 
 // executeTimeSeriesQuery is shared by both query types to avoid duplication.
-func executeTimeSeriesQuery(q *queryContext, groupBy []string, exemplarType typesv1.ExemplarType) (*timeSeriesQueryResult, error) {
+func executeTimeSeriesQuery(q *queryContext, query *queryv1.TimeSeriesQuery) (*timeSeriesQueryResult, error) {
+	groupBy := query.GroupBy
+	exemplarType := query.ExemplarType
 	includeExemplars, err := validateExemplarType(exemplarType)
 	if err != nil {
 		return nil, err
 	}
+	sampler, err := newProfileSampler(query.SamplingRatio)
+	if err != nil {
+		return nil, err
+	}
 
 	otelSpan := trace.SpanFromContext(q.ctx)
 	otelSpan.SetAttributes(
@@ -75,6 +82,9 @@ func executeTimeSeriesQuery(q *queryContext, groupBy []string, exemplarType type
 	if !q.includeStripped {
 		opts = append(opts, withExcludeSampled())
 	}
+	if sampler != nil {
+		opts = append(opts, withSampling(sampler))
+	}
 
 	entries, err := profileEntryIterator(q, opts...)
 	if err != nil {
@@ -90,7 +100,30 @@ func executeTimeSeriesQuery(q *queryContext, groupBy []string, exemplarType type
 	annotationKeysColumn, _ := schemav1.ResolveColumnByPath(q.ds.Profiles().Schema(), schemav1.AnnotationKeyColumnPath)
 	annotationValuesColumn, _ := schemav1.ResolveColumnByPath(q.ds.Profiles().Schema(), schemav1.AnnotationValueColumnPath)
 
-	rows := parquetquery.NewRepeatedRowIteratorBatchSize(q.ctx, entries, q.ds.Profiles().RowGroups(), bigBatchSize, column.ColumnIndex, annotationKeysColumn.ColumnIndex, annotationValuesColumn.ColumnIndex)
+	columns := []int{column.ColumnIndex, annotationKeysColumn.ColumnIndex, annotationValuesColumn.ColumnIndex}
+	// Span exemplars refer to the spans that contribute most to the
+	// profile value: the samples are read if the span column exists.
+	var (
+		samples    schemav1.SampleColumns
+		spanValues timeseries.SpanValues
+		maxSpans   = timeseries.ExemplarsPerPoint(query.MaxExemplarsPerPoint)
+		withSpans  = exemplarType == typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN
+		spanOffset = len(columns)
+	)
+	if withSpans {
+		if err = samples.Resolve(q.ds.Profiles().Schema()); err != nil {
+			return nil, err
+		}
+		withSpans = samples.HasSpanID()
+	}
+	if withSpans {
+		columns = append(columns, samples.SpanID.ColumnIndex, samples.Value.ColumnIndex)
+		if samples.HasTraceID() {
+			columns = append(columns, samples.TraceID.ColumnIndex)
+		}
+	}
+
+	rows := parquetquery.NewRepeatedRowIteratorBatchSize(q.ctx, entries, q.ds.Profiles().RowGroups(), bigBatchSize, columns...)
 	defer runutil.CloseWithErrCapture(&err, rows, "failed to close column iterator")
 
 	builder := timeseries.NewBuilder(groupBy...)
@@ -98,7 +131,7 @@ func executeTimeSeriesQuery(q *queryContext, groupBy []string, exemplarType type
 		row := rows.At()
 		annotations := schemav1.Annotations{Keys: make([]string, 0), Values: make([]string, 0)}
 		stripped := row.Row.Labels.Get(phlaremodel.LabelNameSampled) == "true"
-		for _, e := range row.Values {
+		for _, e := range row.Values[:spanOffset] {
 			if e[0].Column() == annotationKeysColumn.ColumnIndex && e[0].Kind() == parquet.ByteArray {
 				annotations.Keys = append(annotations.Keys, e[0].String())
 			}
@@ -107,17 +140,32 @@ func executeTimeSeriesQuery(q *queryContext, groupBy []string, exemplarType type
 			}
 		}
 		exemplarID := row.Row.ID
-		if stripped {
+		if stripped || withSpans {
 			exemplarID = ""
 		}
+		value := float64(row.Values[0][0].Int64())
+		if sampler != nil {
+			sampler.observe(value)
+		}
 		builder.Add(
 			row.Row.Fingerprint,
 			row.Row.Labels,
 			int64(row.Row.Timestamp),
-			float64(row.Values[0][0].Int64()),
+			value,
 			annotations,
 			exemplarID,
 		)
+		if withSpans && !stripped {
+			collectSpanValues(&spanValues, row.Values[spanOffset:])
+			builder.AddSpanExemplars(
+				row.Row.Fingerprint,
+				row.Row.Labels,
+				int64(row.Row.Timestamp),
+				row.Row.ID,
+				spanValues.Top(maxSpans),
+			)
+			spanValues.Reset()
+		}
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
@@ -129,12 +177,40 @@ func executeTimeSeriesQuery(q *queryContext, groupBy []string, exemplarType type
 	} else {
 		series = builder.Build()
 	}
+	if sampler != nil {
+		// The points are scaled once the series are built: exemplars
+		// keep the values of the individual profiles they refer to.
+		for _, s := range series {
+			for _, p := range s.Points {
+				p.Value = sampler.scale(p.Value)
+			}
+		}
+	}
+
+	return &timeSeriesQueryResult{
+		series:        series,
+		exemplarCount: builder.ExemplarCount(),
+		sampling:      sampler.stats(),
+	}, nil
+}
 
-	return &timeSeriesQueryResult{series: series, exemplarCount: builder.ExemplarCount()}, nil
+// collectSpanValues sums the sample values of a profile by span:
+// the values are read from the SpanID, Value, and the optional
+// TraceID columns, in this order.
+func collectSpanValues(spans *timeseries.SpanValues, values [][]parquet.Value) {
+	for i := range values[0] {
+		var traceID phlaremodel.TraceID
+		if len(values) > 2 && i < len(values[2]) {
+			if b := values[2][i].Bytes(); len(b) == len(traceID) {
+				copy(traceID[:], b)
+			}
+		}
+		spans.Add(values[0][i].Uint64(), traceID, values[1][i].Int64())
+	}
 }
 
 func queryTimeSeries(q *queryContext, query *queryv1.Query) (r *queryv1.Report, err error) {
-	result, err := executeTimeSeriesQuery(q, query.TimeSeries.GroupBy, query.TimeSeries.ExemplarType)
+	result, err := executeTimeSeriesQuery(q, query.TimeSeries)
 	if err != nil {
 		return nil, err
 	}
@@ -147,12 +223,13 @@ func queryTimeSeries(q *queryContext, query *queryv1.Query) (r *queryv1.Report,
 		TimeSeries: &queryv1.TimeSeriesReport{
 			Query:      query.TimeSeries.CloneVT(),
 			TimeSeries: result.series,
+			Sampling:   result.sampling,
 		},
 	}, nil
 }
 
 func queryTimeSeriesCompact(q *queryContext, query *queryv1.Query) (r *queryv1.Report, err error) {
-	result, err := executeTimeSeriesQuery(q, query.TimeSeriesCompact.GroupBy, query.TimeSeriesCompact.ExemplarType)
+	result, err := executeTimeSeriesQuery(q, query.TimeSeriesCompact)
 	if err != nil {
 		return nil, err
 	}
@@ -175,6 +252,7 @@ func queryTimeSeriesCompact(q *queryContext, query *queryv1.Query) (r *queryv1.R
 			Query:          query.TimeSeriesCompact.CloneVT(),
 			TimeSeries:     series,
 			AttributeTable: at.Build(nil),
+			Sampling:       result.sampling,
 		},
 	}, nil
 }
@@ -199,6 +277,7 @@ func convertPoints(points []*typesv1.Point, at *attributetable.Table) []*queryv1
 					Timestamp:     ex.Timestamp,
 					ProfileId:     ex.ProfileId,
 					SpanId:        ex.SpanId,
+					TraceId:       ex.TraceId,
 					Value:         ex.Value,
 					AttributeRefs: at.Refs(phlaremodel.Labels(ex.Labels), nil),
 				}
@@ -214,6 +293,7 @@ type timeSeriesAggregator struct {
 	endTime   int64
 	query     *queryv1.TimeSeriesQuery
 	series    *timeseries.Merger
+	sampling  samplingStatsMerger
 }
 
 func newTimeSeriesAggregator(req *queryv1.InvokeRequest) aggregator {
@@ -230,6 +310,7 @@ func (a *timeSeriesAggregator) aggregate(report *queryv1.Report) error {
 		a.query = r.Query.CloneVT()
 	})
 	a.series.MergeTimeSeries(r.TimeSeries)
+	a.sampling.merge(r.Sampling)
 	return nil
 }
 
@@ -240,11 +321,13 @@ func (a *timeSeriesAggregator) build() *queryv1.Report {
 	sum := typesv1.TimeSeriesAggregationType_TIME_SERIES_AGGREGATION_TYPE_SUM
 	stepMilli := time.Duration(a.query.GetStep() * float64(time.Second)).Milliseconds()
 	seriesIterator := timeseries.NewTimeSeriesMergeIterator(a.series.TimeSeries())
-	series := timeseries.RangeSeries(seriesIterator, a.startTime+stepMilli, a.endTime, stepMilli, &sum)
+	maxExemplars := timeseries.ExemplarsPerPoint(a.query.GetMaxExemplarsPerPoint())
+	series := timeseries.RangeSeriesWithExemplarLimit(seriesIterator, a.startTime+stepMilli, a.endTime, stepMilli, &sum, maxExemplars)
 	return &queryv1.Report{
 		TimeSeries: &queryv1.TimeSeriesReport{
 			Query:      a.query,
 			TimeSeries: series,
+			Sampling:   a.sampling.build(),
 		},
 	}
 }
@@ -255,6 +338,7 @@ type timeSeriesCompactAggregator struct {
 	endTime   int64
 	query     *queryv1.TimeSeriesQuery
 	merger    *timeseriescompact.Merger
+	sampling  samplingStatsMerger
 }
 
 func newTimeSeriesCompactAggregator(req *queryv1.InvokeRequest) aggregator {
@@ -271,18 +355,21 @@ func (a *timeSeriesCompactAggregator) aggregate(report *queryv1.Report) error {
 		a.query = r.Query.CloneVT()
 	})
 	a.merger.MergeReport(r)
+	a.sampling.merge(r.Sampling)
 	return nil
 }
 
 func (a *timeSeriesCompactAggregator) build() *queryv1.Report {
 	stepMilli := time.Duration(a.query.GetStep() * float64(time.Second)).Milliseconds()
 	seriesIterator := a.merger.Iterator()
-	series := timeseriescompact.RangeSeries(seriesIterator, a.startTime+stepMilli, a.endTime, stepMilli)
+	maxExemplars := timeseries.ExemplarsPerPoint(a.query.GetMaxExemplarsPerPoint())
+	series := timeseriescompact.RangeSeries(seriesIterator, a.startTime+stepMilli, a.endTime, stepMilli, maxExemplars)
 	return &queryv1.Report{
 		TimeSeriesCompact: &queryv1.TimeSeriesCompactReport{
 			Query:          a.query,
 			TimeSeries:     series,
 			AttributeTable: a.merger.BuildAttributeTable(),
+			Sampling:       a.sampling.build(),
 		},
 	}
 }
@@ -291,10 +378,8 @@ func validateExemplarType(exemplarType typesv1.ExemplarType) (bool, error) {
 	switch exemplarType {
 	case typesv1.ExemplarType_EXEMPLAR_TYPE_UNSPECIFIED, typesv1.ExemplarType_EXEMPLAR_TYPE_NONE:
 		return false, nil
-	case typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL:
+	case typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL, typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN:
 		return true, nil
-	case typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN:
-		return false, status.Error(codes.Unimplemented, "exemplar type span is not implemented")
 	default:
 		return false, status.Errorf(codes.InvalidArgument, "unknown exemplar type: %v", exemplarType)
 	}
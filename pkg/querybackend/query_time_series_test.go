@@ -50,11 +50,9 @@ func TestValidateExemplarType(t *testing.T) {
 			expectedInclude: true,
 		},
 		{
-			name:             "SPAN returns error with Unimplemented code",
-			exemplarType:     typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN,
-			expectedInclude:  false,
-			expectedErrorMsg: "exemplar type span is not implemented",
-			expectedCode:     codes.Unimplemented,
+			name:            "SPAN returns true, no error",
+			exemplarType:    typesv1.ExemplarType_EXEMPLAR_TYPE_SPAN,
+			expectedInclude: true,
 		},
 		{
 			name:             "Unknown type returns error with InvalidArgument code",
@@ -391,7 +389,7 @@ func setupBenchmarkFixture(b *testing.B) *benchmarkFixture {
 	}
 
 	logger := test.NewTestingLogger(b)
-	reader := NewBlockReader(logger, &objstore.ReaderAtBucket{Bucket: bucket}, nil, validation.MockDefaultOverrides())
+	reader := NewBlockReader(logger, &objstore.ReaderAtBucket{Bucket: bucket}, nil, nil, validation.MockDefaultOverrides())
 
 	meta := make([]*metastorev1.BlockMeta, len(blocks))
 	for i, block := range blocks {
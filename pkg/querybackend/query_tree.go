@@ -64,9 +64,17 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 		return nil, err
 	}
 
+	sampler, err := newProfileSampler(query.Tree.SamplingRatio)
+	if err != nil {
+		return nil, err
+	}
+
 	otelSpan := trace.SpanFromContext(q.ctx)
 
 	profileOpts := []profileIteratorOption{withExcludeSampled()}
+	if sampler != nil {
+		profileOpts = append(profileOpts, withSampling(sampler))
+	}
 	if len(query.Tree.ProfileIdSelector) > 0 {
 		opt, err := withProfileIDSelector(query.Tree.ProfileIdSelector...)
 		if err != nil {
@@ -145,6 +153,9 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 	case len(spanSelector) > 0:
 		for profiles.Next() {
 			p := profiles.At()
+			if sampler != nil {
+				sampler.scaleSamples(p.Values[1])
+			}
 			resolver.AddSamplesWithSpanSelectorFromParquetRow(
 				p.Row.Partition,
 				p.Values[0],
@@ -156,6 +167,9 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 	case len(traceSelector) > 0:
 		for profiles.Next() {
 			p := profiles.At()
+			if sampler != nil {
+				sampler.scaleSamples(p.Values[1])
+			}
 			resolver.AddSamplesWithTraceSelectorFromParquetRow(
 				p.Row.Partition,
 				p.Values[0],
@@ -167,6 +181,9 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 	default:
 		for profiles.Next() {
 			p := profiles.At()
+			if sampler != nil {
+				sampler.scaleSamples(p.Values[1])
+			}
 			resolver.AddSamplesFromParquetRow(p.Row.Partition, p.Values[0], p.Values[1])
 		}
 	}
@@ -174,6 +191,7 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 	if err = profiles.Err(); err != nil {
 		return nil, err
 	}
+	q.resourceCollector.stackTraces.Add(int64(resolver.StackTraces()))
 
 	// output full pprof tree if that's requested
 	if mode == queryv1.SymbolMode_SYMBOL_MODE_FULL {
@@ -183,9 +201,10 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 		}
 		resp := &queryv1.Report{
 			Tree: &queryv1.TreeReport{
-				Query:   query.Tree.CloneVT(),
-				Tree:    tree.Bytes(query.Tree.GetMaxNodes(), symbolBuilder.KeepSymbol),
-				Symbols: new(queryv1.TreeSymbols),
+				Query:    query.Tree.CloneVT(),
+				Tree:     tree.Bytes(query.Tree.GetMaxNodes(), symbolBuilder.KeepSymbol),
+				Symbols:  new(queryv1.TreeSymbols),
+				Sampling: sampler.stats(),
 			},
 		}
 		symbolBuilder.Build(resp.Tree.Symbols)
@@ -196,7 +215,12 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 	// for: keep the existing FunctionName path unconditionally, same as a
 	// non-symbol-ref query.
 	if mode == queryv1.SymbolMode_SYMBOL_MODE_REFS && datasetUnsymbolized(q.obj.Metadata(), q.ds.Metadata()) {
-		return queryTreeSymbolRefs(query, resolver)
+		resp, err := queryTreeSymbolRefs(query, resolver)
+		if err != nil {
+			return nil, err
+		}
+		resp.Tree.Sampling = sampler.stats()
+		return resp, nil
 	}
 
 	tree, err := resolver.Tree()
@@ -206,8 +230,9 @@ func queryTree(q *queryContext, query *queryv1.Query) (*queryv1.Report, error) {
 
 	resp := &queryv1.Report{
 		Tree: &queryv1.TreeReport{
-			Query: query.Tree.CloneVT(),
-			Tree:  tree.Bytes(query.Tree.GetMaxNodes(), nil),
+			Query:    query.Tree.CloneVT(),
+			Tree:     tree.Bytes(query.Tree.GetMaxNodes(), nil),
+			Sampling: sampler.stats(),
 		},
 	}
 	return resp, nil
@@ -288,6 +313,8 @@ type treeAggregator struct {
 
 	symbolRefTable *symbolref.Table
 	symbolRefTree  *model.TreeMerger[model.LocationRefName, model.LocationRefNameI]
+
+	sampling samplingStatsMerger
 }
 
 func newTreeAggregator(*queryv1.InvokeRequest) aggregator { return new(treeAggregator) }
@@ -298,6 +325,7 @@ func (a *treeAggregator) aggregate(report *queryv1.Report) error {
 	if err != nil {
 		return err
 	}
+	a.sampling.merge(r.Sampling)
 	switch mode {
 	case queryv1.SymbolMode_SYMBOL_MODE_REFS:
 		a.init.Do(func() {
@@ -371,7 +399,8 @@ func (a *treeAggregator) checkUnresolvedLimit() error {
 func (a *treeAggregator) build() *queryv1.Report {
 	result := &queryv1.Report{
 		Tree: &queryv1.TreeReport{
-			Query: a.query,
+			Query:    a.query,
+			Sampling: a.sampling.build(),
 		},
 	}
 
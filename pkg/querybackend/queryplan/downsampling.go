@@ -0,0 +1,107 @@
+package queryplan
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+)
+
+// MaxDownsamplingResolution returns the coarsest resolution of downsampled
+// data the queries can be served from. Zero means that raw data is required.
+//
+// Downsampled profiles have no profile IDs, spans, or traces, and their
+// stack traces may be truncated. Therefore, only trees without selectors
+// and time series without exemplars can be built from downsampled data;
+// the time series step must not be finer than the resolution.
+//
+// Downsampled profiles are timestamped with the start of the resolution
+// interval, so the query range boundaries are effectively aligned to the
+// interval. This is acceptable for the long range queries the downsampled
+// data is intended for; the raw data is used otherwise.
+func MaxDownsamplingResolution(queries ...*queryv1.Query) time.Duration {
+	if len(queries) == 0 {
+		return 0
+	}
+	resolution := time.Duration(math.MaxInt64)
+	for _, q := range queries {
+		switch q.QueryType {
+		case queryv1.QueryType_QUERY_TREE:
+			t := q.Tree
+			if t == nil ||
+				t.StackTraceSelector != nil ||
+				len(t.SpanSelector) > 0 ||
+				len(t.ProfileIdSelector) > 0 ||
+				len(t.TraceIdSelector) > 0 {
+				return 0
+			}
+		case queryv1.QueryType_QUERY_TIME_SERIES:
+			ts := q.TimeSeries
+			if ts == nil {
+				return 0
+			}
+			switch ts.ExemplarType {
+			case typesv1.ExemplarType_EXEMPLAR_TYPE_UNSPECIFIED,
+				typesv1.ExemplarType_EXEMPLAR_TYPE_NONE:
+			default:
+				return 0
+			}
+			resolution = min(resolution, time.Duration(ts.Step*float64(time.Second)))
+		default:
+			return 0
+		}
+	}
+	return max(resolution, 0)
+}
+
+// HasDownsampledDatasets reports whether any of the blocks
+// includes downsampled datasets.
+func HasDownsampledDatasets(blocks []*metastorev1.BlockMeta) bool {
+	for _, b := range blocks {
+		for _, ds := range b.Datasets {
+			if _, ok := metadata.DownsamplingResolution(b.StringTable, ds.Labels); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SelectDatasets replaces raw datasets with their downsampled counterparts,
+// if the resolution of the downsampled data does not exceed maxResolution.
+// Downsampled datasets that are not selected are removed: raw and downsampled
+// datasets must never be queried together. The metadata is modified in place.
+//
+// Note that the downsampling resolution must be present in the dataset labels
+// for the downsampled datasets to be identified.
+func SelectDatasets(blocks []*metastorev1.BlockMeta, maxResolution time.Duration) {
+	type datasetKey struct{ tenant, name int32 }
+	for _, b := range blocks {
+		var selected map[datasetKey]bool
+		for _, ds := range b.Datasets {
+			r, ok := metadata.DownsamplingResolution(b.StringTable, ds.Labels)
+			if !ok {
+				continue
+			}
+			if selected == nil {
+				selected = make(map[datasetKey]bool)
+			}
+			k := datasetKey{tenant: ds.Tenant, name: ds.Name}
+			selected[k] = r <= maxResolution
+		}
+		if selected == nil {
+			continue
+		}
+		b.Datasets = slices.DeleteFunc(b.Datasets, func(ds *metastorev1.Dataset) bool {
+			k := datasetKey{tenant: ds.Tenant, name: ds.Name}
+			if _, ok := metadata.DownsamplingResolution(b.StringTable, ds.Labels); ok {
+				return !selected[k]
+			}
+			return selected[k]
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package queryplan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
+)
+
+func Test_MaxDownsamplingResolution(t *testing.T) {
+	tree := &queryv1.Query{
+		QueryType: queryv1.QueryType_QUERY_TREE,
+		Tree:      &queryv1.TreeQuery{MaxNodes: 1024},
+	}
+	timeSeries := &queryv1.Query{
+		QueryType:  queryv1.QueryType_QUERY_TIME_SERIES,
+		TimeSeries: &queryv1.TimeSeriesQuery{Step: 3600},
+	}
+
+	assert.Equal(t, time.Duration(0), MaxDownsamplingResolution())
+	assert.Equal(t, time.Hour, MaxDownsamplingResolution(tree, timeSeries))
+	assert.Equal(t, time.Hour, MaxDownsamplingResolution(timeSeries))
+	assert.Greater(t, MaxDownsamplingResolution(tree), 24*time.Hour)
+
+	assert.Equal(t, time.Duration(0), MaxDownsamplingResolution(&queryv1.Query{
+		QueryType: queryv1.QueryType_QUERY_TREE,
+		Tree:      &queryv1.TreeQuery{SpanSelector: []string{"1234"}},
+	}))
+	assert.Equal(t, time.Duration(0), MaxDownsamplingResolution(&queryv1.Query{
+		QueryType: queryv1.QueryType_QUERY_TIME_SERIES,
+		TimeSeries: &queryv1.TimeSeriesQuery{
+			Step:         3600,
+			ExemplarType: typesv1.ExemplarType_EXEMPLAR_TYPE_INDIVIDUAL,
+		},
+	}))
+	assert.Equal(t, time.Duration(0), MaxDownsamplingResolution(tree, &queryv1.Query{
+		QueryType: queryv1.QueryType_QUERY_PPROF,
+		Pprof:     &queryv1.PprofQuery{},
+	}))
+}
+
+func Test_SelectDatasets(t *testing.T) {
+	newBlock := func() *metastorev1.BlockMeta {
+		strings := metadata.NewStringTable()
+		md := &metastorev1.BlockMeta{Tenant: strings.Put("tenant")}
+		for _, name := range []string{"service-a", "service-b"} {
+			md.Datasets = append(md.Datasets, &metastorev1.Dataset{
+				Tenant: md.Tenant,
+				Name:   strings.Put(name),
+				Labels: metadata.NewLabelBuilder(strings).
+					WithLabelSet("service_name", name).
+					Build(),
+			})
+		}
+		// Only service-a has been downsampled.
+		md.Datasets = append(md.Datasets, &metastorev1.Dataset{
+			Format: 2,
+			Tenant: md.Tenant,
+			Name:   strings.Put("service-a"),
+			Labels: metadata.NewLabelBuilder(strings).
+				WithLabelSet("service_name", "service-a", metadata.LabelNameDownsamplingResolution, "1h").
+				Build(),
+		})
+		md.StringTable = strings.Strings
+		return md
+	}
+
+	formats := func(md *metastorev1.BlockMeta) map[string]uint32 {
+		m := make(map[string]uint32)
+		for _, ds := range md.Datasets {
+			m[md.StringTable[ds.Name]] = ds.Format
+		}
+		return m
+	}
+
+	blocks := []*metastorev1.BlockMeta{newBlock()}
+	assert.True(t, HasDownsampledDatasets(blocks))
+	SelectDatasets(blocks, time.Hour)
+	assert.Len(t, blocks[0].Datasets, 2)
+	assert.Equal(t, map[string]uint32{"service-a": 2, "service-b": 0}, formats(blocks[0]))
+
+	blocks = []*metastorev1.BlockMeta{newBlock()}
+	SelectDatasets(blocks, time.Minute)
+	assert.Len(t, blocks[0].Datasets, 2)
+	assert.Equal(t, map[string]uint32{"service-a": 0, "service-b": 0}, formats(blocks[0]))
+	assert.False(t, HasDownsampledDatasets(blocks))
+}
@@ -1,24 +1,43 @@
 package queryplan
 
 import (
+	"slices"
+
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
 )
 
+// largeTreeSize is the total size of the datasets, in bytes, above which
+// the tree produced by a query is considered large.
+const largeTreeSize = 1 << 30
+
 // Build creates a query plan from the list of block metadata.
 //
-// NOTE(kolesnikovae): At this point it only groups blocks into uniform ranges,
-// and builds a DAG of reads and merges. In practice, however, we may want to
-// implement more sophisticated strategies. For example, it would be beneficial
-// to group blocks based on the tenant services to ensure that a single read
-// covers exactly one service, and does not have to deal with stack trace
-// cardinality issues. Another example is grouping by shards to minimize the
-// number of unique series (assuming the shards are still built based on the
-// series labels) a reader or merger should handle. In general, the strategy
-// should depend on the query type.
+// The plan is a DAG of reads and merges: blocks are grouped into read nodes
+// of at most maxReads blocks, and the read nodes are merged by nodes with at
+// most maxMerges children until a single root node remains.
+//
+// The way blocks are grouped depends on the queries the plan is built for:
+//
+//   - Tree and pprof queries: blocks are split by datasets, so that a read
+//     covers a single dataset (service) wherever possible, and does not have
+//     to deal with the stack trace cardinality of multiple services. Reads of
+//     the same dataset are placed next to each other, therefore they are
+//     merged first. Large trees are merged on fewer nodes with twice as many
+//     children, to reduce the number of times they are re-merged.
+//   - Time series and heatmap queries: blocks are split by datasets and
+//     shards. Assuming that the shards are built based on the series labels,
+//     this bounds the number of unique series a reader has to handle: a read
+//     covers at most maxReads distinct dataset shards, and typically just one.
+//   - Other queries (label names and values, series labels) only access the
+//     dataset indices: blocks are spread uniformly, in the order given.
+//
+// Blocks that only contain datasets of a single group are not split. Splits
+// share the string table with the source block.
 func Build(
 	blocks []*metastorev1.BlockMeta,
 	maxReads, maxMerges int,
+	queries ...*queryv1.Query,
 ) *queryv1.QueryPlan {
 	if len(blocks) == 0 {
 		return new(queryv1.QueryPlan)
@@ -32,16 +51,17 @@ func Build(
 		return new(queryv1.QueryPlan)
 	}
 
-	// create leaf nodes and spread the blocks in a uniform way
-	leafNodeCount := (len(blocks) + maxReads - 1) / maxReads
-	nodes := allocateContiguous[queryv1.QueryNode](leafNodeCount)
-	for start, idx := 0, 0; start < len(blocks); start, idx = start+maxReads, idx+1 {
-		end := start + maxReads
-		if end > len(blocks) {
-			end = len(blocks)
-		}
+	s := newPlanStrategy(blocks, queries)
+	if s.largeTree {
+		maxMerges *= 2
+	}
+
+	// create leaf nodes from the block groups
+	reads := packReads(s.groups(blocks), maxReads)
+	nodes := allocateContiguous[queryv1.QueryNode](len(reads))
+	for idx, read := range reads {
 		nodes[idx].Type = queryv1.QueryNode_READ
-		nodes[idx].Blocks = blocks[start:end]
+		nodes[idx].Blocks = read
 	}
 
 	// create merge nodes until we reach a single root node
@@ -66,6 +86,163 @@ func Build(
 	}
 }
 
+// CountBlocks returns the number of blocks read by the plan node.
+func CountBlocks(node *queryv1.QueryNode) int64 {
+	if node == nil {
+		return 0
+	}
+	n := int64(len(node.Blocks))
+	for _, c := range node.Children {
+		n += CountBlocks(c)
+	}
+	return n
+}
+
+type planStrategy struct {
+	// splitDatasets groups blocks by datasets.
+	splitDatasets bool
+	// splitShards additionally groups blocks by shards.
+	splitShards bool
+	largeTree   bool
+}
+
+func newPlanStrategy(blocks []*metastorev1.BlockMeta, queries []*queryv1.Query) planStrategy {
+	var s planStrategy
+	var tree bool
+	for _, q := range queries {
+		switch q.QueryType {
+		case queryv1.QueryType_QUERY_TREE,
+			queryv1.QueryType_QUERY_PPROF:
+			s.splitDatasets = true
+			tree = true
+		case queryv1.QueryType_QUERY_TIME_SERIES,
+			queryv1.QueryType_QUERY_TIME_SERIES_COMPACT,
+			queryv1.QueryType_QUERY_HEATMAP:
+			s.splitDatasets = true
+			s.splitShards = true
+		}
+	}
+	if tree {
+		var size uint64
+		for _, b := range blocks {
+			for _, ds := range b.Datasets {
+				size += ds.Size
+			}
+		}
+		s.largeTree = size >= largeTreeSize
+	}
+	return s
+}
+
+type groupKey struct {
+	tenant  string
+	dataset string
+	shard   uint32
+}
+
+// groups returns blocks grouped by the strategy key, in the order of the
+// first appearance of the group. The order of blocks within a group is
+// preserved.
+func (s planStrategy) groups(blocks []*metastorev1.BlockMeta) [][]*metastorev1.BlockMeta {
+	if !s.splitDatasets {
+		return [][]*metastorev1.BlockMeta{blocks}
+	}
+	var groups [][]*metastorev1.BlockMeta
+	index := make(map[groupKey]int)
+	add := func(k groupKey, b *metastorev1.BlockMeta) {
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], b)
+	}
+	var keys []groupKey
+	var datasets [][]*metastorev1.Dataset
+	for _, b := range blocks {
+		keys, datasets = keys[:0], datasets[:0]
+		for _, ds := range b.Datasets {
+			k := s.key(b, ds)
+			i := slices.Index(keys, k)
+			if i < 0 {
+				i = len(keys)
+				keys = append(keys, k)
+				datasets = append(datasets, nil)
+			}
+			datasets[i] = append(datasets[i], ds)
+		}
+		switch len(keys) {
+		case 0:
+			add(groupKey{shard: s.shard(b)}, b)
+		case 1:
+			add(keys[0], b)
+		default:
+			for i, k := range keys {
+				add(k, splitBlock(b, datasets[i]))
+			}
+		}
+	}
+	return groups
+}
+
+func (s planStrategy) key(b *metastorev1.BlockMeta, ds *metastorev1.Dataset) groupKey {
+	return groupKey{
+		tenant:  lookupString(b.StringTable, ds.Tenant),
+		dataset: lookupString(b.StringTable, ds.Name),
+		shard:   s.shard(b),
+	}
+}
+
+func (s planStrategy) shard(b *metastorev1.BlockMeta) uint32 {
+	if s.splitShards {
+		return b.Shard
+	}
+	return 0
+}
+
+func lookupString(strings []string, i int32) string {
+	if i < 0 || int(i) >= len(strings) {
+		return ""
+	}
+	return strings[i]
+}
+
+// splitBlock returns a copy of the block metadata that only
+// includes the given datasets.
+func splitBlock(b *metastorev1.BlockMeta, datasets []*metastorev1.Dataset) *metastorev1.BlockMeta {
+	c := b.CloneVT()
+	c.Datasets = datasets
+	return c
+}
+
+// packReads spreads the groups into reads of at most maxReads blocks. Each
+// group is split into full reads first; the remainders of the groups are
+// then packed together, so that small groups do not produce small reads.
+func packReads(groups [][]*metastorev1.BlockMeta, maxReads int) [][]*metastorev1.BlockMeta {
+	var reads [][]*metastorev1.BlockMeta
+	var rest []*metastorev1.BlockMeta
+	if len(groups) == 1 {
+		rest = groups[0]
+	} else {
+		for _, g := range groups {
+			full := len(g) - len(g)%maxReads
+			for start := 0; start < full; start += maxReads {
+				reads = append(reads, g[start:start+maxReads])
+			}
+			rest = append(rest, g[full:]...)
+		}
+	}
+	for start := 0; start < len(rest); start += maxReads {
+		end := start + maxReads
+		if end > len(rest) {
+			end = len(rest)
+		}
+		reads = append(reads, rest[start:end])
+	}
+	return reads
+}
+
 // allocateContiguous returns a []*T of length size where every element points
 // into a single backing []T allocation. This avoids the per-element heap
 // allocations from N separate &T{} expressions.
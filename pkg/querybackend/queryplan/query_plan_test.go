@@ -5,17 +5,21 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
 	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
 )
 
 var update = flag.Bool("update", false, "rewrite golden files in testdata/ from the current plan output")
@@ -72,6 +76,264 @@ func Test_Build(t *testing.T) {
 	}
 }
 
+func Test_Build_QueryTypes(t *testing.T) {
+	small := blockFixture{services: 3, shards: 2, segments: 6, datasetSize: 4 << 20}
+	large := blockFixture{services: 3, shards: 2, segments: 6, datasetSize: 256 << 20}
+
+	tests := []struct {
+		name      string
+		fixture   blockFixture
+		queryType queryv1.QueryType
+		maxReads  int
+		maxMerges int
+	}{
+		{name: "tree_by_dataset", fixture: small, queryType: queryv1.QueryType_QUERY_TREE, maxReads: 2, maxMerges: 3},
+		{name: "pprof_by_dataset", fixture: small, queryType: queryv1.QueryType_QUERY_PPROF, maxReads: 2, maxMerges: 3},
+		{name: "large_tree", fixture: large, queryType: queryv1.QueryType_QUERY_TREE, maxReads: 2, maxMerges: 3},
+		{name: "time_series_by_shard", fixture: small, queryType: queryv1.QueryType_QUERY_TIME_SERIES, maxReads: 2, maxMerges: 3},
+		{name: "label_names_uniform", fixture: small, queryType: queryv1.QueryType_QUERY_LABEL_NAMES, maxReads: 2, maxMerges: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := tt.fixture.blocks(rand.New(rand.NewSource(1)))
+			query := &queryv1.Query{QueryType: tt.queryType}
+			p := Build(blocks, tt.maxReads, tt.maxMerges, query)
+
+			var buf bytes.Buffer
+			writePlan(t, &buf, "", p.Root)
+			assert.Equal(t, Build(blocks, tt.maxReads, tt.maxMerges, query), p)
+
+			if *update {
+				require.NoError(t, os.WriteFile(goldenFile(t), buf.Bytes(), 0o644))
+				return
+			}
+
+			expected, err := os.ReadFile(goldenFile(t))
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func Test_CountBlocks(t *testing.T) {
+	blocks := make([]*metastorev1.BlockMeta, 25)
+	for i := range blocks {
+		blocks[i] = &metastorev1.BlockMeta{Id: strconv.Itoa(i)}
+	}
+	p := Build(blocks, 2, 3)
+	assert.Equal(t, queryv1.QueryNode_MERGE, p.Root.Type)
+	assert.Equal(t, int64(25), CountBlocks(p.Root))
+	assert.Zero(t, CountBlocks(nil))
+}
+
+// Test_Build_Datasets verifies that every dataset of the source blocks is
+// read exactly once, and that reads respect the query type grouping.
+func Test_Build_Datasets(t *testing.T) {
+	f := blockFixture{services: 50, shards: 16, segments: 500, compacted: 2, datasetSize: 4 << 20}
+	blocks := f.blocks(rand.New(rand.NewSource(1)))
+
+	type datasetRef struct{ block, dataset string }
+	expected := make(map[datasetRef]int)
+	for _, b := range blocks {
+		for _, ds := range b.Datasets {
+			expected[datasetRef{b.Id, b.StringTable[ds.Name]}]++
+		}
+	}
+
+	for _, queryType := range []queryv1.QueryType{
+		queryv1.QueryType_QUERY_TREE,
+		queryv1.QueryType_QUERY_TIME_SERIES,
+		queryv1.QueryType_QUERY_LABEL_VALUES,
+	} {
+		t.Run(queryType.String(), func(t *testing.T) {
+			const maxReads = 4
+			p := Build(blocks, maxReads, 20, &queryv1.Query{QueryType: queryType})
+
+			actual := make(map[datasetRef]int)
+			var reads, singleDatasetReads int
+			forEachRead(p.Root, func(n *queryv1.QueryNode) {
+				require.LessOrEqual(t, len(n.Blocks), maxReads)
+				datasets := make(map[string]struct{})
+				for _, b := range n.Blocks {
+					for _, ds := range b.Datasets {
+						name := b.StringTable[ds.Name]
+						actual[datasetRef{b.Id, name}]++
+						if queryType == queryv1.QueryType_QUERY_TIME_SERIES {
+							name = fmt.Sprintf("%s/%d", name, b.Shard)
+						}
+						datasets[name] = struct{}{}
+					}
+				}
+				reads++
+				if len(datasets) == 1 {
+					singleDatasetReads++
+				}
+			})
+
+			assert.Equal(t, expected, actual)
+			if queryType != queryv1.QueryType_QUERY_LABEL_VALUES {
+				// Only the remainders of the groups are packed together.
+				assert.Greater(t, float64(singleDatasetReads)/float64(reads), 0.5)
+			}
+		})
+	}
+}
+
+func Test_splitBlock(t *testing.T) {
+	b := &metastorev1.BlockMeta{
+		FormatVersion:   1,
+		Id:              "block",
+		Tenant:          1,
+		Shard:           2,
+		CompactionLevel: 3,
+		MinTime:         4,
+		MaxTime:         5,
+		CreatedBy:       6,
+		MetadataOffset:  7,
+		Size:            8,
+		Datasets:        []*metastorev1.Dataset{{Name: 1}, {Name: 2}},
+		StringTable:     []string{"", "a", "b"},
+	}
+	datasets := b.Datasets[1:]
+	split := splitBlock(b, datasets)
+	assert.Equal(t, datasets, split.Datasets)
+	assert.Len(t, b.Datasets, 2)
+
+	// All the other fields are preserved.
+	split.Datasets = b.Datasets
+	assert.True(t, split.EqualVT(b))
+}
+
+func Benchmark_Build(b *testing.B) {
+	for _, bb := range []struct {
+		name    string
+		fixture blockFixture
+	}{
+		{name: "segments", fixture: blockFixture{services: 50, shards: 16, segments: 2000, datasetSize: 1 << 20}},
+		{name: "compacted", fixture: blockFixture{services: 500, shards: 64, compacted: 4, datasetSize: 64 << 20}},
+		{name: "mixed", fixture: blockFixture{services: 200, shards: 32, segments: 1000, compacted: 2, datasetSize: 8 << 20}},
+	} {
+		blocks := bb.fixture.blocks(rand.New(rand.NewSource(1)))
+		for _, queryType := range []queryv1.QueryType{
+			queryv1.QueryType_QUERY_TREE,
+			queryv1.QueryType_QUERY_TIME_SERIES,
+			queryv1.QueryType_QUERY_LABEL_NAMES,
+		} {
+			query := &queryv1.Query{QueryType: queryType}
+			b.Run(bb.name+"/"+queryType.String(), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					Build(blocks, 4, 20, query)
+				}
+			})
+		}
+	}
+}
+
+// blockFixture describes the metadata of blocks of a single tenant, as
+// returned by the metastore for a query. Each service is placed on two
+// adjacent shards. Segments (L0 blocks) include datasets of some of the
+// services placed on the segment shard; compacted blocks include datasets
+// of all of them.
+type blockFixture struct {
+	services    int
+	shards      int
+	segments    int
+	compacted   int // Per shard.
+	datasetSize uint64
+}
+
+func (f blockFixture) blocks(r *rand.Rand) []*metastorev1.BlockMeta {
+	const segmentDuration = 10 * time.Second
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	services := make([][]string, f.shards)
+	for i := 0; i < f.services; i++ {
+		name := fmt.Sprintf("service-%03d", i)
+		services[i%f.shards] = append(services[i%f.shards], name)
+		services[(i+1)%f.shards] = append(services[(i+1)%f.shards], name)
+	}
+
+	var blocks []*metastorev1.BlockMeta
+	for i := 0; i < f.segments; i++ {
+		shard := i % f.shards
+		minTime := start.Add(time.Duration(i/f.shards) * segmentDuration)
+		b := f.block(r, uint32(shard), 0, minTime, minTime.Add(segmentDuration), services[shard], 0.8)
+		if len(b.Datasets) > 0 {
+			blocks = append(blocks, b)
+		}
+	}
+	for i := 0; i < f.compacted; i++ {
+		for shard := 0; shard < f.shards; shard++ {
+			minTime := start.Add(time.Duration(i) * time.Hour)
+			blocks = append(blocks, f.block(r, uint32(shard), 1, minTime, minTime.Add(time.Hour), services[shard], 1))
+		}
+	}
+	r.Shuffle(len(blocks), func(i, j int) {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	})
+	return blocks
+}
+
+func (f blockFixture) block(
+	r *rand.Rand,
+	shard, level uint32,
+	minTime, maxTime time.Time,
+	services []string,
+	p float64,
+) *metastorev1.BlockMeta {
+	table := metadata.NewStringTable()
+	tenant := table.Put("tenant-a")
+	b := &metastorev1.BlockMeta{
+		FormatVersion:   1,
+		Id:              ulid.MustNew(ulid.Timestamp(maxTime), r).String(),
+		Tenant:          tenant,
+		Shard:           shard,
+		CompactionLevel: level,
+		MinTime:         minTime.UnixMilli(),
+		MaxTime:         maxTime.UnixMilli(),
+		CreatedBy:       table.Put("segment-writer-0"),
+	}
+	var offset uint64
+	for _, service := range services {
+		if r.Float64() >= p {
+			continue
+		}
+		size := f.datasetSize/2 + uint64(r.Int63n(int64(f.datasetSize)))
+		b.Datasets = append(b.Datasets, &metastorev1.Dataset{
+			Tenant:          tenant,
+			Name:            table.Put(service),
+			MinTime:         b.MinTime,
+			MaxTime:         b.MaxTime,
+			TableOfContents: []uint64{offset, offset + size/2, offset + size/2 + size/8},
+			Size:            size,
+			Labels: metadata.NewLabelBuilder(table).
+				WithLabelSet("service_name", service, "__profile_type__", "process_cpu:cpu:nanoseconds:cpu:nanoseconds").
+				WithLabelSet("service_name", service, "__profile_type__", "memory:inuse_space:bytes:space:bytes").
+				Build(),
+		})
+		offset += size
+	}
+	b.Size = offset
+	b.MetadataOffset = offset
+	b.StringTable = table.Strings
+	return b
+}
+
+// forEachRead calls fn for each read node of the plan rooted at n.
+func forEachRead(n *queryv1.QueryNode, fn func(*queryv1.QueryNode)) {
+	if n == nil {
+		return
+	}
+	if n.Type == queryv1.QueryNode_READ {
+		fn(n)
+		return
+	}
+	for _, child := range n.Children {
+		forEachRead(child, fn)
+	}
+}
+
 // makeBlocks creates n BlockMeta with sequential string IDs starting at "1".
 func makeBlocks(n int) []*metastorev1.BlockMeta {
 	blocks := make([]*metastorev1.BlockMeta, n)
@@ -106,7 +368,15 @@ func writePlan(t *testing.T, w io.Writer, pad string, n *queryv1.QueryNode) {
 		}
 	case queryv1.QueryNode_READ:
 		for _, md := range n.Blocks {
-			fmt.Fprintf(w, pad+"\t"+"id:\"%s\"\n", md.Id)
+			if len(md.Datasets) == 0 {
+				fmt.Fprintf(w, pad+"\t"+"id:\"%s\"\n", md.Id)
+				continue
+			}
+			names := make([]string, len(md.Datasets))
+			for i, ds := range md.Datasets {
+				names[i] = md.StringTable[ds.Name]
+			}
+			fmt.Fprintf(w, pad+"\t"+"id:\"%s\" shard:%d datasets:%q\n", md.Id, md.Shard, names)
 		}
 	default:
 		t.Fatalf("unknown node type: %v", n.Type)
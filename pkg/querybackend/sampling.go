@@ -0,0 +1,184 @@
+package querybackend
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/parquet-go/parquet-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+)
+
+// Approximate queries read a sample of the profiles matching the query, and
+// scale the values back to estimate the totals. A profile is sampled if the
+// hash of its series fingerprint and timestamp falls below the threshold:
+// the sample is deterministic, therefore repeated queries, and queries
+// reading the same profiles from different blocks, select the same profiles.
+//
+// Assuming each profile is sampled independently with probability r, the
+// estimate of the total value T = Σ x / r (over the sampled profiles x) is
+// unbiased, and its variance is estimated as (1-r)/r² Σ x². The relative
+// standard error is therefore sqrt((1-r) Σ x²) / Σ x. The sums are carried
+// in the reports, so that the error can be estimated for the merged result.
+
+type profileSampler struct {
+	ratio     float64
+	threshold uint64
+
+	total   atomic.Int64
+	sampled atomic.Int64
+
+	// Accessed by the goroutine reading the values only.
+	valueSum        float64
+	valueSumSquares float64
+}
+
+// newProfileSampler returns nil, if all the profiles are to be read.
+func newProfileSampler(ratio float64) (*profileSampler, error) {
+	if math.IsNaN(ratio) || ratio < 0 || ratio > 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "sampling ratio must be in the range (0, 1]: %v", ratio)
+	}
+	if ratio == 0 || ratio == 1 {
+		return nil, nil
+	}
+	return &profileSampler{
+		ratio:     ratio,
+		threshold: uint64(ratio * math.MaxUint64),
+	}, nil
+}
+
+func withSampling(s *profileSampler) profileIteratorOption {
+	return profileIteratorOption{
+		iterator: func(opts *iteratorOpts) {
+			opts.sampler = s
+		},
+	}
+}
+
+func (s *profileSampler) sample(e ProfileEntry) bool {
+	s.total.Add(1)
+	if sampleHash(uint64(e.Fingerprint), uint64(e.Timestamp)) >= s.threshold {
+		return false
+	}
+	s.sampled.Add(1)
+	return true
+}
+
+// sampleHash mixes the fingerprint and the timestamp (splitmix64 finalizer):
+// fingerprints of the same series at different timestamps, and timestamps
+// of different series, must produce uncorrelated hashes.
+func sampleHash(fp, ts uint64) uint64 {
+	h := fp ^ (ts * 0x9e3779b97f4a7c15)
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// observe records the total value of a sampled profile.
+func (s *profileSampler) observe(v float64) {
+	s.valueSum += v
+	s.valueSumSquares += v * v
+}
+
+// scaleSamples scales the sample values of the profile in place.
+func (s *profileSampler) scaleSamples(values []parquet.Value) {
+	var total float64
+	for i, v := range values {
+		x := float64(v.Int64())
+		total += x
+		values[i] = parquet.Int64Value(int64(math.Round(x/s.ratio))).
+			Level(v.RepetitionLevel(), v.DefinitionLevel(), v.Column())
+	}
+	s.observe(total)
+}
+
+func (s *profileSampler) scale(v float64) float64 { return v / s.ratio }
+
+func (s *profileSampler) stats() *queryv1.SamplingStats {
+	if s == nil {
+		return nil
+	}
+	return &queryv1.SamplingStats{
+		ProfilesTotal:   s.total.Load(),
+		ProfilesSampled: s.sampled.Load(),
+		ValueSum:        s.valueSum,
+		ValueSumSquares: s.valueSumSquares,
+	}
+}
+
+// sampledProfilesFilter skips profiles not included in the sample.
+type sampledProfilesFilter struct {
+	iter.Iterator[ProfileEntry]
+	sampler *profileSampler
+}
+
+func (f *sampledProfilesFilter) Next() bool {
+	for f.Iterator.Next() {
+		if f.sampler.sample(f.At()) {
+			return true
+		}
+	}
+	return false
+}
+
+// samplingStatsMerger sums the sampling stats of the reports.
+type samplingStatsMerger struct {
+	mu    sync.Mutex
+	stats *queryv1.SamplingStats
+}
+
+func (m *samplingStatsMerger) merge(s *queryv1.SamplingStats) {
+	if s == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = MergeSamplingStats(m.stats, s)
+}
+
+func (m *samplingStatsMerger) build() *queryv1.SamplingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// MergeSamplingStats adds the stats of b to a, which is allocated if nil.
+// Reports of exact queries carry no stats: nil is returned if both are nil.
+func MergeSamplingStats(a, b *queryv1.SamplingStats) *queryv1.SamplingStats {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		a = new(queryv1.SamplingStats)
+	}
+	a.ProfilesTotal += b.ProfilesTotal
+	a.ProfilesSampled += b.ProfilesSampled
+	a.ValueSum += b.ValueSum
+	a.ValueSumSquares += b.ValueSumSquares
+	return a
+}
+
+// SampleRate returns the fraction of the matching profiles read.
+func SampleRate(s *queryv1.SamplingStats) float64 {
+	if s.GetProfilesTotal() == 0 {
+		return 1
+	}
+	return float64(s.ProfilesSampled) / float64(s.ProfilesTotal)
+}
+
+// RelativeError returns the estimated relative standard
+// error of the total value of an approximate query.
+func RelativeError(s *queryv1.SamplingStats) float64 {
+	if s.GetValueSum() == 0 {
+		return 0
+	}
+	r := SampleRate(s)
+	return math.Sqrt((1-r)*s.ValueSumSquares) / s.ValueSum
+}
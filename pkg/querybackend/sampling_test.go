@@ -0,0 +1,101 @@
+package querybackend
+
+import (
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+)
+
+func Test_newProfileSampler(t *testing.T) {
+	for _, ratio := range []float64{0, 1} {
+		s, err := newProfileSampler(ratio)
+		require.NoError(t, err)
+		assert.Nil(t, s)
+	}
+	for _, ratio := range []float64{-0.1, 1.1} {
+		_, err := newProfileSampler(ratio)
+		require.Error(t, err)
+	}
+}
+
+func Test_sampledProfilesFilter(t *testing.T) {
+	entries := make([]ProfileEntry, 0, 10000)
+	for fp := 0; fp < 100; fp++ {
+		for ts := 0; ts < 100; ts++ {
+			entries = append(entries, ProfileEntry{
+				Fingerprint: model.Fingerprint(fp),
+				Timestamp:   model.Time(ts * 15000),
+			})
+		}
+	}
+
+	sample := func(ratio float64) ([]ProfileEntry, *profileSampler) {
+		s, err := newProfileSampler(ratio)
+		require.NoError(t, err)
+		sampled, err := iter.Slice[ProfileEntry](&sampledProfilesFilter{
+			Iterator: iter.NewSliceIterator(entries),
+			sampler:  s,
+		})
+		require.NoError(t, err)
+		return sampled, s
+	}
+
+	sampled, s := sample(0.1)
+	assert.InDelta(t, 1000, len(sampled), 100)
+	stats := s.stats()
+	assert.Equal(t, int64(len(entries)), stats.ProfilesTotal)
+	assert.Equal(t, int64(len(sampled)), stats.ProfilesSampled)
+
+	// The sample is deterministic, and a larger
+	// sample includes all the profiles of a smaller one.
+	again, _ := sample(0.1)
+	assert.Equal(t, sampled, again)
+	larger, _ := sample(0.2)
+	assert.Subset(t, larger, sampled)
+}
+
+func Test_profileSampler_scaleSamples(t *testing.T) {
+	s, err := newProfileSampler(0.25)
+	require.NoError(t, err)
+	values := []parquet.Value{
+		parquet.Int64Value(1).Level(0, 1, 3),
+		parquet.Int64Value(10).Level(1, 1, 3),
+	}
+	s.scaleSamples(values)
+	assert.Equal(t, int64(4), values[0].Int64())
+	assert.Equal(t, int64(40), values[1].Int64())
+	assert.Equal(t, 1, values[1].RepetitionLevel())
+	assert.Equal(t, 3, values[1].Column())
+
+	stats := s.stats()
+	assert.Equal(t, 11.0, stats.ValueSum)
+	assert.Equal(t, 121.0, stats.ValueSumSquares)
+}
+
+func Test_RelativeError(t *testing.T) {
+	assert.Zero(t, RelativeError(nil))
+	assert.Equal(t, 1.0, SampleRate(nil))
+
+	// Four profiles of equal value, out of 16: the relative standard
+	// error of the estimated total is sqrt((1 - 1/4) * 4) / 4.
+	s := &queryv1.SamplingStats{
+		ProfilesTotal:   16,
+		ProfilesSampled: 4,
+		ValueSum:        4,
+		ValueSumSquares: 4,
+	}
+	assert.Equal(t, 0.25, SampleRate(s))
+	assert.InDelta(t, 0.433, RelativeError(s), 0.001)
+
+	merged := MergeSamplingStats(nil, s)
+	merged = MergeSamplingStats(merged, s)
+	assert.Equal(t, int64(32), merged.ProfilesTotal)
+	assert.Equal(t, 4.0, s.ValueSum)
+	assert.Nil(t, MergeSamplingStats(nil, nil))
+}
@@ -0,0 +1,114 @@
+package querybackend
+
+import (
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/pyroscope/v2/pkg/block"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
+	"github.com/grafana/pyroscope/v2/pkg/phlaredb"
+	"github.com/grafana/pyroscope/v2/pkg/phlaredb/tsdb/index"
+)
+
+// deletedSeries lists the dataset series matching the series tombstones:
+// the data remains in the block until it is rewritten by compaction, and
+// must be filtered out at query time.
+type deletedSeries struct {
+	tombstones map[uint32][]*block.SeriesTombstone
+	// Query time range, Unix nano.
+	startTime int64
+	endTime   int64
+}
+
+// getDeletedSeries returns nil, if no series of the dataset were deleted
+// within the query time range.
+func getDeletedSeries(q *queryContext) (*deletedSeries, error) {
+	if len(q.req.tombstones) == 0 {
+		return nil, nil
+	}
+	md := q.ds.Metadata()
+	if block.DatasetFormat(md.Format) == block.DatasetFormat1 {
+		// The dataset index references datasets rather than series,
+		// therefore tombstones can't be applied to it.
+		return nil, nil
+	}
+	d := &deletedSeries{
+		startTime: max(q.req.startTime, model.Time(md.MinTime).UnixNano()),
+		endTime:   min(q.req.endTime, model.Time(md.MaxTime+1).UnixNano()-1),
+	}
+	for i := range q.req.tombstones {
+		t := &q.req.tombstones[i]
+		if t.Tenant != q.ds.TenantID() || !t.Overlaps(d.startTime, d.endTime) {
+			continue
+		}
+		ids, err := getSeriesIDs(q.ds.Index(), t.Matchers...)
+		if err != nil {
+			return nil, err
+		}
+		if d.tombstones == nil {
+			d.tombstones = make(map[uint32][]*block.SeriesTombstone, len(ids))
+		}
+		for id := range ids {
+			d.tombstones[id] = append(d.tombstones[id], t)
+		}
+	}
+	if len(d.tombstones) == 0 {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// covers reports whether the series data is deleted
+// within the time range (Unix nano, inclusive).
+func (d *deletedSeries) covers(series uint32, start, end int64) bool {
+	if d == nil {
+		return false
+	}
+	start, end = max(start, d.startTime), min(end, d.endTime)
+	for _, t := range d.tombstones[series] {
+		if t.Covers(start) && t.Covers(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleted reports whether the series has been deleted
+// entirely within the query time range.
+func (d *deletedSeries) deleted(series uint32) bool {
+	if d == nil {
+		return false
+	}
+	return d.covers(series, d.startTime, d.endTime)
+}
+
+// seriesDeleted reports whether the series referenced by the postings
+// entry has no data left within the query time range.
+func (d *deletedSeries) seriesDeleted(reader phlaredb.IndexReader, ref storage.SeriesRef, chunks *[]index.ChunkMeta) (bool, error) {
+	if d == nil {
+		return false, nil
+	}
+	if _, err := reader.Series(ref, nil, chunks); err != nil {
+		return false, err
+	}
+	c := (*chunks)[0]
+	return d.covers(c.SeriesIndex, c.MinTime, c.MaxTime), nil
+}
+
+// deletedProfilesFilter skips profiles of the series deleted
+// partially within the query time range.
+type deletedProfilesFilter struct {
+	iter.Iterator[ProfileEntry]
+	deleted *deletedSeries
+}
+
+func (f *deletedProfilesFilter) Next() bool {
+	for f.Iterator.Next() {
+		e := f.At()
+		ts := e.Timestamp.UnixNano()
+		if !f.deleted.covers(e.seriesIndex, ts, ts) {
+			return true
+		}
+	}
+	return false
+}
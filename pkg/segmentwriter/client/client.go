@@ -22,6 +22,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	segmentwriterv1 "github.com/grafana/pyroscope/api/gen/proto/go/segmentwriter/v1"
+	"github.com/grafana/pyroscope/v2/pkg/iter"
 	"github.com/grafana/pyroscope/v2/pkg/segmentwriter/client/connpool"
 	"github.com/grafana/pyroscope/v2/pkg/segmentwriter/client/distributor"
 	"github.com/grafana/pyroscope/v2/pkg/segmentwriter/client/distributor/placement"
@@ -42,6 +43,12 @@ const (
 	cbOpenTimeout    = time.Second
 
 	poolCleanupPeriod = 15 * time.Second
+
+	// All the replicas of a replicated push are assigned to the same
+	// replication window: segment writers keep the profiles of each
+	// window in separate segments, and the compactor merges the
+	// segments of all the replicas of the window in a single job.
+	replicationWindow = 5 * time.Second
 )
 
 // Only these errors are considered as a signal to retry the request
@@ -147,6 +154,8 @@ type Client struct {
 	pool        *connpool.Pool
 	distributor *distributor.Distributor
 
+	replicationFactor int
+
 	service     services.Service
 	subservices *services.Manager
 	watcher     *services.FailureWatcher
@@ -158,6 +167,7 @@ func NewSegmentWriterClient(
 	registry prometheus.Registerer,
 	ring ring.ReadRing,
 	placement placement.Placement,
+	replicationFactor int,
 	dialOpts ...grpc.DialOption,
 ) (*Client, error) {
 	pool, err := newConnPool(ring, logger, grpcClientConfig, dialOpts...)
@@ -170,6 +180,8 @@ func NewSegmentWriterClient(
 		distributor: distributor.NewDistributor(placement, ring),
 		pool:        pool,
 		ring:        ring,
+
+		replicationFactor: max(1, replicationFactor),
 	}
 	c.subservices, err = services.NewManager(c.pool)
 	if err != nil {
@@ -251,10 +263,14 @@ func (c *Client) Push(
 		return nil, status.Error(codes.Unavailable, errServiceUnavailableMsg)
 	}
 
-	// In case of a failure, the request is sent to another instance.
-	// At most 5 attempts to push the data to the segment writer.
 	instances := placement.ActiveInstances(p.Instances)
 	req.Shard = p.Shard
+	if c.replicationFactor > 1 {
+		return c.pushReplicated(ctx, req, instances)
+	}
+
+	// In case of a failure, the request is sent to another instance.
+	// At most 5 attempts to push the data to the segment writer.
 	for attempts := 5; attempts >= 0 && instances.Next(); attempts-- {
 		instance := instances.At()
 		logger := log.With(c.logger,
@@ -292,6 +308,152 @@ func (c *Client) Push(
 	return nil, status.Error(codes.Unavailable, errServiceUnavailableMsg)
 }
 
+// pushReplicated sends the request to replicationFactor distinct instances
+// concurrently, and returns once the quorum of replicas acknowledges it.
+// A replica that fails with a retryable error is sent to the next instance
+// of the shard mapping. All the replicas are written to the same shard
+// and replication window: duplicate profiles are removed when the segments
+// of the window are compacted, regardless of which replicas succeeded.
+//
+// Once the quorum is reached, the remaining replicas continue in the
+// background: they are not canceled when the caller returns.
+func (c *Client) pushReplicated(
+	ctx context.Context,
+	req *segmentwriterv1.PushRequest,
+	instances iter.Iterator[ring.InstanceDesc],
+) (*segmentwriterv1.PushResponse, error) {
+	// The replicas inherit the deadline of the request, but not its
+	// cancellation.
+	replicaCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	if deadline, ok := ctx.Deadline(); ok {
+		replicaCtx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline)
+	}
+
+	// The instance iterator is shared by the replicas: it never
+	// yields the same instance twice.
+	var (
+		mu       sync.Mutex
+		attempts = 5 + c.replicationFactor
+	)
+	nextInstance := func() (ring.InstanceDesc, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if attempts == 0 || !instances.Next() {
+			return ring.InstanceDesc{}, false
+		}
+		attempts--
+		return instances.At(), true
+	}
+
+	window := time.Now().Truncate(replicationWindow).Add(replicationWindow).UnixMilli()
+	results := make(chan error, c.replicationFactor)
+	var wg sync.WaitGroup
+	for replica := 0; replica < c.replicationFactor; replica++ {
+		wg.Add(1)
+		go func(req *segmentwriterv1.PushRequest) {
+			defer wg.Done()
+			results <- c.pushReplica(replicaCtx, req, nextInstance)
+		}(replicaRequest(req, uint32(replica), window))
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	quorum := c.replicationFactor/2 + 1
+	var succeeded, failed int
+	var clientErr error
+	for succeeded < quorum && failed <= c.replicationFactor-quorum {
+		select {
+		case err := <-results:
+			if err == nil {
+				succeeded++
+				continue
+			}
+			failed++
+			if isClientError(err) {
+				clientErr = err
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c.metrics.replicatedPushes.WithLabelValues(quorumLabelValue(succeeded >= quorum)).Inc()
+	if succeeded >= quorum {
+		return new(segmentwriterv1.PushResponse), nil
+	}
+	level.Error(c.logger).Log(
+		"msg", "failed to push data to the quorum of segment writers",
+		"tenant", req.TenantId,
+		"shard", req.Shard,
+		"replicas_succeeded", succeeded,
+		"replicas_failed", failed,
+	)
+	if clientErr != nil {
+		return nil, clientErr
+	}
+	return nil, status.Error(codes.Unavailable, errServiceUnavailableMsg)
+}
+
+func (c *Client) pushReplica(
+	ctx context.Context,
+	req *segmentwriterv1.PushRequest,
+	nextInstance func() (ring.InstanceDesc, bool),
+) (err error) {
+	for {
+		instance, ok := nextInstance()
+		if !ok {
+			return status.Error(codes.Unavailable, errServiceUnavailableMsg)
+		}
+		logger := log.With(c.logger,
+			"tenant", req.TenantId,
+			"shard", req.Shard,
+			"replica", req.Replica,
+			"instance_addr", instance.Addr,
+			"instance_id", instance.Id,
+		)
+		level.Debug(logger).Log("msg", "sending replica")
+		if _, err = c.pushToInstance(ctx, req, instance.Addr); err == nil {
+			return nil
+		}
+		if isClientError(err) {
+			return err
+		}
+		if !isRetryable(err) {
+			level.Error(logger).Log("msg", "failed to push replica to segment writer", "err", err)
+			return status.Error(codes.Unavailable, errServiceUnavailableMsg)
+		}
+		level.Warn(logger).Log("msg", "failed attempt to push replica to segment writer", "err", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+	}
+}
+
+// replicaRequest returns a shallow copy of the request that is marked
+// with the replica index and the replication window: segment writers keep
+// the replicas in separate segments, and only the primary replica is
+// visible to queries until the duplicates are removed at compaction.
+func replicaRequest(req *segmentwriterv1.PushRequest, replica uint32, window int64) *segmentwriterv1.PushRequest {
+	return &segmentwriterv1.PushRequest{
+		TenantId:          req.TenantId,
+		Labels:            req.Labels,
+		Profile:           req.Profile,
+		ProfileId:         req.ProfileId,
+		Shard:             req.Shard,
+		Annotations:       req.Annotations,
+		Replica:           replica,
+		ReplicationWindow: window,
+	}
+}
+
+func quorumLabelValue(reached bool) string {
+	if reached {
+		return "success"
+	}
+	return "failure"
+}
+
 func (c *Client) pushToInstance(
 	ctx context.Context,
 	req *segmentwriterv1.PushRequest,
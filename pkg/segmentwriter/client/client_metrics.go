@@ -7,7 +7,8 @@ import (
 )
 
 type metrics struct {
-	sentBytes *prometheus.HistogramVec
+	sentBytes        *prometheus.HistogramVec
+	replicatedPushes *prometheus.CounterVec
 }
 
 func newMetrics(reg prometheus.Registerer) *metrics {
@@ -24,9 +25,14 @@ func newMetrics(reg prometheus.Registerer) *metrics {
 			NativeHistogramMaxBucketNumber:  50,
 			NativeHistogramMinResetDuration: time.Hour,
 		}, []string{"shard", "tenant", "addr"}),
+		replicatedPushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_segment_writer_client_replicated_pushes_total",
+			Help: "Number of replicated push requests by the quorum outcome.",
+		}, []string{"quorum"}),
 	}
 	if reg != nil {
 		reg.MustRegister(m.sentBytes)
+		reg.MustRegister(m.replicatedPushes)
 	}
 	return m
 }
@@ -8,6 +8,8 @@ import (
 	"io"
 	"net"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -89,7 +91,7 @@ func (s *segwriterClientSuite) SetupTest() {
 	var err error
 	s.client, err = NewSegmentWriterClient(
 		s.config, s.logger, nil, s.ring,
-		testPlacement{},
+		testPlacement{}, 1,
 		grpc.WithContextDialer(s.dialer))
 	s.Require().NoError(err)
 
@@ -148,7 +150,7 @@ func (s *segwriterClientSuite) Test_Push_EmptyRing() {
 	var err error
 	s.client, err = NewSegmentWriterClient(
 		s.config, s.logger, nil, emptyRing,
-		testPlacement{},
+		testPlacement{}, 1,
 		grpc.WithContextDialer(s.dialer))
 	s.Require().NoError(err)
 
@@ -236,7 +238,7 @@ func (s *segwriterClientSuite) Test_Push_DialError() {
 	var err error
 	s.client, err = NewSegmentWriterClient(
 		s.config, s.logger, nil, s.ring,
-		testPlacement{},
+		testPlacement{}, 1,
 		grpc.WithContextDialer(dialer))
 	s.Require().NoError(err)
 
@@ -256,7 +258,7 @@ func (s *segwriterClientSuite) Test_Push_DialError_Retry() {
 	var err error
 	s.client, err = NewSegmentWriterClient(
 		s.config, s.logger, nil, s.ring,
-		testPlacement{},
+		testPlacement{}, 1,
 		grpc.WithContextDialer(dialer))
 	s.Require().NoError(err)
 
@@ -288,7 +290,7 @@ func (s *segwriterClientSuite) Test_Push_ConnTimeout() {
 	var err error
 	s.client, err = NewSegmentWriterClient(
 		s.config, s.logger, nil, s.ring,
-		testPlacement{},
+		testPlacement{}, 1,
 		grpc.WithContextDialer(dialer))
 	s.Require().NoError(err)
 
@@ -300,3 +302,124 @@ func (s *segwriterClientSuite) Test_Push_ConnTimeout() {
 	s.Require().NotNil(err)
 	s.Assert().Contains(err.Error(), errServiceUnavailableMsg)
 }
+
+func (s *segwriterClientSuite) newReplicatedClient() {
+	var err error
+	s.client, err = NewSegmentWriterClient(
+		s.config, s.logger, nil, s.ring,
+		testPlacement{}, 3,
+		grpc.WithContextDialer(s.dialer))
+	s.Require().NoError(err)
+}
+
+func (s *segwriterClientSuite) Test_Push_Replicated_Quorum() {
+	s.newReplicatedClient()
+	var calls atomic.Int32
+	s.service.On("Push", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { calls.Add(1) }).
+		Return(new(segmentwriterv1.PushResponse), status.Error(codes.Unavailable, errServiceUnavailableMsg)).
+		Once()
+	s.service.On("Push", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { calls.Add(1) }).
+		Return(new(segmentwriterv1.PushResponse), nil).
+		Twice()
+
+	_, err := s.client.Push(context.Background(), &segmentwriterv1.PushRequest{})
+	s.Assert().NoError(err)
+	// The replica that fails is not retried: there are only 3 instances.
+	s.Eventually(func() bool { return calls.Load() == 3 }, time.Second, 10*time.Millisecond)
+}
+
+func (s *segwriterClientSuite) Test_Push_Replicated_NoQuorum() {
+	s.newReplicatedClient()
+	var calls atomic.Int32
+	s.service.On("Push", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { calls.Add(1) }).
+		Return(new(segmentwriterv1.PushResponse), status.Error(codes.Unavailable, errServiceUnavailableMsg)).
+		Times(3)
+
+	_, err := s.client.Push(context.Background(), &segmentwriterv1.PushRequest{})
+	s.Assert().Equal(codes.Unavailable.String(), status.Code(err).String())
+	s.Eventually(func() bool { return calls.Load() == 3 }, time.Second, 10*time.Millisecond)
+}
+
+func (s *segwriterClientSuite) Test_Push_Replicated_ClientError() {
+	s.newReplicatedClient()
+	var calls atomic.Int32
+	s.service.On("Push", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { calls.Add(1) }).
+		Return(new(segmentwriterv1.PushResponse), status.Error(codes.InvalidArgument, "invalid profile")).
+		Times(3)
+
+	_, err := s.client.Push(context.Background(), &segmentwriterv1.PushRequest{})
+	s.Assert().Equal(codes.InvalidArgument.String(), status.Code(err).String())
+	s.Eventually(func() bool { return calls.Load() == 3 }, time.Second, 10*time.Millisecond)
+}
+
+func (s *segwriterClientSuite) Test_Push_Replicated_ReplicaIndex() {
+	s.newReplicatedClient()
+	var (
+		mu       sync.Mutex
+		replicas []uint32
+	)
+	s.service.On("Push", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			mu.Lock()
+			defer mu.Unlock()
+			replicas = append(replicas, args.Get(1).(*segmentwriterv1.PushRequest).Replica)
+		}).
+		Return(new(segmentwriterv1.PushResponse), nil).
+		Times(3)
+
+	_, err := s.client.Push(context.Background(), &segmentwriterv1.PushRequest{Shard: 1})
+	s.Assert().NoError(err)
+	s.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(replicas) == 3
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	s.Assert().ElementsMatch([]uint32{0, 1, 2}, replicas)
+}
+
+func (s *segwriterClientSuite) Test_Push_Replicated_PrimaryFailed() {
+	s.newReplicatedClient()
+	var (
+		mu       sync.Mutex
+		requests []*segmentwriterv1.PushRequest
+	)
+	record := func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, args.Get(1).(*segmentwriterv1.PushRequest))
+	}
+	isPrimary := mock.MatchedBy(func(r *segmentwriterv1.PushRequest) bool { return r.Replica == 0 })
+	isReplica := mock.MatchedBy(func(r *segmentwriterv1.PushRequest) bool { return r.Replica > 0 })
+	s.service.On("Push", mock.Anything, isPrimary).
+		Run(record).
+		Return(new(segmentwriterv1.PushResponse), status.Error(codes.Internal, "internal error")).
+		Once()
+	s.service.On("Push", mock.Anything, isReplica).
+		Run(record).
+		Return(new(segmentwriterv1.PushResponse), nil).
+		Twice()
+
+	_, err := s.client.Push(context.Background(), &segmentwriterv1.PushRequest{Shard: 1})
+	s.Assert().NoError(err)
+	s.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests) == 3
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	// The profile is only stored in the replica segments: all the
+	// replicas share the replication window, which the compactor
+	// uses to merge them with the segments of other replicas.
+	window := requests[0].ReplicationWindow
+	s.Assert().NotZero(window)
+	for _, r := range requests {
+		s.Assert().Equal(window, r.ReplicationWindow)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
 	"sync"
 
 	"github.com/google/uuid"
@@ -33,6 +34,9 @@ type FlushedHead struct {
 		NumProfiles      uint64
 		NumSeries        uint64
 	}
+	// BuildIDs lists the build IDs of binaries
+	// referenced by unsymbolized locations.
+	BuildIDs []string
 
 	datasetIndexSeries []*profileSeries
 }
@@ -180,6 +184,9 @@ func (h *Head) flush(ctx context.Context) (*FlushedHead, error) {
 	}
 
 	res.Unsymbolized = HasUnsymbolizedProfiles(h.symbols.Symbols())
+	if res.Unsymbolized {
+		res.BuildIDs = UnsymbolizedBuildIDs(h.symbols.Symbols())
+	}
 
 	symbolsBuffer := bytes.NewBuffer(nil)
 	if err := symdb.WritePartition(h.symbols, symbolsBuffer); err != nil {
@@ -213,3 +220,25 @@ func HasUnsymbolizedProfiles(symbols *symdb.Symbols) bool {
 	}
 	return false
 }
+
+// UnsymbolizedBuildIDs returns the sorted list of unique build IDs
+// of the mappings referenced by unsymbolized locations.
+func UnsymbolizedBuildIDs(symbols *symdb.Symbols) []string {
+	seen := make(map[uint32]struct{})
+	var buildIDs []string
+	for _, loc := range symbols.Locations {
+		m := symbols.Mappings[loc.MappingId]
+		if m.HasFunctions {
+			continue
+		}
+		if _, ok := seen[m.BuildId]; ok {
+			continue
+		}
+		seen[m.BuildId] = struct{}{}
+		if buildID := symbols.Strings[m.BuildId]; buildID != "" {
+			buildIDs = append(buildIDs, buildID)
+		}
+	}
+	slices.Sort(buildIDs)
+	return buildIDs
+}
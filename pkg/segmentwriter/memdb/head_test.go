@@ -773,6 +773,37 @@ func TestUnsymbolized(t *testing.T) {
 	}
 }
 
+func TestUnsymbolizedBuildIDs(t *testing.T) {
+	profile := &profilev1.Profile{
+		StringTable: []string{"", "a", "b", "c", "d"},
+		Function: []*profilev1.Function{
+			{Id: 4, Name: 1},
+		},
+		Mapping: []*profilev1.Mapping{
+			{Id: 239, HasFunctions: true, BuildId: 2},
+			{Id: 240, HasFunctions: false, BuildId: 3},
+			{Id: 241, HasFunctions: false, BuildId: 4},
+			{Id: 242, HasFunctions: false},
+		},
+		Location: []*profilev1.Location{
+			{Id: 5, MappingId: 239, Line: []*profilev1.Line{{FunctionId: 4, Line: 1}}},
+			{Id: 6, MappingId: 240},
+			{Id: 7, MappingId: 240, Address: 0x10},
+			{Id: 8, MappingId: 241},
+			{Id: 9, MappingId: 242},
+		},
+		Sample: []*profilev1.Sample{
+			{LocationId: []uint64{5, 6, 7, 8, 9}, Value: []int64{1}},
+		},
+	}
+
+	symbols := symdb.NewPartitionWriter(0, &symdb.Config{
+		Version: symdb.FormatV3,
+	})
+	symbols.WriteProfileSymbols(profile)
+	assert.Equal(t, []string{"c", "d"}, UnsymbolizedBuildIDs(symbols.Symbols()))
+}
+
 func BenchmarkHeadIngestProfiles(t *testing.B) {
 	var (
 		profilePaths = []string{
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -26,6 +28,7 @@ import (
 
 	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	segmentwriterv1 "github.com/grafana/pyroscope/api/gen/proto/go/segmentwriter/v1"
 	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 	"github.com/grafana/pyroscope/v2/pkg/block"
 	"github.com/grafana/pyroscope/v2/pkg/block/metadata"
@@ -36,7 +39,43 @@ import (
 	"github.com/grafana/pyroscope/v2/pkg/util/retry"
 )
 
-type shardKey uint32
+// replicationWindowGrace is how long after the end of a replication
+// window the segment writer accepts pushes of the window: it covers
+// the clock skew and the retries of the client. Once the grace period
+// expires, the last segment of the window is flushed, and the pushes
+// are rejected: the compactor expects no more segments of the window
+// (see the compaction queue L0 MaxAge).
+const replicationWindowGrace = 10 * time.Second
+
+var errReplicationWindowClosed = errors.New("replication window is closed")
+
+// shardKey identifies the segments of a shard. Replicas of the
+// shard written by replicated pushes are kept in separate segments,
+// which are marked with the replica index in the block metadata.
+// Replicated pushes are also grouped by the replication window, so
+// that a segment never spans multiple windows.
+type shardKey struct {
+	shard   uint32
+	replica uint32
+	window  int64
+}
+
+func (k shardKey) String() string {
+	s := strconv.FormatUint(uint64(k.shard), 10)
+	if k.replica > 0 {
+		s += "-r" + strconv.FormatUint(uint64(k.replica), 10)
+	}
+	if k.window > 0 {
+		s += "-w" + strconv.FormatInt(k.window, 10)
+	}
+	return s
+}
+
+// windowClosed reports whether the replication window
+// of the shard does not accept pushes at the given time.
+func (k shardKey) windowClosed(now time.Time) bool {
+	return k.window > 0 && now.After(time.UnixMilli(k.window).Add(replicationWindowGrace))
+}
 
 type segmentsWriter struct {
 	config    Config
@@ -59,6 +98,7 @@ type segmentsWriter struct {
 
 type shard struct {
 	wg        sync.WaitGroup
+	key       shardKey
 	logger    log.Logger
 	concatBuf []byte
 	sw        *segmentsWriter
@@ -66,19 +106,43 @@ type shard struct {
 	segment   *segment
 }
 
-func (sh *shard) ingest(fn func(head segmentIngest)) segmentWaitFlushed {
+func (sh *shard) ingest(fn func(head segmentIngest)) (segmentWaitFlushed, error) {
 	sh.mu.RLock()
 	s := sh.segment
+	if s == nil {
+		// The shard has been retired.
+		sh.mu.RUnlock()
+		return nil, errReplicationWindowClosed
+	}
 	s.inFlightProfiles.Add(1)
 	sh.mu.RUnlock()
 	defer s.inFlightProfiles.Done()
 	fn(s)
-	return s
+	return s, nil
+}
+
+func (sh *shard) syncWAL() {
+	sh.mu.RLock()
+	s := sh.segment
+	sh.mu.RUnlock()
+	if s == nil || s.wal == nil {
+		return
+	}
+	if err := s.wal.sync(); err != nil {
+		sh.sw.metrics.walErrors.WithLabelValues("sync").Inc()
+		level.Error(s.logger).Log("msg", "failed to sync segment WAL", "err", err)
+	}
 }
 
 func (sh *shard) loop(ctx context.Context) {
 	loopWG := new(sync.WaitGroup)
 	ticker := time.NewTicker(sh.sw.config.SegmentDuration)
+	var walSync <-chan time.Time
+	if sh.sw.config.WALEnabled && sh.sw.config.WALSyncInterval > 0 {
+		walSyncTicker := time.NewTicker(sh.sw.config.WALSyncInterval)
+		defer walSyncTicker.Stop()
+		walSync = walSyncTicker.C
+	}
 	defer func() {
 		ticker.Stop()
 		// Blocking here to make sure no asynchronous code is executed on this shard once loop exits
@@ -88,7 +152,13 @@ func (sh *shard) loop(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if sh.key.windowClosed(time.Now()) {
+				sh.retire(context.Background(), loopWG)
+				return
+			}
 			sh.flushSegment(context.Background(), loopWG)
+		case <-walSync:
+			sh.syncWAL()
 		case <-ctx.Done():
 			sh.flushSegment(context.Background(), loopWG)
 			return
@@ -99,20 +169,38 @@ func (sh *shard) loop(ctx context.Context) {
 func (sh *shard) flushSegment(ctx context.Context, wg *sync.WaitGroup) {
 	sh.mu.Lock()
 	s := sh.segment
-	sh.segment = sh.sw.newSegment(sh, s.shard, sh.logger)
+	sh.segment = sh.sw.newSegment(sh, sh.key, sh.logger)
 	sh.mu.Unlock()
+	sh.flush(ctx, s, wg)
+}
 
+// retire flushes the last segment of the shard once its replication
+// window is closed: the shard does not accept profiles anymore, and is
+// removed from the segment writer when the loop exits.
+func (sh *shard) retire(ctx context.Context, wg *sync.WaitGroup) {
+	sh.mu.Lock()
+	s := sh.segment
+	sh.segment = nil
+	sh.mu.Unlock()
+	sh.flush(ctx, s, wg)
+}
+
+func (sh *shard) flush(ctx context.Context, s *segment, wg *sync.WaitGroup) {
 	wg.Add(1)
 	go func() { // not blocking next ticks in case metastore/s3 latency is high
 		defer wg.Done()
 		t1 := time.Now()
 		s.inFlightProfiles.Wait()
 		s.debuginfo.waitInflight = time.Since(t1)
+		s.closeWAL()
 
-		err := s.flush(ctx)
-		if err != nil {
+		if err := s.flush(ctx); err != nil {
 			_ = level.Error(sh.sw.logger).Log("msg", "failed to flush segment", "err", err)
 		}
+		// The outcome of the flush has been reported to the pushes waiting
+		// for the segment: either the segment is stored durably, or the
+		// clients retry the failed requests. The WAL can be truncated.
+		s.removeWAL()
 		if s.debuginfo.movedHeads > 0 {
 			_ = level.Debug(s.logger).Log("msg",
 				"writing segment block done",
@@ -150,7 +238,10 @@ func newSegmentWriter(l log.Logger, metrics *segmentMetrics, hm *memdb.HeadMetri
 	return sw
 }
 
-func (sw *segmentsWriter) ingest(shard shardKey, fn func(head segmentIngest)) (await segmentWaitFlushed) {
+func (sw *segmentsWriter) ingest(shard shardKey, fn func(head segmentIngest)) (segmentWaitFlushed, error) {
+	if shard.windowClosed(time.Now()) {
+		return nil, errReplicationWindowClosed
+	}
 	sw.shardsLock.RLock()
 	s, ok := sw.shards[shard]
 	sw.shardsLock.RUnlock()
@@ -174,9 +265,12 @@ func (sw *segmentsWriter) ingest(shard shardKey, fn func(head segmentIngest)) (a
 func (sw *segmentsWriter) stop() {
 	sw.logger.Log("msg", "stopping segments writer")
 	sw.cancel()
-	sw.shardsLock.Lock()
-	defer sw.shardsLock.Unlock()
-	for _, s := range sw.shards {
+	// The lock is not held while waiting for the shards:
+	// the retired shards remove themselves from the map.
+	sw.shardsLock.RLock()
+	shards := maps.Values(sw.shards)
+	sw.shardsLock.RUnlock()
+	for _, s := range shards {
 		s.wg.Wait()
 	}
 	sw.pool.stop()
@@ -184,9 +278,10 @@ func (sw *segmentsWriter) stop() {
 }
 
 func (sw *segmentsWriter) newShard(sk shardKey) *shard {
-	sl := log.With(sw.logger, "shard", fmt.Sprintf("%d", sk))
+	sl := log.With(sw.logger, "shard", sk.String())
 	sh := &shard{
 		sw:        sw,
+		key:       sk,
 		logger:    sl,
 		concatBuf: make([]byte, 4*0x1000),
 	}
@@ -195,13 +290,29 @@ func (sw *segmentsWriter) newShard(sk shardKey) *shard {
 	go func() {
 		defer sh.wg.Done()
 		sh.loop(sw.ctx)
+		if sk.window > 0 {
+			sw.removeShard(sh)
+		}
 	}()
 	return sh
 }
 
+// removeShard removes the shard of a closed replication window.
+// The WAL directory of the shard is removed, if it is empty.
+func (sw *segmentsWriter) removeShard(sh *shard) {
+	sw.shardsLock.Lock()
+	if sw.shards[sh.key] == sh {
+		delete(sw.shards, sh.key)
+	}
+	sw.shardsLock.Unlock()
+	if sw.config.WALEnabled {
+		_ = os.Remove(sw.walShardDir(sh.key))
+	}
+}
+
 func (sw *segmentsWriter) newSegment(sh *shard, sk shardKey, sl log.Logger) *segment {
 	id := ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader)
-	sshard := fmt.Sprintf("%d", sk)
+	sshard := strconv.FormatUint(uint64(sk.shard), 10)
 	s := &segment{
 		logger:   log.With(sl, "segment-id", id.String()),
 		ulid:     id,
@@ -212,6 +323,9 @@ func (sw *segmentsWriter) newSegment(sh *shard, sk shardKey, sl log.Logger) *seg
 		sshard:   sshard,
 		doneChan: make(chan struct{}),
 	}
+	if sw.config.WALEnabled {
+		s.wal = newSegmentWAL(sw.walShardDir(sk), id)
+	}
 	return s
 }
 
@@ -219,7 +333,9 @@ func (s *segment) flush(ctx context.Context) (err error) {
 	span, ctx := tracing.StartSpanFromContext(ctx, "segment.flush")
 	span.SetTag("block_id", s.ulid.String())
 	span.SetTag("datasets", len(s.datasets))
-	span.SetTag("shard", s.shard)
+	span.SetTag("shard", s.shard.shard)
+	span.SetTag("replica", s.shard.replica)
+	span.SetTag("replication_window", s.shard.window)
 	defer span.Finish()
 
 	t1 := time.Now()
@@ -260,16 +376,18 @@ func (s *segment) flushBlock(stream flushStream) ([]byte, *metastorev1.BlockMeta
 
 	stringTable := metadata.NewStringTable()
 	meta := &metastorev1.BlockMeta{
-		FormatVersion:   1,
-		Id:              s.ulid.String(),
-		Tenant:          0,
-		Shard:           uint32(s.shard),
-		CompactionLevel: 0,
-		CreatedBy:       stringTable.Put(hostname),
-		MinTime:         math.MaxInt64,
-		MaxTime:         0,
-		Size:            0,
-		Datasets:        make([]*metastorev1.Dataset, 0, len(stream.heads)),
+		FormatVersion:     1,
+		Id:                s.ulid.String(),
+		Tenant:            0,
+		Shard:             s.shard.shard,
+		Replica:           s.shard.replica,
+		CompactionLevel:   0,
+		ReplicationWindow: s.shard.window,
+		CreatedBy:         stringTable.Put(hostname),
+		MinTime:           math.MaxInt64,
+		MaxTime:           0,
+		Size:              0,
+		Datasets:          make([]*metastorev1.Dataset, 0, len(stream.heads)),
 	}
 
 	blockFile := bytes.NewBuffer(nil)
@@ -435,6 +553,18 @@ func concatSegmentHead(f *datasetFlush, w *writerOffset, s *metadata.StringTable
 		lb.WithLabelSet(model.LabelNameServiceName, f.dataset.key.service, metadata.LabelNameUnsymbolized, "true")
 	}
 
+	// Build IDs of unsymbolized binaries are used to find debug info
+	// files that are no longer referenced by any block.
+	for _, buildID := range f.flushed.BuildIDs {
+		lb.WithLabelSet(model.LabelNameServiceName, f.dataset.key.service, metadata.LabelNameBuildID, buildID)
+	}
+
+	// Releases are used to decide whether the source maps
+	// are to be applied to the JavaScript frames at query time.
+	for _, release := range f.dataset.releaseValues() {
+		lb.WithLabelSet(model.LabelNameServiceName, f.dataset.key.service, metadata.LabelNameRelease, release)
+	}
+
 	// Other optional labels:
 	// lb.WithLabelSet("label_name", "label_value", ...)
 	ds.Labels = lb.Build()
@@ -537,6 +667,9 @@ type dataset struct {
 	sw   *segmentsWriter
 	once sync.Once
 	head *memdb.Head
+
+	releasesMu sync.Mutex
+	releases   map[string]struct{}
 }
 
 func newDataset(k datasetKey, sw *segmentsWriter) *dataset { return &dataset{key: k, sw: sw} }
@@ -548,6 +681,25 @@ func (d *dataset) initHead() *memdb.Head {
 	return d.head
 }
 
+func (d *dataset) addRelease(release string) {
+	d.releasesMu.Lock()
+	defer d.releasesMu.Unlock()
+	if d.releases == nil {
+		d.releases = make(map[string]struct{})
+	}
+	d.releases[release] = struct{}{}
+}
+
+// releaseValues returns the sorted list of
+// releases of the profiles in the dataset.
+func (d *dataset) releaseValues() []string {
+	d.releasesMu.Lock()
+	defer d.releasesMu.Unlock()
+	releases := maps.Keys(d.releases)
+	slices.Sort(releases)
+	return releases
+}
+
 type datasetFlush struct {
 	dataset *dataset
 	flushed *memdb.FlushedHead
@@ -565,6 +717,8 @@ type segment struct {
 
 	logger log.Logger
 	sw     *segmentsWriter
+	// Optional, nil if WAL is disabled.
+	wal *segmentWAL
 
 	// TODO(kolesnikovae): Revisit.
 	doneChan      chan struct{}
@@ -587,6 +741,7 @@ type segment struct {
 
 type segmentIngest interface {
 	ingest(tenantID string, p *profilev1.Profile, id uuid.UUID, labels []*typesv1.LabelPair, annotations []*typesv1.ProfileAnnotation)
+	appendWAL(req *segmentwriterv1.PushRequest)
 }
 
 type segmentWaitFlushed interface {
@@ -619,6 +774,11 @@ func (s *segment) ingest(tenantID string, p *profilev1.Profile, id uuid.UUID, la
 	//   worth it.
 	serviceName := model.Labels(labels).Get(model.LabelNameServiceName)
 	ds := s.datasetForIngest(datasetKey{tenant: tenantID, service: serviceName})
+	if l := s.sw.config.ReleaseLabel; l != "" {
+		if release := model.Labels(labels).Get(l); release != "" {
+			ds.addRelease(release)
+		}
+	}
 	appender := &sampleAppender{dataset: ds.initHead(), profile: p, id: id, annotations: annotations}
 	// Relabeling rules cannot be applied here: it should be done before the
 	// ingestion, in distributors. Otherwise, it may change the distribution
@@ -629,6 +789,36 @@ func (s *segment) ingest(tenantID string, p *profilev1.Profile, id uuid.UUID, la
 	s.sw.metrics.segmentIngestBytes.WithLabelValues(s.sshard, tenantID).Observe(float64(p.SizeVT()))
 }
 
+func (s *segment) appendWAL(req *segmentwriterv1.PushRequest) {
+	if s.wal == nil {
+		return
+	}
+	if err := s.wal.append(req); err != nil {
+		s.sw.metrics.walErrors.WithLabelValues("append").Inc()
+		level.Error(s.logger).Log("msg", "failed to append to segment WAL", "err", err)
+	}
+}
+
+func (s *segment) closeWAL() {
+	if s.wal == nil {
+		return
+	}
+	if err := s.wal.close(); err != nil {
+		s.sw.metrics.walErrors.WithLabelValues("sync").Inc()
+		level.Error(s.logger).Log("msg", "failed to close segment WAL", "err", err)
+	}
+}
+
+func (s *segment) removeWAL() {
+	if s.wal == nil {
+		return
+	}
+	if err := s.wal.remove(); err != nil {
+		s.sw.metrics.walErrors.WithLabelValues("remove").Inc()
+		level.Error(s.logger).Log("msg", "failed to remove segment WAL", "err", err)
+	}
+}
+
 type sampleAppender struct {
 	id          uuid.UUID
 	dataset     *memdb.Head
@@ -21,6 +21,9 @@ type segmentMetrics struct {
 	flushHeadsDuration          *prometheus.HistogramVec
 	flushServiceHeadDuration    *prometheus.HistogramVec
 	flushServiceHeadError       *prometheus.CounterVec
+	walErrors                   *prometheus.CounterVec
+	walReplayedProfiles         prometheus.Counter
+	walReplayErrors             prometheus.Counter
 }
 
 var (
@@ -93,6 +96,25 @@ func newSegmentMetrics(reg prometheus.Registerer) *segmentMetrics {
 			NativeHistogramMinResetDuration: time.Minute * 15,
 		}, []string{"status"}),
 
+		walErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pyroscope",
+			Subsystem: "segment_writer",
+			Name:      "wal_errors_total",
+			Help:      "Number of WAL operation failures.",
+		}, []string{"op"}),
+		walReplayedProfiles: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "pyroscope",
+			Subsystem: "segment_writer",
+			Name:      "wal_replayed_profiles_total",
+			Help:      "Number of profiles replayed from the WAL on startup.",
+		}),
+		walReplayErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "pyroscope",
+			Subsystem: "segment_writer",
+			Name:      "wal_replay_errors_total",
+			Help:      "Number of corrupted or invalid WAL records skipped on replay.",
+		}),
+
 		storeMetadataDLQ: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "pyroscope",
 			Subsystem: "segment_writer",
@@ -179,6 +201,9 @@ func newSegmentMetrics(reg prometheus.Registerer) *segmentMetrics {
 		reg.MustRegister(m.flushSegmentDuration)
 		reg.MustRegister(m.headSizeBytes)
 		reg.MustRegister(m.tenantIndexBytes)
+		reg.MustRegister(m.walErrors)
+		reg.MustRegister(m.walReplayedProfiles)
+		reg.MustRegister(m.walReplayErrors)
 	}
 	return m
 }
@@ -134,16 +134,50 @@ func TestIngestWait(t *testing.T) {
 	}).Return(new(metastorev1.AddBlockResponse), nil)
 
 	t1 := time.Now()
-	awaiter := sw.ingest(0, func(head segmentIngest) {
+	awaiter, err := sw.ingest(shardKey{}, func(head segmentIngest) {
 		p := cpuProfile(42, 480, "svc1", "foo", "bar")
 		head.ingest("t1", p.Profile, p.UUID, p.Labels, p.Annotations)
 	})
-	err := awaiter.waitFlushed(context.Background())
+	require.NoError(t, err)
+	err = awaiter.waitFlushed(context.Background())
 	require.NoError(t, err)
 	since := time.Since(t1)
 	require.True(t, since > 1*time.Second)
 }
 
+func TestIngestReplicationWindow(t *testing.T) {
+	sw := newTestSegmentWriter(t, defaultTestConfig())
+	defer sw.stop()
+	blocks := make(chan *metastorev1.BlockMeta, 1)
+	sw.client.On("AddBlock", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			blocks <- args.Get(1).(*metastorev1.AddBlockRequest).Block
+		}).Return(new(metastorev1.AddBlockResponse), nil)
+
+	// The window is closed shortly after the profile is ingested.
+	window := time.Now().Add(500*time.Millisecond - replicationWindowGrace).UnixMilli()
+	sk := shardKey{shard: 1, replica: 2, window: window}
+	awaiter, err := sw.ingest(sk, func(head segmentIngest) {
+		p := cpuProfile(42, 480, "svc1", "foo", "bar")
+		head.ingest("t1", p.Profile, p.UUID, p.Labels, p.Annotations)
+	})
+	require.NoError(t, err)
+	require.NoError(t, awaiter.waitFlushed(context.Background()))
+	block := <-blocks
+	assert.Equal(t, uint32(2), block.Replica)
+	assert.Equal(t, window, block.ReplicationWindow)
+
+	// The shard is retired once the window is closed.
+	require.Eventually(t, func() bool {
+		sw.shardsLock.RLock()
+		defer sw.shardsLock.RUnlock()
+		_, ok := sw.shards[sk]
+		return !ok
+	}, 5*time.Second, 50*time.Millisecond)
+	_, err = sw.ingest(sk, func(segmentIngest) {})
+	require.ErrorIs(t, err, errReplicationWindowClosed)
+}
+
 func TestBusyIngestLoop(t *testing.T) {
 
 	sw := newTestSegmentWriter(t, defaultTestConfig())
@@ -203,11 +237,12 @@ func TestBusyIngestLoop(t *testing.T) {
 					return
 				default:
 					ts := workerno*1000000000 + len(profiles)
-					awaiter := sw.ingest(1, func(head segmentIngest) {
+					awaiter, err := sw.ingest(shardKey{shard: 1}, func(head segmentIngest) {
 						p := cpuProfile(42, ts, "svc1", "foo", "bar")
 						head.ingest("t1", p.CloneVT(), p.UUID, p.Labels, p.Annotations)
 						profiles = append(profiles, p)
 					})
+					require.NoError(t, err)
 					awaiters = append(awaiters, awaiter)
 				}
 			}
@@ -258,8 +293,10 @@ func TestDLQFail(t *testing.T) {
 		head.ingest("t1", p.Profile, p.UUID, p.Labels, p.Annotations)
 	}
 
-	awaiter1 := res.ingest(0, ing)
-	awaiter2 := res.ingest(0, ing)
+	awaiter1, err := res.ingest(shardKey{}, ing)
+	require.NoError(t, err)
+	awaiter2, err := res.ingest(shardKey{}, ing)
+	require.NoError(t, err)
 
 	err1 := awaiter1.waitFlushed(context.Background())
 	require.Error(t, err1)
@@ -301,7 +338,7 @@ func TestDatasetMinMaxTime(t *testing.T) {
 		{shard: 1, tenant: "ta", profile: cpuProfile(13, 10, "svc1", "vbn", "foo", "bar")},
 		{shard: 1, tenant: "ta", profile: cpuProfile(13, 1337, "svc1", "vbn", "foo", "bar")},
 	}
-	_ = res.ingest(1, func(head segmentIngest) {
+	_, _ = res.ingest(shardKey{shard: 1}, func(head segmentIngest) {
 		for _, p := range data {
 			head.ingest(p.tenant, p.profile.Profile, p.profile.UUID, p.profile.Labels, p.profile.Annotations)
 		}
@@ -363,7 +400,7 @@ func TestSegmentTenantDatasetIndexes(t *testing.T) {
 		{shard: 1, tenant: "ta", profile: cpuProfile(13, 110, "svc2", "foo", "bar")},
 		{shard: 1, tenant: "tb", profile: cpuProfile(13, 120, "svc1", "foo", "bar")},
 	}
-	_ = sw.ingest(1, func(head segmentIngest) {
+	_, _ = sw.ingest(shardKey{shard: 1}, func(head segmentIngest) {
 		for _, p := range data {
 			head.ingest(p.tenant, p.profile.Profile, p.profile.UUID, p.profile.Labels, p.profile.Annotations)
 		}
@@ -606,6 +643,43 @@ func TestUnsymbolizedLabelIsSet(t *testing.T) {
 	require.True(t, hasUnsymbolizedLabel(t, block))
 }
 
+func TestReleaseLabelIsSet(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.ReleaseLabel = "service_version"
+	sw := newTestSegmentWriter(t, cfg)
+	defer sw.stop()
+	blocks := make(chan *metastorev1.BlockMeta, 1)
+
+	sw.client.On("AddBlock", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			blocks <- args.Get(1).(*metastorev1.AddBlockRequest).Block
+		}).Return(new(metastorev1.AddBlockResponse), nil)
+
+	chunk := inputChunk{
+		{shard: 1, tenant: "t1", profile: cpuProfile(42, 480, "svc1", "foo", "bar").
+			WithLabels("service_version", "v2")},
+		{shard: 1, tenant: "t1", profile: cpuProfile(13, 233, "svc1", "qwe", "foo", "bar").
+			WithLabels("service_version", "v1")},
+		{shard: 1, tenant: "t1", profile: cpuProfile(13, 233, "svc2", "qwe", "foo", "bar")},
+	}
+	_ = sw.ingestChunk(t, chunk, false)
+	block := <-blocks
+
+	var releases []string
+	for _, ds := range block.Datasets {
+		pairs := metadata.LabelPairs(ds.Labels)
+		for pairs.Next() {
+			p := pairs.At()
+			for i := 0; i+1 < len(p); i += 2 {
+				if block.StringTable[p[i]] == metadata.LabelNameRelease {
+					releases = append(releases, block.StringTable[ds.Name]+"@"+block.StringTable[p[i+1]])
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{"svc1@v1", "svc1@v2"}, releases)
+}
+
 type sw struct {
 	*segmentsWriter
 	bucket  *memory.InMemBucket
@@ -836,11 +910,12 @@ func (sw *sw) ingestChunk(t *testing.T, chunk inputChunk, expectAwaitError bool)
 
 		go func() {
 			defer wg.Done()
-			awaiter := sw.ingest(shardKey(it.shard), func(head segmentIngest) {
+			awaiter, err := sw.ingest(shardKey{shard: it.shard}, func(head segmentIngest) {
 				p := it.profile.CloneVT() // important to not rewrite original profile
 				head.ingest(it.tenant, p, it.profile.UUID, it.profile.Labels, it.profile.Annotations)
 			})
-			err := awaiter.waitFlushed(context.Background())
+			require.NoError(t, err)
+			err = awaiter.waitFlushed(context.Background())
 			if expectAwaitError {
 				require.Error(t, err)
 			} else {
@@ -57,10 +57,25 @@ type Config struct {
 	MetadataUpdateTimeout    time.Duration         `yaml:"metadata_update_timeout,omitempty" category:"advanced"`
 	BucketHealthCheckEnabled bool                  `yaml:"bucket_health_check_enabled,omitempty" category:"advanced"`
 	BucketHealthCheckTimeout time.Duration         `yaml:"bucket_health_check_timeout,omitempty" category:"advanced"`
+	WALEnabled               bool                  `yaml:"wal_enabled,omitempty" category:"experimental"`
+	WALDir                   string                `yaml:"wal_dir,omitempty" category:"experimental"`
+	WALSyncInterval          time.Duration         `yaml:"wal_sync_interval,omitempty" category:"experimental"`
+	WriteReplicationFactor   int                   `yaml:"write_replication_factor,omitempty" category:"experimental"`
+
+	// ReleaseLabel is the series label that identifies the release of the
+	// profiles, as configured for the source maps. The releases are recorded
+	// in the dataset labels. Empty, if source maps are disabled.
+	ReleaseLabel string `yaml:"-"`
 }
 
 func (cfg *Config) Validate() error {
 	// TODO(kolesnikovae): implement.
+	if cfg.WriteReplicationFactor < 0 {
+		return errors.New("segment writer write replication factor must not be negative")
+	}
+	if cfg.WALEnabled && cfg.WALDir == "" {
+		return errors.New("segment writer WAL directory is required when WAL is enabled")
+	}
 	if err := cfg.LifecyclerConfig.Validate(); err != nil {
 		return err
 	}
@@ -97,6 +112,10 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.MetadataUpdateTimeout, prefix+".metadata-update-timeout", 2*time.Second, "Timeout for metadata update requests.")
 	f.BoolVar(&cfg.BucketHealthCheckEnabled, prefix+".bucket-health-check-enabled", true, "Enables bucket health check on startup. This both validates credentials and warms up the connection to reduce latency for the first write.")
 	f.DurationVar(&cfg.BucketHealthCheckTimeout, prefix+".bucket-health-check-timeout", 10*time.Second, "Timeout for bucket health check operations.")
+	f.BoolVar(&cfg.WALEnabled, prefix+".wal-enabled", false, "Enables the write-ahead log (WAL). Profiles that have not been flushed to the object storage are replayed from the WAL on startup.")
+	f.StringVar(&cfg.WALDir, prefix+".wal-dir", "./data/v2/segment-writer/wal", "Directory to store the WAL files. The directory must be persisted between restarts.")
+	f.IntVar(&cfg.WriteReplicationFactor, prefix+".write-replication-factor", 1, "Number of segment writers each profile is sent to. The write is acknowledged once the majority of the replicas succeed. Replicas are stored in separate segments that are not queried. The segments of all the replicas written within the same replication window are compacted together, and the duplicates are removed: profiles missing in the primary replica are only visible once compacted.")
+	f.DurationVar(&cfg.WALSyncInterval, prefix+".wal-sync-interval", 100*time.Millisecond, "Interval at which the WAL is synced to the disk. The WAL is always synced before a segment is flushed. 0 disables periodic syncs.")
 }
 
 type Limits interface {
@@ -209,6 +228,14 @@ func (i *SegmentWriterService) starting(ctx context.Context) error {
 	// On error, will emit a warning but continue startup
 	_ = i.performBucketHealthCheck(ctx)
 
+	// Profiles that have not been flushed before the previous shutdown
+	// must be replayed before the instance starts accepting requests.
+	if i.config.WALEnabled {
+		if err := i.segmentWriter.replayWAL(ctx); err != nil {
+			return fmt.Errorf("failed to replay WAL: %w", err)
+		}
+	}
+
 	if err := services.StartManagerAndAwaitHealthy(ctx, i.subservices); err != nil {
 		return err
 	}
@@ -262,9 +289,17 @@ func (i *SegmentWriterService) Push(ctx context.Context, req *segmentwriterv1.Pu
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	wait := i.segmentWriter.ingest(shardKey(req.Shard), func(segment segmentIngest) {
+	sk := shardKey{shard: req.Shard, replica: req.Replica, window: req.ReplicationWindow}
+	wait, err := i.segmentWriter.ingest(sk, func(segment segmentIngest) {
+		segment.appendWAL(req)
 		segment.ingest(req.TenantId, p.Profile, id, req.Labels, req.Annotations)
 	})
+	if err != nil {
+		// The segments of the replication window may have been already
+		// flushed by other replicas: the push must not be retried with
+		// the same window.
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
 
 	flushStarted := time.Now()
 	defer func() {
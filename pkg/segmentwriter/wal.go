@@ -0,0 +1,371 @@
+package segmentwriter
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+
+	segmentwriterv1 "github.com/grafana/pyroscope/api/gen/proto/go/segmentwriter/v1"
+	"github.com/grafana/pyroscope/v2/pkg/pprof"
+)
+
+// The write-ahead log (WAL) makes the in-memory segments durable across
+// restarts. Every segment has its own WAL file in the shard directory:
+//
+//	<wal-dir>/<shard>/<segment-ulid>.wal
+//
+// The shard directory name includes the replica index and the replication
+// window of the segments of replicated pushes, e.g. "1-r2-w1700000005000".
+//
+// The file contains the push requests ingested into the segment: it is
+// created when the first request is appended, and is removed once the
+// segment is flushed, whether the flush succeeds or not. In both cases,
+// the outcome is reported to the pushes waiting for the segment: failed
+// pushes are retried by the clients, and must not be replayed. Therefore,
+// only the WAL files of the segments that have not been flushed, such as
+// the ones left on disk after a crash, are replayed when the service starts.
+//
+// Records are appended to the file with a single write call, therefore
+// they survive a process crash. The file is synced to the disk
+// periodically (see WALSyncInterval), and before the segment is flushed.
+//
+// A record consists of the header and the payload:
+//
+//	| length uint32 | crc32c uint32 | payload (PushRequest) |
+//
+// Note that the replayed profiles may be duplicated if the client retries
+// a request that failed because of the crash.
+
+const (
+	walFileExt         = ".wal"
+	walRecordHeaderLen = 8
+	// Any record exceeding the limit is considered corrupted.
+	walMaxRecordLen = 256 << 20
+)
+
+var walCastagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+var errWALCorrupted = errors.New("corrupted WAL record")
+
+type segmentWAL struct {
+	mu     sync.Mutex
+	dir    string
+	path   string
+	f      *os.File
+	buf    []byte
+	dirty  bool
+	closed bool
+	err    error
+}
+
+// newSegmentWAL returns the WAL of the segment. The file is created
+// when the first record is appended: segments that receive no profiles
+// do not touch the disk.
+func newSegmentWAL(dir string, id ulid.ULID) *segmentWAL {
+	return &segmentWAL{dir: dir, path: filepath.Join(dir, id.String()+walFileExt)}
+}
+
+func (w *segmentWAL) create() error {
+	if err := os.MkdirAll(w.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w.f = f
+	return nil
+}
+
+// append writes the request to the WAL file. Once an error occurs,
+// all subsequent calls fail: the segment is not durable anymore.
+func (w *segmentWAL) append(req *segmentwriterv1.PushRequest) error {
+	size := req.SizeVT()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.f == nil {
+		if w.err = w.create(); w.err != nil {
+			return w.err
+		}
+	}
+	n := walRecordHeaderLen + size
+	if cap(w.buf) < n {
+		w.buf = make([]byte, n)
+	}
+	w.buf = w.buf[:n]
+	if _, err := req.MarshalToSizedBufferVT(w.buf[walRecordHeaderLen:]); err != nil {
+		return err
+	}
+	payload := w.buf[walRecordHeaderLen:]
+	binary.LittleEndian.PutUint32(w.buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(w.buf[4:8], crc32.Checksum(payload, walCastagnoli))
+	if _, w.err = w.f.Write(w.buf); w.err != nil {
+		return w.err
+	}
+	w.dirty = true
+	return nil
+}
+
+// sync commits the appended records to the disk.
+func (w *segmentWAL) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncLocked()
+}
+
+func (w *segmentWAL) syncLocked() error {
+	if w.err != nil || w.f == nil || !w.dirty {
+		return w.err
+	}
+	w.err = w.f.Sync()
+	w.dirty = false
+	return w.err
+}
+
+// close syncs and closes the WAL file. No records can be appended after
+// the call. The file is retained on the disk.
+func (w *segmentWAL) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.f == nil {
+		return w.err
+	}
+	err := w.syncLocked()
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	w.f = nil
+	return err
+}
+
+// remove truncates the WAL: the file is deleted, if it has been created.
+func (w *segmentWAL) remove() error {
+	if err := w.close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// readSegmentWAL calls fn for each record of the WAL file. A torn or
+// corrupted record stops the iteration with errWALCorrupted: it is
+// expected if the process crashed in the middle of a write.
+func readSegmentWAL(path string, fn func(*segmentwriterv1.PushRequest) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var (
+		header [walRecordHeaderLen]byte
+		buf    []byte
+	)
+	for {
+		if _, err = io.ReadFull(f, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("%w: %w", errWALCorrupted, err)
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > walMaxRecordLen {
+			return fmt.Errorf("%w: record size %d exceeds limit", errWALCorrupted, size)
+		}
+		if cap(buf) < int(size) {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		if _, err = io.ReadFull(f, buf); err != nil {
+			return fmt.Errorf("%w: %w", errWALCorrupted, err)
+		}
+		if crc32.Checksum(buf, walCastagnoli) != binary.LittleEndian.Uint32(header[4:8]) {
+			return fmt.Errorf("%w: checksum mismatch", errWALCorrupted)
+		}
+		var req segmentwriterv1.PushRequest
+		if err = req.UnmarshalVT(buf); err != nil {
+			return fmt.Errorf("%w: %w", errWALCorrupted, err)
+		}
+		if err = fn(&req); err != nil {
+			return err
+		}
+	}
+}
+
+func (sw *segmentsWriter) walShardDir(sk shardKey) string {
+	return filepath.Join(sw.config.WALDir, sk.String())
+}
+
+// parseShardKey parses the name of a WAL shard directory.
+func parseShardKey(s string) (shardKey, error) {
+	s, window, hasWindow := strings.Cut(s, "-w")
+	shard, replica, hasReplica := strings.Cut(s, "-r")
+	sk, err := strconv.ParseUint(shard, 10, 32)
+	if err != nil {
+		return shardKey{}, err
+	}
+	var r uint64
+	if hasReplica {
+		if r, err = strconv.ParseUint(replica, 10, 32); err != nil || r == 0 {
+			return shardKey{}, fmt.Errorf("invalid replica: %q", replica)
+		}
+	}
+	var w int64
+	if hasWindow {
+		if w, err = strconv.ParseInt(window, 10, 64); err != nil || w <= 0 {
+			return shardKey{}, fmt.Errorf("invalid replication window: %q", window)
+		}
+	}
+	return shardKey{shard: uint32(sk), replica: uint32(r), window: w}, nil
+}
+
+type walFile struct {
+	shard shardKey
+	path  string
+}
+
+// listWAL returns WAL files found in the WAL directory,
+// ordered by shard, replica, replication window and segment ID
+// (creation time).
+func (sw *segmentsWriter) listWAL() ([]walFile, error) {
+	dirs, err := os.ReadDir(sw.config.WALDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []walFile
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		sk, err := parseShardKey(d.Name())
+		if err != nil {
+			level.Warn(sw.logger).Log("msg", "skipping unexpected directory in WAL dir", "dir", d.Name())
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(sw.config.WALDir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), walFileExt) {
+				continue
+			}
+			files = append(files, walFile{
+				shard: sk,
+				path:  filepath.Join(sw.config.WALDir, d.Name(), e.Name()),
+			})
+		}
+	}
+	slices.SortFunc(files, func(a, b walFile) int {
+		if a.shard.shard != b.shard.shard {
+			return cmp.Compare(a.shard.shard, b.shard.shard)
+		}
+		if a.shard.replica != b.shard.replica {
+			return cmp.Compare(a.shard.replica, b.shard.replica)
+		}
+		if a.shard.window != b.shard.window {
+			return cmp.Compare(a.shard.window, b.shard.window)
+		}
+		return strings.Compare(a.path, b.path)
+	})
+	return files, nil
+}
+
+// replayWAL ingests the profiles from the WAL files left by the previous
+// run, and waits for the segments to be flushed. The pushes in the files
+// have never been acknowledged. A file is removed once all its profiles
+// are flushed; otherwise, it is retained and replayed on the next start.
+// Replayed profiles are not written to the WAL files of the new segments.
+func (sw *segmentsWriter) replayWAL(ctx context.Context) error {
+	files, err := sw.listWAL()
+	if err != nil {
+		return fmt.Errorf("failed to list WAL files: %w", err)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	level.Info(sw.logger).Log("msg", "replaying WAL", "files", len(files))
+	var profiles int
+	awaiters := make([][]segmentWaitFlushed, len(files))
+	for i, file := range files {
+		err = readSegmentWAL(file.path, func(req *segmentwriterv1.PushRequest) error {
+			var id uuid.UUID
+			if err := id.UnmarshalBinary(req.ProfileId); err != nil {
+				sw.metrics.walReplayErrors.Inc()
+				return nil
+			}
+			p, err := pprof.RawFromBytes(req.Profile)
+			if err != nil {
+				sw.metrics.walReplayErrors.Inc()
+				return nil
+			}
+			await, err := sw.ingest(file.shard, func(segment segmentIngest) {
+				segment.ingest(req.TenantId, p.Profile, id, req.Labels, req.Annotations)
+			})
+			if err != nil {
+				// The replication window is closed: the segments of the
+				// window may have been compacted already. The push has not
+				// been acknowledged by this replica, therefore the profile
+				// is either stored by the quorum, or retried by the client.
+				sw.metrics.walReplayErrors.Inc()
+				return nil
+			}
+			awaiters[i] = append(awaiters[i], await)
+			profiles++
+			return nil
+		})
+		if err != nil {
+			if !errors.Is(err, errWALCorrupted) {
+				return fmt.Errorf("failed to replay WAL file %s: %w", file.path, err)
+			}
+			sw.metrics.walReplayErrors.Inc()
+			level.Warn(sw.logger).Log("msg", "WAL file is corrupted; the remaining records are skipped", "path", file.path, "err", err)
+		}
+	}
+	sw.metrics.walReplayedProfiles.Add(float64(profiles))
+	for i, file := range files {
+		var flushErr error
+		for _, a := range awaiters[i] {
+			if err = a.waitFlushed(ctx); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				flushErr = err
+			}
+		}
+		if flushErr != nil {
+			level.Warn(sw.logger).Log("msg", "failed to flush replayed segment; WAL file is retained", "path", file.path, "err", flushErr)
+			continue
+		}
+		if err = os.Remove(file.path); err != nil {
+			level.Warn(sw.logger).Log("msg", "failed to remove WAL file", "path", file.path, "err", err)
+		}
+	}
+	level.Info(sw.logger).Log("msg", "WAL replay completed", "profiles", profiles)
+	return nil
+}
@@ -0,0 +1,249 @@
+package segmentwriter
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	metastorev1 "github.com/grafana/pyroscope/api/gen/proto/go/metastore/v1"
+	segmentwriterv1 "github.com/grafana/pyroscope/api/gen/proto/go/segmentwriter/v1"
+	pprofth "github.com/grafana/pyroscope/v2/pkg/pprof/testhelper"
+)
+
+func walPushRequest(t *testing.T, shard uint32, p *pprofth.ProfileBuilder) *segmentwriterv1.PushRequest {
+	profile, err := p.Profile.MarshalVT()
+	require.NoError(t, err)
+	id, err := p.UUID.MarshalBinary()
+	require.NoError(t, err)
+	return &segmentwriterv1.PushRequest{
+		TenantId:    "t1",
+		Shard:       shard,
+		Labels:      p.Labels,
+		Profile:     profile,
+		ProfileId:   id,
+		Annotations: p.Annotations,
+	}
+}
+
+func TestSegmentWAL_ReadWrite(t *testing.T) {
+	dir := t.TempDir()
+	w := newSegmentWAL(filepath.Join(dir, "1"), ulid.MustNew(ulid.Now(), rand.Reader))
+	// The file is created when the first record is appended.
+	_, err := os.Stat(w.path)
+	require.True(t, os.IsNotExist(err))
+
+	requests := []*segmentwriterv1.PushRequest{
+		walPushRequest(t, 1, cpuProfile(42, 480, "svc1", "foo", "bar")),
+		walPushRequest(t, 1, cpuProfile(13, 960, "svc2", "qux", "bar")),
+	}
+	for _, req := range requests {
+		require.NoError(t, w.append(req))
+	}
+	require.NoError(t, w.sync())
+	require.NoError(t, w.close())
+	require.Error(t, w.append(requests[0]))
+
+	var replayed []*segmentwriterv1.PushRequest
+	require.NoError(t, readSegmentWAL(w.path, func(req *segmentwriterv1.PushRequest) error {
+		replayed = append(replayed, req)
+		return nil
+	}))
+	require.Len(t, replayed, len(requests))
+	for i := range requests {
+		assert.True(t, requests[i].EqualVT(replayed[i]))
+	}
+
+	// Simulate a torn write: the valid records must be read.
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0xFF, 0x00, 0x00})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	replayed = replayed[:0]
+	err = readSegmentWAL(w.path, func(req *segmentwriterv1.PushRequest) error {
+		replayed = append(replayed, req)
+		return nil
+	})
+	require.ErrorIs(t, err, errWALCorrupted)
+	require.Len(t, replayed, len(requests))
+
+	require.NoError(t, w.remove())
+	_, err = os.Stat(w.path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSegmentWAL_TruncatedAfterFlush(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.WALEnabled = true
+	cfg.WALDir = t.TempDir()
+	sw := newTestSegmentWriter(t, cfg)
+	defer sw.stop()
+	sw.client.On("AddBlock", mock.Anything, mock.Anything, mock.Anything).
+		Return(new(metastorev1.AddBlockResponse), nil)
+
+	req := walPushRequest(t, 1, cpuProfile(42, 480, "svc1", "foo", "bar"))
+	p := cpuProfile(42, 480, "svc1", "foo", "bar")
+	awaiter, err := sw.ingest(shardKey{shard: 1}, func(head segmentIngest) {
+		head.appendWAL(req)
+		head.ingest("t1", p.Profile, p.UUID, p.Labels, p.Annotations)
+	})
+	require.NoError(t, err)
+	require.NoError(t, awaiter.waitFlushed(context.Background()))
+
+	// The WAL file is removed asynchronously, after the flush.
+	path := awaiter.(*segment).wal.path
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSegmentWAL_Replay(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.WALEnabled = true
+	cfg.WALDir = t.TempDir()
+
+	// WAL left by a crashed instance.
+	w := newSegmentWAL(filepath.Join(cfg.WALDir, "1"), ulid.MustNew(ulid.Now(), rand.Reader))
+	require.NoError(t, w.append(walPushRequest(t, 1, cpuProfile(42, 480, "svc1", "foo", "bar"))))
+	require.NoError(t, w.append(walPushRequest(t, 1, cpuProfile(13, 960, "svc2", "qux", "bar"))))
+	require.NoError(t, w.close())
+
+	sw := newTestSegmentWriter(t, cfg)
+	blocks := make(chan *metastorev1.BlockMeta, 1)
+	sw.client.On("AddBlock", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			blocks <- args.Get(1).(*metastorev1.AddBlockRequest).Block
+		}).
+		Return(new(metastorev1.AddBlockResponse), nil)
+
+	require.NoError(t, sw.replayWAL(context.Background()))
+	block := <-blocks
+	assert.Equal(t, uint32(1), block.Shard)
+	var services []string
+	for _, ds := range block.Datasets {
+		if ds.Name != 0 {
+			services = append(services, block.StringTable[ds.Name])
+		}
+	}
+	assert.ElementsMatch(t, []string{"svc1", "svc2"}, services)
+
+	_, err := os.Stat(w.path)
+	require.True(t, os.IsNotExist(err))
+	// Replayed profiles are not written to the WAL again.
+	sw.stop()
+	assert.Empty(t, walFiles(t, cfg.WALDir))
+}
+
+func TestSegmentWAL_ReplayRetainedIfNotFlushed(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.WALEnabled = true
+	cfg.WALDir = t.TempDir()
+
+	w := newSegmentWAL(filepath.Join(cfg.WALDir, "1"), ulid.MustNew(ulid.Now(), rand.Reader))
+	require.NoError(t, w.append(walPushRequest(t, 1, cpuProfile(42, 480, "svc1", "foo", "bar"))))
+	require.NoError(t, w.close())
+
+	sw := newTestSegmentWriter(t, cfg)
+	defer sw.stop()
+	sw.client.On("AddBlock", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("mock add block error"))
+
+	require.NoError(t, sw.replayWAL(context.Background()))
+	_, err := os.Stat(w.path)
+	require.NoError(t, err)
+}
+
+func TestSegmentWAL_TruncatedAfterFailedFlush(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.WALEnabled = true
+	cfg.WALDir = t.TempDir()
+	sw := newTestSegmentWriter(t, cfg)
+	defer sw.stop()
+	sw.client.On("AddBlock", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("mock add block error"))
+
+	req := walPushRequest(t, 1, cpuProfile(42, 480, "svc1", "foo", "bar"))
+	p := cpuProfile(42, 480, "svc1", "foo", "bar")
+	awaiter, err := sw.ingest(shardKey{shard: 1}, func(head segmentIngest) {
+		head.appendWAL(req)
+		head.ingest("t1", p.Profile, p.UUID, p.Labels, p.Annotations)
+	})
+	require.NoError(t, err)
+	// The error is returned to the client, which retries the
+	// request: the profile must not be replayed after a restart.
+	require.Error(t, awaiter.waitFlushed(context.Background()))
+
+	path := awaiter.(*segment).wal.path
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSegmentWAL_NoFilesForEmptySegments(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.WALEnabled = true
+	cfg.WALDir = t.TempDir()
+	sw := newTestSegmentWriter(t, cfg)
+	sw.client.On("AddBlock", mock.Anything, mock.Anything, mock.Anything).
+		Return(new(metastorev1.AddBlockResponse), nil)
+
+	req := walPushRequest(t, 1, cpuProfile(42, 480, "svc1", "foo", "bar"))
+	p := cpuProfile(42, 480, "svc1", "foo", "bar")
+	awaiter, err := sw.ingest(shardKey{shard: 1}, func(head segmentIngest) {
+		head.appendWAL(req)
+		head.ingest("t1", p.Profile, p.UUID, p.Labels, p.Annotations)
+	})
+	require.NoError(t, err)
+	require.NoError(t, awaiter.waitFlushed(context.Background()))
+
+	// Segments created after the flush receive no profiles.
+	time.Sleep(3 * cfg.SegmentDuration)
+	sw.stop()
+	assert.Empty(t, walFiles(t, cfg.WALDir))
+}
+
+func walFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestShardKey_WALDir(t *testing.T) {
+	for _, sk := range []shardKey{
+		{shard: 1},
+		{shard: 1, replica: 2},
+		{shard: 0, replica: 1},
+		{shard: 1, window: 1700000005000},
+		{shard: 1, replica: 2, window: 1700000005000},
+	} {
+		parsed, err := parseShardKey(sk.String())
+		require.NoError(t, err)
+		assert.Equal(t, sk, parsed)
+	}
+	assert.Equal(t, "1", shardKey{shard: 1}.String())
+	assert.Equal(t, "1-r2", shardKey{shard: 1, replica: 2}.String())
+	assert.Equal(t, "1-r2-w5000", shardKey{shard: 1, replica: 2, window: 5000}.String())
+	for _, name := range []string{"", "x", "1-r", "1-r0", "1-rx", "-r1", "1-w", "1-w0", "1-r1-wx"} {
+		_, err := parseShardKey(name)
+		assert.Error(t, err, name)
+	}
+}
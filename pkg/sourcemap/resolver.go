@@ -0,0 +1,345 @@
+package sourcemap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+
+	googlev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+)
+
+// Resolver rewrites minified JavaScript frames of pprof profiles
+// using the source maps uploaded to the store.
+//
+// Two frame shapes are recognized:
+//   - Function names carrying the generated position, as reported by
+//     browser SDKs: "a.b (https://example.com/bundle.js:1:48213)", or
+//     "https://example.com/bundle.js:1:48213" for anonymous functions.
+//   - Functions with a JavaScript bundle file name, as reported by the
+//     Node.js SDK: only the line numbers of such frames are known, so
+//     they are mapped to the first mapping of the line.
+type Resolver struct {
+	logger log.Logger
+	bucket objstore.BucketReader
+	cfg    Config
+	cache  *expirable.LRU[string, *Map]
+
+	framesTotal *prometheus.CounterVec
+}
+
+func NewResolver(logger log.Logger, bucket objstore.BucketReader, cfg Config, reg prometheus.Registerer) *Resolver {
+	r := &Resolver{
+		logger: log.With(logger, "component", "source-maps-resolver"),
+		bucket: bucket,
+		cfg:    cfg,
+		cache:  expirable.NewLRU[string, *Map](max(cfg.CacheSize, 1), nil, cfg.CacheTTL),
+		framesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pyroscope",
+			Subsystem: "source_maps",
+			Name:      "frames_total",
+			Help:      "Total number of JavaScript frames processed by the source map resolver, by outcome.",
+		}, []string{"result"}),
+	}
+	if reg != nil {
+		reg.MustRegister(r.framesTotal)
+	}
+	return r
+}
+
+// ReleaseLabel is the series label that identifies the release
+// the source maps of a profile were uploaded for.
+func (r *Resolver) ReleaseLabel() string { return r.cfg.ReleaseLabel }
+
+// MaxReleases is the maximum number of releases a query
+// may be split into for symbolization.
+func (r *Resolver) MaxReleases() int { return r.cfg.MaxReleases }
+
+var (
+	// "name (url:line:column)"
+	namedFrameRegex = regexp.MustCompile(`^(.*) \((\S+):(\d+):(\d+)\)$`)
+	// "url:line:column"
+	anonymousFrameRegex = regexp.MustCompile(`^(\S+):(\d+):(\d+)$`)
+)
+
+// frame is a JavaScript frame in the generated (minified) code.
+type frame struct {
+	name      string
+	bundleURL string
+	line      int
+	column    int
+	// named is true if the position is encoded in the function name.
+	named bool
+}
+
+func parseFunctionName(name string) (frame, bool) {
+	var f frame
+	var m []string
+	if m = namedFrameRegex.FindStringSubmatch(name); m != nil {
+		f.name, m = m[1], m[1:]
+	} else if m = anonymousFrameRegex.FindStringSubmatch(name); m == nil {
+		return f, false
+	}
+	f.bundleURL = m[1]
+	if !isJavaScriptURL(f.bundleURL) {
+		return f, false
+	}
+	f.line, _ = strconv.Atoi(m[2])
+	f.column, _ = strconv.Atoi(m[3])
+	f.named = true
+	return f, true
+}
+
+func isJavaScriptURL(s string) bool {
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+	switch path.Ext(s) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
+// SymbolizePprof rewrites the JavaScript frames of the profile using the
+// source maps uploaded for the release, or without a release.
+func (r *Resolver) SymbolizePprof(ctx context.Context, release string, p *googlev1.Profile) error {
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	frames := make(map[uint64]frame)
+	for _, fn := range p.Function {
+		if f, ok := parseFunctionName(stringAt(p, fn.Name)); ok {
+			frames[fn.Id] = f
+			continue
+		}
+		if filename := stringAt(p, fn.Filename); isJavaScriptURL(filename) {
+			frames[fn.Id] = frame{bundleURL: filename}
+		}
+	}
+	if len(frames) == 0 {
+		return nil
+	}
+
+	maps := make(map[string]*Map)
+	for _, f := range frames {
+		if _, ok := maps[f.bundleURL]; ok {
+			continue
+		}
+		m, err := r.fetch(ctx, tenantID, release, f.bundleURL)
+		if err != nil {
+			return err
+		}
+		maps[f.bundleURL] = m
+	}
+
+	st := newStringTable(p)
+	var resolved, missed int
+
+	// Frames with the position in the function name.
+	positions := make(map[uint64]Position)
+	for id, f := range frames {
+		if !f.named {
+			continue
+		}
+		m := maps[f.bundleURL]
+		if m == nil {
+			missed++
+			continue
+		}
+		pos, ok := m.Lookup(f.line, f.column)
+		if !ok {
+			missed++
+			continue
+		}
+		positions[id] = pos
+		resolved++
+	}
+
+	// The original name of a function is not known at the position within
+	// the function, but at the call sites of the function: the name of
+	// the identifier called by the caller frame is used.
+	names := make(map[uint64]string)
+	locations := make(map[uint64]*googlev1.Location, len(p.Location))
+	for _, loc := range p.Location {
+		locations[loc.Id] = loc
+	}
+	for _, s := range p.Sample {
+		for i := 0; i+1 < len(s.LocationId); i++ {
+			callee, caller := locations[s.LocationId[i]], locations[s.LocationId[i+1]]
+			if callee == nil || caller == nil || len(callee.Line) == 0 || len(caller.Line) == 0 {
+				continue
+			}
+			calleeID := callee.Line[0].FunctionId
+			if _, ok := positions[calleeID]; !ok {
+				continue
+			}
+			if _, ok := names[calleeID]; ok {
+				continue
+			}
+			if pos, ok := positions[caller.Line[len(caller.Line)-1].FunctionId]; ok && pos.Name != "" {
+				names[calleeID] = pos.Name
+			}
+		}
+	}
+
+	for _, fn := range p.Function {
+		f, ok := frames[fn.Id]
+		if !ok || !f.named {
+			continue
+		}
+		pos, ok := positions[fn.Id]
+		if !ok {
+			continue
+		}
+		name := f.name
+		if n, ok := names[fn.Id]; ok {
+			name = n
+		}
+		location := fmt.Sprintf("%s:%d:%d", pos.Source, pos.Line, pos.Column)
+		if name != "" {
+			location = name + " (" + location + ")"
+		}
+		fn.Name = st.add(location)
+		fn.SystemName = fn.Name
+		fn.Filename = st.add(pos.Source)
+		fn.StartLine = int64(pos.Line)
+	}
+
+	// Frames with the bundle file name are mapped line by line.
+	sources := make(map[uint64]string)
+	for _, loc := range p.Location {
+		for _, line := range loc.Line {
+			f, ok := frames[line.FunctionId]
+			if !ok {
+				continue
+			}
+			if f.named {
+				if pos, ok := positions[line.FunctionId]; ok {
+					line.Line = int64(pos.Line)
+				}
+				continue
+			}
+			m := maps[f.bundleURL]
+			if m == nil {
+				missed++
+				continue
+			}
+			pos, ok := m.Lookup(int(line.Line), 0)
+			if !ok {
+				missed++
+				continue
+			}
+			line.Line = int64(pos.Line)
+			if _, ok = sources[line.FunctionId]; !ok {
+				sources[line.FunctionId] = pos.Source
+			}
+			resolved++
+		}
+	}
+	for _, fn := range p.Function {
+		if source, ok := sources[fn.Id]; ok {
+			fn.Filename = st.add(source)
+			fn.StartLine = 0
+		}
+	}
+
+	p.StringTable = st.table
+	r.framesTotal.WithLabelValues("resolved").Add(float64(resolved))
+	r.framesTotal.WithLabelValues("miss").Add(float64(missed))
+	return nil
+}
+
+// fetch returns the source map of the bundle for the release. Source maps
+// uploaded without a release apply to all releases. The function returns
+// nil if no source map is found.
+func (r *Resolver) fetch(ctx context.Context, tenantID, release, bundleURL string) (*Map, error) {
+	candidates := []string{bundleURL}
+	if i := strings.IndexAny(bundleURL, "?#"); i > 0 {
+		candidates = append(candidates, bundleURL[:i])
+	}
+	releases := []string{release}
+	if release != "" {
+		releases = append(releases, "")
+	}
+	for _, rel := range releases {
+		for _, u := range candidates {
+			m, err := r.fetchObject(ctx, ObjectPath(tenantID, rel, u))
+			if m != nil || err != nil {
+				return m, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) fetchObject(ctx context.Context, name string) (*Map, error) {
+	if m, ok := r.cache.Get(name); ok {
+		return m, nil
+	}
+	rc, err := r.bucket.Get(ctx, name)
+	if err != nil {
+		if r.bucket.IsObjNotFoundErr(err) {
+			r.cache.Add(name, nil)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetch source map from object storage: %w", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("read source map from object storage: %w", err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		// Source maps are validated on upload: treat the
+		// corrupted ones as missing rather than failing queries.
+		level.Warn(r.logger).Log("msg", "failed to parse source map", "path", name, "err", err)
+	}
+	r.cache.Add(name, m)
+	return m, nil
+}
+
+func stringAt(p *googlev1.Profile, i int64) string {
+	if i < 0 || i >= int64(len(p.StringTable)) {
+		return ""
+	}
+	return p.StringTable[i]
+}
+
+type stringTable struct {
+	table []string
+	index map[string]int64
+}
+
+func newStringTable(p *googlev1.Profile) *stringTable {
+	t := &stringTable{table: p.StringTable, index: make(map[string]int64, len(p.StringTable))}
+	for i, s := range p.StringTable {
+		if _, ok := t.index[s]; !ok {
+			t.index[s] = int64(i)
+		}
+	}
+	return t
+}
+
+func (t *stringTable) add(s string) int64 {
+	if i, ok := t.index[s]; ok {
+		return i
+	}
+	i := int64(len(t.table))
+	t.table = append(t.table, s)
+	t.index[s] = i
+	return i
+}
@@ -0,0 +1,123 @@
+package sourcemap
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	googlev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/memory"
+	"github.com/grafana/pyroscope/v2/pkg/tenant"
+)
+
+func TestParseFunctionName(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		expected frame
+		ok       bool
+	}{
+		{
+			name:     "a.b (https://example.com/static/bundle.js:1:48213)",
+			expected: frame{name: "a.b", bundleURL: "https://example.com/static/bundle.js", line: 1, column: 48213, named: true},
+			ok:       true,
+		},
+		{
+			name:     "https://example.com/bundle.min.js?v=3:12:7",
+			expected: frame{bundleURL: "https://example.com/bundle.min.js?v=3", line: 12, column: 7, named: true},
+			ok:       true,
+		},
+		{
+			name:     "new Foo (/app/dist/index.mjs:4:2)",
+			expected: frame{name: "new Foo", bundleURL: "/app/dist/index.mjs", line: 4, column: 2, named: true},
+			ok:       true,
+		},
+		{name: "main.main"},
+		{name: "foo (main.go:1:2)"},
+		{name: "handler"},
+	} {
+		f, ok := parseFunctionName(tc.name)
+		assert.Equal(t, tc.ok, ok, tc.name)
+		if ok {
+			assert.Equal(t, tc.expected, f, tc.name)
+		}
+	}
+}
+
+type testProfileBuilder struct {
+	p *googlev1.Profile
+}
+
+func newTestProfileBuilder() *testProfileBuilder {
+	return &testProfileBuilder{p: &googlev1.Profile{StringTable: []string{""}}}
+}
+
+func (b *testProfileBuilder) str(s string) int64 {
+	b.p.StringTable = append(b.p.StringTable, s)
+	return int64(len(b.p.StringTable) - 1)
+}
+
+// addFrame adds a function and a location for it, and returns the location ID.
+func (b *testProfileBuilder) addFrame(name, filename string, line int64) uint64 {
+	id := uint64(len(b.p.Function) + 1)
+	b.p.Function = append(b.p.Function, &googlev1.Function{Id: id, Name: b.str(name), Filename: b.str(filename)})
+	b.p.Location = append(b.p.Location, &googlev1.Location{Id: id, Line: []*googlev1.Line{{FunctionId: id, Line: line}}})
+	return id
+}
+
+func (b *testProfileBuilder) functionName(id uint64) string {
+	return b.p.StringTable[b.p.Function[id-1].Name]
+}
+
+func (b *testProfileBuilder) functionFilename(id uint64) string {
+	return b.p.StringTable[b.p.Function[id-1].Filename]
+}
+
+func TestResolver_SymbolizePprof(t *testing.T) {
+	ctx := tenant.InjectTenantID(context.Background(), "tenant")
+	bucket := memory.NewInMemBucket()
+	sourceMap := testSourceMap(t)
+	require.NoError(t, bucket.Upload(ctx, ObjectPath("tenant", "v1", "https://example.com/bundle.js"), bytes.NewReader(sourceMap)))
+	require.NoError(t, bucket.Upload(ctx, ObjectPath("tenant", "", "/app/dist/index.js"), bytes.NewReader(sourceMap)))
+
+	b := newTestProfileBuilder()
+	callee := b.addFrame("a.b (https://example.com/bundle.js?v=1:1:25)", "", 0)
+	caller := b.addFrame("https://example.com/bundle.js:1:10", "", 0)
+	node := b.addFrame("handler", "/app/dist/index.js", 3)
+	native := b.addFrame("main", "main.go", 42)
+	unknown := b.addFrame("x (https://example.com/other.js:1:1)", "", 0)
+	b.p.Sample = []*googlev1.Sample{
+		{LocationId: []uint64{callee, caller}, Value: []int64{1}},
+		{LocationId: []uint64{node, native}, Value: []int64{1}},
+		{LocationId: []uint64{unknown}, Value: []int64{1}},
+	}
+
+	r := NewResolver(log.NewNopLogger(), bucket, Config{CacheSize: 8, CacheTTL: time.Minute}, nil)
+	require.NoError(t, r.SymbolizePprof(ctx, "v1", b.p))
+
+	// The name of the callee is the name at the call site in the caller.
+	assert.Equal(t, "render (webpack://app/src/app.ts:5:3)", b.functionName(callee))
+	assert.Equal(t, "webpack://app/src/app.ts", b.functionFilename(callee))
+	assert.Equal(t, int64(5), b.p.Location[callee-1].Line[0].Line)
+	assert.Equal(t, "webpack://app/src/app.ts:1:10", b.functionName(caller))
+
+	// Source maps uploaded without a release apply to all releases.
+	assert.Equal(t, "handler", b.functionName(node))
+	assert.Equal(t, "webpack://app/src/util.ts", b.functionFilename(node))
+	assert.Equal(t, int64(3), b.p.Location[node-1].Line[0].Line)
+
+	assert.Equal(t, "main", b.functionName(native))
+	assert.Equal(t, int64(42), b.p.Location[native-1].Line[0].Line)
+	assert.Equal(t, "x (https://example.com/other.js:1:1)", b.functionName(unknown))
+
+	// Source maps of other releases are not used.
+	b = newTestProfileBuilder()
+	callee = b.addFrame("a.b (https://example.com/bundle.js:1:25)", "", 0)
+	b.p.Sample = []*googlev1.Sample{{LocationId: []uint64{callee}, Value: []int64{1}}}
+	require.NoError(t, r.SymbolizePprof(ctx, "v2", b.p))
+	assert.Equal(t, "a.b (https://example.com/bundle.js:1:25)", b.functionName(callee))
+}
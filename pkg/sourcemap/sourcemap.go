@@ -0,0 +1,249 @@
+// Package sourcemap implements storage and lookup of JavaScript source maps
+// (revision 3), used to translate minified JavaScript frames back to their
+// original function names and source locations.
+package sourcemap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Map is a parsed source map. Index maps (maps with sections) are
+// flattened into a single list of mappings on parse.
+type Map struct {
+	sources []string
+	names   []string
+	// lines holds the mapping segments of every generated line,
+	// sorted by the generated column.
+	lines [][]segment
+}
+
+type segment struct {
+	genColumn int32
+	source    int32 // -1 if the segment is not mapped to a source.
+	line      int32
+	column    int32
+	name      int32 // -1 if the segment has no name.
+}
+
+// Position is an original source location.
+type Position struct {
+	Source string
+	// Line and Column are 1-based, as in JavaScript stack traces.
+	Line   int
+	Column int
+	// Name is the original name of the symbol at the location, if any.
+	Name string
+}
+
+type rawMap struct {
+	Version    int          `json:"version"`
+	SourceRoot string       `json:"sourceRoot"`
+	Sources    []string     `json:"sources"`
+	Names      []string     `json:"names"`
+	Mappings   string       `json:"mappings"`
+	Sections   []rawSection `json:"sections"`
+}
+
+type rawSection struct {
+	Offset struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"offset"`
+	Map *rawMap `json:"map"`
+	URL string  `json:"url"`
+}
+
+// Parse parses a JSON source map.
+func Parse(data []byte) (*Map, error) {
+	var raw rawMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid source map: %w", err)
+	}
+	m := new(Map)
+	if err := m.add(&raw, 0, 0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Map) add(raw *rawMap, lineOffset, columnOffset int) error {
+	if raw.Version != 3 {
+		return fmt.Errorf("unsupported source map version %d", raw.Version)
+	}
+	if len(raw.Sections) > 0 {
+		for _, s := range raw.Sections {
+			if s.Map == nil {
+				// Sections referencing external maps by URL are not supported:
+				// the whole map is expected to be uploaded at once.
+				return errors.New("source map sections with url are not supported")
+			}
+			if err := m.add(s.Map, lineOffset+s.Offset.Line, columnOffset+s.Offset.Column); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sourceBase := int32(len(m.sources))
+	nameBase := int32(len(m.names))
+	for _, s := range raw.Sources {
+		m.sources = append(m.sources, joinSourceRoot(raw.SourceRoot, s))
+	}
+	m.names = append(m.names, raw.Names...)
+
+	var (
+		line   = lineOffset
+		state  [5]int32 // genColumn, source, line, column, name
+		fields [5]int32
+	)
+	state[0] = int32(columnOffset)
+	mappings := raw.Mappings
+	for len(mappings) > 0 {
+		switch mappings[0] {
+		case ';':
+			line++
+			state[0] = 0
+			mappings = mappings[1:]
+			continue
+		case ',':
+			mappings = mappings[1:]
+			continue
+		}
+		n := 0
+		for len(mappings) > 0 && mappings[0] != ',' && mappings[0] != ';' {
+			if n == len(fields) {
+				return errors.New("invalid source map mappings: too many segment fields")
+			}
+			v, rest, err := decodeVLQ(mappings)
+			if err != nil {
+				return err
+			}
+			fields[n] = v
+			n++
+			mappings = rest
+		}
+		if n != 1 && n != 4 && n != 5 {
+			return fmt.Errorf("invalid source map mappings: unexpected segment with %d fields", n)
+		}
+		seg := segment{source: -1, name: -1}
+		state[0] += fields[0]
+		seg.genColumn = state[0]
+		if n >= 4 {
+			for i := 1; i < 4; i++ {
+				state[i] += fields[i]
+			}
+			if state[1] < 0 || int(state[1]) >= len(raw.Sources) {
+				return fmt.Errorf("invalid source map mappings: source index %d out of range", state[1])
+			}
+			seg.source = sourceBase + state[1]
+			seg.line = state[2]
+			seg.column = state[3]
+		}
+		if n == 5 {
+			state[4] += fields[4]
+			if state[4] < 0 || int(state[4]) >= len(raw.Names) {
+				return fmt.Errorf("invalid source map mappings: name index %d out of range", state[4])
+			}
+			seg.name = nameBase + state[4]
+		}
+		for len(m.lines) <= line {
+			m.lines = append(m.lines, nil)
+		}
+		m.lines[line] = append(m.lines[line], seg)
+	}
+
+	for _, segments := range m.lines {
+		if !sort.SliceIsSorted(segments, func(i, j int) bool { return segments[i].genColumn < segments[j].genColumn }) {
+			sort.SliceStable(segments, func(i, j int) bool { return segments[i].genColumn < segments[j].genColumn })
+		}
+	}
+	return nil
+}
+
+func joinSourceRoot(root, source string) string {
+	if root == "" || strings.Contains(source, "://") || strings.HasPrefix(source, "/") {
+		return source
+	}
+	return strings.TrimSuffix(root, "/") + "/" + source
+}
+
+// Lookup returns the original position of the generated location.
+// Line and column are 1-based, as in JavaScript stack traces. A column
+// of 0 means the column is unknown: the first mapping of the line is
+// used in that case.
+func (m *Map) Lookup(line, column int) (Position, bool) {
+	if line < 1 || line > len(m.lines) {
+		return Position{}, false
+	}
+	segments := m.lines[line-1]
+	if len(segments) == 0 {
+		return Position{}, false
+	}
+	i := 0
+	if column > 0 {
+		// The segment with the largest generated column not exceeding
+		// the column covers the location.
+		i = sort.Search(len(segments), func(i int) bool {
+			return int(segments[i].genColumn) > column-1
+		}) - 1
+		if i < 0 {
+			return Position{}, false
+		}
+	}
+	seg := segments[i]
+	if seg.source < 0 {
+		return Position{}, false
+	}
+	p := Position{
+		Source: m.sources[seg.source],
+		Line:   int(seg.line) + 1,
+		Column: int(seg.column) + 1,
+	}
+	if seg.name >= 0 {
+		p.Name = m.names[seg.name]
+	}
+	return p, true
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+var base64Values = func() (v [256]int8) {
+	for i := range v {
+		v[i] = -1
+	}
+	for i := 0; i < len(base64Chars); i++ {
+		v[base64Chars[i]] = int8(i)
+	}
+	return v
+}()
+
+// decodeVLQ decodes a single base64 VLQ value from the beginning of s.
+func decodeVLQ(s string) (int32, string, error) {
+	var (
+		value int64
+		shift uint
+	)
+	for i := 0; i < len(s); i++ {
+		d := base64Values[s[i]]
+		if d < 0 {
+			return 0, "", fmt.Errorf("invalid source map mappings: unexpected character %q", s[i])
+		}
+		value |= int64(d&0x1f) << shift
+		if d&0x20 == 0 {
+			v := value >> 1
+			if value&1 != 0 {
+				v = -v
+			}
+			return int32(v), s[i+1:], nil
+		}
+		shift += 5
+		if shift > 31 {
+			return 0, "", errors.New("invalid source map mappings: value overflow")
+		}
+	}
+	return 0, "", errors.New("invalid source map mappings: truncated value")
+}
@@ -0,0 +1,157 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeMappings encodes the segments of every generated line. Segment
+// fields are absolute: generated column, source, line, column, and name.
+func encodeMappings(lines ...[][]int) string {
+	var b strings.Builder
+	var prev [5]int
+	for i, segments := range lines {
+		if i > 0 {
+			b.WriteByte(';')
+		}
+		prev[0] = 0
+		for j, seg := range segments {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			for k, v := range seg {
+				encodeVLQ(&b, v-prev[k])
+				prev[k] = v
+			}
+		}
+	}
+	return b.String()
+}
+
+func encodeVLQ(b *strings.Builder, v int) {
+	u := v << 1
+	if v < 0 {
+		u = -v<<1 | 1
+	}
+	for {
+		d := u & 0x1f
+		u >>= 5
+		if u > 0 {
+			d |= 0x20
+		}
+		b.WriteByte(base64Chars[d])
+		if u == 0 {
+			return
+		}
+	}
+}
+
+func testSourceMap(t testing.TB) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"version":    3,
+		"file":       "bundle.js",
+		"sourceRoot": "webpack://app/",
+		"sources":    []string{"src/app.ts", "src/util.ts"},
+		"names":      []string{"render", "compute"},
+		"mappings": encodeMappings(
+			[][]int{
+				{0, 0, 0, 0},
+				{9, 0, 0, 9, 0},
+				{20, 0, 4, 2, 1},
+				{30, 1, 9, 0},
+				{40},
+			},
+			nil,
+			[][]int{{4, 1, 2, 4}},
+		),
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func TestParse_Lookup(t *testing.T) {
+	m, err := Parse(testSourceMap(t))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		line, column int
+		expected     Position
+		ok           bool
+	}{
+		{line: 1, column: 1, expected: Position{Source: "webpack://app/src/app.ts", Line: 1, Column: 1}, ok: true},
+		{line: 1, column: 10, expected: Position{Source: "webpack://app/src/app.ts", Line: 1, Column: 10, Name: "render"}, ok: true},
+		{line: 1, column: 25, expected: Position{Source: "webpack://app/src/app.ts", Line: 5, Column: 3, Name: "compute"}, ok: true},
+		{line: 1, column: 31, expected: Position{Source: "webpack://app/src/util.ts", Line: 10, Column: 1}, ok: true},
+		// Unmapped segment.
+		{line: 1, column: 45},
+		// Unknown column: the first mapping of the line.
+		{line: 1, column: 0, expected: Position{Source: "webpack://app/src/app.ts", Line: 1, Column: 1}, ok: true},
+		{line: 2, column: 1},
+		{line: 3, column: 2},
+		{line: 3, column: 5, expected: Position{Source: "webpack://app/src/util.ts", Line: 3, Column: 5}, ok: true},
+		{line: 4, column: 1},
+		{line: 0, column: 1},
+	} {
+		p, ok := m.Lookup(tc.line, tc.column)
+		assert.Equal(t, tc.ok, ok, "%d:%d", tc.line, tc.column)
+		assert.Equal(t, tc.expected, p, "%d:%d", tc.line, tc.column)
+	}
+}
+
+func TestParse_Sections(t *testing.T) {
+	section := func(source string) map[string]any {
+		return map[string]any{
+			"version":  3,
+			"sources":  []string{source},
+			"names":    []string{"fn"},
+			"mappings": encodeMappings([][]int{{0, 0, 0, 0, 0}}, [][]int{{2, 0, 1, 0}}),
+		}
+	}
+	data, err := json.Marshal(map[string]any{
+		"version": 3,
+		"sections": []any{
+			map[string]any{"offset": map[string]int{"line": 0, "column": 0}, "map": section("a.ts")},
+			map[string]any{"offset": map[string]int{"line": 1, "column": 10}, "map": section("b.ts")},
+		},
+	})
+	require.NoError(t, err)
+	m, err := Parse(data)
+	require.NoError(t, err)
+
+	p, ok := m.Lookup(1, 1)
+	require.True(t, ok)
+	assert.Equal(t, Position{Source: "a.ts", Line: 1, Column: 1, Name: "fn"}, p)
+
+	// The second line of the first section and the first line
+	// of the second section, shifted by the column offset.
+	p, ok = m.Lookup(2, 5)
+	require.True(t, ok)
+	assert.Equal(t, Position{Source: "a.ts", Line: 2, Column: 1}, p)
+	p, ok = m.Lookup(2, 11)
+	require.True(t, ok)
+	assert.Equal(t, Position{Source: "b.ts", Line: 1, Column: 1, Name: "fn"}, p)
+	p, ok = m.Lookup(3, 3)
+	require.True(t, ok)
+	assert.Equal(t, Position{Source: "b.ts", Line: 2, Column: 1}, p)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`not json`,
+		`{"version":2,"sources":[],"mappings":""}`,
+		`{"version":3,"sources":["a.js"],"mappings":"AAAA,!"}`,
+		`{"version":3,"sources":["a.js"],"mappings":"AA"}`,
+		`{"version":3,"sources":["a.js"],"mappings":"ACAA"}`,
+		`{"version":3,"sources":["a.js"],"names":[],"mappings":"AAAAA"}`,
+		`{"version":3,"sources":["a.js"],"mappings":"g"}`,
+		`{"version":3,"sections":[{"offset":{"line":0,"column":0},"url":"a.js.map"}]}`,
+	} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
@@ -0,0 +1,281 @@
+package sourcemap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/pyroscope/v2/pkg/tenant"
+)
+
+type Config struct {
+	Enabled       bool          `yaml:"-" category:"experimental"`
+	MaxUploadSize int64         `yaml:"-" category:"experimental"`
+	ReleaseLabel  string        `yaml:"-" category:"experimental"`
+	MaxReleases   int           `yaml:"-" category:"experimental"`
+	CacheSize     int           `yaml:"-" category:"experimental"`
+	CacheTTL      time.Duration `yaml:"-" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "source-maps.enabled", false, "Enable JavaScript source map uploads and symbolization of minified JavaScript frames.")
+	f.Int64Var(&cfg.MaxUploadSize, "source-maps.max-upload-size", 64*1024*1024, "Maximum size of a single source map upload in bytes.")
+	f.StringVar(&cfg.ReleaseLabel, "source-maps.release-label", "service_version", "Series label that identifies the release the source maps are uploaded for. Only profiles carrying the label are symbolized: the segment writer records the releases in the block metadata.")
+	f.IntVar(&cfg.MaxReleases, "source-maps.max-releases", 10, "Maximum number of distinct releases a query is split into for symbolization. Queries matching more releases are symbolized with the source maps uploaded without a release.")
+	f.IntVar(&cfg.CacheSize, "source-maps.cache-size", 256, "Maximum number of parsed source maps kept in memory.")
+	f.DurationVar(&cfg.CacheTTL, "source-maps.cache-ttl", 5*time.Minute, "How long parsed source maps, and the absence of source maps, are cached.")
+}
+
+const (
+	bucketPrefix = "source-maps"
+
+	listFetchConcurrency = 32
+)
+
+// Metadata describes an uploaded source map.
+type Metadata struct {
+	BundleURL  string    `json:"bundle_url"`
+	Release    string    `json:"release,omitempty"`
+	SizeBytes  int64     `json:"size_bytes"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+type Store struct {
+	logger log.Logger
+	bucket objstore.Bucket
+	cfg    Config
+}
+
+// NewStore returns a new source map store.
+func NewStore(logger log.Logger, bucket objstore.Bucket, cfg Config) (*Store, error) {
+	if cfg.Enabled && bucket == nil {
+		return nil, errors.New("enabled source maps require a bucket")
+	}
+	return &Store{
+		logger: log.With(logger, "component", "source-maps"),
+		bucket: bucket,
+		cfg:    cfg,
+	}, nil
+}
+
+// UploadHTTPHandler stores the source map sent in the request body for
+// the bundle_url and (optional) release query parameters.
+func (s *Store) UploadHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		tenantID, ok := s.checkRequest(w, r)
+		if !ok {
+			return
+		}
+		bundleURL := r.URL.Query().Get("bundle_url")
+		if bundleURL == "" {
+			http.Error(w, "bundle_url is required", http.StatusBadRequest)
+			return
+		}
+		release := r.URL.Query().Get("release")
+
+		if s.cfg.MaxUploadSize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxUploadSize)
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "source map is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, fmt.Sprintf("failed to read source map: %v", err), http.StatusBadRequest)
+			return
+		}
+		if _, err = Parse(data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		l := log.With(s.logger, "bundle_url", bundleURL, "release", release)
+		if err = s.bucket.Upload(ctx, ObjectPath(tenantID, release, bundleURL), bytes.NewReader(data)); err != nil {
+			_ = level.Error(l).Log("msg", "failed to upload source map", "err", err)
+			http.Error(w, "upload failed", http.StatusInternalServerError)
+			return
+		}
+		md, err := json.Marshal(&Metadata{
+			BundleURL:  bundleURL,
+			Release:    release,
+			SizeBytes:  int64(len(data)),
+			UploadedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if err = s.bucket.Upload(ctx, MetadataObjectPath(tenantID, release, bundleURL), bytes.NewReader(md)); err != nil {
+			_ = level.Error(l).Log("msg", "failed to upload source map metadata", "err", err)
+			http.Error(w, "upload failed", http.StatusInternalServerError)
+			return
+		}
+
+		_ = level.Debug(l).Log("msg", "source map upload completed")
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// ListHTTPHandler lists the uploaded source maps, optionally
+// filtered by the release query parameter.
+func (s *Store) ListHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := s.checkRequest(w, r)
+		if !ok {
+			return
+		}
+		var release *string
+		if r.URL.Query().Has("release") {
+			v := r.URL.Query().Get("release")
+			release = &v
+		}
+		list, err := s.list(r.Context(), tenantID, release)
+		if err != nil {
+			_ = level.Error(s.logger).Log("msg", "failed to list source maps", "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			SourceMaps []*Metadata `json:"source_maps"`
+		}{SourceMaps: list})
+	})
+}
+
+// DeleteHTTPHandler deletes the source map of the bundle_url and
+// (optional) release query parameters.
+func (s *Store) DeleteHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := s.checkRequest(w, r)
+		if !ok {
+			return
+		}
+		bundleURL := r.URL.Query().Get("bundle_url")
+		if bundleURL == "" {
+			http.Error(w, "bundle_url is required", http.StatusBadRequest)
+			return
+		}
+		release := r.URL.Query().Get("release")
+		for _, objectPath := range []string{
+			MetadataObjectPath(tenantID, release, bundleURL),
+			ObjectPath(tenantID, release, bundleURL),
+		} {
+			if err := s.bucket.Delete(r.Context(), objectPath); err != nil && !s.bucket.IsObjNotFoundErr(err) {
+				_ = level.Error(s.logger).Log("msg", "failed to delete source map", "path", objectPath, "err", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func (s *Store) checkRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenantID, err := tenant.ExtractTenantIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	if !s.cfg.Enabled {
+		http.Error(w, "source maps are disabled", http.StatusNotFound)
+		return "", false
+	}
+	return tenantID, true
+}
+
+func (s *Store) list(ctx context.Context, tenantID string, release *string) ([]*Metadata, error) {
+	var keys []string
+	err := s.bucket.Iter(ctx, path.Join(bucketPrefix, tenantID)+objstore.DirDelim, func(name string) error {
+		if strings.HasSuffix(name, objstore.DirDelim) {
+			keys = append(keys, path.Base(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	list := make([]*Metadata, 0, len(keys))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(listFetchConcurrency)
+	for _, key := range keys {
+		g.Go(func() error {
+			md, err := fetchMetadata(gctx, s.bucket, path.Join(bucketPrefix, tenantID, key, "metadata"))
+			if err != nil || md == nil {
+				return err
+			}
+			if release != nil && md.Release != *release {
+				return nil
+			}
+			mu.Lock()
+			list = append(list, md)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Release != list[j].Release {
+			return list[i].Release < list[j].Release
+		}
+		return list[i].BundleURL < list[j].BundleURL
+	})
+	return list, nil
+}
+
+func fetchMetadata(ctx context.Context, bucket objstore.BucketReader, name string) (*Metadata, error) {
+	r, err := bucket.Get(ctx, name)
+	if err != nil {
+		if bucket.IsObjNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetch source map metadata from object storage: %w", err)
+	}
+	defer r.Close()
+	var md Metadata
+	if err = json.NewDecoder(r).Decode(&md); err != nil {
+		return nil, fmt.Errorf("unmarshal source map metadata: %w", err)
+	}
+	return &md, nil
+}
+
+// objectKey identifies a source map within the tenant. Bundle URLs and
+// release names are arbitrary strings, therefore they are hashed.
+func objectKey(release, bundleURL string) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, release)
+	_, _ = h.Write([]byte{0})
+	_, _ = io.WriteString(h, bundleURL)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func ObjectPath(tenantID, release, bundleURL string) string {
+	return path.Join(bucketPrefix, tenantID, objectKey(release, bundleURL), "map")
+}
+
+func MetadataObjectPath(tenantID, release, bundleURL string) string {
+	return path.Join(bucketPrefix, tenantID, objectKey(release, bundleURL), "metadata")
+}
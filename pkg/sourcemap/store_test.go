@@ -0,0 +1,94 @@
+package sourcemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/pyroscope/v2/pkg/objstore/providers/memory"
+	"github.com/grafana/pyroscope/v2/pkg/tenant"
+)
+
+func doRequest(t *testing.T, h http.Handler, method string, params url.Values, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/v1/sourcemaps?"+params.Encode(), bytes.NewReader(body))
+	req = req.WithContext(tenant.InjectTenantID(context.Background(), "tenant"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func listSourceMaps(t *testing.T, s *Store, params url.Values) []*Metadata {
+	t.Helper()
+	w := doRequest(t, s.ListHTTPHandler(), http.MethodGet, params, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		SourceMaps []*Metadata `json:"source_maps"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp.SourceMaps
+}
+
+func TestStore(t *testing.T) {
+	bucket := memory.NewInMemBucket()
+	s, err := NewStore(log.NewNopLogger(), bucket, Config{Enabled: true, MaxUploadSize: 1 << 20})
+	require.NoError(t, err)
+	sourceMap := testSourceMap(t)
+
+	for _, params := range []url.Values{
+		{"bundle_url": {"https://example.com/bundle.js"}, "release": {"v1"}},
+		{"bundle_url": {"https://example.com/bundle.js"}, "release": {"v2"}},
+		{"bundle_url": {"https://example.com/vendor.js"}},
+	} {
+		w := doRequest(t, s.UploadHTTPHandler(), http.MethodPost, params, sourceMap)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	exists, err := bucket.Exists(context.Background(), ObjectPath("tenant", "v1", "https://example.com/bundle.js"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	list := listSourceMaps(t, s, nil)
+	require.Len(t, list, 3)
+	assert.Equal(t, "", list[0].Release)
+	assert.Equal(t, "https://example.com/vendor.js", list[0].BundleURL)
+	assert.Equal(t, "v1", list[1].Release)
+	assert.Equal(t, int64(len(sourceMap)), list[1].SizeBytes)
+	assert.Equal(t, "v2", list[2].Release)
+
+	list = listSourceMaps(t, s, url.Values{"release": {"v2"}})
+	require.Len(t, list, 1)
+	assert.Equal(t, "https://example.com/bundle.js", list[0].BundleURL)
+
+	w := doRequest(t, s.DeleteHTTPHandler(), http.MethodDelete, url.Values{"bundle_url": {"https://example.com/bundle.js"}, "release": {"v2"}}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, listSourceMaps(t, s, nil), 2)
+}
+
+func TestStore_UploadValidation(t *testing.T) {
+	s, err := NewStore(log.NewNopLogger(), memory.NewInMemBucket(), Config{Enabled: true, MaxUploadSize: 64})
+	require.NoError(t, err)
+
+	w := doRequest(t, s.UploadHTTPHandler(), http.MethodPost, url.Values{}, testSourceMap(t))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	params := url.Values{"bundle_url": {"https://example.com/bundle.js"}}
+	w = doRequest(t, s.UploadHTTPHandler(), http.MethodPost, params, []byte(`{"version":3,"mappings":"!"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(t, s.UploadHTTPHandler(), http.MethodPost, params, testSourceMap(t))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	disabled, err := NewStore(log.NewNopLogger(), nil, Config{})
+	require.NoError(t, err)
+	w = doRequest(t, disabled.UploadHTTPHandler(), http.MethodPost, params, testSourceMap(t))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/dgraph-io/ristretto/v2"
 
+	"github.com/grafana/pyroscope/v2/pkg/debuginfo"
 	"github.com/grafana/pyroscope/v2/pkg/util/build"
 )
 
@@ -431,6 +433,9 @@ func isRetryableError(err error) bool {
 // sanitizeBuildID ensures that the buildID is a safe and valid string for use in file paths.
 // It validates that the build ID contains only alphanumeric characters, underscores, and hyphens.
 // This prevents potential security issues like path traversal attacks.
+//
+// Mach-O UUIDs and PE debug IDs are case-insensitive: they are normalized to lowercase,
+// so that the same binary always maps to the same cache and storage keys.
 func sanitizeBuildID(buildID string) (string, error) {
 	if buildID == "" {
 		return "", nil
@@ -440,5 +445,8 @@ func sanitizeBuildID(buildID string) (string, error) {
 	if !validBuildID.MatchString(buildID) {
 		return "", invalidBuildIDError{buildID: buildID}
 	}
+	if debuginfo.BuildIDTypeOf(buildID) != debuginfo.BuildIDTypeGNU {
+		return strings.ToLower(buildID), nil
+	}
 	return buildID, nil
 }
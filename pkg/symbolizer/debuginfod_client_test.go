@@ -295,6 +295,18 @@ func TestSanitizeBuildID(t *testing.T) {
 			expected:    "abcdef-1234_7890",
 			expectError: false,
 		},
+		{
+			name:        "mach-o uuid is lowercased",
+			buildID:     "2CFDDACB-28B2-34D7-9958-B93F1E318D67",
+			expected:    "2cfddacb-28b2-34d7-9958-b93f1e318d67",
+			expectError: false,
+		},
+		{
+			name:        "pe debug id is lowercased",
+			buildID:     "12345678-1234-5678-0102-030405060708-A",
+			expected:    "12345678-1234-5678-0102-030405060708-a",
+			expectError: false,
+		},
 		{
 			name:        "invalid build ID with slashes",
 			buildID:     "abcdef/1234",
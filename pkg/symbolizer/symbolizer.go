@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"debug/elf"
+	"debug/macho"
+	"debug/pe"
 	"errors"
 	"flag"
 	"fmt"
@@ -39,6 +41,9 @@ type DebuginfodClient interface {
 // ctx is done (context.Canceled or context.DeadlineExceeded), in which case
 // result is nil; any other failure degrades to unresolved slots.
 // A buildID that is empty or fails validation resolves nothing: every result slot is nil.
+// The build ID type determines the format of the debug info: GNU build IDs refer to ELF
+// files, UUIDs to Mach-O files, and debug IDs to PE or PDB files, for which addresses
+// are relative to the image base.
 type Resolver interface {
 	Resolve(ctx context.Context, buildID, binaryName string, addrs []uint64) ([][]lidia.SourceInfoFrame, error)
 }
@@ -56,10 +61,12 @@ func (s *Symbolizer) Resolve(ctx context.Context, buildID, binaryName string, ad
 		return make([][]lidia.SourceInfoFrame, len(addrs)), nil
 	}
 
-	if _, err := sanitizeBuildID(buildID); err != nil {
+	sanitizedBuildID, err := sanitizeBuildID(buildID)
+	if err != nil {
 		s.metrics.debugSymbolResolutionErrors.WithLabelValues("invalid_build_id").Inc()
 		return make([][]lidia.SourceInfoFrame, len(addrs)), nil
 	}
+	buildID = sanitizedBuildID
 
 	lidiaBytes, err := s.getLidiaBytes(ctx, buildID)
 	// Whatever the fetch outcome, only this caller's own context ends the
@@ -544,7 +551,7 @@ func (s *Symbolizer) fetchLidiaFromDebuginfod(ctx context.Context, buildID strin
 		return nil, fmt.Errorf("read debuginfo data: %w", err)
 	}
 
-	lidiaData, err := s.processELFData(elfData, maxSize)
+	lidiaData, err := s.processDebugInfo(elfData, buildID, maxSize)
 	if err != nil {
 		return nil, err
 	}
@@ -556,6 +563,11 @@ func (s *Symbolizer) fetch(ctx context.Context, buildID string) (io.ReadCloser,
 	if r, err := s.fetchFromUploadedDebugInfo(ctx, buildID); err == nil {
 		return r, nil
 	}
+	// debuginfod only serves ELF files: Mach-O and PE
+	// symbols are only available if they were uploaded.
+	if debuginfo.BuildIDTypeOf(buildID) != debuginfo.BuildIDTypeGNU {
+		return nil, buildIDNotFoundError{buildID: buildID}
+	}
 	return s.fetchFromDebuginfod(ctx, buildID)
 }
 
@@ -564,7 +576,7 @@ func (s *Symbolizer) fetchFromUploadedDebugInfo(ctx context.Context, buildID str
 	if err != nil {
 		return nil, err
 	}
-	validatedBuildID, err := debuginfo.ValidateGnuBuildID(buildID)
+	validatedBuildID, err := debuginfo.ValidateBuildID(buildID)
 	if err != nil {
 		return nil, err
 	}
@@ -587,6 +599,98 @@ func (s *Symbolizer) fetchFromDebuginfod(ctx context.Context, buildID string) (i
 	return debugReader, nil
 }
 
+// processDebugInfo converts the debug info to the Lidia format. The object
+// file format is chosen based on the build ID type: ELF for GNU build IDs,
+// Mach-O for UUIDs, and PE or PDB for PE debug IDs.
+func (s *Symbolizer) processDebugInfo(data []byte, buildID string, maxSize int64) ([]byte, error) {
+	switch debuginfo.BuildIDTypeOf(buildID) {
+	case debuginfo.BuildIDTypeMachO:
+		return s.processMachOData(data, buildID, maxSize)
+	case debuginfo.BuildIDTypePE:
+		return s.processPEData(data, maxSize)
+	default:
+		return s.processELFData(data, maxSize)
+	}
+}
+
+func (s *Symbolizer) processMachOData(data []byte, uuid string, maxSize int64) ([]byte, error) {
+	decompressedData, err := detectCompression(data, maxSize)
+	if err != nil {
+		s.metrics.debugSymbolResolutionErrors.WithLabelValues("compression_error").Inc()
+		return nil, fmt.Errorf("detect compression: %w", err)
+	}
+
+	reader := bytes.NewReader(decompressedData)
+	format, err := lidia.DetectObjectFormat(reader)
+	if err != nil {
+		return nil, fmt.Errorf("detect object format: %w", err)
+	}
+
+	var machoFile *macho.File
+	switch format {
+	case lidia.ObjectFormatMachO:
+		machoFile, err = macho.NewFile(reader)
+	case lidia.ObjectFormatMachOFat:
+		// Universal binaries include files for multiple architectures:
+		// the one that matches the mapping is selected by its UUID.
+		var fatFile *macho.FatFile
+		if fatFile, err = macho.NewFatFile(reader); err == nil {
+			defer fatFile.Close()
+			machoFile, err = lidia.OpenMachOFatArch(fatFile, uuid)
+		}
+	default:
+		err = fmt.Errorf("unexpected object format: %s", format)
+	}
+	if err != nil {
+		s.metrics.debugSymbolResolutionErrors.WithLabelValues("macho_parsing_error").Inc()
+		return nil, fmt.Errorf("parse Mach-O file: %w", err)
+	}
+
+	memBuffer := newMemoryBuffer(len(data) * 2)
+	if err = lidia.CreateLidiaFromMachO(machoFile, memBuffer, lidia.WithCRC(), lidia.WithFiles(), lidia.WithLines()); err != nil {
+		return nil, fmt.Errorf("create lidia file: %w", err)
+	}
+
+	return memBuffer.Bytes(), nil
+}
+
+// processPEData converts PE executables and PDB files. The addresses
+// of the resulting table are relative to the image base.
+func (s *Symbolizer) processPEData(data []byte, maxSize int64) ([]byte, error) {
+	decompressedData, err := detectCompression(data, maxSize)
+	if err != nil {
+		s.metrics.debugSymbolResolutionErrors.WithLabelValues("compression_error").Inc()
+		return nil, fmt.Errorf("detect compression: %w", err)
+	}
+
+	reader := bytes.NewReader(decompressedData)
+	format, err := lidia.DetectObjectFormat(reader)
+	if err != nil {
+		return nil, fmt.Errorf("detect object format: %w", err)
+	}
+
+	memBuffer := newMemoryBuffer(len(data) * 2)
+	opts := []lidia.Option{lidia.WithCRC(), lidia.WithFiles(), lidia.WithLines()}
+	switch format {
+	case lidia.ObjectFormatPE:
+		var peFile *pe.File
+		if peFile, err = pe.NewFile(reader); err == nil {
+			defer peFile.Close()
+			err = lidia.CreateLidiaFromPE(peFile, memBuffer, opts...)
+		}
+	case lidia.ObjectFormatPDB:
+		err = lidia.CreateLidiaFromPDB(reader, memBuffer, opts...)
+	default:
+		err = fmt.Errorf("unexpected object format: %s", format)
+	}
+	if err != nil {
+		s.metrics.debugSymbolResolutionErrors.WithLabelValues("pe_parsing_error").Inc()
+		return nil, fmt.Errorf("create lidia file: %w", err)
+	}
+
+	return memBuffer.Bytes(), nil
+}
+
 func (s *Symbolizer) processELFData(data []byte, maxSize int64) (lidiaData []byte, err error) {
 	decompressedData, err := detectCompression(data, maxSize)
 	if err != nil {
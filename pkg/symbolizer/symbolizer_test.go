@@ -18,6 +18,7 @@ import (
 
 	googlev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	"github.com/grafana/pyroscope/lidia"
+	"github.com/grafana/pyroscope/v2/pkg/debuginfo"
 	"github.com/grafana/pyroscope/v2/pkg/model"
 	"github.com/grafana/pyroscope/v2/pkg/tenant"
 	"github.com/grafana/pyroscope/v2/pkg/test/mocks/mockobjstore"
@@ -110,6 +111,38 @@ func TestResolveCanceledProbeDoesNotFallThrough(t *testing.T) {
 		testutil.ToFloat64(s.metrics.cacheOperations.WithLabelValues("object_storage", "get", "error")))
 }
 
+// Mach-O and PE symbols are only available if they were uploaded:
+// debuginfod only serves ELF files.
+func TestResolveNonELFBuildIDs(t *testing.T) {
+	const (
+		uuid    = "2cfddacb-28b2-34d7-9958-b93f1e318d67"
+		debugID = "12345678-1234-5678-0102-030405060708-1"
+	)
+	s, _, bucket := newSymbolizerTest(t, nil)
+	ctx := tenant.InjectTenantID(context.Background(), "tenant")
+
+	uuidID, err := debuginfo.ValidateBuildID(uuid)
+	require.NoError(t, err)
+	bucket.On("Get", mock.Anything, lidiaObjectPath("tenant", uuid)).Return(nil, errBucketObjectNotFound).Once()
+	bucket.On("Get", mock.Anything, debuginfo.ObjectPath("tenant", uuidID)).Return(nil, errBucketObjectNotFound).Once()
+
+	frames, err := s.Resolve(ctx, strings.ToUpper(uuid), "binary", []uint64{0x1500})
+	require.NoError(t, err)
+	require.Equal(t, [][]lidia.SourceInfoFrame{nil}, frames)
+
+	peID, err := debuginfo.ValidateBuildID(debugID)
+	require.NoError(t, err)
+	bucket.On("Get", mock.Anything, lidiaObjectPath("tenant", debugID)).Return(nil, errBucketObjectNotFound).Once()
+	bucket.On("Get", mock.Anything, debuginfo.ObjectPath("tenant", peID)).
+		Return(io.NopCloser(strings.NewReader("not a PE file")), nil).Once()
+
+	frames, err = s.Resolve(ctx, debugID, "binary.dll", []uint64{0x1500})
+	require.NoError(t, err)
+	require.Equal(t, [][]lidia.SourceInfoFrame{nil}, frames)
+	require.Equal(t, float64(1),
+		testutil.ToFloat64(s.metrics.debugSymbolResolutionErrors.WithLabelValues("pe_parsing_error")))
+}
+
 // TestSymbolizePprof tests symbolization using testdata/symbols.debug which contains:
 //
 // 0x1500 -> (contains both functions)
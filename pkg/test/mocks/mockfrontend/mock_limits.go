@@ -343,6 +343,52 @@ func (_c *MockLimits_QueryAnalysisEnabled_Call) RunAndReturn(run func(string) bo
 	return _c
 }
 
+// QueryDownsampledMinRange provides a mock function with given fields: _a0
+func (_m *MockLimits) QueryDownsampledMinRange(_a0 string) time.Duration {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryDownsampledMinRange")
+	}
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(string) time.Duration); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	return r0
+}
+
+// MockLimits_QueryDownsampledMinRange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueryDownsampledMinRange'
+type MockLimits_QueryDownsampledMinRange_Call struct {
+	*mock.Call
+}
+
+// QueryDownsampledMinRange is a helper method to define mock.On call
+//   - _a0 string
+func (_e *MockLimits_Expecter) QueryDownsampledMinRange(_a0 interface{}) *MockLimits_QueryDownsampledMinRange_Call {
+	return &MockLimits_QueryDownsampledMinRange_Call{Call: _e.mock.On("QueryDownsampledMinRange", _a0)}
+}
+
+func (_c *MockLimits_QueryDownsampledMinRange_Call) Run(run func(_a0 string)) *MockLimits_QueryDownsampledMinRange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockLimits_QueryDownsampledMinRange_Call) Return(_a0 time.Duration) *MockLimits_QueryDownsampledMinRange_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockLimits_QueryDownsampledMinRange_Call) RunAndReturn(run func(string) time.Duration) *MockLimits_QueryDownsampledMinRange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // QuerySanitizeOnMerge provides a mock function with given fields: _a0
 func (_m *MockLimits) QuerySanitizeOnMerge(_a0 string) bool {
 	ret := _m.Called(_a0)
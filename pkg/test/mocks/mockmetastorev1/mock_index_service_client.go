@@ -97,6 +97,80 @@ func (_c *MockIndexServiceClient_AddBlock_Call) RunAndReturn(run func(context.Co
 	return _c
 }
 
+// AddRecoveredBlock provides a mock function with given fields: ctx, in, opts
+func (_m *MockIndexServiceClient) AddRecoveredBlock(ctx context.Context, in *metastorev1.AddBlockRequest, opts ...grpc.CallOption) (*metastorev1.AddBlockResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddRecoveredBlock")
+	}
+
+	var r0 *metastorev1.AddBlockResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *metastorev1.AddBlockRequest, ...grpc.CallOption) (*metastorev1.AddBlockResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *metastorev1.AddBlockRequest, ...grpc.CallOption) *metastorev1.AddBlockResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*metastorev1.AddBlockResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *metastorev1.AddBlockRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockIndexServiceClient_AddRecoveredBlock_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddRecoveredBlock'
+type MockIndexServiceClient_AddRecoveredBlock_Call struct {
+	*mock.Call
+}
+
+// AddRecoveredBlock is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *metastorev1.AddBlockRequest
+//   - opts ...grpc.CallOption
+func (_e *MockIndexServiceClient_Expecter) AddRecoveredBlock(ctx interface{}, in interface{}, opts ...interface{}) *MockIndexServiceClient_AddRecoveredBlock_Call {
+	return &MockIndexServiceClient_AddRecoveredBlock_Call{Call: _e.mock.On("AddRecoveredBlock",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *MockIndexServiceClient_AddRecoveredBlock_Call) Run(run func(ctx context.Context, in *metastorev1.AddBlockRequest, opts ...grpc.CallOption)) *MockIndexServiceClient_AddRecoveredBlock_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*metastorev1.AddBlockRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockIndexServiceClient_AddRecoveredBlock_Call) Return(_a0 *metastorev1.AddBlockResponse, _a1 error) *MockIndexServiceClient_AddRecoveredBlock_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockIndexServiceClient_AddRecoveredBlock_Call) RunAndReturn(run func(context.Context, *metastorev1.AddBlockRequest, ...grpc.CallOption) (*metastorev1.AddBlockResponse, error)) *MockIndexServiceClient_AddRecoveredBlock_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetBlockMetadata provides a mock function with given fields: ctx, in, opts
 func (_m *MockIndexServiceClient) GetBlockMetadata(ctx context.Context, in *metastorev1.GetBlockMetadataRequest, opts ...grpc.CallOption) (*metastorev1.GetBlockMetadataResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -23,6 +23,80 @@ func (_m *MockTenantServiceClient) EXPECT() *MockTenantServiceClient_Expecter {
 	return &MockTenantServiceClient_Expecter{mock: &_m.Mock}
 }
 
+// DeleteSeries provides a mock function with given fields: ctx, in, opts
+func (_m *MockTenantServiceClient) DeleteSeries(ctx context.Context, in *metastorev1.DeleteSeriesRequest, opts ...grpc.CallOption) (*metastorev1.DeleteSeriesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSeries")
+	}
+
+	var r0 *metastorev1.DeleteSeriesResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *metastorev1.DeleteSeriesRequest, ...grpc.CallOption) (*metastorev1.DeleteSeriesResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *metastorev1.DeleteSeriesRequest, ...grpc.CallOption) *metastorev1.DeleteSeriesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*metastorev1.DeleteSeriesResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *metastorev1.DeleteSeriesRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTenantServiceClient_DeleteSeries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSeries'
+type MockTenantServiceClient_DeleteSeries_Call struct {
+	*mock.Call
+}
+
+// DeleteSeries is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *metastorev1.DeleteSeriesRequest
+//   - opts ...grpc.CallOption
+func (_e *MockTenantServiceClient_Expecter) DeleteSeries(ctx interface{}, in interface{}, opts ...interface{}) *MockTenantServiceClient_DeleteSeries_Call {
+	return &MockTenantServiceClient_DeleteSeries_Call{Call: _e.mock.On("DeleteSeries",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *MockTenantServiceClient_DeleteSeries_Call) Run(run func(ctx context.Context, in *metastorev1.DeleteSeriesRequest, opts ...grpc.CallOption)) *MockTenantServiceClient_DeleteSeries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*metastorev1.DeleteSeriesRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockTenantServiceClient_DeleteSeries_Call) Return(_a0 *metastorev1.DeleteSeriesResponse, _a1 error) *MockTenantServiceClient_DeleteSeries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTenantServiceClient_DeleteSeries_Call) RunAndReturn(run func(context.Context, *metastorev1.DeleteSeriesRequest, ...grpc.CallOption) (*metastorev1.DeleteSeriesResponse, error)) *MockTenantServiceClient_DeleteSeries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteTenant provides a mock function with given fields: ctx, in, opts
 func (_m *MockTenantServiceClient) DeleteTenant(ctx context.Context, in *metastorev1.DeleteTenantRequest, opts ...grpc.CallOption) (*metastorev1.DeleteTenantResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -380,6 +380,65 @@ func (_c *MockQuerierServiceClient_LabelValues_Call) RunAndReturn(run func(conte
 	return _c
 }
 
+// LabelValuesCardinality provides a mock function with given fields: _a0, _a1
+func (_m *MockQuerierServiceClient) LabelValuesCardinality(_a0 context.Context, _a1 *connect.Request[querierv1.LabelValuesCardinalityRequest]) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LabelValuesCardinality")
+	}
+
+	var r0 *connect.Response[querierv1.LabelValuesCardinalityResponse]
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *connect.Request[querierv1.LabelValuesCardinalityRequest]) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *connect.Request[querierv1.LabelValuesCardinalityRequest]) *connect.Response[querierv1.LabelValuesCardinalityResponse]); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*connect.Response[querierv1.LabelValuesCardinalityResponse])
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *connect.Request[querierv1.LabelValuesCardinalityRequest]) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockQuerierServiceClient_LabelValuesCardinality_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LabelValuesCardinality'
+type MockQuerierServiceClient_LabelValuesCardinality_Call struct {
+	*mock.Call
+}
+
+// LabelValuesCardinality is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *connect.Request[querierv1.LabelValuesCardinalityRequest]
+func (_e *MockQuerierServiceClient_Expecter) LabelValuesCardinality(_a0 interface{}, _a1 interface{}) *MockQuerierServiceClient_LabelValuesCardinality_Call {
+	return &MockQuerierServiceClient_LabelValuesCardinality_Call{Call: _e.mock.On("LabelValuesCardinality", _a0, _a1)}
+}
+
+func (_c *MockQuerierServiceClient_LabelValuesCardinality_Call) Run(run func(_a0 context.Context, _a1 *connect.Request[querierv1.LabelValuesCardinalityRequest])) *MockQuerierServiceClient_LabelValuesCardinality_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*connect.Request[querierv1.LabelValuesCardinalityRequest]))
+	})
+	return _c
+}
+
+func (_c *MockQuerierServiceClient_LabelValuesCardinality_Call) Return(_a0 *connect.Response[querierv1.LabelValuesCardinalityResponse], _a1 error) *MockQuerierServiceClient_LabelValuesCardinality_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockQuerierServiceClient_LabelValuesCardinality_Call) RunAndReturn(run func(context.Context, *connect.Request[querierv1.LabelValuesCardinalityRequest]) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error)) *MockQuerierServiceClient_LabelValuesCardinality_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ProfileTypes provides a mock function with given fields: _a0, _a1
 func (_m *MockQuerierServiceClient) ProfileTypes(_a0 context.Context, _a1 *connect.Request[querierv1.ProfileTypesRequest]) (*connect.Response[querierv1.ProfileTypesResponse], error) {
 	ret := _m.Called(_a0, _a1)
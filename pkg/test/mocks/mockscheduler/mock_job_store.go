@@ -269,6 +269,54 @@ func (_c *MockJobStore_ListEntries_Call) RunAndReturn(run func(*bbolt.Tx) iter.I
 	return _c
 }
 
+// ListJobPlans provides a mock function with given fields: _a0
+func (_m *MockJobStore) ListJobPlans(_a0 *bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan] {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListJobPlans")
+	}
+
+	var r0 iter.Iterator[*raft_log.CompactionJobPlan]
+	if rf, ok := ret.Get(0).(func(*bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan]); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Iterator[*raft_log.CompactionJobPlan])
+		}
+	}
+
+	return r0
+}
+
+// MockJobStore_ListJobPlans_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListJobPlans'
+type MockJobStore_ListJobPlans_Call struct {
+	*mock.Call
+}
+
+// ListJobPlans is a helper method to define mock.On call
+//   - _a0 *bbolt.Tx
+func (_e *MockJobStore_Expecter) ListJobPlans(_a0 interface{}) *MockJobStore_ListJobPlans_Call {
+	return &MockJobStore_ListJobPlans_Call{Call: _e.mock.On("ListJobPlans", _a0)}
+}
+
+func (_c *MockJobStore_ListJobPlans_Call) Run(run func(_a0 *bbolt.Tx)) *MockJobStore_ListJobPlans_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*bbolt.Tx))
+	})
+	return _c
+}
+
+func (_c *MockJobStore_ListJobPlans_Call) Return(_a0 iter.Iterator[*raft_log.CompactionJobPlan]) *MockJobStore_ListJobPlans_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockJobStore_ListJobPlans_Call) RunAndReturn(run func(*bbolt.Tx) iter.Iterator[*raft_log.CompactionJobPlan]) *MockJobStore_ListJobPlans_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // StoreJobPlan provides a mock function with given fields: _a0, _a1
 func (_m *MockJobStore) StoreJobPlan(_a0 *bbolt.Tx, _a1 *raft_log.CompactionJobPlan) error {
 	ret := _m.Called(_a0, _a1)
@@ -72,11 +72,28 @@ func (l LogSpanParametersWrapper) logQuery(
 				"estimation_ratio", fmt.Sprintf("%.2f", float64(stats.EstimatedBytes)/float64(stats.ObjectStorageBytes)),
 			)
 		}
+		if stats.executed {
+			finishFields = append(finishFields,
+				"scanned_rows", stats.RowsScanned,
+				"scanned_profiles", stats.ProfilesScanned,
+				"stack_traces", stats.StackTraces,
+				"peak_memory_estimate", humanize.Bytes(stats.PeakMemoryBytes),
+				"cache_hits", stats.CacheHits,
+				"cache_misses", stats.CacheMisses,
+			)
+		}
 	}
 	level.Info(logger).Log(finishFields...)
 	return err
 }
 
+// setResponseHeaders sets the resource usage headers of the response.
+func setResponseHeaders[T any](resp *connect.Response[T], stats *QueryStats) {
+	if resp != nil {
+		stats.SetHeaders(resp.Header())
+	}
+}
+
 func (l LogSpanParametersWrapper) ProfileTypes(ctx context.Context, c *connect.Request[querierv1.ProfileTypesRequest]) (*connect.Response[querierv1.ProfileTypesResponse], error) {
 	spanName := "ProfileTypes"
 	sp, ctx := tracing.StartSpanFromContext(ctx, spanName)
@@ -93,6 +110,7 @@ func (l LogSpanParametersWrapper) ProfileTypes(ctx context.Context, c *connect.R
 		resp, err = l.client.ProfileTypes(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -114,6 +132,7 @@ func (l LogSpanParametersWrapper) LabelValues(ctx context.Context, c *connect.Re
 		resp, err = l.client.LabelValues(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -134,6 +153,7 @@ func (l LogSpanParametersWrapper) LabelNames(ctx context.Context, c *connect.Req
 		resp, err = l.client.LabelNames(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -155,6 +175,7 @@ func (l LogSpanParametersWrapper) Series(ctx context.Context, c *connect.Request
 		resp, err = l.client.Series(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -180,6 +201,7 @@ func (l LogSpanParametersWrapper) SelectMergeStacktraces(ctx context.Context, c
 		resp, err = l.client.SelectMergeStacktraces(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -203,6 +225,7 @@ func (l LogSpanParametersWrapper) SelectMergeSpanProfile(ctx context.Context, c
 		resp, err = l.client.SelectMergeSpanProfile(ctx, c) //nolint:staticcheck // Required querier.v1 compatibility wrapper.
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -227,6 +250,7 @@ func (l LogSpanParametersWrapper) SelectMergeProfile(ctx context.Context, c *con
 		resp, err = l.client.SelectMergeProfile(ctx, c) //nolint:staticcheck // Required querier.v1 compatibility wrapper.
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -254,6 +278,7 @@ func (l LogSpanParametersWrapper) SelectSeries(ctx context.Context, c *connect.R
 		resp, err = l.client.SelectSeries(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -280,6 +305,7 @@ func (l LogSpanParametersWrapper) SelectHeatmap(ctx context.Context, c *connect.
 		resp, err = l.client.SelectHeatmap(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -321,6 +347,7 @@ func (l LogSpanParametersWrapper) Diff(ctx context.Context, c *connect.Request[q
 		resp, err = l.client.Diff(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -348,6 +375,32 @@ func (l LogSpanParametersWrapper) AnalyzeQuery(ctx context.Context, c *connect.R
 		resp, err = l.client.AnalyzeQuery(ctx, c)
 		return err
 	})
+	setResponseHeaders(resp, stats)
+	return resp, err
+}
+
+func (l LogSpanParametersWrapper) LabelValuesCardinality(ctx context.Context, c *connect.Request[querierv1.LabelValuesCardinalityRequest]) (*connect.Response[querierv1.LabelValuesCardinalityResponse], error) {
+	spanName := "LabelValuesCardinality"
+	sp, ctx := tracing.StartSpanFromContext(ctx, spanName)
+	defer sp.Finish()
+	ctx, stats := ContextWithQueryStats(ctx)
+
+	var resp *connect.Response[querierv1.LabelValuesCardinalityResponse]
+	err := l.logQuery(l.logWithRequestMetadata(ctx, c), stats, []interface{}{
+		"method", spanName,
+		"start", model.Time(c.Msg.Start).Time().String(),
+		"end", model.Time(c.Msg.End).Time().String(),
+		"query_window", model.Time(c.Msg.End).Sub(model.Time(c.Msg.Start)).String(),
+		"matchers", lazyJoin(c.Msg.Matchers),
+		"name", c.Msg.Name,
+		"prefix", c.Msg.Prefix,
+		"regex", c.Msg.Regex,
+		"limit", c.Msg.Limit,
+	}, func() (err error) {
+		resp, err = l.client.LabelValuesCardinality(ctx, c)
+		return err
+	})
+	setResponseHeaders(resp, stats)
 	return resp, err
 }
 
@@ -1,6 +1,24 @@
 package spanlogger
 
-import "context"
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	queryv1 "github.com/grafana/pyroscope/api/gen/proto/go/query/v1"
+)
+
+// Response headers reporting the resources consumed by the query. They are
+// only set for queries executed by the query backend (v2 read path).
+const (
+	HeaderBytesFetched    = "Pyroscope-Query-Bytes-Fetched"
+	HeaderRowsScanned     = "Pyroscope-Query-Rows-Scanned"
+	HeaderProfilesScanned = "Pyroscope-Query-Profiles-Scanned"
+	HeaderStackTraces     = "Pyroscope-Query-Stack-Traces"
+	HeaderPeakMemoryBytes = "Pyroscope-Query-Peak-Memory-Bytes"
+	HeaderCacheHits       = "Pyroscope-Query-Cache-Hits"
+	HeaderCacheMisses     = "Pyroscope-Query-Cache-Misses"
+)
 
 // QueryStats is populated by the query-frontend during query execution and
 // read by LogSpanParametersWrapper after the call returns, so that bytes
@@ -11,6 +29,18 @@ type QueryStats struct {
 	// EstimatedBytes is the pre-execution metadata size estimate (weight.Total())
 	// derived from block dataset section offsets before the backend is invoked.
 	EstimatedBytes uint64
+
+	// Resources consumed by the query backend, summed over all
+	// the invocations made for the query. The peak memory usage
+	// is the maximum of the invocation estimates.
+	RowsScanned     int64
+	ProfilesScanned int64
+	StackTraces     int64
+	PeakMemoryBytes uint64
+	CacheHits       int64
+	CacheMisses     int64
+
+	executed bool
 }
 
 type queryStatsKey struct{}
@@ -27,3 +57,31 @@ func QueryStatsFromContext(ctx context.Context) *QueryStats {
 	s, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
 	return s
 }
+
+// AddExecutionStats records the resources consumed by a query backend
+// invocation.
+func (s *QueryStats) AddExecutionStats(stats *queryv1.ExecutionStats) {
+	s.ObjectStorageBytes += stats.GetBytesFetched()
+	s.RowsScanned += stats.GetRowsScanned()
+	s.ProfilesScanned += stats.GetProfilesScanned()
+	s.StackTraces += stats.GetStackTraces()
+	s.PeakMemoryBytes = max(s.PeakMemoryBytes, stats.GetPeakMemoryBytes())
+	s.CacheHits += stats.GetCacheHits()
+	s.CacheMisses += stats.GetCacheMisses()
+	s.executed = true
+}
+
+// SetHeaders sets the resource usage response headers,
+// if the query was executed by the query backend.
+func (s *QueryStats) SetHeaders(h http.Header) {
+	if s == nil || !s.executed {
+		return
+	}
+	h.Set(HeaderBytesFetched, strconv.FormatUint(s.ObjectStorageBytes, 10))
+	h.Set(HeaderRowsScanned, strconv.FormatInt(s.RowsScanned, 10))
+	h.Set(HeaderProfilesScanned, strconv.FormatInt(s.ProfilesScanned, 10))
+	h.Set(HeaderStackTraces, strconv.FormatInt(s.StackTraces, 10))
+	h.Set(HeaderPeakMemoryBytes, strconv.FormatUint(s.PeakMemoryBytes, 10))
+	h.Set(HeaderCacheHits, strconv.FormatInt(s.CacheHits, 10))
+	h.Set(HeaderCacheMisses, strconv.FormatInt(s.CacheMisses, 10))
+}
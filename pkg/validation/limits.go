@@ -99,6 +99,12 @@ type Limits struct {
 	QuerySplitDuration       model.Duration `yaml:"split_queries_by_interval" json:"split_queries_by_interval"`
 	QuerySanitizeOnMerge     bool           `yaml:"query_sanitize_on_merge" json:"query_sanitize_on_merge"`
 	MaxAsyncQueryConcurrency int            `yaml:"max_async_query_concurrency" json:"max_async_query_concurrency"`
+	QueryDownsampledMinRange model.Duration `yaml:"query_downsampled_min_range" json:"query_downsampled_min_range" category:"experimental"`
+
+	// Query cost admission.
+	QueryMaxEstimatedBytes        int64          `yaml:"query_max_estimated_bytes" json:"query_max_estimated_bytes" category:"experimental"`
+	QueryBytesScannedBudget       int64          `yaml:"query_bytes_scanned_budget" json:"query_bytes_scanned_budget" category:"experimental"`
+	QueryBytesScannedBudgetWindow model.Duration `yaml:"query_bytes_scanned_budget_window" json:"query_bytes_scanned_budget_window" category:"experimental"`
 
 	// Compactor.
 	CompactorBlocksRetentionPeriod     model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
@@ -181,6 +187,12 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.QuerySplitDuration, "querier.split-queries-by-interval", "Split queries by a time interval and execute in parallel. The value 0 disables splitting by time")
 	f.BoolVar(&l.QuerySanitizeOnMerge, "querier.sanitize-on-merge", true, "Whether profiles should be sanitized when merging.")
 	f.IntVar(&l.MaxAsyncQueryConcurrency, "query-frontend.max-async-query-concurrency", 5, "Maximum number of concurrent async queries per tenant. 0 to disable async queries.")
+	_ = l.QueryDownsampledMinRange.Set("24h")
+	f.Var(&l.QueryDownsampledMinRange, "query-frontend.downsampled-min-range", "Minimum time range of a query to be served from downsampled data, if available. Only applies to flame graph and time series queries that do not need individual profiles; the time series step must not be finer than the downsampling resolution. Downsampled profiles are timestamped with the start of the resolution interval, therefore the data near the query range boundaries may be off by up to one interval. 0 to disable.")
+	f.Int64Var(&l.QueryMaxEstimatedBytes, "query-frontend.max-query-estimated-bytes", 0, "Maximum number of bytes a single query is estimated to scan, based on the metadata of the blocks it reads. Queries over the limit are rejected before execution. Only applies to the v2 read path. 0 to disable.")
+	f.Int64Var(&l.QueryBytesScannedBudget, "query-frontend.bytes-scanned-budget", 0, "Maximum number of bytes the queries of a tenant may scan within the budget window. A query is rejected if its estimated cost does not fit into the remaining budget; query splits served from the results cache are not charged. Each query-frontend replica enforces the budget divided by the number of replicas discovered via -query-frontend.replicas-address; if the address is not set, each replica enforces the whole budget. Only applies to the v2 read path. 0 to disable.")
+	_ = l.QueryBytesScannedBudgetWindow.Set("1h")
+	f.Var(&l.QueryBytesScannedBudgetWindow, "query-frontend.bytes-scanned-budget-window", "The rolling time window the bytes scanned budget applies to.")
 
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 0, "Maximum number of queries that will be scheduled in parallel by the frontend.")
 
@@ -266,6 +278,10 @@ func (l *Limits) Validate() error {
 		}
 	}
 
+	if err := l.Retention.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -481,6 +497,30 @@ func (o *Overrides) QuerySanitizeOnMerge(tenantID string) bool {
 	return o.getOverridesForTenant(tenantID).QuerySanitizeOnMerge
 }
 
+// QueryDownsampledMinRange returns the minimum time range of a query
+// to be served from downsampled data.
+func (o *Overrides) QueryDownsampledMinRange(tenantID string) time.Duration {
+	return time.Duration(o.getOverridesForTenant(tenantID).QueryDownsampledMinRange)
+}
+
+// QueryMaxEstimatedBytes returns the maximum number of
+// bytes a single query is estimated to scan.
+func (o *Overrides) QueryMaxEstimatedBytes(tenantID string) int64 {
+	return o.getOverridesForTenant(tenantID).QueryMaxEstimatedBytes
+}
+
+// QueryBytesScannedBudget returns the maximum number of bytes
+// the queries of the tenant may scan within the budget window.
+func (o *Overrides) QueryBytesScannedBudget(tenantID string) int64 {
+	return o.getOverridesForTenant(tenantID).QueryBytesScannedBudget
+}
+
+// QueryBytesScannedBudgetWindow returns the rolling time
+// window the bytes scanned budget applies to.
+func (o *Overrides) QueryBytesScannedBudgetWindow(tenantID string) time.Duration {
+	return time.Duration(o.getOverridesForTenant(tenantID).QueryBytesScannedBudgetWindow)
+}
+
 // MaxAsyncQueryConcurrency returns the maximum number of concurrent async queries per tenant.
 func (o *Overrides) MaxAsyncQueryConcurrency(tenantID string) int {
 	return o.getOverridesForTenant(tenantID).MaxAsyncQueryConcurrency
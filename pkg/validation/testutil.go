@@ -41,6 +41,7 @@ type MockLimits struct {
 	SymbolizerMaxUnresolvedLocationsValue int
 
 	MaxAsyncQueryConcurrencyValue int
+	QueryDownsampledMinRangeValue time.Duration
 
 	IngestionBodyLimitBytesValue int64
 
@@ -59,6 +60,9 @@ func (m MockLimits) QueryAnalysisSeriesEnabled(tenantID string) bool {
 func (m MockLimits) QuerySanitizeOnMerge(tenantID string) bool {
 	return m.QuerySanitizeOnMergeValue
 }
+func (m MockLimits) QueryDownsampledMinRange(tenantID string) time.Duration {
+	return m.QueryDownsampledMinRangeValue
+}
 func (m MockLimits) MaxFlameGraphNodesDefault(string) int { return m.MaxFlameGraphNodesDefaultValue }
 func (m MockLimits) MaxFlameGraphNodesMax(string) int     { return m.MaxFlameGraphNodesMaxValue }
 func (m MockLimits) MaxFlameGraphNodesOnSelectMergeProfile(string) bool {
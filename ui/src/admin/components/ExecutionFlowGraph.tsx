@@ -18,7 +18,11 @@ import {
 import type { Node, Edge, NodeProps } from '@xyflow/react';
 import '@xyflow/react/dist/style.css';
 
-import type { ExecutionTreeNode, BlockExecutionInfo } from '../types';
+import type {
+  ExecutionTreeNode,
+  BlockExecutionInfo,
+  ExecutionResources,
+} from '../types';
 
 interface ExecutionFlowGraphProps {
   executionTree: ExecutionTreeNode;
@@ -40,6 +44,7 @@ interface ExecutionNodeData extends Record<string, unknown> {
     blocksRead: number;
     datasetsProcessed: number;
     blockExecutions?: BlockExecutionInfo[];
+    resources: ExecutionResources;
   };
   error?: string;
 }
@@ -445,6 +450,38 @@ function NodeDetailsPanel({
               <label>Datasets Processed:</label>
               <span>{data.stats.datasetsProcessed}</span>
             </div>
+            <div className="detail-row">
+              <label>Bytes Fetched:</label>
+              <span>{data.stats.resources.bytesFetched}</span>
+            </div>
+            <div className="detail-row">
+              <label>Rows Scanned:</label>
+              <span>{data.stats.resources.rowsScanned}</span>
+            </div>
+            <div className="detail-row">
+              <label>Profiles Scanned:</label>
+              <span>{data.stats.resources.profilesScanned}</span>
+            </div>
+            <div className="detail-row">
+              <label>Stack Traces:</label>
+              <span>{data.stats.resources.stackTraces}</span>
+            </div>
+            <div className="detail-row">
+              <label>Peak Memory (est.):</label>
+              <span>{data.stats.resources.peakMemory}</span>
+            </div>
+            {data.stats.resources.cacheHits +
+              data.stats.resources.cacheMisses >
+              0 && (
+              <div className="detail-row">
+                <label>Cache Hits:</label>
+                <span>
+                  {data.stats.resources.cacheHits} /{' '}
+                  {data.stats.resources.cacheHits +
+                    data.stats.resources.cacheMisses}
+                </span>
+              </div>
+            )}
 
             {data.stats.blockExecutions &&
               data.stats.blockExecutions.length > 0 && (
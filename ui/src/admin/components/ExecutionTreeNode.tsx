@@ -22,6 +22,18 @@ export function ExecutionTreeNode({ node }: ExecutionTreeNodeProps) {
             datasets
           </span>
         )}
+        {node.stats && (
+          <span
+            className="badge bg-light text-dark"
+            title="Resources used: bytes fetched, rows and profiles scanned, stack traces symbolized, peak memory estimate"
+          >
+            {node.stats.resources.bytesFetched} fetched,{' '}
+            {node.stats.resources.rowsScanned} rows,{' '}
+            {node.stats.resources.profilesScanned} profiles,{' '}
+            {node.stats.resources.stackTraces} stack traces, ~
+            {node.stats.resources.peakMemory} memory
+          </span>
+        )}
       </div>
       {node.error && (
         <div className="exec-error text-danger">
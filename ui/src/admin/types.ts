@@ -111,6 +111,13 @@ export interface RawExecutionStats {
   blocks_read: number;
   datasets_processed: number;
   block_executions?: RawBlockExecution[];
+  bytes_fetched?: number;
+  rows_scanned?: number;
+  profiles_scanned?: number;
+  stack_traces?: number;
+  peak_memory_bytes?: number;
+  cache_hits?: number;
+  cache_misses?: number;
 }
 
 export interface RawBlockExecution {
@@ -155,6 +162,17 @@ export interface ExecutionTreeStats {
   blocksRead: number;
   datasetsProcessed: number;
   blockExecutions?: BlockExecutionInfo[];
+  resources: ExecutionResources;
+}
+
+export interface ExecutionResources {
+  bytesFetched: string;
+  rowsScanned: number;
+  profilesScanned: number;
+  stackTraces: number;
+  peakMemory: string;
+  cacheHits: number;
+  cacheMisses: number;
 }
 
 export interface BlockExecutionInfo {
@@ -331,6 +331,15 @@ function convertExecutionNodeToTreeWithBase(
       blocksRead: node.stats.blocks_read,
       datasetsProcessed: node.stats.datasets_processed,
       blockExecutions: [],
+      resources: {
+        bytesFetched: formatBytes(node.stats.bytes_fetched ?? 0),
+        rowsScanned: node.stats.rows_scanned ?? 0,
+        profilesScanned: node.stats.profiles_scanned ?? 0,
+        stackTraces: node.stats.stack_traces ?? 0,
+        peakMemory: formatBytes(node.stats.peak_memory_bytes ?? 0),
+        cacheHits: node.stats.cache_hits ?? 0,
+        cacheMisses: node.stats.cache_misses ?? 0,
+      },
     };
 
     for (const blockExec of node.stats.block_executions || []) {
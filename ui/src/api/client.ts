@@ -108,6 +108,28 @@ export async function fetchLabelValues(
   return data.names ?? [];
 }
 
+interface LabelValuesCardinalityResponse {
+  values?: { value: string; cardinality?: string }[];
+}
+
+// Resolves the values of a label recorded in the dataset metadata
+// (service_name, __profile_type__) from the metastore, ordered by the
+// approximate cardinality. Only available in the v2 storage.
+export async function fetchLabelValuesCardinality(
+  name: string,
+  matchers: string[],
+  start: number,
+  end: number,
+  signal?: AbortSignal,
+): Promise<string[]> {
+  const data = await post<LabelValuesCardinalityResponse>(
+    '/querier.v1.QuerierService/LabelValuesCardinality',
+    { name, matchers, start, end },
+    signal,
+  );
+  return (data.values ?? []).map((v) => v.value);
+}
+
 interface LabelSet {
   labels: { name: string; value: string }[];
 }
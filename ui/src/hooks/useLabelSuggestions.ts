@@ -1,5 +1,9 @@
 import { useEffect, useMemo, useRef, useState } from 'react';
-import { fetchLabelNames, fetchLabelValues } from '@api/client';
+import {
+  fetchLabelNames,
+  fetchLabelValues,
+  fetchLabelValuesCardinality,
+} from '@api/client';
 import {
   getCursorContext,
   isInternalLabel,
@@ -15,6 +19,39 @@ const MAX_SUGGESTIONS = 200;
 const DEBOUNCE_NAMES_MS = 150;
 const DEBOUNCE_VALUES_MS = 250;
 
+// Labels recorded in the dataset metadata. Their values are served from the
+// metastore, most common first, without scanning the blocks. The matchers
+// must only refer to these labels as well: the endpoint rejects the others.
+const METADATA_LABELS = new Set(['service_name', '__profile_type__']);
+
+// Matchers are serialized as `{name="value"}`.
+function matcherLabelName(matcher: string): string {
+  return /^\{\s*([A-Za-z0-9_]+)/.exec(matcher)?.[1] ?? '';
+}
+
+function fetchValues(
+  name: string,
+  matchers: string[],
+  start: number,
+  end: number,
+  signal: AbortSignal,
+): Promise<string[]> {
+  if (
+    !METADATA_LABELS.has(name) ||
+    !matchers.every((m) => METADATA_LABELS.has(matcherLabelName(m)))
+  ) {
+    return fetchLabelValues(name, matchers, start, end, signal);
+  }
+  return fetchLabelValuesCardinality(name, matchers, start, end, signal).catch(
+    (err: unknown) => {
+      if ((err as { name?: string }).name === 'AbortError') throw err;
+      // The endpoint is not available in the v1 storage, and rejects
+      // matchers on labels missing in the dataset metadata.
+      return fetchLabelValues(name, matchers, start, end, signal);
+    },
+  );
+}
+
 export interface LabelSuggestionsArgs {
   query: string;
   cursor: number;
@@ -122,7 +159,7 @@ export function useLabelSuggestions({
               ),
           )
         : ctx.kind === 'value'
-          ? fetchLabelValues(
+          ? fetchValues(
               toInternalLabel(ctx.labelName),
               ctx.otherMatchers,
               s,